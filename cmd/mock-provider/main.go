@@ -3,22 +3,22 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
 )
 
 type processRequest struct {
@@ -36,9 +36,128 @@ type callbackPayload struct {
 	Status      string `json:"status"`
 	ProviderRef string `json:"provider_ref,omitempty"`
 	Reason      string `json:"reason,omitempty"`
+	Code        string `json:"code,omitempty"`
 	Timestamp   string `json:"timestamp"`
 }
 
+type failure struct {
+	code   string
+	reason string
+}
+
+var failures = []failure{
+	{code: "bank_unavailable", reason: "Destination bank temporarily unavailable"},
+	{code: "provider_timeout", reason: "Destination bank did not respond in time"},
+	{code: "invalid_account", reason: "Destination account does not exist"},
+}
+
+type paymentStatus struct {
+	PaymentID   string `json:"payment_id"`
+	Status      string `json:"status"`
+	ProviderRef string `json:"provider_ref,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Code        string `json:"code,omitempty"`
+}
+
+type acceptance struct {
+	Status     string `json:"status"`
+	PaymentID  string `json:"payment_id"`
+	AcceptedAt string `json:"accepted_at"`
+}
+
+type statusStore struct {
+	mu       sync.RWMutex
+	payments map[string]paymentStatus
+	accepted map[string]acceptance
+}
+
+func newStatusStore() *statusStore {
+	return &statusStore{payments: make(map[string]paymentStatus), accepted: make(map[string]acceptance)}
+}
+
+// claim records paymentID as accepted. It reports false, with the original
+// acceptance, when the payment was already submitted.
+func (s *statusStore) claim(paymentID string) (acceptance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accepted[paymentID]; ok {
+		return a, false
+	}
+	a := acceptance{Status: "accepted", PaymentID: paymentID, AcceptedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+	s.accepted[paymentID] = a
+	s.payments[paymentID] = paymentStatus{PaymentID: paymentID, Status: "pending"}
+	return a, true
+}
+
+func (s *statusStore) set(st paymentStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payments[st.PaymentID] = st
+}
+
+func (s *statusStore) get(paymentID string) (paymentStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.payments[paymentID]
+	return st, ok
+}
+
+// handshakes remembers which callback URLs have answered the handshake, so
+// each URL is challenged once before its first event.
+type handshakes struct {
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+func (h *handshakes) ensure(client *http.Client, secret, callbackURL string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.verified[callbackURL] {
+		return nil
+	}
+	if err := handshake(client, secret, callbackURL); err != nil {
+		return err
+	}
+	h.verified[callbackURL] = true
+	return nil
+}
+
+// handshake sends a signed challenge to callbackURL and checks that the
+// receiver answers it with the same secret.
+func handshake(client *http.Client, secret, callbackURL string) error {
+	challenge := uuid.NewString()
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("parse callback url: %w", err)
+	}
+	q := u.Query()
+	q.Set(webhookverify.ChallengeParam, challenge)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(webhookverify.SignatureHeader, webhookverify.SignChallenge(secret, challenge))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("handshake rejected with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data webhookverify.ChallengeReply `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decode handshake reply: %w", err)
+	}
+	return webhookverify.VerifyChallengeReply(secret, challenge, body.Data)
+}
+
 func main() {
 	logLevel := os.Getenv("LOG_LEVEL")
 	if logLevel == "" {
@@ -52,9 +171,59 @@ func main() {
 		os.Exit(1)
 	}
 
+	dropPct, _ := strconv.Atoi(os.Getenv("WEBHOOK_DROP_PCT"))
+	strictDedup := os.Getenv("STRICT_DEDUP") == "true"
+
+	var hs *handshakes
+	if os.Getenv("WEBHOOK_HANDSHAKE") == "true" {
+		hs = &handshakes{verified: make(map[string]bool)}
+	}
+
 	client := &http.Client{Timeout: 10 * time.Second}
+	store := newStatusStore()
 	var wg sync.WaitGroup
 
+	// Accepted payments are settled in the background; shutdown waits for
+	// their callbacks.
+	process := func(req processRequest) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processPayment(client, secret, store, hs, dropPct, req)
+		}()
+	}
+	mux := newMux(store, strictDedup, process)
+
+	srv := &http.Server{Addr: ":8081", Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		slog.Info("mock provider started", "addr", ":8081")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("shutting down mock provider")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown error", "error", err)
+	}
+
+	wg.Wait()
+	slog.Info("mock provider stopped")
+}
+
+// newMux serves the provider API. A payment_id is accepted once and handed
+// to process; a repeat is refused with 409 in strict mode, or answered with
+// the original acceptance otherwise.
+func newMux(store *statusStore, strictDedup bool, process func(processRequest)) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -82,46 +251,49 @@ func main() {
 			"currency", req.Currency,
 		)
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			processPayment(client, secret, req)
-		}()
+		accepted, first := store.claim(req.PaymentID)
+		if !first {
+			if strictDedup {
+				slog.Error("DUPLICATE SUBMISSION REJECTED (strict mode)",
+					"payment_id", req.PaymentID,
+					"originally_accepted_at", accepted.AcceptedAt,
+				)
+				http.Error(w, "duplicate submission for payment_id "+req.PaymentID, http.StatusConflict)
+				return
+			}
+			slog.Warn("duplicate submission, returning original acceptance",
+				"payment_id", req.PaymentID,
+				"originally_accepted_at", accepted.AcceptedAt,
+			)
+			w.Header().Set("X-Duplicate-Submission", "true")
+		} else {
+			process(req)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
-		if err := json.NewEncoder(w).Encode(map[string]string{"status": "accepted"}); err != nil {
+		if err := json.NewEncoder(w).Encode(accepted); err != nil {
 			slog.Error("failed to write process response", "error", err)
 		}
 	})
 
-	srv := &http.Server{Addr: ":8081", Handler: mux}
-
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	go func() {
-		slog.Info("mock provider started", "addr", ":8081")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("server error", "error", err)
-			os.Exit(1)
+	mux.HandleFunc("GET /status/{payment_id}", func(w http.ResponseWriter, r *http.Request) {
+		st, ok := store.get(r.PathValue("payment_id"))
+		if !ok {
+			http.Error(w, "payment not found", http.StatusNotFound)
+			return
 		}
-	}()
 
-	<-ctx.Done()
-	slog.Info("shutting down mock provider")
-
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		slog.Error("server shutdown error", "error", err)
-	}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(st); err != nil {
+			slog.Error("failed to write status response", "error", err)
+		}
+	})
 
-	wg.Wait()
-	slog.Info("mock provider stopped")
+	return mux
 }
 
-func processPayment(client *http.Client, secret string, req processRequest) {
+func processPayment(client *http.Client, secret string, store *statusStore, hs *handshakes, dropPct int, req processRequest) {
 	// Simulate processing delay: 1-3 seconds
 	delay := time.Duration(1+rand.Intn(3)) * time.Second
 	time.Sleep(delay)
@@ -137,8 +309,30 @@ func processPayment(client *http.Client, secret string, req processRequest) {
 		payload.Status = "completed"
 		payload.ProviderRef = fmt.Sprintf("mock_ref_%d", rand.Int63())
 	} else {
+		f := failures[rand.Intn(len(failures))]
 		payload.Status = "failed"
-		payload.Reason = "Insufficient funds at destination bank"
+		payload.Reason = f.reason
+		payload.Code = f.code
+	}
+
+	store.set(paymentStatus{
+		PaymentID:   req.PaymentID,
+		Status:      payload.Status,
+		ProviderRef: payload.ProviderRef,
+		Reason:      payload.Reason,
+		Code:        payload.Code,
+	})
+
+	if rand.Intn(100) < dropPct {
+		slog.Info("dropping callback", "payment_id", req.PaymentID, "status", payload.Status)
+		return
+	}
+
+	if hs != nil {
+		if err := hs.ensure(client, secret, req.CallbackURL); err != nil {
+			slog.Error("callback handshake failed, not sending callback", "error", err, "payment_id", req.PaymentID, "callback_url", req.CallbackURL)
+			return
+		}
 	}
 
 	body, err := json.Marshal(payload)
@@ -147,7 +341,7 @@ func processPayment(client *http.Client, secret string, req processRequest) {
 		return
 	}
 
-	sig := computeHMAC(body, secret)
+	sig := webhookverify.Sign(secret, body)
 
 	httpReq, err := http.NewRequest(http.MethodPost, req.CallbackURL, bytes.NewReader(body))
 	if err != nil {
@@ -155,7 +349,7 @@ func processPayment(client *http.Client, secret string, req processRequest) {
 		return
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-Webhook-Signature", sig)
+	httpReq.Header.Set(webhookverify.SignatureHeader, sig)
 
 	resp, err := client.Do(httpReq)
 	if err != nil {
@@ -170,9 +364,3 @@ func processPayment(client *http.Client, secret string, req processRequest) {
 		"callback_status_code", resp.StatusCode,
 	)
 }
-
-func computeHMAC(data []byte, secret string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(data)
-	return hex.EncodeToString(mac.Sum(nil))
-}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func submit(mux *http.ServeMux, paymentID string) *httptest.ResponseRecorder {
+	body := `{"payment_id":"` + paymentID + `","amount":5000,"currency":"USD","callback_url":"http://api/webhooks/provider"}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/process", strings.NewReader(body)))
+	return rec
+}
+
+func decodeAcceptance(t *testing.T, rec *httptest.ResponseRecorder) acceptance {
+	t.Helper()
+	var a acceptance
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &a))
+	return a
+}
+
+func TestProcess_DedupesPaymentID(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		strict bool
+	}{
+		{"strict", true},
+		{"lenient", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var processed []string
+			mux := newMux(newStatusStore(), tc.strict, func(req processRequest) {
+				processed = append(processed, req.PaymentID)
+			})
+
+			first := submit(mux, "pay-1")
+			require.Equal(t, http.StatusAccepted, first.Code)
+			assert.Empty(t, first.Header().Get("X-Duplicate-Submission"))
+			original := decodeAcceptance(t, first)
+			assert.Equal(t, "pay-1", original.PaymentID)
+
+			for range 2 {
+				again := submit(mux, "pay-1")
+				if tc.strict {
+					assert.Equal(t, http.StatusConflict, again.Code)
+					assert.Contains(t, again.Body.String(), "duplicate submission for payment_id pay-1")
+				} else {
+					require.Equal(t, http.StatusAccepted, again.Code)
+					assert.Equal(t, "true", again.Header().Get("X-Duplicate-Submission"))
+					assert.Equal(t, original, decodeAcceptance(t, again), "the original acceptance is returned")
+				}
+			}
+
+			other := submit(mux, "pay-2")
+			require.Equal(t, http.StatusAccepted, other.Code, "another payment is accepted")
+
+			assert.Equal(t, []string{"pay-1", "pay-2"}, processed, "each payment is processed once")
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/pay-1", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"status":"pending"`)
+		})
+	}
+}
+
+func TestProcess_ConcurrentDuplicatesProcessOnce(t *testing.T) {
+	var processed atomic.Int32
+	mux := newMux(newStatusStore(), true, func(processRequest) { processed.Add(1) })
+
+	var wg sync.WaitGroup
+	var accepted, conflicts atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch submit(mux, "pay-1").Code {
+			case http.StatusAccepted:
+				accepted.Add(1)
+			case http.StatusConflict:
+				conflicts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), accepted.Load())
+	assert.Equal(t, int32(19), conflicts.Load())
+	assert.Equal(t, int32(1), processed.Load())
+}
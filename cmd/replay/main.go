@@ -0,0 +1,71 @@
+// Command replay re-issues requests captured with CAPTURE_ROUTES against a
+// server, usually a local one, and reports which responses changed status.
+//
+//	go run ./cmd/replay -server http://localhost:8080 -token $TOKEN captures/
+//
+// Captured credentials are redacted, so -token supplies the bearer token for
+// every request. Requests are sent one at a time in capture order.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/capture"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "base URL to replay against")
+	token := flag.String("token", "", "bearer token sent with every request")
+	verbose := flag.Bool("v", false, "print each replayed response body")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout per request")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: replay [flags] capture-file-or-dir...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files, err := capture.Files(flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	replayer := capture.NewReplayer(&http.Client{Timeout: *timeout}, *server, *token)
+	changed := 0
+	for _, file := range files {
+		e, err := capture.Load(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		res, err := replayer.Replay(context.Background(), e)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		mark := "same"
+		if res.StatusChanged() {
+			mark = "CHANGED"
+			changed++
+		}
+		fmt.Printf("%s %s %s captured=%d replayed=%d %s\n", file, e.Request.Method, e.Request.URL, e.Response.Status, res.Status, mark)
+		if *verbose {
+			fmt.Printf("%s\n", res.Body)
+		}
+	}
+
+	fmt.Printf("%d replayed, %d changed status\n", len(files), changed)
+	if changed > 0 {
+		os.Exit(1)
+	}
+}
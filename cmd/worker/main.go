@@ -0,0 +1,11 @@
+// Command worker runs the background processors (webhook processing, status
+// polling, settlement and ledger jobs, the outbox relay and the rest) without
+// the HTTP API, so they can be deployed and scaled apart from it. Run the API
+// with RUN_MODE=api next to it.
+package main
+
+import "github.com/josh-kwaku/grey-backend-assessment/internal/app"
+
+func main() {
+	app.Main("grey-worker", app.RunModeWorker)
+}
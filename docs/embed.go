@@ -1,6 +1,41 @@
 package docs
 
-import _ "embed"
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
 
 //go:embed openapi.yaml
 var OpenAPISpec []byte
+
+// OpenAPIJSON is OpenAPISpec as JSON, for client tooling that doesn't read
+// YAML. The spec is embedded at build time, so a spec that can't be
+// converted fails at startup and in this package's tests rather than on a
+// request.
+var OpenAPIJSON = mustJSON(OpenAPISpec)
+
+func mustJSON(spec []byte) []byte {
+	b, err := toJSON(spec)
+	if err != nil {
+		panic(fmt.Sprintf("docs: openapi.yaml: %v", err))
+	}
+	return b
+}
+
+func toJSON(spec []byte) ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// GraphQLSchema is the GraphQL gateway's schema. The handler parses it at
+// startup and binds its resolvers to it; it is also served for client code
+// generators.
+//
+//go:embed schema.graphql
+var GraphQLSchema []byte
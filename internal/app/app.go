@@ -0,0 +1,906 @@
+// Package app builds the service and runs it. cmd/api and cmd/worker are
+// thin entrypoints that choose which part of it a process runs.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/archive"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/capture"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/config"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/grpcapi"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/metrics"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/middleware"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/notify/email"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/ratelimit"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
+)
+
+// Run modes select what a process serves.
+const (
+	// RunModeAll serves the HTTP API and runs the background processors.
+	RunModeAll = "all"
+	// RunModeAPI serves the HTTP API only; a worker must run alongside it.
+	RunModeAPI = "api"
+	// RunModeWorker runs the background processors and serves only health
+	// and metrics.
+	RunModeWorker = "worker"
+)
+
+// Main runs the service until SIGINT or SIGTERM, then shuts it down
+// gracefully. mode overrides RUN_MODE when set.
+func Main(serviceName, mode string) {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+	if mode == "" {
+		mode = cfg.RunMode
+	}
+
+	logging.Init(serviceName, cfg.LogLevel, cfg.AppEnv)
+	for _, name := range cfg.DeprecatedVars {
+		slog.Warn("deprecated setting, use its CODE=amount map instead", "var", name)
+	}
+
+	switch mode {
+	case RunModeAll, RunModeAPI, RunModeWorker:
+	default:
+		slog.Error("unknown RUN_MODE", "mode", mode)
+		os.Exit(1)
+	}
+	runsAPI := mode != RunModeWorker
+	runsProcessors := mode != RunModeAPI
+
+	if err := domain.RegisterCurrencies(cfg.CurrencySpecs); err != nil {
+		slog.Error("invalid CURRENCY_SPECS", "error", err)
+		os.Exit(1)
+	}
+	if err := domain.EnableCurrencies(cfg.Currencies); err != nil {
+		slog.Error("invalid CURRENCIES", "error", err)
+		os.Exit(1)
+	}
+	if !domain.Currency(cfg.FXBaseCurrency).IsValid() {
+		slog.Error("FX_BASE_CURRENCY must be an enabled currency", "currency", cfg.FXBaseCurrency)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := repository.NewPostgresDB(ctx, cfg.DatabaseURL, repository.PoolConfig{
+		MaxConns:         cfg.DBMaxOpenConns,
+		MinConns:         cfg.DBMinConns,
+		ConnMaxLifetimeS: cfg.DBConnMaxLifetimeS,
+		ConnMaxIdleTimeS: cfg.DBConnMaxIdleTimeS,
+	})
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	paymentEventRepo := repository.NewPaymentEventRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	webhookEndpointRepo := repository.NewWebhookEndpointRepository(db)
+	paymentEvents := service.NewPaymentEventOutbox(paymentEventRepo, outboxRepo)
+	webhookEventRepo := repository.NewWebhookEventRepository(db, cfg.WebhookCompressAboveBytes, time.Duration(cfg.WebhookPriorityAgingS)*time.Second, time.Duration(cfg.WebhookLeaseS)*time.Second)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	supportNoteRepo := repository.NewSupportNoteRepository(db)
+	settlementRepo := repository.NewSettlementRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	providerLatencyRepo := repository.NewProviderLatencyRepository(db)
+	processedEventRepo := repository.NewProcessedEventRepository(db)
+	balanceSnapshotRepo := repository.NewBalanceSnapshotRepository(db)
+	providerSubmissionRepo := repository.NewProviderSubmissionRepository(db)
+	userLimitRepo := repository.NewUserLimitRepository(db)
+	kycRepo := repository.NewKYCRepository(db)
+	denylistRepo := repository.NewDenylistRepository(db)
+	residencyRuleRepo := repository.NewResidencyRuleRepository(db)
+	identifierChangeRepo := repository.NewIdentifierChangeRepository(db)
+	digestRepo := repository.NewDigestRepository(db)
+	qaSampleRepo := repository.NewQASampleRepository(db)
+	paymentRequestRepo := repository.NewPaymentRequestRepository(db)
+	holdRepo := repository.NewHoldRepository(db)
+	beneficiaryRepo := repository.NewBeneficiaryRepository(db)
+	fxPoolWatermarkRepo := repository.NewFXPoolWatermarkRepository(db)
+	shutdownReportRepo := repository.NewShutdownReportRepository(db)
+	ledgerChainBreakRepo := repository.NewLedgerChainBreakRepository(db)
+	providerRegistrationRepo := repository.NewProviderRegistrationRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	userTOTPRepo := repository.NewUserTOTPRepository(db)
+	notificationLogRepo := repository.NewNotificationLogRepository(db)
+	paymentEventArchiveRepo := repository.NewPaymentEventArchiveRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+
+	var poolBalances map[domain.Currency]int64
+	if cfg.AppEnv != "production" {
+		poolBalances = perCurrency(cfg.FXPoolBootstrap)
+	}
+	bootstrap := service.NewSystemBootstrap(repository.NewSystemRepository(db), domain.SupportedCurrencies, poolBalances)
+	if _, err := bootstrap.Run(ctx); err != nil {
+		slog.Error("failed to bootstrap system accounts", "error", err)
+		os.Exit(1)
+	}
+
+	metricsRegistry := metrics.NewRegistry()
+	paymentMetrics := metrics.NewPaymentMetrics(metricsRegistry)
+	treasuryMetrics := metrics.NewTreasuryMetrics(metricsRegistry)
+	ledgerMetrics := metrics.NewLedgerMetrics(metricsRegistry)
+	webhookMetrics := metrics.NewWebhookMetrics(metricsRegistry)
+
+	inFlight := service.NewInFlightTracker()
+
+	fxRates, err := fx.ParseRates(cfg.FXRates)
+	if err != nil {
+		slog.Error("invalid FX_RATES", "error", err)
+		os.Exit(1)
+	}
+	fxSvc := fx.NewRateService(cfg.FXSpreadPct, domain.Currency(cfg.FXBaseCurrency), fxRates)
+	providerRouter := service.NewProviderRouter(cfg.DefaultProvider, cfg.ProviderRoutes)
+	webhookVerifiers := make(map[string]handler.WebhookVerifier)
+	webhookMaxBody := make(map[string]int64)
+	for name, url := range cfg.Providers() {
+		providerRouter.Register(service.NewProviderClient(name, url, cfg.WebhookCallbackURL+"/"+name, inFlight, providerSubmissionRepo))
+		webhookVerifiers[name] = handler.HMACVerifier(cfg.ProviderWebhookSecret(name), webhookverify.SignatureHeader)
+		webhookMaxBody[name] = cfg.ProviderWebhookMaxBody(name)
+	}
+	handshakeSecrets := make(map[string]string)
+	for _, name := range cfg.WebhookHandshakeProviders {
+		if _, ok := webhookVerifiers[name]; !ok {
+			slog.Error("WEBHOOK_HANDSHAKE_PROVIDERS names an unknown provider", "provider", name)
+			os.Exit(1)
+		}
+		handshakeSecrets[name] = cfg.ProviderWebhookSecret(name)
+	}
+	if err := providerRouter.Validate(); err != nil {
+		slog.Error("invalid provider routing config", "error", err)
+		os.Exit(1)
+	}
+
+	accountSvc := service.NewAccountService(accountRepo, userRepo, paymentRepo, holdRepo, ledgerRepo, outboxRepo, db)
+	supportNoteSvc := service.NewSupportNoteService(supportNoteRepo, userRepo, paymentRepo)
+	settlementSvc := service.NewSettlementService(settlementRepo, accountRepo, ledgerRepo, db)
+	disputeSvc := service.NewDisputeService(
+		disputeRepo, paymentRepo, accountRepo, ledgerRepo, supportNoteRepo,
+		time.Duration(cfg.DisputeRespondSLAH)*time.Hour,
+		time.Duration(cfg.DisputeResolveSLAH)*time.Hour,
+	)
+	auditSvc := service.NewAuditService(auditLogRepo)
+	userLimitSvc := service.NewUserLimitService(userLimitRepo, userRepo, perCurrency(cfg.TxLimit), auditSvc)
+	accountFreezeSvc := service.NewAccountFreezeService(accountRepo, auditSvc)
+	kycSvc := service.NewKYCService(kycRepo, userRepo, db)
+	denylistSvc := service.NewDenylistService(denylistRepo)
+	residencySvc := service.NewResidencyService(residencyRuleRepo, userRepo, auditSvc)
+	identitySvc := service.NewIdentityService(
+		identifierChangeRepo, userRepo, db,
+		time.Duration(cfg.HandleReclaimCooldownD)*24*time.Hour,
+		time.Duration(cfg.HandleReassignWarningD)*24*time.Hour,
+	)
+	totpKey, err := cfg.TOTPKey()
+	if err != nil {
+		slog.Error("invalid totp config", "error", err)
+		os.Exit(1)
+	}
+	totpSvc, err := service.NewTOTPService(userTOTPRepo, userRepo, db, totpKey, cfg.TOTPIssuer)
+	if err != nil {
+		slog.Error("failed to create totp service", "error", err)
+		os.Exit(1)
+	}
+	paymentSvc := payment.NewService(paymentRepo, accountRepo, ledgerRepo, paymentEvents, userRepo, userLimitRepo, holdRepo, beneficiaryRepo, fxSvc, providerRouter, denylistSvc, residencySvc, totpSvc, paymentMetrics, db, cfg)
+
+	// With LISTEN the processor picks events up as they are stored and the
+	// ticker is only a sweep; without it, it polls every second.
+	webhookInterval := time.Second
+	var webhookWake <-chan struct{}
+	if cfg.WebhookListen && runsProcessors {
+		listener, err := repository.NewListener(ctx, cfg.DatabaseURL, repository.WebhookEventsChannel, slog.Default())
+		if err != nil {
+			slog.Warn("webhook listener unavailable, polling instead", "error", err)
+		} else {
+			defer listener.Close()
+			webhookWake = listener.Notifications()
+			webhookInterval = time.Duration(cfg.WebhookSweepIntervalS) * time.Second
+		}
+	}
+
+	alerter := service.NewLogAlerter(slog.Default())
+	webhookProcessor := service.NewWebhookProcessor(
+		webhookEventRepo, paymentRepo, accountRepo, ledgerRepo, paymentEvents, providerLatencyRepo, processedEventRepo,
+		db, slog.Default(), inFlight, alerter, webhookMetrics, webhookInterval, webhookWake,
+		cfg.WebhookConcurrency,
+	)
+
+	treasurySvc := service.NewTreasuryService(
+		accountRepo, fxPoolWatermarkRepo, alerter, treasuryMetrics, slog.Default(),
+		time.Duration(cfg.FXPoolCheckIntervalS)*time.Second,
+	)
+
+	ledgerVerifier := service.NewLedgerVerifier(
+		ledgerRepo, ledgerChainBreakRepo, alerter, ledgerMetrics, slog.Default(),
+		time.Duration(cfg.LedgerVerifyIntervalS)*time.Second,
+		time.Duration(cfg.LedgerVerifyWindowH)*time.Hour,
+	)
+
+	balanceHistorySvc := service.NewBalanceHistoryService(
+		accountRepo, balanceSnapshotRepo, slog.Default(),
+		time.Duration(cfg.BalanceSnapshotIntervalM)*time.Minute, cfg.BalanceCacheSize,
+	)
+
+	archiveStore, err := newArchiveStore(cfg)
+	if err != nil {
+		slog.Error("failed to configure archive store", "error", err)
+		os.Exit(1)
+	}
+	paymentEventArchiver := service.NewPaymentEventArchiver(
+		paymentEventRepo, paymentEventArchiveRepo, archiveStore, db, slog.Default(),
+		time.Duration(cfg.PaymentEventArchiveIntervalM)*time.Minute,
+		time.Duration(cfg.PaymentEventRetentionD)*24*time.Hour,
+		cfg.PaymentEventArchiveBatch,
+	)
+
+	eventPublisher, err := newEventPublisher(cfg)
+	if err != nil {
+		slog.Error("failed to configure event bus", "error", err)
+		os.Exit(1)
+	}
+	outboxRelay := service.NewOutboxRelay(outboxRepo, eventPublisher, slog.Default(),
+		time.Duration(cfg.OutboxPollIntervalMS)*time.Millisecond, cfg.OutboxBatchSize)
+
+	if cfg.WebhookEndpointAllowPrivate && cfg.AppEnv == "production" {
+		slog.Error("private webhook endpoints are for development only; unset WEBHOOK_ENDPOINT_ALLOW_PRIVATE in production")
+		os.Exit(1)
+	}
+	webhookKey, err := cfg.WebhookEndpointKey()
+	if err != nil {
+		slog.Error("invalid webhook endpoint config", "error", err)
+		os.Exit(1)
+	}
+	webhookSender, err := service.NewWebhookSender(
+		service.NewWebhookHTTPClient(time.Duration(cfg.WebhookDeliveryTimeoutMS)*time.Millisecond, cfg.WebhookEndpointAllowPrivate),
+		webhookKey,
+	)
+	if err != nil {
+		slog.Error("failed to create webhook sender", "error", err)
+		os.Exit(1)
+	}
+	webhookEndpointSvc := service.NewWebhookEndpointService(webhookEndpointRepo, webhookSender, cfg.WebhookEndpointAllowPrivate)
+	webhookDispatcher := service.NewWebhookDispatcher(webhookEndpointRepo, webhookSender, slog.Default(),
+		time.Duration(cfg.WebhookDeliveryIntervalS)*time.Second,
+		time.Duration(cfg.WebhookDeliveryBackoffS)*time.Second,
+		cfg.WebhookDeliveryMaxAttempts,
+	)
+	notificationFanOut := service.NewNotificationFanOut(outboxRepo, notificationRepo, webhookEndpointRepo, processedEventRepo, db, slog.Default(),
+		time.Duration(cfg.OutboxPollIntervalMS)*time.Millisecond, cfg.OutboxBatchSize)
+
+	statusPoller := service.NewStatusPoller(
+		paymentRepo, providerRouter, webhookProcessor, slog.Default(),
+		time.Duration(cfg.StatusPollThresholdS)*time.Second,
+		time.Duration(cfg.StatusPollIntervalS)*time.Second,
+	)
+	submissionRetrier := service.NewProviderSubmissionRetrier(
+		providerSubmissionRepo, paymentRepo, providerRouter, webhookProcessor, slog.Default(),
+		time.Duration(cfg.ProviderSubmitRetryIntervalS)*time.Second,
+		time.Duration(cfg.ProviderSubmitBackoffS)*time.Second,
+		cfg.ProviderSubmitMaxAttempts,
+	)
+
+	providerSLAMonitor := service.NewProviderSLAMonitor(providerLatencyRepo, providerRouter, slog.Default(), service.ProviderSLAConfig{
+		P95Threshold: time.Duration(cfg.ProviderSLAP95S) * time.Second,
+		Window:       time.Duration(cfg.ProviderSLAWindowM) * time.Minute,
+		MinSamples:   cfg.ProviderSLAMinSamples,
+		Interval:     time.Duration(cfg.ProviderSLACheckIntervalS) * time.Second,
+	})
+
+	disputeMonitor := service.NewDisputeSLAMonitor(
+		disputeRepo, supportNoteRepo, slog.Default(),
+		time.Duration(cfg.DisputeSLACheckIntervalS)*time.Second,
+	)
+
+	fxRateHistorySvc := service.NewFXRateHistoryService(
+		fxSvc, repository.NewFXRateHistoryRepository(db), domain.SupportedCurrencies,
+		map[domain.UserTier]decimal.Decimal{
+			domain.UserTierStandard: decimal.NewFromFloat(cfg.FXSpreadPct),
+			domain.UserTierPlus:     decimal.NewFromFloat(cfg.FXSpreadPctPlus),
+			domain.UserTierBusiness: decimal.NewFromFloat(cfg.FXSpreadPctBusiness),
+		},
+		slog.Default(), time.Duration(cfg.FXRateRecordIntervalM)*time.Minute,
+	)
+
+	corridorAnalyticsSvc := service.NewCorridorAnalyticsService(
+		repository.NewCorridorStatsRepository(db), slog.Default(),
+		time.Duration(cfg.CorridorRefreshIntervalM)*time.Minute, cfg.CorridorRefreshLookbackD,
+	)
+
+	partitionMaintainer := service.NewPartitionMaintainer(
+		repository.NewPartitionRepository(db), slog.Default(),
+		time.Duration(cfg.PartitionCheckIntervalH)*time.Hour, cfg.PartitionMonthsAhead,
+	)
+
+	emailSender, err := newEmailSender(cfg)
+	if err != nil {
+		slog.Error("failed to configure email", "error", err)
+		os.Exit(1)
+	}
+	var baseNotifier service.Notifier = service.NewLogNotifier(slog.Default())
+	if cfg.EmailBackend == "smtp" {
+		baseNotifier = service.NewEmailNotifier(userRepo, emailSender)
+	}
+	notifier := service.NewRecordingNotifier(baseNotifier, notificationLogRepo, slog.Default())
+	notificationEmailDispatcher := service.NewNotificationEmailDispatcher(notificationRepo, emailSender, slog.Default(),
+		time.Duration(cfg.NotificationEmailIntervalS)*time.Second)
+
+	digestSvc := service.NewDigestService(
+		digestRepo, notifier, slog.Default(),
+		time.Duration(cfg.DigestCheckIntervalM)*time.Minute,
+	)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, notifier, db, cfg.APIKeyMaxActive)
+	beneficiarySvc := service.NewBeneficiaryService(beneficiaryRepo, db)
+	passwordResetSvc := service.NewPasswordResetService(userRepo, passwordResetRepo, apiKeyRepo, emailSender, db,
+		time.Duration(cfg.PasswordResetTTLM)*time.Minute, cfg.PasswordResetURL)
+	loginGuard := service.NewLoginGuard(loginThrottleRepo, db, service.LoginGuardConfig{
+		MaxFailures:   cfg.LoginMaxFailures,
+		MaxIPFailures: cfg.LoginMaxFailuresPerIP,
+		Window:        time.Duration(cfg.LoginFailureWindowM) * time.Minute,
+		Lockout:       time.Duration(cfg.LoginLockoutM) * time.Minute,
+	})
+
+	qaSampler := service.NewQASampler(qaSampleRepo, slog.Default(), service.QASamplerConfig{
+		RatePct:     cfg.QASampleRatePct,
+		LargeAmount: perCurrency(cfg.QALargeAmount),
+		Lookback:    time.Duration(cfg.QASampleLookbackH) * time.Hour,
+		Interval:    time.Duration(cfg.QASampleIntervalM) * time.Minute,
+	})
+
+	paymentRequestSvc := service.NewPaymentRequestService(
+		paymentRequestRepo, userRepo, accountRepo, paymentSvc, notifier, slog.Default(),
+		time.Duration(cfg.PaymentRequestTTLH)*time.Hour,
+		time.Duration(cfg.PaymentRequestExpiryIntervalM)*time.Minute,
+	)
+
+	rateLimitStore, err := newRateLimitStore(cfg)
+	if err != nil {
+		slog.Error("failed to configure rate limit store", "error", err)
+		os.Exit(1)
+	}
+	if c, ok := rateLimitStore.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	// Proof of work is always available to turn on; a CAPTCHA only with the
+	// provider's secret configured.
+	loginChallengeSecret := cfg.LoginChallengeSecret
+	if loginChallengeSecret == "" {
+		loginChallengeSecret = cfg.JWTSecret
+	}
+	loginChallengeVerifiers := map[domain.LoginChallengeMode]service.LoginChallengeVerifier{
+		domain.LoginChallengeProofOfWork: service.NewProofOfWork(loginChallengeSecret,
+			time.Duration(cfg.LoginChallengeTTLS)*time.Second, rateLimitStore),
+	}
+	if cfg.CaptchaSecret != "" {
+		loginChallengeVerifiers[domain.LoginChallengeCaptcha] = service.NewCaptcha(cfg.CaptchaVerifyURL, cfg.CaptchaSiteKey, cfg.CaptchaSecret)
+	}
+	loginChallengeSvc := service.NewLoginChallengeService(repository.NewRuntimeSettingRepository(db), loginChallengeVerifiers)
+
+	authHandler := handler.NewAuthHandler(userRepo, loginGuard, loginChallengeSvc, totpSvc,
+		service.NewLoginDeviceService(repository.NewUserDeviceRepository(db), notificationRepo, db), auditSvc, cfg.JWTSecret, 24*time.Hour)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetSvc)
+	totpHandler := handler.NewTOTPHandler(totpSvc)
+	userHandler := handler.NewUserHandler(userRepo)
+	accountHandler := handler.NewAccountHandler(accountSvc, balanceHistorySvc)
+	graphQLHandler := handler.NewGraphQLHandler(userRepo, accountSvc, paymentRepo, ledgerRepo, paymentEventArchiver)
+	paymentHandler := handler.NewPaymentHandler(paymentSvc)
+	refundHandler := handler.NewRefundHandler(paymentSvc)
+	paymentRequestHandler := handler.NewPaymentRequestHandler(paymentRequestSvc)
+	holdHandler := handler.NewHoldHandler(paymentSvc)
+	accountCloseHandler := handler.NewAccountCloseHandler(paymentSvc, auditSvc)
+	fxHandler := handler.NewFXHandler(fxSvc, fxRateHistorySvc)
+	webhookPrioritizer := service.NewWebhookPrioritizer(paymentRepo, perCurrency(cfg.WebhookPriorityHighAmount))
+	webhookHandler := handler.NewWebhookHandler(
+		webhookEventRepo, webhookPrioritizer, webhookVerifiers, webhookMaxBody, cfg.DefaultProvider,
+		time.Duration(cfg.WebhookToleranceS)*time.Second,
+	)
+	handshakeHandler := handler.NewWebhookHandshakeHandler(providerRegistrationRepo, handshakeSecrets, cfg.DefaultProvider)
+	healthHandler := handler.NewHealthHandler(db)
+	supportNoteHandler := handler.NewSupportNoteHandler(supportNoteSvc)
+	adminPaymentHandler := handler.NewAdminPaymentHandler(paymentRepo, paymentEventArchiver, ledgerRepo, supportNoteSvc)
+	settlementHandler := handler.NewSettlementHandler(settlementSvc)
+	adminFXHandler := handler.NewAdminFXHandler(paymentRepo, ledgerRepo)
+	adminTreasuryHandler := handler.NewAdminTreasuryHandler(treasurySvc, paymentSvc)
+	disputeHandler := handler.NewDisputeHandler(disputeSvc)
+	notificationHandler := handler.NewNotificationHandler(service.NewNotificationService(notificationRepo))
+	adminProviderHandler := handler.NewAdminProviderHandler(providerSLAMonitor, providerSubmissionRepo)
+	adminLimitHandler := handler.NewAdminLimitHandler(userLimitSvc)
+	adminAccountHandler := handler.NewAdminAccountHandler(accountFreezeSvc)
+	kycHandler := handler.NewKYCHandler(kycSvc)
+	identityHandler := handler.NewIdentityHandler(identitySvc)
+	digestHandler := handler.NewDigestHandler(digestSvc)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeySvc)
+	webhookEndpointHandler := handler.NewWebhookEndpointHandler(webhookEndpointSvc)
+	beneficiaryHandler := handler.NewBeneficiaryHandler(beneficiarySvc)
+	adminTemplateHandler := handler.NewAdminTemplateHandler(templates.Default())
+	adminAnalyticsHandler := handler.NewAdminAnalyticsHandler(corridorAnalyticsSvc)
+	adminAuditHandler := handler.NewAdminAuditHandler(auditSvc)
+	adminScreeningHandler := handler.NewAdminScreeningHandler(denylistSvc)
+	adminResidencyHandler := handler.NewAdminResidencyHandler(residencySvc)
+	adminReviewHandler := handler.NewAdminReviewHandler(paymentRepo, paymentSvc, webhookProcessor)
+	adminReversalHandler := handler.NewAdminReversalHandler(paymentSvc)
+	adminResolutionHandler := handler.NewAdminResolutionHandler(webhookProcessor, auditSvc)
+	adminQAHandler := handler.NewAdminQAHandler(qaSampleRepo, qaSampler)
+	adminWebhookHandler := handler.NewAdminWebhookHandler(webhookEventRepo)
+	adminLedgerHandler := handler.NewAdminLedgerHandler(ledgerChainBreakRepo, ledgerVerifier, ledgerRepo)
+	adminSettingHandler := handler.NewAdminSettingHandler(loginChallengeSvc)
+
+	sessionVersions := middleware.NewSessionVersions(userRepo, time.Duration(cfg.SessionCacheTTLS)*time.Second)
+	authMW := middleware.Auth(cfg.JWTSecret, sessionVersions)
+	idempotencyPolicy := middleware.IdempotencyConfig{
+		RequireUUID:    cfg.IdempotencyRequireUUID,
+		MinEntropyBits: cfg.IdempotencyMinEntropyBits,
+		MaxKeyLength:   cfg.IdempotencyMaxKeyLength,
+	}
+	idempotencyMW := middleware.Idempotency(idempotencyRepo, idempotencyPolicy)
+	// Account and dispute creation mint a key when the client sends none and
+	// echo it in the Idempotency-Key response header for retries.
+	optionalIdempotencyMW := middleware.Idempotency(idempotencyRepo, idempotencyPolicy.WithGeneratedKeys())
+
+	loginLimitMW := middleware.RateLimit(rateLimitStore, middleware.RateLimitRule{
+		Name:  "login",
+		PerIP: ratelimit.PerMinute(cfg.RateLimitLoginIPPerMin, cfg.RateLimitLoginIPBurst),
+	})
+	paymentLimitMW := middleware.RateLimit(rateLimitStore, middleware.RateLimitRule{
+		Name:    "payment",
+		PerIP:   ratelimit.PerMinute(cfg.RateLimitPaymentIPPerMin, cfg.RateLimitPaymentIPBurst),
+		PerUser: ratelimit.PerMinute(cfg.RateLimitPaymentUserPerMin, cfg.RateLimitPaymentUserBurst),
+	})
+
+	var mux http.Handler = newRouter(routeHandlers{
+		auth:            authHandler,
+		passwordReset:   passwordResetHandler,
+		totp:            totpHandler,
+		user:            userHandler,
+		account:         accountHandler,
+		payment:         paymentHandler,
+		refund:          refundHandler,
+		paymentRequest:  paymentRequestHandler,
+		hold:            holdHandler,
+		accountClose:    accountCloseHandler,
+		fx:              fxHandler,
+		webhook:         webhookHandler,
+		handshake:       handshakeHandler,
+		health:          healthHandler,
+		supportNote:     supportNoteHandler,
+		adminPayment:    adminPaymentHandler,
+		settlement:      settlementHandler,
+		adminFX:         adminFXHandler,
+		adminTreasury:   adminTreasuryHandler,
+		dispute:         disputeHandler,
+		notification:    notificationHandler,
+		adminProvider:   adminProviderHandler,
+		adminLimit:      adminLimitHandler,
+		adminAccount:    adminAccountHandler,
+		kyc:             kycHandler,
+		identity:        identityHandler,
+		digest:          digestHandler,
+		apiKey:          apiKeyHandler,
+		webhookEndpoint: webhookEndpointHandler,
+		beneficiary:     beneficiaryHandler,
+		graphQL:         graphQLHandler,
+		adminTemplate:   adminTemplateHandler,
+		adminAnalytics:  adminAnalyticsHandler,
+		adminAudit:      adminAuditHandler,
+		adminScreening:  adminScreeningHandler,
+		adminResidency:  adminResidencyHandler,
+		adminReview:     adminReviewHandler,
+		adminReversal:   adminReversalHandler,
+		adminResolution: adminResolutionHandler,
+		adminQA:         adminQAHandler,
+		adminWebhook:    adminWebhookHandler,
+		adminLedger:     adminLedgerHandler,
+		adminSetting:    adminSettingHandler,
+	}, routeMiddleware{
+		auth: authMW,
+		apiKey: func(scope domain.APIKeyScope) func(http.Handler) http.Handler {
+			return middleware.AuthOrAPIKey(cfg.JWTSecret, sessionVersions, apiKeySvc, scope)
+		},
+		idempotency:         idempotencyMW,
+		optionalIdempotency: optionalIdempotencyMW,
+		loginLimit:          loginLimitMW,
+		paymentLimit:        paymentLimitMW,
+		audit:               middleware.Audit(auditSvc),
+	})
+
+	shedPriorities, err := middleware.MergePriorities(cfg.LoadShedPriorities)
+	if err != nil {
+		slog.Error("invalid load shedding config", "error", err)
+		os.Exit(1)
+	}
+	loadShedMW := middleware.LoadShedding(middleware.LoadShedConfig{
+		SoftInFlight: cfg.LoadShedSoftInFlight,
+		HardInFlight: cfg.LoadShedHardInFlight,
+		MaxPoolWait:  time.Duration(cfg.LoadShedMaxPoolWaitMS) * time.Millisecond,
+		RetryAfter:   time.Duration(cfg.LoadShedRetryAfterS) * time.Second,
+		Priorities:   shedPriorities,
+	}, middleware.PgxPoolStats{Pool: db})
+
+	requestTimeoutMW := middleware.RequestTimeout(middleware.RequestTimeoutConfig{
+		Min: time.Duration(cfg.RequestTimeoutMinMS) * time.Millisecond,
+		Max: time.Duration(cfg.RequestTimeoutMaxMS) * time.Millisecond,
+	})
+
+	trustedProxies, err := handler.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
+	if !runsAPI {
+		mux = newWorkerRouter(healthHandler, metricsRegistry)
+	}
+
+	captureMW := func(next http.Handler) http.Handler { return next }
+	if len(cfg.CaptureRoutes) > 0 {
+		if cfg.AppEnv == "production" {
+			slog.Error("request capture is for development only; unset CAPTURE_ROUTES in production")
+			os.Exit(1)
+		}
+		slog.Warn("capturing requests", "routes", cfg.CaptureRoutes, "dir", cfg.CaptureDir)
+		captureMW = middleware.Capture(capture.NewDir(cfg.CaptureDir), cfg.CaptureRoutes)
+	}
+
+	stack := middleware.SecureHeaders(middleware.Tracing(captureMW(middleware.ClientIP(trustedProxies)(middleware.InFlight(inFlight)(middleware.Logging(requestTimeoutMW(loadShedMW(middleware.Recovery(mux)))))))))
+
+	addr := fmt.Sprintf(":%d", cfg.Port)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           stack,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// The gRPC API shares the payment service with the REST API. The gRPC
+	// server runs as the handler of an HTTP/2 server without TLS, so it gets
+	// the same middleware and shutdown as the REST API and belongs on the
+	// internal network only.
+	var grpcSrv *http.Server
+	if runsAPI && cfg.GRPCAddr != "" {
+		grpcHandler, err := grpcapi.NewServer(paymentSvc, cfg.GRPCAuthToken)
+		if err != nil {
+			slog.Error("GRPC_AUTH_TOKEN is required when GRPC_ADDR is set", "error", err)
+			os.Exit(1)
+		}
+		grpcSrv = &http.Server{
+			Addr:              cfg.GRPCAddr,
+			Handler:           middleware.Tracing(middleware.InFlight(inFlight)(middleware.Logging(grpcHandler))),
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			Protocols:         new(http.Protocols),
+		}
+		grpcSrv.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	// The API router doesn't serve /metrics; a process that runs the API
+	// serves it on METRICS_ADDR, which belongs on the internal network only.
+	var metricsSrv *http.Server
+	if runsAPI && cfg.MetricsAddr != "" {
+		metricsSrv = &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           newMetricsRouter(metricsRegistry),
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+
+	// In api mode no processor runs here; a separate worker process has to.
+	processorCtx, processorCancel := context.WithCancel(context.Background())
+	var processorWg sync.WaitGroup
+	if runsProcessors {
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			webhookProcessor.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			statusPoller.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			submissionRetrier.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			providerSLAMonitor.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			disputeMonitor.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			partitionMaintainer.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			digestSvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			qaSampler.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			paymentRequestSvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			treasurySvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			fxRateHistorySvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			ledgerVerifier.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			balanceHistorySvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			paymentEventArchiver.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			corridorAnalyticsSvc.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			outboxRelay.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			notificationFanOut.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			notificationEmailDispatcher.Start(processorCtx)
+		}()
+		processorWg.Add(1)
+		go func() {
+			defer processorWg.Done()
+			webhookDispatcher.Start(processorCtx)
+		}()
+	}
+
+	// Reset emails are queued in the process that took the request, so their
+	// sender runs alongside the API and stops after it.
+	resetCtx, resetCancel := context.WithCancel(context.Background())
+	resetDone := make(chan struct{})
+	if runsAPI {
+		go func() {
+			defer close(resetDone)
+			passwordResetSvc.Start(resetCtx)
+		}()
+	} else {
+		close(resetDone)
+	}
+
+	go func() {
+		slog.Info("server started", "addr", addr, "mode", mode)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	if grpcSrv != nil {
+		go func() {
+			slog.Info("gRPC server started", "addr", grpcSrv.Addr)
+			if err := grpcSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("gRPC server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if metricsSrv != nil {
+		go func() {
+			slog.Info("metrics server started", "addr", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("metrics server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+
+	slog.Info("shutting down server")
+	gracePeriod := time.Duration(cfg.ShutdownGracePeriodS) * time.Second
+	inFlight.BeginDrain(time.Now())
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer shutdownCancel()
+
+	processorCancel()
+	processorsDone := make(chan struct{})
+	go func() {
+		processorWg.Wait()
+		close(processorsDone)
+	}()
+	select {
+	case <-processorsDone:
+	case <-shutdownCtx.Done():
+		slog.Error("background processors did not stop within the grace period")
+	}
+
+	shutdownErr := srv.Shutdown(shutdownCtx)
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(shutdownCtx); err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+
+	resetCancel()
+	select {
+	case <-resetDone:
+	case <-shutdownCtx.Done():
+		slog.Error("queued password reset emails were not sent within the grace period")
+	}
+
+	hostname, _ := os.Hostname()
+	report := inFlight.Report(hostname, sig.String(), gracePeriod, time.Now())
+	reportCtx, reportCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer reportCancel()
+	if err := service.NewShutdownReporter(shutdownReportRepo, slog.Default()).Publish(reportCtx, report); err != nil {
+		slog.Error("failed to store shutdown report", "error", err)
+	}
+
+	if shutdownErr != nil {
+		slog.Error("server forced to shutdown", "error", shutdownErr)
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// newArchiveStore returns the cold storage configured by ARCHIVE_BACKEND, or
+// nil when archiving is off.
+func newArchiveStore(cfg *config.Config) (archive.Store, error) {
+	switch cfg.ArchiveBackend {
+	case "":
+		return nil, nil
+	case "fs":
+		return archive.NewFSStore(cfg.ArchiveFSDir), nil
+	case "s3":
+		if cfg.ArchiveS3Bucket == "" {
+			return nil, errors.New("ARCHIVE_S3_BUCKET is required for the s3 backend")
+		}
+		return archive.NewS3Store(context.Background(), archive.S3Config{
+			Endpoint:        cfg.ArchiveS3Endpoint,
+			Region:          cfg.ArchiveS3Region,
+			Bucket:          cfg.ArchiveS3Bucket,
+			AccessKeyID:     cfg.ArchiveS3AccessKeyID,
+			SecretAccessKey: cfg.ArchiveS3SecretAccessKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown ARCHIVE_BACKEND %q", cfg.ArchiveBackend)
+	}
+}
+
+// perCurrency collects a per-currency setting for every enabled currency.
+func perCurrency(setting func(currency string) int64) map[domain.Currency]int64 {
+	amounts := make(map[domain.Currency]int64, len(domain.SupportedCurrencies))
+	for _, c := range domain.SupportedCurrencies {
+		amounts[c] = setting(string(c))
+	}
+	return amounts
+}
+
+// newEventPublisher returns the event bus publisher configured by EVENT_BUS.
+func newEventPublisher(cfg *config.Config) (events.Publisher, error) {
+	timeout := time.Duration(cfg.EventBusTimeoutMS) * time.Millisecond
+	switch cfg.EventBus {
+	case "log":
+		return events.NewLogPublisher(slog.Default()), nil
+	case "nats":
+		return events.NewNATSPublisher(events.NATSConfig{URL: cfg.NATSURL, CAFile: cfg.NATSCAFile, Timeout: timeout})
+	case "kafka":
+		return events.NewKafkaPublisher(events.KafkaConfig{Brokers: cfg.KafkaBrokers, TLS: cfg.KafkaTLS, Timeout: timeout})
+	default:
+		return nil, fmt.Errorf("unknown EVENT_BUS %q", cfg.EventBus)
+	}
+}
+
+// newEmailSender returns the sender configured by EMAIL_BACKEND.
+func newEmailSender(cfg *config.Config) (email.Sender, error) {
+	switch cfg.EmailBackend {
+	case "log":
+		return email.NewLogSender(slog.Default()), nil
+	case "smtp":
+		return email.NewSMTPSender(email.SMTPConfig{
+			Host:       cfg.SMTPHost,
+			Port:       cfg.SMTPPort,
+			Username:   cfg.SMTPUsername,
+			Password:   cfg.SMTPPassword,
+			From:       cfg.EmailFrom,
+			RequireTLS: cfg.SMTPRequireTLS,
+			Timeout:    time.Duration(cfg.SMTPTimeoutMS) * time.Millisecond,
+		})
+	default:
+		return nil, fmt.Errorf("unknown EMAIL_BACKEND %q", cfg.EmailBackend)
+	}
+}
+
+// newRateLimitStore returns the bucket store configured by RATE_LIMIT_BACKEND.
+func newRateLimitStore(cfg *config.Config) (ratelimit.Store, error) {
+	switch cfg.RateLimitBackend {
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "redis":
+		return ratelimit.NewRedisStore(cfg.RedisAddr, cfg.RedisPassword,
+			time.Duration(cfg.RedisTimeoutMS)*time.Millisecond, cfg.RedisPoolSize), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+)
+
+// pathOwner is the user the {id} in pathParams belongs to on /users/{id}
+// routes.
+var pathOwner = uuid.MustParse("7b0d0f0e-3c3a-4a55-9a49-0c6f7a3b5d21")
+
+type caller struct {
+	name   string
+	userID uuid.UUID
+	role   domain.UserRole
+}
+
+var callers = []caller{
+	{name: "anonymous"},
+	{name: "user", userID: pathOwner, role: domain.UserRoleUser},
+	{name: "other-user", userID: uuid.New(), role: domain.UserRoleUser},
+	{name: "support", userID: uuid.New(), role: domain.UserRoleSupport},
+	{name: "admin", userID: uuid.New(), role: domain.UserRoleAdmin},
+	{name: "payment-ops", userID: uuid.New(), role: domain.UserRolePaymentOps},
+}
+
+// allowed means the request got past every access check. The matrix
+// router has no services behind its handlers, so a request that reaches one
+// usually panics, which the harness records as allowed.
+const allowed = 0
+
+func expectedStatus(a access, c caller) int {
+	if c.name == "anonymous" {
+		if a == public {
+			return allowed
+		}
+		return http.StatusUnauthorized
+	}
+	switch a {
+	case owner:
+		if c.userID != pathOwner && !c.role.IsAdmin() {
+			return http.StatusNotFound
+		}
+	case self:
+		if c.userID != pathOwner {
+			return http.StatusNotFound
+		}
+	case staff:
+		if !c.role.IsStaff() {
+			return http.StatusForbidden
+		}
+	case admin:
+		if !c.role.IsAdmin() {
+			return http.StatusForbidden
+		}
+	case paymentOps:
+		if c.role != domain.UserRolePaymentOps {
+			return http.StatusForbidden
+		}
+	}
+	return allowed
+}
+
+// newAuthzRouter builds the real route table with an auth middleware that
+// trusts whatever caller the test put on the request context.
+func newAuthzRouter() *router {
+	requireCaller := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := auth.UserIDFromContext(r.Context()); !ok {
+				handler.RespondAppError(w, handler.ErrMissingToken, nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	pass := func(next http.Handler) http.Handler { return next }
+	return newRouter(routeHandlers{}, routeMiddleware{
+		auth:                requireCaller,
+		apiKey:              func(domain.APIKeyScope) func(http.Handler) http.Handler { return requireCaller },
+		idempotency:         pass,
+		optionalIdempotency: pass,
+		loginLimit:          pass,
+		paymentLimit:        pass,
+		audit:               pass,
+	})
+}
+
+// serveAs sends req as c and reports the status, or allowed if a handler
+// was reached.
+func serveAs(r *router, req *http.Request, c caller) (status int) {
+	if c.name != "anonymous" {
+		ctx := auth.ContextWithUserID(req.Context(), c.userID)
+		ctx = auth.ContextWithRole(ctx, c.role)
+		req = req.WithContext(ctx)
+	}
+
+	defer func() {
+		if recover() != nil {
+			status = allowed
+		}
+	}()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	switch rec.Code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return rec.Code
+	}
+	return allowed
+}
+
+// TestRouter_AuthorizationMatrix checks every route against every kind of
+// caller. A route's access level is declared in routeTable, so a new route
+// fails TestRouter_RegistersFullRouteTable until someone states who may call
+// it, and then fails here if the wiring doesn't match.
+func TestRouter_AuthorizationMatrix(t *testing.T) {
+	r := newAuthzRouter()
+
+	for _, rt := range routeTable {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		for _, c := range callers {
+			t.Run(rt.pattern+"/"+c.name, func(t *testing.T) {
+				req := httptest.NewRequestWithContext(context.Background(), method, pathParams.Replace(path), strings.NewReader("{}"))
+				got := serveAs(r, req, c)
+				assert.Equal(t, expectedStatus(rt.access, c), got, "0 means the request reached the handler")
+			})
+		}
+	}
+}
+
+// TestRouter_UserRoutesCheckOwnership keeps new /users/{id} routes from
+// being declared authed, which would leave the ownership check to the
+// handler, and checks that an {id} that isn't a user ID is rejected before
+// any handler sees it.
+func TestRouter_UserRoutesCheckOwnership(t *testing.T) {
+	r := newAuthzRouter()
+	adminCaller := caller{name: "admin", userID: uuid.New(), role: domain.UserRoleAdmin}
+
+	for _, rt := range routeTable {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		if !strings.HasPrefix(path, "/api/v1/users/{id}") {
+			continue
+		}
+		t.Run(rt.pattern, func(t *testing.T) {
+			assert.Contains(t, []access{owner, self}, rt.access)
+
+			probe := strings.Replace(path, "{id}", "alice", 1)
+			req := httptest.NewRequestWithContext(context.Background(), method, pathParams.Replace(probe), strings.NewReader("{}"))
+			assert.Equal(t, http.StatusNotFound, serveAs(r, req, adminCaller))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/josh-kwaku/grey-backend-assessment/docs"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/middleware"
+)
+
+type routeHandlers struct {
+	auth            *handler.AuthHandler
+	passwordReset   *handler.PasswordResetHandler
+	totp            *handler.TOTPHandler
+	user            *handler.UserHandler
+	account         *handler.AccountHandler
+	payment         *handler.PaymentHandler
+	refund          *handler.RefundHandler
+	paymentRequest  *handler.PaymentRequestHandler
+	hold            *handler.HoldHandler
+	accountClose    *handler.AccountCloseHandler
+	fx              *handler.FXHandler
+	webhook         *handler.WebhookHandler
+	handshake       *handler.WebhookHandshakeHandler
+	health          *handler.HealthHandler
+	supportNote     *handler.SupportNoteHandler
+	adminPayment    *handler.AdminPaymentHandler
+	settlement      *handler.SettlementHandler
+	adminFX         *handler.AdminFXHandler
+	adminTreasury   *handler.AdminTreasuryHandler
+	dispute         *handler.DisputeHandler
+	notification    *handler.NotificationHandler
+	adminProvider   *handler.AdminProviderHandler
+	adminLimit      *handler.AdminLimitHandler
+	adminAccount    *handler.AdminAccountHandler
+	kyc             *handler.KYCHandler
+	identity        *handler.IdentityHandler
+	digest          *handler.DigestHandler
+	apiKey          *handler.APIKeyHandler
+	webhookEndpoint *handler.WebhookEndpointHandler
+	beneficiary     *handler.BeneficiaryHandler
+	graphQL         *handler.GraphQLHandler
+	adminScreening  *handler.AdminScreeningHandler
+	adminResidency  *handler.AdminResidencyHandler
+	adminReview     *handler.AdminReviewHandler
+	adminReversal   *handler.AdminReversalHandler
+	adminResolution *handler.AdminResolutionHandler
+	adminQA         *handler.AdminQAHandler
+	adminWebhook    *handler.AdminWebhookHandler
+	adminLedger     *handler.AdminLedgerHandler
+	adminSetting    *handler.AdminSettingHandler
+	adminTemplate   *handler.AdminTemplateHandler
+	adminAnalytics  *handler.AdminAnalyticsHandler
+	adminAudit      *handler.AdminAuditHandler
+}
+
+type routeMiddleware struct {
+	// auth admits signed-in sessions only. apiKey also admits API keys that
+	// have the given scope; only routes a server-to-server integration needs
+	// use it.
+	auth                func(http.Handler) http.Handler
+	apiKey              func(scope domain.APIKeyScope) func(http.Handler) http.Handler
+	idempotency         func(http.Handler) http.Handler
+	optionalIdempotency func(http.Handler) http.Handler
+	loginLimit          func(http.Handler) http.Handler
+	paymentLimit        func(http.Handler) http.Handler
+	// audit records admin changes; it goes inside the role check.
+	audit func(http.Handler) http.Handler
+}
+
+// router is a ServeMux that remembers the patterns registered on it, so the
+// route table can be checked without a running server.
+type router struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (r *router) Handle(pattern string, h http.Handler) {
+	r.ServeMux.Handle(pattern, h)
+	r.patterns = append(r.patterns, pattern)
+}
+
+func (r *router) HandleFunc(pattern string, h func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(h))
+}
+
+func newRouter(h routeHandlers, mw routeMiddleware) *router {
+	r := &router{ServeMux: http.NewServeMux()}
+	r.HandleFunc("GET /docs", handler.ServeDocs())
+	r.HandleFunc("GET /docs/openapi.yaml", handler.ServeSpec(docs.OpenAPISpec))
+	r.HandleFunc("GET /api/v1/openapi.json", handler.ServeSpecJSON(docs.OpenAPIJSON))
+	r.HandleFunc("GET /docs/schema.graphql", handler.ServeGraphQLSchema(docs.GraphQLSchema))
+
+	r.HandleFunc("GET /health", h.health.Liveness)
+	r.HandleFunc("GET /health/ready", h.health.Readiness)
+	r.HandleFunc("GET /api/v1/auth/login-challenge", h.auth.LoginChallenge)
+	r.Handle("POST /api/v1/auth/login", mw.loginLimit(http.HandlerFunc(h.auth.Login)))
+	r.Handle("POST /api/v1/auth/password-reset/request", mw.loginLimit(http.HandlerFunc(h.passwordReset.Request)))
+	r.Handle("POST /api/v1/auth/password-reset/confirm", mw.loginLimit(http.HandlerFunc(h.passwordReset.Confirm)))
+
+	r.Handle("GET /api/v1/users/{id}", mw.apiKey(domain.APIKeyScopeRead)(middleware.RequireOwner(http.HandlerFunc(h.user.GetByID))))
+	r.Handle("POST /api/v1/users/{id}/accounts", mw.auth(middleware.RequireOwner(mw.optionalIdempotency(http.HandlerFunc(h.account.Create)))))
+	r.Handle("GET /api/v1/users/{id}/accounts", mw.apiKey(domain.APIKeyScopeRead)(middleware.RequireOwner(http.HandlerFunc(h.account.List))))
+	r.Handle("PUT /api/v1/users/{id}/default-account", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.account.SetDefault))))
+	r.Handle("GET /api/v1/accounts/{id}/balance", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.account.Balance)))
+	r.Handle("GET /api/v1/accounts/{id}/transactions", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.account.Transactions)))
+	r.Handle("POST /api/v1/accounts/{id}/close", mw.auth(mw.optionalIdempotency(http.HandlerFunc(h.accountClose.Close))))
+	r.Handle("POST /api/v1/accounts/{id}/holds", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.optionalIdempotency(http.HandlerFunc(h.hold.Place))))
+	r.Handle("GET /api/v1/accounts/{id}/holds", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.hold.List)))
+	r.Handle("POST /api/v1/holds/{id}/release", mw.apiKey(domain.APIKeyScopePaymentsWrite)(http.HandlerFunc(h.hold.Release)))
+	r.Handle("POST /api/v1/users/{id}/kyc", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.kyc.Submit))))
+	r.Handle("GET /api/v1/users/{id}/kyc", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.kyc.Get))))
+	r.Handle("PUT /api/v1/users/{id}/unique-name", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.identity.ChangeUniqueName))))
+	r.Handle("PUT /api/v1/users/{id}/email", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.identity.ChangeEmail))))
+	r.Handle("GET /api/v1/users/{id}/identifier-history", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.identity.History))))
+	r.Handle("GET /api/v1/users/{id}/digest-preferences", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.digest.GetPreference))))
+	r.Handle("PUT /api/v1/users/{id}/digest-preferences", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.digest.UpdatePreference))))
+	r.Handle("GET /api/v1/users/{id}/digests", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.digest.List))))
+	r.Handle("GET /api/v1/users/{id}/digests/{digest_id}", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.digest.Get))))
+	r.Handle("POST /api/v1/users/{id}/api-keys", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.apiKey.Create))))
+	r.Handle("GET /api/v1/users/{id}/api-keys", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.apiKey.List))))
+	r.Handle("POST /api/v1/users/{id}/api-keys/{key_id}/rotate", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.apiKey.Rotate))))
+	r.Handle("DELETE /api/v1/users/{id}/api-keys/{key_id}", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.apiKey.Revoke))))
+	r.Handle("GET /api/v1/users/{id}/webhook-endpoint", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.webhookEndpoint.Get))))
+	r.Handle("PUT /api/v1/users/{id}/webhook-endpoint", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.webhookEndpoint.Set))))
+	r.Handle("DELETE /api/v1/users/{id}/webhook-endpoint", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.webhookEndpoint.Delete))))
+	r.Handle("POST /api/v1/users/{id}/webhook-endpoint/rotate-secret", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.webhookEndpoint.RotateSecret))))
+	r.Handle("GET /api/v1/users/{id}/webhook-endpoint/deliveries", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.webhookEndpoint.ListAttempts))))
+	r.Handle("POST /api/v1/users/{id}/webhook-endpoint/test", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.webhookEndpoint.SendTest))))
+	r.Handle("POST /api/v1/users/{id}/beneficiaries", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.beneficiary.Create))))
+	r.Handle("GET /api/v1/users/{id}/beneficiaries", mw.apiKey(domain.APIKeyScopeRead)(middleware.RequireOwner(http.HandlerFunc(h.beneficiary.List))))
+	r.Handle("DELETE /api/v1/users/{id}/beneficiaries/{beneficiary_id}", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.beneficiary.Delete))))
+	r.Handle("POST /api/v1/users/{id}/beneficiaries/{beneficiary_id}/restore", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.beneficiary.Restore))))
+	r.Handle("GET /api/v1/users/{id}/totp", mw.auth(middleware.RequireOwner(http.HandlerFunc(h.totp.Status))))
+	r.Handle("POST /api/v1/users/{id}/totp", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.totp.Enroll))))
+	r.Handle("POST /api/v1/users/{id}/totp/confirm", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.totp.Confirm))))
+	r.Handle("DELETE /api/v1/users/{id}/totp", mw.auth(middleware.RequireSelf(http.HandlerFunc(h.totp.Disable))))
+	r.Handle("GET /api/v1/recipients/{unique_name}", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.identity.VerifyRecipient)))
+	r.Handle("POST /api/v1/graphql", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.graphQL.Query)))
+
+	r.Handle("POST /api/v1/payments", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.paymentLimit(mw.idempotency(http.HandlerFunc(h.payment.Create)))))
+	r.Handle("POST /api/v1/payments/external", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.paymentLimit(mw.idempotency(http.HandlerFunc(h.payment.CreateExternal)))))
+	r.Handle("POST /api/v1/payments/preview", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.payment.Preview)))
+	r.Handle("GET /api/v1/payments", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.payment.Lookup)))
+	r.Handle("GET /api/v1/payments/{id}", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.payment.Get)))
+	r.Handle("POST /api/v1/payments/{id}/retry", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.paymentLimit(mw.idempotency(http.HandlerFunc(h.payment.Retry)))))
+	r.Handle("POST /api/v1/payments/{id}/refunds", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.idempotency(http.HandlerFunc(h.refund.Create))))
+	r.Handle("GET /api/v1/payments/{id}/refunds", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.refund.List)))
+	r.Handle("POST /api/v1/payments/{id}/disputes", mw.auth(mw.optionalIdempotency(http.HandlerFunc(h.dispute.Create))))
+	r.Handle("GET /api/v1/disputes", mw.auth(http.HandlerFunc(h.dispute.ListMine)))
+	r.Handle("GET /api/v1/disputes/{id}", mw.auth(http.HandlerFunc(h.dispute.GetMine)))
+
+	r.Handle("GET /api/v1/notifications", mw.auth(http.HandlerFunc(h.notification.List)))
+	r.Handle("POST /api/v1/notifications/{id}/read", mw.auth(http.HandlerFunc(h.notification.MarkRead)))
+	r.Handle("POST /api/v1/notifications/read-all", mw.auth(http.HandlerFunc(h.notification.MarkAllRead)))
+
+	r.Handle("POST /api/v1/payment-requests", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.optionalIdempotency(http.HandlerFunc(h.paymentRequest.Create))))
+	r.Handle("GET /api/v1/payment-requests", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.paymentRequest.List)))
+	r.Handle("GET /api/v1/payment-requests/{id}", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.paymentRequest.Get)))
+	r.Handle("POST /api/v1/payment-requests/{id}/accept", mw.apiKey(domain.APIKeyScopePaymentsWrite)(mw.optionalIdempotency(http.HandlerFunc(h.paymentRequest.Accept))))
+	r.Handle("POST /api/v1/payment-requests/{id}/decline", mw.apiKey(domain.APIKeyScopePaymentsWrite)(http.HandlerFunc(h.paymentRequest.Decline)))
+	r.Handle("POST /api/v1/payment-requests/{id}/cancel", mw.apiKey(domain.APIKeyScopePaymentsWrite)(http.HandlerFunc(h.paymentRequest.Cancel)))
+
+	r.Handle("GET /api/v1/fx/rates", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.fx.GetRate)))
+	r.Handle("GET /api/v1/fx/rates/history", mw.apiKey(domain.APIKeyScopeRead)(http.HandlerFunc(h.fx.History)))
+
+	r.Handle("GET /api/v1/admin/payments/review-queue", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminReview.List))))
+	r.Handle("POST /api/v1/admin/payments/{id}/approve", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminReview.Approve)))))
+	r.Handle("POST /api/v1/admin/payments/{id}/reject", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminReview.Reject)))))
+	r.Handle("POST /api/v1/admin/payments/{id}/reverse", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminReversal.Reverse)))))
+	r.Handle("POST /api/v1/admin/payments/{id}/resolve", mw.auth(middleware.RequirePaymentOps(mw.audit(http.HandlerFunc(h.adminResolution.Resolve)))))
+	r.Handle("GET /api/v1/admin/payments/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminPayment.Get))))
+	r.Handle("POST /api/v1/admin/payments/{id}/notes", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.supportNote.CreatePaymentNote)))))
+	r.Handle("GET /api/v1/admin/payments/{id}/notes", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.supportNote.ListPaymentNotes))))
+	r.Handle("POST /api/v1/admin/users/{id}/notes", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.supportNote.CreateUserNote)))))
+	r.Handle("GET /api/v1/admin/users/{id}/notes", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.supportNote.ListUserNotes))))
+	r.Handle("GET /api/v1/admin/users/{id}/identifier-history", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.identity.AdminHistory))))
+	r.Handle("GET /api/v1/admin/users/{id}/limits", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminLimit.List))))
+	r.Handle("PUT /api/v1/admin/users/{id}/limits/{currency}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminLimit.Set)))))
+	r.Handle("DELETE /api/v1/admin/users/{id}/limits/{currency}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminLimit.Reset)))))
+	r.Handle("PUT /api/v1/admin/users/{id}/tier", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminLimit.SetTier)))))
+	r.Handle("PUT /api/v1/admin/users/{id}/residency", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminResidency.SetUserResidency)))))
+	r.Handle("GET /api/v1/admin/accounts/frozen", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminAccount.ListFrozen))))
+	r.Handle("POST /api/v1/admin/accounts/{id}/freeze", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminAccount.Freeze)))))
+	r.Handle("POST /api/v1/admin/accounts/{id}/unfreeze", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminAccount.Unfreeze)))))
+	r.Handle("GET /api/v1/admin/fx/revenue", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminFX.Revenue))))
+	r.Handle("GET /api/v1/admin/fx/fees", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminFX.Fees))))
+	r.Handle("GET /api/v1/admin/fx/pools", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminTreasury.Pools))))
+	r.Handle("POST /api/v1/admin/fx/pools/transfer", mw.auth(middleware.RequireAdmin(mw.audit(mw.optionalIdempotency(http.HandlerFunc(h.adminTreasury.Transfer))))))
+	r.Handle("PUT /api/v1/admin/fx/pools/{currency}/watermark", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminTreasury.SetWatermark)))))
+	r.Handle("DELETE /api/v1/admin/fx/pools/{currency}/watermark", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminTreasury.ClearWatermark)))))
+	r.Handle("GET /api/v1/admin/webhooks/stats", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminWebhook.Stats))))
+	r.Handle("GET /api/v1/admin/webhook-events", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminWebhook.ListEvents))))
+	r.Handle("GET /api/v1/admin/webhook-events/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminWebhook.GetEvent))))
+	r.Handle("POST /api/v1/admin/webhook-events/{id}/retry", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.adminWebhook.RetryEvent)))))
+	r.Handle("GET /api/v1/admin/webhooks/registrations", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.handshake.Registrations))))
+	r.Handle("GET /api/v1/admin/ledger/trial-balance", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminLedger.TrialBalance))))
+	r.Handle("GET /api/v1/admin/ledger/chain-breaks", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminLedger.ChainBreaks))))
+	r.Handle("GET /api/v1/admin/ledger/chain-breaks/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminLedger.ChainBreak))))
+	r.Handle("POST /api/v1/admin/ledger/chain-breaks/{id}/annotate", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminLedger.AnnotateChainBreak)))))
+	r.Handle("GET /api/v1/admin/settings/login-challenge", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminSetting.LoginChallenge))))
+	r.Handle("PUT /api/v1/admin/settings/login-challenge", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminSetting.SetLoginChallenge)))))
+	r.Handle("GET /api/v1/admin/notification-templates", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminTemplate.List))))
+	r.Handle("GET /api/v1/admin/notification-templates/{name}/preview", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminTemplate.Preview))))
+	r.Handle("GET /api/v1/admin/analytics/corridors", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminAnalytics.Corridors))))
+	r.Handle("GET /api/v1/admin/providers/sla", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminProvider.SLA))))
+	r.Handle("GET /api/v1/admin/providers/failures", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminProvider.Failures))))
+	r.Handle("POST /api/v1/admin/settlements", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.settlement.Build)))))
+	r.Handle("GET /api/v1/admin/settlements", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.settlement.List))))
+	r.Handle("GET /api/v1/admin/settlements/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.settlement.Get))))
+	r.Handle("POST /api/v1/admin/settlements/{id}/close", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.settlement.Close)))))
+	r.Handle("GET /api/v1/admin/disputes", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.dispute.AdminList))))
+	r.Handle("GET /api/v1/admin/disputes/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.dispute.AdminGet))))
+	r.Handle("POST /api/v1/admin/disputes/{id}/respond", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.dispute.Respond)))))
+	r.Handle("POST /api/v1/admin/disputes/{id}/resolve", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.dispute.Resolve)))))
+	r.Handle("GET /api/v1/admin/denylist", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminScreening.ListEntries))))
+	r.Handle("POST /api/v1/admin/denylist", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminScreening.CreateEntry)))))
+	r.Handle("GET /api/v1/admin/denylist/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminScreening.GetEntry))))
+	r.Handle("PATCH /api/v1/admin/denylist/{id}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminScreening.UpdateEntry)))))
+	r.Handle("DELETE /api/v1/admin/denylist/{id}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminScreening.DeleteEntry)))))
+	r.Handle("GET /api/v1/admin/residency-rules", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminResidency.ListRules))))
+	r.Handle("POST /api/v1/admin/residency-rules", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminResidency.CreateRule)))))
+	r.Handle("GET /api/v1/admin/residency-rules/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminResidency.GetRule))))
+	r.Handle("PATCH /api/v1/admin/residency-rules/{id}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminResidency.UpdateRule)))))
+	r.Handle("DELETE /api/v1/admin/residency-rules/{id}", mw.auth(middleware.RequireAdmin(mw.audit(http.HandlerFunc(h.adminResidency.DeleteRule)))))
+	r.Handle("GET /api/v1/admin/qa-samples", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminQA.List))))
+	r.Handle("GET /api/v1/admin/qa-samples/{id}", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.adminQA.Get))))
+	r.Handle("POST /api/v1/admin/qa-samples/{id}/review", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.adminQA.Review)))))
+	r.Handle("GET /api/v1/admin/kyc/submissions", mw.auth(middleware.RequireStaff(http.HandlerFunc(h.kyc.AdminList))))
+	r.Handle("POST /api/v1/admin/kyc/submissions/{id}/review", mw.auth(middleware.RequireStaff(mw.audit(http.HandlerFunc(h.kyc.Review)))))
+	r.Handle("GET /api/v1/admin/audit-logs", mw.auth(middleware.RequireAdmin(http.HandlerFunc(h.adminAudit.List))))
+
+	r.HandleFunc("POST /api/v1/webhooks/provider", h.handshake.RequireVerified(h.webhook.ReceiveProviderWebhook))
+	r.HandleFunc("POST /api/v1/webhooks/provider/{provider}", h.handshake.RequireVerified(h.webhook.ReceiveProviderWebhook))
+	r.HandleFunc("GET /api/v1/webhooks/provider", h.handshake.Challenge)
+	r.HandleFunc("GET /api/v1/webhooks/provider/{provider}", h.handshake.Challenge)
+
+	return r
+}
+
+// newWorkerRouter is what a worker process serves: health checks for the
+// orchestrator and the metrics its processors record. The API isn't there.
+func newWorkerRouter(health *handler.HealthHandler, metrics http.Handler) *router {
+	r := &router{ServeMux: http.NewServeMux()}
+	r.HandleFunc("GET /health", health.Liveness)
+	r.HandleFunc("GET /health/ready", health.Readiness)
+	r.Handle("GET /metrics", metrics)
+	return r
+}
+
+// newMetricsRouter is what METRICS_ADDR serves in a process that runs the
+// API. Metrics carry per-corridor volumes and FX pool balances, so they stay
+// off the public router.
+func newMetricsRouter(metrics http.Handler) *router {
+	r := &router{ServeMux: http.NewServeMux()}
+	r.Handle("GET /metrics", metrics)
+	return r
+}
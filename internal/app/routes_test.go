@@ -0,0 +1,373 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+)
+
+// access is who a route admits. authorization_test.go turns it into the
+// expected status for each caller role.
+type access int
+
+const (
+	// public routes need no token.
+	public access = iota
+	// authed routes admit any signed-in caller; ownership of the resource
+	// (account, payment, hold, ...) is checked in the service.
+	authed
+	// owner routes are under /users/{id} and admit that user or an admin.
+	owner
+	// self routes are under /users/{id} and admit only that user, not even
+	// an admin. They mint or change the user's credentials.
+	self
+	// staff routes admit support and admin users.
+	staff
+	// admin routes admit admin users only, including payment_ops.
+	admin
+	// paymentOps routes admit the payment_ops role only.
+	paymentOps
+)
+
+// routeTable is the full public API surface. Adding, removing or moving a
+// route has to be reflected here, along with who may call it.
+var routeTable = []struct {
+	pattern string
+	access  access
+}{
+	{"GET /docs", public},
+	{"GET /docs/openapi.yaml", public},
+	{"GET /api/v1/openapi.json", public},
+	{"GET /docs/schema.graphql", public},
+	{"GET /health", public},
+	{"GET /health/ready", public},
+	{"GET /api/v1/auth/login-challenge", public},
+	{"POST /api/v1/auth/login", public},
+	{"POST /api/v1/auth/password-reset/request", public},
+	{"POST /api/v1/auth/password-reset/confirm", public},
+	{"GET /api/v1/users/{id}", owner},
+	{"POST /api/v1/users/{id}/accounts", owner},
+	{"GET /api/v1/users/{id}/accounts", owner},
+	{"PUT /api/v1/users/{id}/default-account", owner},
+	{"GET /api/v1/accounts/{id}/balance", authed},
+	{"GET /api/v1/accounts/{id}/transactions", authed},
+	{"POST /api/v1/accounts/{id}/close", authed},
+	{"POST /api/v1/accounts/{id}/holds", authed},
+	{"GET /api/v1/accounts/{id}/holds", authed},
+	{"POST /api/v1/holds/{id}/release", authed},
+	{"POST /api/v1/users/{id}/kyc", owner},
+	{"GET /api/v1/users/{id}/kyc", owner},
+	{"PUT /api/v1/users/{id}/unique-name", self},
+	{"PUT /api/v1/users/{id}/email", self},
+	{"GET /api/v1/users/{id}/identifier-history", owner},
+	{"GET /api/v1/users/{id}/digest-preferences", owner},
+	{"PUT /api/v1/users/{id}/digest-preferences", owner},
+	{"GET /api/v1/users/{id}/digests", owner},
+	{"GET /api/v1/users/{id}/digests/{digest_id}", owner},
+	{"POST /api/v1/users/{id}/api-keys", self},
+	{"GET /api/v1/users/{id}/api-keys", owner},
+	{"POST /api/v1/users/{id}/api-keys/{key_id}/rotate", self},
+	{"DELETE /api/v1/users/{id}/api-keys/{key_id}", owner},
+	{"GET /api/v1/users/{id}/webhook-endpoint", self},
+	{"PUT /api/v1/users/{id}/webhook-endpoint", self},
+	{"DELETE /api/v1/users/{id}/webhook-endpoint", owner},
+	{"POST /api/v1/users/{id}/webhook-endpoint/rotate-secret", self},
+	{"GET /api/v1/users/{id}/webhook-endpoint/deliveries", owner},
+	{"POST /api/v1/users/{id}/webhook-endpoint/test", owner},
+	{"POST /api/v1/users/{id}/beneficiaries", owner},
+	{"GET /api/v1/users/{id}/beneficiaries", owner},
+	{"DELETE /api/v1/users/{id}/beneficiaries/{beneficiary_id}", owner},
+	{"POST /api/v1/users/{id}/beneficiaries/{beneficiary_id}/restore", owner},
+	{"GET /api/v1/users/{id}/totp", owner},
+	{"POST /api/v1/users/{id}/totp", self},
+	{"POST /api/v1/users/{id}/totp/confirm", self},
+	{"DELETE /api/v1/users/{id}/totp", self},
+	{"GET /api/v1/recipients/{unique_name}", authed},
+	{"POST /api/v1/graphql", authed},
+	{"POST /api/v1/payments", authed},
+	{"POST /api/v1/payments/external", authed},
+	{"POST /api/v1/payments/preview", authed},
+	{"GET /api/v1/payments", authed},
+	{"GET /api/v1/payments/{id}", authed},
+	{"POST /api/v1/payments/{id}/retry", authed},
+	{"POST /api/v1/payments/{id}/refunds", authed},
+	{"GET /api/v1/payments/{id}/refunds", authed},
+	{"POST /api/v1/payments/{id}/disputes", authed},
+	{"GET /api/v1/disputes", authed},
+	{"GET /api/v1/disputes/{id}", authed},
+	{"GET /api/v1/notifications", authed},
+	{"POST /api/v1/notifications/{id}/read", authed},
+	{"POST /api/v1/notifications/read-all", authed},
+	{"POST /api/v1/payment-requests", authed},
+	{"GET /api/v1/payment-requests", authed},
+	{"GET /api/v1/payment-requests/{id}", authed},
+	{"POST /api/v1/payment-requests/{id}/accept", authed},
+	{"POST /api/v1/payment-requests/{id}/decline", authed},
+	{"POST /api/v1/payment-requests/{id}/cancel", authed},
+	{"GET /api/v1/fx/rates", authed},
+	{"GET /api/v1/fx/rates/history", authed},
+	{"GET /api/v1/admin/payments/review-queue", staff},
+	{"POST /api/v1/admin/payments/{id}/approve", admin},
+	{"POST /api/v1/admin/payments/{id}/reject", admin},
+	{"POST /api/v1/admin/payments/{id}/resolve", paymentOps},
+	{"POST /api/v1/admin/payments/{id}/reverse", admin},
+	{"GET /api/v1/admin/payments/{id}", staff},
+	{"POST /api/v1/admin/payments/{id}/notes", staff},
+	{"GET /api/v1/admin/payments/{id}/notes", staff},
+	{"POST /api/v1/admin/users/{id}/notes", staff},
+	{"GET /api/v1/admin/users/{id}/notes", staff},
+	{"GET /api/v1/admin/users/{id}/identifier-history", staff},
+	{"GET /api/v1/admin/users/{id}/limits", staff},
+	{"PUT /api/v1/admin/users/{id}/limits/{currency}", admin},
+	{"DELETE /api/v1/admin/users/{id}/limits/{currency}", admin},
+	{"PUT /api/v1/admin/users/{id}/tier", admin},
+	{"PUT /api/v1/admin/users/{id}/residency", admin},
+	{"GET /api/v1/admin/accounts/frozen", staff},
+	{"POST /api/v1/admin/accounts/{id}/freeze", admin},
+	{"POST /api/v1/admin/accounts/{id}/unfreeze", admin},
+	{"GET /api/v1/admin/fx/revenue", staff},
+	{"GET /api/v1/admin/fx/fees", staff},
+	{"GET /api/v1/admin/fx/pools", staff},
+	{"POST /api/v1/admin/fx/pools/transfer", admin},
+	{"PUT /api/v1/admin/fx/pools/{currency}/watermark", admin},
+	{"DELETE /api/v1/admin/fx/pools/{currency}/watermark", admin},
+	{"GET /api/v1/admin/webhooks/stats", staff},
+	{"GET /api/v1/admin/webhook-events", staff},
+	{"GET /api/v1/admin/webhook-events/{id}", staff},
+	{"POST /api/v1/admin/webhook-events/{id}/retry", staff},
+	{"GET /api/v1/admin/webhooks/registrations", staff},
+	{"GET /api/v1/admin/ledger/trial-balance", staff},
+	{"GET /api/v1/admin/ledger/chain-breaks", staff},
+	{"GET /api/v1/admin/ledger/chain-breaks/{id}", staff},
+	{"POST /api/v1/admin/ledger/chain-breaks/{id}/annotate", admin},
+	{"GET /api/v1/admin/settings/login-challenge", staff},
+	{"PUT /api/v1/admin/settings/login-challenge", admin},
+	{"GET /api/v1/admin/notification-templates", staff},
+	{"GET /api/v1/admin/notification-templates/{name}/preview", staff},
+	{"GET /api/v1/admin/analytics/corridors", staff},
+	{"GET /api/v1/admin/providers/sla", staff},
+	{"GET /api/v1/admin/providers/failures", staff},
+	{"POST /api/v1/admin/settlements", staff},
+	{"GET /api/v1/admin/settlements", staff},
+	{"GET /api/v1/admin/settlements/{id}", staff},
+	{"POST /api/v1/admin/settlements/{id}/close", admin},
+	{"GET /api/v1/admin/disputes", staff},
+	{"GET /api/v1/admin/disputes/{id}", staff},
+	{"POST /api/v1/admin/disputes/{id}/respond", staff},
+	{"POST /api/v1/admin/disputes/{id}/resolve", staff},
+	{"GET /api/v1/admin/denylist", staff},
+	{"POST /api/v1/admin/denylist", admin},
+	{"GET /api/v1/admin/denylist/{id}", staff},
+	{"PATCH /api/v1/admin/denylist/{id}", admin},
+	{"DELETE /api/v1/admin/denylist/{id}", admin},
+	{"GET /api/v1/admin/residency-rules", staff},
+	{"POST /api/v1/admin/residency-rules", admin},
+	{"GET /api/v1/admin/residency-rules/{id}", staff},
+	{"PATCH /api/v1/admin/residency-rules/{id}", admin},
+	{"DELETE /api/v1/admin/residency-rules/{id}", admin},
+	{"GET /api/v1/admin/qa-samples", staff},
+	{"GET /api/v1/admin/qa-samples/{id}", staff},
+	{"POST /api/v1/admin/qa-samples/{id}/review", staff},
+	{"GET /api/v1/admin/kyc/submissions", staff},
+	{"POST /api/v1/admin/kyc/submissions/{id}/review", staff},
+	{"GET /api/v1/admin/audit-logs", admin},
+	{"POST /api/v1/webhooks/provider", public},
+	{"POST /api/v1/webhooks/provider/{provider}", public},
+	{"GET /api/v1/webhooks/provider", public},
+	{"GET /api/v1/webhooks/provider/{provider}", public},
+}
+
+var pathParams = strings.NewReplacer(
+	"{id}", "7b0d0f0e-3c3a-4a55-9a49-0c6f7a3b5d21",
+	"{digest_id}", "0f8e3a52-6f0b-4d6e-8d7a-2b1c9e4f5a60",
+	"{key_id}", "5c2e9d7a-1b4f-4e8a-9c3d-6a7b8e9f0a12",
+	"{beneficiary_id}", "8d1f4c6b-2a7e-4f3d-b5c9-0e8a7d6c5b43",
+	"{currency}", "USD",
+	"{provider}", "mock_provider",
+	"{unique_name}", "alice",
+	"{name}", "payments_digest",
+)
+
+// apiKeyScopes are the routes API keys can call and the scope each needs.
+// Every other route takes a signed-in session only.
+var apiKeyScopes = map[string]domain.APIKeyScope{
+	"GET /api/v1/users/{id}":                     domain.APIKeyScopeRead,
+	"GET /api/v1/users/{id}/accounts":            domain.APIKeyScopeRead,
+	"GET /api/v1/accounts/{id}/balance":          domain.APIKeyScopeRead,
+	"GET /api/v1/accounts/{id}/transactions":     domain.APIKeyScopeRead,
+	"GET /api/v1/accounts/{id}/holds":            domain.APIKeyScopeRead,
+	"GET /api/v1/recipients/{unique_name}":       domain.APIKeyScopeRead,
+	"POST /api/v1/graphql":                       domain.APIKeyScopeRead,
+	"GET /api/v1/payments":                       domain.APIKeyScopeRead,
+	"GET /api/v1/payments/{id}":                  domain.APIKeyScopeRead,
+	"GET /api/v1/payments/{id}/refunds":          domain.APIKeyScopeRead,
+	"GET /api/v1/payment-requests":               domain.APIKeyScopeRead,
+	"GET /api/v1/payment-requests/{id}":          domain.APIKeyScopeRead,
+	"GET /api/v1/fx/rates":                       domain.APIKeyScopeRead,
+	"GET /api/v1/fx/rates/history":               domain.APIKeyScopeRead,
+	"GET /api/v1/users/{id}/beneficiaries":       domain.APIKeyScopeRead,
+	"POST /api/v1/payments/preview":              domain.APIKeyScopeRead,
+	"POST /api/v1/payments":                      domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payments/external":             domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payments/{id}/retry":           domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payments/{id}/refunds":         domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/accounts/{id}/holds":           domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/holds/{id}/release":            domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payment-requests":              domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payment-requests/{id}/accept":  domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payment-requests/{id}/decline": domain.APIKeyScopePaymentsWrite,
+	"POST /api/v1/payment-requests/{id}/cancel":  domain.APIKeyScopePaymentsWrite,
+}
+
+func newTestRouter() *router {
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	// apiKey denies too, and says which scope the route asked for.
+	apiKey := func(scope domain.APIKeyScope) func(http.Handler) http.Handler {
+		return func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test-Scope", string(scope))
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		}
+	}
+	pass := func(next http.Handler) http.Handler { return next }
+	return newRouter(routeHandlers{}, routeMiddleware{
+		auth:                deny,
+		apiKey:              apiKey,
+		idempotency:         pass,
+		optionalIdempotency: pass,
+		loginLimit:          pass,
+		paymentLimit:        pass,
+		audit:               pass,
+	})
+}
+
+func TestRouter_RegistersFullRouteTable(t *testing.T) {
+	r := newTestRouter()
+
+	want := make([]string, len(routeTable))
+	for i, rt := range routeTable {
+		want[i] = rt.pattern
+	}
+	assert.ElementsMatch(t, want, r.patterns)
+}
+
+func TestWorkerRouter_ServesOnlyHealthAndMetrics(t *testing.T) {
+	r := newWorkerRouter(handler.NewHealthHandler(nil), http.NotFoundHandler())
+
+	assert.ElementsMatch(t, []string{"GET /health", "GET /health/ready", "GET /metrics"}, r.patterns)
+
+	_, pattern := r.Handler(httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+	assert.Empty(t, pattern)
+}
+
+// Metrics carry FX pool balances that only staff may read through the API,
+// so the public router must not answer for them.
+func TestRouter_DoesNotServeMetrics(t *testing.T) {
+	r := newTestRouter()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMetricsRouter_ServesOnlyMetrics(t *testing.T) {
+	r := newMetricsRouter(http.NotFoundHandler())
+
+	assert.Equal(t, []string{"GET /metrics"}, r.patterns)
+}
+
+func TestRouter_ResolvesEveryRoute(t *testing.T) {
+	r := newTestRouter()
+
+	for _, rt := range routeTable {
+		t.Run(rt.pattern, func(t *testing.T) {
+			method, path, _ := strings.Cut(rt.pattern, " ")
+			req := httptest.NewRequest(method, pathParams.Replace(path), nil)
+
+			_, matched := r.Handler(req)
+			require.Equal(t, rt.pattern, matched)
+
+			if rt.access == public {
+				return
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, "route must sit behind auth")
+		})
+	}
+}
+
+func TestRouter_APIKeyScopes(t *testing.T) {
+	r := newTestRouter()
+
+	for _, rt := range routeTable {
+		if rt.access == public {
+			continue
+		}
+		t.Run(rt.pattern, func(t *testing.T) {
+			method, path, _ := strings.Cut(rt.pattern, " ")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(method, pathParams.Replace(path), nil))
+			assert.Equal(t, string(apiKeyScopes[rt.pattern]), rec.Header().Get("X-Test-Scope"))
+		})
+	}
+}
+
+func TestRouter_ExternalPayoutsAreNotPaymentIDs(t *testing.T) {
+	r := newTestRouter()
+
+	_, matched := r.Handler(httptest.NewRequest(http.MethodPost, "/api/v1/payments/external", nil))
+	assert.Equal(t, "POST /api/v1/payments/external", matched)
+
+	_, matched = r.Handler(httptest.NewRequest(http.MethodPost, "/api/v1/payments/preview", nil))
+	assert.Equal(t, "POST /api/v1/payments/preview", matched)
+}
+
+// TestRouter_AuditsAdminChanges checks every admin route that changes
+// something goes through the audit middleware.
+func TestRouter_AuditsAdminChanges(t *testing.T) {
+	pass := func(next http.Handler) http.Handler { return next }
+	audited := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Test-Audited", "true")
+		})
+	}
+	r := newRouter(routeHandlers{}, routeMiddleware{
+		auth:                pass,
+		apiKey:              func(domain.APIKeyScope) func(http.Handler) http.Handler { return pass },
+		idempotency:         pass,
+		optionalIdempotency: pass,
+		loginLimit:          pass,
+		paymentLimit:        pass,
+		audit:               audited,
+	})
+	// payment_ops passes every admin gate, including its own.
+	ctx := auth.ContextWithRole(auth.ContextWithUserID(context.Background(), uuid.New()), domain.UserRolePaymentOps)
+
+	for _, rt := range routeTable {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		if method == http.MethodGet || !strings.HasPrefix(path, "/api/v1/admin/") {
+			continue
+		}
+		t.Run(rt.pattern, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, method, pathParams.Replace(path), nil))
+			assert.Equal(t, "true", rec.Header().Get("X-Test-Audited"))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := NewFSStore(t.TempDir())
+
+	require.NoError(t, store.Put(ctx, "payment_events/2026/10/16/batch.ndjson.gz", strings.NewReader("hello")))
+
+	rc, err := store.Get(ctx, "payment_events/2026/10/16/batch.ndjson.gz")
+	require.NoError(t, err)
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	_, err = store.Get(ctx, "payment_events/missing")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+// s3Env points the SDK's default credential chain at the environment only,
+// so the tests don't read the machine's AWS config or reach for IMDS.
+func s3Env(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_CA_BUNDLE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+}
+
+// fakeS3 stores objects by path and records the last request's headers.
+func fakeS3(t *testing.T) (*httptest.Server, map[string]string, *http.Header) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	var last http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		last = r.Header.Clone()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+				return
+			}
+			io.WriteString(w, body)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, objects, &last
+}
+
+func TestS3Store_PutGet(t *testing.T) {
+	s3Env(t)
+	srv, objects, _ := fakeS3(t)
+
+	ctx := context.Background()
+	store, err := NewS3Store(ctx, S3Config{Endpoint: srv.URL, Region: "eu-west-1", Bucket: "archive", AccessKeyID: "id", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "payment_events/a.ndjson.gz", strings.NewReader("data")))
+	assert.Equal(t, "data", objects["/archive/payment_events/a.ndjson.gz"], "path-style, body sent as is")
+
+	rc, err := store.Get(ctx, "payment_events/a.ndjson.gz")
+	require.NoError(t, err)
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "data", string(got))
+
+	_, err = store.Get(ctx, "payment_events/missing")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+// Without static keys the default chain is used, and temporary credentials
+// carry their session token.
+func TestS3Store_DefaultChainSessionToken(t *testing.T) {
+	s3Env(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "ASIATEMPORARY")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session-token")
+	srv, _, last := fakeS3(t)
+
+	ctx := context.Background()
+	store, err := NewS3Store(ctx, S3Config{Endpoint: srv.URL, Region: "eu-west-1", Bucket: "archive"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "payment_events/b.ndjson.gz", strings.NewReader("data")))
+	assert.Equal(t, "session-token", last.Get("X-Amz-Security-Token"))
+	assert.Contains(t, last.Get("Authorization"), "Credential=ASIATEMPORARY/")
+}
@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FSStore keeps objects as files under a root directory. It suits a single
+// instance or a shared volume; multi-instance deployments use S3Store.
+type FSStore struct {
+	root string
+}
+
+func NewFSStore(root string) *FSStore {
+	return &FSStore{root: root}
+}
+
+// Put writes to a temporary file and renames it into place, so a reader
+// never sees a partial object.
+func (s *FSStore) Put(_ context.Context, key string, body io.Reader) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("FSStore.Put: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("FSStore.Put: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("FSStore.Put: write: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("FSStore.Put: sync: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("FSStore.Put: close: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("FSStore.Put: rename: %w", err)
+	}
+	return nil
+}
+
+func (s *FSStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("FSStore.Get: %s: %w", key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("FSStore.Get: %w", err)
+	}
+	return f, nil
+}
+
+func (s *FSStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
@@ -0,0 +1,107 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Config names the bucket and, optionally, where it lives and who signs
+// for it.
+type S3Config struct {
+	// Endpoint is the service URL of an S3-compatible store (MinIO, R2,
+	// ...), addressed with path-style URLs. Empty uses AWS's own endpoint
+	// for Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKeyID and SecretAccessKey are static credentials. Without them
+	// the SDK's default chain is used: environment, shared config, web
+	// identity (IRSA), then the ECS or EC2 instance role, with session
+	// tokens refreshed as they expire.
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store keeps objects in an S3 bucket through the AWS SDK.
+type S3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Store loads the region's config and credentials and builds the
+// client. Credentials from the default chain are fetched on first use.
+func NewS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(cfg.Region)}
+	if cfg.AccessKeyID != "" || cfg.SecretAccessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("NewS3Store: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &S3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader) error {
+	// PutObject needs the length up front, and a seekable body so a retry
+	// can send it again.
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("S3Store.Put: read body: %w", err)
+	}
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return fmt.Errorf("S3Store.Put: %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("S3Store.Get: %s: %w", key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("S3Store.Get: %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+// isNotFound reports whether err is a missing object. Some S3-compatible
+// stores answer a bare 404 instead of the NoSuchKey error code.
+func isNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+	var resp *awshttp.ResponseError
+	return errors.As(err, &resp) && resp.HTTPStatusCode() == http.StatusNotFound
+}
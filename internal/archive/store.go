@@ -0,0 +1,19 @@
+// Package archive stores cold data as objects outside Postgres. Stores are
+// write-once: an object is written in full under a key and read back whole.
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrObjectNotFound = errors.New("archive object not found")
+
+// Store is an object store. Keys are slash-separated paths made of
+// [a-z0-9._-] segments.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader) error
+	// Get returns ErrObjectNotFound for a key that was never written.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
 type userIDKey struct{}
 
+type roleKey struct{}
+
+type clientIPKey struct{}
+
 func ContextWithUserID(ctx context.Context, id uuid.UUID) context.Context {
 	return context.WithValue(ctx, userIDKey{}, id)
 }
@@ -16,3 +22,25 @@ func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	id, ok := ctx.Value(userIDKey{}).(uuid.UUID)
 	return id, ok
 }
+
+func ContextWithRole(ctx context.Context, role domain.UserRole) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+func RoleFromContext(ctx context.Context) domain.UserRole {
+	if role, ok := ctx.Value(roleKey{}).(domain.UserRole); ok {
+		return role
+	}
+	return domain.UserRoleUser
+}
+
+// ContextWithClientIP records the address the request came from, for
+// services that log who did what from where.
+func ContextWithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -6,28 +6,39 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
 type Claims struct {
 	UserID uuid.UUID
 	Email  string
+	Role   domain.UserRole
+	// SessionVersion is the user's session version when the token was
+	// issued. Tokens from before the claim existed read as 0.
+	SessionVersion int
+	IssuedAt       time.Time
 }
 
 type tokenClaims struct {
 	jwt.RegisteredClaims
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID         string `json:"user_id"`
+	Email          string `json:"email"`
+	Role           string `json:"role,omitempty"`
+	SessionVersion int    `json:"sv,omitempty"`
 }
 
-func GenerateToken(userID uuid.UUID, email string, secret string, expiry time.Duration) (string, error) {
+func GenerateToken(userID uuid.UUID, email string, role domain.UserRole, sessionVersion int, secret string, expiry time.Duration) (string, error) {
 	now := time.Now()
 	claims := tokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
-		UserID: userID.String(),
-		Email:  email,
+		UserID:         userID.String(),
+		Email:          email,
+		Role:           string(role),
+		SessionVersion: sessionVersion,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -59,8 +70,19 @@ func ValidateToken(tokenString string, secret string) (*Claims, error) {
 		return nil, fmt.Errorf("ValidateToken: invalid user_id in token: %w", err)
 	}
 
-	return &Claims{
-		UserID: userID,
-		Email:  tc.Email,
-	}, nil
+	role := domain.UserRole(tc.Role)
+	if !role.IsValid() {
+		role = domain.UserRoleUser
+	}
+
+	claims := &Claims{
+		UserID:         userID,
+		Email:          tc.Email,
+		Role:           role,
+		SessionVersion: tc.SessionVersion,
+	}
+	if tc.IssuedAt != nil {
+		claims.IssuedAt = tc.IssuedAt.Time
+	}
+	return claims, nil
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
 const testSecret = "test-jwt-secret"
@@ -16,7 +18,7 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	userID := uuid.New()
 	email := "user@test.com"
 
-	token, err := GenerateToken(userID, email, testSecret, 24*time.Hour)
+	token, err := GenerateToken(userID, email, domain.UserRoleSupport, 3, testSecret, 24*time.Hour)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -24,16 +26,27 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, email, claims.Email)
+	assert.Equal(t, domain.UserRoleSupport, claims.Role)
+	assert.Equal(t, 3, claims.SessionVersion)
+}
+
+func TestValidateToken_DefaultsMissingRoleToUser(t *testing.T) {
+	token, err := GenerateToken(uuid.New(), "user@test.com", "", 0, testSecret, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(token, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserRoleUser, claims.Role)
 }
 
 func TestValidateToken(t *testing.T) {
 	userID := uuid.New()
 	email := "user@test.com"
 
-	validToken, err := GenerateToken(userID, email, testSecret, 24*time.Hour)
+	validToken, err := GenerateToken(userID, email, domain.UserRoleUser, 0, testSecret, 24*time.Hour)
 	require.NoError(t, err)
 
-	expiredToken, err := GenerateToken(userID, email, testSecret, -1*time.Hour)
+	expiredToken, err := GenerateToken(userID, email, domain.UserRoleUser, 0, testSecret, -1*time.Hour)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "t=%d", tt.unix)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	prev, err := TOTPCode(rfcSecret, step-1)
+	require.NoError(t, err)
+
+	got, ok := VerifyTOTP(rfcSecret, "050471", now, 1, 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	got, ok = VerifyTOTP(rfcSecret, prev, now, 1, 0)
+	assert.True(t, ok, "the previous step is accepted for clock drift")
+	assert.Equal(t, step-1, got)
+
+	_, ok = VerifyTOTP(rfcSecret, prev, now, 0, 0)
+	assert.False(t, ok)
+
+	_, ok = VerifyTOTP(rfcSecret, "050471", now, 1, step)
+	assert.False(t, ok, "a step already used is rejected")
+
+	_, ok = VerifyTOTP(rfcSecret, "000000", now, 1, 0)
+	assert.False(t, ok)
+	_, ok = VerifyTOTP(rfcSecret, "50471", now, 1, 0)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	b, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 32)
+
+	_, err = TOTPCode(a, 1)
+	assert.NoError(t, err)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Grey", "alice@test.com", rfcSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Grey:alice@test.com?"), uri)
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=Grey")
+}
@@ -0,0 +1,216 @@
+// Package capture records full request/response pairs to a directory for
+// reproducing client-reported issues, and replays them against a server.
+// It is a development tool: the app refuses to capture in production.
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBody is the most of a request or response body kept in a capture.
+// Longer bodies are cut and the exchange marked truncated.
+const MaxBody = 1 << 20
+
+// Redacted replaces credentials in captured headers and bodies.
+const Redacted = "REDACTED"
+
+// Exchange is one captured request and the response it got.
+type Exchange struct {
+	ID         uuid.UUID `json:"id"`
+	CapturedAt time.Time `json:"captured_at"`
+	RequestID  string    `json:"request_id,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	// URL is the path and query the client sent.
+	URL       string          `json:"url"`
+	Header    http.Header     `json:"header"`
+	Body      json.RawMessage `json:"body,omitempty"`
+	RawBody   []byte          `json:"raw_body,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+type Response struct {
+	Status    int             `json:"status"`
+	Header    http.Header     `json:"header"`
+	Body      json.RawMessage `json:"body,omitempty"`
+	RawBody   []byte          `json:"raw_body,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// SetBody stores a request body: JSON as JSON with credentials redacted,
+// anything else (a gzipped webhook, say) as base64 in raw_body.
+func (r *Request) SetBody(body []byte) {
+	r.Body, r.RawBody, r.Truncated = encodeBody(body)
+}
+
+// SetBody stores a response body the same way as Request.SetBody.
+func (r *Response) SetBody(body []byte) {
+	r.Body, r.RawBody, r.Truncated = encodeBody(body)
+}
+
+// BodyBytes is the request body as it will be replayed. JSON is compacted
+// again, since the capture file is indented.
+func (r *Request) BodyBytes() []byte {
+	if len(r.Body) > 0 {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, r.Body); err == nil {
+			return buf.Bytes()
+		}
+		return r.Body
+	}
+	return r.RawBody
+}
+
+func encodeBody(body []byte) (json.RawMessage, []byte, bool) {
+	truncated := len(body) > MaxBody
+	if truncated {
+		body = body[:MaxBody]
+	}
+	if len(body) == 0 {
+		return nil, nil, truncated
+	}
+	if !truncated && json.Valid(body) {
+		var v any
+		if err := json.Unmarshal(body, &v); err == nil {
+			if out, err := json.Marshal(redactJSON(v)); err == nil {
+				return out, nil, false
+			}
+		}
+	}
+	return nil, body, truncated
+}
+
+// sensitiveHeaders are replaced with Redacted. A replay supplies its own
+// credentials.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// RedactHeader copies h with credentials replaced.
+func RedactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	if out == nil {
+		out = http.Header{}
+	}
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, Redacted)
+		}
+	}
+	return out
+}
+
+// sensitiveField reports whether a JSON field holds a credential: passwords,
+// secrets and tokens, whatever they are prefixed with.
+func sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"password", "secret", "token"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, inner := range v {
+			if sensitiveField(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = redactJSON(inner)
+			}
+		}
+		return v
+	case []any:
+		for i, inner := range v {
+			v[i] = redactJSON(inner)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Dir keeps each exchange as a JSON file in a directory, named so a plain
+// listing is in capture order.
+type Dir struct {
+	root string
+}
+
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// Save writes the exchange and returns the file it went to.
+func (d *Dir) Save(e *Exchange) (string, error) {
+	if err := os.MkdirAll(d.root, 0o750); err != nil {
+		return "", fmt.Errorf("Dir.Save: %w", err)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("Dir.Save: marshal: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.json", e.CapturedAt.UTC().Format("20060102T150405.000000000Z"), e.ID)
+	path := filepath.Join(d.root, name)
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", fmt.Errorf("Dir.Save: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads one capture file.
+func Load(path string) (*Exchange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+	var e Exchange
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("Load: %s: %w", path, err)
+	}
+	if e.Request.Method == "" || e.Request.URL == "" {
+		return nil, fmt.Errorf("Load: %s: %w", path, errNotCapture)
+	}
+	return &e, nil
+}
+
+var errNotCapture = errors.New("not a capture file")
+
+// Files expands the paths given to it: files are kept as they are and
+// directories are replaced by the capture files in them, oldest first.
+func Files(paths []string) ([]string, error) {
+	var out []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("Files: %w", err)
+		}
+		if !info.IsDir() {
+			out = append(out, p)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(p, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("Files: %w", err)
+		}
+		sort.Strings(matches)
+		out = append(out, matches...)
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir_SaveLoadReplay(t *testing.T) {
+	dir := NewDir(t.TempDir())
+	captured := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	var paths []string
+	for i, body := range []string{`{"amount":100}`, "\x1f\x8b not json"} {
+		e := &Exchange{
+			ID:         uuid.New(),
+			CapturedAt: captured.Add(time.Duration(i) * time.Second),
+			Request: Request{
+				Method: http.MethodPost,
+				URL:    "/api/v1/transfers?x=1",
+				Header: RedactHeader(http.Header{
+					"Authorization":   {"Bearer old"},
+					"Idempotency-Key": {"k1"},
+					"Content-Length":  {"14"},
+				}),
+			},
+			Response: Response{Status: http.StatusCreated},
+		}
+		e.Request.SetBody([]byte(body))
+		path, err := dir.Save(e)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+
+	// The directory lists in capture order, and stray files are rejected.
+	files, err := Files([]string{filepath.Dir(paths[0])})
+	require.NoError(t, err)
+	assert.Equal(t, paths, files)
+	stray := filepath.Join(t.TempDir(), "stray.json")
+	require.NoError(t, os.WriteFile(stray, []byte(`{}`), 0o600))
+	_, err = Load(stray)
+	assert.ErrorIs(t, err, errNotCapture)
+
+	type received struct {
+		method, url, auth, key, body string
+	}
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, received{r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), r.Header.Get("Idempotency-Key"), string(b)})
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer srv.Close()
+
+	replayer := NewReplayer(srv.Client(), srv.URL+"/", "new")
+	for _, path := range files {
+		e, err := Load(path)
+		require.NoError(t, err)
+		res, err := replayer.Replay(context.Background(), e)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, res.Status)
+		assert.True(t, res.StatusChanged())
+	}
+
+	require.Len(t, got, 2)
+	assert.Equal(t, received{http.MethodPost, "/api/v1/transfers?x=1", "Bearer new", "k1", `{"amount":100}`}, got[0])
+	assert.Equal(t, "\x1f\x8b not json", got[1].body, "bodies that aren't JSON are replayed byte for byte")
+}
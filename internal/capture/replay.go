@@ -0,0 +1,70 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Result is what a replayed request got back.
+type Result struct {
+	Exchange *Exchange
+	Status   int
+	Body     []byte
+}
+
+// StatusChanged reports whether the replay got a different status from the
+// one captured.
+func (r *Result) StatusChanged() bool {
+	return r.Status != r.Exchange.Response.Status
+}
+
+// Replayer re-issues captured requests against a server.
+type Replayer struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+// NewReplayer sends to baseURL (e.g. http://localhost:8080). Captured
+// credentials are redacted, so token, when set, is sent as the bearer
+// token of every request instead.
+func NewReplayer(client *http.Client, baseURL, token string) *Replayer {
+	return &Replayer{client: client, baseURL: strings.TrimRight(baseURL, "/"), token: token}
+}
+
+// Replay sends e's request with its captured headers and body. Redacted
+// headers are left out rather than sent as the placeholder.
+func (r *Replayer) Replay(ctx context.Context, e *Exchange) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, e.Request.Method, r.baseURL+e.Request.URL, bytes.NewReader(e.Request.BodyBytes()))
+	if err != nil {
+		return nil, fmt.Errorf("Replay: %w", err)
+	}
+	for name, values := range e.Request.Header {
+		if len(values) == 1 && values[0] == Redacted {
+			continue
+		}
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	// Set by the transport from the body actually sent.
+	req.Header.Del("Content-Length")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Replay: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBody))
+	if err != nil {
+		return nil, fmt.Errorf("Replay: read body: %w", err)
+	}
+	return &Result{Exchange: e, Status: resp.StatusCode, Body: body}, nil
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	env "github.com/caarlos0/env/v11"
 )
@@ -10,21 +15,288 @@ type Config struct {
 	DatabaseURL     string  `env:"DATABASE_URL,required"`
 	JWTSecret       string  `env:"JWT_SECRET,required"`
 	FXSpreadPct     float64 `env:"FX_SPREAD_PCT" envDefault:"0.005"`
+	// FXBaseCurrency is the currency cross rates are derived through for
+	// pairs without a rate of their own.
+	FXBaseCurrency string `env:"FX_BASE_CURRENCY" envDefault:"USD"`
 	MockProviderURL    string  `env:"MOCK_PROVIDER_URL" envDefault:"http://mock-provider:8081"`
 	WebhookCallbackURL string  `env:"WEBHOOK_CALLBACK_URL" envDefault:"http://app:8080/api/v1/webhooks/provider"`
 	WebhookSecret      string  `env:"WEBHOOK_SECRET,required"`
+
+	DefaultProvider        string            `env:"DEFAULT_PROVIDER" envDefault:"mock_provider"`
+	ProviderURLs           map[string]string `env:"PROVIDER_URLS" envKeyValSeparator:"="`
+	ProviderRoutes         map[string]string `env:"PROVIDER_ROUTES" envKeyValSeparator:"="`
+	ProviderWebhookSecrets map[string]string `env:"PROVIDER_WEBHOOK_SECRETS" envKeyValSeparator:"="`
+	WebhookToleranceS      int               `env:"WEBHOOK_TOLERANCE_S" envDefault:"0"`
+
+	WebhookMaxBodyBytes         int64            `env:"WEBHOOK_MAX_BODY_BYTES" envDefault:"1048576"`
+	ProviderWebhookMaxBodyBytes map[string]int64 `env:"PROVIDER_WEBHOOK_MAX_BODY_BYTES" envKeyValSeparator:"="`
+	WebhookCompressAboveBytes   int              `env:"WEBHOOK_COMPRESS_ABOVE_BYTES" envDefault:"16384"`
+
+	// Callbacks for payments at or above these amounts are processed first
+	// during a backlog. 0 turns the high class off for a currency.
+	WebhookPriorityHighAmounts map[string]int64 `env:"WEBHOOK_PRIORITY_HIGH_AMOUNTS" envKeyValSeparator:"="`
+	// WebhookPriorityAgingS promotes a pending callback one class for every
+	// this many seconds it waits, so low ones still get through. 0 disables it.
+	WebhookPriorityAgingS int `env:"WEBHOOK_PRIORITY_AGING_S" envDefault:"30"`
+
+	// WebhookListen wakes the webhook processor with LISTEN/NOTIFY when an
+	// event is stored. Polling then only runs every WebhookSweepIntervalS as
+	// a fallback; without it the processor polls every second.
+	WebhookListen         bool `env:"WEBHOOK_LISTEN" envDefault:"true"`
+	WebhookSweepIntervalS int  `env:"WEBHOOK_SWEEP_INTERVAL_S" envDefault:"15"`
+	// WebhookConcurrency is how many webhook events are processed at once.
+	// A payment's own events are still processed one at a time.
+	WebhookConcurrency int `env:"WEBHOOK_CONCURRENCY" envDefault:"4"`
+	// WebhookLeaseS is how long a processor holds the events it claims. An
+	// instance that dies mid-batch leaves them to the others after this.
+	WebhookLeaseS int `env:"WEBHOOK_LEASE_S" envDefault:"60"`
+
+	// WebhookHandshakeProviders must complete the callback handshake before
+	// their events are accepted.
+	WebhookHandshakeProviders []string `env:"WEBHOOK_HANDSHAKE_PROVIDERS" envSeparator:","`
+
+	// TrustedProxies are the CIDRs of proxies in front of the API. The
+	// client IP is taken from their X-Forwarded-For; with none it is the
+	// connection's remote address.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
 	Port            int     `env:"PORT" envDefault:"8080"`
 	LogLevel        string  `env:"LOG_LEVEL" envDefault:"info"`
 	AppEnv          string  `env:"APP_ENV" envDefault:"production"`
 
-	TxLimitUSD int64 `env:"TX_LIMIT_USD" envDefault:"10000000"`
-	TxLimitEUR int64 `env:"TX_LIMIT_EUR" envDefault:"9000000"`
-	TxLimitGBP int64 `env:"TX_LIMIT_GBP" envDefault:"8000000"`
+	// CaptureRoutes saves each request whose path starts with one of these
+	// prefixes, with its response, to CaptureDir for cmd/replay. It is for
+	// reproducing issues locally; the app won't start with it in production.
+	CaptureRoutes []string `env:"CAPTURE_ROUTES" envSeparator:","`
+	CaptureDir    string   `env:"CAPTURE_DIR" envDefault:"captures"`
+
+	// Currencies are the currencies accounts can be opened in, out of the
+	// registered ones. Each per-currency amount is a CODE=amount map, e.g.
+	// TX_LIMITS=USD=10000000,NGN=15000000000, whose entries override the
+	// defaults in currencyAmounts currency by currency.
+	Currencies []string `env:"CURRENCIES" envSeparator:"," envDefault:"USD,EUR,GBP,NGN,KES,GHS"`
+	// CurrencySpecs registers currencies, or changes registered ones, as
+	// CODE=exponent:country, e.g. JPY=0:JP. See domain.RegisterCurrencies.
+	CurrencySpecs map[string]string `env:"CURRENCY_SPECS" envKeyValSeparator:"="`
+	// FXRates are the mid-market rates, FROM_TO=rate. Pairs left out are
+	// quoted through FXBaseCurrency when both legs are here.
+	FXRates map[string]string `env:"FX_RATES" envKeyValSeparator:"=" envDefault:"USD_EUR=0.92,EUR_USD=1.087,USD_GBP=0.79,GBP_USD=1.266,EUR_GBP=0.858,GBP_EUR=1.166,USD_NGN=1550,NGN_USD=0.000645,USD_KES=129.5,KES_USD=0.00772,USD_GHS=15.4,GHS_USD=0.0649"`
+
+	TxLimits map[string]int64 `env:"TX_LIMITS" envKeyValSeparator:"="`
+
+	KYCReducedLimitPct int `env:"KYC_REDUCED_LIMIT_PCT" envDefault:"20"`
+
+	FXSpreadPctPlus      float64 `env:"FX_SPREAD_PCT_PLUS" envDefault:"0.0035"`
+	FXSpreadPctBusiness  float64 `env:"FX_SPREAD_PCT_BUSINESS" envDefault:"0.002"`
+	TierLimitPctPlus     int     `env:"TIER_LIMIT_PCT_PLUS" envDefault:"200"`
+	TierLimitPctBusiness int     `env:"TIER_LIMIT_PCT_BUSINESS" envDefault:"500"`
+
+	ReviewThresholds map[string]int64 `env:"REVIEW_THRESHOLDS" envKeyValSeparator:"="`
+
+	HandleReclaimCooldownD int `env:"HANDLE_RECLAIM_COOLDOWN_D" envDefault:"30"`
+	HandleReassignWarningD int `env:"HANDLE_REASSIGN_WARNING_D" envDefault:"90"`
+
+	APIKeyMaxActive int `env:"API_KEY_MAX_ACTIVE" envDefault:"10"`
+
+	// Failed logins within the window lock the email address or client IP
+	// out for LoginLockoutM minutes. 0 disables that check.
+	LoginMaxFailures      int `env:"LOGIN_MAX_FAILURES" envDefault:"5"`
+	LoginMaxFailuresPerIP int `env:"LOGIN_MAX_FAILURES_PER_IP" envDefault:"20"`
+	LoginFailureWindowM   int `env:"LOGIN_FAILURE_WINDOW_M" envDefault:"15"`
+	LoginLockoutM         int `env:"LOGIN_LOCKOUT_M" envDefault:"15"`
+
+	// Login challenges are turned on at runtime by staff. Proof-of-work
+	// challenges are signed with LoginChallengeSecret (JWT_SECRET when unset)
+	// and must be solved within LoginChallengeTTLS. The CAPTCHA mode is only
+	// available with a CaptchaSecret; CaptchaVerifyURL takes a
+	// siteverify-style form POST, as hCaptcha and Turnstile do.
+	LoginChallengeSecret string `env:"LOGIN_CHALLENGE_SECRET"`
+	LoginChallengeTTLS   int    `env:"LOGIN_CHALLENGE_TTL_S" envDefault:"120"`
+	CaptchaVerifyURL     string `env:"CAPTCHA_VERIFY_URL" envDefault:"https://hcaptcha.com/siteverify"`
+	CaptchaSiteKey       string `env:"CAPTCHA_SITE_KEY"`
+	CaptchaSecret        string `env:"CAPTCHA_SECRET"`
+
+	// PasswordResetURL is the page reset emails link to; the token is
+	// appended to it.
+	PasswordResetURL  string `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:3000/reset-password?token="`
+	PasswordResetTTLM int    `env:"PASSWORD_RESET_TTL_M" envDefault:"30"`
+	// SessionCacheTTLS is how long the auth middleware keeps a user's
+	// session version, so how long a password reset can take to end
+	// sessions on every instance. 0 reads it on every request.
+	SessionCacheTTLS int `env:"SESSION_CACHE_TTL_S" envDefault:"30"`
+
+	// EmailBackend is log or smtp. With smtp, notifications are emailed as
+	// well as logged to the feed; SMTPRequireTLS refuses a server without
+	// STARTTLS.
+	EmailBackend               string `env:"EMAIL_BACKEND" envDefault:"log"`
+	EmailFrom                  string `env:"EMAIL_FROM" envDefault:"Grey <no-reply@grey.local>"`
+	SMTPHost                   string `env:"SMTP_HOST" envDefault:"localhost"`
+	SMTPPort                   int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername               string `env:"SMTP_USERNAME"`
+	SMTPPassword               string `env:"SMTP_PASSWORD"`
+	SMTPRequireTLS             bool   `env:"SMTP_REQUIRE_TLS" envDefault:"true"`
+	SMTPTimeoutMS              int    `env:"SMTP_TIMEOUT_MS" envDefault:"10000"`
+	NotificationEmailIntervalS int    `env:"NOTIFICATION_EMAIL_INTERVAL_S" envDefault:"15"`
+
+	// TOTPEncryptionKey encrypts stored authenticator secrets: 32 bytes, hex
+	// encoded. Unset, a key is derived from JWTSecret.
+	TOTPEncryptionKey string `env:"TOTP_ENCRYPTION_KEY"`
+	TOTPIssuer        string `env:"TOTP_ISSUER" envDefault:"Grey"`
+
+	// Webhooks to users' own endpoints. A failed delivery is retried after
+	// WebhookDeliveryBackoffS, doubling each time, until it has been tried
+	// WebhookDeliveryMaxAttempts times. WebhookEndpointEncryptionKey
+	// encrypts the stored signing secrets: 32 bytes, hex encoded; unset, a
+	// key is derived from JWTSecret. WebhookEndpointAllowPrivate lets
+	// endpoints use plain http and private addresses, for receivers on a
+	// developer's machine; the app won't start with it in production.
+	WebhookDeliveryIntervalS     int    `env:"WEBHOOK_DELIVERY_INTERVAL_S" envDefault:"5"`
+	WebhookDeliveryBackoffS      int    `env:"WEBHOOK_DELIVERY_BACKOFF_S" envDefault:"30"`
+	WebhookDeliveryMaxAttempts   int    `env:"WEBHOOK_DELIVERY_MAX_ATTEMPTS" envDefault:"8"`
+	WebhookDeliveryTimeoutMS     int    `env:"WEBHOOK_DELIVERY_TIMEOUT_MS" envDefault:"5000"`
+	WebhookEndpointEncryptionKey string `env:"WEBHOOK_ENDPOINT_ENCRYPTION_KEY"`
+	WebhookEndpointAllowPrivate  bool   `env:"WEBHOOK_ENDPOINT_ALLOW_PRIVATE" envDefault:"false"`
+
+	// External payouts at or above these amounts need a code from the
+	// sender's authenticator app. 0 disables the step-up for a currency.
+	TOTPStepUps map[string]int64 `env:"TOTP_STEP_UPS" envKeyValSeparator:"="`
+
+	DailyLimits   map[string]int64 `env:"DAILY_LIMITS" envKeyValSeparator:"="`
+	MonthlyLimits map[string]int64 `env:"MONTHLY_LIMITS" envKeyValSeparator:"="`
+
+	IdempotencyRequireUUID    bool    `env:"IDEMPOTENCY_REQUIRE_UUID" envDefault:"false"`
+	IdempotencyMinEntropyBits float64 `env:"IDEMPOTENCY_MIN_ENTROPY_BITS" envDefault:"64"`
+	IdempotencyMaxKeyLength   int     `env:"IDEMPOTENCY_MAX_KEY_LENGTH" envDefault:"128"`
+
+	StatusPollThresholdS int `env:"STATUS_POLL_THRESHOLD_S" envDefault:"300"`
+	StatusPollIntervalS  int `env:"STATUS_POLL_INTERVAL_S" envDefault:"60"`
+
+	ProviderSubmitRetryIntervalS int `env:"PROVIDER_SUBMIT_RETRY_INTERVAL_S" envDefault:"15"`
+	ProviderSubmitBackoffS       int `env:"PROVIDER_SUBMIT_BACKOFF_S" envDefault:"30"`
+	ProviderSubmitMaxAttempts    int `env:"PROVIDER_SUBMIT_MAX_ATTEMPTS" envDefault:"5"`
+
+	ProviderSLAP95S           int `env:"PROVIDER_SLA_P95_S" envDefault:"900"`
+	ProviderSLAWindowM        int `env:"PROVIDER_SLA_WINDOW_M" envDefault:"360"`
+	ProviderSLAMinSamples     int `env:"PROVIDER_SLA_MIN_SAMPLES" envDefault:"20"`
+	ProviderSLACheckIntervalS int `env:"PROVIDER_SLA_CHECK_INTERVAL_S" envDefault:"60"`
+
+	DisputeRespondSLAH       int `env:"DISPUTE_RESPOND_SLA_H" envDefault:"48"`
+	DisputeResolveSLAH       int `env:"DISPUTE_RESOLVE_SLA_H" envDefault:"240"`
+	DisputeSLACheckIntervalS int `env:"DISPUTE_SLA_CHECK_INTERVAL_S" envDefault:"300"`
+
+	PartitionMonthsAhead    int `env:"PARTITION_MONTHS_AHEAD" envDefault:"3"`
+	PartitionCheckIntervalH int `env:"PARTITION_CHECK_INTERVAL_H" envDefault:"6"`
+
+	DigestCheckIntervalM int `env:"DIGEST_CHECK_INTERVAL_M" envDefault:"60"`
+
+	QASampleRatePct   float64          `env:"QA_SAMPLE_RATE_PCT" envDefault:"1"`
+	QALargeAmounts    map[string]int64 `env:"QA_LARGE_AMOUNTS" envKeyValSeparator:"="`
+	QASampleIntervalM int              `env:"QA_SAMPLE_INTERVAL_M" envDefault:"60"`
+	QASampleLookbackH int              `env:"QA_SAMPLE_LOOKBACK_H" envDefault:"24"`
+
+	PaymentRequestTTLH            int `env:"PAYMENT_REQUEST_TTL_H" envDefault:"168"`
+	PaymentRequestExpiryIntervalM int `env:"PAYMENT_REQUEST_EXPIRY_INTERVAL_M" envDefault:"15"`
+
+	HoldDefaultTTLM int `env:"HOLD_DEFAULT_TTL_M" envDefault:"1440"`
+	HoldMaxTTLM     int `env:"HOLD_MAX_TTL_M" envDefault:"10080"`
+
+	FXPoolCheckIntervalS int `env:"FX_POOL_CHECK_INTERVAL_S" envDefault:"60"`
+	// FXRateRecordIntervalM is how often the rates are refreshed and the
+	// pairs whose rate changed written to fx_rates_history.
+	FXRateRecordIntervalM int `env:"FX_RATE_RECORD_INTERVAL_M" envDefault:"60"`
+
+	// FXPoolBootstraps is the balance a missing FX pool is opened with at
+	// startup. Ignored in production, where pools are funded by treasury.
+	FXPoolBootstraps map[string]int64 `env:"FX_POOL_BOOTSTRAPS" envKeyValSeparator:"="`
+
+	LedgerVerifyIntervalS int `env:"LEDGER_VERIFY_INTERVAL_S" envDefault:"300"`
+	LedgerVerifyWindowH   int `env:"LEDGER_VERIFY_WINDOW_H" envDefault:"24"`
+
+	// BalanceCacheSize is how many settled historical balances each instance
+	// keeps in memory; 0 disables the cache.
+	BalanceSnapshotIntervalM int `env:"BALANCE_SNAPSHOT_INTERVAL_M" envDefault:"60"`
+	BalanceCacheSize         int `env:"BALANCE_CACHE_SIZE" envDefault:"10000"`
+
+	LocklessBalancePct int `env:"LOCKLESS_BALANCE_PCT" envDefault:"0"`
+
+	// An empty ArchiveS3Endpoint means AWS itself, and empty S3 keys mean
+	// the AWS default credential chain.
+	ArchiveBackend           string `env:"ARCHIVE_BACKEND"`
+	ArchiveFSDir             string `env:"ARCHIVE_FS_DIR" envDefault:"./archive"`
+	ArchiveS3Endpoint        string `env:"ARCHIVE_S3_ENDPOINT"`
+	ArchiveS3Region          string `env:"ARCHIVE_S3_REGION" envDefault:"us-east-1"`
+	ArchiveS3Bucket          string `env:"ARCHIVE_S3_BUCKET"`
+	ArchiveS3AccessKeyID     string `env:"ARCHIVE_S3_ACCESS_KEY_ID"`
+	ArchiveS3SecretAccessKey string `env:"ARCHIVE_S3_SECRET_ACCESS_KEY"`
+
+	PaymentEventRetentionD       int `env:"PAYMENT_EVENT_RETENTION_D" envDefault:"365"`
+	PaymentEventArchiveIntervalM int `env:"PAYMENT_EVENT_ARCHIVE_INTERVAL_M" envDefault:"60"`
+	PaymentEventArchiveBatch     int `env:"PAYMENT_EVENT_ARCHIVE_BATCH" envDefault:"1000"`
+
+	// EventBus is log, nats or kafka. NATSCAFile and KafkaTLS turn on TLS
+	// to the bus; a tls:// NATS_URL does too.
+	EventBus             string   `env:"EVENT_BUS" envDefault:"log"`
+	NATSURL              string   `env:"NATS_URL" envDefault:"nats://localhost:4222"`
+	NATSCAFile           string   `env:"NATS_CA_FILE"`
+	KafkaBrokers         []string `env:"KAFKA_BROKERS" envSeparator:"," envDefault:"localhost:9092"`
+	KafkaTLS             bool     `env:"KAFKA_TLS" envDefault:"false"`
+	EventBusTimeoutMS    int      `env:"EVENT_BUS_TIMEOUT_MS" envDefault:"5000"`
+	OutboxPollIntervalMS int      `env:"OUTBOX_POLL_INTERVAL_MS" envDefault:"1000"`
+	OutboxBatchSize      int      `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+
+	CorridorRefreshIntervalM int `env:"CORRIDOR_REFRESH_INTERVAL_M" envDefault:"15"`
+	CorridorRefreshLookbackD int `env:"CORRIDOR_REFRESH_LOOKBACK_D" envDefault:"7"`
+
+	// RateLimitBackend is memory or redis. Limits are requests per minute;
+	// 0 disables a limit.
+	RateLimitBackend           string `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	RedisAddr                  string `env:"REDIS_ADDR" envDefault:"localhost:6379"`
+	RedisPassword              string `env:"REDIS_PASSWORD"`
+	RedisTimeoutMS             int    `env:"REDIS_TIMEOUT_MS" envDefault:"100"`
+	RedisPoolSize              int    `env:"REDIS_POOL_SIZE" envDefault:"10"`
+	RateLimitLoginIPPerMin     int    `env:"RATE_LIMIT_LOGIN_IP_PER_MIN" envDefault:"20"`
+	RateLimitLoginIPBurst      int    `env:"RATE_LIMIT_LOGIN_IP_BURST" envDefault:"10"`
+	RateLimitPaymentUserPerMin int    `env:"RATE_LIMIT_PAYMENT_USER_PER_MIN" envDefault:"60"`
+	RateLimitPaymentUserBurst  int    `env:"RATE_LIMIT_PAYMENT_USER_BURST" envDefault:"30"`
+	RateLimitPaymentIPPerMin   int    `env:"RATE_LIMIT_PAYMENT_IP_PER_MIN" envDefault:"300"`
+	RateLimitPaymentIPBurst    int    `env:"RATE_LIMIT_PAYMENT_IP_BURST" envDefault:"100"`
+
+	// RunMode is what cmd/api runs: "all" (the API and the background
+	// processors), "api" or "worker". cmd/worker always runs as a worker.
+	RunMode string `env:"RUN_MODE" envDefault:"all"`
+
+	ShutdownGracePeriodS int `env:"SHUTDOWN_GRACE_PERIOD_S" envDefault:"30"`
+
+	// GRPCAddr is where the gRPC PaymentService listens for internal
+	// callers; empty disables it. Callers send GRPCAuthToken as a bearer
+	// token.
+	GRPCAddr      string `env:"GRPC_ADDR"`
+	GRPCAuthToken string `env:"GRPC_AUTH_TOKEN"`
+
+	// MetricsAddr is where a process that serves the API exposes /metrics
+	// for the scraper, on the internal network; empty disables it. A worker
+	// serves /metrics on its own port.
+	MetricsAddr string `env:"METRICS_ADDR"`
+
+	// X-Request-Timeout is clamped to this range. The maximum should stay
+	// under the server's 15s write timeout so a 504 can still be written.
+	RequestTimeoutMinMS int `env:"REQUEST_TIMEOUT_MIN_MS" envDefault:"500"`
+	RequestTimeoutMaxMS int `env:"REQUEST_TIMEOUT_MAX_MS" envDefault:"10000"`
+
+	LoadShedSoftInFlight  int               `env:"LOAD_SHED_SOFT_IN_FLIGHT" envDefault:"200"`
+	LoadShedHardInFlight  int               `env:"LOAD_SHED_HARD_IN_FLIGHT" envDefault:"500"`
+	LoadShedMaxPoolWaitMS int               `env:"LOAD_SHED_MAX_POOL_WAIT_MS" envDefault:"250"`
+	LoadShedRetryAfterS   int               `env:"LOAD_SHED_RETRY_AFTER_S" envDefault:"5"`
+	LoadShedPriorities    map[string]string `env:"LOAD_SHED_PRIORITIES" envKeyValSeparator:"="`
 
 	DBMaxOpenConns    int `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
-	DBMaxIdleConns    int `env:"DB_MAX_IDLE_CONNS" envDefault:"10"`
+	DBMinConns        int `env:"DB_MIN_CONNS" envDefault:"0"`
 	DBConnMaxLifetimeS int `env:"DB_CONN_MAX_LIFETIME_S" envDefault:"300"`
 	DBConnMaxIdleTimeS int `env:"DB_CONN_MAX_IDLE_TIME_S" envDefault:"60"`
+
+	// DeprecatedVars are the deprecated variables Load found set, for the
+	// caller to warn about once logging is up.
+	DeprecatedVars []string
 }
 
 func Load() (*Config, error) {
@@ -32,5 +304,160 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("config.Load: %w", err)
 	}
+	if err := cfg.applyCurrencyAmounts(); err != nil {
+		return nil, fmt.Errorf("config.Load: %w", err)
+	}
 	return &cfg, nil
 }
+
+// currencyAmounts are the per-currency settings: each one's CODE=amount
+// map, its defaults, and the prefix of the deprecated variables that set
+// it for USD, EUR and GBP one at a time, e.g. TX_LIMIT_USD.
+var currencyAmounts = []struct {
+	name     string
+	legacy   string
+	defaults string
+	field    func(c *Config) *map[string]int64
+}{
+	{"TX_LIMITS", "TX_LIMIT", "USD=10000000,EUR=9000000,GBP=8000000,NGN=15000000000,KES=1300000000,GHS=150000000",
+		func(c *Config) *map[string]int64 { return &c.TxLimits }},
+	{"REVIEW_THRESHOLDS", "REVIEW_THRESHOLD", "USD=5000000,EUR=4500000,GBP=4000000,NGN=7500000000,KES=650000000,GHS=75000000",
+		func(c *Config) *map[string]int64 { return &c.ReviewThresholds }},
+	{"TOTP_STEP_UPS", "TOTP_STEP_UP", "USD=1000000,EUR=900000,GBP=800000,NGN=1500000000,KES=130000000,GHS=15000000",
+		func(c *Config) *map[string]int64 { return &c.TOTPStepUps }},
+	{"DAILY_LIMITS", "DAILY_LIMIT", "USD=20000000,EUR=18000000,GBP=16000000,NGN=30000000000,KES=2600000000,GHS=300000000",
+		func(c *Config) *map[string]int64 { return &c.DailyLimits }},
+	{"MONTHLY_LIMITS", "MONTHLY_LIMIT", "USD=100000000,EUR=90000000,GBP=80000000,NGN=150000000000,KES=13000000000,GHS=1500000000",
+		func(c *Config) *map[string]int64 { return &c.MonthlyLimits }},
+	{"QA_LARGE_AMOUNTS", "QA_LARGE_AMOUNT", "USD=1000000,EUR=900000,GBP=800000,NGN=1500000000,KES=130000000,GHS=15000000",
+		func(c *Config) *map[string]int64 { return &c.QALargeAmounts }},
+	{"WEBHOOK_PRIORITY_HIGH_AMOUNTS", "WEBHOOK_PRIORITY_HIGH", "USD=1000000,EUR=900000,GBP=800000,NGN=1500000000,KES=130000000,GHS=15000000",
+		func(c *Config) *map[string]int64 { return &c.WebhookPriorityHighAmounts }},
+	{"FX_POOL_BOOTSTRAPS", "FX_POOL_BOOTSTRAP", "",
+		func(c *Config) *map[string]int64 { return &c.FXPoolBootstraps }},
+}
+
+// legacyCurrencies are the currencies that had a variable of their own
+// for each setting before the maps.
+var legacyCurrencies = []string{"USD", "EUR", "GBP"}
+
+// applyCurrencyAmounts fills in each per-currency map. A currency's amount
+// is its entry in the map's variable, else its deprecated single-currency
+// variable, else the default. The deprecated variables used are listed in
+// DeprecatedVars.
+func (c *Config) applyCurrencyAmounts() error {
+	for _, s := range currencyAmounts {
+		m := make(map[string]int64)
+		if s.defaults != "" {
+			for _, pair := range strings.Split(s.defaults, ",") {
+				code, amount, _ := strings.Cut(pair, "=")
+				n, err := strconv.ParseInt(amount, 10, 64)
+				if err != nil {
+					return fmt.Errorf("%s default %q: %w", s.name, pair, err)
+				}
+				m[code] = n
+			}
+		}
+		for _, code := range legacyCurrencies {
+			name := s.legacy + "_" + code
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			m[code] = n
+			c.DeprecatedVars = append(c.DeprecatedVars, name)
+		}
+		for code, n := range *s.field(c) {
+			m[code] = n
+		}
+		*s.field(c) = m
+	}
+	return nil
+}
+
+func (c *Config) Providers() map[string]string {
+	providers := map[string]string{"mock_provider": c.MockProviderURL}
+	for name, url := range c.ProviderURLs {
+		providers[name] = url
+	}
+	return providers
+}
+
+// ProviderWebhookMaxBody is the largest webhook body accepted from a
+// provider, after decompression.
+func (c *Config) ProviderWebhookMaxBody(name string) int64 {
+	if n, ok := c.ProviderWebhookMaxBodyBytes[name]; ok {
+		return n
+	}
+	return c.WebhookMaxBodyBytes
+}
+
+// TxLimit is the default per-transaction limit in currency.
+func (c *Config) TxLimit(currency string) int64 {
+	return c.TxLimits[currency]
+}
+
+func (c *Config) ReviewThreshold(currency string) int64 {
+	return c.ReviewThresholds[currency]
+}
+
+func (c *Config) TOTPStepUp(currency string) int64 {
+	return c.TOTPStepUps[currency]
+}
+
+func (c *Config) DailyLimit(currency string) int64 {
+	return c.DailyLimits[currency]
+}
+
+func (c *Config) MonthlyLimit(currency string) int64 {
+	return c.MonthlyLimits[currency]
+}
+
+func (c *Config) QALargeAmount(currency string) int64 {
+	return c.QALargeAmounts[currency]
+}
+
+func (c *Config) WebhookPriorityHighAmount(currency string) int64 {
+	return c.WebhookPriorityHighAmounts[currency]
+}
+
+func (c *Config) FXPoolBootstrap(currency string) int64 {
+	return c.FXPoolBootstraps[currency]
+}
+
+func (c *Config) ProviderWebhookSecret(name string) string {
+	if secret, ok := c.ProviderWebhookSecrets[name]; ok {
+		return secret
+	}
+	return c.WebhookSecret
+}
+
+// TOTPKey is the key authenticator secrets are encrypted with.
+func (c *Config) TOTPKey() ([]byte, error) {
+	if c.TOTPEncryptionKey == "" {
+		sum := sha256.Sum256([]byte("totp:" + c.JWTSecret))
+		return sum[:], nil
+	}
+	key, err := hex.DecodeString(c.TOTPEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("config.TOTPKey: TOTP_ENCRYPTION_KEY must be 32 bytes, hex encoded")
+	}
+	return key, nil
+}
+
+// WebhookEndpointKey is the key webhook endpoint secrets are encrypted with.
+func (c *Config) WebhookEndpointKey() ([]byte, error) {
+	if c.WebhookEndpointEncryptionKey == "" {
+		sum := sha256.Sum256([]byte("webhook-endpoint:" + c.JWTSecret))
+		return sum[:], nil
+	}
+	key, err := hex.DecodeString(c.WebhookEndpointEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("config.WebhookEndpointKey: WEBHOOK_ENDPOINT_ENCRYPTION_KEY must be 32 bytes, hex encoded")
+	}
+	return key, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_CurrencyAmounts(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/grey")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("WEBHOOK_SECRET", "secret")
+	t.Setenv("TX_LIMITS", "NGN=1,JPY=2")
+	t.Setenv("TX_LIMIT_EUR", "3")
+	t.Setenv("TX_LIMIT_NGN", "4")
+	t.Setenv("REVIEW_THRESHOLDS", "GBP=5")
+	t.Setenv("REVIEW_THRESHOLD_GBP", "6")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(10000000), cfg.TxLimit("USD"), "default")
+	assert.Equal(t, int64(3), cfg.TxLimit("EUR"), "deprecated variable over the default")
+	assert.Equal(t, int64(1), cfg.TxLimit("NGN"), "map entry; NGN never had a variable of its own")
+	assert.Equal(t, int64(2), cfg.TxLimit("JPY"))
+	assert.Equal(t, int64(5), cfg.ReviewThreshold("GBP"), "map entry over the deprecated variable")
+	assert.Zero(t, cfg.FXPoolBootstrap("USD"))
+	assert.ElementsMatch(t, []string{"TX_LIMIT_EUR", "REVIEW_THRESHOLD_GBP"}, cfg.DeprecatedVars)
+
+	t.Setenv("TX_LIMIT_EUR", "lots")
+	_, err = Load()
+	assert.ErrorContains(t, err, "TX_LIMIT_EUR")
+}
@@ -0,0 +1,89 @@
+// Package deadline tracks a request's time budget and the stage of work it
+// is in, so a request that runs out of time can say where it was.
+package deadline
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type budgetKey struct{}
+
+type stageMark struct {
+	name string
+	at   time.Time
+}
+
+// Budget is the time a request has to finish, and the stages it has been
+// through so far.
+type Budget struct {
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	stages []stageMark
+}
+
+// WithBudget bounds ctx to timeout and starts tracking stages, beginning
+// with "request".
+func WithBudget(ctx context.Context, timeout time.Duration) (context.Context, *Budget, context.CancelFunc) {
+	b := &Budget{Timeout: timeout, stages: []stageMark{{name: "request", at: time.Now()}}}
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, budgetKey{}, b), timeout)
+	return ctx, b, cancel
+}
+
+// FromContext returns the request's budget, or nil if it has none.
+func FromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
+
+// Stage records that the request has moved on to name. It does nothing
+// for a context without a budget, so callers needn't check.
+func Stage(ctx context.Context, name string) {
+	b := FromContext(ctx)
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.stages = append(b.stages, stageMark{name: name, at: time.Now()})
+	b.mu.Unlock()
+}
+
+// StageAt returns the stage the request was in at t.
+func (b *Budget) StageAt(t time.Time) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	name := b.stages[0].name
+	for _, s := range b.stages[1:] {
+		if s.at.After(t) {
+			break
+		}
+		name = s.name
+	}
+	return name
+}
+
+// Trace lists the stages with the time spent in each, up to end, as
+// "request=3ms fx_quote=120ms".
+func (b *Budget) Trace(end time.Time) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	parts := make([]string, len(b.stages))
+	for i, s := range b.stages {
+		until := end
+		if i+1 < len(b.stages) {
+			until = b.stages[i+1].at
+		}
+		parts[i] = s.name + "=" + until.Sub(s.at).Round(time.Millisecond).String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// Started is when the budget began.
+func (b *Budget) Started() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stages[0].at
+}
@@ -0,0 +1,25 @@
+package deadline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget_StageAt(t *testing.T) {
+	ctx, b, cancel := WithBudget(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	Stage(ctx, "db:executeTransfer")
+	time.Sleep(5 * time.Millisecond)
+	mid := time.Now()
+	Stage(ctx, "provider:mock")
+
+	assert.Equal(t, "request", b.StageAt(start.Add(-time.Millisecond)))
+	assert.Equal(t, "db:executeTransfer", b.StageAt(mid))
+	assert.Equal(t, "provider:mock", b.StageAt(time.Now()))
+	assert.Contains(t, b.Trace(time.Now()), "db:executeTransfer=")
+}
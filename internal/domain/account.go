@@ -1,34 +1,20 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Currency string
-
-const (
-	CurrencyUSD Currency = "USD"
-	CurrencyEUR Currency = "EUR"
-	CurrencyGBP Currency = "GBP"
-)
-
-func (c Currency) IsValid() bool {
-	switch c {
-	case CurrencyUSD, CurrencyEUR, CurrencyGBP:
-		return true
-	default:
-		return false
-	}
-}
-
 type AccountType string
 
 const (
 	AccountTypeUser     AccountType = "user"
 	AccountTypeFXPool   AccountType = "fx_pool"
 	AccountTypeOutgoing AccountType = "outgoing"
+	AccountTypeSettled  AccountType = "settled"
+	AccountTypeRevenue  AccountType = "revenue"
 )
 
 type AccountStatus string
@@ -40,6 +26,42 @@ const (
 	AccountStatusClosed  AccountStatus = "closed"
 )
 
+// FreezeReason is why an account was frozen. It decides what the owner is
+// told when a payment from the account is refused.
+type FreezeReason string
+
+const (
+	FreezeReasonComplianceReview FreezeReason = "compliance_review"
+	FreezeReasonUserRequested    FreezeReason = "user_requested"
+	FreezeReasonFraudSuspected   FreezeReason = "fraud_suspected"
+)
+
+func (r FreezeReason) IsValid() bool {
+	switch r {
+	case FreezeReasonComplianceReview, FreezeReasonUserRequested, FreezeReasonFraudSuspected:
+		return true
+	default:
+		return false
+	}
+}
+
+// AccountFrozenError reports a frozen account along with why it was frozen.
+// It is only returned to the account's owner; the other side of a payment
+// gets the bare ErrAccountFrozen. Reason is empty for accounts frozen before
+// reasons were recorded.
+type AccountFrozenError struct {
+	Reason FreezeReason
+}
+
+func (e *AccountFrozenError) Error() string {
+	if e.Reason == "" {
+		return ErrAccountFrozen.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrAccountFrozen, e.Reason)
+}
+
+func (e *AccountFrozenError) Unwrap() error { return ErrAccountFrozen }
+
 type Account struct {
 	ID            uuid.UUID
 	UserID        uuid.UUID
@@ -54,5 +76,58 @@ type Account struct {
 	Provider      *string
 	ProviderRef   *string
 	Status        AccountStatus
-	CreatedAt     time.Time
+	// FreezeReason, FrozenAt and FrozenBy are set while the account is
+	// frozen.
+	FreezeReason *FreezeReason
+	FrozenAt     *time.Time
+	FrozenBy     *uuid.UUID
+	CreatedAt    time.Time
+}
+
+// FrozenError is the error the account's owner gets for a frozen account.
+func (a *Account) FrozenError() error {
+	e := &AccountFrozenError{}
+	if a.FreezeReason != nil {
+		e.Reason = *a.FreezeReason
+	}
+	return e
+}
+
+// BalanceUpdate sets an account's balance, provided the row is still at
+// Version, the version the caller read under FOR UPDATE.
+type BalanceUpdate struct {
+	AccountID uuid.UUID
+	Balance   int64
+	Version   int64
+}
+
+// AccountSummary is an account with what its owner can spend from it now:
+// the balance less live holds, the same figure the funds check uses.
+type AccountSummary struct {
+	Account
+	Available int64
+}
+
+// AccountBalance is the read model behind the balance endpoint. Payouts
+// debit the account when they're created, so PendingOutgoing is already
+// out of Ledger; it's reported so clients can show money that's on its way
+// out but not yet confirmed by the provider. Available is Ledger less Held.
+type AccountBalance struct {
+	AccountID       uuid.UUID
+	Currency        Currency
+	Ledger          int64
+	Held            int64
+	PendingOutgoing int64
+	Available       int64
+	AsOf            time.Time
+}
+
+// HistoricalBalance is an account's ledger balance as of a past instant.
+// Holds and pending payouts aren't kept historically, so it has no
+// available figure.
+type HistoricalBalance struct {
+	AccountID uuid.UUID
+	Currency  Currency
+	Ledger    int64
+	AsOf      time.Time
 }
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every key, so leaked keys are easy to recognise in
+// logs and by secret scanners, and so a key can be told apart from a JWT.
+const APIKeyPrefix = "grey_"
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeRead          APIKeyScope = "read"
+	APIKeyScopePaymentsWrite APIKeyScope = "payments:write"
+)
+
+func (s APIKeyScope) Valid() bool {
+	return s == APIKeyScopeRead || s == APIKeyScopePaymentsWrite
+}
+
+// APIKey is a long-lived credential a user creates for their own
+// integrations. Only a hash of the secret is stored; Prefix and LastFour are
+// kept so the key can be recognised in listings without revealing it.
+type APIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    []byte
+	LastFour   string
+	Scopes     []APIKeyScope
+	CreatedIP  string
+	CreatedUA  string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	// RotatedFrom is the key this one replaced, if it was issued by a rotation.
+	RotatedFrom *uuid.UUID
+}
+
+// Masked is the form shown in listings, e.g. "grey_3f9a1c2e…x7Qk".
+func (k *APIKey) Masked() string {
+	return k.Prefix + "…" + k.LastFour
+}
+
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
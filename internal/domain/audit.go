@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction names a sensitive action recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditActionLogin         AuditAction = "auth.login"
+	AuditActionLoginFailed   AuditAction = "auth.login_failed"
+	AuditActionLimitSet      AuditAction = "user_limit.set"
+	AuditActionLimitReset    AuditAction = "user_limit.reset"
+	AuditActionAccountClosed AuditAction = "account.closed"
+	AuditActionAccountFrozen AuditAction = "account.frozen"
+	// AuditActionAccountUnfrozen keeps the reason the account was frozen
+	// for in Before; the account itself forgets it.
+	AuditActionAccountUnfrozen AuditAction = "account.unfrozen"
+	// AuditActionResidencySet is a change to a user's country of residence.
+	AuditActionResidencySet AuditAction = "user.residency_set"
+	// AuditActionTierSet is a change to a user's tier.
+	AuditActionTierSet AuditAction = "user.tier_set"
+	// AuditActionResidencyBlocked is a payment turned away by a residency
+	// rule. The attempt and the rule are kept in After, for compliance
+	// reporting.
+	AuditActionResidencyBlocked AuditAction = "payment.residency_blocked"
+	// AuditActionAdminRequest is any change made through the admin API. The
+	// route and request body are kept in After.
+	AuditActionAdminRequest AuditAction = "admin.request"
+	// AuditActionPaymentResolved is a payout settled by hand instead of by
+	// its provider's callback. The operator's evidence is kept in After.
+	AuditActionPaymentResolved AuditAction = "payment.resolved_manually"
+	// AuditActionExported is an export of the audit log itself.
+	AuditActionExported AuditAction = "audit_log.exported"
+)
+
+// AuditEntry is an action to record. The signed-in user is the actor unless
+// ActorID is set. Before and After are marshalled to JSON.
+type AuditEntry struct {
+	ActorID      *uuid.UUID
+	Action       AuditAction
+	ResourceType string
+	ResourceID   string
+	Before       any
+	After        any
+}
+
+// AuditLog is one recorded action. ActorID is nil when nobody is signed in,
+// as on a failed login. Before and After are the resource's state around the
+// action, as JSON; either may be nil.
+type AuditLog struct {
+	ID           uuid.UUID
+	ActorID      *uuid.UUID
+	Action       AuditAction
+	ResourceType string
+	ResourceID   string
+	IP           string
+	Before       json.RawMessage
+	After        json.RawMessage
+	CreatedAt    time.Time
+}
+
+// AuditCursor marks a position in the audit log, newest first. ID breaks
+// ties between rows written in the same instant.
+type AuditCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Cursor is the position just past l.
+func (l *AuditLog) Cursor() AuditCursor {
+	return AuditCursor{CreatedAt: l.CreatedAt, ID: l.ID}
+}
+
+// AuditFilter narrows an audit log query. Zero fields don't filter; From is
+// inclusive and To exclusive.
+type AuditFilter struct {
+	ActorID      *uuid.UUID
+	Action       AuditAction
+	ResourceType string
+	ResourceID   string
+	From         *time.Time
+	To           *time.Time
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Beneficiary is a bank account a user saved to pay out to. Deleting one
+// only sets DeletedAt, so payouts that went to it still point at it and it
+// can be restored.
+type Beneficiary struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	IBAN      string
+	BankName  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+func (b *Beneficiary) Deleted() bool {
+	return b.DeletedAt != nil
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// CorridorGranularity is how a corridor report buckets days.
+type CorridorGranularity string
+
+const (
+	CorridorGranularityDay   CorridorGranularity = "day"
+	CorridorGranularityWeek  CorridorGranularity = "week"
+	CorridorGranularityMonth CorridorGranularity = "month"
+	// CorridorGranularityTotal reports one row per corridor for the whole
+	// range.
+	CorridorGranularityTotal CorridorGranularity = "total"
+)
+
+func (g CorridorGranularity) IsValid() bool {
+	switch g {
+	case CorridorGranularityDay, CorridorGranularityWeek, CorridorGranularityMonth, CorridorGranularityTotal:
+		return true
+	}
+	return false
+}
+
+// CorridorStats aggregates customer payments from one currency to another
+// over a period. Succeeded counts completed payments, including ones later
+// reversed; Failed counts failed payouts. Volume and Fees are in minor units
+// of the source currency and cover succeeded payments only.
+// CompletionSeconds is summed over succeeded payments that recorded a
+// completion time, Timed of them.
+type CorridorStats struct {
+	PeriodStart       time.Time
+	SourceCurrency    Currency
+	DestCurrency      Currency
+	Payments          int64
+	Succeeded         int64
+	Failed            int64
+	Volume            int64
+	Fees              int64
+	Timed             int64
+	CompletionSeconds float64
+}
+
+// AvgFee is the mean fee per succeeded payment, in minor units.
+func (s CorridorStats) AvgFee() float64 {
+	if s.Succeeded == 0 {
+		return 0
+	}
+	return float64(s.Fees) / float64(s.Succeeded)
+}
+
+// FailureRate is the share of finished payments that failed. Payments still
+// in flight aren't counted either way.
+func (s CorridorStats) FailureRate() float64 {
+	if s.Succeeded+s.Failed == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(s.Succeeded+s.Failed)
+}
+
+// AvgCompletionSeconds is the mean time from creation to completion.
+func (s CorridorStats) AvgCompletionSeconds() float64 {
+	if s.Timed == 0 {
+		return 0
+	}
+	return s.CompletionSeconds / float64(s.Timed)
+}
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyNGN Currency = "NGN"
+	CurrencyKES Currency = "KES"
+	CurrencyGHS Currency = "GHS"
+)
+
+// CurrencySpec is what the platform needs to know about a currency.
+type CurrencySpec struct {
+	Code Currency
+	// Exponent is how many decimal places the minor unit is. Not every
+	// currency has two: JPY has none and KWD has three.
+	Exponent int
+	// Country is the ISO 3166 code that starts the IBANs generated for
+	// accounts in the currency.
+	Country string
+}
+
+// currencyRegistry is every currency the platform knows how to handle.
+// These are the built-in ones; CURRENCY_SPECS adds more at startup (see
+// RegisterCurrencies). Which of them are in use is configuration too (see
+// EnableCurrencies), as are their limits, thresholds and FX rates.
+var currencyRegistry = []CurrencySpec{
+	{Code: CurrencyUSD, Exponent: 2, Country: "US"},
+	{Code: CurrencyEUR, Exponent: 2, Country: "DE"},
+	{Code: CurrencyGBP, Exponent: 2, Country: "GB"},
+	{Code: CurrencyNGN, Exponent: 2, Country: "NG"},
+	{Code: CurrencyKES, Exponent: 2, Country: "KE"},
+	{Code: CurrencyGHS, Exponent: 2, Country: "GH"},
+}
+
+// SupportedCurrencies lists every currency accounts can be opened in, in
+// registry order. It is every registered currency until EnableCurrencies
+// narrows it.
+var SupportedCurrencies = registeredCurrencies()
+
+func registeredCurrencies() []Currency {
+	codes := make([]Currency, len(currencyRegistry))
+	for i, spec := range currencyRegistry {
+		codes[i] = spec.Code
+	}
+	return codes
+}
+
+// RegisterCurrencies adds specs to the registry, replacing the entry of a
+// code already in it, and resets SupportedCurrencies to the whole registry.
+// Each spec is code => "exponent:country", e.g. "JPY" => "0:JP". It is
+// called once at startup, before EnableCurrencies.
+func RegisterCurrencies(specs map[string]string) error {
+	codes := make([]string, 0, len(specs))
+	for code := range specs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		spec, err := parseCurrencySpec(code, specs[code])
+		if err != nil {
+			return fmt.Errorf("RegisterCurrencies: %w", err)
+		}
+		replaced := false
+		for i := range currencyRegistry {
+			if currencyRegistry[i].Code == spec.Code {
+				currencyRegistry[i], replaced = spec, true
+			}
+		}
+		if !replaced {
+			currencyRegistry = append(currencyRegistry, spec)
+		}
+	}
+	SupportedCurrencies = registeredCurrencies()
+	return nil
+}
+
+func parseCurrencySpec(code, value string) (CurrencySpec, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !isUpperAlpha(string(c), 3) {
+		return CurrencySpec{}, fmt.Errorf("%q: code must be three letters: %w", code, ErrInvalidCurrency)
+	}
+	exp, country, ok := strings.Cut(value, ":")
+	if !ok {
+		return CurrencySpec{}, fmt.Errorf("%s: %q is not exponent:country", c, value)
+	}
+	exponent, err := strconv.Atoi(strings.TrimSpace(exp))
+	if err != nil || exponent < 0 || exponent > 4 {
+		return CurrencySpec{}, fmt.Errorf("%s: exponent %q must be 0 to 4", c, exp)
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if !isUpperAlpha(country, 2) {
+		return CurrencySpec{}, fmt.Errorf("%s: country %q must be two letters", c, country)
+	}
+	return CurrencySpec{Code: c, Exponent: exponent, Country: country}, nil
+}
+
+func isUpperAlpha(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// EnableCurrencies limits SupportedCurrencies to codes, which must all be
+// registered. It is called once at startup, before anything is served.
+func EnableCurrencies(codes []string) error {
+	if len(codes) == 0 {
+		return fmt.Errorf("EnableCurrencies: no currencies enabled")
+	}
+	enabled := make(map[Currency]bool, len(codes))
+	for _, code := range codes {
+		c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+		if _, ok := LookupCurrency(c); !ok {
+			return fmt.Errorf("EnableCurrencies: %q: %w", code, ErrInvalidCurrency)
+		}
+		enabled[c] = true
+	}
+
+	var supported []Currency
+	for _, spec := range currencyRegistry {
+		if enabled[spec.Code] {
+			supported = append(supported, spec.Code)
+		}
+	}
+	SupportedCurrencies = supported
+	return nil
+}
+
+// LookupCurrency returns the registry entry for c, enabled or not.
+func LookupCurrency(c Currency) (CurrencySpec, bool) {
+	for _, spec := range currencyRegistry {
+		if spec.Code == c {
+			return spec, true
+		}
+	}
+	return CurrencySpec{}, false
+}
+
+// IsValid reports whether c is an enabled currency.
+func (c Currency) IsValid() bool {
+	for _, s := range SupportedCurrencies {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Exponent is how many decimal places the currency's minor unit is, 2 for
+// cents and pence. Amounts are stored in minor units and shown to clients
+// in major units with this many decimals.
+func (c Currency) Exponent() int {
+	if spec, ok := LookupCurrency(c); ok {
+		return spec.Exponent
+	}
+	return 2
+}
+
+// CurrencyList is the enabled currencies joined for messages, "USD, EUR,
+// GBP".
+func CurrencyList() string {
+	codes := make([]string, len(SupportedCurrencies))
+	for i, c := range SupportedCurrencies {
+		codes[i] = string(c)
+	}
+	return strings.Join(codes, ", ")
+}
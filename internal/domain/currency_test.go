@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableCurrencies(t *testing.T) {
+	t.Cleanup(func() { SupportedCurrencies = registeredCurrencies() })
+
+	require.NoError(t, EnableCurrencies([]string{"ngn", "USD"}))
+	assert.Equal(t, []Currency{CurrencyUSD, CurrencyNGN}, SupportedCurrencies, "registry order, not config order")
+	assert.True(t, CurrencyNGN.IsValid())
+	assert.False(t, CurrencyEUR.IsValid())
+	assert.Equal(t, "USD, NGN", CurrencyList())
+	assert.Equal(t, 2, CurrencyEUR.Exponent(), "disabled currencies keep their spec")
+
+	assert.ErrorIs(t, EnableCurrencies([]string{"USD", "XYZ"}), ErrInvalidCurrency)
+	assert.Error(t, EnableCurrencies(nil))
+}
+
+func TestRegisterCurrencies(t *testing.T) {
+	builtin := append([]CurrencySpec(nil), currencyRegistry...)
+	t.Cleanup(func() {
+		currencyRegistry = builtin
+		SupportedCurrencies = registeredCurrencies()
+	})
+
+	require.NoError(t, RegisterCurrencies(map[string]string{"jpy": "0:jp", "NGN": "2:NG", "KWD": "3:KW"}))
+	assert.Equal(t, 0, Currency("JPY").Exponent())
+	assert.Equal(t, 3, Currency("KWD").Exponent())
+	assert.True(t, Currency("JPY").IsValid(), "registered currencies are supported until EnableCurrencies narrows them")
+	assert.Len(t, currencyRegistry, len(builtin)+2, "NGN is replaced, not added twice")
+	spec, ok := LookupCurrency("JPY")
+	require.True(t, ok)
+	assert.Equal(t, "JP", spec.Country)
+
+	for _, bad := range []map[string]string{
+		{"YEN": "0"},
+		{"JPYY": "0:JP"},
+		{"JPY": "x:JP"},
+		{"JPY": "9:JP"},
+		{"JPY": "0:JPN"},
+	} {
+		assert.Error(t, RegisterCurrencies(bad), "%v", bad)
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DenylistField string
+
+const (
+	DenylistFieldIBAN     DenylistField = "iban"
+	DenylistFieldBankName DenylistField = "bank_name"
+)
+
+func (f DenylistField) IsValid() bool {
+	switch f {
+	case DenylistFieldIBAN, DenylistFieldBankName:
+		return true
+	default:
+		return false
+	}
+}
+
+// Normalize puts a value into the form it is stored and matched in: IBANs
+// without spaces in upper case, bank names lower-cased with single spaces.
+func (f DenylistField) Normalize(value string) string {
+	switch f {
+	case DenylistFieldIBAN:
+		return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+	case DenylistFieldBankName:
+		return strings.ToLower(strings.Join(strings.Fields(value), " "))
+	default:
+		return strings.TrimSpace(value)
+	}
+}
+
+type DenylistEntry struct {
+	ID        uuid.UUID
+	Field     DenylistField
+	Value     string
+	Reason    string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
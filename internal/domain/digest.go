@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DigestPeriod string
+
+const (
+	DigestPeriodWeekly  DigestPeriod = "weekly"
+	DigestPeriodMonthly DigestPeriod = "monthly"
+)
+
+func (p DigestPeriod) IsValid() bool {
+	return p == DigestPeriodWeekly || p == DigestPeriodMonthly
+}
+
+// LastComplete returns the bounds of the most recent period that ended at or
+// before now. Weeks start on Monday; all bounds are UTC midnight.
+func (p DigestPeriod) LastComplete(now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case DigestPeriodMonthly:
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -1, 0), end
+	default:
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		end = today.AddDate(0, 0, -daysSinceMonday)
+		return end.AddDate(0, 0, -7), end
+	}
+}
+
+type DigestPreference struct {
+	UserID    uuid.UUID
+	Weekly    bool
+	Monthly   bool
+	UpdatedAt time.Time
+}
+
+// Wants reports whether the user opted in to digests for the period.
+func (p DigestPreference) Wants(period DigestPeriod) bool {
+	switch period {
+	case DigestPeriodWeekly:
+		return p.Weekly
+	case DigestPeriodMonthly:
+		return p.Monthly
+	default:
+		return false
+	}
+}
+
+// DigestTotals is one currency's activity in a digest. Sent excludes failed
+// and reversed payments; Received counts completed incoming transfers only.
+// FXCount and FXSent cover sends that converted currency.
+type DigestTotals struct {
+	Currency      Currency
+	SentCount     int64
+	Sent          int64
+	ReceivedCount int64
+	Received      int64
+	Fees          int64
+	FXCount       int64
+	FXSent        int64
+}
+
+type Digest struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Period      DigestPeriod
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Totals      []DigestTotals
+	DeliveredAt *time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen     DisputeStatus = "open"
+	DisputeStatusInReview DisputeStatus = "in_review"
+	DisputeStatusResolved DisputeStatus = "resolved"
+	DisputeStatusRejected DisputeStatus = "rejected"
+)
+
+func (s DisputeStatus) IsActive() bool {
+	return s == DisputeStatusOpen || s == DisputeStatusInReview
+}
+
+type DisputeReason string
+
+const (
+	DisputeReasonUnrecognized    DisputeReason = "unrecognized"
+	DisputeReasonIncorrectAmount DisputeReason = "incorrect_amount"
+	DisputeReasonDuplicate       DisputeReason = "duplicate"
+	DisputeReasonOther           DisputeReason = "other"
+)
+
+func (r DisputeReason) IsValid() bool {
+	switch r {
+	case DisputeReasonUnrecognized, DisputeReasonIncorrectAmount, DisputeReasonDuplicate, DisputeReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
+type Dispute struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	PaymentID       uuid.UUID
+	LedgerEntryID   *uuid.UUID
+	Reason          DisputeReason
+	Description     string
+	Status          DisputeStatus
+	RespondBy       time.Time
+	ResolveBy       time.Time
+	FirstResponseAt *time.Time
+	EscalatedAt     *time.Time
+	ResolvedAt      *time.Time
+	ResolvedBy      *uuid.UUID
+	ResolutionNote  *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ResponseOverdue reports whether staff missed the first-response deadline.
+func (d *Dispute) ResponseOverdue(now time.Time) bool {
+	if d.FirstResponseAt != nil {
+		return d.FirstResponseAt.After(d.RespondBy)
+	}
+	return d.Status.IsActive() && now.After(d.RespondBy)
+}
+
+// ResolutionOverdue reports whether the dispute missed its resolution deadline.
+func (d *Dispute) ResolutionOverdue(now time.Time) bool {
+	if d.ResolvedAt != nil {
+		return d.ResolvedAt.After(d.ResolveBy)
+	}
+	return now.After(d.ResolveBy)
+}
@@ -1,6 +1,11 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 var (
 	ErrNotFound                 = errors.New("not found")
@@ -10,14 +15,137 @@ var (
 	ErrSelfTransfer             = errors.New("cannot transfer to same account")
 	ErrInvalidCurrency          = errors.New("invalid currency")
 	ErrInvalidAmount            = errors.New("amount must be greater than zero")
+	ErrMalformedAmount          = errors.New("amount is not a decimal in the currency's precision")
 	ErrRecipientNotFound        = errors.New("recipient not found")
 	ErrAccountNotFound          = errors.New("account not found")
 	ErrLimitExceeded            = errors.New("transaction limit exceeded")
+	ErrPeriodLimitExceeded      = errors.New("cumulative period limit exceeded")
 	ErrAccountExists            = errors.New("account already exists for this currency")
 	ErrAccountClosed            = errors.New("account closed")
 	ErrCurrencyMismatch         = errors.New("currency mismatch")
 	ErrVersionConflict          = errors.New("optimistic lock conflict")
+	ErrTxContention             = errors.New("transaction aborted by concurrent contention")
 	ErrInvalidRequest           = errors.New("invalid request")
 	ErrPaymentTerminal          = errors.New("payment already in terminal state")
+	ErrInvalidTransition        = errors.New("payment status transition not allowed")
 	ErrDuplicateIdempotencyKey  = errors.New("duplicate idempotency key")
+	ErrMissingIdempotencyKey    = errors.New("idempotency key required")
+	ErrAlreadyProcessed         = errors.New("event already processed")
+	ErrNoProviderRoute          = errors.New("no payout provider configured for corridor")
+	ErrPaymentNotRetriable      = errors.New("payment cannot be retried")
+	ErrPaymentAlreadyRetried    = errors.New("payment has already been retried")
+	ErrSettlementClosed         = errors.New("settlement batch already closed")
+	ErrSettlementEmpty          = errors.New("settlement batch has no payments")
+	ErrDisputeExists            = errors.New("an active dispute already exists for this payment")
+	ErrDisputeClosed            = errors.New("dispute already closed")
+	ErrKYCTierInsufficient      = errors.New("kyc tier does not allow this operation")
+	ErrKYCSubmissionPending     = errors.New("a verification submission is already pending")
+	ErrKYCSubmissionClosed      = errors.New("verification submission already reviewed")
+	ErrPaymentNotPendingReview  = errors.New("payment is not pending review")
+	ErrDenylistEntryExists      = errors.New("denylist entry already exists")
+	ErrUniqueNameTaken          = errors.New("unique name already taken")
+	ErrUniqueNameCoolingDown    = errors.New("unique name was recently released and cannot be claimed yet")
+	ErrEmailTaken               = errors.New("email already in use")
+	ErrPaymentNotReversible     = errors.New("only completed internal transfers can be reversed")
+	ErrPaymentNotRefundable     = errors.New("only completed internal transfers can be refunded")
+	ErrPaymentNotResolvable     = errors.New("only payouts waiting on their provider can be resolved by hand")
+	ErrRefundExceedsPayment     = errors.New("refund exceeds the amount left to refund")
+	ErrQASampleAlreadyReviewed  = errors.New("qa sample already reviewed")
+	ErrPaymentRequestClosed     = errors.New("payment request is no longer pending")
+	ErrPaymentRequestExpired    = errors.New("payment request has expired")
+	ErrHoldNotActive            = errors.New("hold is no longer active")
+	ErrHoldAmountExceeded       = errors.New("amount exceeds the hold")
+	ErrSweepRequired            = errors.New("account has a balance and needs a sweep destination")
+	ErrAccountCloseBlocked      = errors.New("account has active holds or payouts in flight")
+	ErrDefaultAccountNotSet     = errors.New("no source currency given and no default account set")
+	ErrChainBreakAnnotated      = errors.New("ledger chain break already annotated")
+	ErrAPIKeyRevoked            = errors.New("api key has been revoked")
+	ErrAPIKeyLimitReached       = errors.New("active api key limit reached")
+	ErrResetTokenInvalid        = errors.New("password reset token is invalid or expired")
+	ErrTOTPRequired             = errors.New("a one-time code is required")
+	ErrInvalidTOTPCode          = errors.New("one-time code is invalid")
+	ErrTOTPNotEnabled           = errors.New("two-factor authentication is not enabled")
+	ErrTOTPAlreadyEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrBeneficiaryExists        = errors.New("a beneficiary with this iban already exists")
+	ErrBeneficiaryDeleted       = errors.New("beneficiary has been deleted")
+	ErrBeneficiaryInUse         = errors.New("beneficiary is used by payouts still in flight")
+	ErrAccountNotFrozen         = errors.New("account is not frozen")
+	ErrResidencyRestricted      = errors.New("payment not permitted for the sender's country of residence")
+	ErrResidencyRuleExists      = errors.New("a residency rule with this scope already exists")
+	ErrWebhookEventNotRetriable = errors.New("only failed webhook events can be retried")
+
+	ErrLoginChallengeRequired    = errors.New("a login challenge must be solved")
+	ErrLoginChallengeFailed      = errors.New("login challenge solution is invalid")
+	ErrLoginChallengeUnavailable = errors.New("login challenge mode is not configured")
 )
+
+// DomainError wraps a sentinel error with context about what tripped it:
+// which account, which limit, which status. errors.Is still matches the
+// sentinel. Handlers report the context as the error's details, so it must
+// only hold what the caller may see; the other side of a payment gets the
+// bare sentinel, as with AccountFrozenError.
+type DomainError struct {
+	Err     error
+	Context map[string]any
+}
+
+// NewDomainError wraps err with context given as alternating keys and
+// values, as slog takes attributes. Keys are the snake_case names clients
+// see. A key without a value is dropped.
+func NewDomainError(err error, keyvals ...any) *DomainError {
+	e := &DomainError{Err: err, Context: make(map[string]any, len(keyvals)/2)}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		e.Context[key] = keyvals[i+1]
+	}
+	return e
+}
+
+func (e *DomainError) Error() string {
+	if len(e.Context) == 0 {
+		return e.Err.Error()
+	}
+	keys := make([]string, 0, len(e.Context))
+	for k := range e.Context {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteString(" (")
+		} else {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%v", k, e.Context[k])
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (e *DomainError) Unwrap() error { return e.Err }
+
+// ErrorContext merges the context of every DomainError in err's chain. Where
+// two set the same key, the outermost wins, so a caller can refine what a
+// callee reported. It is nil when there is none.
+func ErrorContext(err error) map[string]any {
+	var ctx map[string]any
+	for err != nil {
+		if de, ok := err.(*DomainError); ok {
+			for k, v := range de.Context {
+				if ctx == nil {
+					ctx = make(map[string]any)
+				}
+				if _, set := ctx[k]; !set {
+					ctx[k] = v
+				}
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return ctx
+}
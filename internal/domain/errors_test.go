@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainError_WrapsSentinelWithContext(t *testing.T) {
+	inner := NewDomainError(ErrLimitExceeded, "currency", CurrencyUSD, "limit", int64(10000))
+	err := fmt.Errorf("CreateTransfer: %w", NewDomainError(fmt.Errorf("validate: %w", inner), "limit", int64(5000), "amount", int64(7500)))
+
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	var de *DomainError
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, map[string]any{"currency": CurrencyUSD, "limit": int64(5000), "amount": int64(7500)}, ErrorContext(err),
+		"contexts merge, the outer one winning")
+	assert.Equal(t, "transaction limit exceeded (currency=USD, limit=10000)", inner.Error())
+
+	assert.Nil(t, ErrorContext(fmt.Errorf("x: %w", ErrLimitExceeded)))
+	assert.Nil(t, ErrorContext(nil))
+	assert.Equal(t, "not found", NewDomainError(ErrNotFound, "dangling").Error(), "a key without a value is dropped")
+}
@@ -0,0 +1,291 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type Type string
+
+const (
+	TypePaymentCreated   Type = "payment.created"
+	TypePaymentCompleted Type = "payment.completed"
+	TypePaymentFailed    Type = "payment.failed"
+	TypePaymentHeld      Type = "payment.held"
+	TypePaymentReleased  Type = "payment.released"
+	TypePaymentReversed  Type = "payment.reversed"
+	TypePaymentRefunded  Type = "payment.refunded"
+	TypeAccountCreated   Type = "account.created"
+)
+
+var ErrUnknownEvent = errors.New("unknown event type or version")
+
+type Event interface {
+	EventType() Type
+	EventVersion() int
+}
+
+// Envelope wraps every event payload. ID is only set on events published to
+// the event bus, where consumers use it to drop redeliveries.
+type Envelope struct {
+	ID      *uuid.UUID      `json:"id,omitempty"`
+	Type    Type            `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type PaymentCreatedV1 struct {
+	PaymentID      uuid.UUID `json:"payment_id"`
+	PaymentType    string    `json:"payment_type"`
+	SourceAmount   int64     `json:"source_amount"`
+	SourceCurrency string    `json:"source_currency"`
+	DestAmount     int64     `json:"dest_amount"`
+	DestCurrency   string    `json:"dest_currency"`
+	ExchangeRate   string    `json:"exchange_rate,omitempty"`
+	FeeAmount      int64     `json:"fee_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (PaymentCreatedV1) EventType() Type   { return TypePaymentCreated }
+func (PaymentCreatedV1) EventVersion() int { return 1 }
+
+type PaymentCompletedV1 struct {
+	PaymentID    uuid.UUID `json:"payment_id"`
+	PaymentType  string    `json:"payment_type"`
+	DestAmount   int64     `json:"dest_amount"`
+	DestCurrency string    `json:"dest_currency"`
+	ProviderRef  string    `json:"provider_ref,omitempty"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+func (PaymentCompletedV1) EventType() Type   { return TypePaymentCompleted }
+func (PaymentCompletedV1) EventVersion() int { return 1 }
+
+type PaymentFailedV1 struct {
+	PaymentID   uuid.UUID `json:"payment_id"`
+	PaymentType string    `json:"payment_type"`
+	Reason      string    `json:"reason"`
+	Code        string    `json:"code,omitempty"`
+	Reversed    bool      `json:"reversed"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+func (PaymentFailedV1) EventType() Type   { return TypePaymentFailed }
+func (PaymentFailedV1) EventVersion() int { return 1 }
+
+type PaymentHeldV1 struct {
+	PaymentID   uuid.UUID `json:"payment_id"`
+	PaymentType string    `json:"payment_type"`
+	RuleID      string    `json:"rule_id"`
+	Reason      string    `json:"reason"`
+	HeldAt      time.Time `json:"held_at"`
+}
+
+func (PaymentHeldV1) EventType() Type   { return TypePaymentHeld }
+func (PaymentHeldV1) EventVersion() int { return 1 }
+
+// PaymentHeldV2 covers every route into the review queue, not just
+// screening matches. RuleID is only set for screening.
+type PaymentHeldV2 struct {
+	PaymentID    uuid.UUID `json:"payment_id"`
+	PaymentType  string    `json:"payment_type"`
+	ReviewReason string    `json:"review_reason"`
+	RuleID       string    `json:"rule_id,omitempty"`
+	Detail       string    `json:"detail"`
+	HeldAt       time.Time `json:"held_at"`
+}
+
+func (PaymentHeldV2) EventType() Type   { return TypePaymentHeld }
+func (PaymentHeldV2) EventVersion() int { return 2 }
+
+type PaymentReleasedV1 struct {
+	PaymentID   uuid.UUID `json:"payment_id"`
+	PaymentType string    `json:"payment_type"`
+	Provider    string    `json:"provider,omitempty"`
+	ReleasedAt  time.Time `json:"released_at"`
+}
+
+func (PaymentReleasedV1) EventType() Type   { return TypePaymentReleased }
+func (PaymentReleasedV1) EventVersion() int { return 1 }
+
+type PaymentReversedV1 struct {
+	PaymentID         uuid.UUID `json:"payment_id"`
+	PaymentType       string    `json:"payment_type"`
+	ReversalPaymentID uuid.UUID `json:"reversal_payment_id"`
+	Reason            string    `json:"reason"`
+	ReversedAt        time.Time `json:"reversed_at"`
+}
+
+func (PaymentReversedV1) EventType() Type   { return TypePaymentReversed }
+func (PaymentReversedV1) EventVersion() int { return 1 }
+
+type PaymentRefundedV1 struct {
+	PaymentID       uuid.UUID `json:"payment_id"`
+	PaymentType     string    `json:"payment_type"`
+	RefundPaymentID uuid.UUID `json:"refund_payment_id"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	RefundedTotal   int64     `json:"refunded_total"`
+	Reason          string    `json:"reason,omitempty"`
+	RefundedAt      time.Time `json:"refunded_at"`
+}
+
+func (PaymentRefundedV1) EventType() Type   { return TypePaymentRefunded }
+func (PaymentRefundedV1) EventVersion() int { return 1 }
+
+type AccountCreatedV1 struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Currency    string    `json:"currency"`
+	AccountType string    `json:"account_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (AccountCreatedV1) EventType() Type   { return TypeAccountCreated }
+func (AccountCreatedV1) EventVersion() int { return 1 }
+
+func Marshal(e Event) (json.RawMessage, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("events.Marshal: %s: %w", e.EventType(), err)
+	}
+	raw, err := json.Marshal(Envelope{Type: e.EventType(), Version: e.EventVersion(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("events.Marshal: envelope: %w", err)
+	}
+	return raw, nil
+}
+
+func Unmarshal(raw []byte) (Event, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("events.Unmarshal: envelope: %w", err)
+	}
+
+	var e Event
+	switch {
+	case env.Type == TypePaymentCreated && env.Version == 1:
+		e = &PaymentCreatedV1{}
+	case env.Type == TypePaymentCompleted && env.Version == 1:
+		e = &PaymentCompletedV1{}
+	case env.Type == TypePaymentFailed && env.Version == 1:
+		e = &PaymentFailedV1{}
+	case env.Type == TypePaymentHeld && env.Version == 1:
+		e = &PaymentHeldV1{}
+	case env.Type == TypePaymentHeld && env.Version == 2:
+		e = &PaymentHeldV2{}
+	case env.Type == TypePaymentReleased && env.Version == 1:
+		e = &PaymentReleasedV1{}
+	case env.Type == TypePaymentReversed && env.Version == 1:
+		e = &PaymentReversedV1{}
+	case env.Type == TypePaymentRefunded && env.Version == 1:
+		e = &PaymentRefundedV1{}
+	case env.Type == TypeAccountCreated && env.Version == 1:
+		e = &AccountCreatedV1{}
+	default:
+		return nil, fmt.Errorf("events.Unmarshal: %s v%d: %w", env.Type, env.Version, ErrUnknownEvent)
+	}
+
+	if err := json.Unmarshal(env.Data, e); err != nil {
+		return nil, fmt.Errorf("events.Unmarshal: %s v%d: %w", env.Type, env.Version, err)
+	}
+	return e, nil
+}
+
+func NewPaymentCreated(p *domain.Payment) PaymentCreatedV1 {
+	e := PaymentCreatedV1{
+		PaymentID:      p.ID,
+		PaymentType:    string(p.Type),
+		SourceAmount:   p.Source.Amount,
+		SourceCurrency: string(p.Source.Currency),
+		DestAmount:     p.Dest.Amount,
+		DestCurrency:   string(p.Dest.Currency),
+		FeeAmount:      p.Fee.Amount,
+		CreatedAt:      p.CreatedAt,
+	}
+	if p.ExchangeRate != nil {
+		e.ExchangeRate = p.ExchangeRate.String()
+	}
+	return e
+}
+
+func NewPaymentCompleted(p *domain.Payment, providerRef string, completedAt time.Time) PaymentCompletedV1 {
+	return PaymentCompletedV1{
+		PaymentID:    p.ID,
+		PaymentType:  string(p.Type),
+		DestAmount:   p.Dest.Amount,
+		DestCurrency: string(p.Dest.Currency),
+		ProviderRef:  providerRef,
+		CompletedAt:  completedAt,
+	}
+}
+
+func NewPaymentFailed(p *domain.Payment, reason string, code domain.FailureCode, reversed bool, failedAt time.Time) PaymentFailedV1 {
+	return PaymentFailedV1{
+		PaymentID:   p.ID,
+		PaymentType: string(p.Type),
+		Reason:      reason,
+		Code:        string(code),
+		Reversed:    reversed,
+		FailedAt:    failedAt,
+	}
+}
+
+func NewPaymentHeld(p *domain.Payment, reason domain.ReviewReason, ruleID, detail string, heldAt time.Time) PaymentHeldV2 {
+	return PaymentHeldV2{
+		PaymentID:    p.ID,
+		PaymentType:  string(p.Type),
+		ReviewReason: string(reason),
+		RuleID:       ruleID,
+		Detail:       detail,
+		HeldAt:       heldAt,
+	}
+}
+
+func NewPaymentReleased(p *domain.Payment, provider string, releasedAt time.Time) PaymentReleasedV1 {
+	return PaymentReleasedV1{
+		PaymentID:   p.ID,
+		PaymentType: string(p.Type),
+		Provider:    provider,
+		ReleasedAt:  releasedAt,
+	}
+}
+
+func NewPaymentReversed(p *domain.Payment, reversalID uuid.UUID, reason string, reversedAt time.Time) PaymentReversedV1 {
+	return PaymentReversedV1{
+		PaymentID:         p.ID,
+		PaymentType:       string(p.Type),
+		ReversalPaymentID: reversalID,
+		Reason:            reason,
+		ReversedAt:        reversedAt,
+	}
+}
+
+func NewPaymentRefunded(p *domain.Payment, refund *domain.Payment, reason string, refundedAt time.Time) PaymentRefundedV1 {
+	return PaymentRefundedV1{
+		PaymentID:       p.ID,
+		PaymentType:     string(p.Type),
+		RefundPaymentID: refund.ID,
+		Amount:          refund.Source.Amount,
+		Currency:        string(refund.Source.Currency),
+		RefundedTotal:   p.Refunded.Amount,
+		Reason:          reason,
+		RefundedAt:      refundedAt,
+	}
+}
+
+func NewAccountCreated(a *domain.Account) AccountCreatedV1 {
+	return AccountCreatedV1{
+		AccountID:   a.ID,
+		UserID:      a.UserID,
+		Currency:    string(a.Currency),
+		AccountType: string(a.AccountType),
+		CreatedAt:   a.CreatedAt,
+	}
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal_RoundTrip(t *testing.T) {
+	now := time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
+	paymentID := uuid.New()
+
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{"created", &PaymentCreatedV1{PaymentID: paymentID, PaymentType: "external_payout", SourceAmount: 100, SourceCurrency: "USD", DestAmount: 92, DestCurrency: "EUR", ExchangeRate: "0.92", CreatedAt: now}},
+		{"completed", &PaymentCompletedV1{PaymentID: paymentID, PaymentType: "external_payout", DestAmount: 92, DestCurrency: "EUR", ProviderRef: "ref-1", CompletedAt: now}},
+		{"failed", &PaymentFailedV1{PaymentID: paymentID, PaymentType: "external_payout", Reason: "bank down", Code: "bank_unavailable", Reversed: true, FailedAt: now}},
+		{"held", &PaymentHeldV1{PaymentID: paymentID, PaymentType: "external_payout", RuleID: "denylist:iban", Reason: "sanctioned", HeldAt: now}},
+		{"held v2", &PaymentHeldV2{PaymentID: paymentID, PaymentType: "external_payout", ReviewReason: "amount_threshold", Detail: "amount 5000000 USD at or above review threshold 5000000", HeldAt: now}},
+		{"released", &PaymentReleasedV1{PaymentID: paymentID, PaymentType: "external_payout", Provider: "mock_provider", ReleasedAt: now}},
+		{"refunded", &PaymentRefundedV1{PaymentID: paymentID, PaymentType: "internal_transfer", RefundPaymentID: uuid.New(), Amount: 500, Currency: "USD", RefundedTotal: 1500, Reason: "item returned", RefundedAt: now}},
+		{"reversed", &PaymentReversedV1{PaymentID: paymentID, PaymentType: "internal_transfer", ReversalPaymentID: uuid.New(), Reason: "sent to wrong recipient", ReversedAt: now}},
+		{"account created", &AccountCreatedV1{AccountID: uuid.New(), UserID: uuid.New(), Currency: "GBP", AccountType: "user", CreatedAt: now}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := Marshal(tc.event)
+			require.NoError(t, err)
+
+			var env Envelope
+			require.NoError(t, json.Unmarshal(raw, &env))
+			assert.Equal(t, tc.event.EventType(), env.Type)
+			assert.Equal(t, tc.event.EventVersion(), env.Version)
+
+			got, err := Unmarshal(raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.event, got)
+		})
+	}
+}
+
+func TestUnmarshal_UnknownVersion(t *testing.T) {
+	_, err := Unmarshal([]byte(`{"type":"payment.completed","version":99,"data":{}}`))
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FXCorridorRevenue aggregates conversions for one source/destination pair.
+// Amounts are in minor units: source volume in the source currency, everything
+// else in the destination currency.
+type FXCorridorRevenue struct {
+	SourceCurrency  Currency
+	DestCurrency    Currency
+	ConversionCount int
+	SourceVolume    int64
+	DestVolume      int64
+	FeeTotal        int64
+	SlippageTotal   int64
+	AvgSlippageBps  decimal.Decimal
+	P50SlippageBps  decimal.Decimal
+	P95SlippageBps  decimal.Decimal
+	MaxSlippageBps  decimal.Decimal
+}
+
+// FeeRevenue summarises a revenue account. Balance is everything accumulated
+// to date; Earned and Reversed cover the requested window only.
+type FeeRevenue struct {
+	Currency   Currency
+	AccountID  uuid.UUID
+	Balance    int64
+	Earned     int64
+	Reversed   int64
+	EntryCount int
+}
+
+// LedgerCategoryTotal is one line of the trial balance: what was debited and
+// credited in a currency under one entry category.
+type LedgerCategoryTotal struct {
+	Currency   Currency
+	Category   EntryCategory
+	Debits     int64
+	Credits    int64
+	EntryCount int
+}
+
+// FXRateRecord is one pair's rate for one user tier, from the refresh at
+// which it last changed. SpreadPct is the spread that tier's conversions
+// pay. Via is the base currency of a cross rate, empty for a pair with its
+// own rate.
+type FXRateRecord struct {
+	FromCurrency  Currency
+	ToCurrency    Currency
+	Tier          UserTier
+	MidMarketRate decimal.Decimal
+	SpreadPct     decimal.Decimal
+	Via           Currency
+	RecordedAt    time.Time
+}
+
+// EffectiveRate is the rate a conversion by the record's tier got.
+func (r FXRateRecord) EffectiveRate() decimal.Decimal {
+	return r.MidMarketRate.Mul(decimal.NewFromInt(1).Sub(r.SpreadPct))
+}
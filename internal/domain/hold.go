@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusReleased HoldStatus = "released"
+)
+
+// Hold reserves part of an account's balance without moving money. Active
+// holds reduce what the account can spend until they're captured by a
+// payment, released, or expire.
+type Hold struct {
+	ID             uuid.UUID
+	AccountID      uuid.UUID
+	Amount         int64
+	Currency       Currency
+	Status         HoldStatus
+	Reference      *string
+	PaymentID      *uuid.UUID
+	CapturedAmount *int64
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	ResolvedAt     *time.Time
+}
+
+// IsLive reports whether the hold still reserves funds at now. Expired
+// holds stay active in storage but no longer count.
+func (h *Hold) IsLive(now time.Time) bool {
+	return h.Status == HoldStatusActive && now.Before(h.ExpiresAt)
+}
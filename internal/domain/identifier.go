@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdentifierKind string
+
+const (
+	IdentifierEmail      IdentifierKind = "email"
+	IdentifierUniqueName IdentifierKind = "unique_name"
+)
+
+// IdentifierChange records a user moving from one email or grey tag to
+// another. OldValue is nil for a first claim.
+type IdentifierChange struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      IdentifierKind
+	OldValue  *string
+	NewValue  *string
+	ChangedAt time.Time
+}
+
+// RecipientCheck is what a sender sees before paying a grey tag.
+// ReassignedAt is set when the tag belonged to someone else until recently.
+type RecipientCheck struct {
+	UniqueName   string
+	Name         string
+	ReassignedAt *time.Time
+}
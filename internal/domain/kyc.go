@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type KYCTier string
+
+const (
+	KYCTierUnverified KYCTier = "unverified"
+	KYCTierBasic      KYCTier = "basic"
+	KYCTierFull       KYCTier = "full"
+)
+
+func (t KYCTier) IsValid() bool {
+	switch t {
+	case KYCTierUnverified, KYCTierBasic, KYCTierFull:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t KYCTier) rank() int {
+	switch t {
+	case KYCTierBasic:
+		return 1
+	case KYCTierFull:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether t is as verified as other or more.
+func (t KYCTier) AtLeast(other KYCTier) bool {
+	return t.rank() >= other.rank()
+}
+
+// CanPayOut reports whether the tier may send money off-platform.
+func (t KYCTier) CanPayOut() bool {
+	return t.AtLeast(KYCTierBasic)
+}
+
+// HasFullLimits reports whether the tier gets the configured limits rather
+// than the reduced ones.
+func (t KYCTier) HasFullLimits() bool {
+	return t == KYCTierFull
+}
+
+type KYCDocumentType string
+
+const (
+	KYCDocumentPassport       KYCDocumentType = "passport"
+	KYCDocumentNationalID     KYCDocumentType = "national_id"
+	KYCDocumentDriversLicense KYCDocumentType = "drivers_license"
+)
+
+func (d KYCDocumentType) IsValid() bool {
+	switch d {
+	case KYCDocumentPassport, KYCDocumentNationalID, KYCDocumentDriversLicense:
+		return true
+	default:
+		return false
+	}
+}
+
+type KYCStatus string
+
+const (
+	KYCStatusPending  KYCStatus = "pending"
+	KYCStatusApproved KYCStatus = "approved"
+	KYCStatusRejected KYCStatus = "rejected"
+)
+
+func (s KYCStatus) IsValid() bool {
+	switch s {
+	case KYCStatusPending, KYCStatusApproved, KYCStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// KYCSubmission is a user's request to move up a tier, reviewed by staff.
+// DocumentRef points at the document in the verification vendor's store; the
+// document itself never touches this database.
+type KYCSubmission struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	RequestedTier KYCTier
+	DocumentType  KYCDocumentType
+	DocumentRef   string
+	Status        KYCStatus
+	ReviewedBy    *uuid.UUID
+	ReviewedAt    *time.Time
+	ReviewNote    *string
+	CreatedAt     time.Time
+}
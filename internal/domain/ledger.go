@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,14 +14,98 @@ const (
 	EntryTypeCredit EntryType = "credit"
 )
 
+// EntryCategory says which part of a payment a ledger entry carries, so
+// reports can total fees or reversals without joining to payments.
+type EntryCategory string
+
+const (
+	// EntryCategoryPrincipal is the money sent and received, including its
+	// moves through the clearing and settlement accounts.
+	EntryCategoryPrincipal EntryCategory = "principal"
+	// EntryCategoryFee is a fee booked to a revenue account.
+	EntryCategoryFee EntryCategory = "fee"
+	// EntryCategoryFXSpread is a leg through an FX pool, where a conversion
+	// is made at mid-market and the spread taken.
+	EntryCategoryFXSpread EntryCategory = "fx_spread"
+	// EntryCategoryReversal undoes earlier entries: reversals, refunds and
+	// the compensating entries of a failed payout.
+	EntryCategoryReversal EntryCategory = "reversal"
+	// EntryCategoryAdjustment moves money between system accounts, such as
+	// a treasury transfer between FX pools.
+	EntryCategoryAdjustment EntryCategory = "adjustment"
+)
+
 type LedgerEntry struct {
 	ID            uuid.UUID
 	PaymentID     uuid.UUID
 	AccountID     uuid.UUID
 	EntryType     EntryType
-	Amount        int64
-	Currency      Currency
+	Category      EntryCategory
+	Amount        Money
 	BalanceBefore int64
 	BalanceAfter  int64
 	CreatedAt     time.Time
 }
+
+// NewLedgerEntry builds the entry that posts amount to acct: a debit takes
+// it off the balance and a credit adds it. An amount in any currency but the
+// account's fails with ErrCurrencyMismatch instead of mixing units in the
+// balance.
+func NewLedgerEntry(paymentID uuid.UUID, acct *Account, entryType EntryType, category EntryCategory, amount Money, at time.Time) (*LedgerEntry, error) {
+	if amount.Currency != acct.Currency {
+		return nil, fmt.Errorf("post %s to %s account %s: %w", amount.Currency, acct.Currency, acct.ID, ErrCurrencyMismatch)
+	}
+	after := acct.Balance + amount.Amount
+	if entryType == EntryTypeDebit {
+		after = acct.Balance - amount.Amount
+	}
+	return &LedgerEntry{
+		ID:            uuid.New(),
+		PaymentID:     paymentID,
+		AccountID:     acct.ID,
+		EntryType:     entryType,
+		Category:      category,
+		Amount:        amount,
+		BalanceBefore: acct.Balance,
+		BalanceAfter:  after,
+		CreatedAt:     at,
+	}, nil
+}
+
+// LedgerCursor marks a position in an account's entries, newest first. A
+// page continues with the entries strictly older than the cursor; ID breaks
+// ties between entries written in the same instant.
+type LedgerCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Cursor is the position just past e.
+func (e *LedgerEntry) Cursor() LedgerCursor {
+	return LedgerCursor{CreatedAt: e.CreatedAt, ID: e.ID}
+}
+
+// LedgerChainBreak is a ledger entry whose BalanceBefore doesn't match the
+// BalanceAfter of the account's previous entry. The span runs from the
+// previous entry to the offending one. Ledger entries are immutable, so a
+// break is repaired with compensating entries and staff annotate the break
+// with what was done.
+type LedgerChainBreak struct {
+	ID              uuid.UUID
+	AccountID       uuid.UUID
+	EntryID         uuid.UUID
+	EntryCreatedAt  time.Time
+	PrevEntryID     uuid.UUID
+	PrevCreatedAt   time.Time
+	ExpectedBefore  int64
+	ActualBefore    int64
+	DetectedAt      time.Time
+	Annotation      *string
+	RepairPaymentID *uuid.UUID
+	AnnotatedBy     *uuid.UUID
+	AnnotatedAt     *time.Time
+}
+
+func (b *LedgerChainBreak) Annotated() bool {
+	return b.AnnotatedAt != nil
+}
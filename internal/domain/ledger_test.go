@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLedgerEntry(t *testing.T) {
+	acct := &Account{ID: uuid.New(), Currency: CurrencyUSD, Balance: 10_000}
+	now := time.Now().UTC()
+
+	debit, err := NewLedgerEntry(uuid.New(), acct, EntryTypeDebit, EntryCategoryPrincipal, NewMoney(2_500, CurrencyUSD), now)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(2_500, CurrencyUSD), debit.Amount)
+	assert.Equal(t, int64(10_000), debit.BalanceBefore)
+	assert.Equal(t, int64(7_500), debit.BalanceAfter)
+
+	credit, err := NewLedgerEntry(uuid.New(), acct, EntryTypeCredit, EntryCategoryPrincipal, NewMoney(2_500, CurrencyUSD), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12_500), credit.BalanceAfter)
+
+	_, err = NewLedgerEntry(uuid.New(), acct, EntryTypeCredit, EntryCategoryPrincipal, NewMoney(2_500, CurrencyEUR), now)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch, "a EUR amount can't be posted to a USD account")
+}
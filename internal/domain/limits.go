@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LimitPeriod string
+
+const (
+	LimitPeriodDaily   LimitPeriod = "daily"
+	LimitPeriodMonthly LimitPeriod = "monthly"
+)
+
+// Window is the rolling lookback the period's usage is summed over.
+func (p LimitPeriod) Window() time.Duration {
+	if p == LimitPeriodMonthly {
+		return 30 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// PeriodLimitError reports a cumulative cap breach along with the allowance
+// the user has left, so clients can tell them how much they can still send.
+type PeriodLimitError struct {
+	Period    LimitPeriod
+	Currency  Currency
+	Limit     int64
+	Used      int64
+	Remaining int64
+}
+
+func (e *PeriodLimitError) Error() string {
+	return fmt.Sprintf("%s %s limit exceeded: used %d of %d", e.Period, e.Currency, e.Used, e.Limit)
+}
+
+func (e *PeriodLimitError) Unwrap() error { return ErrPeriodLimitExceeded }
+
+// UserLimit overrides the default per-transaction limit for one user in one
+// currency, typically after a KYC tier change.
+type UserLimit struct {
+	UserID    uuid.UUID
+	Currency  Currency
+	TxLimit   int64
+	Reason    string
+	UpdatedBy uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TxLimit is the per-transaction limit in force for a user and currency.
+// Override is nil when the default applies.
+type TxLimit struct {
+	Currency Currency
+	Default  int64
+	Override *UserLimit
+}
+
+func (l TxLimit) Effective() int64 {
+	if l.Override != nil {
+		return l.Override.TxLimit
+	}
+	return l.Default
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginChallengeMode is what a client must do before a login's password is
+// checked.
+type LoginChallengeMode string
+
+const (
+	LoginChallengeOff         LoginChallengeMode = "off"
+	LoginChallengeProofOfWork LoginChallengeMode = "proof_of_work"
+	LoginChallengeCaptcha     LoginChallengeMode = "captcha"
+)
+
+func (m LoginChallengeMode) IsValid() bool {
+	switch m {
+	case LoginChallengeOff, LoginChallengeProofOfWork, LoginChallengeCaptcha:
+		return true
+	}
+	return false
+}
+
+// LoginChallengeSetting is the runtime setting staff turn on while the login
+// endpoint is under attack. Difficulty is the number of leading zero bits a
+// proof of work needs; other modes ignore it. UpdatedBy is nil until staff
+// first change the setting.
+type LoginChallengeSetting struct {
+	Mode       LoginChallengeMode `json:"mode"`
+	Difficulty int                `json:"difficulty,omitempty"`
+	UpdatedBy  *uuid.UUID         `json:"-"`
+	UpdatedAt  *time.Time         `json:"-"`
+}
+
+// LoginChallenge is handed to one client IP to solve before it logs in.
+// Token is set for proof of work and SiteKey for a CAPTCHA.
+type LoginChallenge struct {
+	Mode       LoginChallengeMode
+	Token      string
+	Difficulty int
+	SiteKey    string
+	ExpiresAt  *time.Time
+}
+
+// LoginChallengeProof is what a client sends with its login: the challenge
+// token it was given and its solution, or for a CAPTCHA just the widget's
+// response token as the solution.
+type LoginChallengeProof struct {
+	Challenge string
+	Solution  string
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units of one currency. Arithmetic and
+// comparison between amounts in different currencies fail with
+// ErrCurrencyMismatch instead of silently mixing units.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("add %s to %s: %w", o.Currency, m.Currency, ErrCurrencyMismatch)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("subtract %s from %s: %w", o.Currency, m.Currency, ErrCurrencyMismatch)
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("compare %s with %s: %w", m.Currency, o.Currency, ErrCurrencyMismatch)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// String formats m in major units, e.g. "USD 19.99".
+func (m Money) String() string {
+	return string(m.Currency) + " " + m.Decimal()
+}
+
+// Decimal formats m in major units with as many decimal places as its
+// currency has, e.g. "19.99" for 1999 USD cents. This is how the API shows
+// amounts; ParseMoney reads them back.
+func (m Money) Decimal() string {
+	digits := strconv.FormatUint(absUint(m.Amount), 10)
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	exp := m.Currency.Exponent()
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// ParseMoney reads s, an amount in major units such as "150.00" or "-0.5",
+// as an amount of currency. s may have fewer decimal places than the
+// currency but not more, since they would be fractions of a minor unit.
+// Exponents, a leading "+", and a bare "." aren't accepted.
+func ParseMoney(s string, currency Currency) (Money, error) {
+	invalid := func() (Money, error) {
+		return Money{}, NewDomainError(ErrMalformedAmount, "amount", s, "currency", currency, "max_decimals", currency.Exponent())
+	}
+
+	digits := strings.TrimPrefix(s, "-")
+	neg := len(digits) < len(s)
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || hasPoint && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return invalid()
+	}
+	exp := currency.Exponent()
+	if len(frac) > exp {
+		return invalid()
+	}
+
+	n, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return invalid()
+	}
+	if neg {
+		n = -n
+	}
+	return Money{Amount: n, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type moneyJSON struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if !v.Currency.IsValid() {
+		return fmt.Errorf("money: %q: %w", v.Currency, ErrInvalidCurrency)
+	}
+	*m = Money{Amount: v.Amount, Currency: v.Currency}
+	return nil
+}
+
+// Value stores m in a text column as "<minor units> <currency>", e.g.
+// "1999 USD". Tables that keep amount and currency in separate columns scan
+// them into the fields directly.
+func (m Money) Value() (driver.Value, error) {
+	return strconv.FormatInt(m.Amount, 10) + " " + string(m.Currency), nil
+}
+
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	amount, currency, ok := strings.Cut(s, " ")
+	if !ok {
+		return fmt.Errorf("money: malformed value %q", s)
+	}
+	n, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: malformed amount %q: %w", amount, err)
+	}
+	if !Currency(currency).IsValid() {
+		return fmt.Errorf("money: %q: %w", currency, ErrInvalidCurrency)
+	}
+	*m = Money{Amount: n, Currency: Currency(currency)}
+	return nil
+}
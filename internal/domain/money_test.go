@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	usd := NewMoney(1999, CurrencyUSD)
+
+	sum, err := usd.Add(NewMoney(1, CurrencyUSD))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(2000, CurrencyUSD), sum)
+
+	diff, err := usd.Sub(NewMoney(2000, CurrencyUSD))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(-1, CurrencyUSD), diff)
+
+	c, err := usd.Cmp(NewMoney(2000, CurrencyUSD))
+	require.NoError(t, err)
+	assert.Equal(t, -1, c)
+
+	_, err = usd.Add(NewMoney(1, CurrencyEUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = usd.Sub(NewMoney(1, CurrencyEUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = usd.Cmp(NewMoney(1, CurrencyEUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "USD 19.99", NewMoney(1999, CurrencyUSD).String())
+	assert.Equal(t, "EUR 0.05", NewMoney(5, CurrencyEUR).String())
+	assert.Equal(t, "GBP -1.50", NewMoney(-150, CurrencyGBP).String())
+}
+
+// withExponents registers currencies that aren't supported yet, so the
+// tests can cover minor units other than cents.
+func withExponents(t *testing.T, exps map[Currency]int) {
+	registry := currencyRegistry
+	currencyRegistry = append([]CurrencySpec(nil), registry...)
+	for c, e := range exps {
+		currencyRegistry = append(currencyRegistry, CurrencySpec{Code: c, Exponent: e})
+	}
+	t.Cleanup(func() { currencyRegistry = registry })
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	withExponents(t, map[Currency]int{"JPY": 0, "KWD": 3})
+
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{NewMoney(15000, CurrencyUSD), "150.00"},
+		{NewMoney(5, CurrencyEUR), "0.05"},
+		{NewMoney(-150, CurrencyGBP), "-1.50"},
+		{NewMoney(0, CurrencyUSD), "0.00"},
+		{NewMoney(150, "JPY"), "150"},
+		{NewMoney(1500, "KWD"), "1.500"},
+		{NewMoney(-7, "KWD"), "-0.007"},
+		{NewMoney(math.MinInt64, CurrencyUSD), "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.m.Decimal())
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	withExponents(t, map[Currency]int{"JPY": 0, "KWD": 3})
+
+	valid := []struct {
+		s        string
+		currency Currency
+		want     int64
+	}{
+		{"150.00", CurrencyUSD, 15000},
+		{"150", CurrencyUSD, 15000},
+		{"0.5", CurrencyUSD, 50},
+		{"-1.50", CurrencyGBP, -150},
+		{"150", "JPY", 150},
+		{"1.5", "KWD", 1500},
+		{"0.007", "KWD", 7},
+		{"92233720368547758.07", CurrencyUSD, math.MaxInt64},
+	}
+	for _, tt := range valid {
+		m, err := ParseMoney(tt.s, tt.currency)
+		require.NoError(t, err, tt.s)
+		assert.Equal(t, NewMoney(tt.want, tt.currency), m, tt.s)
+		if strings.Contains(tt.s, ".") && len(tt.s)-strings.Index(tt.s, ".")-1 == tt.currency.Exponent() {
+			assert.Equal(t, tt.s, m.Decimal(), "round trip")
+		}
+	}
+
+	invalid := []struct {
+		s        string
+		currency Currency
+	}{
+		{"", CurrencyUSD},
+		{"-", CurrencyUSD},
+		{"1.001", CurrencyUSD},
+		{"1.5", "JPY"},
+		{"1.", CurrencyUSD},
+		{".5", CurrencyUSD},
+		{"+1", CurrencyUSD},
+		{"1e2", CurrencyUSD},
+		{" 1", CurrencyUSD},
+		{"1,000.00", CurrencyUSD},
+		{"--1", CurrencyUSD},
+		{"92233720368547758.08", CurrencyUSD},
+	}
+	for _, tt := range invalid {
+		_, err := ParseMoney(tt.s, tt.currency)
+		assert.ErrorIs(t, err, ErrMalformedAmount, "%q %s", tt.s, tt.currency)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1999, CurrencyUSD))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1999,"currency":"USD"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, NewMoney(1999, CurrencyUSD), m)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":1,"currency":"XYZ"}`), &m), ErrInvalidCurrency)
+}
+
+func TestMoney_SQL(t *testing.T) {
+	v, err := NewMoney(-250, CurrencyGBP).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "-250 GBP", v)
+
+	var m Money
+	require.NoError(t, m.Scan([]byte("-250 GBP")))
+	assert.Equal(t, NewMoney(-250, CurrencyGBP), m)
+
+	assert.Error(t, m.Scan("250"))
+	assert.Error(t, m.Scan("abc USD"))
+	assert.ErrorIs(t, m.Scan("250 XYZ"), ErrInvalidCurrency)
+	assert.Error(t, m.Scan(42))
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is an entry in a user's in-app feed. Subject and Body are
+// rendered when it's created; the template fields say which version of
+// which template they came from. PaymentID is set for notifications about a
+// payment. ReadAt is nil until the user marks it read.
+type Notification struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Kind            string
+	Subject         string
+	Body            string
+	PaymentID       *uuid.UUID
+	TemplateName    string
+	TemplateLocale  string
+	TemplateVersion string
+	CreatedAt       time.Time
+	ReadAt          *time.Time
+}
+
+// NotificationCursor marks a position in a feed, newest first. ID breaks
+// ties between notifications created in the same instant.
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Cursor is the position just past n.
+func (n *Notification) Cursor() NotificationCursor {
+	return NotificationCursor{CreatedAt: n.CreatedAt, ID: n.ID}
+}
+
+// PaymentParties is what notifying the people on either side of a payment
+// needs: the amounts and who sent and received it. RecipientID is nil for
+// payments that leave to a bank. SenderTag is the sender's grey tag, if
+// they have one.
+type PaymentParties struct {
+	PaymentID   uuid.UUID
+	Type        PaymentType
+	Source      Money
+	Dest        Money
+	SenderID    uuid.UUID
+	SenderTag   *string
+	RecipientID *uuid.UUID
+}
+
+// NotificationEmail is a notification waiting to be emailed to its user at
+// Email. Attempts counts the sends that have failed so far.
+type NotificationEmail struct {
+	Notification
+	Email    string
+	Attempts int
+}
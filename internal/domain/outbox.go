@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is an event waiting to be published to the event bus. Key
+// orders events on the bus: events with the same key are delivered in the
+// order they were written.
+type OutboxEvent struct {
+	ID          uuid.UUID
+	Topic       string
+	Key         string
+	Payload     json.RawMessage
+	Attempts    int
+	LastError   *string
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+	FannedOutAt *time.Time
+}
+
+// OutboxCursor marks a position in the outbox, oldest first.
+type OutboxCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Cursor is the position just past e.
+func (e *OutboxEvent) Cursor() OutboxCursor {
+	return OutboxCursor{CreatedAt: e.CreatedAt, ID: e.ID}
+}
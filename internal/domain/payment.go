@@ -13,6 +13,26 @@ type PaymentType string
 const (
 	PaymentTypeInternalTransfer PaymentType = "internal_transfer"
 	PaymentTypeExternalPayout   PaymentType = "external_payout"
+	// PaymentTypeReversal moves the money of a reversed internal transfer
+	// back to the sender.
+	PaymentTypeReversal PaymentType = "reversal"
+	// PaymentTypeRefund returns part of a received internal transfer to the
+	// sender.
+	PaymentTypeRefund PaymentType = "refund"
+	// PaymentTypeSweep empties an account that is being closed, into another
+	// of the owner's accounts or out to a bank.
+	PaymentTypeSweep PaymentType = "sweep"
+	// PaymentTypePoolTransfer moves liquidity from one FX pool to another
+	// at the mid-market rate. Only treasury admins create these.
+	PaymentTypePoolTransfer PaymentType = "pool_transfer"
+)
+
+// PaymentDirection is how a payment relates to the user viewing it.
+type PaymentDirection string
+
+const (
+	PaymentDirectionSent     PaymentDirection = "sent"
+	PaymentDirectionReceived PaymentDirection = "received"
 )
 
 type PaymentStatus string
@@ -23,8 +43,53 @@ const (
 	PaymentStatusCompleted  PaymentStatus = "completed"
 	PaymentStatusFailed     PaymentStatus = "failed"
 	PaymentStatusReversed   PaymentStatus = "reversed"
+	// PaymentStatusPendingReview marks an external payout flagged for manual
+	// review. Funds are debited but nothing is sent until staff approve it.
+	PaymentStatusPendingReview PaymentStatus = "pending_review"
+	// PaymentStatusPendingReversal marks a failed payout whose reversal is
+	// waiting on a system account (usually an FX pool) that was too short to
+	// pay it back. The sender stays debited until the reversal runs.
+	PaymentStatusPendingReversal PaymentStatus = "pending_reversal"
+)
+
+// ReviewReason records why a payment was sent to the review queue.
+type ReviewReason string
+
+const (
+	ReviewReasonScreening       ReviewReason = "screening"
+	ReviewReasonAmountThreshold ReviewReason = "amount_threshold"
+)
+
+type FailureCode string
+
+const (
+	FailureCodeBankUnavailable    FailureCode = "bank_unavailable"
+	FailureCodeProviderTimeout    FailureCode = "provider_timeout"
+	FailureCodeInvalidAccount     FailureCode = "invalid_account"
+	FailureCodeAccountClosed      FailureCode = "account_closed"
+	FailureCodeComplianceRejected FailureCode = "compliance_rejected"
+	FailureCodeProviderRejected   FailureCode = "provider_rejected"
 )
 
+func (c FailureCode) IsValid() bool {
+	switch c {
+	case FailureCodeBankUnavailable, FailureCodeProviderTimeout, FailureCodeInvalidAccount,
+		FailureCodeAccountClosed, FailureCodeComplianceRejected, FailureCodeProviderRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c FailureCode) IsRetriable() bool {
+	switch c {
+	case FailureCodeInvalidAccount, FailureCodeAccountClosed, FailureCodeComplianceRejected, FailureCodeProviderRejected:
+		return false
+	default:
+		return true
+	}
+}
+
 type Payment struct {
 	ID               uuid.UUID
 	IdempotencyKey   string
@@ -36,18 +101,52 @@ type Payment struct {
 	DestIBAN         *string
 	DestSwiftBIC     *string
 	DestBankName     *string
-	SourceAmount     int64
-	SourceCurrency   Currency
-	DestAmount       int64
-	DestCurrency     Currency
+	// Source is what the sender paid and Dest what the recipient is paid.
+	Source           Money
+	Dest             Money
 	ExchangeRate     *decimal.Decimal
-	FeeAmount        int64
-	FeeCurrency      *Currency
+	MidMarketRate    *decimal.Decimal
+	// Slippage is what the recipient got short of the mid-market amount, in
+	// the destination currency. Only converted payments have it.
+	Slippage         *Money
+	// Fee is the FX fee. Fees are taken in the destination currency, so a
+	// payment without one has a zero fee in that currency.
+	Fee              Money
 	Provider         *string
 	ProviderRef      *string
 	FailureReason    *string
+	FailureCode      *FailureCode
+	RetryOfPaymentID *uuid.UUID
+	ReviewReason     *ReviewReason
+	ReversalOfPaymentID *uuid.UUID
+	RefundOfPaymentID *uuid.UUID
+	// BeneficiaryID is the saved beneficiary a payout went to, if it was
+	// sent to one. DestIBAN and DestBankName are copied from it.
+	BeneficiaryID *uuid.UUID
+	// Refunded is the total refunded so far, in the destination currency.
+	Refunded Money
+	// UserTier is the sender's tier when the payment was priced, for user
+	// payments. System payments have none.
+	UserTier *UserTier
 	Metadata         json.RawMessage
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	SubmittedAt      *time.Time
 	CompletedAt      *time.Time
 }
+
+// SlippageBps is the slippage expressed in basis points of the amount the
+// recipient would have received at the mid-market rate.
+func (p *Payment) SlippageBps() (decimal.Decimal, bool) {
+	if p.Slippage == nil {
+		return decimal.Zero, false
+	}
+	mid, err := p.Dest.Add(*p.Slippage)
+	if err != nil || !mid.IsPositive() {
+		return decimal.Zero, false
+	}
+	return decimal.NewFromInt(p.Slippage.Amount).
+		Mul(decimal.NewFromInt(10000)).
+		Div(decimal.NewFromInt(mid.Amount)).
+		Round(2), true
+}
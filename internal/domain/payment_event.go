@@ -15,6 +15,9 @@ const (
 	PaymentEventTypeCompleted  PaymentEventType = "completed"
 	PaymentEventTypeFailed     PaymentEventType = "failed"
 	PaymentEventTypeReversed   PaymentEventType = "reversed"
+	PaymentEventTypeHeld       PaymentEventType = "held"
+	PaymentEventTypeReleased   PaymentEventType = "released"
+	PaymentEventTypeRefunded   PaymentEventType = "refunded"
 )
 
 type PaymentEvent struct {
@@ -25,3 +28,13 @@ type PaymentEvent struct {
 	Payload   json.RawMessage
 	CreatedAt time.Time
 }
+
+// PaymentEventArchive is a batch of payment events moved out of Postgres to
+// the archive store, where they are kept under ObjectKey.
+type PaymentEventArchive struct {
+	ObjectKey      string
+	EventCount     int
+	FirstCreatedAt time.Time
+	LastCreatedAt  time.Time
+	ArchivedAt     time.Time
+}
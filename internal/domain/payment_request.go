@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestStatusPending   PaymentRequestStatus = "pending"
+	PaymentRequestStatusAccepted  PaymentRequestStatus = "accepted"
+	PaymentRequestStatusDeclined  PaymentRequestStatus = "declined"
+	PaymentRequestStatusCancelled PaymentRequestStatus = "cancelled"
+	PaymentRequestStatusExpired   PaymentRequestStatus = "expired"
+)
+
+func (s PaymentRequestStatus) IsValid() bool {
+	switch s {
+	case PaymentRequestStatusPending, PaymentRequestStatusAccepted, PaymentRequestStatusDeclined,
+		PaymentRequestStatusCancelled, PaymentRequestStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// PaymentRequest asks the payer to send Amount in Currency to the requester.
+// Accepting it creates an internal transfer, linked through PaymentID.
+type PaymentRequest struct {
+	ID              uuid.UUID
+	RequesterUserID uuid.UUID
+	PayerUserID     uuid.UUID
+	Amount          int64
+	Currency        Currency
+	Note            *string
+	Status          PaymentRequestStatus
+	PaymentID       *uuid.UUID
+	ExpiresAt       time.Time
+	RespondedAt     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// IsExpired reports whether a pending request has passed its expiry, even if
+// the expiry job hasn't marked it yet.
+func (r *PaymentRequest) IsExpired(now time.Time) bool {
+	return r.Status == PaymentRequestStatusPending && !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,53 @@
+package domain
+
+// paymentTransitions lists the statuses each status may move to. Payments
+// are created in pending, pending_review or, for internal transfers that
+// settle at once, completed.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending: {
+		PaymentStatusProcessing,
+		PaymentStatusCompleted,
+		PaymentStatusFailed,
+		PaymentStatusPendingReversal,
+	},
+	PaymentStatusProcessing: {
+		PaymentStatusCompleted,
+		PaymentStatusFailed,
+		PaymentStatusPendingReversal,
+	},
+	// Approved back to pending, or rejected.
+	PaymentStatusPendingReview: {
+		PaymentStatusPending,
+		PaymentStatusFailed,
+		PaymentStatusPendingReversal,
+	},
+	// The provider has already failed it; only the reversal is left.
+	PaymentStatusPendingReversal: {
+		PaymentStatusFailed,
+	},
+	// An admin reversing an internal transfer.
+	PaymentStatusCompleted: {
+		PaymentStatusReversed,
+	},
+}
+
+// CanTransition reports whether a payment in status s may move to status to.
+func (s PaymentStatus) CanTransition(to PaymentStatus) bool {
+	for _, next := range paymentTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether the payment's outcome is settled. A completed
+// payment can still be reversed, but no provider outcome can change it.
+func (s PaymentStatus) IsTerminal() bool {
+	switch s {
+	case PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusReversed:
+		return true
+	default:
+		return false
+	}
+}
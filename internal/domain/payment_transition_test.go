@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to PaymentStatus
+		ok       bool
+	}{
+		{PaymentStatusPending, PaymentStatusProcessing, true},
+		{PaymentStatusPending, PaymentStatusCompleted, true},
+		{PaymentStatusProcessing, PaymentStatusFailed, true},
+		{PaymentStatusPendingReview, PaymentStatusPending, true},
+		{PaymentStatusPendingReversal, PaymentStatusFailed, true},
+		{PaymentStatusCompleted, PaymentStatusReversed, true},
+		{PaymentStatusCompleted, PaymentStatusFailed, false},
+		{PaymentStatusFailed, PaymentStatusCompleted, false},
+		{PaymentStatusReversed, PaymentStatusCompleted, false},
+		{PaymentStatusPendingReview, PaymentStatusCompleted, false},
+		{PaymentStatusPendingReversal, PaymentStatusCompleted, false},
+		{PaymentStatusProcessing, PaymentStatusPending, false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.ok, tc.from.CanTransition(tc.to), "%s to %s", tc.from, tc.to)
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderLatency is the time a provider took to report a terminal outcome
+// for a submitted payout.
+type ProviderLatency struct {
+	PaymentID      uuid.UUID
+	Provider       string
+	SourceCurrency Currency
+	DestCurrency   Currency
+	Outcome        PaymentStatus
+	SubmittedAt    time.Time
+	ResolvedAt     time.Time
+	LatencyMS      int64
+}
+
+type ProviderSLAStats struct {
+	Provider       string
+	SourceCurrency Currency
+	DestCurrency   Currency
+	Samples        int
+	Failed         int
+	P50MS          int64
+	P95MS          int64
+	P99MS          int64
+	MaxMS          int64
+}
+
+func (s ProviderSLAStats) Corridor() string {
+	return string(s.SourceCurrency) + "-" + string(s.DestCurrency)
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderErrorClass says how an attempt to submit a payout to its provider
+// failed, and so whether sending it again can help.
+type ProviderErrorClass string
+
+const (
+	// ProviderErrorNetwork: no response came back (connection refused,
+	// timeout). The provider may or may not have the payout.
+	ProviderErrorNetwork ProviderErrorClass = "network"
+	// ProviderErrorRetriable: the provider answered with a 5xx, a 408 or a
+	// 429.
+	ProviderErrorRetriable ProviderErrorClass = "retriable"
+	// ProviderErrorPermanent: the provider refused the payout (any other
+	// 4xx). Sending it again gets the same answer.
+	ProviderErrorPermanent ProviderErrorClass = "permanent"
+	// ProviderErrorValidation: the request itself was rejected as malformed,
+	// by the provider (400, 422) or before it was sent.
+	ProviderErrorValidation ProviderErrorClass = "validation"
+)
+
+var ProviderErrorClasses = []ProviderErrorClass{
+	ProviderErrorNetwork, ProviderErrorRetriable, ProviderErrorPermanent, ProviderErrorValidation,
+}
+
+func (c ProviderErrorClass) IsRetriable() bool {
+	return c == ProviderErrorNetwork || c == ProviderErrorRetriable
+}
+
+// FailureCode is the code a payout is failed with once its submission is
+// given up on.
+func (c ProviderErrorClass) FailureCode() FailureCode {
+	switch c {
+	case ProviderErrorNetwork:
+		return FailureCodeProviderTimeout
+	case ProviderErrorRetriable:
+		return FailureCodeBankUnavailable
+	default:
+		return FailureCodeProviderRejected
+	}
+}
+
+// ProviderErrorClassForStatus classifies an unexpected HTTP status from a
+// provider.
+func ProviderErrorClassForStatus(code int) ProviderErrorClass {
+	switch {
+	case code == http.StatusBadRequest, code == http.StatusUnprocessableEntity:
+		return ProviderErrorValidation
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return ProviderErrorRetriable
+	case code >= 400 && code < 500:
+		return ProviderErrorPermanent
+	default:
+		return ProviderErrorRetriable
+	}
+}
+
+// ProviderError is a classified submission failure. StatusCode is zero when
+// no response came back.
+type ProviderError struct {
+	Class      ProviderErrorClass
+	StatusCode int
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider %s error: %v", e.Class, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderErrorClassOf returns err's class. Errors no provider client
+// classified are treated as retriable.
+func ProviderErrorClassOf(err error) ProviderErrorClass {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Class
+	}
+	return ProviderErrorRetriable
+}
+
+// ProviderSubmission is one attempt to hand a payout to its provider.
+// ErrorClass is nil when the provider accepted it.
+type ProviderSubmission struct {
+	ID         uuid.UUID
+	PaymentID  uuid.UUID
+	Provider   string
+	Attempt    int
+	ErrorClass *ProviderErrorClass
+	StatusCode *int
+	Error      *string
+	CreatedAt  time.Time
+}
+
+// ProviderFailureStats counts a provider's submission attempts over a
+// period, by how the failed ones failed.
+type ProviderFailureStats struct {
+	Provider   string
+	Attempts   int
+	Accepted   int
+	Network    int
+	Retriable  int
+	Permanent  int
+	Validation int
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type QASampleStatus string
+
+const (
+	QASampleStatusPending  QASampleStatus = "pending"
+	QASampleStatusReviewed QASampleStatus = "reviewed"
+)
+
+// QASampleReason records what pushed a payment's sampling weight up.
+type QASampleReason string
+
+const (
+	QASampleReasonRandom        QASampleReason = "random"
+	QASampleReasonLargeAmount   QASampleReason = "large_amount"
+	QASampleReasonUnusualAmount QASampleReason = "unusual_amount"
+)
+
+type QAOutcome string
+
+const (
+	QAOutcomePass       QAOutcome = "pass"
+	QAOutcomeIssueFound QAOutcome = "issue_found"
+)
+
+func (o QAOutcome) IsValid() bool {
+	return o == QAOutcomePass || o == QAOutcomeIssueFound
+}
+
+// QACandidate is a completed payment the sampler may pick, with the sender's
+// usual payment size for comparison.
+type QACandidate struct {
+	PaymentID        uuid.UUID
+	PaymentType      PaymentType
+	Amount           int64
+	Currency         Currency
+	SenderAvgAmount  int64
+	SenderPriorCount int
+	CompletedAt      time.Time
+}
+
+// QASample is a payment picked for manual QA review. Amount, currency and the
+// weight are snapshotted at sampling time as evidence of why it was picked.
+type QASample struct {
+	ID          uuid.UUID
+	PaymentID   uuid.UUID
+	PaymentType PaymentType
+	Amount      int64
+	Currency    Currency
+	Reason      QASampleReason
+	Weight      float64
+	Probability float64
+	Status      QASampleStatus
+	Outcome     *QAOutcome
+	ReviewNote  *string
+	ReviewedBy  *uuid.UUID
+	ReviewedAt  *time.Time
+	SampledAt   time.Time
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NormalizeCountry upper-cases an ISO 3166-1 alpha-2 country code and
+// reports whether it has that shape. It doesn't check the code is assigned.
+func NormalizeCountry(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", false
+	}
+	return code, true
+}
+
+// IBANCountry is the country an IBAN's account is held in, taken from its
+// first two letters, or "" when it doesn't start with a country code.
+func IBANCountry(iban string) string {
+	iban = strings.TrimSpace(iban)
+	if len(iban) < 2 {
+		return ""
+	}
+	country, _ := NormalizeCountry(iban[:2])
+	return country
+}
+
+// ResidencyRule forbids users resident in Country from sending payments that
+// match it. A nil field matches anything, so a rule with none of them set
+// stops the residency from sending at all. DestCountry is where the money
+// lands: a payout IBAN's country, or the recipient's residency for an
+// internal transfer.
+type ResidencyRule struct {
+	ID             uuid.UUID
+	Country        string
+	SourceCurrency *Currency
+	DestCurrency   *Currency
+	DestCountry    *string
+	Reason         string
+	CreatedBy      uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ResidencyRestrictedError reports a payment that a residency rule forbids,
+// described the way the sender asked for it. DestCountry is empty when the
+// destination's country isn't known.
+type ResidencyRestrictedError struct {
+	RuleID         uuid.UUID
+	Residency      string
+	SourceCurrency Currency
+	DestCurrency   Currency
+	DestCountry    string
+}
+
+func (e *ResidencyRestrictedError) Error() string {
+	if e.DestCountry == "" {
+		return fmt.Sprintf("%s: %s to %s from %s", ErrResidencyRestricted, e.SourceCurrency, e.DestCurrency, e.Residency)
+	}
+	return fmt.Sprintf("%s: %s to %s in %s from %s", ErrResidencyRestricted, e.SourceCurrency, e.DestCurrency, e.DestCountry, e.Residency)
+}
+
+func (e *ResidencyRestrictedError) Unwrap() error { return ErrResidencyRestricted }
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RuntimeSettingLoginChallenge holds the LoginChallengeSetting.
+const RuntimeSettingLoginChallenge = "login_challenge"
+
+// RuntimeSetting is a setting staff change while the service runs. Value is
+// the setting as JSON.
+type RuntimeSetting struct {
+	Key       string
+	Value     json.RawMessage
+	UpdatedBy uuid.UUID
+	UpdatedAt time.Time
+}
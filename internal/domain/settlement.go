@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SettlementStatus string
+
+const (
+	SettlementStatusOpen   SettlementStatus = "open"
+	SettlementStatusClosed SettlementStatus = "closed"
+)
+
+type SettlementBatch struct {
+	ID             uuid.UUID
+	Currency       Currency
+	SettlementDate time.Time
+	Status         SettlementStatus
+	PaymentCount   int
+	TotalAmount    int64
+	ClosedAt       *time.Time
+	ClosedBy       *uuid.UUID
+	CreatedAt      time.Time
+}
+
+type SettlementItem struct {
+	PaymentID   uuid.UUID
+	Amount      int64
+	Provider    *string
+	ProviderRef *string
+	CompletedAt *time.Time
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InFlightKind string
+
+const (
+	InFlightRequest            InFlightKind = "http_request"
+	InFlightWebhookEvent       InFlightKind = "webhook_event"
+	InFlightProviderSubmission InFlightKind = "provider_submission"
+)
+
+// ShutdownCount summarises one kind of work across a shutdown. InFlight is
+// what was running when the drain began; StartedDuringDrain is work that
+// began after that (e.g. on a kept-alive connection).
+type ShutdownCount struct {
+	Kind               InFlightKind
+	InFlight           int
+	Completed          int
+	Abandoned          int
+	StartedDuringDrain int
+}
+
+// AbandonedOperation is work that was still running when the grace period
+// ran out.
+type AbandonedOperation struct {
+	Kind      InFlightKind
+	Ref       string
+	StartedAt time.Time
+}
+
+type ShutdownReport struct {
+	ID          uuid.UUID
+	Instance    string
+	Signal      string
+	GracePeriod time.Duration
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Counts      []ShutdownCount
+	Abandoned   []AbandonedOperation
+	CreatedAt   time.Time
+}
+
+func (r *ShutdownReport) Clean() bool {
+	return len(r.Abandoned) == 0
+}
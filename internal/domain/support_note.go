@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NoteSubjectType string
+
+const (
+	NoteSubjectPayment NoteSubjectType = "payment"
+	NoteSubjectUser    NoteSubjectType = "user"
+)
+
+type SupportNote struct {
+	ID               uuid.UUID
+	SubjectType      NoteSubjectType
+	SubjectID        uuid.UUID
+	AuthorID         uuid.UUID
+	Body             string
+	MentionedUserIDs []uuid.UUID
+	Flagged          bool
+	CreatedAt        time.Time
+}
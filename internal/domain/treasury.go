@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FXPoolWatermark is the balance below which an FX pool is reported as low
+// and operators are alerted.
+type FXPoolWatermark struct {
+	Currency     Currency
+	LowWatermark int64
+	UpdatedBy    uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// FXPool is the system FX pool account for one currency. Watermark is nil
+// when no low-watermark is configured.
+type FXPool struct {
+	Currency  Currency
+	AccountID uuid.UUID
+	Balance   int64
+	Watermark *FXPoolWatermark
+}
+
+func (p FXPool) Low() bool {
+	return p.Watermark != nil && p.Balance < p.Watermark.LowWatermark
+}
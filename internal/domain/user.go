@@ -14,6 +14,35 @@ const (
 	UserStatusClosed    UserStatus = "closed"
 )
 
+type UserRole string
+
+const (
+	UserRoleUser    UserRole = "user"
+	UserRoleSupport UserRole = "support"
+	UserRoleAdmin   UserRole = "admin"
+	// UserRolePaymentOps is an admin who may also settle a stuck payout by
+	// hand when its provider callback is lost. Grant it to few people.
+	UserRolePaymentOps UserRole = "payment_ops"
+)
+
+func (r UserRole) IsValid() bool {
+	switch r {
+	case UserRoleUser, UserRoleSupport, UserRoleAdmin, UserRolePaymentOps:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r UserRole) IsStaff() bool {
+	return r == UserRoleSupport || r.IsAdmin()
+}
+
+// IsAdmin reports whether r has every admin permission.
+func (r UserRole) IsAdmin() bool {
+	return r == UserRoleAdmin || r == UserRolePaymentOps
+}
+
 type User struct {
 	ID           uuid.UUID
 	Email        string
@@ -21,5 +50,64 @@ type User struct {
 	PasswordHash string
 	UniqueName   *string
 	Status       UserStatus
-	CreatedAt    time.Time
+	Role         UserRole
+	KYCTier      KYCTier
+	Tier         UserTier
+	// DefaultAccountID is the account payments are sent from when the request
+	// names no source currency.
+	DefaultAccountID *uuid.UUID
+	// Country is the ISO 3166-1 alpha-2 code of the user's country of
+	// residence, set by staff once it has been verified. Residency rules
+	// don't apply while it is nil.
+	Country *string
+	// SessionVersion goes up on every password change. Sessions issued at
+	// an earlier version are no longer accepted.
+	SessionVersion int
+	CreatedAt      time.Time
+}
+
+// LoginThrottle counts failed logins for one subject, an email address or a
+// client IP, within a window that starts at the first failure.
+type LoginThrottle struct {
+	Subject     string
+	Failures    int
+	WindowStart *time.Time
+	LockedUntil *time.Time
+	Lockouts    int
+}
+
+func (t *LoginThrottle) LockedAt(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// PasswordResetToken is a one-time token emailed to a user so they can set a
+// new password. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash []byte
+	CreatedIP string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (t *PasswordResetToken) UsableAt(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// UserTOTP is a user's authenticator app enrollment. The secret is stored
+// encrypted. Enrollment takes effect once a first code confirms it;
+// LastUsedStep is the time step of the last code accepted, so a code can't
+// be used twice.
+type UserTOTP struct {
+	UserID          uuid.UUID
+	SecretEncrypted []byte
+	ConfirmedAt     *time.Time
+	LastUsedStep    int64
+	CreatedAt       time.Time
+}
+
+func (t *UserTOTP) Enabled() bool {
+	return t.ConfirmedAt != nil
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserDevice is a browser or app a user has signed in from, told apart by
+// its user agent. LastIP is where it last signed in from.
+type UserDevice struct {
+	UserID        uuid.UUID
+	UserAgentHash string
+	UserAgent     string
+	LastIP        string
+	FirstSeenAt   time.Time
+	LastSeenAt    time.Time
+}
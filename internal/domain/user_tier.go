@@ -0,0 +1,21 @@
+package domain
+
+// UserTier is the commercial plan a user is on. It sets their FX spread and
+// scales their default limits; it is separate from the KYC tier, which
+// gates what they may do at all.
+type UserTier string
+
+const (
+	UserTierStandard UserTier = "standard"
+	UserTierPlus     UserTier = "plus"
+	UserTierBusiness UserTier = "business"
+)
+
+func (t UserTier) IsValid() bool {
+	switch t {
+	case UserTierStandard, UserTierPlus, UserTierBusiness:
+		return true
+	default:
+		return false
+	}
+}
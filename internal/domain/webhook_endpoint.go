@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventTest is the type of the event users send themselves to check
+// their receiver.
+const WebhookEventTest = "webhook.test"
+
+// WebhookEndpoint is where a user receives webhooks about their payments.
+// A user has at most one. Each request is signed with the endpoint's
+// secret, which is stored encrypted.
+type WebhookEndpoint struct {
+	UserID          uuid.UUID
+	URL             string
+	SecretEncrypted []byte
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	SecretRotatedAt *time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event waiting to be sent to a user's endpoint.
+// Payload is the event's envelope. URL and SecretEncrypted are the
+// endpoint's, filled in when deliveries are listed to send.
+type WebhookDelivery struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	EventID         uuid.UUID
+	EventType       string
+	Payload         json.RawMessage
+	Status          WebhookDeliveryStatus
+	Attempts        int
+	NextAttemptAt   time.Time
+	CreatedAt       time.Time
+	DeliveredAt     *time.Time
+	URL             string
+	SecretEncrypted []byte
+}
+
+// WebhookDeliveryAttempt is one request to a user's endpoint. DeliveryID is
+// nil for test events, and StatusCode when no response came back.
+type WebhookDeliveryAttempt struct {
+	ID         uuid.UUID
+	DeliveryID *uuid.UUID
+	UserID     uuid.UUID
+	EventID    uuid.UUID
+	EventType  string
+	Attempt    int
+	URL        string
+	StatusCode *int
+	LatencyMS  int
+	Error      *string
+	CreatedAt  time.Time
+}
+
+// Succeeded reports whether the endpoint answered with a 2xx.
+func (a *WebhookDeliveryAttempt) Succeeded() bool {
+	return a.StatusCode != nil && *a.StatusCode >= 200 && *a.StatusCode < 300
+}
@@ -5,12 +5,16 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type WebhookEventStatus string
 
 const (
-	WebhookEventStatusPending    WebhookEventStatus = "pending"
+	WebhookEventStatusPending WebhookEventStatus = "pending"
+	// WebhookEventStatusProcessing means a processor has claimed the event
+	// and holds it until its lease expires.
+	WebhookEventStatusProcessing WebhookEventStatus = "processing"
 	WebhookEventStatusDispatched WebhookEventStatus = "dispatched"
 	WebhookEventStatusFailed     WebhookEventStatus = "failed"
 )
@@ -22,13 +26,107 @@ const (
 	WebhookEventTypePaymentFailed    WebhookEventType = "payment.failed"
 )
 
+// WebhookPriority is the processing class a callback gets when it arrives.
+// Lower values are claimed first.
+type WebhookPriority int
+
+const (
+	WebhookPriorityHigh WebhookPriority = iota
+	WebhookPriorityNormal
+	WebhookPriorityLow
+)
+
+func (p WebhookPriority) String() string {
+	switch p {
+	case WebhookPriorityHigh:
+		return "high"
+	case WebhookPriorityNormal:
+		return "normal"
+	case WebhookPriorityLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
 type WebhookEvent struct {
 	ID             uuid.UUID
 	IdempotencyKey string
 	EventType      WebhookEventType
 	Payload        json.RawMessage
-	Status         WebhookEventStatus
-	Attempts       int
-	LastAttempt    *time.Time
+	// PaymentID is the payment the callback names. A payment's events are
+	// never claimed by two processors at once; events without one are
+	// ordered against nothing.
+	PaymentID *uuid.UUID
+	// Provider is the provider whose signature the callback carried. It is
+	// nil for events stored before callbacks recorded it.
+	Provider    *string
+	Status      WebhookEventStatus
+	Priority    WebhookPriority
+	Attempts    int
+	LastAttempt *time.Time
+	// LastError is why the last attempt didn't succeed.
+	LastError      *string
+	ClaimedBy      *string
+	LeaseExpiresAt *time.Time
 	CreatedAt      time.Time
 }
+
+// WebhookStats is a snapshot of the callback pipeline: the backlog now, and
+// how much was processed in each of the most recent intervals.
+type WebhookStats struct {
+	Counts          map[WebhookEventStatus]int
+	OldestPendingAt *time.Time
+	Intervals       []WebhookInterval
+}
+
+// WebhookInterval counts the events received and processed in [Start, End).
+// Processed events are dated by their last attempt.
+type WebhookInterval struct {
+	Start      time.Time
+	End        time.Time
+	Received   int
+	Dispatched int
+	Failed     int
+}
+
+// WebhookEventFilter selects events for the admin listing. Empty fields
+// match everything.
+type WebhookEventFilter struct {
+	Statuses  []WebhookEventStatus
+	PaymentID *uuid.UUID
+}
+
+// FailureRate is the share of events processed in the interval that failed,
+// or zero when nothing was processed.
+func (i WebhookInterval) FailureRate() decimal.Decimal {
+	processed := i.Dispatched + i.Failed
+	if processed == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(i.Failed)).Div(decimal.NewFromInt(int64(processed))).Round(4)
+}
+
+// ProviderRegistrationStatus tracks whether a provider has proved, through
+// the callback handshake, that it holds our webhook secret.
+type ProviderRegistrationStatus string
+
+const (
+	// ProviderRegistrationPending means the provider hasn't attempted the
+	// handshake yet. It has no row in provider_webhook_registrations.
+	ProviderRegistrationPending  ProviderRegistrationStatus = "pending"
+	ProviderRegistrationVerified ProviderRegistrationStatus = "verified"
+	// ProviderRegistrationFailed means every handshake so far has failed.
+	// A verified provider stays verified when a later handshake fails.
+	ProviderRegistrationFailed ProviderRegistrationStatus = "failed"
+)
+
+type ProviderRegistration struct {
+	Provider        string
+	Status          ProviderRegistrationStatus
+	Challenges      int
+	Failures        int
+	LastChallengeAt *time.Time
+	VerifiedAt      *time.Time
+	LastFailureAt   *time.Time
+}
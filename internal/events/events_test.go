@@ -0,0 +1,133 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func testEvent() *domain.OutboxEvent {
+	id := uuid.New()
+	return &domain.OutboxEvent{
+		ID:      id,
+		Topic:   "payment.completed",
+		Key:     uuid.New().String(),
+		Payload: json.RawMessage(`{"id":"` + id.String() + `","type":"payment.completed","version":1,"data":{}}`),
+	}
+}
+
+// runNATS starts an in-process NATS server that only accepts token.
+func runNATS(t *testing.T, token string) *server.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.Authorization = token
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	srv := runNATS(t, "tok")
+	url := "nats://tok@" + srv.Addr().String()
+
+	sub, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer sub.Close()
+	msgs := make(chan *nats.Msg, 1)
+	_, err = sub.ChanSubscribe("payment.completed", msgs)
+	require.NoError(t, err)
+	require.NoError(t, sub.Flush())
+
+	p, err := NewNATSPublisher(NATSConfig{URL: url, Timeout: time.Second})
+	require.NoError(t, err)
+	defer p.Close()
+	require.Eventually(t, p.conn.IsConnected, 2*time.Second, 10*time.Millisecond)
+
+	e := testEvent()
+	require.NoError(t, p.Publish(context.Background(), e))
+
+	select {
+	case got := <-msgs:
+		assert.Equal(t, e.ID.String(), got.Header.Get(nats.MsgIdHdr))
+		assert.Equal(t, string(e.Payload), string(got.Data))
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered")
+	}
+}
+
+func TestNATSPublisher_FailsWhileDisconnected(t *testing.T) {
+	srv := runNATS(t, "tok")
+	url := "nats://tok@" + srv.Addr().String()
+
+	p, err := NewNATSPublisher(NATSConfig{URL: url, Timeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+	defer p.Close()
+	require.Eventually(t, p.conn.IsConnected, 2*time.Second, 10*time.Millisecond)
+
+	srv.Shutdown()
+	require.Eventually(t, func() bool { return !p.conn.IsConnected() }, 2*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, p.Publish(context.Background(), testEvent()), "an event isn't buffered for later")
+}
+
+func TestNewNATSPublisher_ConnectsInBackground(t *testing.T) {
+	p, err := NewNATSPublisher(NATSConfig{URL: "nats://127.0.0.1:1", Timeout: 100 * time.Millisecond})
+	require.NoError(t, err, "an unreachable server doesn't stop startup")
+	defer p.Close()
+
+	assert.Error(t, p.Publish(context.Background(), testEvent()))
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, "payment.completed"))
+	require.NoError(t, err)
+	defer cluster.Close()
+
+	p, err := NewKafkaPublisher(KafkaConfig{Brokers: cluster.ListenAddrs(), Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer p.Close()
+
+	ctx := context.Background()
+	e := testEvent()
+	require.NoError(t, p.Publish(ctx, e))
+
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.ConsumeTopics("payment.completed"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	fetches := consumer.PollFetches(fetchCtx)
+	require.NoError(t, fetches.Err())
+	records := fetches.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, e.Key, string(records[0].Key))
+	assert.JSONEq(t, string(e.Payload), string(records[0].Value))
+	require.Len(t, records[0].Headers, 1)
+	assert.Equal(t, "id", records[0].Headers[0].Key)
+	assert.Equal(t, e.ID.String(), string(records[0].Headers[0].Value))
+}
+
+func TestKafkaPublisher_FailsWithoutBroker(t *testing.T) {
+	p, err := NewKafkaPublisher(KafkaConfig{Brokers: []string{"127.0.0.1:1"}, Timeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+	defer p.Close()
+
+	assert.Error(t, p.Publish(context.Background(), testEvent()))
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// KafkaPublisher publishes to Kafka, one Kafka topic per event topic.
+// Events are keyed so every event for one payment or account lands on the
+// same partition, in order. The producer is idempotent, so its own retries
+// don't duplicate a record; the event ID also goes in an id header for
+// consumers that deduplicate outbox redeliveries.
+type KafkaPublisher struct {
+	client  *kgo.Client
+	timeout time.Duration
+}
+
+// KafkaConfig configures the Kafka client. Brokers seeds the cluster
+// metadata; the client finds the rest of the brokers itself.
+type KafkaConfig struct {
+	Brokers []string
+	TLS     bool
+	Timeout time.Duration
+}
+
+// NewKafkaPublisher returns without contacting the cluster; brokers are
+// dialled on the first Publish.
+func NewKafkaPublisher(cfg KafkaConfig) (*KafkaPublisher, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ClientID("grey-api"),
+		kgo.DialTimeout(cfg.Timeout),
+		kgo.ProduceRequestTimeout(cfg.Timeout),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	}
+	if cfg.TLS {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("NewKafkaPublisher: %w", err)
+	}
+	return &KafkaPublisher{client: client, timeout: cfg.Timeout}, nil
+}
+
+// Publish produces e and returns once every in-sync replica has it.
+func (p *KafkaPublisher) Publish(ctx context.Context, e *domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	record := &kgo.Record{
+		Topic:   e.Topic,
+		Key:     []byte(e.Key),
+		Value:   e.Payload,
+		Headers: []kgo.RecordHeader{{Key: "id", Value: []byte(e.ID.String())}},
+	}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("KafkaPublisher.Publish: %s: %w", e.Topic, err)
+	}
+	return nil
+}
+
+// Close closes the connections to the brokers.
+func (p *KafkaPublisher) Close() {
+	p.client.Close()
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// NATSPublisher publishes to NATS, one subject per topic. The event ID goes
+// in the Nats-Msg-Id header, so a JetStream stream on the subjects drops
+// redeliveries itself.
+type NATSPublisher struct {
+	conn    *nats.Conn
+	timeout time.Duration
+}
+
+// NATSConfig configures the NATS connection. URL is nats://[user:pass@]host:port,
+// or tls:// to require TLS; a user with no password is sent as a token.
+// CAFile, when set, is the CA the server's certificate must chain to,
+// instead of the system roots, and also turns TLS on.
+type NATSConfig struct {
+	URL     string
+	CAFile  string
+	Timeout time.Duration
+}
+
+// NewNATSPublisher returns without waiting for the server: the connection
+// is made in the background and retried until it succeeds. Publishing
+// fails while the publisher isn't connected, rather than buffering events
+// the outbox would send again anyway.
+func NewNATSPublisher(cfg NATSConfig) (*NATSPublisher, error) {
+	opts := []nats.Option{
+		nats.Name("grey-api"),
+		nats.Timeout(cfg.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	}
+	if cfg.CAFile != "" {
+		opts = append(opts, nats.RootCAs(cfg.CAFile))
+	}
+
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("NewNATSPublisher: %w", err)
+	}
+	return &NATSPublisher{conn: conn, timeout: cfg.Timeout}, nil
+}
+
+// Publish sends e and flushes, so it returns once the server has taken the
+// message.
+func (p *NATSPublisher) Publish(ctx context.Context, e *domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	msg := nats.NewMsg(e.Topic)
+	msg.Header.Set(nats.MsgIdHdr, e.ID.String())
+	msg.Data = e.Payload
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("NATSPublisher.Publish: %s: %w", e.Topic, err)
+	}
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("NATSPublisher.Publish: %s: %w", e.Topic, err)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (p *NATSPublisher) Close() {
+	p.conn.Close()
+}
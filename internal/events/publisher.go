@@ -0,0 +1,32 @@
+// Package events publishes outbox events to an event bus. Delivery is at
+// least once: an event can be published again if marking it published
+// fails, so consumers drop redeliveries by the envelope's id.
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// Publisher sends one event to the bus. It returns once the bus has
+// accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, e *domain.OutboxEvent) error
+}
+
+// LogPublisher logs events instead of publishing them, for running without
+// a bus.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, e *domain.OutboxEvent) error {
+	p.logger.Info("event published", "event_id", e.ID, "topic", e.Topic, "key", e.Key)
+	return nil
+}
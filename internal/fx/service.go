@@ -3,6 +3,7 @@ package fx
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/shopspring/decimal"
@@ -14,40 +15,80 @@ type Quote struct {
 	MidMarketRate decimal.Decimal
 	EffectiveRate decimal.Decimal
 	SpreadPct     decimal.Decimal
+	// Via is the base currency a cross rate was derived through, or empty
+	// for a pair with a rate of its own.
+	Via domain.Currency
 }
 
+// Conversion is the result of converting Source into the destination
+// currency. Dest, Fee and Slippage are all in the destination currency.
 type Conversion struct {
-	SourceAmount  int64
-	DestAmount    int64
-	FeeAmount     int64
+	Source        domain.Money
+	Dest          domain.Money
+	Fee           domain.Money
 	ExchangeRate  decimal.Decimal
 	MidMarketRate decimal.Decimal
+	// Slippage is the mid-market destination amount minus Dest.
+	Slippage domain.Money
+}
+
+// MidMarketDest is what the recipient would get at the mid-market rate.
+func (c *Conversion) MidMarketDest() domain.Money {
+	return domain.Money{Amount: c.Dest.Amount + c.Slippage.Amount, Currency: c.Dest.Currency}
 }
 
 type RateService struct {
 	rates     map[string]decimal.Decimal
 	spreadPct decimal.Decimal
+	base      domain.Currency
 }
 
-func NewRateService(spreadPct float64) *RateService {
+// NewRateService quotes from rates, keyed FROM_TO, and quotes pairs
+// without a rate of their own through base: from to base, then base to the
+// destination.
+func NewRateService(spreadPct float64, base domain.Currency, rates map[string]decimal.Decimal) *RateService {
 	return &RateService{
 		spreadPct: decimal.NewFromFloat(spreadPct),
-		rates: map[string]decimal.Decimal{
-			"USD_EUR": decimal.NewFromFloat(0.92),
-			"EUR_USD": decimal.NewFromFloat(1.087),
-			"USD_GBP": decimal.NewFromFloat(0.79),
-			"GBP_USD": decimal.NewFromFloat(1.266),
-			"EUR_GBP": decimal.NewFromFloat(0.858),
-			"GBP_EUR": decimal.NewFromFloat(1.166),
-		},
+		base:      base,
+		rates:     rates,
+	}
+}
+
+// ParseRates parses FROM_TO=rate settings into the rates NewRateService
+// takes. Both codes must be registered currencies and every rate positive.
+func ParseRates(settings map[string]string) (map[string]decimal.Decimal, error) {
+	rates := make(map[string]decimal.Decimal, len(settings))
+	for pair, value := range settings {
+		from, to, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(pair)), "_")
+		if !ok || from == to {
+			return nil, fmt.Errorf("ParseRates: %q is not FROM_TO", pair)
+		}
+		for _, code := range []string{from, to} {
+			if _, ok := domain.LookupCurrency(domain.Currency(code)); !ok {
+				return nil, fmt.Errorf("ParseRates: %s: %q: %w", pair, code, domain.ErrInvalidCurrency)
+			}
+		}
+		rate, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("ParseRates: %s: rate %q must be a positive number", pair, value)
+		}
+		rates[pairKey(domain.Currency(from), domain.Currency(to))] = rate
 	}
+	return rates, nil
 }
 
 func pairKey(from, to domain.Currency) string {
 	return string(from) + "_" + string(to)
 }
 
-func (s *RateService) GetRate(_ context.Context, from, to domain.Currency) (*Quote, error) {
+// GetRate quotes the pair at the configured spread.
+func (s *RateService) GetRate(ctx context.Context, from, to domain.Currency) (*Quote, error) {
+	return s.GetRateWithSpread(ctx, from, to, s.spreadPct)
+}
+
+// GetRateWithSpread quotes the pair at the given spread, for pricing that
+// depends on who is converting.
+func (s *RateService) GetRateWithSpread(_ context.Context, from, to domain.Currency, spreadPct decimal.Decimal) (*Quote, error) {
 	if !from.IsValid() || !to.IsValid() {
 		return nil, fmt.Errorf("GetRate: invalid currency pair %s/%s: %w", from, to, domain.ErrInvalidCurrency)
 	}
@@ -62,43 +103,96 @@ func (s *RateService) GetRate(_ context.Context, from, to domain.Currency) (*Quo
 		}, nil
 	}
 
-	mid, ok := s.rates[pairKey(from, to)]
-	if !ok {
-		return nil, fmt.Errorf("GetRate: unsupported pair %s/%s: %w", from, to, domain.ErrInvalidCurrency)
+	mid, via, err := s.midRate(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("GetRate: %w", err)
 	}
 
-	effective := mid.Mul(decimal.NewFromInt(1).Sub(s.spreadPct))
+	// The spread is taken once, off the cross rate, so a derived pair costs
+	// the same as a direct one rather than paying it on both legs.
+	effective := mid.Mul(decimal.NewFromInt(1).Sub(spreadPct))
 
 	return &Quote{
 		FromCurrency:  from,
 		ToCurrency:    to,
 		MidMarketRate: mid,
 		EffectiveRate: effective,
-		SpreadPct:     s.spreadPct,
+		SpreadPct:     spreadPct,
+		Via:           via,
 	}, nil
 }
 
+// midRate is the pair's own rate, or else the cross rate through the base
+// currency. The legs are multiplied exactly, with no rounding between
+// them; conversions round once, to the destination's minor unit.
+func (s *RateService) midRate(from, to domain.Currency) (decimal.Decimal, domain.Currency, error) {
+	if mid, ok := s.rates[pairKey(from, to)]; ok {
+		return mid, "", nil
+	}
+	if s.base == "" || from == s.base || to == s.base {
+		return decimal.Zero, "", fmt.Errorf("midRate: unsupported pair %s/%s: %w", from, to, domain.ErrInvalidCurrency)
+	}
+	toBase, ok := s.rates[pairKey(from, s.base)]
+	if !ok {
+		return decimal.Zero, "", fmt.Errorf("midRate: unsupported pair %s/%s: %w", from, s.base, domain.ErrInvalidCurrency)
+	}
+	fromBase, ok := s.rates[pairKey(s.base, to)]
+	if !ok {
+		return decimal.Zero, "", fmt.Errorf("midRate: unsupported pair %s/%s: %w", s.base, to, domain.ErrInvalidCurrency)
+	}
+	return toBase.Mul(fromBase), s.base, nil
+}
+
+// Quotes quotes every pair of distinct currencies at the configured
+// spread, direct or cross, leaving out pairs that can't be quoted. It is
+// what a rate refresh records.
+func (s *RateService) Quotes(ctx context.Context, currencies []domain.Currency) []Quote {
+	var quotes []Quote
+	for _, from := range currencies {
+		for _, to := range currencies {
+			if from == to {
+				continue
+			}
+			q, err := s.GetRate(ctx, from, to)
+			if err != nil {
+				continue
+			}
+			quotes = append(quotes, *q)
+		}
+	}
+	return quotes
+}
+
+// Convert converts at the configured spread.
 func (s *RateService) Convert(ctx context.Context, amount int64, from, to domain.Currency) (*Conversion, error) {
+	return s.ConvertWithSpread(ctx, amount, from, to, s.spreadPct)
+}
+
+// ConvertWithSpread converts at the given spread.
+func (s *RateService) ConvertWithSpread(ctx context.Context, amount int64, from, to domain.Currency, spreadPct decimal.Decimal) (*Conversion, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("Convert: %w", domain.ErrInvalidAmount)
 	}
 
-	quote, err := s.GetRate(ctx, from, to)
+	quote, err := s.GetRateWithSpread(ctx, from, to, spreadPct)
 	if err != nil {
 		return nil, fmt.Errorf("Convert: %w", err)
 	}
 
 	if from == to {
 		return &Conversion{
-			SourceAmount:  amount,
-			DestAmount:    amount,
-			FeeAmount:     0,
+			Source:        domain.NewMoney(amount, from),
+			Dest:          domain.NewMoney(amount, to),
+			Fee:           domain.NewMoney(0, to),
 			ExchangeRate:  quote.EffectiveRate,
 			MidMarketRate: quote.MidMarketRate,
+			Slippage:      domain.NewMoney(0, to),
 		}, nil
 	}
 
-	src := decimal.NewFromInt(amount)
+	// Rates are per major unit, so a destination with more or fewer decimal
+	// places than the source scales by the difference.
+	src := decimal.NewFromInt(amount).Shift(int32(to.Exponent() - from.Exponent()))
 
 	destRaw := src.Mul(quote.EffectiveRate).Round(0)
 	destAmount := destRaw.IntPart()
@@ -113,10 +207,11 @@ func (s *RateService) Convert(ctx context.Context, amount int64, from, to domain
 	}
 
 	return &Conversion{
-		SourceAmount:  amount,
-		DestAmount:    destAmount,
-		FeeAmount:     fee,
+		Source:        domain.NewMoney(amount, from),
+		Dest:          domain.NewMoney(destAmount, to),
+		Fee:           domain.NewMoney(fee, to),
 		ExchangeRate:  quote.EffectiveRate,
 		MidMarketRate: quote.MidMarketRate,
+		Slippage:      domain.NewMoney(midRounded-destAmount, to),
 	}, nil
 }
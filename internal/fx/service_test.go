@@ -10,8 +10,24 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// testRates are the rates FX_RATES ships with.
+var testRates = map[string]decimal.Decimal{
+	"USD_EUR": decimal.RequireFromString("0.92"),
+	"EUR_USD": decimal.RequireFromString("1.087"),
+	"USD_GBP": decimal.RequireFromString("0.79"),
+	"GBP_USD": decimal.RequireFromString("1.266"),
+	"EUR_GBP": decimal.RequireFromString("0.858"),
+	"GBP_EUR": decimal.RequireFromString("1.166"),
+	"USD_NGN": decimal.RequireFromString("1550"),
+	"NGN_USD": decimal.RequireFromString("0.000645"),
+	"USD_KES": decimal.RequireFromString("129.5"),
+	"KES_USD": decimal.RequireFromString("0.00772"),
+	"USD_GHS": decimal.RequireFromString("15.4"),
+	"GHS_USD": decimal.RequireFromString("0.0649"),
+}
+
 func TestGetRate(t *testing.T) {
-	svc := NewRateService(0.005)
+	svc := NewRateService(0.005, domain.CurrencyUSD, testRates)
 	ctx := context.Background()
 
 	tests := []struct {
@@ -47,6 +63,22 @@ func TestGetRate(t *testing.T) {
 			wantEffective: "1",
 			wantSpread:    "0",
 		},
+		{
+			name:          "USD to NGN",
+			from:          domain.CurrencyUSD,
+			to:            domain.CurrencyNGN,
+			wantMid:       "1550",
+			wantEffective: "1542.25",
+			wantSpread:    "0.005",
+		},
+		{
+			name:          "NGN to EUR through USD",
+			from:          domain.CurrencyNGN,
+			to:            domain.CurrencyEUR,
+			wantMid:       "0.0005934",
+			wantEffective: "0.000590433",
+			wantSpread:    "0.005",
+		},
 		{
 			name:    "invalid currency",
 			from:    domain.CurrencyUSD,
@@ -88,6 +120,7 @@ func TestConvert(t *testing.T) {
 		to         domain.Currency
 		wantDest   int64
 		wantFee    int64
+		wantSlip   int64
 		wantErr    error
 	}{
 		{
@@ -98,6 +131,7 @@ func TestConvert(t *testing.T) {
 			to:        domain.CurrencyEUR,
 			wantDest:  9154,
 			wantFee:   46,
+			wantSlip:  46,
 		},
 		{
 			name:      "same currency passthrough",
@@ -154,7 +188,7 @@ func TestConvert(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewRateService(tc.spreadPct)
+			svc := NewRateService(tc.spreadPct, domain.CurrencyUSD, testRates)
 			conv, err := svc.Convert(ctx, tc.amount, tc.from, tc.to)
 
 			if tc.wantErr != nil {
@@ -163,10 +197,107 @@ func TestConvert(t *testing.T) {
 			}
 
 			require.NoError(t, err)
-			assert.Equal(t, tc.amount, conv.SourceAmount)
-			assert.Equal(t, tc.wantDest, conv.DestAmount)
-			assert.Equal(t, tc.wantFee, conv.FeeAmount)
-			assert.True(t, conv.DestAmount >= 1, "dest amount must be >= 1")
+			assert.Equal(t, domain.NewMoney(tc.amount, tc.from), conv.Source)
+			assert.Equal(t, domain.NewMoney(tc.wantDest, tc.to), conv.Dest)
+			assert.Equal(t, domain.NewMoney(tc.wantFee, tc.to), conv.Fee)
+			assert.Equal(t, domain.NewMoney(tc.wantSlip, tc.to), conv.Slippage)
+			assert.True(t, conv.Dest.Amount >= 1, "dest amount must be >= 1")
 		})
 	}
 }
+
+func TestConvertWithSpread(t *testing.T) {
+	svc := NewRateService(0.005, domain.CurrencyUSD, testRates)
+	ctx := context.Background()
+
+	conv, err := svc.ConvertWithSpread(ctx, 10000, domain.CurrencyUSD, domain.CurrencyEUR, decimal.RequireFromString("0.002"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(9182), conv.Dest.Amount)
+	assert.Equal(t, int64(18), conv.Fee.Amount)
+	assert.True(t, conv.ExchangeRate.Equal(decimal.RequireFromString("0.91816")))
+
+	standard, err := svc.Convert(ctx, 10000, domain.CurrencyUSD, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, int64(46), standard.Fee.Amount)
+}
+
+func TestGetRate_CrossRate(t *testing.T) {
+	ctx := context.Background()
+
+	quote, err := NewRateService(0.005, domain.CurrencyUSD, testRates).GetRate(ctx, domain.CurrencyKES, domain.CurrencyGHS)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyUSD, quote.Via)
+	assert.True(t, quote.MidMarketRate.Equal(decimal.RequireFromString("0.118888")), "the exact product of 0.00772 and 15.4, got %s", quote.MidMarketRate)
+
+	direct, err := NewRateService(0.005, domain.CurrencyUSD, testRates).GetRate(ctx, domain.CurrencyEUR, domain.CurrencyGBP)
+	require.NoError(t, err)
+	assert.Empty(t, direct.Via, "a pair with its own rate isn't derived")
+
+	_, err = NewRateService(0.005, domain.CurrencyEUR, testRates).GetRate(ctx, domain.CurrencyNGN, domain.CurrencyKES)
+	assert.ErrorIs(t, err, domain.ErrInvalidCurrency, "NGN has no EUR leg")
+}
+
+// TestConvert_CrossRateRoundsOnce converts a derived pair over a range of
+// amounts. Each result must be the exact cross rate rounded once; going
+// leg by leg through USD rounds twice and drifts from it.
+func TestConvert_CrossRateRoundsOnce(t *testing.T) {
+	svc := NewRateService(0, domain.CurrencyUSD, testRates)
+	ctx := context.Background()
+	cross := decimal.RequireFromString("0.0005934")
+	half := decimal.RequireFromString("0.5")
+
+	drifted := 0
+	for amount := int64(100_000); amount < 200_000; amount += 7 {
+		conv, err := svc.Convert(ctx, amount, domain.CurrencyNGN, domain.CurrencyEUR)
+		require.NoError(t, err)
+		exact := decimal.NewFromInt(amount).Mul(cross)
+		off := exact.Sub(decimal.NewFromInt(conv.Dest.Amount)).Abs()
+		require.True(t, off.LessThanOrEqual(half), "%d NGN: got %d, exact %s", amount, conv.Dest.Amount, exact)
+
+		usd, err := svc.Convert(ctx, amount, domain.CurrencyNGN, domain.CurrencyUSD)
+		require.NoError(t, err)
+		eur, err := svc.Convert(ctx, usd.Dest.Amount, domain.CurrencyUSD, domain.CurrencyEUR)
+		require.NoError(t, err)
+		if eur.Dest.Amount != conv.Dest.Amount {
+			drifted++
+		}
+	}
+	assert.Positive(t, drifted, "two roundings should drift somewhere in the range")
+}
+
+func TestQuotes(t *testing.T) {
+	svc := NewRateService(0.005, domain.CurrencyEUR, testRates)
+	quotes := svc.Quotes(context.Background(), []domain.Currency{domain.CurrencyUSD, domain.CurrencyGBP, domain.CurrencyNGN})
+
+	pairs := make(map[string]domain.Currency)
+	for _, q := range quotes {
+		pairs[pairKey(q.FromCurrency, q.ToCurrency)] = q.Via
+	}
+	// NGN has only USD legs, so with EUR as the base its GBP pairs can't be
+	// quoted and are left out.
+	assert.Equal(t, map[string]domain.Currency{
+		"USD_GBP": "", "GBP_USD": "", "USD_NGN": "", "NGN_USD": "",
+	}, pairs)
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(map[string]string{"usd_eur": "0.92", "EUR_USD": " 1.087 "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD_EUR": "0.92", "EUR_USD": "1.087"}, map[string]string{
+		"USD_EUR": rates["USD_EUR"].String(),
+		"EUR_USD": rates["EUR_USD"].String(),
+	})
+	assert.Len(t, rates, 2)
+
+	for _, bad := range []map[string]string{
+		{"USDEUR": "0.92"},
+		{"USD_USD": "1"},
+		{"USD_XYZ": "0.92"},
+		{"USD_EUR": "zero"},
+		{"USD_EUR": "0"},
+		{"USD_EUR": "-0.92"},
+	} {
+		_, err := ParseRates(bad)
+		assert.Error(t, err, "%v", bad)
+	}
+}
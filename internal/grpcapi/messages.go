@@ -0,0 +1,155 @@
+package grpcapi
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/grpcapi/paymentv1"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+// transferRequest validates m as the REST handler validates its body.
+func transferRequest(m *paymentv1.CreateTransferRequest) (payment.InternalTransferRequest, error) {
+	req := payment.InternalTransferRequest{
+		RecipientUniqueName: m.GetRecipientUniqueName(),
+		SourceCurrency:      domain.Currency(m.GetSourceCurrency()),
+		DestCurrency:        domain.Currency(m.GetDestCurrency()),
+		Amount:              m.GetAmount(),
+		IdempotencyKey:      m.GetIdempotencyKey(),
+	}
+	var err error
+	if req.SenderUserID, err = requiredUUID("sender_user_id", m.GetSenderUserId()); err != nil {
+		return req, err
+	}
+	if req.HoldID, err = optionalUUID("hold_id", m.GetHoldId()); err != nil {
+		return req, err
+	}
+	if req.RecipientUniqueName == "" {
+		return req, invalidArgument("recipient_unique_name", "required")
+	}
+	if err := validateCurrencies(m.GetSourceCurrency(), m.GetDestCurrency()); err != nil {
+		return req, err
+	}
+	if req.Amount <= 0 {
+		return req, invalidArgument("amount", "must be greater than 0")
+	}
+	return req, nil
+}
+
+// payoutRequest validates m as the REST handler validates its body.
+func payoutRequest(m *paymentv1.CreatePayoutRequest) (payment.ExternalPayoutRequest, error) {
+	req := payment.ExternalPayoutRequest{
+		SourceCurrency: domain.Currency(m.GetSourceCurrency()),
+		DestCurrency:   domain.Currency(m.GetDestCurrency()),
+		Amount:         m.GetAmount(),
+		DestIBAN:       m.GetDestIban(),
+		DestBankName:   m.GetDestBankName(),
+		IdempotencyKey: m.GetIdempotencyKey(),
+		TOTPCode:       m.GetTotpCode(),
+	}
+	var err error
+	if req.SenderUserID, err = requiredUUID("sender_user_id", m.GetSenderUserId()); err != nil {
+		return req, err
+	}
+	if req.HoldID, err = optionalUUID("hold_id", m.GetHoldId()); err != nil {
+		return req, err
+	}
+	if req.BeneficiaryID, err = optionalUUID("beneficiary_id", m.GetBeneficiaryId()); err != nil {
+		return req, err
+	}
+	if err := validateCurrencies(m.GetSourceCurrency(), m.GetDestCurrency()); err != nil {
+		return req, err
+	}
+	if req.Amount <= 0 {
+		return req, invalidArgument("amount", "must be greater than 0")
+	}
+	// A saved beneficiary stands in for dest_iban and dest_bank_name.
+	switch {
+	case req.BeneficiaryID != nil && (req.DestIBAN != "" || req.DestBankName != ""):
+		return req, invalidArgument("beneficiary_id", "cannot be combined with dest_iban or dest_bank_name")
+	case req.BeneficiaryID == nil && req.DestIBAN == "":
+		return req, invalidArgument("dest_iban", "required")
+	case req.BeneficiaryID == nil && req.DestBankName == "":
+		return req, invalidArgument("dest_bank_name", "required")
+	}
+	return req, nil
+}
+
+// paymentMessage is p as dir's party sees it. dir is empty for the sender
+// of a payment just created.
+func paymentMessage(p *domain.Payment, dir domain.PaymentDirection) *paymentv1.Payment {
+	m := &paymentv1.Payment{
+		Id:              p.ID.String(),
+		Type:            string(p.Type),
+		Status:          string(p.Status),
+		SourceAccountId: p.SourceAccountID.String(),
+		SourceAmount:    p.Source.Amount,
+		SourceCurrency:  string(p.Source.Currency),
+		DestAmount:      p.Dest.Amount,
+		DestCurrency:    string(p.Dest.Currency),
+		Direction:       string(dir),
+		CreatedAt:       timestamppb.New(p.CreatedAt),
+	}
+	if p.DestAccountID != nil {
+		m.DestAccountId = p.DestAccountID.String()
+	}
+	if p.ExchangeRate != nil {
+		m.ExchangeRate = p.ExchangeRate.String()
+	}
+	if p.CompletedAt != nil {
+		m.CompletedAt = timestamppb.New(*p.CompletedAt)
+	}
+	// The recipient doesn't see what the sender paid or why it failed.
+	if dir != domain.PaymentDirectionReceived {
+		m.FeeAmount = p.Fee.Amount
+		if !p.Fee.IsZero() {
+			m.FeeCurrency = string(p.Fee.Currency)
+		}
+		if p.FailureCode != nil {
+			m.FailureCode = string(*p.FailureCode)
+		}
+	}
+	return m
+}
+
+func requiredUUID(field, s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, invalidArgument(field, "required")
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, invalidArgument(field, "must be a UUID")
+	}
+	return id, nil
+}
+
+func optionalUUID(field, s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, invalidArgument(field, "must be a UUID")
+	}
+	return &id, nil
+}
+
+// validateCurrencies checks the currencies given; either may be left out.
+func validateCurrencies(source, dest string) error {
+	if source != "" && !domain.Currency(source).IsValid() {
+		return invalidArgument("source_currency", "must be one of "+domain.CurrencyList())
+	}
+	if dest != "" && !domain.Currency(dest).IsValid() {
+		return invalidArgument("dest_currency", "must be one of "+domain.CurrencyList())
+	}
+	return nil
+}
+
+func invalidArgument(field, msg string) *statusError {
+	return &statusError{code: codes.InvalidArgument, errCode: handler.ErrValidationFailed.Code, message: fmt.Sprintf("%s: %s", field, msg)}
+}
@@ -0,0 +1,184 @@
+// Package grpcapi serves PaymentService (proto/grey/payment/v1) to internal
+// services over gRPC. The messages and service interface are generated
+// into paymentv1 by protoc-gen-go and protoc-gen-go-grpc; this package
+// implements that interface on top of the payment service.
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/grpcapi/paymentv1"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+// maxMessageSize bounds a request message; the largest real one is well
+// under a kilobyte.
+const maxMessageSize = 64 << 10
+
+// Trailers that carry the REST error of a failed call.
+const (
+	errorCodeTrailer    = "grey-error-code"
+	errorDetailsTrailer = "grey-error-details"
+)
+
+// statusError is a call's outcome when it isn't OK. errCode is the REST
+// error code, so callers can handle both transports the same way.
+type statusError struct {
+	code    codes.Code
+	errCode string
+	message string
+	details any
+}
+
+func (e *statusError) Error() string { return e.message }
+
+type paymentService interface {
+	CreateInternalTransfer(ctx context.Context, req payment.InternalTransferRequest) (*domain.Payment, error)
+	CreateExternalPayout(ctx context.Context, req payment.ExternalPayoutRequest) (*domain.Payment, error)
+	GetPaymentForUser(ctx context.Context, paymentID, userID uuid.UUID) (*domain.Payment, domain.PaymentDirection, error)
+}
+
+// Server implements paymentv1.PaymentServiceServer.
+type Server struct {
+	paymentv1.UnimplementedPaymentServiceServer
+
+	payments paymentService
+	token    []byte
+}
+
+// NewServer returns a gRPC server for PaymentService that accepts calls
+// bearing token, which must not be empty. It can serve a listener itself,
+// or sit behind an HTTP/2 http.Server as an http.Handler.
+func NewServer(payments paymentService, token string) (*grpc.Server, error) {
+	if token == "" {
+		return nil, errors.New("NewServer: an auth token is required")
+	}
+	s := &Server{payments: payments, token: []byte(token)}
+	srv := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(mapErrors, s.authenticate),
+	)
+	paymentv1.RegisterPaymentServiceServer(srv, s)
+	return srv, nil
+}
+
+func (s *Server) CreateTransfer(ctx context.Context, in *paymentv1.CreateTransferRequest) (*paymentv1.Payment, error) {
+	req, err := transferRequest(in)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.payments.CreateInternalTransfer(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("CreateTransfer: %w", err)
+	}
+	return paymentMessage(p, ""), nil
+}
+
+func (s *Server) CreatePayout(ctx context.Context, in *paymentv1.CreatePayoutRequest) (*paymentv1.Payment, error) {
+	req, err := payoutRequest(in)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.payments.CreateExternalPayout(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("CreatePayout: %w", err)
+	}
+	return paymentMessage(p, ""), nil
+}
+
+func (s *Server) GetPayment(ctx context.Context, in *paymentv1.GetPaymentRequest) (*paymentv1.Payment, error) {
+	paymentID, err := requiredUUID("payment_id", in.GetPaymentId())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requiredUUID("user_id", in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	p, dir, err := s.payments.GetPaymentForUser(ctx, paymentID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPayment: %w", err)
+	}
+	return paymentMessage(p, dir), nil
+}
+
+// authenticate rejects calls without the bearer token before they reach a
+// method.
+func (s *Server) authenticate(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), s.token) == 1 {
+			return next(ctx, req)
+		}
+	}
+	return nil, &statusError{code: codes.Unauthenticated, errCode: handler.ErrInvalidToken.Code, message: "a valid bearer token is required"}
+}
+
+// mapErrors turns a failed call's error into its gRPC status. Service
+// errors are mapped as the REST API maps them, and the HTTP status chosen
+// there picks the gRPC code. The REST error code is sent in the
+// grey-error-code trailer and its details as percent-encoded JSON in
+// grey-error-details.
+func mapErrors(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	resp, err := next(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	var se *statusError
+	if !errors.As(err, &se) {
+		logging.FromContext(ctx).Warn("gRPC call failed", "error", err)
+		appErr, details := handler.DomainAppError(err)
+		se = &statusError{code: codeForHTTPStatus(appErr.Status), errCode: appErr.Code, message: appErr.Message, details: details}
+	}
+
+	trailer := metadata.Pairs(errorCodeTrailer, se.errCode)
+	if se.details != nil {
+		if b, err := json.Marshal(se.details); err == nil {
+			trailer.Set(errorDetailsTrailer, url.PathEscape(string(b)))
+		}
+	}
+	if err := grpc.SetTrailer(ctx, trailer); err != nil {
+		logging.FromContext(ctx).Warn("failed to set gRPC error trailers", "error", err)
+	}
+	return nil, status.Error(se.code, se.errCode+": "+se.message)
+}
+
+func codeForHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
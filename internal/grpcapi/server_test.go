@@ -0,0 +1,204 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/grpcapi/paymentv1"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+const testToken = "internal-token"
+
+type stubPayments struct {
+	transfer payment.InternalTransferRequest
+	payment  *domain.Payment
+	dir      domain.PaymentDirection
+	err      error
+}
+
+func (s *stubPayments) CreateInternalTransfer(_ context.Context, req payment.InternalTransferRequest) (*domain.Payment, error) {
+	s.transfer = req
+	return s.payment, s.err
+}
+
+func (s *stubPayments) CreateExternalPayout(_ context.Context, _ payment.ExternalPayoutRequest) (*domain.Payment, error) {
+	return s.payment, s.err
+}
+
+func (s *stubPayments) GetPaymentForUser(_ context.Context, _, _ uuid.UUID) (*domain.Payment, domain.PaymentDirection, error) {
+	return s.payment, s.dir, s.err
+}
+
+type callResult struct {
+	code    codes.Code
+	message string
+	errCode string
+	details string
+}
+
+// dial serves stub over HTTP/2 without TLS, as the app does, and returns a
+// client connection to it.
+func dial(t *testing.T, stub *stubPayments) *grpc.ClientConn {
+	t.Helper()
+	s, err := NewServer(stub, testToken)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(s)
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// call invokes method with token and returns the call's status and
+// trailers.
+func call(t *testing.T, stub *stubPayments, method, token string, in any) (*paymentv1.Payment, callResult) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	out := new(paymentv1.Payment)
+	var trailer metadata.MD
+	err := dial(t, stub).Invoke(ctx, "/"+paymentv1.PaymentService_ServiceDesc.ServiceName+"/"+method, in, out, grpc.Trailer(&trailer))
+
+	st := status.Convert(err)
+	res := callResult{code: st.Code(), message: st.Message()}
+	if v := trailer.Get(errorCodeTrailer); len(v) > 0 {
+		res.errCode = v[0]
+	}
+	if v := trailer.Get(errorDetailsTrailer); len(v) > 0 {
+		res.details, err = url.PathUnescape(v[0])
+		require.NoError(t, err)
+	}
+	if res.code != codes.OK {
+		return nil, res
+	}
+	return out, res
+}
+
+func testPayment() *domain.Payment {
+	dest := uuid.New()
+	reason := domain.FailureCode("INSUFFICIENT_FUNDS")
+	return &domain.Payment{
+		ID: uuid.New(), Type: domain.PaymentTypeInternalTransfer, Status: domain.PaymentStatusCompleted,
+		SourceAccountID: uuid.New(), DestAccountID: &dest,
+		Source: domain.NewMoney(2500, domain.CurrencyUSD),
+		Dest:   domain.NewMoney(2500, domain.CurrencyUSD),
+		Fee:    domain.NewMoney(25, domain.CurrencyUSD), FailureCode: &reason,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func TestServer_CreateTransfer(t *testing.T) {
+	p := testPayment()
+	stub := &stubPayments{payment: p}
+	sender := uuid.New()
+
+	out, res := call(t, stub, "CreateTransfer", testToken, &paymentv1.CreateTransferRequest{
+		SenderUserId:        sender.String(),
+		RecipientUniqueName: "bob",
+		SourceCurrency:      "USD",
+		Amount:              2500,
+		IdempotencyKey:      "key-1",
+	})
+	require.Equal(t, codes.OK, res.code, res.message)
+	assert.Equal(t, sender, stub.transfer.SenderUserID)
+	assert.Equal(t, "bob", stub.transfer.RecipientUniqueName)
+	assert.Equal(t, domain.CurrencyUSD, stub.transfer.SourceCurrency)
+	assert.Equal(t, int64(2500), stub.transfer.Amount)
+	assert.Equal(t, "key-1", stub.transfer.IdempotencyKey)
+
+	assert.Equal(t, p.ID.String(), out.GetId())
+	assert.Equal(t, "completed", out.GetStatus())
+	assert.Equal(t, int64(2500), out.GetSourceAmount())
+	assert.Equal(t, int64(25), out.GetFeeAmount())
+	assert.True(t, p.CreatedAt.Equal(out.GetCreatedAt().AsTime()))
+	assert.Nil(t, out.GetCompletedAt())
+}
+
+func TestServer_Errors(t *testing.T) {
+	valid := &paymentv1.CreateTransferRequest{
+		SenderUserId: uuid.NewString(), RecipientUniqueName: "bob", Amount: 2500, IdempotencyKey: "key-1",
+	}
+	noAmount := &paymentv1.CreateTransferRequest{SenderUserId: uuid.NewString(), RecipientUniqueName: "bob"}
+
+	tests := []struct {
+		name    string
+		method  string
+		token   string
+		in      any
+		err     error
+		code    codes.Code
+		errCode string
+	}{
+		{"missing token", "CreateTransfer", "", valid, nil, codes.Unauthenticated, "INVALID_TOKEN"},
+		{"wrong token", "CreateTransfer", "other", valid, nil, codes.Unauthenticated, "INVALID_TOKEN"},
+		{"unknown method", "Refund", testToken, valid, nil, codes.Unimplemented, ""},
+		{"invalid field", "CreateTransfer", testToken, noAmount, nil, codes.InvalidArgument, "VALIDATION_FAILED"},
+		{"insufficient funds", "CreateTransfer", testToken, valid, domain.ErrInsufficientFunds, codes.FailedPrecondition, "INSUFFICIENT_FUNDS"},
+		{"duplicate", "CreateTransfer", testToken, valid, domain.ErrDuplicatePayment, codes.FailedPrecondition, "DUPLICATE_PAYMENT"},
+		{"not found", "GetPayment", testToken, getRequest(), domain.ErrNotFound, codes.NotFound, "RESOURCE_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, res := call(t, &stubPayments{err: tt.err}, tt.method, tt.token, tt.in)
+			assert.Equal(t, tt.code, res.code, res.message)
+			assert.Equal(t, tt.errCode, res.errCode)
+			if tt.errCode != "" {
+				assert.True(t, strings.HasPrefix(res.message, tt.errCode+": "), res.message)
+			}
+			assert.Nil(t, out)
+		})
+	}
+}
+
+func TestServer_ErrorDetails(t *testing.T) {
+	err := fmt.Errorf("validateTransfer: %w", domain.NewDomainError(domain.ErrLimitExceeded, "currency", domain.CurrencyUSD, "limit", 10000))
+	_, res := call(t, &stubPayments{err: err}, "CreateTransfer", testToken, &paymentv1.CreateTransferRequest{
+		SenderUserId: uuid.NewString(), RecipientUniqueName: "bob", Amount: 12000, IdempotencyKey: "key-1",
+	})
+	assert.Equal(t, codes.FailedPrecondition, res.code)
+	assert.Equal(t, "TRANSACTION_LIMIT_EXCEEDED", res.errCode)
+	assert.JSONEq(t, `{"currency":"USD","limit":10000}`, res.details)
+}
+
+func TestServer_GetPaymentHidesSenderDetailsFromRecipient(t *testing.T) {
+	stub := &stubPayments{payment: testPayment(), dir: domain.PaymentDirectionReceived}
+
+	out, res := call(t, stub, "GetPayment", testToken, getRequest())
+	require.Equal(t, codes.OK, res.code, res.message)
+
+	assert.Equal(t, "received", out.GetDirection())
+	assert.Zero(t, out.GetFeeAmount(), "fee")
+	assert.Empty(t, out.GetFeeCurrency(), "fee currency")
+	assert.Empty(t, out.GetFailureCode(), "failure code")
+}
+
+func getRequest() *paymentv1.GetPaymentRequest {
+	return &paymentv1.GetPaymentRequest{PaymentId: uuid.NewString(), UserId: uuid.NewString()}
+}
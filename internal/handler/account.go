@@ -4,24 +4,39 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 )
 
 type accountService interface {
 	CreateAccount(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.Account, error)
-	GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]domain.Account, error)
+	GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]domain.AccountSummary, error)
+	GetBalance(ctx context.Context, accountID, userID uuid.UUID) (*domain.AccountBalance, error)
+	SetDefaultAccount(ctx context.Context, userID uuid.UUID, accountID *uuid.UUID) (*domain.User, error)
+	ListTransactions(ctx context.Context, accountID, userID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error)
 }
 
+type balanceHistoryService interface {
+	BalanceAt(ctx context.Context, accountID, userID uuid.UUID, at time.Time) (*domain.HistoricalBalance, error)
+}
+
+const (
+	defaultTransactionLimit = 50
+	maxTransactionLimit     = 200
+)
+
 type AccountHandler struct {
 	accounts accountService
+	history  balanceHistoryService
 }
 
-func NewAccountHandler(accounts accountService) *AccountHandler {
-	return &AccountHandler{accounts: accounts}
+func NewAccountHandler(accounts accountService, history balanceHistoryService) *AccountHandler {
+	return &AccountHandler{accounts: accounts, history: history}
 }
 
 type createAccountRequest struct {
@@ -33,35 +48,109 @@ func (r createAccountRequest) Validate() []FieldError {
 	if r.Currency == "" {
 		errs = append(errs, FieldError{Field: "currency", Message: "required"})
 	} else if !domain.Currency(r.Currency).IsValid() {
-		errs = append(errs, FieldError{Field: "currency", Message: "must be USD, EUR, or GBP"})
+		errs = append(errs, FieldError{Field: "currency", Message: "must be one of " + domain.CurrencyList()})
 	}
 	return errs
 }
 
+// setDefaultAccountRequest names the account payments default to; a null
+// account_id clears the default.
+type setDefaultAccountRequest struct {
+	AccountID *uuid.UUID `json:"account_id"`
+}
+
 type accountDTO struct {
-	ID            uuid.UUID `json:"id"`
-	UserID        uuid.UUID `json:"user_id"`
-	Currency      string    `json:"currency"`
-	Balance       int64     `json:"balance"`
-	AccountNumber *string   `json:"account_number"`
-	IBAN          *string   `json:"iban"`
-	Status        string    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Currency         string    `json:"currency"`
+	Balance          string    `json:"balance"`
+	AvailableBalance string    `json:"available_balance"`
+	AccountNumber    *string   `json:"account_number"`
+	IBAN             *string   `json:"iban"`
+	Status           string    `json:"status"`
+	FreezeReason     *string   `json:"freeze_reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
+// toAccountDTO reports the whole balance as available; use
+// toAccountSummaryDTO where holds may exist.
 func toAccountDTO(a *domain.Account) accountDTO {
 	return accountDTO{
-		ID:            a.ID,
-		UserID:        a.UserID,
-		Currency:      string(a.Currency),
-		Balance:       a.Balance,
-		AccountNumber: a.AccountNumber,
-		IBAN:          a.IBAN,
-		Status:        string(a.Status),
-		CreatedAt:     a.CreatedAt,
+		ID:               a.ID,
+		UserID:           a.UserID,
+		Currency:         string(a.Currency),
+		Balance:          formatAmount(a.Balance, a.Currency),
+		AvailableBalance: formatAmount(a.Balance, a.Currency),
+		AccountNumber:    a.AccountNumber,
+		IBAN:             a.IBAN,
+		Status:           string(a.Status),
+		FreezeReason:     (*string)(a.FreezeReason),
+		CreatedAt:        a.CreatedAt,
 	}
 }
 
+func toAccountSummaryDTO(a *domain.AccountSummary) accountDTO {
+	dto := toAccountDTO(&a.Account)
+	dto.AvailableBalance = formatAmount(a.Available, a.Currency)
+	return dto
+}
+
+type accountBalanceDTO struct {
+	AccountID        uuid.UUID `json:"account_id"`
+	Currency         string    `json:"currency"`
+	LedgerBalance    string    `json:"ledger_balance"`
+	AvailableBalance string    `json:"available_balance"`
+	Held             string    `json:"held"`
+	PendingOutgoing  string    `json:"pending_outgoing"`
+	AsOf             time.Time `json:"as_of"`
+}
+
+func toAccountBalanceDTO(b *domain.AccountBalance) accountBalanceDTO {
+	return accountBalanceDTO{
+		AccountID:        b.AccountID,
+		Currency:         string(b.Currency),
+		LedgerBalance:    formatAmount(b.Ledger, b.Currency),
+		AvailableBalance: formatAmount(b.Available, b.Currency),
+		Held:             formatAmount(b.Held, b.Currency),
+		PendingOutgoing:  formatAmount(b.PendingOutgoing, b.Currency),
+		AsOf:             b.AsOf,
+	}
+}
+
+type historicalBalanceDTO struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	Currency      string    `json:"currency"`
+	LedgerBalance string    `json:"ledger_balance"`
+	AsOf          time.Time `json:"as_of"`
+}
+
+func toHistoricalBalanceDTO(b *domain.HistoricalBalance) historicalBalanceDTO {
+	return historicalBalanceDTO{
+		AccountID:     b.AccountID,
+		Currency:      string(b.Currency),
+		LedgerBalance: formatAmount(b.Ledger, b.Currency),
+		AsOf:          b.AsOf,
+	}
+}
+
+type transactionDTO struct {
+	ID            uuid.UUID `json:"id"`
+	PaymentID     uuid.UUID `json:"payment_id"`
+	EntryType     string    `json:"entry_type"`
+	Category      string    `json:"category"`
+	Amount        string    `json:"amount"`
+	Currency      string    `json:"currency"`
+	BalanceBefore string    `json:"balance_before"`
+	BalanceAfter  string    `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type transactionPageDTO struct {
+	Transactions []transactionDTO `json:"transactions"`
+	HasMore      bool             `json:"has_more"`
+	NextCursor   *string          `json:"next_cursor,omitempty"`
+}
+
 func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, appErr := ownerFromPath(r)
 	if appErr != nil {
@@ -106,8 +195,151 @@ func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	dtos := make([]accountDTO, len(accounts))
 	for i := range accounts {
-		dtos[i] = toAccountDTO(&accounts[i])
+		dtos[i] = toAccountSummaryDTO(&accounts[i])
 	}
 
 	RespondSuccess(w, http.StatusOK, dtos)
 }
+
+// Balance returns the account's balance breakdown now or, with ?at, its
+// ledger balance as of that instant.
+func (h *AccountHandler) Balance(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	if v := r.URL.Query().Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			RespondValidationError(w, []FieldError{{Field: "at", Message: "must be an RFC 3339 timestamp"}})
+			return
+		}
+		if at.After(time.Now()) {
+			RespondValidationError(w, []FieldError{{Field: "at", Message: "must not be in the future"}})
+			return
+		}
+		balance, err := h.history.BalanceAt(r.Context(), accountID, userID, at)
+		if err != nil {
+			RespondDomainError(w, err)
+			return
+		}
+		RespondSuccess(w, http.StatusOK, toHistoricalBalanceDTO(balance))
+		return
+	}
+
+	balance, err := h.accounts.GetBalance(r.Context(), accountID, userID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAccountBalanceDTO(balance))
+}
+
+func (h *AccountHandler) SetDefault(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req setDefaultAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	user, err := h.accounts.SetDefaultAccount(r.Context(), userID, req.AccountID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set default account", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
+}
+
+// Transactions pages through the account's ledger entries, newest first.
+// Each page carries an opaque next_cursor while has_more is true; passing it
+// back as ?cursor= returns the entries after it, unaffected by entries
+// written in the meantime.
+func (h *AccountHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	limit, before, fields := parseTransactionPage(r)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	entries, more, err := h.accounts.ListTransactions(r.Context(), accountID, userID, before, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to list transactions", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	resp := transactionPageDTO{
+		Transactions: make([]transactionDTO, len(entries)),
+		HasMore:      more,
+	}
+	for i, e := range entries {
+		resp.Transactions[i] = transactionDTO{
+			ID:            e.ID,
+			PaymentID:     e.PaymentID,
+			EntryType:     string(e.EntryType),
+			Category:      string(e.Category),
+			Amount:        e.Amount.Decimal(),
+			Currency:      string(e.Amount.Currency),
+			BalanceBefore: formatAmount(e.BalanceBefore, e.Amount.Currency),
+			BalanceAfter:  formatAmount(e.BalanceAfter, e.Amount.Currency),
+			CreatedAt:     e.CreatedAt,
+		}
+	}
+	if more {
+		c := encodeLedgerCursor(entries[len(entries)-1].Cursor())
+		resp.NextCursor = &c
+	}
+
+	RespondSuccess(w, http.StatusOK, resp)
+}
+
+func parseTransactionPage(r *http.Request) (int, *domain.LedgerCursor, []FieldError) {
+	var errs []FieldError
+	limit := defaultTransactionLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTransactionLimit {
+			errs = append(errs, FieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxTransactionLimit)})
+		}
+		limit = n
+	}
+
+	var before *domain.LedgerCursor
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := decodeLedgerCursor(v)
+		if err != nil {
+			errs = append(errs, FieldError{Field: "cursor", Message: "must be a next_cursor from a previous page"})
+		}
+		before = c
+	}
+	return limit, before, errs
+}
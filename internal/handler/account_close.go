@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type accountCloser interface {
+	CloseAccount(ctx context.Context, req payment.CloseAccountRequest) (*payment.CloseAccountResult, error)
+}
+
+type AccountCloseHandler struct {
+	closer accountCloser
+	audit  auditRecorder
+}
+
+func NewAccountCloseHandler(closer accountCloser, audit auditRecorder) *AccountCloseHandler {
+	return &AccountCloseHandler{closer: closer, audit: audit}
+}
+
+type closeAccountRequest struct {
+	SweepToAccountID *uuid.UUID `json:"sweep_to_account_id,omitempty"`
+	DestIBAN         string     `json:"dest_iban,omitempty"`
+	DestBankName     string     `json:"dest_bank_name,omitempty"`
+}
+
+func (r closeAccountRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.SweepToAccountID != nil && r.DestIBAN != "" {
+		errs = append(errs, FieldError{Field: "sweep_to_account_id", Message: "give either an account or a bank, not both"})
+	}
+	if r.DestIBAN != "" && r.DestBankName == "" {
+		errs = append(errs, FieldError{Field: "dest_bank_name", Message: "required with dest_iban"})
+	}
+	if r.DestIBAN == "" && r.DestBankName != "" {
+		errs = append(errs, FieldError{Field: "dest_iban", Message: "required with dest_bank_name"})
+	}
+	return errs
+}
+
+type closeAccountDTO struct {
+	Account accountDTO  `json:"account"`
+	Sweep   *paymentDTO `json:"sweep,omitempty"`
+}
+
+// Close closes an own account, sweeping any balance to the destination in
+// the body. The body may be empty for an account with nothing in it.
+func (h *AccountCloseHandler) Close(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req closeAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	result, err := h.closer.CloseAccount(r.Context(), payment.CloseAccountRequest{
+		UserID:           userID,
+		AccountID:        accountID,
+		SweepToAccountID: req.SweepToAccountID,
+		DestIBAN:         req.DestIBAN,
+		DestBankName:     req.DestBankName,
+		IdempotencyKey:   r.Header.Get("Idempotency-Key"),
+		TOTPCode:         r.Header.Get(TOTPCodeHeader),
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to close account", "account_id", accountID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	after := map[string]any{"status": result.Account.Status}
+	resp := closeAccountDTO{Account: toAccountDTO(result.Account)}
+	if result.Sweep != nil {
+		dto := toPaymentDTO(result.Sweep)
+		resp.Sweep = &dto
+		after["sweep_payment_id"] = result.Sweep.ID
+		after["sweep_amount"] = result.Sweep.Source.Amount
+	}
+	h.audit.Record(r.Context(), domain.AuditEntry{
+		Action:       domain.AuditActionAccountClosed,
+		ResourceType: "account",
+		ResourceID:   accountID.String(),
+		Before:       map[string]any{"status": domain.AccountStatusActive},
+		After:        after,
+	})
+	RespondSuccess(w, http.StatusOK, resp)
+}
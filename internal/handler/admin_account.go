@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type accountFreezeService interface {
+	Freeze(ctx context.Context, req service.FreezeAccountRequest) (*domain.Account, error)
+	Unfreeze(ctx context.Context, accountID, actorID uuid.UUID) (*domain.Account, error)
+	ListFrozen(ctx context.Context, reason domain.FreezeReason, limit, offset int) ([]domain.Account, error)
+}
+
+type AdminAccountHandler struct {
+	freezes accountFreezeService
+}
+
+func NewAdminAccountHandler(freezes accountFreezeService) *AdminAccountHandler {
+	return &AdminAccountHandler{freezes: freezes}
+}
+
+type freezeAccountRequest struct {
+	Reason string `json:"reason"`
+	Note   string `json:"note"`
+}
+
+func (r freezeAccountRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Reason == "" {
+		errs = append(errs, FieldError{Field: "reason", Message: "required"})
+	} else if !domain.FreezeReason(r.Reason).IsValid() {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be one of compliance_review, user_requested, fraud_suspected"})
+	}
+	return errs
+}
+
+type adminAccountDTO struct {
+	accountDTO
+	AccountType string     `json:"account_type"`
+	FrozenAt    *time.Time `json:"frozen_at,omitempty"`
+	FrozenBy    *uuid.UUID `json:"frozen_by,omitempty"`
+}
+
+func toAdminAccountDTO(a *domain.Account) adminAccountDTO {
+	return adminAccountDTO{
+		accountDTO:  toAccountDTO(a),
+		AccountType: string(a.AccountType),
+		FrozenAt:    a.FrozenAt,
+		FrozenBy:    a.FrozenBy,
+	}
+}
+
+// ListFrozen lists frozen accounts, optionally only those frozen for one
+// reason.
+func (h *AdminAccountHandler) ListFrozen(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	reason := domain.FreezeReason(q.Get("reason"))
+	if reason != "" && !reason.IsValid() {
+		RespondValidationError(w, []FieldError{{Field: "reason", Message: "must be one of compliance_review, user_requested, fraud_suspected"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	accounts, err := h.freezes.ListFrozen(r.Context(), reason, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list frozen accounts", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]adminAccountDTO, len(accounts))
+	for i := range accounts {
+		dtos[i] = toAdminAccountDTO(&accounts[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminAccountHandler) Freeze(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req freezeAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	acct, err := h.freezes.Freeze(r.Context(), service.FreezeAccountRequest{
+		AccountID: accountID,
+		Reason:    domain.FreezeReason(req.Reason),
+		Note:      req.Note,
+		ActorID:   actorID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to freeze account", "account_id", accountID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminAccountDTO(acct))
+}
+
+func (h *AdminAccountHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	acct, err := h.freezes.Unfreeze(r.Context(), accountID, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to unfreeze account", "account_id", accountID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminAccountDTO(acct))
+}
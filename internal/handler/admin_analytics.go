@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type corridorReporter interface {
+	Report(ctx context.Context, from, to time.Time, g domain.CorridorGranularity) (*service.CorridorReport, error)
+}
+
+type AdminAnalyticsHandler struct {
+	corridors corridorReporter
+}
+
+func NewAdminAnalyticsHandler(corridors corridorReporter) *AdminAnalyticsHandler {
+	return &AdminAnalyticsHandler{corridors: corridors}
+}
+
+type corridorStatsDTO struct {
+	PeriodStart          string  `json:"period_start"`
+	SourceCurrency       string  `json:"source_currency"`
+	DestCurrency         string  `json:"dest_currency"`
+	Payments             int64   `json:"payments"`
+	Succeeded            int64   `json:"succeeded"`
+	Failed               int64   `json:"failed"`
+	Volume               string  `json:"volume"`
+	AvgFee               string  `json:"avg_fee"`
+	FailureRate          float64 `json:"failure_rate"`
+	AvgCompletionSeconds float64 `json:"avg_completion_seconds"`
+}
+
+type corridorReportDTO struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Granularity string             `json:"granularity"`
+	AsOf        *time.Time         `json:"as_of"`
+	Corridors   []corridorStatsDTO `json:"corridors"`
+}
+
+func toCorridorStatsDTO(s domain.CorridorStats) corridorStatsDTO {
+	return corridorStatsDTO{
+		PeriodStart:          s.PeriodStart.Format(time.DateOnly),
+		SourceCurrency:       string(s.SourceCurrency),
+		DestCurrency:         string(s.DestCurrency),
+		Payments:             s.Payments,
+		Succeeded:            s.Succeeded,
+		Failed:               s.Failed,
+		Volume:               formatAmount(s.Volume, s.SourceCurrency),
+		AvgFee:               formatAverageAmount(s.AvgFee(), s.SourceCurrency),
+		FailureRate:          s.FailureRate(),
+		AvgCompletionSeconds: s.AvgCompletionSeconds(),
+	}
+}
+
+// Corridors reports payment activity per source/destination currency pair
+// for the UTC days from..to inclusive, bucketed by ?granularity=day, week,
+// month or total (the default). ?format=csv downloads it as CSV.
+func (h *AdminAnalyticsHandler) Corridors(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	q := r.URL.Query()
+
+	from, to, fields := parseDateRange(q.Get("from"), q.Get("to"))
+	g := domain.CorridorGranularity(q.Get("granularity"))
+	if g == "" {
+		g = domain.CorridorGranularityTotal
+	}
+	if !g.IsValid() {
+		fields = append(fields, FieldError{Field: "granularity", Message: "must be day, week, month or total"})
+	}
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	report, err := h.corridors.Report(r.Context(), from, to, g)
+	if err != nil {
+		log.Error("failed to build corridor report", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	if q.Get("format") == "csv" {
+		writeCorridorCSV(w, from, to, report)
+		return
+	}
+
+	dto := corridorReportDTO{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		Granularity: string(g),
+		AsOf:        report.AsOf,
+		Corridors:   make([]corridorStatsDTO, len(report.Corridors)),
+	}
+	for i, s := range report.Corridors {
+		dto.Corridors[i] = toCorridorStatsDTO(s)
+	}
+	RespondSuccess(w, http.StatusOK, dto)
+}
+
+func writeCorridorCSV(w http.ResponseWriter, from, to time.Time, report *service.CorridorReport) {
+	filename := fmt.Sprintf("corridors_%s_%s.csv", from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"period_start", "source_currency", "dest_currency", "payments", "succeeded", "failed",
+		"volume", "avg_fee", "failure_rate", "avg_completion_seconds",
+	})
+	for _, s := range report.Corridors {
+		d := toCorridorStatsDTO(s)
+		cw.Write([]string{
+			d.PeriodStart,
+			d.SourceCurrency,
+			d.DestCurrency,
+			strconv.FormatInt(d.Payments, 10),
+			strconv.FormatInt(d.Succeeded, 10),
+			strconv.FormatInt(d.Failed, 10),
+			d.Volume,
+			d.AvgFee,
+			formatFloat(d.FailureRate),
+			formatFloat(d.AvgCompletionSeconds),
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+type auditLogService interface {
+	List(ctx context.Context, f domain.AuditFilter, before *domain.AuditCursor, limit int) ([]domain.AuditLog, bool, error)
+	Export(ctx context.Context, f domain.AuditFilter) ([]domain.AuditLog, bool, error)
+}
+
+type AdminAuditHandler struct {
+	logs auditLogService
+}
+
+func NewAdminAuditHandler(logs auditLogService) *AdminAuditHandler {
+	return &AdminAuditHandler{logs: logs}
+}
+
+type auditLogDTO struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      *uuid.UUID      `json:"actor_id"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	IP           string          `json:"ip"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type auditLogPageDTO struct {
+	Logs       []auditLogDTO `json:"logs"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor *string       `json:"next_cursor,omitempty"`
+}
+
+func toAuditLogDTO(l *domain.AuditLog) auditLogDTO {
+	return auditLogDTO{
+		ID:           l.ID,
+		ActorID:      l.ActorID,
+		Action:       string(l.Action),
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		IP:           l.IP,
+		Before:       l.Before,
+		After:        l.After,
+		CreatedAt:    l.CreatedAt,
+	}
+}
+
+// List pages through the audit log, newest first, filtered by ?actor_id=,
+// ?action=, ?resource_type=, ?resource_id= and the RFC 3339 range
+// ?from= (inclusive) to ?to= (exclusive). ?format=csv exports every match up
+// to a cap instead of one page, and the export is itself audited.
+func (h *AdminAuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	q := r.URL.Query()
+
+	f, fields := parseAuditFilter(q)
+	if q.Get("format") == "csv" {
+		if len(fields) > 0 {
+			RespondValidationError(w, fields)
+			return
+		}
+		logs, truncated, err := h.logs.Export(r.Context(), f)
+		if err != nil {
+			log.Error("failed to export audit log", "error", err)
+			RespondDomainError(w, err)
+			return
+		}
+		writeAuditCSV(w, logs, truncated)
+		return
+	}
+
+	limit := defaultAuditLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxAuditLimit)})
+		}
+		limit = n
+	}
+	var before *domain.AuditCursor
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeAuditCursor(v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "cursor", Message: "must be a next_cursor from a previous page"})
+		}
+		before = c
+	}
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	logs, more, err := h.logs.List(r.Context(), f, before, limit)
+	if err != nil {
+		log.Error("failed to list audit log", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	resp := auditLogPageDTO{Logs: make([]auditLogDTO, len(logs)), HasMore: more}
+	for i := range logs {
+		resp.Logs[i] = toAuditLogDTO(&logs[i])
+	}
+	if more {
+		c := encodeAuditCursor(logs[len(logs)-1].Cursor())
+		resp.NextCursor = &c
+	}
+	RespondSuccess(w, http.StatusOK, resp)
+}
+
+func parseAuditFilter(q url.Values) (domain.AuditFilter, []FieldError) {
+	var errs []FieldError
+	f := domain.AuditFilter{
+		Action:       domain.AuditAction(q.Get("action")),
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+	}
+	if v := q.Get("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			errs = append(errs, FieldError{Field: "actor_id", Message: "must be a UUID"})
+		}
+		f.ActorID = &id
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errs = append(errs, FieldError{Field: p.name, Message: "must be an RFC 3339 timestamp"})
+			continue
+		}
+		*p.dst = &t
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		errs = append(errs, FieldError{Field: "from", Message: "must be before to"})
+	}
+	return f, errs
+}
+
+// writeAuditCSV writes the export. X-Export-Truncated says whether rows were
+// left out at the cap.
+func writeAuditCSV(w http.ResponseWriter, logs []domain.AuditLog, truncated bool) {
+	filename := fmt.Sprintf("audit_log_%s.csv", time.Now().UTC().Format("20060102T150405Z"))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Export-Truncated", strconv.FormatBool(truncated))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "actor_id", "action", "resource_type", "resource_id", "ip", "before", "after"})
+	for _, l := range logs {
+		actor := ""
+		if l.ActorID != nil {
+			actor = l.ActorID.String()
+		}
+		cw.Write([]string{
+			l.ID.String(),
+			l.CreatedAt.UTC().Format(time.RFC3339Nano),
+			actor,
+			string(l.Action),
+			l.ResourceType,
+			l.ResourceID,
+			l.IP,
+			string(l.Before),
+			string(l.After),
+		})
+	}
+	cw.Flush()
+}
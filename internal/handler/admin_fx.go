@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const defaultFXRevenueWindow = 30 * 24 * time.Hour
+
+type fxRevenueReader interface {
+	FXRevenueByCorridor(ctx context.Context, from, to time.Time) ([]domain.FXCorridorRevenue, error)
+}
+
+type feeRevenueReader interface {
+	FeeRevenueByCurrency(ctx context.Context, from, to time.Time) ([]domain.FeeRevenue, error)
+}
+
+type AdminFXHandler struct {
+	revenue fxRevenueReader
+	fees    feeRevenueReader
+}
+
+func NewAdminFXHandler(revenue fxRevenueReader, fees feeRevenueReader) *AdminFXHandler {
+	return &AdminFXHandler{revenue: revenue, fees: fees}
+}
+
+type fxCorridorRevenueDTO struct {
+	SourceCurrency  string `json:"source_currency"`
+	DestCurrency    string `json:"dest_currency"`
+	ConversionCount int    `json:"conversion_count"`
+	SourceVolume    string `json:"source_volume"`
+	DestVolume      string `json:"dest_volume"`
+	FeeTotal        string `json:"fee_total"`
+	SlippageTotal   string `json:"slippage_total"`
+	AvgSlippageBps  string `json:"avg_slippage_bps"`
+	P50SlippageBps  string `json:"p50_slippage_bps"`
+	P95SlippageBps  string `json:"p95_slippage_bps"`
+	MaxSlippageBps  string `json:"max_slippage_bps"`
+}
+
+type fxRevenueReportDTO struct {
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	Corridors []fxCorridorRevenueDTO `json:"corridors"`
+}
+
+type feeRevenueDTO struct {
+	Currency   string    `json:"currency"`
+	AccountID  uuid.UUID `json:"account_id"`
+	Balance    string    `json:"balance"`
+	Earned     string    `json:"earned"`
+	Reversed   string    `json:"reversed"`
+	Net        string    `json:"net"`
+	EntryCount int       `json:"entry_count"`
+}
+
+type feeRevenueReportDTO struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Currencies []feeRevenueDTO `json:"currencies"`
+}
+
+// Revenue reports conversion volume, fees and slippage per corridor for the
+// UTC days from..to inclusive. Defaults to the last 30 days.
+func (h *AdminFXHandler) Revenue(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	from, to, fields := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	corridors, err := h.revenue.FXRevenueByCorridor(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Error("failed to build fx revenue report", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	report := fxRevenueReportDTO{
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+		Corridors: make([]fxCorridorRevenueDTO, len(corridors)),
+	}
+	for i, c := range corridors {
+		report.Corridors[i] = fxCorridorRevenueDTO{
+			SourceCurrency:  string(c.SourceCurrency),
+			DestCurrency:    string(c.DestCurrency),
+			ConversionCount: c.ConversionCount,
+			SourceVolume:    formatAmount(c.SourceVolume, c.SourceCurrency),
+			DestVolume:      formatAmount(c.DestVolume, c.DestCurrency),
+			FeeTotal:        formatAmount(c.FeeTotal, c.DestCurrency),
+			SlippageTotal:   formatAmount(c.SlippageTotal, c.DestCurrency),
+			AvgSlippageBps:  c.AvgSlippageBps.String(),
+			P50SlippageBps:  c.P50SlippageBps.String(),
+			P95SlippageBps:  c.P95SlippageBps.String(),
+			MaxSlippageBps:  c.MaxSlippageBps.String(),
+		}
+	}
+
+	RespondSuccess(w, http.StatusOK, report)
+}
+
+func parseDateRange(fromParam, toParam string) (time.Time, time.Time, []FieldError) {
+	var errs []FieldError
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toParam != "" {
+		t, err := time.Parse(time.DateOnly, toParam)
+		if err != nil {
+			errs = append(errs, FieldError{Field: "to", Message: "must be a date in YYYY-MM-DD format"})
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultFXRevenueWindow)
+	if fromParam != "" {
+		f, err := time.Parse(time.DateOnly, fromParam)
+		if err != nil {
+			errs = append(errs, FieldError{Field: "from", Message: "must be a date in YYYY-MM-DD format"})
+		}
+		from = f
+	}
+
+	if len(errs) == 0 && from.After(to) {
+		errs = append(errs, FieldError{Field: "from", Message: "must not be after to"})
+	}
+	return from, to, errs
+}
+
+// Fees reports the fee revenue booked to the revenue accounts: the running
+// balance per currency, and fees earned and reversed over from..to.
+func (h *AdminFXHandler) Fees(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	from, to, fields := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	fees, err := h.fees.FeeRevenueByCurrency(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Error("failed to build fee revenue report", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	report := feeRevenueReportDTO{
+		From:       from.Format(time.DateOnly),
+		To:         to.Format(time.DateOnly),
+		Currencies: make([]feeRevenueDTO, len(fees)),
+	}
+	for i, f := range fees {
+		report.Currencies[i] = feeRevenueDTO{
+			Currency:   string(f.Currency),
+			AccountID:  f.AccountID,
+			Balance:    formatAmount(f.Balance, f.Currency),
+			Earned:     formatAmount(f.Earned, f.Currency),
+			Reversed:   formatAmount(f.Reversed, f.Currency),
+			Net:        formatAmount(f.Earned-f.Reversed, f.Currency),
+			EntryCount: f.EntryCount,
+		}
+	}
+
+	RespondSuccess(w, http.StatusOK, report)
+}
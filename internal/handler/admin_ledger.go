@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type chainBreakReader interface {
+	List(ctx context.Context, annotated *bool, limit, offset int) ([]domain.LedgerChainBreak, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.LedgerChainBreak, error)
+}
+
+type chainBreakAnnotator interface {
+	Annotate(ctx context.Context, req service.AnnotateChainBreakRequest) (*domain.LedgerChainBreak, error)
+}
+
+type ledgerTotalsReader interface {
+	TotalsByCategory(ctx context.Context, from, to time.Time) ([]domain.LedgerCategoryTotal, error)
+}
+
+type AdminLedgerHandler struct {
+	breaks    chainBreakReader
+	annotator chainBreakAnnotator
+	totals    ledgerTotalsReader
+}
+
+func NewAdminLedgerHandler(breaks chainBreakReader, annotator chainBreakAnnotator, totals ledgerTotalsReader) *AdminLedgerHandler {
+	return &AdminLedgerHandler{breaks: breaks, annotator: annotator, totals: totals}
+}
+
+type annotateChainBreakRequest struct {
+	Note            string     `json:"note"`
+	RepairPaymentID *uuid.UUID `json:"repair_payment_id,omitempty"`
+}
+
+func (r annotateChainBreakRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Note == "" {
+		errs = append(errs, FieldError{Field: "note", Message: "required"})
+	} else if len(r.Note) > 2000 {
+		errs = append(errs, FieldError{Field: "note", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+type chainBreakDTO struct {
+	ID              uuid.UUID  `json:"id"`
+	AccountID       uuid.UUID  `json:"account_id"`
+	EntryID         uuid.UUID  `json:"entry_id"`
+	EntryCreatedAt  time.Time  `json:"entry_created_at"`
+	PrevEntryID     uuid.UUID  `json:"prev_entry_id"`
+	PrevCreatedAt   time.Time  `json:"prev_created_at"`
+	ExpectedBefore  int64      `json:"expected_balance_before"`
+	ActualBefore    int64      `json:"actual_balance_before"`
+	Discrepancy     int64      `json:"discrepancy"`
+	DetectedAt      time.Time  `json:"detected_at"`
+	Status          string     `json:"status"`
+	Annotation      *string    `json:"annotation,omitempty"`
+	RepairPaymentID *uuid.UUID `json:"repair_payment_id,omitempty"`
+	AnnotatedBy     *uuid.UUID `json:"annotated_by,omitempty"`
+	AnnotatedAt     *time.Time `json:"annotated_at,omitempty"`
+}
+
+func toChainBreakDTO(b *domain.LedgerChainBreak) chainBreakDTO {
+	status := "open"
+	if b.Annotated() {
+		status = "annotated"
+	}
+	return chainBreakDTO{
+		ID:              b.ID,
+		AccountID:       b.AccountID,
+		EntryID:         b.EntryID,
+		EntryCreatedAt:  b.EntryCreatedAt,
+		PrevEntryID:     b.PrevEntryID,
+		PrevCreatedAt:   b.PrevCreatedAt,
+		ExpectedBefore:  b.ExpectedBefore,
+		ActualBefore:    b.ActualBefore,
+		Discrepancy:     b.ActualBefore - b.ExpectedBefore,
+		DetectedAt:      b.DetectedAt,
+		Status:          status,
+		Annotation:      b.Annotation,
+		RepairPaymentID: b.RepairPaymentID,
+		AnnotatedBy:     b.AnnotatedBy,
+		AnnotatedAt:     b.AnnotatedAt,
+	}
+}
+
+// ChainBreaks lists ledger chain breaks found by the verifier, newest first
+// (?status=open|annotated).
+func (h *AdminLedgerHandler) ChainBreaks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var annotated *bool
+	switch q.Get("status") {
+	case "":
+	case "open":
+		annotated = new(bool)
+	case "annotated":
+		v := true
+		annotated = &v
+	default:
+		RespondValidationError(w, []FieldError{{Field: "status", Message: "must be open or annotated"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	breaks, err := h.breaks.List(r.Context(), annotated, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list ledger chain breaks", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]chainBreakDTO, len(breaks))
+	for i := range breaks {
+		dtos[i] = toChainBreakDTO(&breaks[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminLedgerHandler) ChainBreak(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	b, err := h.breaks.GetByID(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("ledger chain break lookup failed", "break_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toChainBreakDTO(b))
+}
+
+// AnnotateChainBreak records how a break was repaired. Ledger entries are
+// never edited, so the repair itself is a compensating payment, which the
+// annotation can point at.
+func (h *AdminLedgerHandler) AnnotateChainBreak(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req annotateChainBreakRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	b, err := h.annotator.Annotate(r.Context(), service.AnnotateChainBreakRequest{
+		BreakID:         id,
+		StaffID:         staffID,
+		Note:            req.Note,
+		RepairPaymentID: req.RepairPaymentID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to annotate ledger chain break", "break_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toChainBreakDTO(b))
+}
+
+type categoryTotalDTO struct {
+	Category   string `json:"category"`
+	Debits     string `json:"debits"`
+	Credits    string `json:"credits"`
+	EntryCount int    `json:"entry_count"`
+}
+
+type currencyTrialBalanceDTO struct {
+	Currency   string             `json:"currency"`
+	Debits     string             `json:"debits"`
+	Credits    string             `json:"credits"`
+	Categories []categoryTotalDTO `json:"categories"`
+}
+
+type trialBalanceDTO struct {
+	From       string                    `json:"from"`
+	To         string                    `json:"to"`
+	Currencies []currencyTrialBalanceDTO `json:"currencies"`
+}
+
+// TrialBalance totals the ledger entries written over the UTC days from..to
+// inclusive per currency, broken down by entry category. A currency's
+// debits and credits match unless money left or entered it through a pool
+// transfer, which shows up under adjustment.
+func (h *AdminLedgerHandler) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	from, to, fields := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	totals, err := h.totals.TotalsByCategory(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to build trial balance", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	report := trialBalanceDTO{
+		From:       from.Format(time.DateOnly),
+		To:         to.Format(time.DateOnly),
+		Currencies: []currencyTrialBalanceDTO{},
+	}
+	// Totals come ordered by currency.
+	var debits, credits int64
+	for i, t := range totals {
+		n := len(report.Currencies)
+		if n == 0 || report.Currencies[n-1].Currency != string(t.Currency) {
+			report.Currencies = append(report.Currencies, currencyTrialBalanceDTO{Currency: string(t.Currency)})
+			n++
+			debits, credits = 0, 0
+		}
+		c := &report.Currencies[n-1]
+		debits += t.Debits
+		credits += t.Credits
+		c.Categories = append(c.Categories, categoryTotalDTO{
+			Category:   string(t.Category),
+			Debits:     formatAmount(t.Debits, t.Currency),
+			Credits:    formatAmount(t.Credits, t.Currency),
+			EntryCount: t.EntryCount,
+		})
+		if i == len(totals)-1 || totals[i+1].Currency != t.Currency {
+			c.Debits = formatAmount(debits, t.Currency)
+			c.Credits = formatAmount(credits, t.Currency)
+		}
+	}
+	RespondSuccess(w, http.StatusOK, report)
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type userLimitService interface {
+	ListLimits(ctx context.Context, userID uuid.UUID) ([]domain.TxLimit, error)
+	SetLimit(ctx context.Context, req service.SetLimitRequest) (*domain.TxLimit, error)
+	ResetLimit(ctx context.Context, userID uuid.UUID, currency domain.Currency, actorID uuid.UUID) (*domain.TxLimit, error)
+	SetTier(ctx context.Context, userID uuid.UUID, tier domain.UserTier, reason string, actorID uuid.UUID) (*domain.User, error)
+}
+
+type AdminLimitHandler struct {
+	limits userLimitService
+}
+
+func NewAdminLimitHandler(limits userLimitService) *AdminLimitHandler {
+	return &AdminLimitHandler{limits: limits}
+}
+
+type setLimitRequest struct {
+	TxLimit string `json:"tx_limit"`
+	Reason  string `json:"reason"`
+}
+
+func (r setLimitRequest) Validate() []FieldError {
+	var errs []FieldError
+	if fe := checkAmount("tx_limit", r.TxLimit); fe != nil {
+		errs = append(errs, *fe)
+	}
+	return errs
+}
+
+type setTierRequest struct {
+	Tier   string `json:"tier"`
+	Reason string `json:"reason"`
+}
+
+func (r setTierRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.UserTier(r.Tier).IsValid() {
+		errs = append(errs, FieldError{Field: "tier", Message: "must be standard, plus or business"})
+	}
+	if len(r.Reason) > 500 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+type txLimitDTO struct {
+	Currency     string     `json:"currency"`
+	TxLimit      string     `json:"tx_limit"`
+	DefaultLimit string     `json:"default_limit"`
+	Overridden   bool       `json:"overridden"`
+	Reason       *string    `json:"reason,omitempty"`
+	UpdatedBy    *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+func toTxLimitDTO(l *domain.TxLimit) txLimitDTO {
+	dto := txLimitDTO{
+		Currency:     string(l.Currency),
+		TxLimit:      formatAmount(l.Effective(), l.Currency),
+		DefaultLimit: formatAmount(l.Default, l.Currency),
+		Overridden:   l.Override != nil,
+	}
+	if o := l.Override; o != nil {
+		dto.Reason = &o.Reason
+		dto.UpdatedBy = &o.UpdatedBy
+		dto.UpdatedAt = &o.UpdatedAt
+	}
+	return dto
+}
+
+func (h *AdminLimitHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	limits, err := h.limits.ListLimits(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list user limits", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]txLimitDTO, len(limits))
+	for i := range limits {
+		dtos[i] = toTxLimitDTO(&limits[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminLimitHandler) Set(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req setLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	currency := domain.Currency(r.PathValue("currency"))
+	txLimit, fields := parseAmount("tx_limit", req.TxLimit, currency)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	limit, err := h.limits.SetLimit(r.Context(), service.SetLimitRequest{
+		UserID:   userID,
+		Currency: currency,
+		TxLimit:  txLimit,
+		Reason:   req.Reason,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set user limit", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toTxLimitDTO(limit))
+}
+
+func (h *AdminLimitHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	limit, err := h.limits.ResetLimit(r.Context(), userID, domain.Currency(r.PathValue("currency")), actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to reset user limit", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toTxLimitDTO(limit))
+}
+
+// SetTier moves a user to another tier, which scales their default limits
+// and sets the FX spread they pay.
+func (h *AdminLimitHandler) SetTier(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req setTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	user, err := h.limits.SetTier(r.Context(), userID, domain.UserTier(req.Tier), req.Reason, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set user tier", "user_id", userID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type adminPaymentReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+}
+
+type paymentEventLister interface {
+	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.PaymentEvent, error)
+}
+
+type paymentLedgerLister interface {
+	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error)
+}
+
+type AdminPaymentHandler struct {
+	payments adminPaymentReader
+	events   paymentEventLister
+	ledger   paymentLedgerLister
+	notes    supportNoteService
+}
+
+func NewAdminPaymentHandler(payments adminPaymentReader, events paymentEventLister, ledger paymentLedgerLister, notes supportNoteService) *AdminPaymentHandler {
+	return &AdminPaymentHandler{payments: payments, events: events, ledger: ledger, notes: notes}
+}
+
+type paymentEventDTO struct {
+	ID        uuid.UUID       `json:"id"`
+	EventType string          `json:"event_type"`
+	Actor     string          `json:"actor"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ledgerEntryDTO struct {
+	ID            uuid.UUID `json:"id"`
+	AccountID     uuid.UUID `json:"account_id"`
+	EntryType     string    `json:"entry_type"`
+	Category      string    `json:"category"`
+	Amount        string    `json:"amount"`
+	Currency      string    `json:"currency"`
+	BalanceBefore string    `json:"balance_before"`
+	BalanceAfter  string    `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type adminPaymentDetailDTO struct {
+	Payment        paymentDTO        `json:"payment"`
+	Provider       *string           `json:"provider,omitempty"`
+	ProviderRef    *string           `json:"provider_ref,omitempty"`
+	FailureReason  *string           `json:"failure_reason,omitempty"`
+	MidMarketRate  *decimal.Decimal  `json:"mid_market_rate,omitempty"`
+	SlippageAmount *string           `json:"slippage_amount,omitempty"`
+	Events         []paymentEventDTO `json:"events"`
+	LedgerEntries  []ledgerEntryDTO  `json:"ledger_entries"`
+	Notes          []supportNoteDTO  `json:"notes"`
+}
+
+func (h *AdminPaymentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	p, err := h.payments.GetByID(r.Context(), paymentID)
+	if err != nil {
+		log.Warn("admin payment lookup failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	events, err := h.events.GetByPaymentID(r.Context(), paymentID)
+	if err != nil {
+		log.Error("failed to load payment events", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	entries, err := h.ledger.GetByPaymentID(r.Context(), paymentID)
+	if err != nil {
+		log.Error("failed to load ledger entries", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	notes, err := h.notes.ListNotes(r.Context(), domain.NoteSubjectPayment, paymentID)
+	if err != nil {
+		log.Error("failed to load support notes", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	detail := adminPaymentDetailDTO{
+		Payment:        toPaymentDTO(p),
+		Provider:       p.Provider,
+		ProviderRef:    p.ProviderRef,
+		FailureReason:  p.FailureReason,
+		MidMarketRate:  p.MidMarketRate,
+		SlippageAmount: formatMoneyPtr(p.Slippage),
+		Events:         make([]paymentEventDTO, len(events)),
+		LedgerEntries:  make([]ledgerEntryDTO, len(entries)),
+		Notes:          toSupportNoteDTOs(notes),
+	}
+	for i, e := range events {
+		detail.Events[i] = paymentEventDTO{
+			ID:        e.ID,
+			EventType: string(e.EventType),
+			Actor:     e.Actor,
+			Payload:   e.Payload,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	for i, e := range entries {
+		detail.LedgerEntries[i] = toLedgerEntryDTO(e)
+	}
+
+	RespondSuccess(w, http.StatusOK, detail)
+}
+
+func toLedgerEntryDTO(e domain.LedgerEntry) ledgerEntryDTO {
+	return ledgerEntryDTO{
+		ID:            e.ID,
+		AccountID:     e.AccountID,
+		EntryType:     string(e.EntryType),
+		Category:      string(e.Category),
+		Amount:        e.Amount.Decimal(),
+		Currency:      string(e.Amount.Currency),
+		BalanceBefore: formatAmount(e.BalanceBefore, e.Amount.Currency),
+		BalanceAfter:  formatAmount(e.BalanceAfter, e.Amount.Currency),
+		CreatedAt:     e.CreatedAt,
+	}
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type providerSLAReporter interface {
+	Report(ctx context.Context, from, to time.Time) ([]service.ProviderSLAReport, error)
+}
+
+type providerFailureReader interface {
+	FailureStats(ctx context.Context, from, to time.Time) ([]domain.ProviderFailureStats, error)
+}
+
+type AdminProviderHandler struct {
+	sla      providerSLAReporter
+	failures providerFailureReader
+}
+
+func NewAdminProviderHandler(sla providerSLAReporter, failures providerFailureReader) *AdminProviderHandler {
+	return &AdminProviderHandler{sla: sla, failures: failures}
+}
+
+type providerSLADTO struct {
+	Provider       string `json:"provider"`
+	SourceCurrency string `json:"source_currency"`
+	DestCurrency   string `json:"dest_currency"`
+	Samples        int    `json:"samples"`
+	Failed         int    `json:"failed"`
+	P50MS          int64  `json:"p50_ms"`
+	P95MS          int64  `json:"p95_ms"`
+	P99MS          int64  `json:"p99_ms"`
+	MaxMS          int64  `json:"max_ms"`
+	Breaching      bool   `json:"breaching"`
+	Degraded       bool   `json:"degraded"`
+}
+
+type providerSLAReportDTO struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Corridors []providerSLADTO `json:"corridors"`
+}
+
+// SLA reports submission-to-callback latency percentiles per provider and
+// corridor for the UTC days from..to inclusive. Degraded reflects whether
+// routing is avoiding the corridor right now.
+func (h *AdminProviderHandler) SLA(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	from, to, fields := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	reports, err := h.sla.Report(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Error("failed to build provider SLA report", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dto := providerSLAReportDTO{
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+		Corridors: make([]providerSLADTO, len(reports)),
+	}
+	for i, rep := range reports {
+		s := rep.Stats
+		dto.Corridors[i] = providerSLADTO{
+			Provider:       s.Provider,
+			SourceCurrency: string(s.SourceCurrency),
+			DestCurrency:   string(s.DestCurrency),
+			Samples:        s.Samples,
+			Failed:         s.Failed,
+			P50MS:          s.P50MS,
+			P95MS:          s.P95MS,
+			P99MS:          s.P99MS,
+			MaxMS:          s.MaxMS,
+			Breaching:      rep.Breaching,
+			Degraded:       rep.Degraded,
+		}
+	}
+
+	RespondSuccess(w, http.StatusOK, dto)
+}
+
+type providerFailuresDTO struct {
+	Provider   string `json:"provider"`
+	Attempts   int    `json:"attempts"`
+	Accepted   int    `json:"accepted"`
+	Network    int    `json:"network"`
+	Retriable  int    `json:"retriable"`
+	Permanent  int    `json:"permanent"`
+	Validation int    `json:"validation"`
+}
+
+type providerFailureReportDTO struct {
+	From      string                `json:"from"`
+	To        string                `json:"to"`
+	Providers []providerFailuresDTO `json:"providers"`
+}
+
+// Failures counts submission attempts per provider for the UTC days from..to
+// inclusive, split by how the failed ones failed.
+func (h *AdminProviderHandler) Failures(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	from, to, fields := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	stats, err := h.failures.FailureStats(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Error("failed to build provider failure report", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dto := providerFailureReportDTO{
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+		Providers: make([]providerFailuresDTO, len(stats)),
+	}
+	for i, s := range stats {
+		dto.Providers[i] = providerFailuresDTO{
+			Provider:   s.Provider,
+			Attempts:   s.Attempts,
+			Accepted:   s.Accepted,
+			Network:    s.Network,
+			Retriable:  s.Retriable,
+			Permanent:  s.Permanent,
+			Validation: s.Validation,
+		}
+	}
+
+	RespondSuccess(w, http.StatusOK, dto)
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type qaSampleReader interface {
+	List(ctx context.Context, status domain.QASampleStatus, outcome domain.QAOutcome, limit, offset int) ([]domain.QASample, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.QASample, error)
+}
+
+type qaReviewer interface {
+	Review(ctx context.Context, sampleID, staffID uuid.UUID, outcome domain.QAOutcome, note string) (*domain.QASample, error)
+}
+
+type AdminQAHandler struct {
+	samples  qaSampleReader
+	reviewer qaReviewer
+}
+
+func NewAdminQAHandler(samples qaSampleReader, reviewer qaReviewer) *AdminQAHandler {
+	return &AdminQAHandler{samples: samples, reviewer: reviewer}
+}
+
+type reviewQASampleRequest struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+func (r reviewQASampleRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.QAOutcome(r.Outcome).IsValid() {
+		errs = append(errs, FieldError{Field: "outcome", Message: "must be pass or issue_found"})
+	} else if r.Outcome == string(domain.QAOutcomeIssueFound) && r.Note == "" {
+		errs = append(errs, FieldError{Field: "note", Message: "is required when an issue is found"})
+	}
+	if len(r.Note) > 2000 {
+		errs = append(errs, FieldError{Field: "note", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+type qaSampleDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	PaymentID   uuid.UUID  `json:"payment_id"`
+	PaymentType string     `json:"payment_type"`
+	Amount      string     `json:"amount"`
+	Currency    string     `json:"currency"`
+	Reason      string     `json:"reason"`
+	Weight      float64    `json:"weight"`
+	Probability float64    `json:"probability"`
+	Status      string     `json:"status"`
+	Outcome     *string    `json:"outcome,omitempty"`
+	ReviewNote  *string    `json:"review_note,omitempty"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	SampledAt   time.Time  `json:"sampled_at"`
+}
+
+func toQASampleDTO(s *domain.QASample) qaSampleDTO {
+	dto := qaSampleDTO{
+		ID:          s.ID,
+		PaymentID:   s.PaymentID,
+		PaymentType: string(s.PaymentType),
+		Amount:      formatAmount(s.Amount, s.Currency),
+		Currency:    string(s.Currency),
+		Reason:      string(s.Reason),
+		Weight:      s.Weight,
+		Probability: s.Probability,
+		Status:      string(s.Status),
+		ReviewNote:  s.ReviewNote,
+		ReviewedBy:  s.ReviewedBy,
+		ReviewedAt:  s.ReviewedAt,
+		SampledAt:   s.SampledAt,
+	}
+	if s.Outcome != nil {
+		o := string(*s.Outcome)
+		dto.Outcome = &o
+	}
+	return dto
+}
+
+func (h *AdminQAHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	status := domain.QASampleStatus(q.Get("status"))
+	if status != "" && status != domain.QASampleStatusPending && status != domain.QASampleStatusReviewed {
+		RespondValidationError(w, []FieldError{{Field: "status", Message: "must be pending or reviewed"}})
+		return
+	}
+	outcome := domain.QAOutcome(q.Get("outcome"))
+	if outcome != "" && !outcome.IsValid() {
+		RespondValidationError(w, []FieldError{{Field: "outcome", Message: "must be pass or issue_found"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	samples, err := h.samples.List(r.Context(), status, outcome, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list qa samples", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]qaSampleDTO, len(samples))
+	for i := range samples {
+		dtos[i] = toQASampleDTO(&samples[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminQAHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	sample, err := h.samples.GetByID(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("qa sample lookup failed", "sample_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toQASampleDTO(sample))
+}
+
+func (h *AdminQAHandler) Review(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req reviewQASampleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	sample, err := h.reviewer.Review(r.Context(), id, staffID, domain.QAOutcome(req.Outcome), req.Note)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to review qa sample", "sample_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toQASampleDTO(sample))
+}
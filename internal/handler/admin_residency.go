@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type residencyService interface {
+	Add(ctx context.Context, req service.AddResidencyRuleRequest) (*domain.ResidencyRule, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.ResidencyRule, error)
+	List(ctx context.Context, country string, limit, offset int) ([]domain.ResidencyRule, error)
+	UpdateReason(ctx context.Context, id uuid.UUID, reason string, actorID uuid.UUID) (*domain.ResidencyRule, error)
+	Remove(ctx context.Context, id uuid.UUID, actorID uuid.UUID) (*domain.ResidencyRule, error)
+	SetUserResidency(ctx context.Context, userID uuid.UUID, country *string, actorID uuid.UUID) (*domain.User, error)
+}
+
+type AdminResidencyHandler struct {
+	residency residencyService
+}
+
+func NewAdminResidencyHandler(residency residencyService) *AdminResidencyHandler {
+	return &AdminResidencyHandler{residency: residency}
+}
+
+type addResidencyRuleRequest struct {
+	Country        string  `json:"country"`
+	SourceCurrency *string `json:"source_currency"`
+	DestCurrency   *string `json:"dest_currency"`
+	DestCountry    *string `json:"dest_country"`
+	Reason         string  `json:"reason"`
+}
+
+func (r addResidencyRuleRequest) Validate() []FieldError {
+	var errs []FieldError
+	if _, ok := domain.NormalizeCountry(r.Country); !ok {
+		errs = append(errs, FieldError{Field: "country", Message: "must be a two-letter ISO 3166-1 country code"})
+	}
+	if r.SourceCurrency != nil && !domain.Currency(*r.SourceCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "source_currency", Message: "must be a supported currency"})
+	}
+	if r.DestCurrency != nil && !domain.Currency(*r.DestCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "dest_currency", Message: "must be a supported currency"})
+	}
+	if r.DestCountry != nil {
+		if _, ok := domain.NormalizeCountry(*r.DestCountry); !ok {
+			errs = append(errs, FieldError{Field: "dest_country", Message: "must be a two-letter ISO 3166-1 country code"})
+		}
+	}
+	if len(r.Reason) > 500 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+type updateResidencyRuleRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r updateResidencyRuleRequest) Validate() []FieldError {
+	var errs []FieldError
+	if len(r.Reason) > 500 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+// setResidencyRequest sets a user's country of residence; a null country
+// clears it.
+type setResidencyRequest struct {
+	Country *string `json:"country"`
+}
+
+func (r setResidencyRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Country != nil {
+		if _, ok := domain.NormalizeCountry(*r.Country); !ok {
+			errs = append(errs, FieldError{Field: "country", Message: "must be a two-letter ISO 3166-1 country code or null"})
+		}
+	}
+	return errs
+}
+
+type residencyRuleDTO struct {
+	ID             uuid.UUID `json:"id"`
+	Country        string    `json:"country"`
+	SourceCurrency *string   `json:"source_currency"`
+	DestCurrency   *string   `json:"dest_currency"`
+	DestCountry    *string   `json:"dest_country"`
+	Reason         string    `json:"reason"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func toResidencyRuleDTO(rule *domain.ResidencyRule) residencyRuleDTO {
+	return residencyRuleDTO{
+		ID:             rule.ID,
+		Country:        rule.Country,
+		SourceCurrency: currencyPtrString(rule.SourceCurrency),
+		DestCurrency:   currencyPtrString(rule.DestCurrency),
+		DestCountry:    rule.DestCountry,
+		Reason:         rule.Reason,
+		CreatedBy:      rule.CreatedBy,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+}
+
+func currencyPtrString(c *domain.Currency) *string {
+	if c == nil {
+		return nil
+	}
+	s := string(*c)
+	return &s
+}
+
+func (h *AdminResidencyHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req addResidencyRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	add := service.AddResidencyRuleRequest{
+		Country:     req.Country,
+		DestCountry: req.DestCountry,
+		Reason:      req.Reason,
+		ActorID:     actorID,
+	}
+	if req.SourceCurrency != nil {
+		c := domain.Currency(*req.SourceCurrency)
+		add.SourceCurrency = &c
+	}
+	if req.DestCurrency != nil {
+		c := domain.Currency(*req.DestCurrency)
+		add.DestCurrency = &c
+	}
+
+	rule, err := h.residency.Add(r.Context(), add)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to add residency rule", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toResidencyRuleDTO(rule))
+}
+
+func (h *AdminResidencyHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	country := q.Get("country")
+	if country != "" {
+		if _, ok := domain.NormalizeCountry(country); !ok {
+			RespondValidationError(w, []FieldError{{Field: "country", Message: "must be a two-letter ISO 3166-1 country code"}})
+			return
+		}
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	rules, err := h.residency.List(r.Context(), country, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list residency rules", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]residencyRuleDTO, len(rules))
+	for i := range rules {
+		dtos[i] = toResidencyRuleDTO(&rules[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminResidencyHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	rule, err := h.residency.Get(r.Context(), ruleID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toResidencyRuleDTO(rule))
+}
+
+func (h *AdminResidencyHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req updateResidencyRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	rule, err := h.residency.UpdateReason(r.Context(), ruleID, req.Reason, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to update residency rule", "rule_id", ruleID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toResidencyRuleDTO(rule))
+}
+
+func (h *AdminResidencyHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	rule, err := h.residency.Remove(r.Context(), ruleID, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to remove residency rule", "rule_id", ruleID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toResidencyRuleDTO(rule))
+}
+
+// SetUserResidency records a user's verified country of residence. Users
+// can't set their own, since it decides which residency rules apply to them.
+func (h *AdminResidencyHandler) SetUserResidency(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req setResidencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	user, err := h.residency.SetUserResidency(r.Context(), userID, req.Country, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set user residency", "user_id", userID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
+}
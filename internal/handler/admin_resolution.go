@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type paymentResolver interface {
+	ResolvePayment(ctx context.Context, req service.ResolvePaymentRequest) (*domain.Payment, domain.PaymentStatus, error)
+}
+
+// AdminResolutionHandler settles payouts whose provider callback was lost.
+type AdminResolutionHandler struct {
+	resolver paymentResolver
+	audit    auditRecorder
+}
+
+func NewAdminResolutionHandler(resolver paymentResolver, audit auditRecorder) *AdminResolutionHandler {
+	return &AdminResolutionHandler{resolver: resolver, audit: audit}
+}
+
+type resolvePaymentRequest struct {
+	Status      string `json:"status"`
+	ProviderRef string `json:"provider_ref"`
+	FailureCode string `json:"failure_code,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Evidence    string `json:"evidence"`
+}
+
+func (r resolvePaymentRequest) Validate() []FieldError {
+	var errs []FieldError
+	status := domain.PaymentStatus(r.Status)
+	if status != domain.PaymentStatusCompleted && status != domain.PaymentStatusFailed {
+		errs = append(errs, FieldError{Field: "status", Message: "must be completed or failed"})
+	}
+	if r.ProviderRef == "" {
+		errs = append(errs, FieldError{Field: "provider_ref", Message: "is required"})
+	} else if len(r.ProviderRef) > 255 {
+		errs = append(errs, FieldError{Field: "provider_ref", Message: "must be at most 255 characters"})
+	}
+	if r.Evidence == "" {
+		errs = append(errs, FieldError{Field: "evidence", Message: "is required"})
+	} else if len(r.Evidence) > 2000 {
+		errs = append(errs, FieldError{Field: "evidence", Message: "must be at most 2000 characters"})
+	}
+	if status == domain.PaymentStatusCompleted {
+		if r.FailureCode != "" {
+			errs = append(errs, FieldError{Field: "failure_code", Message: "only for failed outcomes"})
+		}
+		if r.Reason != "" {
+			errs = append(errs, FieldError{Field: "reason", Message: "only for failed outcomes"})
+		}
+	}
+	if r.FailureCode != "" && !domain.FailureCode(r.FailureCode).IsValid() {
+		errs = append(errs, FieldError{Field: "failure_code", Message: "must be a known failure code"})
+	}
+	if len(r.Reason) > 2000 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+// Resolve applies a payout outcome an operator confirmed with the provider
+// by other means, through the same path as the provider's callback.
+func (h *AdminResolutionHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	operatorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req resolvePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	p, before, err := h.resolver.ResolvePayment(r.Context(), service.ResolvePaymentRequest{
+		PaymentID:   paymentID,
+		OperatorID:  operatorID,
+		Status:      domain.PaymentStatus(req.Status),
+		ProviderRef: req.ProviderRef,
+		FailureCode: domain.FailureCode(req.FailureCode),
+		Reason:      req.Reason,
+		Evidence:    req.Evidence,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to resolve payout", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	after := map[string]any{
+		"status":       p.Status,
+		"provider_ref": req.ProviderRef,
+		"evidence":     req.Evidence,
+	}
+	if p.FailureCode != nil {
+		after["failure_code"] = *p.FailureCode
+	}
+	if p.FailureReason != nil {
+		after["failure_reason"] = *p.FailureReason
+	}
+	h.audit.Record(r.Context(), domain.AuditEntry{
+		Action:       domain.AuditActionPaymentResolved,
+		ResourceType: "payment",
+		ResourceID:   paymentID.String(),
+		Before:       map[string]any{"status": before},
+		After:        after,
+	})
+	RespondSuccess(w, http.StatusOK, toPaymentDTO(p))
+}
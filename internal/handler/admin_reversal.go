@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type transferReverser interface {
+	ReverseInternalTransfer(ctx context.Context, req payment.ReverseTransferRequest) (*domain.Payment, *domain.Payment, error)
+}
+
+type AdminReversalHandler struct {
+	reverser transferReverser
+}
+
+func NewAdminReversalHandler(reverser transferReverser) *AdminReversalHandler {
+	return &AdminReversalHandler{reverser: reverser}
+}
+
+type reversePaymentRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r reversePaymentRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Reason == "" {
+		errs = append(errs, FieldError{Field: "reason", Message: "is required"})
+	} else if len(r.Reason) > 2000 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+type paymentReversalDTO struct {
+	Payment  paymentDTO `json:"payment"`
+	Reversal paymentDTO `json:"reversal"`
+}
+
+func (h *AdminReversalHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req reversePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	original, reversal, err := h.reverser.ReverseInternalTransfer(r.Context(), payment.ReverseTransferRequest{
+		PaymentID: paymentID,
+		StaffID:   staffID,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to reverse payment", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, paymentReversalDTO{
+		Payment:  toPaymentDTO(original),
+		Reversal: toPaymentDTO(reversal),
+	})
+}
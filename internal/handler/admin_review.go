@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type reviewQueueLister interface {
+	ListPendingReview(ctx context.Context, reason domain.ReviewReason, limit, offset int) ([]domain.Payment, error)
+}
+
+type reviewApprover interface {
+	ApproveReview(ctx context.Context, paymentID, staffID uuid.UUID) (*domain.Payment, error)
+}
+
+type reviewRejecter interface {
+	RejectReview(ctx context.Context, paymentID, staffID uuid.UUID, reason string) (*domain.Payment, error)
+}
+
+type AdminReviewHandler struct {
+	queue    reviewQueueLister
+	approver reviewApprover
+	rejecter reviewRejecter
+}
+
+func NewAdminReviewHandler(queue reviewQueueLister, approver reviewApprover, rejecter reviewRejecter) *AdminReviewHandler {
+	return &AdminReviewHandler{queue: queue, approver: approver, rejecter: rejecter}
+}
+
+type rejectReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r rejectReviewRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Reason == "" {
+		errs = append(errs, FieldError{Field: "reason", Message: "is required"})
+	} else if len(r.Reason) > 2000 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+func (h *AdminReviewHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	reason := domain.ReviewReason(q.Get("reason"))
+	if reason != "" && reason != domain.ReviewReasonScreening && reason != domain.ReviewReasonAmountThreshold {
+		RespondValidationError(w, []FieldError{{Field: "reason", Message: "must be screening or amount_threshold"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	payments, err := h.queue.ListPendingReview(r.Context(), reason, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list review queue", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]paymentDTO, len(payments))
+	for i := range payments {
+		dtos[i] = toPaymentDTO(&payments[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminReviewHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	p, err := h.approver.ApproveReview(r.Context(), paymentID, staffID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to approve payout under review", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toPaymentDTO(p))
+}
+
+func (h *AdminReviewHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req rejectReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	p, err := h.rejecter.RejectReview(r.Context(), paymentID, staffID, req.Reason)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to reject payout under review", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toPaymentDTO(p))
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type denylistService interface {
+	Add(ctx context.Context, req service.AddDenylistEntryRequest) (*domain.DenylistEntry, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.DenylistEntry, error)
+	List(ctx context.Context, field domain.DenylistField, limit, offset int) ([]domain.DenylistEntry, error)
+	UpdateReason(ctx context.Context, id uuid.UUID, reason string, actorID uuid.UUID) (*domain.DenylistEntry, error)
+	Remove(ctx context.Context, id uuid.UUID, actorID uuid.UUID) (*domain.DenylistEntry, error)
+}
+
+type AdminScreeningHandler struct {
+	denylist denylistService
+}
+
+func NewAdminScreeningHandler(denylist denylistService) *AdminScreeningHandler {
+	return &AdminScreeningHandler{denylist: denylist}
+}
+
+type addDenylistEntryRequest struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+func (r addDenylistEntryRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.DenylistField(r.Field).IsValid() {
+		errs = append(errs, FieldError{Field: "field", Message: "must be iban or bank_name"})
+	}
+	if r.Value == "" {
+		errs = append(errs, FieldError{Field: "value", Message: "is required"})
+	} else if len(r.Value) > 255 {
+		errs = append(errs, FieldError{Field: "value", Message: "must be at most 255 characters"})
+	}
+	if len(r.Reason) > 500 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+type updateDenylistEntryRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r updateDenylistEntryRequest) Validate() []FieldError {
+	var errs []FieldError
+	if len(r.Reason) > 500 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+type denylistEntryDTO struct {
+	ID        uuid.UUID `json:"id"`
+	Field     string    `json:"field"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toDenylistEntryDTO(e *domain.DenylistEntry) denylistEntryDTO {
+	return denylistEntryDTO{
+		ID:        e.ID,
+		Field:     string(e.Field),
+		Value:     e.Value,
+		Reason:    e.Reason,
+		CreatedBy: e.CreatedBy,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+func (h *AdminScreeningHandler) CreateEntry(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req addDenylistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	entry, err := h.denylist.Add(r.Context(), service.AddDenylistEntryRequest{
+		Field:   domain.DenylistField(req.Field),
+		Value:   req.Value,
+		Reason:  req.Reason,
+		ActorID: actorID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to add denylist entry", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toDenylistEntryDTO(entry))
+}
+
+func (h *AdminScreeningHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	field := domain.DenylistField(q.Get("field"))
+	if field != "" && !field.IsValid() {
+		RespondValidationError(w, []FieldError{{Field: "field", Message: "must be iban or bank_name"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	entries, err := h.denylist.List(r.Context(), field, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list denylist entries", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]denylistEntryDTO, len(entries))
+	for i := range entries {
+		dtos[i] = toDenylistEntryDTO(&entries[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminScreeningHandler) GetEntry(w http.ResponseWriter, r *http.Request) {
+	entryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	entry, err := h.denylist.Get(r.Context(), entryID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toDenylistEntryDTO(entry))
+}
+
+func (h *AdminScreeningHandler) UpdateEntry(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	entryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req updateDenylistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	entry, err := h.denylist.UpdateReason(r.Context(), entryID, req.Reason, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to update denylist entry", "entry_id", entryID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toDenylistEntryDTO(entry))
+}
+
+func (h *AdminScreeningHandler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	entryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	entry, err := h.denylist.Remove(r.Context(), entryID, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to remove denylist entry", "entry_id", entryID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toDenylistEntryDTO(entry))
+}
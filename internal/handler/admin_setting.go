@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// maxProofOfWorkDifficulty keeps a challenge solvable on a phone.
+const maxProofOfWorkDifficulty = 26
+
+type loginChallengeSettings interface {
+	Setting(ctx context.Context) (*domain.LoginChallengeSetting, error)
+	Set(ctx context.Context, setting domain.LoginChallengeSetting, actorID uuid.UUID) (*domain.LoginChallengeSetting, error)
+}
+
+type AdminSettingHandler struct {
+	loginChallenge loginChallengeSettings
+}
+
+func NewAdminSettingHandler(loginChallenge loginChallengeSettings) *AdminSettingHandler {
+	return &AdminSettingHandler{loginChallenge: loginChallenge}
+}
+
+type setLoginChallengeRequest struct {
+	Mode       domain.LoginChallengeMode `json:"mode"`
+	Difficulty int                       `json:"difficulty"`
+}
+
+func (r setLoginChallengeRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !r.Mode.IsValid() {
+		errs = append(errs, FieldError{Field: "mode", Message: "must be off, proof_of_work or captcha"})
+	}
+	if r.Difficulty < 0 || r.Difficulty > maxProofOfWorkDifficulty {
+		errs = append(errs, FieldError{Field: "difficulty", Message: "must be between 1 and 26, or omitted for the default"})
+	}
+	return errs
+}
+
+type loginChallengeSettingDTO struct {
+	Mode       domain.LoginChallengeMode `json:"mode"`
+	Difficulty int                       `json:"difficulty,omitempty"`
+	UpdatedBy  *uuid.UUID                `json:"updated_by,omitempty"`
+	UpdatedAt  *time.Time                `json:"updated_at,omitempty"`
+}
+
+func toLoginChallengeSettingDTO(s *domain.LoginChallengeSetting) loginChallengeSettingDTO {
+	return loginChallengeSettingDTO{
+		Mode:       s.Mode,
+		Difficulty: s.Difficulty,
+		UpdatedBy:  s.UpdatedBy,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+func (h *AdminSettingHandler) LoginChallenge(w http.ResponseWriter, r *http.Request) {
+	setting, err := h.loginChallenge.Setting(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read login challenge setting", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toLoginChallengeSettingDTO(setting))
+}
+
+// SetLoginChallenge turns login challenges on or off for every instance.
+func (h *AdminSettingHandler) SetLoginChallenge(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req setLoginChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	setting, err := h.loginChallenge.Set(r.Context(), domain.LoginChallengeSetting{
+		Mode:       req.Mode,
+		Difficulty: req.Difficulty,
+	}, actorID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set login challenge setting", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toLoginChallengeSettingDTO(setting))
+}
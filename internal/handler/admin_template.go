@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type templateSet interface {
+	List() []templates.Ref
+	Preview(name, locale string) (templates.Message, error)
+}
+
+type AdminTemplateHandler struct {
+	templates templateSet
+}
+
+func NewAdminTemplateHandler(templates templateSet) *AdminTemplateHandler {
+	return &AdminTemplateHandler{templates: templates}
+}
+
+type templatePreviewDTO struct {
+	Template templates.Ref `json:"template"`
+	Subject  string        `json:"subject"`
+	Body     string        `json:"body"`
+}
+
+func (h *AdminTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	RespondSuccess(w, http.StatusOK, h.templates.List())
+}
+
+// Preview renders a template with built-in sample data. Without ?locale=
+// the default locale is used; a locale the template lacks falls back to it,
+// as it would when sending.
+func (h *AdminTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale = templates.DefaultLocale
+	}
+
+	msg, err := h.templates.Preview(r.PathValue("name"), locale)
+	if err != nil {
+		if errors.Is(err, templates.ErrUnknownTemplate) {
+			RespondAppError(w, ErrResourceNotFound, nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("failed to render template preview", "error", err)
+		RespondAppError(w, ErrInternalError, nil)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, templatePreviewDTO{Template: msg.Ref, Subject: msg.Subject, Body: msg.Body})
+}
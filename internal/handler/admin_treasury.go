@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type fxPoolService interface {
+	ListPools(ctx context.Context) ([]domain.FXPool, error)
+	SetWatermark(ctx context.Context, req service.SetWatermarkRequest) (*domain.FXPoolWatermark, error)
+	ClearWatermark(ctx context.Context, currency domain.Currency, actorID uuid.UUID) error
+}
+
+type poolTransferer interface {
+	TransferBetweenPools(ctx context.Context, req payment.PoolTransferRequest) (*domain.Payment, error)
+}
+
+type AdminTreasuryHandler struct {
+	pools     fxPoolService
+	transfers poolTransferer
+}
+
+func NewAdminTreasuryHandler(pools fxPoolService, transfers poolTransferer) *AdminTreasuryHandler {
+	return &AdminTreasuryHandler{pools: pools, transfers: transfers}
+}
+
+type fxPoolDTO struct {
+	Currency       string     `json:"currency"`
+	AccountID      uuid.UUID  `json:"account_id"`
+	Balance        string     `json:"balance"`
+	LowWatermark   *string    `json:"low_watermark,omitempty"`
+	BelowWatermark bool       `json:"below_watermark"`
+	WatermarkSetBy *uuid.UUID `json:"watermark_set_by,omitempty"`
+	WatermarkSetAt *time.Time `json:"watermark_set_at,omitempty"`
+}
+
+func toFXPoolDTO(p domain.FXPool) fxPoolDTO {
+	dto := fxPoolDTO{
+		Currency:       string(p.Currency),
+		AccountID:      p.AccountID,
+		Balance:        formatAmount(p.Balance, p.Currency),
+		BelowWatermark: p.Low(),
+	}
+	if m := p.Watermark; m != nil {
+		dto.LowWatermark = formatAmountPtr(&m.LowWatermark, p.Currency)
+		dto.WatermarkSetBy = &m.UpdatedBy
+		dto.WatermarkSetAt = &m.UpdatedAt
+	}
+	return dto
+}
+
+type poolTransferRequest struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	Amount       string `json:"amount"`
+}
+
+func (r poolTransferRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.Currency(r.FromCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "from_currency", Message: "must be a supported currency"})
+	}
+	if !domain.Currency(r.ToCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "to_currency", Message: "must be a supported currency"})
+	}
+	if r.FromCurrency == r.ToCurrency && r.FromCurrency != "" {
+		errs = append(errs, FieldError{Field: "to_currency", Message: "must differ from from_currency"})
+	}
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
+	}
+	return errs
+}
+
+type setWatermarkRequest struct {
+	LowWatermark string `json:"low_watermark"`
+}
+
+func (r setWatermarkRequest) Validate() []FieldError {
+	var errs []FieldError
+	if fe := checkAmount("low_watermark", r.LowWatermark); fe != nil {
+		errs = append(errs, *fe)
+	}
+	return errs
+}
+
+// Pools lists every FX pool with its balance and low-watermark.
+func (h *AdminTreasuryHandler) Pools(w http.ResponseWriter, r *http.Request) {
+	pools, err := h.pools.ListPools(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list fx pools", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]fxPoolDTO, len(pools))
+	for i, p := range pools {
+		dtos[i] = toFXPoolDTO(p)
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+// Transfer moves liquidity from one FX pool to another at the mid-market
+// rate.
+func (h *AdminTreasuryHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req poolTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	amount, fields := parseAmount("amount", req.Amount, domain.Currency(req.FromCurrency))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	p, err := h.transfers.TransferBetweenPools(r.Context(), payment.PoolTransferRequest{
+		ActorID:        actorID,
+		FromCurrency:   domain.Currency(req.FromCurrency),
+		ToCurrency:     domain.Currency(req.ToCurrency),
+		Amount:         amount,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to transfer between fx pools", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toPaymentDTO(p))
+}
+
+func (h *AdminTreasuryHandler) SetWatermark(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req setWatermarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	currency := domain.Currency(r.PathValue("currency"))
+	watermark, fields := parseAmount("low_watermark", req.LowWatermark, currency)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	if _, err := h.pools.SetWatermark(r.Context(), service.SetWatermarkRequest{
+		Currency:     currency,
+		LowWatermark: watermark,
+		ActorID:      actorID,
+	}); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set fx pool watermark", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	h.respondPool(w, r, currency)
+}
+
+func (h *AdminTreasuryHandler) ClearWatermark(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	currency := domain.Currency(r.PathValue("currency"))
+	if err := h.pools.ClearWatermark(r.Context(), currency, actorID); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to clear fx pool watermark", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	h.respondPool(w, r, currency)
+}
+
+func (h *AdminTreasuryHandler) respondPool(w http.ResponseWriter, r *http.Request, currency domain.Currency) {
+	pools, err := h.pools.ListPools(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list fx pools", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	for _, p := range pools {
+		if p.Currency == currency {
+			RespondSuccess(w, http.StatusOK, toFXPoolDTO(p))
+			return
+		}
+	}
+	RespondAppError(w, ErrResourceNotFound, nil)
+}
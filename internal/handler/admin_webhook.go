@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	defaultWebhookStatsIntervalM = 5
+	maxWebhookStatsIntervalM     = 60
+	defaultWebhookStatsIntervals = 12
+	maxWebhookStatsIntervals     = 96
+)
+
+type webhookEventAdmin interface {
+	Stats(ctx context.Context, now time.Time, interval time.Duration, n int) (*domain.WebhookStats, error)
+	List(ctx context.Context, filter domain.WebhookEventFilter, limit, offset int) ([]domain.WebhookEvent, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.WebhookEvent, error)
+	Requeue(ctx context.Context, id uuid.UUID) (*domain.WebhookEvent, error)
+}
+
+type AdminWebhookHandler struct {
+	events webhookEventAdmin
+}
+
+func NewAdminWebhookHandler(events webhookEventAdmin) *AdminWebhookHandler {
+	return &AdminWebhookHandler{events: events}
+}
+
+type webhookEventDTO struct {
+	ID             uuid.UUID       `json:"id"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	EventType      string          `json:"event_type"`
+	PaymentID      *uuid.UUID      `json:"payment_id"`
+	Provider       *string         `json:"provider"`
+	Status         string          `json:"status"`
+	Priority       string          `json:"priority"`
+	Attempts       int             `json:"attempts"`
+	LastAttempt    *time.Time      `json:"last_attempt"`
+	LastError      *string         `json:"last_error"`
+	ClaimedBy      *string         `json:"claimed_by"`
+	LeaseExpiresAt *time.Time      `json:"lease_expires_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func toWebhookEventDTO(e *domain.WebhookEvent) webhookEventDTO {
+	return webhookEventDTO{
+		ID:             e.ID,
+		IdempotencyKey: e.IdempotencyKey,
+		EventType:      string(e.EventType),
+		PaymentID:      e.PaymentID,
+		Provider:       e.Provider,
+		Status:         string(e.Status),
+		Priority:       e.Priority.String(),
+		Attempts:       e.Attempts,
+		LastAttempt:    e.LastAttempt,
+		LastError:      e.LastError,
+		ClaimedBy:      e.ClaimedBy,
+		LeaseExpiresAt: e.LeaseExpiresAt,
+		CreatedAt:      e.CreatedAt,
+		Payload:        e.Payload,
+	}
+}
+
+var webhookEventStatuses = []domain.WebhookEventStatus{
+	domain.WebhookEventStatusPending,
+	domain.WebhookEventStatusProcessing,
+	domain.WebhookEventStatusDispatched,
+	domain.WebhookEventStatusFailed,
+}
+
+// ListEvents lists stored provider callbacks, newest first, filtered by
+// `status` (comma-separated) and `payment_id`.
+func (h *AdminWebhookHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var filter domain.WebhookEventFilter
+	var fields []FieldError
+
+	if v := q.Get("status"); v != "" {
+		for _, raw := range strings.Split(v, ",") {
+			status := domain.WebhookEventStatus(strings.TrimSpace(raw))
+			if !slices.Contains(webhookEventStatuses, status) {
+				fields = append(fields, FieldError{Field: "status", Message: "must be pending, processing, dispatched or failed"})
+				break
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if v := q.Get("payment_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "payment_id", Message: "must be a valid UUID"})
+		}
+		filter.PaymentID = &id
+	}
+	limit, offset, pageFields := parsePage(q.Get("limit"), q.Get("offset"))
+	fields = append(fields, pageFields...)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	events, err := h.events.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list webhook events", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]webhookEventDTO, len(events))
+	for i := range events {
+		dtos[i] = toWebhookEventDTO(&events[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *AdminWebhookHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	event, err := h.events.GetByID(r.Context(), id)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toWebhookEventDTO(event))
+}
+
+// RetryEvent puts a failed event back in the queue once whatever made it
+// fail has been fixed. The processor picks it up straight away.
+func (h *AdminWebhookHandler) RetryEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	event, err := h.events.Requeue(r.Context(), id)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) && !errors.Is(err, domain.ErrWebhookEventNotRetriable) {
+			logging.FromContext(r.Context()).Error("failed to requeue webhook event", "webhook_event_id", id, "error", err)
+		}
+		RespondDomainError(w, err)
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("webhook event requeued", "webhook_event_id", id, "attempts", event.Attempts)
+	RespondSuccess(w, http.StatusOK, toWebhookEventDTO(event))
+}
+
+type webhookIntervalDTO struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Received    int       `json:"received"`
+	Dispatched  int       `json:"dispatched"`
+	Failed      int       `json:"failed"`
+	FailureRate string    `json:"failure_rate"`
+}
+
+type webhookStatsDTO struct {
+	Counts           map[string]int       `json:"counts"`
+	Backlog          int                  `json:"backlog"`
+	OldestPendingAt  *time.Time           `json:"oldest_pending_at,omitempty"`
+	OldestPendingAge int64                `json:"oldest_pending_age_s"`
+	IntervalSeconds  int64                `json:"interval_s"`
+	Intervals        []webhookIntervalDTO `json:"intervals"`
+}
+
+// Stats reports the webhook backlog and, for each of the last `intervals`
+// windows of `interval_m` minutes, how many events arrived, were processed
+// and failed. Intervals are oldest first.
+func (h *AdminWebhookHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	intervalM, n, fields := parseWebhookStatsParams(r)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	now := time.Now().UTC()
+	interval := time.Duration(intervalM) * time.Minute
+	stats, err := h.events.Stats(r.Context(), now, interval, n)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read webhook stats", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	resp := webhookStatsDTO{
+		Counts:          make(map[string]int, len(stats.Counts)),
+		Backlog:         stats.Counts[domain.WebhookEventStatusPending],
+		OldestPendingAt: stats.OldestPendingAt,
+		IntervalSeconds: int64(interval.Seconds()),
+		Intervals:       make([]webhookIntervalDTO, len(stats.Intervals)),
+	}
+	for _, s := range webhookEventStatuses {
+		resp.Counts[string(s)] = stats.Counts[s]
+	}
+	if stats.OldestPendingAt != nil {
+		resp.OldestPendingAge = int64(now.Sub(*stats.OldestPendingAt).Seconds())
+	}
+	for i, iv := range stats.Intervals {
+		resp.Intervals[i] = webhookIntervalDTO{
+			Start:       iv.Start,
+			End:         iv.End,
+			Received:    iv.Received,
+			Dispatched:  iv.Dispatched,
+			Failed:      iv.Failed,
+			FailureRate: iv.FailureRate().String(),
+		}
+	}
+
+	RespondSuccess(w, http.StatusOK, resp)
+}
+
+func parseWebhookStatsParams(r *http.Request) (int, int, []FieldError) {
+	var errs []FieldError
+	intervalM := defaultWebhookStatsIntervalM
+	if v := r.URL.Query().Get("interval_m"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWebhookStatsIntervalM {
+			errs = append(errs, FieldError{Field: "interval_m", Message: "must be between 1 and " + strconv.Itoa(maxWebhookStatsIntervalM)})
+		}
+		intervalM = n
+	}
+
+	n := defaultWebhookStatsIntervals
+	if v := r.URL.Query().Get("intervals"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil || c < 1 || c > maxWebhookStatsIntervals {
+			errs = append(errs, FieldError{Field: "intervals", Message: "must be between 1 and " + strconv.Itoa(maxWebhookStatsIntervals)})
+		}
+		n = c
+	}
+	return intervalM, n, errs
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubWebhookStats struct {
+	stats    *domain.WebhookStats
+	interval time.Duration
+	n        int
+
+	events []domain.WebhookEvent
+	filter domain.WebhookEventFilter
+}
+
+func (s *stubWebhookStats) Stats(_ context.Context, _ time.Time, interval time.Duration, n int) (*domain.WebhookStats, error) {
+	s.interval, s.n = interval, n
+	return s.stats, nil
+}
+
+func (s *stubWebhookStats) List(_ context.Context, filter domain.WebhookEventFilter, _, _ int) ([]domain.WebhookEvent, error) {
+	s.filter = filter
+	return s.events, nil
+}
+
+func (s *stubWebhookStats) GetByID(_ context.Context, id uuid.UUID) (*domain.WebhookEvent, error) {
+	for i := range s.events {
+		if s.events[i].ID == id {
+			return &s.events[i], nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *stubWebhookStats) Requeue(ctx context.Context, id uuid.UUID) (*domain.WebhookEvent, error) {
+	e, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != domain.WebhookEventStatusFailed {
+		return nil, domain.ErrWebhookEventNotRetriable
+	}
+	e.Status = domain.WebhookEventStatusPending
+	return e, nil
+}
+
+func TestAdminWebhookEvents_ListAndRetry(t *testing.T) {
+	paymentID := uuid.New()
+	reason := "malformed payload: unexpected end of JSON input"
+	failed := domain.WebhookEvent{
+		ID: uuid.New(), IdempotencyKey: "evt-1", EventType: domain.WebhookEventTypePaymentFailed,
+		Payload: json.RawMessage(`{"payment_id":"x"}`), PaymentID: &paymentID,
+		Status: domain.WebhookEventStatusFailed, Priority: domain.WebhookPriorityNormal, Attempts: 1, LastError: &reason,
+	}
+	dispatched := domain.WebhookEvent{ID: uuid.New(), Status: domain.WebhookEventStatusDispatched, Payload: json.RawMessage(`{}`)}
+	stub := &stubWebhookStats{events: []domain.WebhookEvent{failed, dispatched}}
+	h := NewAdminWebhookHandler(stub)
+
+	rec := httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhook-events?status=failed,pending&payment_id="+paymentID.String(), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []domain.WebhookEventStatus{domain.WebhookEventStatusFailed, domain.WebhookEventStatusPending}, stub.filter.Statuses)
+	require.NotNil(t, stub.filter.PaymentID)
+	assert.Equal(t, paymentID, *stub.filter.PaymentID)
+	var list struct {
+		Data []webhookEventDTO `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, "normal", list.Data[0].Priority)
+	assert.Equal(t, reason, *list.Data[0].LastError)
+	assert.JSONEq(t, `{"payment_id":"x"}`, string(list.Data[0].Payload))
+
+	rec = httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhook-events?status=lost", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	retry := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhook-events/"+id+"/retry", nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		h.RetryEvent(rec, req)
+		return rec
+	}
+	rec = retry(failed.ID.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"pending"`)
+	assert.Equal(t, http.StatusConflict, retry(dispatched.ID.String()).Code)
+	assert.Equal(t, http.StatusNotFound, retry(uuid.NewString()).Code)
+}
+
+func TestAdminWebhookStats(t *testing.T) {
+	oldest := time.Now().UTC().Add(-10 * time.Minute)
+	start := time.Now().UTC().Truncate(time.Minute)
+	stub := &stubWebhookStats{stats: &domain.WebhookStats{
+		Counts:          map[domain.WebhookEventStatus]int{domain.WebhookEventStatusPending: 7},
+		OldestPendingAt: &oldest,
+		Intervals: []domain.WebhookInterval{
+			{Start: start, End: start.Add(time.Minute), Received: 4, Dispatched: 3, Failed: 1},
+		},
+	}}
+	h := NewAdminWebhookHandler(stub)
+
+	rec := httptest.NewRecorder()
+	h.Stats(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/stats?interval_m=1&intervals=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, time.Minute, stub.interval)
+	assert.Equal(t, 1, stub.n)
+
+	var body struct {
+		Data webhookStatsDTO `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 7, body.Data.Backlog)
+	assert.Equal(t, 0, body.Data.Counts["failed"], "every status is reported, zero or not")
+	assert.GreaterOrEqual(t, body.Data.OldestPendingAge, int64(600))
+	require.Len(t, body.Data.Intervals, 1)
+	assert.Equal(t, "0.25", body.Data.Intervals[0].FailureRate)
+
+	rec = httptest.NewRecorder()
+	h.Stats(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/stats?interval_m=0&intervals=1000", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// Amounts cross the API as decimal strings in major units, "150.00", with
+// as many decimal places as the currency has. Internally they stay int64
+// minor units; these helpers convert at the edge.
+
+// formatAmount shows minor units of currency as the API shows amounts.
+func formatAmount(minor int64, currency domain.Currency) string {
+	return domain.NewMoney(minor, currency).Decimal()
+}
+
+// formatAmountPtr is formatAmount for optional amounts.
+func formatAmountPtr(minor *int64, currency domain.Currency) *string {
+	if minor == nil {
+		return nil
+	}
+	s := formatAmount(*minor, currency)
+	return &s
+}
+
+// formatMoneyPtr shows an optional Money as the API shows amounts.
+func formatMoneyPtr(m *domain.Money) *string {
+	if m == nil {
+		return nil
+	}
+	s := m.Decimal()
+	return &s
+}
+
+// checkAmount is the part of validating an amount field that needs no
+// currency: it is present, a number, and above zero. parseAmount checks
+// the rest once the currency is known.
+func checkAmount(field, s string) *FieldError {
+	if s == "" {
+		return &FieldError{Field: field, Message: "required"}
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return &FieldError{Field: field, Message: `must be a decimal string, e.g. "150.00"`}
+	}
+	if !d.IsPositive() {
+		return &FieldError{Field: field, Message: "must be greater than zero"}
+	}
+	return nil
+}
+
+// parseAmount reads an amount field as minor units of currency. It fails
+// when the amount isn't written as a plain decimal or has more decimal
+// places than the currency.
+func parseAmount(field, s string, currency domain.Currency) (int64, []FieldError) {
+	m, err := domain.ParseMoney(s, currency)
+	if err != nil {
+		msg := fmt.Sprintf("must be a decimal with at most %d decimal places for %s", currency.Exponent(), currency)
+		if currency.Exponent() == 0 {
+			msg = fmt.Sprintf("must be a whole number for %s", currency)
+		}
+		return 0, []FieldError{{Field: field, Message: msg}}
+	}
+	return m.Amount, nil
+}
+
+// formatAverageAmount shows a mean of minor units, rounded to the
+// currency's minor unit.
+func formatAverageAmount(minor float64, currency domain.Currency) string {
+	exp := int32(currency.Exponent())
+	return decimal.NewFromFloat(minor).Shift(-exp).StringFixed(exp)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestCheckAmount(t *testing.T) {
+	assert.Nil(t, checkAmount("amount", "150.00"))
+	assert.Nil(t, checkAmount("amount", "0.001"), "precision is checked once the currency is known")
+
+	for in, msg := range map[string]string{
+		"":      "required",
+		"abc":   `must be a decimal string, e.g. "150.00"`,
+		"0.00":  "must be greater than zero",
+		"-1.00": "must be greater than zero",
+	} {
+		fe := checkAmount("amount", in)
+		require.NotNil(t, fe, in)
+		assert.Equal(t, FieldError{Field: "amount", Message: msg}, *fe, in)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	minor, fields := parseAmount("amount", "150.5", domain.CurrencyUSD)
+	assert.Empty(t, fields)
+	assert.Equal(t, int64(15_050), minor)
+
+	_, fields = parseAmount("amount", "1.005", domain.CurrencyUSD)
+	assert.Equal(t, []FieldError{{Field: "amount", Message: "must be a decimal with at most 2 decimal places for USD"}}, fields)
+
+	_, fields = parseAmount("amount", "1e3", domain.CurrencyUSD)
+	assert.NotEmpty(t, fields, "exponent notation is not a plain decimal")
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "150.00", formatAmount(15_000, domain.CurrencyUSD))
+	assert.Equal(t, "-0.05", formatAmount(-5, domain.CurrencyEUR))
+	assert.Nil(t, formatAmountPtr(nil, domain.CurrencyUSD))
+	assert.Equal(t, "12.33", formatAverageAmount(1_233.4, domain.CurrencyGBP))
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const maxAPIKeyNameLen = 100
+
+type apiKeyService interface {
+	Create(ctx context.Context, userID uuid.UUID, name string, scopes []domain.APIKeyScope, ip, userAgent string) (*domain.APIKey, string, error)
+	List(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error)
+	Rotate(ctx context.Context, userID, keyID uuid.UUID, ip, userAgent string) (*domain.APIKey, string, error)
+	Revoke(ctx context.Context, userID, keyID uuid.UUID) (*domain.APIKey, error)
+}
+
+type APIKeyHandler struct {
+	keys apiKeyService
+}
+
+func NewAPIKeyHandler(keys apiKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (r createAPIKeyRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "is required"})
+	} else if len(r.Name) > maxAPIKeyNameLen {
+		errs = append(errs, FieldError{Field: "name", Message: "must be at most 100 characters"})
+	}
+	if len(r.Scopes) == 0 {
+		errs = append(errs, FieldError{Field: "scopes", Message: "is required"})
+	}
+	for _, s := range r.Scopes {
+		if !domain.APIKeyScope(s).Valid() {
+			errs = append(errs, FieldError{Field: "scopes", Message: "must be read or payments:write"})
+			break
+		}
+	}
+	return errs
+}
+
+type apiKeyDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	MaskedKey   string     `json:"masked_key"`
+	Scopes      []string   `json:"scopes"`
+	CreatedIP   string     `json:"created_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RotatedFrom *uuid.UUID `json:"rotated_from,omitempty"`
+}
+
+// issuedAPIKeyDTO carries the full key. It is only returned by create and
+// rotate; the key can't be retrieved again.
+type issuedAPIKeyDTO struct {
+	apiKeyDTO
+	Key string `json:"key"`
+}
+
+func toAPIKeyDTO(k *domain.APIKey) apiKeyDTO {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	return apiKeyDTO{
+		ID:          k.ID,
+		Name:        k.Name,
+		MaskedKey:   k.Masked(),
+		Scopes:      scopes,
+		CreatedIP:   k.CreatedIP,
+		CreatedAt:   k.CreatedAt,
+		LastUsedAt:  k.LastUsedAt,
+		RevokedAt:   k.RevokedAt,
+		RotatedFrom: k.RotatedFrom,
+	}
+}
+
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	scopes := make([]domain.APIKeyScope, len(req.Scopes))
+	for i, s := range req.Scopes {
+		scopes[i] = domain.APIKeyScope(s)
+	}
+	key, plain, err := h.keys.Create(r.Context(), userID, req.Name, scopes, ClientIP(r), r.UserAgent())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to create api key", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, issuedAPIKeyDTO{apiKeyDTO: toAPIKeyDTO(key), Key: plain})
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	keys, err := h.keys.List(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list api keys", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]apiKeyDTO, len(keys))
+	for i := range keys {
+		dtos[i] = toAPIKeyDTO(&keys[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	userID, keyID, appErr := apiKeyFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	key, plain, err := h.keys.Rotate(r.Context(), userID, keyID, ClientIP(r), r.UserAgent())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to rotate api key", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, issuedAPIKeyDTO{apiKeyDTO: toAPIKeyDTO(key), Key: plain})
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, keyID, appErr := apiKeyFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	key, err := h.keys.Revoke(r.Context(), userID, keyID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to revoke api key", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAPIKeyDTO(key))
+}
+
+func apiKeyFromPath(r *http.Request) (uuid.UUID, uuid.UUID, *AppError) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		return uuid.Nil, uuid.Nil, appErr
+	}
+	keyID, err := uuid.Parse(r.PathValue("key_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrResourceNotFound
+	}
+	return userID, keyID, nil
+}
@@ -16,14 +16,24 @@ var (
 	ErrInvalidCredentials = &AppError{http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password"}
 	ErrInvalidRequest     = &AppError{http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body"}
 	ErrValidationFailed   = &AppError{http.StatusBadRequest, "VALIDATION_FAILED", "Validation failed"}
+	ErrForbidden          = &AppError{http.StatusForbidden, "FORBIDDEN", "Insufficient permissions"}
 	ErrResourceNotFound   = &AppError{http.StatusNotFound, "RESOURCE_NOT_FOUND", "Resource not found"}
 	ErrInternalError      = &AppError{http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred"}
+	ErrServiceOverloaded  = &AppError{http.StatusServiceUnavailable, "SERVICE_OVERLOADED", "Service is under heavy load, retry later"}
+	ErrRequestTimeout     = &AppError{http.StatusGatewayTimeout, "REQUEST_TIMEOUT", "The request did not finish within its deadline"}
+	ErrInvalidRequestTimeout = &AppError{http.StatusBadRequest, "INVALID_REQUEST_TIMEOUT", "X-Request-Timeout header is invalid"}
+	ErrRateLimited        = &AppError{http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests, retry later"}
+	ErrLoginLocked        = &AppError{http.StatusTooManyRequests, "LOGIN_LOCKED", "Too many failed login attempts, try again later"}
+	ErrInvalidAPIKey      = &AppError{http.StatusUnauthorized, "INVALID_API_KEY", "API key is invalid or has been revoked"}
+	ErrAPIKeyScope        = &AppError{http.StatusForbidden, "INSUFFICIENT_SCOPE", "API key does not have the scope this endpoint needs"}
+	ErrAPIKeyNotAllowed   = &AppError{http.StatusForbidden, "API_KEY_NOT_ALLOWED", "This endpoint requires a signed-in session, not an API key"}
 
 	ErrInsufficientFunds = &AppError{http.StatusUnprocessableEntity, "INSUFFICIENT_FUNDS", "Insufficient funds"}
 	ErrAccountFrozen     = &AppError{http.StatusUnprocessableEntity, "ACCOUNT_FROZEN", "Account is frozen"}
 	ErrDuplicatePayment  = &AppError{http.StatusConflict, "DUPLICATE_PAYMENT", "Duplicate payment"}
 	ErrSelfTransfer      = &AppError{http.StatusUnprocessableEntity, "SELF_TRANSFER_NOT_ALLOWED", "Cannot transfer to the same account"}
 	ErrLimitExceeded     = &AppError{http.StatusUnprocessableEntity, "TRANSACTION_LIMIT_EXCEEDED", "Transaction limit exceeded"}
+	ErrPeriodLimitExceeded = &AppError{http.StatusUnprocessableEntity, "LIMIT_EXCEEDED_PERIOD", "Daily or monthly limit exceeded"}
 	ErrRecipientNotFound = &AppError{http.StatusUnprocessableEntity, "RECIPIENT_NOT_FOUND", "Recipient not found"}
 	ErrAccountNotFound   = &AppError{http.StatusUnprocessableEntity, "ACCOUNT_NOT_FOUND", "Account not found"}
 	ErrAccountExists     = &AppError{http.StatusConflict, "ACCOUNT_ALREADY_EXISTS", "Account already exists for this currency"}
@@ -32,7 +42,57 @@ var (
 	ErrCurrencyMismatch  = &AppError{http.StatusUnprocessableEntity, "CURRENCY_MISMATCH", "Currency mismatch"}
 	ErrVersionConflict          = &AppError{http.StatusConflict, "VERSION_CONFLICT", "Resource was modified concurrently, please retry"}
 	ErrMissingIdempotencyKey    = &AppError{http.StatusBadRequest, "MISSING_IDEMPOTENCY_KEY", "Idempotency-Key header is required"}
+	ErrInvalidIdempotencyKey    = &AppError{http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key header does not meet the key policy"}
 	ErrIdempotencyConflict      = &AppError{http.StatusConflict, "IDEMPOTENCY_CONFLICT", "Idempotency key already used with a different request"}
 	ErrInvalidAmount            = &AppError{http.StatusBadRequest, "INVALID_AMOUNT", "Amount must be greater than zero"}
 	ErrInvalidSignature         = &AppError{http.StatusUnauthorized, "INVALID_SIGNATURE", "Webhook signature is invalid"}
+	ErrPayloadTooLarge          = &AppError{http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds the size limit"}
+	ErrStaleWebhook             = &AppError{http.StatusUnauthorized, "STALE_WEBHOOK", "Webhook timestamp is outside the allowed tolerance"}
+	ErrProviderNotVerified      = &AppError{http.StatusForbidden, "PROVIDER_NOT_VERIFIED", "Provider has not completed the callback handshake"}
+	ErrPaymentNotRetriable      = &AppError{http.StatusUnprocessableEntity, "PAYMENT_NOT_RETRIABLE", "Payment cannot be retried"}
+	ErrPaymentAlreadyRetried    = &AppError{http.StatusConflict, "PAYMENT_ALREADY_RETRIED", "Payment has already been retried"}
+	ErrNoProviderRoute          = &AppError{http.StatusUnprocessableEntity, "NO_PROVIDER_ROUTE", "No payout provider available for this currency corridor"}
+	ErrSettlementClosed         = &AppError{http.StatusConflict, "SETTLEMENT_CLOSED", "Settlement batch is already closed"}
+	ErrSettlementEmpty          = &AppError{http.StatusUnprocessableEntity, "SETTLEMENT_EMPTY", "Settlement batch has no payments"}
+	ErrDisputeExists            = &AppError{http.StatusConflict, "DISPUTE_EXISTS", "An active dispute already exists for this payment"}
+	ErrDisputeClosed            = &AppError{http.StatusConflict, "DISPUTE_CLOSED", "Dispute is already closed"}
+	ErrKYCTierInsufficient      = &AppError{http.StatusForbidden, "KYC_TIER_INSUFFICIENT", "Your verification tier does not allow this operation"}
+	ErrKYCSubmissionPending     = &AppError{http.StatusConflict, "KYC_SUBMISSION_PENDING", "A verification submission is already pending"}
+	ErrKYCSubmissionClosed      = &AppError{http.StatusConflict, "KYC_SUBMISSION_CLOSED", "Verification submission has already been reviewed"}
+	ErrPaymentNotPendingReview  = &AppError{http.StatusConflict, "PAYMENT_NOT_PENDING_REVIEW", "Payment is not pending review"}
+	ErrDenylistEntryExists      = &AppError{http.StatusConflict, "DENYLIST_ENTRY_EXISTS", "A denylist entry with this value already exists"}
+	ErrUniqueNameTaken          = &AppError{http.StatusConflict, "UNIQUE_NAME_TAKEN", "This grey tag is already taken"}
+	ErrUniqueNameCoolingDown    = &AppError{http.StatusConflict, "UNIQUE_NAME_COOLING_DOWN", "This grey tag was recently released and cannot be claimed yet"}
+	ErrEmailTaken               = &AppError{http.StatusConflict, "EMAIL_TAKEN", "This email is already in use"}
+	ErrPaymentNotReversible     = &AppError{http.StatusConflict, "PAYMENT_NOT_REVERSIBLE", "Only completed internal transfers can be reversed"}
+	ErrPaymentNotRefundable     = &AppError{http.StatusConflict, "PAYMENT_NOT_REFUNDABLE", "Only completed internal transfers can be refunded"}
+	ErrPaymentNotResolvable     = &AppError{http.StatusConflict, "PAYMENT_NOT_RESOLVABLE", "Only payouts waiting on their provider can be resolved by hand"}
+	ErrRefundExceedsPayment     = &AppError{http.StatusUnprocessableEntity, "REFUND_EXCEEDS_PAYMENT", "Refund exceeds the amount left to refund"}
+	ErrQASampleAlreadyReviewed  = &AppError{http.StatusConflict, "QA_SAMPLE_ALREADY_REVIEWED", "This QA sample has already been reviewed"}
+	ErrPaymentRequestClosed     = &AppError{http.StatusConflict, "PAYMENT_REQUEST_CLOSED", "This payment request is no longer pending"}
+	ErrPaymentRequestExpired    = &AppError{http.StatusConflict, "PAYMENT_REQUEST_EXPIRED", "This payment request has expired"}
+	ErrHoldNotActive            = &AppError{http.StatusConflict, "HOLD_NOT_ACTIVE", "Hold has been captured, released or has expired"}
+	ErrHoldAmountExceeded       = &AppError{http.StatusUnprocessableEntity, "HOLD_AMOUNT_EXCEEDED", "Amount exceeds the hold"}
+	ErrSweepRequired            = &AppError{http.StatusUnprocessableEntity, "SWEEP_REQUIRED", "Account has a balance; choose an account or bank to sweep it to"}
+	ErrAccountCloseBlocked      = &AppError{http.StatusConflict, "ACCOUNT_CLOSE_BLOCKED", "Account has active holds or payouts in flight"}
+	ErrDefaultAccountNotSet     = &AppError{http.StatusUnprocessableEntity, "DEFAULT_ACCOUNT_NOT_SET", "No source_currency given and no default account is set"}
+	ErrChainBreakAnnotated      = &AppError{http.StatusConflict, "CHAIN_BREAK_ANNOTATED", "This ledger chain break has already been annotated"}
+	ErrAPIKeyRevoked            = &AppError{http.StatusConflict, "API_KEY_REVOKED", "This API key has already been revoked"}
+	ErrAPIKeyLimitReached       = &AppError{http.StatusConflict, "API_KEY_LIMIT_REACHED", "You have reached the maximum number of active API keys"}
+	ErrResetTokenInvalid        = &AppError{http.StatusBadRequest, "RESET_TOKEN_INVALID", "The password reset link is invalid or has expired"}
+	ErrTOTPRequired             = &AppError{http.StatusUnauthorized, "TOTP_REQUIRED", "A code from your authenticator app is required"}
+	ErrInvalidTOTPCode          = &AppError{http.StatusUnauthorized, "INVALID_TOTP_CODE", "The authenticator code is invalid or has already been used"}
+	ErrTOTPNotEnabled           = &AppError{http.StatusForbidden, "TOTP_NOT_ENABLED", "Two-factor authentication must be enabled for this operation"}
+	ErrTOTPAlreadyEnabled       = &AppError{http.StatusConflict, "TOTP_ALREADY_ENABLED", "Two-factor authentication is already enabled"}
+	ErrBeneficiaryExists        = &AppError{http.StatusConflict, "BENEFICIARY_EXISTS", "A beneficiary with this IBAN already exists"}
+	ErrBeneficiaryDeleted       = &AppError{http.StatusUnprocessableEntity, "BENEFICIARY_DELETED", "Beneficiary has been deleted; restore it to pay out to it"}
+	ErrBeneficiaryInUse         = &AppError{http.StatusConflict, "BENEFICIARY_IN_USE", "Beneficiary is used by payouts that haven't finished"}
+	ErrAccountNotFrozen         = &AppError{http.StatusConflict, "ACCOUNT_NOT_FROZEN", "Account is not frozen"}
+	ErrResidencyRestricted      = &AppError{http.StatusForbidden, "RESIDENCY_RESTRICTED", "This payment is not available to residents of your country"}
+	ErrResidencyRuleExists      = &AppError{http.StatusConflict, "RESIDENCY_RULE_EXISTS", "A residency rule with this country, currencies and destination already exists"}
+	ErrWebhookEventNotRetriable = &AppError{http.StatusConflict, "WEBHOOK_EVENT_NOT_RETRIABLE", "Only failed webhook events can be retried"}
+
+	ErrLoginChallengeRequired    = &AppError{http.StatusForbidden, "LOGIN_CHALLENGE_REQUIRED", "Solve the challenge from GET /api/v1/auth/login-challenge and send it with the login"}
+	ErrLoginChallengeFailed      = &AppError{http.StatusForbidden, "LOGIN_CHALLENGE_FAILED", "The challenge solution is wrong, expired or already used; get a new challenge"}
+	ErrLoginChallengeUnavailable = &AppError{http.StatusUnprocessableEntity, "LOGIN_CHALLENGE_UNAVAILABLE", "This challenge mode is not configured on the server"}
 )
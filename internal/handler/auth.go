@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,15 +20,50 @@ type userReader interface {
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 }
 
+type loginGuard interface {
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	Failed(ctx context.Context, email, ip string) error
+	Succeeded(ctx context.Context, email string) error
+}
+
+type loginChallenge interface {
+	Issue(ctx context.Context, ip string) (*domain.LoginChallenge, error)
+	Verify(ctx context.Context, ip string, proof domain.LoginChallengeProof) error
+}
+
+// loginDevices tracks the devices users sign in from, to warn them of new
+// ones.
+type loginDevices interface {
+	SignedIn(ctx context.Context, userID uuid.UUID, ip, userAgent string) (bool, error)
+}
+
+type loginTOTP interface {
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+type auditRecorder interface {
+	Record(ctx context.Context, e domain.AuditEntry)
+}
+
 type AuthHandler struct {
 	users     userReader
+	guard     loginGuard
+	challenge loginChallenge
+	totp      loginTOTP
+	devices   loginDevices
+	audit     auditRecorder
 	jwtSecret string
 	jwtExpiry time.Duration
 }
 
-func NewAuthHandler(users userReader, jwtSecret string, jwtExpiry time.Duration) *AuthHandler {
+func NewAuthHandler(users userReader, guard loginGuard, challenge loginChallenge, totp loginTOTP, devices loginDevices, audit auditRecorder, jwtSecret string, jwtExpiry time.Duration) *AuthHandler {
 	return &AuthHandler{
 		users:     users,
+		guard:     guard,
+		challenge: challenge,
+		totp:      totp,
+		devices:   devices,
+		audit:     audit,
 		jwtSecret: jwtSecret,
 		jwtExpiry: jwtExpiry,
 	}
@@ -34,6 +72,12 @@ func NewAuthHandler(users userReader, jwtSecret string, jwtExpiry time.Duration)
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// TOTPCode is only needed by users with two-factor authentication on.
+	TOTPCode string `json:"totp_code"`
+	// Challenge and ChallengeSolution are only needed while login challenges
+	// are on. For a CAPTCHA the solution is the widget's response token.
+	Challenge         string `json:"challenge"`
+	ChallengeSolution string `json:"challenge_solution"`
 }
 
 func (r loginRequest) Validate() []FieldError {
@@ -53,10 +97,14 @@ type loginResponse struct {
 }
 
 type userDTO struct {
-	ID         uuid.UUID `json:"id"`
-	Email      string    `json:"email"`
-	Name       string    `json:"name"`
-	UniqueName *string   `json:"unique_name"`
+	ID               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	Name             string     `json:"name"`
+	UniqueName       *string    `json:"unique_name"`
+	KYCTier          string     `json:"kyc_tier"`
+	Tier             string     `json:"tier"`
+	DefaultAccountID *uuid.UUID `json:"default_account_id"`
+	Country          *string    `json:"country"`
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -71,9 +119,37 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.users.GetByEmail(r.Context(), req.Email)
+	ctx := r.Context()
+	ip := ClientIP(r)
+
+	// The challenge is checked first: it costs a hash, where the lockout
+	// check costs a query and the password a bcrypt comparison. If it can't
+	// be checked the login goes ahead unchallenged.
+	err := h.challenge.Verify(ctx, ip, domain.LoginChallengeProof{Challenge: req.Challenge, Solution: req.ChallengeSolution})
+	if errors.Is(err, domain.ErrLoginChallengeRequired) || errors.Is(err, domain.ErrLoginChallengeFailed) {
+		RespondDomainError(w, err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check login challenge", "error", err)
+	}
+
+	// A locked out email or IP is turned away before the password is
+	// checked, so guesses made during the lockout can't succeed.
+	wait, err := h.guard.Check(ctx, req.Email, ip)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check login lockout", "error", err)
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+		RespondAppError(w, ErrLoginLocked, nil)
+		return
+	}
+
+	user, err := h.users.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
+			h.loginFailed(ctx, req.Email, ip, nil, "unknown_email")
 			RespondAppError(w, ErrInvalidCredentials, nil)
 			return
 		}
@@ -82,23 +158,90 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		h.loginFailed(ctx, req.Email, ip, user, "wrong_password")
 		RespondAppError(w, ErrInvalidCredentials, nil)
 		return
 	}
 
-	token, err := auth.GenerateToken(user.ID, user.Email, h.jwtSecret, h.jwtExpiry)
+	// With two-factor authentication on, the password alone isn't enough. A
+	// missing code is a challenge rather than a failure; a wrong one counts
+	// towards the lockout like a wrong password.
+	if err := h.totp.Verify(ctx, user.ID, req.TOTPCode); err != nil && !errors.Is(err, domain.ErrTOTPNotEnabled) {
+		if errors.Is(err, domain.ErrInvalidTOTPCode) {
+			h.loginFailed(ctx, req.Email, ip, user, "wrong_totp_code")
+		}
+		RespondDomainError(w, err)
+		return
+	}
+
+	if err := h.guard.Succeeded(ctx, req.Email); err != nil {
+		logging.FromContext(ctx).Error("failed to reset login failures", "error", err)
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.SessionVersion, h.jwtSecret, h.jwtExpiry)
 	if err != nil {
 		RespondAppError(w, ErrInternalError, nil)
 		return
 	}
 
+	if _, err := h.devices.SignedIn(ctx, user.ID, ip, r.UserAgent()); err != nil {
+		logging.FromContext(ctx).Error("failed to record login device", "user_id", user.ID, "error", err)
+	}
+
+	h.audit.Record(ctx, domain.AuditEntry{
+		ActorID:      &user.ID,
+		Action:       domain.AuditActionLogin,
+		ResourceType: "user",
+		ResourceID:   user.ID.String(),
+	})
+
 	RespondSuccess(w, http.StatusOK, loginResponse{
 		Token: token,
-		User: userDTO{
-			ID:         user.ID,
-			Email:      user.Email,
-			Name:       user.Name,
-			UniqueName: user.UniqueName,
-		},
+		User:  toUserDTO(user),
+	})
+}
+
+type loginChallengeDTO struct {
+	Mode       domain.LoginChallengeMode `json:"mode"`
+	Challenge  string                    `json:"challenge,omitempty"`
+	Difficulty int                       `json:"difficulty,omitempty"`
+	SiteKey    string                    `json:"site_key,omitempty"`
+	ExpiresAt  *time.Time                `json:"expires_at,omitempty"`
+}
+
+// LoginChallenge hands the client a challenge to solve before logging in,
+// or mode off when none is needed.
+func (h *AuthHandler) LoginChallenge(w http.ResponseWriter, r *http.Request) {
+	c, err := h.challenge.Issue(r.Context(), ClientIP(r))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to issue login challenge", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, loginChallengeDTO{
+		Mode:       c.Mode,
+		Challenge:  c.Token,
+		Difficulty: c.Difficulty,
+		SiteKey:    c.SiteKey,
+		ExpiresAt:  c.ExpiresAt,
 	})
 }
+
+// loginFailed records a failed attempt against the lockout and in the audit
+// log. user is nil when no account has the email. The caller still answers
+// with invalid credentials if recording fails.
+func (h *AuthHandler) loginFailed(ctx context.Context, email, ip string, user *domain.User, reason string) {
+	if err := h.guard.Failed(ctx, email, ip); err != nil {
+		logging.FromContext(ctx).Error("failed to record login failure", "error", err)
+	}
+
+	entry := domain.AuditEntry{
+		Action:       domain.AuditActionLoginFailed,
+		ResourceType: "user",
+		After:        map[string]string{"email": email, "reason": reason},
+	}
+	if user != nil {
+		entry.ResourceID = user.ID.String()
+	}
+	h.audit.Record(ctx, entry)
+}
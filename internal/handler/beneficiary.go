@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	maxBeneficiaryNameLen = 100
+	maxIBANLen            = 34
+)
+
+type beneficiaryService interface {
+	Create(ctx context.Context, userID uuid.UUID, name, iban, bankName string) (*domain.Beneficiary, error)
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Beneficiary, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error)
+	Restore(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error)
+	Purge(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error)
+}
+
+type BeneficiaryHandler struct {
+	beneficiaries beneficiaryService
+}
+
+func NewBeneficiaryHandler(beneficiaries beneficiaryService) *BeneficiaryHandler {
+	return &BeneficiaryHandler{beneficiaries: beneficiaries}
+}
+
+type createBeneficiaryRequest struct {
+	Name     string `json:"name"`
+	IBAN     string `json:"iban"`
+	BankName string `json:"bank_name"`
+}
+
+func (r createBeneficiaryRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "is required"})
+	} else if len(r.Name) > maxBeneficiaryNameLen {
+		errs = append(errs, FieldError{Field: "name", Message: "must be at most 100 characters"})
+	}
+	if r.IBAN == "" {
+		errs = append(errs, FieldError{Field: "iban", Message: "is required"})
+	} else if len(r.IBAN) > maxIBANLen+8 {
+		// IBANs are printed in groups of four, so allow for the spaces.
+		errs = append(errs, FieldError{Field: "iban", Message: "must be at most 34 characters"})
+	}
+	if r.BankName == "" {
+		errs = append(errs, FieldError{Field: "bank_name", Message: "is required"})
+	}
+	return errs
+}
+
+type beneficiaryDTO struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	IBAN      string     `json:"iban"`
+	BankName  string     `json:"bank_name"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func toBeneficiaryDTO(b *domain.Beneficiary) beneficiaryDTO {
+	return beneficiaryDTO{
+		ID:        b.ID,
+		Name:      b.Name,
+		IBAN:      b.IBAN,
+		BankName:  b.BankName,
+		CreatedAt: b.CreatedAt,
+		DeletedAt: b.DeletedAt,
+	}
+}
+
+func (h *BeneficiaryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req createBeneficiaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	b, err := h.beneficiaries.Create(r.Context(), userID, req.Name, req.IBAN, req.BankName)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to create beneficiary", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toBeneficiaryDTO(b))
+}
+
+// List returns the user's beneficiaries. Deleted ones are left out.
+func (h *BeneficiaryHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	list, err := h.beneficiaries.List(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list beneficiaries", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]beneficiaryDTO, len(list))
+	for i := range list {
+		dtos[i] = toBeneficiaryDTO(&list[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+// Delete soft-deletes a beneficiary. With ?permanent=true it is removed for
+// good instead, which is refused while payouts to it haven't finished.
+func (h *BeneficiaryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, beneficiaryID, appErr := beneficiaryFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	remove := h.beneficiaries.Delete
+	if r.URL.Query().Get("permanent") == "true" {
+		remove = h.beneficiaries.Purge
+	}
+
+	b, err := remove(r.Context(), userID, beneficiaryID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to delete beneficiary", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toBeneficiaryDTO(b))
+}
+
+func (h *BeneficiaryHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, beneficiaryID, appErr := beneficiaryFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	b, err := h.beneficiaries.Restore(r.Context(), userID, beneficiaryID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to restore beneficiary", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toBeneficiaryDTO(b))
+}
+
+func beneficiaryFromPath(r *http.Request) (uuid.UUID, uuid.UUID, *AppError) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		return uuid.Nil, uuid.Nil, appErr
+	}
+	beneficiaryID, err := uuid.Parse(r.PathValue("beneficiary_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrResourceNotFound
+	}
+	return userID, beneficiaryID, nil
+}
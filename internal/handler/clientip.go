@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+)
+
+// TrustedProxies are the networks in front of the API, such as a load
+// balancer, whose X-Forwarded-For is believed. With none, forwarding headers
+// are ignored and the client is the connection's remote address.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses CIDRs, or bare addresses meaning just that
+// address.
+func ParseTrustedProxies(list []string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP resolves the address r came from. When the connection is from a
+// trusted proxy, X-Forwarded-For is read from the right, since each proxy
+// appends the address it received from, and the first address that isn't a
+// trusted proxy is the client. Anything left of it was sent by the client
+// and could be forged. A malformed entry stops the walk at the proxy that
+// passed it on.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	addr, err := netip.ParseAddr(remote)
+	if err != nil || !p.trusts(addr) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = hop
+		if !p.trusts(hop) {
+			break
+		}
+	}
+	return client.Unmap().String()
+}
+
+// ClientIP is the address the request came from, as middleware.ClientIP
+// resolved it, or the connection's remote address outside that middleware.
+func ClientIP(r *http.Request) string {
+	if ip := auth.ClientIPFromContext(r.Context()); ip != "" {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
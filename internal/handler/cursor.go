@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+var errMalformedCursor = errors.New("malformed cursor")
+
+// encodeLedgerCursor makes an opaque page token out of c. Clients pass it
+// back unchanged; its contents aren't part of the API.
+func encodeLedgerCursor(c domain.LedgerCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeLedgerCursor(s string) (*domain.LedgerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errMalformedCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errMalformedCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, errMalformedCursor
+	}
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errMalformedCursor
+	}
+	return &domain.LedgerCursor{CreatedAt: createdAt, ID: entryID}, nil
+}
+
+// Audit log cursors use the same encoding as ledger cursors.
+func encodeAuditCursor(c domain.AuditCursor) string {
+	return encodeLedgerCursor(domain.LedgerCursor(c))
+}
+
+func decodeAuditCursor(s string) (*domain.AuditCursor, error) {
+	c, err := decodeLedgerCursor(s)
+	if err != nil {
+		return nil, err
+	}
+	ac := domain.AuditCursor(*c)
+	return &ac, nil
+}
+
+// Notification cursors use the same encoding as ledger cursors.
+func encodeNotificationCursor(c domain.NotificationCursor) string {
+	return encodeLedgerCursor(domain.LedgerCursor(c))
+}
+
+func decodeNotificationCursor(s string) (*domain.NotificationCursor, error) {
+	c, err := decodeLedgerCursor(s)
+	if err != nil {
+		return nil, err
+	}
+	nc := domain.NotificationCursor(*c)
+	return &nc, nil
+}
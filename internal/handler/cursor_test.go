@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestLedgerCursorRoundTrip(t *testing.T) {
+	c := domain.LedgerCursor{
+		CreatedAt: time.Date(2026, 10, 16, 9, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	got, err := decodeLedgerCursor(encodeLedgerCursor(c))
+	require.NoError(t, err)
+	assert.True(t, c.CreatedAt.Equal(got.CreatedAt), "microseconds survive")
+	assert.Equal(t, c.ID, got.ID)
+
+	for _, bad := range []string{"not base64!", "bm8tc2VwYXJhdG9y", encodeLedgerCursor(c)[:10]} {
+		_, err := decodeLedgerCursor(bad)
+		assert.ErrorIs(t, err, errMalformedCursor, bad)
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type digestService interface {
+	GetPreference(ctx context.Context, userID uuid.UUID) (*domain.DigestPreference, error)
+	UpdatePreference(ctx context.Context, userID uuid.UUID, weekly, monthly bool) (*domain.DigestPreference, error)
+	List(ctx context.Context, userID uuid.UUID, period domain.DigestPeriod, limit, offset int) ([]domain.Digest, error)
+	GetForUser(ctx context.Context, userID, digestID uuid.UUID) (*domain.Digest, error)
+}
+
+type DigestHandler struct {
+	digests digestService
+}
+
+func NewDigestHandler(digests digestService) *DigestHandler {
+	return &DigestHandler{digests: digests}
+}
+
+type updateDigestPreferenceRequest struct {
+	Weekly  *bool `json:"weekly"`
+	Monthly *bool `json:"monthly"`
+}
+
+func (r updateDigestPreferenceRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Weekly == nil {
+		errs = append(errs, FieldError{Field: "weekly", Message: "is required"})
+	}
+	if r.Monthly == nil {
+		errs = append(errs, FieldError{Field: "monthly", Message: "is required"})
+	}
+	return errs
+}
+
+type digestPreferenceDTO struct {
+	Weekly  bool `json:"weekly"`
+	Monthly bool `json:"monthly"`
+}
+
+type digestTotalsDTO struct {
+	Currency      string `json:"currency"`
+	SentCount     int64  `json:"sent_count"`
+	Sent          string `json:"sent"`
+	ReceivedCount int64  `json:"received_count"`
+	Received      string `json:"received"`
+	Fees          string `json:"fees"`
+	FXCount       int64  `json:"fx_count"`
+	FXSent        string `json:"fx_sent"`
+}
+
+type digestDTO struct {
+	ID          uuid.UUID         `json:"id"`
+	Period      string            `json:"period"`
+	PeriodStart time.Time         `json:"period_start"`
+	PeriodEnd   time.Time         `json:"period_end"`
+	Totals      []digestTotalsDTO `json:"totals"`
+	DeliveredAt *time.Time        `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+func toDigestDTO(d *domain.Digest) digestDTO {
+	totals := make([]digestTotalsDTO, len(d.Totals))
+	for i, t := range d.Totals {
+		totals[i] = digestTotalsDTO{
+			Currency:      string(t.Currency),
+			SentCount:     t.SentCount,
+			Sent:          formatAmount(t.Sent, t.Currency),
+			ReceivedCount: t.ReceivedCount,
+			Received:      formatAmount(t.Received, t.Currency),
+			Fees:          formatAmount(t.Fees, t.Currency),
+			FXCount:       t.FXCount,
+			FXSent:        formatAmount(t.FXSent, t.Currency),
+		}
+	}
+	return digestDTO{
+		ID:          d.ID,
+		Period:      string(d.Period),
+		PeriodStart: d.PeriodStart,
+		PeriodEnd:   d.PeriodEnd,
+		Totals:      totals,
+		DeliveredAt: d.DeliveredAt,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+func (h *DigestHandler) GetPreference(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	p, err := h.digests.GetPreference(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to get digest preference", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, digestPreferenceDTO{Weekly: p.Weekly, Monthly: p.Monthly})
+}
+
+func (h *DigestHandler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req updateDigestPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	p, err := h.digests.UpdatePreference(r.Context(), userID, *req.Weekly, *req.Monthly)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to update digest preference", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, digestPreferenceDTO{Weekly: p.Weekly, Monthly: p.Monthly})
+}
+
+func (h *DigestHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	q := r.URL.Query()
+	period := domain.DigestPeriod(q.Get("period"))
+	if period != "" && !period.IsValid() {
+		RespondValidationError(w, []FieldError{{Field: "period", Message: "must be weekly or monthly"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	digests, err := h.digests.List(r.Context(), userID, period, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list digests", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]digestDTO, len(digests))
+	for i := range digests {
+		dtos[i] = toDigestDTO(&digests[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *DigestHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	digestID, err := uuid.Parse(r.PathValue("digest_id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	d, err := h.digests.GetForUser(r.Context(), userID, digestID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to get digest", "digest_id", digestID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toDigestDTO(d))
+}
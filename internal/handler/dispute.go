@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type disputeService interface {
+	OpenDispute(ctx context.Context, req service.OpenDisputeRequest) (*domain.Dispute, error)
+	GetForUser(ctx context.Context, userID, disputeID uuid.UUID) (*domain.Dispute, error)
+	ListForUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.Dispute, error)
+	Get(ctx context.Context, disputeID uuid.UUID) (*domain.Dispute, error)
+	List(ctx context.Context, status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error)
+	Respond(ctx context.Context, disputeID, staffID uuid.UUID) (*domain.Dispute, error)
+	Resolve(ctx context.Context, disputeID, staffID uuid.UUID, outcome domain.DisputeStatus, note string) (*domain.Dispute, error)
+}
+
+type DisputeHandler struct {
+	disputes disputeService
+}
+
+func NewDisputeHandler(disputes disputeService) *DisputeHandler {
+	return &DisputeHandler{disputes: disputes}
+}
+
+type openDisputeRequest struct {
+	LedgerEntryID *string `json:"ledger_entry_id"`
+	Reason        string  `json:"reason"`
+	Description   string  `json:"description"`
+}
+
+func (r openDisputeRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.DisputeReason(r.Reason).IsValid() {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be one of unrecognized, incorrect_amount, duplicate, other"})
+	}
+	if r.LedgerEntryID != nil {
+		if _, err := uuid.Parse(*r.LedgerEntryID); err != nil {
+			errs = append(errs, FieldError{Field: "ledger_entry_id", Message: "must be a valid UUID"})
+		}
+	}
+	if len(r.Description) > 2000 {
+		errs = append(errs, FieldError{Field: "description", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+type resolveDisputeRequest struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+func (r resolveDisputeRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Outcome != string(domain.DisputeStatusResolved) && r.Outcome != string(domain.DisputeStatusRejected) {
+		errs = append(errs, FieldError{Field: "outcome", Message: "must be resolved or rejected"})
+	}
+	if r.Note == "" {
+		errs = append(errs, FieldError{Field: "note", Message: "is required"})
+	}
+	return errs
+}
+
+type disputeDTO struct {
+	ID                uuid.UUID  `json:"id"`
+	PaymentID         uuid.UUID  `json:"payment_id"`
+	LedgerEntryID     *uuid.UUID `json:"ledger_entry_id,omitempty"`
+	Reason            string     `json:"reason"`
+	Description       string     `json:"description"`
+	Status            string     `json:"status"`
+	RespondBy         time.Time  `json:"respond_by"`
+	ResolveBy         time.Time  `json:"resolve_by"`
+	ResponseOverdue   bool       `json:"response_overdue"`
+	ResolutionOverdue bool       `json:"resolution_overdue"`
+	FirstResponseAt   *time.Time `json:"first_response_at,omitempty"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`
+	ResolutionNote    *string    `json:"resolution_note,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type adminDisputeDTO struct {
+	disputeDTO
+	UserID      uuid.UUID  `json:"user_id"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+	ResolvedBy  *uuid.UUID `json:"resolved_by,omitempty"`
+}
+
+func toDisputeDTO(d *domain.Dispute) disputeDTO {
+	now := time.Now().UTC()
+	return disputeDTO{
+		ID:                d.ID,
+		PaymentID:         d.PaymentID,
+		LedgerEntryID:     d.LedgerEntryID,
+		Reason:            string(d.Reason),
+		Description:       d.Description,
+		Status:            string(d.Status),
+		RespondBy:         d.RespondBy,
+		ResolveBy:         d.ResolveBy,
+		ResponseOverdue:   d.ResponseOverdue(now),
+		ResolutionOverdue: d.ResolutionOverdue(now),
+		FirstResponseAt:   d.FirstResponseAt,
+		ResolvedAt:        d.ResolvedAt,
+		ResolutionNote:    d.ResolutionNote,
+		CreatedAt:         d.CreatedAt,
+	}
+}
+
+func toAdminDisputeDTO(d *domain.Dispute) adminDisputeDTO {
+	return adminDisputeDTO{
+		disputeDTO:  toDisputeDTO(d),
+		UserID:      d.UserID,
+		EscalatedAt: d.EscalatedAt,
+		ResolvedBy:  d.ResolvedBy,
+	}
+}
+
+func (h *DisputeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req openDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	var entryID *uuid.UUID
+	if req.LedgerEntryID != nil {
+		id, _ := uuid.Parse(*req.LedgerEntryID)
+		entryID = &id
+	}
+
+	dispute, err := h.disputes.OpenDispute(r.Context(), service.OpenDisputeRequest{
+		UserID:        userID,
+		PaymentID:     paymentID,
+		LedgerEntryID: entryID,
+		Reason:        domain.DisputeReason(req.Reason),
+		Description:   req.Description,
+	})
+	if err != nil {
+		log.Warn("failed to open dispute", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toDisputeDTO(dispute))
+}
+
+func (h *DisputeHandler) ListMine(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	limit, offset, fields := parsePage(r.URL.Query().Get("limit"), r.URL.Query().Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	disputes, err := h.disputes.ListForUser(r.Context(), userID, limit, offset)
+	if err != nil {
+		log.Error("failed to list disputes", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]disputeDTO, len(disputes))
+	for i := range disputes {
+		dtos[i] = toDisputeDTO(&disputes[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *DisputeHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	dispute, err := h.disputes.GetForUser(r.Context(), userID, disputeID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toDisputeDTO(dispute))
+}
+
+func (h *DisputeHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	q := r.URL.Query()
+
+	status := domain.DisputeStatus(q.Get("status"))
+	switch status {
+	case "", domain.DisputeStatusOpen, domain.DisputeStatusInReview, domain.DisputeStatusResolved, domain.DisputeStatusRejected:
+	default:
+		RespondValidationError(w, []FieldError{{Field: "status", Message: "must be one of open, in_review, resolved, rejected"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	disputes, err := h.disputes.List(r.Context(), status, limit, offset)
+	if err != nil {
+		log.Error("failed to list disputes", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]adminDisputeDTO, len(disputes))
+	for i := range disputes {
+		dtos[i] = toAdminDisputeDTO(&disputes[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *DisputeHandler) AdminGet(w http.ResponseWriter, r *http.Request) {
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	dispute, err := h.disputes.Get(r.Context(), disputeID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminDisputeDTO(dispute))
+}
+
+func (h *DisputeHandler) Respond(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	dispute, err := h.disputes.Respond(r.Context(), disputeID, staffID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to respond to dispute", "dispute_id", disputeID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminDisputeDTO(dispute))
+}
+
+func (h *DisputeHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req resolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	dispute, err := h.disputes.Resolve(r.Context(), disputeID, staffID, domain.DisputeStatus(req.Outcome), req.Note)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to resolve dispute", "dispute_id", disputeID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminDisputeDTO(dispute))
+}
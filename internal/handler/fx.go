@@ -3,23 +3,35 @@ package handler
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+const (
+	defaultFXHistoryLimit = 100
+	maxFXHistoryLimit     = 1000
 )
 
 type fxService interface {
 	GetRate(ctx context.Context, from, to domain.Currency) (*fx.Quote, error)
 }
 
+type fxHistoryService interface {
+	History(ctx context.Context, from, to domain.Currency, tier domain.UserTier, since, until time.Time, limit int) (*service.FXRateHistory, error)
+}
+
 type FXHandler struct {
-	fx fxService
+	fx      fxService
+	history fxHistoryService
 }
 
-func NewFXHandler(fxSvc fxService) *FXHandler {
-	return &FXHandler{fx: fxSvc}
+func NewFXHandler(fxSvc fxService, history fxHistoryService) *FXHandler {
+	return &FXHandler{fx: fxSvc, history: history}
 }
 
 type fxRateResponse struct {
@@ -28,6 +40,7 @@ type fxRateResponse struct {
 	MidMarketRate string `json:"mid_market_rate"`
 	EffectiveRate string `json:"effective_rate"`
 	SpreadPct     string `json:"spread_pct"`
+	Via           string `json:"via,omitempty"`
 	Timestamp     string `json:"timestamp"`
 }
 
@@ -53,23 +66,118 @@ func (h *FXHandler) GetRate(w http.ResponseWriter, r *http.Request) {
 		MidMarketRate: quote.MidMarketRate.String(),
 		EffectiveRate: quote.EffectiveRate.String(),
 		SpreadPct:     quote.SpreadPct.String(),
+		Via:           string(quote.Via),
 		Timestamp:     time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
+type fxRateRecordDTO struct {
+	MidMarketRate string    `json:"mid_market_rate"`
+	EffectiveRate string    `json:"effective_rate"`
+	SpreadPct     string    `json:"spread_pct"`
+	Via           string    `json:"via,omitempty"`
+	RecordedAt    time.Time `json:"recorded_at"`
+}
+
+type fxRateHistoryResponse struct {
+	FromCurrency string            `json:"from_currency"`
+	ToCurrency   string            `json:"to_currency"`
+	Tier         string            `json:"tier"`
+	Since        time.Time         `json:"since"`
+	Until        time.Time         `json:"until"`
+	Rates        []fxRateRecordDTO `json:"rates"`
+	HasMore      bool              `json:"has_more"`
+}
+
+// History lists the pair's recorded rates for ?tier= (standard by default),
+// oldest first, over the RFC 3339 range ?since= to ?until= (a week up to
+// now by default). The first is the rate in effect at since, so a payment
+// made at some instant can be checked against the rate recorded by then.
+func (h *FXHandler) History(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+
+	fields := validateFXRateParams(from, to)
+	tier := domain.UserTierStandard
+	if v := q.Get("tier"); v != "" {
+		tier = domain.UserTier(v)
+		if !tier.IsValid() {
+			fields = append(fields, FieldError{Field: "tier", Message: "must be standard, plus or business"})
+		}
+	}
+	var since, until time.Time
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: p.name, Message: "must be an RFC 3339 timestamp"})
+			continue
+		}
+		*p.dst = t
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		fields = append(fields, FieldError{Field: "since", Message: "must be before until"})
+	}
+	limit := defaultFXHistoryLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxFXHistoryLimit {
+			fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxFXHistoryLimit)})
+		}
+		limit = n
+	}
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	hist, err := h.history.History(r.Context(), domain.Currency(from), domain.Currency(to), tier, since, until, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("fx rate history lookup failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	resp := fxRateHistoryResponse{
+		FromCurrency: from,
+		ToCurrency:   to,
+		Tier:         string(tier),
+		Since:        hist.Since,
+		Until:        hist.Until,
+		Rates:        make([]fxRateRecordDTO, len(hist.Rates)),
+		HasMore:      hist.HasMore,
+	}
+	for i, rate := range hist.Rates {
+		resp.Rates[i] = fxRateRecordDTO{
+			MidMarketRate: rate.MidMarketRate.String(),
+			EffectiveRate: rate.EffectiveRate().String(),
+			SpreadPct:     rate.SpreadPct.String(),
+			Via:           string(rate.Via),
+			RecordedAt:    rate.RecordedAt,
+		}
+	}
+	RespondSuccess(w, http.StatusOK, resp)
+}
+
 func validateFXRateParams(from, to string) []FieldError {
 	var errs []FieldError
 
 	if from == "" {
 		errs = append(errs, FieldError{Field: "from", Message: "required"})
 	} else if !domain.Currency(from).IsValid() {
-		errs = append(errs, FieldError{Field: "from", Message: "must be USD, EUR, or GBP"})
+		errs = append(errs, FieldError{Field: "from", Message: "must be one of " + domain.CurrencyList()})
 	}
 
 	if to == "" {
 		errs = append(errs, FieldError{Field: "to", Message: "required"})
 	} else if !domain.Currency(to).IsValid() {
-		errs = append(errs, FieldError{Field: "to", Message: "must be USD, EUR, or GBP"})
+		errs = append(errs, FieldError{Field: "to", Message: "must be one of " + domain.CurrencyList()})
 	}
 
 	return errs
@@ -0,0 +1,604 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/josh-kwaku/grey-backend-assessment/docs"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	// maxGraphQLBody bounds a request; real queries are a few kilobytes.
+	maxGraphQLBody = 64 << 10
+	// maxGraphQLDepth allows me { accounts { transactions { entries {
+	// payment { events { ... } } } } } } with a level to spare.
+	maxGraphQLDepth = 8
+	// graphQLBatchWait is how long a loader collects keys before fetching.
+	// The executor resolves the fields of a level concurrently, so their
+	// loads land within it.
+	graphQLBatchWait = 2 * time.Millisecond
+)
+
+type graphQLAccounts interface {
+	GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]domain.AccountSummary, error)
+	ListTransactions(ctx context.Context, accountID, userID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error)
+}
+
+type graphQLPayments interface {
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Payment, error)
+}
+
+type graphQLLedger interface {
+	GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.LedgerEntry, error)
+}
+
+type graphQLEvents interface {
+	GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.PaymentEvent, error)
+}
+
+// GraphQLHandler serves the read side of the API as one graph, so a client
+// can fetch a payment with its events and ledger entries in one round trip.
+// Everything in it hangs off the caller: their profile, their accounts, and
+// payments that touch their accounts. Payments are shown as the REST API
+// shows them to that party, and a payment's ledger entries are limited to
+// the caller's own. The schema is docs/schema.graphql.
+type GraphQLHandler struct {
+	users    userGetter
+	accounts graphQLAccounts
+	payments graphQLPayments
+	ledger   graphQLLedger
+	events   graphQLEvents
+	schema   *graphql.Schema
+}
+
+func NewGraphQLHandler(users userGetter, accounts graphQLAccounts, payments graphQLPayments, ledger graphQLLedger, events graphQLEvents) *GraphQLHandler {
+	h := &GraphQLHandler{users: users, accounts: accounts, payments: payments, ledger: ledger, events: events}
+	// Parsing checks every field in the schema has a resolver.
+	h.schema = graphql.MustParseSchema(string(docs.GraphQLSchema), &graphQLQuery{h: h},
+		graphql.MaxDepth(maxGraphQLDepth),
+		graphql.UseStringDescriptions(),
+	)
+	return h
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type graphQLResponseDTO struct {
+	Data   json.RawMessage         `json:"data,omitempty"`
+	Errors []*gqlerrors.QueryError `json:"errors,omitempty"`
+}
+
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req graphQLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBody)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			RespondAppError(w, ErrPayloadTooLarge, nil)
+			return
+		}
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphQLSessionKey{}, h.newSession(userID))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	// A request that never ran is the client's mistake; one that ran
+	// answers 200 even if some fields failed, with the failures in errors.
+	status := http.StatusOK
+	if len(resp.Data) == 0 {
+		status = http.StatusBadRequest
+	}
+	RespondJSON(w, status, graphQLResponseDTO{Data: resp.Data, Errors: resp.Errors})
+}
+
+// graphQLError is an error as a GraphQL client sees it. The executor puts
+// Extensions under the error's extensions.
+type graphQLError struct {
+	message    string
+	extensions map[string]any
+}
+
+func (e *graphQLError) Error() string              { return e.message }
+func (e *graphQLError) Extensions() map[string]any { return e.extensions }
+
+// presentGraphQLError shows a resolver's error as the REST API would: its
+// message and code, and the same details, under extensions.
+func presentGraphQLError(ctx context.Context, err error) error {
+	appErr, details := DomainAppError(err)
+	if appErr == ErrInternalError {
+		logging.FromContext(ctx).Error("graphql field failed", "error", err)
+	}
+	ext := map[string]any{"code": appErr.Code}
+	if details != nil {
+		ext["details"] = details
+	}
+	return &graphQLError{message: appErr.Message, extensions: ext}
+}
+
+func graphQLValidationError(msg string) error {
+	return &graphQLError{message: msg, extensions: map[string]any{"code": ErrValidationFailed.Code}}
+}
+
+type graphQLSessionKey struct{}
+
+// graphQLSession is one request's view of the graph: who is asking, and
+// the loaders that batch and cache what the query touches. Each loader is
+// keyed by a UUID: a user for accounts, a payment for the rest.
+type graphQLSession struct {
+	h        *GraphQLHandler
+	userID   uuid.UUID
+	accounts *dataloader.Loader
+	payments *dataloader.Loader
+	ledger   *dataloader.Loader
+	events   *dataloader.Loader
+}
+
+func (h *GraphQLHandler) newSession(userID uuid.UUID) *graphQLSession {
+	return &graphQLSession{
+		h:      h,
+		userID: userID,
+		accounts: newGraphQLLoader(func(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]domain.AccountSummary, error) {
+			out := make(map[uuid.UUID][]domain.AccountSummary, len(userIDs))
+			for _, id := range userIDs {
+				accounts, err := h.accounts.GetUserAccounts(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				out[id] = accounts
+			}
+			return out, nil
+		}),
+		payments: newGraphQLLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]domain.Payment, error) {
+			payments, err := h.payments.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			out := make(map[uuid.UUID]domain.Payment, len(payments))
+			for _, p := range payments {
+				out[p.ID] = p
+			}
+			return out, nil
+		}),
+		ledger: newGraphQLLoader(func(ctx context.Context, paymentIDs []uuid.UUID) (map[uuid.UUID][]domain.LedgerEntry, error) {
+			entries, err := h.ledger.GetByPaymentIDs(ctx, paymentIDs)
+			if err != nil {
+				return nil, err
+			}
+			out := make(map[uuid.UUID][]domain.LedgerEntry)
+			for _, e := range entries {
+				out[e.PaymentID] = append(out[e.PaymentID], e)
+			}
+			return out, nil
+		}),
+		events: newGraphQLLoader(func(ctx context.Context, paymentIDs []uuid.UUID) (map[uuid.UUID][]domain.PaymentEvent, error) {
+			events, err := h.events.GetByPaymentIDs(ctx, paymentIDs)
+			if err != nil {
+				return nil, err
+			}
+			out := make(map[uuid.UUID][]domain.PaymentEvent)
+			for _, e := range events {
+				out[e.PaymentID] = append(out[e.PaymentID], e)
+			}
+			return out, nil
+		}),
+	}
+}
+
+// uuidKey is a UUID as a dataloader key.
+type uuidKey uuid.UUID
+
+func (k uuidKey) String() string { return uuid.UUID(k).String() }
+func (k uuidKey) Raw() any       { return uuid.UUID(k) }
+
+// newGraphQLLoader returns a loader that fetches the keys loaded within
+// graphQLBatchWait of each other with one call to fetch, and caches them
+// for the request. Keys fetch leaves out of its result load as nil.
+func newGraphQLLoader[V any](fetch func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]V, error)) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		ids := make([]uuid.UUID, len(keys))
+		for i, k := range keys {
+			ids[i] = k.Raw().(uuid.UUID)
+		}
+		found, err := fetch(ctx, ids)
+		out := make([]*dataloader.Result, len(ids))
+		for i, id := range ids {
+			out[i] = &dataloader.Result{Error: err}
+			if v, ok := found[id]; ok && err == nil {
+				out[i].Data = v
+			}
+		}
+		return out
+	}, dataloader.WithWait(graphQLBatchWait))
+}
+
+// load returns id's value from l, and whether there was one.
+func load[V any](ctx context.Context, l *dataloader.Loader, id uuid.UUID) (V, bool, error) {
+	var zero V
+	v, err := l.Load(ctx, uuidKey(id))()
+	if err != nil || v == nil {
+		return zero, false, err
+	}
+	return v.(V), true, nil
+}
+
+func sessionFrom(ctx context.Context) *graphQLSession {
+	return ctx.Value(graphQLSessionKey{}).(*graphQLSession)
+}
+
+// ownAccounts returns the caller's accounts.
+func (s *graphQLSession) ownAccounts(ctx context.Context) ([]domain.AccountSummary, error) {
+	accounts, _, err := load[[]domain.AccountSummary](ctx, s.accounts, s.userID)
+	return accounts, err
+}
+
+// owns reports which of the caller's accounts exist, by ID.
+func (s *graphQLSession) owns(ctx context.Context) (map[uuid.UUID]bool, error) {
+	accounts, err := s.ownAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[uuid.UUID]bool, len(accounts))
+	for _, a := range accounts {
+		owned[a.ID] = true
+	}
+	return owned, nil
+}
+
+// loadPayment returns the payment with the given ID as the caller sees it.
+// A payment that doesn't touch the caller's accounts is not found, the
+// same as one that doesn't exist.
+func (s *graphQLSession) loadPayment(ctx context.Context, id uuid.UUID) (*graphQLPayment, error) {
+	owned, err := s.owns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, ok, err := load[domain.Payment](ctx, s.payments, id)
+	switch {
+	case err != nil:
+		return nil, err
+	case !ok:
+		return nil, fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	case owned[p.SourceAccountID]:
+		return &graphQLPayment{toUserPaymentDTO(&p, domain.PaymentDirectionSent)}, nil
+	case p.DestAccountID != nil && owned[*p.DestAccountID]:
+		return &graphQLPayment{toUserPaymentDTO(&p, domain.PaymentDirectionReceived)}, nil
+	default:
+		return nil, fmt.Errorf("payment %s: %w", id, domain.ErrNotFound)
+	}
+}
+
+// graphQLQuery resolves the Query type.
+type graphQLQuery struct {
+	h *GraphQLHandler
+}
+
+func (q *graphQLQuery) Me(ctx context.Context) (*graphQLUser, error) {
+	user, err := q.h.users.GetByID(ctx, sessionFrom(ctx).userID)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("me: %w", err))
+	}
+	return &graphQLUser{user}, nil
+}
+
+func (q *graphQLQuery) Account(ctx context.Context, args struct{ ID graphql.ID }) (*graphQLAccount, error) {
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("account: %w", domain.ErrNotFound))
+	}
+	accounts, err := sessionFrom(ctx).ownAccounts(ctx)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("account: %w", err))
+	}
+	for _, a := range accounts {
+		if a.ID == id {
+			return &graphQLAccount{a}, nil
+		}
+	}
+	return nil, presentGraphQLError(ctx, fmt.Errorf("account %s: %w", id, domain.ErrNotFound))
+}
+
+func (q *graphQLQuery) Payment(ctx context.Context, args struct{ ID graphql.ID }) (*graphQLPayment, error) {
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("payment: %w", domain.ErrNotFound))
+	}
+	p, err := sessionFrom(ctx).loadPayment(ctx, id)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, err)
+	}
+	return p, nil
+}
+
+type graphQLUser struct {
+	u *domain.User
+}
+
+func (u *graphQLUser) ID() graphql.ID                { return graphQLID(u.u.ID) }
+func (u *graphQLUser) Email() string                 { return u.u.Email }
+func (u *graphQLUser) Name() string                  { return u.u.Name }
+func (u *graphQLUser) UniqueName() *string           { return u.u.UniqueName }
+func (u *graphQLUser) KYCTier() string               { return string(u.u.KYCTier) }
+func (u *graphQLUser) Tier() string                  { return string(u.u.Tier) }
+func (u *graphQLUser) Country() *string              { return u.u.Country }
+func (u *graphQLUser) DefaultAccountID() *graphql.ID { return graphQLIDPtr(u.u.DefaultAccountID) }
+
+func (u *graphQLUser) Accounts(ctx context.Context) ([]*graphQLAccount, error) {
+	accounts, err := sessionFrom(ctx).ownAccounts(ctx)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("accounts: %w", err))
+	}
+	out := make([]*graphQLAccount, len(accounts))
+	for i, a := range accounts {
+		out[i] = &graphQLAccount{a}
+	}
+	return out, nil
+}
+
+type graphQLAccount struct {
+	a domain.AccountSummary
+}
+
+func (a *graphQLAccount) ID() graphql.ID   { return graphQLID(a.a.ID) }
+func (a *graphQLAccount) Currency() string { return string(a.a.Currency) }
+func (a *graphQLAccount) Balance() graphQLDecimal {
+	return graphQLDecimal(formatAmount(a.a.Balance, a.a.Currency))
+}
+func (a *graphQLAccount) AvailableBalance() graphQLDecimal {
+	return graphQLDecimal(formatAmount(a.a.Available, a.a.Currency))
+}
+func (a *graphQLAccount) AccountNumber() *string     { return a.a.AccountNumber }
+func (a *graphQLAccount) IBAN() *string              { return a.a.IBAN }
+func (a *graphQLAccount) Status() string             { return string(a.a.Status) }
+func (a *graphQLAccount) CreatedAt() graphQLDateTime { return graphQLDateTime{a.a.CreatedAt} }
+
+// Transactions pages the account's entries with a query of its own; a user
+// has an account per currency at most, so there are few.
+func (a *graphQLAccount) Transactions(ctx context.Context, args struct {
+	First graphql.NullInt
+	After *string
+}) (*graphQLTransactionPage, error) {
+	// An unset $first variable arrives as null rather than the default.
+	limit := defaultTransactionLimit
+	if args.First.Value != nil {
+		limit = int(*args.First.Value)
+	}
+	if limit < 1 || limit > maxTransactionLimit {
+		return nil, graphQLValidationError(fmt.Sprintf("first must be between 1 and %d", maxTransactionLimit))
+	}
+	var before *domain.LedgerCursor
+	if args.After != nil {
+		c, err := decodeLedgerCursor(*args.After)
+		if err != nil {
+			return nil, graphQLValidationError("after must be a nextCursor from a previous page")
+		}
+		before = c
+	}
+
+	session := sessionFrom(ctx)
+	entries, more, err := session.h.accounts.ListTransactions(ctx, a.a.ID, session.userID, before, limit)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("transactions: %w", err))
+	}
+	return &graphQLTransactionPage{entries: entries, more: more}, nil
+}
+
+// graphQLTransactionPage is an Account.transactions result.
+type graphQLTransactionPage struct {
+	entries []domain.LedgerEntry
+	more    bool
+}
+
+func (p *graphQLTransactionPage) Entries() []*graphQLLedgerEntry {
+	return graphQLLedgerEntries(p.entries)
+}
+func (p *graphQLTransactionPage) HasMore() bool { return p.more }
+
+func (p *graphQLTransactionPage) NextCursor() *string {
+	if !p.more || len(p.entries) == 0 {
+		return nil
+	}
+	c := encodeLedgerCursor(p.entries[len(p.entries)-1].Cursor())
+	return &c
+}
+
+type graphQLLedgerEntry struct {
+	e domain.LedgerEntry
+}
+
+func graphQLLedgerEntries(entries []domain.LedgerEntry) []*graphQLLedgerEntry {
+	out := make([]*graphQLLedgerEntry, len(entries))
+	for i, e := range entries {
+		out[i] = &graphQLLedgerEntry{e}
+	}
+	return out
+}
+
+func (e *graphQLLedgerEntry) ID() graphql.ID        { return graphQLID(e.e.ID) }
+func (e *graphQLLedgerEntry) AccountID() graphql.ID { return graphQLID(e.e.AccountID) }
+func (e *graphQLLedgerEntry) EntryType() string     { return string(e.e.EntryType) }
+func (e *graphQLLedgerEntry) Category() string      { return string(e.e.Category) }
+func (e *graphQLLedgerEntry) Amount() graphQLDecimal {
+	return graphQLDecimal(e.e.Amount.Decimal())
+}
+func (e *graphQLLedgerEntry) Currency() string { return string(e.e.Amount.Currency) }
+func (e *graphQLLedgerEntry) BalanceBefore() graphQLDecimal {
+	return graphQLDecimal(formatAmount(e.e.BalanceBefore, e.e.Amount.Currency))
+}
+func (e *graphQLLedgerEntry) BalanceAfter() graphQLDecimal {
+	return graphQLDecimal(formatAmount(e.e.BalanceAfter, e.e.Amount.Currency))
+}
+func (e *graphQLLedgerEntry) CreatedAt() graphQLDateTime { return graphQLDateTime{e.e.CreatedAt} }
+
+func (e *graphQLLedgerEntry) Payment(ctx context.Context) (*graphQLPayment, error) {
+	p, err := sessionFrom(ctx).loadPayment(ctx, e.e.PaymentID)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, err)
+	}
+	return p, nil
+}
+
+// graphQLPayment is a payment as the caller's side of it sees it.
+type graphQLPayment struct {
+	p paymentDTO
+}
+
+func (p *graphQLPayment) ID() graphql.ID                 { return graphQLID(p.p.ID) }
+func (p *graphQLPayment) Type() string                   { return p.p.Type }
+func (p *graphQLPayment) Status() string                 { return p.p.Status }
+func (p *graphQLPayment) Direction() string              { return p.p.Direction }
+func (p *graphQLPayment) SourceAccountID() graphql.ID    { return graphQLID(p.p.SourceAccountID) }
+func (p *graphQLPayment) DestAccountID() *graphql.ID     { return graphQLIDPtr(p.p.DestAccountID) }
+func (p *graphQLPayment) SourceAmount() graphQLDecimal   { return graphQLDecimal(p.p.SourceAmount) }
+func (p *graphQLPayment) SourceCurrency() string         { return p.p.SourceCurrency }
+func (p *graphQLPayment) DestAmount() graphQLDecimal     { return graphQLDecimal(p.p.DestAmount) }
+func (p *graphQLPayment) DestCurrency() string           { return p.p.DestCurrency }
+func (p *graphQLPayment) FeeAmount() graphQLDecimal      { return graphQLDecimal(p.p.FeeAmount) }
+func (p *graphQLPayment) FeeCurrency() *string           { return p.p.FeeCurrency }
+func (p *graphQLPayment) DestIBAN() *string              { return p.p.DestIBAN }
+func (p *graphQLPayment) DestBankName() *string          { return p.p.DestBankName }
+func (p *graphQLPayment) BeneficiaryID() *graphql.ID     { return graphQLIDPtr(p.p.BeneficiaryID) }
+func (p *graphQLPayment) FailureCode() *string           { return p.p.FailureCode }
+func (p *graphQLPayment) ReviewReason() *string          { return p.p.ReviewReason }
+func (p *graphQLPayment) RetryOf() *graphql.ID           { return graphQLIDPtr(p.p.RetryOf) }
+func (p *graphQLPayment) ReversalOf() *graphql.ID        { return graphQLIDPtr(p.p.ReversalOf) }
+func (p *graphQLPayment) RefundOf() *graphql.ID          { return graphQLIDPtr(p.p.RefundOf) }
+func (p *graphQLPayment) RefundedAmount() graphQLDecimal { return graphQLDecimal(p.p.RefundedAmount) }
+func (p *graphQLPayment) CreatedAt() graphQLDateTime     { return graphQLDateTime{p.p.CreatedAt} }
+
+func (p *graphQLPayment) ExchangeRate() *string {
+	if p.p.ExchangeRate == nil {
+		return nil
+	}
+	s := p.p.ExchangeRate.String()
+	return &s
+}
+
+func (p *graphQLPayment) CompletedAt() *graphQLDateTime {
+	if p.p.CompletedAt == nil {
+		return nil
+	}
+	return &graphQLDateTime{*p.p.CompletedAt}
+}
+
+// recipientHiddenEvents are the events a payment's recipient doesn't see,
+// as they don't see the review reason.
+var recipientHiddenEvents = map[domain.PaymentEventType]bool{
+	domain.PaymentEventTypeHeld:     true,
+	domain.PaymentEventTypeReleased: true,
+}
+
+func (p *graphQLPayment) Events(ctx context.Context) ([]*graphQLPaymentEvent, error) {
+	found, _, err := load[[]domain.PaymentEvent](ctx, sessionFrom(ctx).events, p.p.ID)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("events: %w", err))
+	}
+	events := []*graphQLPaymentEvent{}
+	for _, e := range found {
+		if p.p.Direction == string(domain.PaymentDirectionReceived) && recipientHiddenEvents[e.EventType] {
+			continue
+		}
+		events = append(events, &graphQLPaymentEvent{e})
+	}
+	return events, nil
+}
+
+func (p *graphQLPayment) LedgerEntries(ctx context.Context) ([]*graphQLLedgerEntry, error) {
+	session := sessionFrom(ctx)
+	owned, err := session.owns(ctx)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("ledgerEntries: %w", err))
+	}
+	found, _, err := load[[]domain.LedgerEntry](ctx, session.ledger, p.p.ID)
+	if err != nil {
+		return nil, presentGraphQLError(ctx, fmt.Errorf("ledgerEntries: %w", err))
+	}
+	entries := []domain.LedgerEntry{}
+	for _, e := range found {
+		if owned[e.AccountID] {
+			entries = append(entries, e)
+		}
+	}
+	return graphQLLedgerEntries(entries), nil
+}
+
+type graphQLPaymentEvent struct {
+	e domain.PaymentEvent
+}
+
+func (e *graphQLPaymentEvent) ID() graphql.ID             { return graphQLID(e.e.ID) }
+func (e *graphQLPaymentEvent) EventType() string          { return string(e.e.EventType) }
+func (e *graphQLPaymentEvent) CreatedAt() graphQLDateTime { return graphQLDateTime{e.e.CreatedAt} }
+
+func graphQLID(id uuid.UUID) graphql.ID {
+	return graphql.ID(id.String())
+}
+
+func graphQLIDPtr(id *uuid.UUID) *graphql.ID {
+	if id == nil {
+		return nil
+	}
+	v := graphQLID(*id)
+	return &v
+}
+
+// graphQLDecimal is the Decimal scalar: an amount in major units, as
+// formatAmount writes it.
+type graphQLDecimal string
+
+func (graphQLDecimal) ImplementsGraphQLType(name string) bool { return name == "Decimal" }
+
+func (d *graphQLDecimal) UnmarshalGraphQL(input any) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("Decimal cannot represent %v", input)
+	}
+	*d = graphQLDecimal(s)
+	return nil
+}
+
+// graphQLDateTime is the DateTime scalar, an RFC 3339 timestamp in UTC.
+type graphQLDateTime struct {
+	time.Time
+}
+
+func (graphQLDateTime) ImplementsGraphQLType(name string) bool { return name == "DateTime" }
+
+func (t *graphQLDateTime) UnmarshalGraphQL(input any) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("DateTime cannot represent %v", input)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("DateTime cannot represent %q", s)
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t graphQLDateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(time.RFC3339Nano))
+}
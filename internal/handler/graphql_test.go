@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// graphQLFixture is two users, Ada and Bo, with a USD account each, and a
+// payment from Ada to Bo that was held for review and released.
+type graphQLFixture struct {
+	ada, bo               domain.User
+	adaAccount, boAccount domain.AccountSummary
+	payment               domain.Payment
+	ledger                []domain.LedgerEntry
+	events                []domain.PaymentEvent
+
+	paymentFetches, ledgerFetches, eventFetches int
+}
+
+func newGraphQLFixture() *graphQLFixture {
+	f := &graphQLFixture{
+		ada: domain.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada", KYCTier: domain.KYCTierFull, Tier: domain.UserTierStandard},
+		bo:  domain.User{ID: uuid.New(), Email: "bo@example.com", Name: "Bo", KYCTier: domain.KYCTierBasic, Tier: domain.UserTierStandard},
+	}
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.adaAccount = domain.AccountSummary{Account: domain.Account{ID: uuid.New(), UserID: f.ada.ID, Currency: domain.CurrencyUSD, Balance: 9_000, Status: domain.AccountStatusActive, CreatedAt: created}, Available: 9_000}
+	f.boAccount = domain.AccountSummary{Account: domain.Account{ID: uuid.New(), UserID: f.bo.ID, Currency: domain.CurrencyUSD, Balance: 1_000, Status: domain.AccountStatusActive, CreatedAt: created}, Available: 1_000}
+
+	reason := domain.ReviewReasonAmountThreshold
+	f.payment = domain.Payment{
+		ID: uuid.New(), Type: domain.PaymentTypeInternalTransfer, Status: domain.PaymentStatusCompleted,
+		SourceAccountID: f.adaAccount.ID, DestAccountID: &f.boAccount.ID,
+		Source: domain.NewMoney(1_000, domain.CurrencyUSD), Dest: domain.NewMoney(1_000, domain.CurrencyUSD),
+		Fee: domain.NewMoney(25, domain.CurrencyUSD), ReviewReason: &reason, CreatedAt: created,
+	}
+	f.ledger = []domain.LedgerEntry{
+		{ID: uuid.New(), PaymentID: f.payment.ID, AccountID: f.adaAccount.ID, EntryType: domain.EntryTypeDebit, Amount: domain.NewMoney(1_025, domain.CurrencyUSD), BalanceBefore: 10_025, BalanceAfter: 9_000, CreatedAt: created},
+		{ID: uuid.New(), PaymentID: f.payment.ID, AccountID: f.boAccount.ID, EntryType: domain.EntryTypeCredit, Amount: domain.NewMoney(1_000, domain.CurrencyUSD), BalanceBefore: 0, BalanceAfter: 1_000, CreatedAt: created},
+	}
+	for _, et := range []domain.PaymentEventType{domain.PaymentEventTypeCreated, domain.PaymentEventTypeHeld, domain.PaymentEventTypeReleased, domain.PaymentEventTypeCompleted} {
+		f.events = append(f.events, domain.PaymentEvent{ID: uuid.New(), PaymentID: f.payment.ID, EventType: et, CreatedAt: created})
+	}
+	return f
+}
+
+func (f *graphQLFixture) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	for _, u := range []*domain.User{&f.ada, &f.bo} {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *graphQLFixture) GetUserAccounts(_ context.Context, userID uuid.UUID) ([]domain.AccountSummary, error) {
+	var out []domain.AccountSummary
+	for _, a := range []domain.AccountSummary{f.adaAccount, f.boAccount} {
+		if a.UserID == userID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *graphQLFixture) ListTransactions(_ context.Context, accountID, _ uuid.UUID, _ *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error) {
+	var out []domain.LedgerEntry
+	for _, e := range f.ledger {
+		if e.AccountID == accountID {
+			out = append(out, e)
+		}
+	}
+	if len(out) > limit {
+		return out[:limit], true, nil
+	}
+	return out, false, nil
+}
+
+func (f *graphQLFixture) GetByIDs(_ context.Context, ids []uuid.UUID) ([]domain.Payment, error) {
+	f.paymentFetches++
+	if slices.Contains(ids, f.payment.ID) {
+		return []domain.Payment{f.payment}, nil
+	}
+	return nil, nil
+}
+
+type graphQLLedgerStub struct{ *graphQLFixture }
+
+func (s graphQLLedgerStub) GetByPaymentIDs(_ context.Context, ids []uuid.UUID) ([]domain.LedgerEntry, error) {
+	s.ledgerFetches++
+	if slices.Contains(ids, s.payment.ID) {
+		return s.ledger, nil
+	}
+	return nil, nil
+}
+
+type graphQLEventsStub struct{ *graphQLFixture }
+
+func (s graphQLEventsStub) GetByPaymentIDs(_ context.Context, ids []uuid.UUID) ([]domain.PaymentEvent, error) {
+	s.eventFetches++
+	if slices.Contains(ids, s.payment.ID) {
+		return s.events, nil
+	}
+	return nil, nil
+}
+
+func (f *graphQLFixture) handler() *GraphQLHandler {
+	return NewGraphQLHandler(f, f, f, graphQLLedgerStub{f}, graphQLEventsStub{f})
+}
+
+type graphQLResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, h *GraphQLHandler, userID uuid.UUID, query string, variables map[string]any) (int, graphQLResult) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(string(body)))
+	req = req.WithContext(auth.ContextWithUserID(req.Context(), userID))
+	rec := httptest.NewRecorder()
+	h.Query(rec, req)
+
+	var res graphQLResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return rec.Code, res
+}
+
+const graphQLPaymentQuery = `query ($id: ID!) {
+	payment(id: $id) {
+		direction feeAmount reviewReason
+		events { eventType }
+		ledgerEntries { accountId amount }
+	}
+}`
+
+func TestGraphQL_PaymentIsScopedToCallersSide(t *testing.T) {
+	f := newGraphQLFixture()
+	h := f.handler()
+	vars := map[string]any{"id": f.payment.ID.String()}
+
+	code, sender := postGraphQL(t, h, f.ada.ID, graphQLPaymentQuery, vars)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, sender.Errors)
+	assert.Equal(t, map[string]any{
+		"direction":     "sent",
+		"feeAmount":     "0.25",
+		"reviewReason":  "amount_threshold",
+		"events":        []any{map[string]any{"eventType": "created"}, map[string]any{"eventType": "held"}, map[string]any{"eventType": "released"}, map[string]any{"eventType": "completed"}},
+		"ledgerEntries": []any{map[string]any{"accountId": f.adaAccount.ID.String(), "amount": "10.25"}},
+	}, sender.Data["payment"])
+
+	_, recipient := postGraphQL(t, h, f.bo.ID, graphQLPaymentQuery, vars)
+	require.Empty(t, recipient.Errors)
+	assert.Equal(t, map[string]any{
+		"direction":     "received",
+		"feeAmount":     "0.00",
+		"reviewReason":  nil,
+		"events":        []any{map[string]any{"eventType": "created"}, map[string]any{"eventType": "completed"}},
+		"ledgerEntries": []any{map[string]any{"accountId": f.boAccount.ID.String(), "amount": "10.00"}},
+	}, recipient.Data["payment"])
+}
+
+func TestGraphQL_OtherUsersPaymentIsNotFound(t *testing.T) {
+	f := newGraphQLFixture()
+	stranger := domain.User{ID: uuid.New(), Name: "Cy"}
+
+	code, res := postGraphQL(t, f.handler(), stranger.ID, graphQLPaymentQuery, map[string]any{"id": f.payment.ID.String()})
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, res.Data["payment"])
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, []any{"payment"}, res.Errors[0].Path)
+	assert.Equal(t, ErrResourceNotFound.Code, res.Errors[0].Extensions["code"])
+}
+
+func TestGraphQL_BatchesAcrossAccountsAndEntries(t *testing.T) {
+	f := newGraphQLFixture()
+	// Give Bo both accounts, so both sides' entries point at the payment
+	// and it's reached twice; it's still one fetch of each kind.
+	f.adaAccount.UserID = f.bo.ID
+
+	code, res := postGraphQL(t, f.handler(), f.bo.ID, `{
+		me {
+			name
+			accounts {
+				currency
+				transactions(first: 5) {
+					hasMore
+					entries { amount payment { id events { id } ledgerEntries { id } } }
+				}
+			}
+		}
+	}`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, res.Errors)
+
+	accounts := res.Data["me"].(map[string]any)["accounts"].([]any)
+	require.Len(t, accounts, 2)
+	entry := accounts[0].(map[string]any)["transactions"].(map[string]any)["entries"].([]any)[0].(map[string]any)
+	assert.Len(t, entry["payment"].(map[string]any)["ledgerEntries"], 2)
+	assert.Equal(t, 1, f.paymentFetches)
+	assert.Equal(t, 1, f.ledgerFetches)
+	assert.Equal(t, 1, f.eventFetches)
+}
+
+func TestGraphQL_TransactionPaging(t *testing.T) {
+	f := newGraphQLFixture()
+	h := f.handler()
+	query := `query ($id: ID!, $first: Int) { account(id: $id) { transactions(first: $first) { hasMore nextCursor } } }`
+
+	_, res := postGraphQL(t, h, f.ada.ID, query, map[string]any{"id": f.adaAccount.ID.String(), "first": 1})
+	require.Empty(t, res.Errors)
+	page := res.Data["account"].(map[string]any)["transactions"].(map[string]any)
+	assert.Equal(t, false, page["hasMore"])
+	assert.Nil(t, page["nextCursor"])
+
+	_, res = postGraphQL(t, h, f.ada.ID, query, map[string]any{"id": f.adaAccount.ID.String(), "first": 500})
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, ErrValidationFailed.Code, res.Errors[0].Extensions["code"])
+
+	_, res = postGraphQL(t, h, f.ada.ID, query, map[string]any{"id": f.boAccount.ID.String()})
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, ErrResourceNotFound.Code, res.Errors[0].Extensions["code"])
+}
+
+func TestGraphQL_RejectsBadRequests(t *testing.T) {
+	f := newGraphQLFixture()
+	h := f.handler()
+
+	code, res := postGraphQL(t, h, f.ada.ID, `mutation { me { id } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Nil(t, res.Data)
+	require.Len(t, res.Errors, 1)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(`{"query":`))
+	h.Query(rec, req.WithContext(auth.ContextWithUserID(req.Context(), f.ada.ID)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -1,17 +1,18 @@
 package handler
 
 import (
-	"database/sql"
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type HealthHandler struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewHealthHandler(db *sql.DB) *HealthHandler {
+func NewHealthHandler(db *pgxpool.Pool) *HealthHandler {
 	return &HealthHandler{db: db}
 }
 
@@ -27,7 +28,7 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 	dbStatus := "ok"
 	httpStatus := http.StatusOK
 
-	if err := h.db.PingContext(r.Context()); err != nil {
+	if err := h.db.Ping(r.Context()); err != nil {
 		slog.Warn("readiness check failed: database unreachable", "error", err)
 		dbStatus = "down"
 		httpStatus = http.StatusServiceUnavailable
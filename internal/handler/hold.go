@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type holdService interface {
+	AccountCurrency(ctx context.Context, accountID, userID uuid.UUID) (domain.Currency, error)
+	PlaceHold(ctx context.Context, req payment.PlaceHoldRequest) (*domain.Hold, error)
+	ReleaseHold(ctx context.Context, holdID, userID uuid.UUID) (*domain.Hold, error)
+	ListHolds(ctx context.Context, accountID, userID uuid.UUID, status domain.HoldStatus, limit, offset int) ([]domain.Hold, error)
+}
+
+type HoldHandler struct {
+	holds holdService
+}
+
+func NewHoldHandler(holds holdService) *HoldHandler {
+	return &HoldHandler{holds: holds}
+}
+
+type placeHoldRequest struct {
+	Amount     string `json:"amount"`
+	Reference  string `json:"reference"`
+	TTLSeconds int64  `json:"ttl_seconds"`
+}
+
+func (r placeHoldRequest) Validate() []FieldError {
+	var errs []FieldError
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
+	}
+	if len(r.Reference) > 200 {
+		errs = append(errs, FieldError{Field: "reference", Message: "must be at most 200 characters"})
+	}
+	if r.TTLSeconds < 0 {
+		errs = append(errs, FieldError{Field: "ttl_seconds", Message: "must not be negative"})
+	}
+	return errs
+}
+
+type holdDTO struct {
+	ID             uuid.UUID  `json:"id"`
+	AccountID      uuid.UUID  `json:"account_id"`
+	Amount         string     `json:"amount"`
+	Currency       string     `json:"currency"`
+	Status         string     `json:"status"`
+	Reference      *string    `json:"reference,omitempty"`
+	PaymentID      *uuid.UUID `json:"payment_id,omitempty"`
+	CapturedAmount *string    `json:"captured_amount,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	Expired        bool       `json:"expired"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func toHoldDTO(h *domain.Hold) holdDTO {
+	return holdDTO{
+		ID:             h.ID,
+		AccountID:      h.AccountID,
+		Amount:         formatAmount(h.Amount, h.Currency),
+		Currency:       string(h.Currency),
+		Status:         string(h.Status),
+		Reference:      h.Reference,
+		PaymentID:      h.PaymentID,
+		CapturedAmount: formatAmountPtr(h.CapturedAmount, h.Currency),
+		ExpiresAt:      h.ExpiresAt,
+		Expired:        h.Status == domain.HoldStatusActive && !h.IsLive(time.Now().UTC()),
+		ResolvedAt:     h.ResolvedAt,
+		CreatedAt:      h.CreatedAt,
+	}
+}
+
+func (h *HoldHandler) Place(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req placeHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	currency, err := h.holds.AccountCurrency(r.Context(), accountID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to look up hold account", "account_id", accountID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	amount, fields := parseAmount("amount", req.Amount, currency)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	hold, err := h.holds.PlaceHold(r.Context(), payment.PlaceHoldRequest{
+		UserID:    userID,
+		AccountID: accountID,
+		Amount:    amount,
+		Reference: req.Reference,
+		TTL:       time.Duration(req.TTLSeconds) * time.Second,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to place hold", "account_id", accountID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toHoldDTO(hold))
+}
+
+func (h *HoldHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	status := domain.HoldStatus(q.Get("status"))
+	switch status {
+	case "", domain.HoldStatusActive, domain.HoldStatusCaptured, domain.HoldStatusReleased:
+	default:
+		fields = append(fields, FieldError{Field: "status", Message: "must be active, captured, or released"})
+	}
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	holds, err := h.holds.ListHolds(r.Context(), accountID, userID, status, limit, offset)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]holdDTO, len(holds))
+	for i := range holds {
+		dtos[i] = toHoldDTO(&holds[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *HoldHandler) Release(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	holdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	hold, err := h.holds.ReleaseHold(r.Context(), holdID, userID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toHoldDTO(hold))
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type identityService interface {
+	ChangeUniqueName(ctx context.Context, userID uuid.UUID, uniqueName string) (*domain.User, error)
+	ChangeEmail(ctx context.Context, userID uuid.UUID, email string) (*domain.User, error)
+	VerifyRecipient(ctx context.Context, uniqueName string) (*domain.RecipientCheck, error)
+	History(ctx context.Context, userID uuid.UUID) ([]domain.IdentifierChange, error)
+}
+
+type IdentityHandler struct {
+	identity identityService
+}
+
+func NewIdentityHandler(identity identityService) *IdentityHandler {
+	return &IdentityHandler{identity: identity}
+}
+
+type changeUniqueNameRequest struct {
+	UniqueName string `json:"unique_name"`
+}
+
+func (r changeUniqueNameRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.UniqueName == "" {
+		errs = append(errs, FieldError{Field: "unique_name", Message: "is required"})
+	} else if len(r.UniqueName) < 3 || len(r.UniqueName) > 20 {
+		errs = append(errs, FieldError{Field: "unique_name", Message: "must be 3-20 characters"})
+	}
+	return errs
+}
+
+type changeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+func (r changeEmailRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Email == "" {
+		errs = append(errs, FieldError{Field: "email", Message: "is required"})
+	} else if len(r.Email) > 254 {
+		errs = append(errs, FieldError{Field: "email", Message: "must be at most 254 characters"})
+	}
+	return errs
+}
+
+type identifierChangeDTO struct {
+	Kind      string    `json:"kind"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type recipientWarningDTO struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type recipientDTO struct {
+	UniqueName string               `json:"unique_name"`
+	Name       string               `json:"name"`
+	Warning    *recipientWarningDTO `json:"warning,omitempty"`
+}
+
+func toIdentifierChangeDTO(c *domain.IdentifierChange) identifierChangeDTO {
+	return identifierChangeDTO{
+		Kind:      string(c.Kind),
+		OldValue:  c.OldValue,
+		NewValue:  c.NewValue,
+		ChangedAt: c.ChangedAt,
+	}
+}
+
+func toUserDTO(u *domain.User) userDTO {
+	return userDTO{
+		ID:               u.ID,
+		Email:            u.Email,
+		Name:             u.Name,
+		UniqueName:       u.UniqueName,
+		KYCTier:          string(u.KYCTier),
+		Tier:             string(u.Tier),
+		DefaultAccountID: u.DefaultAccountID,
+		Country:          u.Country,
+	}
+}
+
+func (h *IdentityHandler) ChangeUniqueName(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req changeUniqueNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	user, err := h.identity.ChangeUniqueName(r.Context(), userID, req.UniqueName)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to change grey tag", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
+}
+
+func (h *IdentityHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req changeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	user, err := h.identity.ChangeEmail(r.Context(), userID, req.Email)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to change email", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
+}
+
+func (h *IdentityHandler) History(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+	h.respondHistory(w, r, userID)
+}
+
+func (h *IdentityHandler) AdminHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+	h.respondHistory(w, r, userID)
+}
+
+func (h *IdentityHandler) respondHistory(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	changes, err := h.identity.History(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list identifier history", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]identifierChangeDTO, len(changes))
+	for i := range changes {
+		dtos[i] = toIdentifierChangeDTO(&changes[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *IdentityHandler) VerifyRecipient(w http.ResponseWriter, r *http.Request) {
+	check, err := h.identity.VerifyRecipient(r.Context(), r.PathValue("unique_name"))
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to verify recipient", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dto := recipientDTO{UniqueName: check.UniqueName, Name: check.Name}
+	if check.ReassignedAt != nil {
+		dto.Warning = &recipientWarningDTO{
+			Code:    "HANDLE_RECENTLY_REASSIGNED",
+			Message: "This grey tag belonged to a different user until " + check.ReassignedAt.Format(time.DateOnly) + ". Confirm the recipient's name before sending.",
+		}
+	}
+	RespondSuccess(w, http.StatusOK, dto)
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type kycService interface {
+	Submit(ctx context.Context, req service.SubmitKYCRequest) (*domain.KYCSubmission, error)
+	GetStatus(ctx context.Context, userID uuid.UUID) (*service.KYCStatus, error)
+	ListSubmissions(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, error)
+	Review(ctx context.Context, submissionID, staffID uuid.UUID, outcome domain.KYCStatus, note string) (*domain.KYCSubmission, error)
+}
+
+type KYCHandler struct {
+	kyc kycService
+}
+
+func NewKYCHandler(kyc kycService) *KYCHandler {
+	return &KYCHandler{kyc: kyc}
+}
+
+type submitKYCRequest struct {
+	RequestedTier string `json:"requested_tier"`
+	DocumentType  string `json:"document_type"`
+	DocumentRef   string `json:"document_ref"`
+}
+
+func (r submitKYCRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.RequestedTier != string(domain.KYCTierBasic) && r.RequestedTier != string(domain.KYCTierFull) {
+		errs = append(errs, FieldError{Field: "requested_tier", Message: "must be basic or full"})
+	}
+	if !domain.KYCDocumentType(r.DocumentType).IsValid() {
+		errs = append(errs, FieldError{Field: "document_type", Message: "must be one of passport, national_id, drivers_license"})
+	}
+	if r.DocumentRef == "" {
+		errs = append(errs, FieldError{Field: "document_ref", Message: "is required"})
+	} else if len(r.DocumentRef) > 200 {
+		errs = append(errs, FieldError{Field: "document_ref", Message: "must be at most 200 characters"})
+	}
+	return errs
+}
+
+type reviewKYCRequest struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+func (r reviewKYCRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Outcome != string(domain.KYCStatusApproved) && r.Outcome != string(domain.KYCStatusRejected) {
+		errs = append(errs, FieldError{Field: "outcome", Message: "must be approved or rejected"})
+	}
+	if r.Outcome == string(domain.KYCStatusRejected) && r.Note == "" {
+		errs = append(errs, FieldError{Field: "note", Message: "is required when rejecting"})
+	}
+	if len(r.Note) > 2000 {
+		errs = append(errs, FieldError{Field: "note", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+type kycSubmissionDTO struct {
+	ID            uuid.UUID  `json:"id"`
+	RequestedTier string     `json:"requested_tier"`
+	DocumentType  string     `json:"document_type"`
+	Status        string     `json:"status"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    *string    `json:"review_note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type adminKYCSubmissionDTO struct {
+	kycSubmissionDTO
+	UserID      uuid.UUID  `json:"user_id"`
+	DocumentRef string     `json:"document_ref"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by,omitempty"`
+}
+
+type kycStatusDTO struct {
+	Tier             string            `json:"tier"`
+	LatestSubmission *kycSubmissionDTO `json:"latest_submission,omitempty"`
+}
+
+func toKYCSubmissionDTO(s *domain.KYCSubmission) kycSubmissionDTO {
+	return kycSubmissionDTO{
+		ID:            s.ID,
+		RequestedTier: string(s.RequestedTier),
+		DocumentType:  string(s.DocumentType),
+		Status:        string(s.Status),
+		ReviewedAt:    s.ReviewedAt,
+		ReviewNote:    s.ReviewNote,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+func toAdminKYCSubmissionDTO(s *domain.KYCSubmission) adminKYCSubmissionDTO {
+	return adminKYCSubmissionDTO{
+		kycSubmissionDTO: toKYCSubmissionDTO(s),
+		UserID:           s.UserID,
+		DocumentRef:      s.DocumentRef,
+		ReviewedBy:       s.ReviewedBy,
+	}
+}
+
+func (h *KYCHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req submitKYCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	sub, err := h.kyc.Submit(r.Context(), service.SubmitKYCRequest{
+		UserID:        userID,
+		RequestedTier: domain.KYCTier(req.RequestedTier),
+		DocumentType:  domain.KYCDocumentType(req.DocumentType),
+		DocumentRef:   req.DocumentRef,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to submit kyc", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toKYCSubmissionDTO(sub))
+}
+
+func (h *KYCHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	status, err := h.kyc.GetStatus(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to get kyc status", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dto := kycStatusDTO{Tier: string(status.Tier)}
+	if status.Latest != nil {
+		latest := toKYCSubmissionDTO(status.Latest)
+		dto.LatestSubmission = &latest
+	}
+	RespondSuccess(w, http.StatusOK, dto)
+}
+
+func (h *KYCHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	q := r.URL.Query()
+
+	status := domain.KYCStatus(q.Get("status"))
+	if status != "" && !status.IsValid() {
+		RespondValidationError(w, []FieldError{{Field: "status", Message: "must be one of pending, approved, rejected"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	subs, err := h.kyc.ListSubmissions(r.Context(), status, limit, offset)
+	if err != nil {
+		log.Error("failed to list kyc submissions", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]adminKYCSubmissionDTO, len(subs))
+	for i := range subs {
+		dtos[i] = toAdminKYCSubmissionDTO(&subs[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *KYCHandler) Review(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	submissionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req reviewKYCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	sub, err := h.kyc.Review(r.Context(), submissionID, staffID, domain.KYCStatus(req.Outcome), req.Note)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to review kyc submission", "submission_id", submissionID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toAdminKYCSubmissionDTO(sub))
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+type notificationService interface {
+	List(ctx context.Context, userID uuid.UUID, unreadOnly bool, before *domain.NotificationCursor, limit int) (*service.NotificationFeed, error)
+	MarkRead(ctx context.Context, userID, id uuid.UUID) (*domain.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type NotificationHandler struct {
+	notifications notificationService
+}
+
+func NewNotificationHandler(notifications notificationService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+type notificationDTO struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	PaymentID *uuid.UUID `json:"payment_id,omitempty"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func toNotificationDTO(n *domain.Notification) notificationDTO {
+	return notificationDTO{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		PaymentID: n.PaymentID,
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+type notificationPageDTO struct {
+	Notifications []notificationDTO `json:"notifications"`
+	UnreadCount   int               `json:"unread_count"`
+	HasMore       bool              `json:"has_more"`
+	NextCursor    *string           `json:"next_cursor,omitempty"`
+}
+
+type markAllReadDTO struct {
+	Marked int64 `json:"marked"`
+}
+
+// List pages through the caller's notifications, newest first, with the
+// same opaque cursor as account transactions. ?unread=true leaves out the
+// ones already read.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	q := r.URL.Query()
+	var fields []FieldError
+	limit := defaultNotificationLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxNotificationLimit {
+			fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxNotificationLimit)})
+		}
+		limit = n
+	}
+	var unreadOnly bool
+	if v := q.Get("unread"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "unread", Message: "must be true or false"})
+		}
+		unreadOnly = b
+	}
+	var before *domain.NotificationCursor
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeNotificationCursor(v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "cursor", Message: "must be a next_cursor from a previous page"})
+		}
+		before = c
+	}
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	feed, err := h.notifications.List(r.Context(), userID, unreadOnly, before, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list notifications", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	resp := notificationPageDTO{
+		Notifications: make([]notificationDTO, len(feed.Notifications)),
+		UnreadCount:   feed.Unread,
+		HasMore:       feed.HasMore,
+	}
+	for i := range feed.Notifications {
+		resp.Notifications[i] = toNotificationDTO(&feed.Notifications[i])
+	}
+	if feed.HasMore {
+		c := encodeNotificationCursor(feed.Notifications[len(feed.Notifications)-1].Cursor())
+		resp.NextCursor = &c
+	}
+	RespondSuccess(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	n, err := h.notifications.MarkRead(r.Context(), userID, id)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to mark notification read", "notification_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toNotificationDTO(n))
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	n, err := h.notifications.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to mark notifications read", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, markAllReadDTO{Marked: n})
+}
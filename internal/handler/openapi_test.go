@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/docs"
+)
+
+// specSchemas maps the response schemas in docs/openapi.yaml to the DTOs
+// the handlers encode them from.
+var specSchemas = map[string]any{
+	"APIKey":                 apiKeyDTO{},
+	"Account":                accountDTO{},
+	"AccountBalance":         accountBalanceDTO{},
+	"AdminAccount":           adminAccountDTO{},
+	"AdminDispute":           adminDisputeDTO{},
+	"AdminKYCSubmission":     adminKYCSubmissionDTO{},
+	"AdminPaymentDetail":     adminPaymentDetailDTO{},
+	"AuditLog":               auditLogDTO{},
+	"Beneficiary":            beneficiaryDTO{},
+	"CorridorReport":         corridorReportDTO{},
+	"DenylistEntry":          denylistEntryDTO{},
+	"Digest":                 digestDTO{},
+	"DigestPreference":       digestPreferenceDTO{},
+	"Dispute":                disputeDTO{},
+	"FXPool":                 fxPoolDTO{},
+	"FXRateHistory":          fxRateHistoryResponse{},
+	"FXRateRecord":           fxRateRecordDTO{},
+	"FXRevenueReport":        fxRevenueReportDTO{},
+	"FeeRevenueReport":       feeRevenueReportDTO{},
+	"GraphQLResponse":        graphQLResponseDTO{},
+	"HistoricalBalance":      historicalBalanceDTO{},
+	"Hold":                   holdDTO{},
+	"IdentifierChange":       identifierChangeDTO{},
+	"IssuedAPIKey":           issuedAPIKeyDTO{},
+	"KYCStatus":              kycStatusDTO{},
+	"KYCSubmission":          kycSubmissionDTO{},
+	"LedgerChainBreak":       chainBreakDTO{},
+	"LoginChallenge":         loginChallengeDTO{},
+	"LoginChallengeSetting":  loginChallengeSettingDTO{},
+	"Notification":           notificationDTO{},
+	"NotificationPage":       notificationPageDTO{},
+	"Payment":                paymentDTO{},
+	"PaymentRequest":         paymentRequestDTO{},
+	"ProviderFailureReport":  providerFailureReportDTO{},
+	"ProviderSLAReport":      providerSLAReportDTO{},
+	"QASample":               qaSampleDTO{},
+	"Recipient":              recipientDTO{},
+	"ResidencyRule":          residencyRuleDTO{},
+	"SettlementBatch":        settlementBatchDTO{},
+	"SettlementReport":       settlementReportDTO{},
+	"SupportNote":            supportNoteDTO{},
+	"TOTPStatus":             totpStatusDTO{},
+	"TransferPreview":        transferPreviewDTO{},
+	"TrialBalance":           trialBalanceDTO{},
+	"TxLimit":                txLimitDTO{},
+	"User":                   userDTO{},
+	"WebhookDeliveryAttempt": webhookAttemptDTO{},
+	"WebhookEndpoint":        webhookEndpointDTO{},
+	"WebhookEvent":           webhookEventDTO{},
+	"WebhookStats":           webhookStatsDTO{},
+}
+
+type specSchema struct {
+	Ref        string                `json:"$ref"`
+	Properties map[string]specSchema `json:"properties"`
+	AllOf      []specSchema          `json:"allOf"`
+}
+
+// properties are the fields s documents, following allOf and $ref.
+func (s specSchema) properties(schemas map[string]specSchema) []string {
+	if s.Ref != "" {
+		return schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")].properties(schemas)
+	}
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	for _, sub := range s.AllOf {
+		names = append(names, sub.properties(schemas)...)
+	}
+	return names
+}
+
+// jsonFields are the names t encodes, with embedded structs flattened as
+// encoding/json does.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			names = append(names, jsonFields(ft)...)
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		names = append(names, tag)
+	}
+	return names
+}
+
+// The spec is maintained by hand; this fails when a DTO gains, loses or
+// renames a field without the schema following.
+func TestOpenAPI_SchemasMatchDTOs(t *testing.T) {
+	var spec struct {
+		Components struct {
+			Schemas map[string]specSchema `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(docs.OpenAPIJSON, &spec))
+	schemas := spec.Components.Schemas
+
+	for name, dto := range specSchemas {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := schemas[name]
+			require.True(t, ok, "schema %s is not in the spec", name)
+
+			documented := schema.properties(schemas)
+			encoded := jsonFields(reflect.TypeOf(dto))
+			sort.Strings(documented)
+			sort.Strings(encoded)
+			assert.Equal(t, encoded, documented, "%T fields vs schema %s properties", dto, name)
+		})
+	}
+}
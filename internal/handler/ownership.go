@@ -4,23 +4,16 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
-	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
 )
 
+// ownerFromPath returns the user a /users/{id} route acts on. Whether the
+// caller may act for them is checked before the handler runs, by
+// middleware.RequireOwner or RequireSelf, so it can be an admin's request
+// on another user's behalf.
 func ownerFromPath(r *http.Request) (uuid.UUID, *AppError) {
-	authUserID, ok := auth.UserIDFromContext(r.Context())
-	if !ok {
-		return uuid.Nil, ErrMissingToken
-	}
-
 	userID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		return uuid.Nil, ErrResourceNotFound
 	}
-
-	if userID != authUserID {
-		return uuid.Nil, ErrResourceNotFound
-	}
-
 	return userID, nil
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	minPasswordLen = 8
+	// bcrypt ignores anything past 72 bytes.
+	maxPasswordLen = 72
+)
+
+type passwordResetService interface {
+	Request(ctx context.Context, email, ip string)
+	Confirm(ctx context.Context, token, password string) error
+}
+
+type PasswordResetHandler struct {
+	resets passwordResetService
+}
+
+func NewPasswordResetHandler(resets passwordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{resets: resets}
+}
+
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+func (r passwordResetRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Email == "" {
+		errs = append(errs, FieldError{Field: "email", Message: "required"})
+	}
+	return errs
+}
+
+type passwordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r passwordResetConfirmRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Token == "" {
+		errs = append(errs, FieldError{Field: "token", Message: "required"})
+	}
+	switch {
+	case r.Password == "":
+		errs = append(errs, FieldError{Field: "password", Message: "required"})
+	case len(r.Password) < minPasswordLen:
+		errs = append(errs, FieldError{Field: "password", Message: "must be at least 8 characters"})
+	case len(r.Password) > maxPasswordLen:
+		errs = append(errs, FieldError{Field: "password", Message: "must be at most 72 bytes"})
+	}
+	return errs
+}
+
+// Request always answers 202 once the body is valid, whether or not the
+// email belongs to a user. The email is sent in the background, so a slow or
+// failing mail server doesn't show in the response either.
+func (h *PasswordResetHandler) Request(w http.ResponseWriter, r *http.Request) {
+	var req passwordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	h.resets.Request(r.Context(), req.Email, ClientIP(r))
+
+	RespondSuccess(w, http.StatusAccepted, map[string]string{"status": "requested"})
+}
+
+func (h *PasswordResetHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	var req passwordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	if err := h.resets.Confirm(r.Context(), req.Token, req.Password); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to confirm password reset", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, map[string]string{"status": "password_reset"})
+}
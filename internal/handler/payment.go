@@ -18,8 +18,12 @@ import (
 
 type paymentService interface {
 	CreateInternalTransfer(ctx context.Context, req payment.InternalTransferRequest) (*domain.Payment, error)
+	PreviewInternalTransfer(ctx context.Context, req payment.InternalTransferRequest) (*payment.TransferPreview, error)
 	CreateExternalPayout(ctx context.Context, req payment.ExternalPayoutRequest) (*domain.Payment, error)
-	GetPaymentForUser(ctx context.Context, paymentID, userID uuid.UUID) (*domain.Payment, error)
+	GetPaymentForUser(ctx context.Context, paymentID, userID uuid.UUID) (*domain.Payment, domain.PaymentDirection, error)
+	GetPaymentByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.Payment, error)
+	RetryExternalPayout(ctx context.Context, req payment.RetryPayoutRequest) (*domain.Payment, error)
+	SourceCurrency(ctx context.Context, userID uuid.UUID, source domain.Currency) (domain.Currency, error)
 }
 
 type PaymentHandler struct {
@@ -31,10 +35,11 @@ func NewPaymentHandler(payments paymentService) *PaymentHandler {
 }
 
 type createPaymentRequest struct {
-	RecipientUniqueName string `json:"recipient_unique_name"`
-	SourceCurrency      string `json:"source_currency"`
-	DestCurrency        string `json:"dest_currency"`
-	Amount              int64  `json:"amount"`
+	RecipientUniqueName string     `json:"recipient_unique_name"`
+	SourceCurrency      string     `json:"source_currency"`
+	DestCurrency        string     `json:"dest_currency"`
+	Amount              string     `json:"amount"`
+	HoldID              *uuid.UUID `json:"hold_id,omitempty"`
 }
 
 func (r createPaymentRequest) Validate() []FieldError {
@@ -44,50 +49,84 @@ func (r createPaymentRequest) Validate() []FieldError {
 		errs = append(errs, FieldError{Field: "recipient_unique_name", Message: "required"})
 	}
 
-	if r.SourceCurrency == "" {
-		errs = append(errs, FieldError{Field: "source_currency", Message: "required"})
-	} else if !domain.Currency(r.SourceCurrency).IsValid() {
-		errs = append(errs, FieldError{Field: "source_currency", Message: "must be USD, EUR, or GBP"})
+	// source_currency may be left out to send from the default account, and
+	// dest_currency to let the recipient's account be picked.
+	if r.SourceCurrency != "" && !domain.Currency(r.SourceCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "source_currency", Message: "must be one of " + domain.CurrencyList()})
 	}
 
-	if r.DestCurrency == "" {
-		errs = append(errs, FieldError{Field: "dest_currency", Message: "required"})
-	} else if !domain.Currency(r.DestCurrency).IsValid() {
-		errs = append(errs, FieldError{Field: "dest_currency", Message: "must be USD, EUR, or GBP"})
+	if r.DestCurrency != "" && !domain.Currency(r.DestCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "dest_currency", Message: "must be one of " + domain.CurrencyList()})
 	}
 
-	if r.Amount <= 0 {
-		errs = append(errs, FieldError{Field: "amount", Message: "must be greater than 0"})
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
 	}
 
 	return errs
 }
 
+// transferPreviewDTO leaves out the recipient's account ID; the path says
+// which of their accounts the money would land in.
+type transferPreviewDTO struct {
+	Path            string           `json:"path"`
+	SourceAccountID uuid.UUID        `json:"source_account_id"`
+	SourceAmount    string           `json:"source_amount"`
+	SourceCurrency  string           `json:"source_currency"`
+	DestAmount      string           `json:"dest_amount"`
+	DestCurrency    string           `json:"dest_currency"`
+	ExchangeRate    *decimal.Decimal `json:"exchange_rate"`
+	FeeAmount       string           `json:"fee_amount"`
+	FeeCurrency     string           `json:"fee_currency"`
+}
+
+func toTransferPreviewDTO(p *payment.TransferPreview) transferPreviewDTO {
+	return transferPreviewDTO{
+		Path:            string(p.Path),
+		SourceAccountID: p.SourceAccountID,
+		SourceAmount:    p.Source.Decimal(),
+		SourceCurrency:  string(p.Source.Currency),
+		DestAmount:      p.Dest.Decimal(),
+		DestCurrency:    string(p.Dest.Currency),
+		ExchangeRate:    p.ExchangeRate,
+		FeeAmount:       p.Fee.Decimal(),
+		FeeCurrency:     string(p.Fee.Currency),
+	}
+}
+
 type createExternalPayoutRequest struct {
-	SourceCurrency string `json:"source_currency"`
-	DestCurrency   string `json:"dest_currency"`
-	Amount         int64  `json:"amount"`
-	DestIBAN       string `json:"dest_iban"`
-	DestBankName   string `json:"dest_bank_name"`
+	SourceCurrency string     `json:"source_currency"`
+	DestCurrency   string     `json:"dest_currency"`
+	Amount         string     `json:"amount"`
+	DestIBAN       string     `json:"dest_iban"`
+	DestBankName   string     `json:"dest_bank_name"`
+	BeneficiaryID  *uuid.UUID `json:"beneficiary_id,omitempty"`
+	HoldID         *uuid.UUID `json:"hold_id,omitempty"`
 }
 
 func (r createExternalPayoutRequest) Validate() []FieldError {
 	var errs []FieldError
 
-	if r.SourceCurrency == "" {
-		errs = append(errs, FieldError{Field: "source_currency", Message: "required"})
-	} else if !domain.Currency(r.SourceCurrency).IsValid() {
-		errs = append(errs, FieldError{Field: "source_currency", Message: "must be USD, EUR, or GBP"})
+	// source_currency may be left out to send from the default account, and
+	// dest_currency to send without converting.
+	if r.SourceCurrency != "" && !domain.Currency(r.SourceCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "source_currency", Message: "must be one of " + domain.CurrencyList()})
 	}
 
-	if r.DestCurrency == "" {
-		errs = append(errs, FieldError{Field: "dest_currency", Message: "required"})
-	} else if !domain.Currency(r.DestCurrency).IsValid() {
-		errs = append(errs, FieldError{Field: "dest_currency", Message: "must be USD, EUR, or GBP"})
+	if r.DestCurrency != "" && !domain.Currency(r.DestCurrency).IsValid() {
+		errs = append(errs, FieldError{Field: "dest_currency", Message: "must be one of " + domain.CurrencyList()})
 	}
 
-	if r.Amount <= 0 {
-		errs = append(errs, FieldError{Field: "amount", Message: "must be greater than 0"})
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
+	}
+
+	// A saved beneficiary stands in for dest_iban and dest_bank_name.
+	if r.BeneficiaryID != nil {
+		if r.DestIBAN != "" || r.DestBankName != "" {
+			errs = append(errs, FieldError{Field: "beneficiary_id", Message: "cannot be combined with dest_iban or dest_bank_name"})
+		}
+		return errs
 	}
 
 	if r.DestIBAN == "" {
@@ -107,15 +146,24 @@ type paymentDTO struct {
 	Status          string           `json:"status"`
 	SourceAccountID uuid.UUID        `json:"source_account_id"`
 	DestAccountID   *uuid.UUID       `json:"dest_account_id"`
-	SourceAmount    int64            `json:"source_amount"`
+	SourceAmount    string           `json:"source_amount"`
 	SourceCurrency  string           `json:"source_currency"`
-	DestAmount      int64            `json:"dest_amount"`
+	DestAmount      string           `json:"dest_amount"`
 	DestCurrency    string           `json:"dest_currency"`
 	ExchangeRate    *decimal.Decimal `json:"exchange_rate"`
-	FeeAmount       int64            `json:"fee_amount"`
+	FeeAmount       string           `json:"fee_amount"`
 	FeeCurrency     *string          `json:"fee_currency,omitempty"`
 	DestIBAN        *string          `json:"dest_iban,omitempty"`
 	DestBankName    *string          `json:"dest_bank_name,omitempty"`
+	BeneficiaryID   *uuid.UUID       `json:"beneficiary_id,omitempty"`
+	FailureCode     *string          `json:"failure_code,omitempty"`
+	ReviewReason    *string          `json:"review_reason,omitempty"`
+	RetryOf         *uuid.UUID       `json:"retry_of,omitempty"`
+	ReversalOf      *uuid.UUID       `json:"reversal_of,omitempty"`
+	RefundOf        *uuid.UUID       `json:"refund_of,omitempty"`
+	RefundedAmount  string           `json:"refunded_amount"`
+	UserTier        *string          `json:"user_tier,omitempty"`
+	Direction       string           `json:"direction,omitempty"`
 	CreatedAt       time.Time        `json:"created_at"`
 	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
 }
@@ -127,24 +175,79 @@ func toPaymentDTO(p *domain.Payment) paymentDTO {
 		Status:          string(p.Status),
 		SourceAccountID: p.SourceAccountID,
 		DestAccountID:   p.DestAccountID,
-		SourceAmount:    p.SourceAmount,
-		SourceCurrency:  string(p.SourceCurrency),
-		DestAmount:      p.DestAmount,
-		DestCurrency:    string(p.DestCurrency),
+		SourceAmount:    p.Source.Decimal(),
+		SourceCurrency:  string(p.Source.Currency),
+		DestAmount:      p.Dest.Decimal(),
+		DestCurrency:    string(p.Dest.Currency),
 		ExchangeRate:    p.ExchangeRate,
-		FeeAmount:       p.FeeAmount,
+		FeeAmount:       p.Fee.Decimal(),
+		RefundedAmount:  p.Refunded.Decimal(),
 		CreatedAt:       p.CreatedAt,
 		CompletedAt:     p.CompletedAt,
 	}
-	if p.FeeCurrency != nil {
-		c := string(*p.FeeCurrency)
+	if !p.Fee.IsZero() {
+		c := string(p.Fee.Currency)
 		dto.FeeCurrency = &c
 	}
+	if p.FailureCode != nil {
+		c := string(*p.FailureCode)
+		dto.FailureCode = &c
+	}
+	if p.ReviewReason != nil {
+		r := string(*p.ReviewReason)
+		dto.ReviewReason = &r
+	}
+	if p.UserTier != nil {
+		t := string(*p.UserTier)
+		dto.UserTier = &t
+	}
 	dto.DestIBAN = p.DestIBAN
 	dto.DestBankName = p.DestBankName
+	dto.BeneficiaryID = p.BeneficiaryID
+	dto.RetryOf = p.RetryOfPaymentID
+	dto.ReversalOf = p.ReversalOfPaymentID
+	dto.RefundOf = p.RefundOfPaymentID
 	return dto
 }
 
+// toUserPaymentDTO renders a payment for one of its parties. The recipient
+// doesn't see the sender's side of it: where a payout went, why it failed or
+// was held for review, or what the sender paid in fees and at which tier.
+func toUserPaymentDTO(p *domain.Payment, dir domain.PaymentDirection) paymentDTO {
+	dto := toPaymentDTO(p)
+	dto.Direction = string(dir)
+	if dir == domain.PaymentDirectionReceived {
+		dto.DestIBAN = nil
+		dto.DestBankName = nil
+		dto.BeneficiaryID = nil
+		dto.FailureCode = nil
+		dto.ReviewReason = nil
+		dto.RetryOf = nil
+		dto.FeeAmount = formatAmount(0, p.Fee.Currency)
+		dto.FeeCurrency = nil
+		dto.UserTier = nil
+	}
+	return dto
+}
+
+// sourceAmount resolves the currency a payment is sent in, which is the
+// default account's when the body names none, and reads the amount in it.
+// It writes the error response itself when either fails.
+func (h *PaymentHandler) sourceAmount(w http.ResponseWriter, r *http.Request, userID uuid.UUID, currency, amount string) (domain.Currency, int64, bool) {
+	source, err := h.payments.SourceCurrency(r.Context(), userID, domain.Currency(currency))
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("resolving source currency failed", "error", err)
+		RespondDomainError(w, err)
+		return "", 0, false
+	}
+	minor, fields := parseAmount("amount", amount, source)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return "", 0, false
+	}
+	return source, minor, true
+}
+
 func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	log := logging.FromContext(r.Context())
 
@@ -167,13 +270,19 @@ func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	source, amount, ok := h.sourceAmount(w, r, userID, req.SourceCurrency, req.Amount)
+	if !ok {
+		return
+	}
+
 	p, err := h.payments.CreateInternalTransfer(r.Context(), payment.InternalTransferRequest{
 		SenderUserID:        userID,
 		RecipientUniqueName: req.RecipientUniqueName,
-		SourceCurrency:      domain.Currency(req.SourceCurrency),
+		SourceCurrency:      source,
 		DestCurrency:        domain.Currency(req.DestCurrency),
-		Amount:              req.Amount,
+		Amount:              amount,
 		IdempotencyKey:      idempotencyKey,
+		HoldID:              req.HoldID,
 	})
 	if err != nil {
 		log.Warn("payment creation failed", "error", err)
@@ -185,6 +294,47 @@ func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	RespondSuccess(w, http.StatusCreated, toPaymentDTO(p))
 }
 
+// Preview reports where a transfer would land and what the recipient would
+// get, without sending it. It takes the same body as Create.
+func (h *PaymentHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req createPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	source, amount, ok := h.sourceAmount(w, r, userID, req.SourceCurrency, req.Amount)
+	if !ok {
+		return
+	}
+
+	preview, err := h.payments.PreviewInternalTransfer(r.Context(), payment.InternalTransferRequest{
+		SenderUserID:        userID,
+		RecipientUniqueName: req.RecipientUniqueName,
+		SourceCurrency:      source,
+		DestCurrency:        domain.Currency(req.DestCurrency),
+		Amount:              amount,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("transfer preview failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toTransferPreviewDTO(preview))
+}
+
 func (h *PaymentHandler) CreateExternal(w http.ResponseWriter, r *http.Request) {
 	log := logging.FromContext(r.Context())
 
@@ -207,14 +357,22 @@ func (h *PaymentHandler) CreateExternal(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	source, amount, ok := h.sourceAmount(w, r, userID, req.SourceCurrency, req.Amount)
+	if !ok {
+		return
+	}
+
 	p, err := h.payments.CreateExternalPayout(r.Context(), payment.ExternalPayoutRequest{
 		SenderUserID:   userID,
-		SourceCurrency: domain.Currency(req.SourceCurrency),
+		SourceCurrency: source,
 		DestCurrency:   domain.Currency(req.DestCurrency),
-		Amount:         req.Amount,
+		Amount:         amount,
 		DestIBAN:       req.DestIBAN,
 		DestBankName:   req.DestBankName,
+		BeneficiaryID:  req.BeneficiaryID,
 		IdempotencyKey: idempotencyKey,
+		HoldID:         req.HoldID,
+		TOTPCode:       r.Header.Get(TOTPCodeHeader),
 	})
 	if err != nil {
 		log.Warn("external payout creation failed", "error", err)
@@ -239,12 +397,68 @@ func (h *PaymentHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.payments.GetPaymentForUser(r.Context(), paymentID, userID)
+	p, dir, err := h.payments.GetPaymentForUser(r.Context(), paymentID, userID)
 	if err != nil {
 		logging.FromContext(r.Context()).Warn("payment lookup failed", "error", err)
 		RespondDomainError(w, err)
 		return
 	}
 
-	RespondSuccess(w, http.StatusOK, toPaymentDTO(p))
+	RespondSuccess(w, http.StatusOK, toUserPaymentDTO(p, dir))
+}
+
+// Lookup finds a payment by the Idempotency-Key it was created with, for a
+// client that timed out before it got the payment's id.
+func (h *PaymentHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	key := r.URL.Query().Get("idempotency_key")
+	if key == "" {
+		RespondValidationError(w, []FieldError{{Field: "idempotency_key", Message: "required"}})
+		return
+	}
+
+	p, err := h.payments.GetPaymentByIdempotencyKey(r.Context(), userID, key)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("payment lookup by idempotency key failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toUserPaymentDTO(p, domain.PaymentDirectionSent))
+}
+
+func (h *PaymentHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	p, err := h.payments.RetryExternalPayout(r.Context(), payment.RetryPayoutRequest{
+		PaymentID:      paymentID,
+		UserID:         userID,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		TOTPCode:       r.Header.Get(TOTPCodeHeader),
+	})
+	if err != nil {
+		log.Warn("payout retry failed", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/payments/%s", p.ID))
+	RespondSuccess(w, http.StatusAccepted, toPaymentDTO(p))
 }
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type paymentRequestService interface {
+	Create(ctx context.Context, in service.CreatePaymentRequestInput) (*domain.PaymentRequest, error)
+	Get(ctx context.Context, id, userID uuid.UUID) (*domain.PaymentRequest, error)
+	List(ctx context.Context, userID uuid.UUID, incoming, outgoing bool, status domain.PaymentRequestStatus, limit, offset int) ([]domain.PaymentRequest, error)
+	Accept(ctx context.Context, id, payerID uuid.UUID) (*domain.PaymentRequest, *domain.Payment, error)
+	Decline(ctx context.Context, id, payerID uuid.UUID) (*domain.PaymentRequest, error)
+	Cancel(ctx context.Context, id, requesterID uuid.UUID) (*domain.PaymentRequest, error)
+}
+
+type PaymentRequestHandler struct {
+	requests paymentRequestService
+}
+
+func NewPaymentRequestHandler(requests paymentRequestService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{requests: requests}
+}
+
+type requestMoneyRequest struct {
+	PayerUniqueName string `json:"payer_unique_name"`
+	Currency        string `json:"currency"`
+	Amount          string `json:"amount"`
+	Note            string `json:"note"`
+}
+
+func (r requestMoneyRequest) Validate() []FieldError {
+	var errs []FieldError
+
+	if r.PayerUniqueName == "" {
+		errs = append(errs, FieldError{Field: "payer_unique_name", Message: "required"})
+	}
+
+	if r.Currency == "" {
+		errs = append(errs, FieldError{Field: "currency", Message: "required"})
+	} else if !domain.Currency(r.Currency).IsValid() {
+		errs = append(errs, FieldError{Field: "currency", Message: "must be one of " + domain.CurrencyList()})
+	}
+
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
+	}
+
+	if len(r.Note) > 500 {
+		errs = append(errs, FieldError{Field: "note", Message: "must be at most 500 characters"})
+	}
+
+	return errs
+}
+
+type paymentRequestDTO struct {
+	ID              uuid.UUID  `json:"id"`
+	RequesterUserID uuid.UUID  `json:"requester_user_id"`
+	PayerUserID     uuid.UUID  `json:"payer_user_id"`
+	Amount          string     `json:"amount"`
+	Currency        string     `json:"currency"`
+	Note            *string    `json:"note,omitempty"`
+	Status          string     `json:"status"`
+	PaymentID       *uuid.UUID `json:"payment_id,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	RespondedAt     *time.Time `json:"responded_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func toPaymentRequestDTO(pr *domain.PaymentRequest) paymentRequestDTO {
+	return paymentRequestDTO{
+		ID:              pr.ID,
+		RequesterUserID: pr.RequesterUserID,
+		PayerUserID:     pr.PayerUserID,
+		Amount:          formatAmount(pr.Amount, pr.Currency),
+		Currency:        string(pr.Currency),
+		Note:            pr.Note,
+		Status:          string(pr.Status),
+		PaymentID:       pr.PaymentID,
+		ExpiresAt:       pr.ExpiresAt,
+		RespondedAt:     pr.RespondedAt,
+		CreatedAt:       pr.CreatedAt,
+	}
+}
+
+type acceptPaymentRequestDTO struct {
+	Request paymentRequestDTO `json:"request"`
+	Payment paymentDTO        `json:"payment"`
+}
+
+func (h *PaymentRequestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	var req requestMoneyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	amount, fields := parseAmount("amount", req.Amount, domain.Currency(req.Currency))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	pr, err := h.requests.Create(r.Context(), service.CreatePaymentRequestInput{
+		RequesterUserID: userID,
+		PayerUniqueName: req.PayerUniqueName,
+		Amount:          amount,
+		Currency:        domain.Currency(req.Currency),
+		Note:            req.Note,
+	})
+	if err != nil {
+		log.Warn("payment request creation failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/payment-requests/%s", pr.ID))
+	RespondSuccess(w, http.StatusCreated, toPaymentRequestDTO(pr))
+}
+
+// List returns requests the caller sent (role=outgoing), received
+// (role=incoming), or both when role is omitted.
+func (h *PaymentRequestHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+
+	incoming, outgoing := true, true
+	switch q.Get("role") {
+	case "":
+	case "incoming":
+		outgoing = false
+	case "outgoing":
+		incoming = false
+	default:
+		fields = append(fields, FieldError{Field: "role", Message: "must be incoming or outgoing"})
+	}
+
+	status := domain.PaymentRequestStatus(q.Get("status"))
+	if status != "" && !status.IsValid() {
+		fields = append(fields, FieldError{Field: "status", Message: "must be pending, accepted, declined, cancelled, or expired"})
+	}
+
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	requests, err := h.requests.List(r.Context(), userID, incoming, outgoing, status, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list payment requests", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]paymentRequestDTO, len(requests))
+	for i := range requests {
+		dtos[i] = toPaymentRequestDTO(&requests[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *PaymentRequestHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := h.requests.Get(r.Context(), id, userID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toPaymentRequestDTO(pr))
+}
+
+func (h *PaymentRequestHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	pr, p, err := h.requests.Accept(r.Context(), id, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("payment request accept failed", "payment_request_id", id, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, acceptPaymentRequestDTO{
+		Request: toPaymentRequestDTO(pr),
+		Payment: toPaymentDTO(p),
+	})
+}
+
+func (h *PaymentRequestHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := h.requests.Decline(r.Context(), id, userID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toPaymentRequestDTO(pr))
+}
+
+func (h *PaymentRequestHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := h.requests.Cancel(r.Context(), id, userID)
+	if err != nil {
+		RespondDomainError(w, err)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, toPaymentRequestDTO(pr))
+}
+
+func (h *PaymentRequestHandler) parseTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, id, true
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type refundService interface {
+	RefundCurrency(ctx context.Context, paymentID, actorID uuid.UUID, isAdmin bool) (domain.Currency, error)
+	RefundPayment(ctx context.Context, req payment.RefundRequest) (*domain.Payment, error)
+	ListRefunds(ctx context.Context, paymentID, userID uuid.UUID, isStaff bool) ([]domain.Payment, error)
+}
+
+type RefundHandler struct {
+	refunds refundService
+}
+
+func NewRefundHandler(refunds refundService) *RefundHandler {
+	return &RefundHandler{refunds: refunds}
+}
+
+type createRefundRequest struct {
+	Amount string `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+func (r createRefundRequest) Validate() []FieldError {
+	var errs []FieldError
+	if fe := checkAmount("amount", r.Amount); fe != nil {
+		errs = append(errs, *fe)
+	}
+	if len(r.Reason) > 2000 {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be at most 2000 characters"})
+	}
+	return errs
+}
+
+func (h *RefundHandler) Create(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req createRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	isAdmin := auth.RoleFromContext(r.Context()).IsAdmin()
+	currency, err := h.refunds.RefundCurrency(r.Context(), paymentID, userID, isAdmin)
+	if err != nil {
+		log.Warn("refund failed", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+	amount, fields := parseAmount("amount", req.Amount, currency)
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	refund, err := h.refunds.RefundPayment(r.Context(), payment.RefundRequest{
+		PaymentID:      paymentID,
+		ActorID:        userID,
+		ActorIsAdmin:   isAdmin,
+		Amount:         amount,
+		Reason:         req.Reason,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		log.Warn("refund failed", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/payments/%s", refund.ID))
+	RespondSuccess(w, http.StatusCreated, toPaymentDTO(refund))
+}
+
+func (h *RefundHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	paymentID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	refunds, err := h.refunds.ListRefunds(r.Context(), paymentID, userID, auth.RoleFromContext(r.Context()).IsStaff())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to list refunds", "payment_id", paymentID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]paymentDTO, len(refunds))
+	for i := range refunds {
+		dtos[i] = toPaymentDTO(&refunds[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
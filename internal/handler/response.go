@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -58,8 +59,74 @@ func RespondValidationError(w http.ResponseWriter, fields []FieldError) {
 	RespondAppError(w, ErrValidationFailed, fields)
 }
 
+type periodLimitDetails struct {
+	Period    string `json:"period"`
+	Currency  string `json:"currency"`
+	Limit     string `json:"limit"`
+	Used      string `json:"used"`
+	Remaining string `json:"remaining"`
+}
+
+// accountFrozenDetails tells the account's owner why it's frozen and what to
+// do about it.
+type accountFrozenDetails struct {
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+	Action  string `json:"action"`
+}
+
+// freezeMessages is what each freeze reason tells the owner. The empty
+// reason covers accounts frozen before reasons were recorded.
+var freezeMessages = map[domain.FreezeReason]accountFrozenDetails{
+	domain.FreezeReasonComplianceReview: {
+		Message: "Your account is under a routine compliance review. Payments from it are paused until the review is finished.",
+		Action:  "Check your email for any documents we've asked for. Contact support if you haven't heard from us.",
+	},
+	domain.FreezeReasonUserRequested: {
+		Message: "This account was frozen at your request.",
+		Action:  "Contact support to unfreeze it.",
+	},
+	domain.FreezeReasonFraudSuspected: {
+		Message: "We've paused this account after noticing unusual activity.",
+		Action:  "Contact support to confirm your recent activity.",
+	},
+	"": {
+		Message: "This account is frozen.",
+		Action:  "Contact support.",
+	},
+}
+
+func toAccountFrozenDetails(reason domain.FreezeReason) accountFrozenDetails {
+	d, ok := freezeMessages[reason]
+	if !ok {
+		d = freezeMessages[""]
+	}
+	d.Reason = string(reason)
+	return d
+}
+
+// residencyRestrictedDetails tells the sender which part of the payment
+// their country of residence doesn't allow.
+type residencyRestrictedDetails struct {
+	Residency      string `json:"residency"`
+	SourceCurrency string `json:"source_currency"`
+	DestCurrency   string `json:"dest_currency"`
+	DestCountry    string `json:"dest_country,omitempty"`
+}
+
 func RespondDomainError(w http.ResponseWriter, err error) {
+	appErr, details := DomainAppError(err)
+	RespondAppError(w, appErr, details)
+}
+
+// DomainAppError maps a service error to the API error it is reported as,
+// with its details, if any. Errors that carry their own details type use it;
+// otherwise the details are the context of any domain.DomainError wrapped in
+// err. Transports other than the REST API use it to report errors with the
+// same codes.
+func DomainAppError(err error) (*AppError, any) {
 	var appErr *AppError
+	var details any
 
 	switch {
 	case errors.Is(err, domain.ErrNotFound):
@@ -68,12 +135,28 @@ func RespondDomainError(w http.ResponseWriter, err error) {
 		appErr = ErrInsufficientFunds
 	case errors.Is(err, domain.ErrAccountFrozen):
 		appErr = ErrAccountFrozen
+		var afe *domain.AccountFrozenError
+		if errors.As(err, &afe) {
+			details = toAccountFrozenDetails(afe.Reason)
+		}
 	case errors.Is(err, domain.ErrDuplicatePayment):
 		appErr = ErrDuplicatePayment
 	case errors.Is(err, domain.ErrSelfTransfer):
 		appErr = ErrSelfTransfer
 	case errors.Is(err, domain.ErrLimitExceeded):
 		appErr = ErrLimitExceeded
+	case errors.Is(err, domain.ErrPeriodLimitExceeded):
+		appErr = ErrPeriodLimitExceeded
+		var ple *domain.PeriodLimitError
+		if errors.As(err, &ple) {
+			details = periodLimitDetails{
+				Period:    string(ple.Period),
+				Currency:  string(ple.Currency),
+				Limit:     formatAmount(ple.Limit, ple.Currency),
+				Used:      formatAmount(ple.Used, ple.Currency),
+				Remaining: formatAmount(ple.Remaining, ple.Currency),
+			}
+		}
 	case errors.Is(err, domain.ErrRecipientNotFound):
 		appErr = ErrRecipientNotFound
 	case errors.Is(err, domain.ErrAccountNotFound):
@@ -86,16 +169,124 @@ func RespondDomainError(w http.ResponseWriter, err error) {
 		appErr = ErrAccountClosed
 	case errors.Is(err, domain.ErrCurrencyMismatch):
 		appErr = ErrCurrencyMismatch
-	case errors.Is(err, domain.ErrVersionConflict):
+	case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrTxContention):
 		appErr = ErrVersionConflict
 	case errors.Is(err, domain.ErrInvalidAmount):
 		appErr = ErrInvalidAmount
+	case errors.Is(err, domain.ErrMissingIdempotencyKey):
+		appErr = ErrMissingIdempotencyKey
 	case errors.Is(err, domain.ErrInvalidRequest):
 		appErr = ErrInvalidRequest
+	case errors.Is(err, domain.ErrPaymentNotRetriable):
+		appErr = ErrPaymentNotRetriable
+	case errors.Is(err, domain.ErrPaymentAlreadyRetried):
+		appErr = ErrPaymentAlreadyRetried
+	case errors.Is(err, domain.ErrNoProviderRoute):
+		appErr = ErrNoProviderRoute
+	case errors.Is(err, domain.ErrSettlementClosed):
+		appErr = ErrSettlementClosed
+	case errors.Is(err, domain.ErrSettlementEmpty):
+		appErr = ErrSettlementEmpty
+	case errors.Is(err, domain.ErrDisputeExists):
+		appErr = ErrDisputeExists
+	case errors.Is(err, domain.ErrDisputeClosed):
+		appErr = ErrDisputeClosed
+	case errors.Is(err, domain.ErrKYCTierInsufficient):
+		appErr = ErrKYCTierInsufficient
+	case errors.Is(err, domain.ErrKYCSubmissionPending):
+		appErr = ErrKYCSubmissionPending
+	case errors.Is(err, domain.ErrKYCSubmissionClosed):
+		appErr = ErrKYCSubmissionClosed
+	case errors.Is(err, domain.ErrPaymentNotPendingReview):
+		appErr = ErrPaymentNotPendingReview
+	case errors.Is(err, domain.ErrDenylistEntryExists):
+		appErr = ErrDenylistEntryExists
+	case errors.Is(err, domain.ErrUniqueNameTaken):
+		appErr = ErrUniqueNameTaken
+	case errors.Is(err, domain.ErrUniqueNameCoolingDown):
+		appErr = ErrUniqueNameCoolingDown
+	case errors.Is(err, domain.ErrEmailTaken):
+		appErr = ErrEmailTaken
+	case errors.Is(err, domain.ErrPaymentNotReversible):
+		appErr = ErrPaymentNotReversible
+	case errors.Is(err, domain.ErrPaymentNotRefundable):
+		appErr = ErrPaymentNotRefundable
+	case errors.Is(err, domain.ErrPaymentNotResolvable):
+		appErr = ErrPaymentNotResolvable
+	case errors.Is(err, domain.ErrRefundExceedsPayment):
+		appErr = ErrRefundExceedsPayment
+	case errors.Is(err, domain.ErrQASampleAlreadyReviewed):
+		appErr = ErrQASampleAlreadyReviewed
+	case errors.Is(err, domain.ErrPaymentRequestClosed):
+		appErr = ErrPaymentRequestClosed
+	case errors.Is(err, domain.ErrPaymentRequestExpired):
+		appErr = ErrPaymentRequestExpired
+	case errors.Is(err, domain.ErrHoldNotActive):
+		appErr = ErrHoldNotActive
+	case errors.Is(err, domain.ErrHoldAmountExceeded):
+		appErr = ErrHoldAmountExceeded
+	case errors.Is(err, domain.ErrSweepRequired):
+		appErr = ErrSweepRequired
+	case errors.Is(err, domain.ErrAccountCloseBlocked):
+		appErr = ErrAccountCloseBlocked
+	case errors.Is(err, domain.ErrDefaultAccountNotSet):
+		appErr = ErrDefaultAccountNotSet
+	case errors.Is(err, domain.ErrChainBreakAnnotated):
+		appErr = ErrChainBreakAnnotated
+	case errors.Is(err, domain.ErrAPIKeyRevoked):
+		appErr = ErrAPIKeyRevoked
+	case errors.Is(err, domain.ErrAPIKeyLimitReached):
+		appErr = ErrAPIKeyLimitReached
+	case errors.Is(err, domain.ErrResetTokenInvalid):
+		appErr = ErrResetTokenInvalid
+	case errors.Is(err, domain.ErrTOTPRequired):
+		appErr = ErrTOTPRequired
+	case errors.Is(err, domain.ErrInvalidTOTPCode):
+		appErr = ErrInvalidTOTPCode
+	case errors.Is(err, domain.ErrTOTPNotEnabled):
+		appErr = ErrTOTPNotEnabled
+	case errors.Is(err, domain.ErrTOTPAlreadyEnabled):
+		appErr = ErrTOTPAlreadyEnabled
+	case errors.Is(err, domain.ErrBeneficiaryExists):
+		appErr = ErrBeneficiaryExists
+	case errors.Is(err, domain.ErrBeneficiaryDeleted):
+		appErr = ErrBeneficiaryDeleted
+	case errors.Is(err, domain.ErrBeneficiaryInUse):
+		appErr = ErrBeneficiaryInUse
+	case errors.Is(err, domain.ErrAccountNotFrozen):
+		appErr = ErrAccountNotFrozen
+	case errors.Is(err, domain.ErrResidencyRestricted):
+		appErr = ErrResidencyRestricted
+		var rre *domain.ResidencyRestrictedError
+		if errors.As(err, &rre) {
+			details = residencyRestrictedDetails{
+				Residency:      rre.Residency,
+				SourceCurrency: string(rre.SourceCurrency),
+				DestCurrency:   string(rre.DestCurrency),
+				DestCountry:    rre.DestCountry,
+			}
+		}
+	case errors.Is(err, domain.ErrResidencyRuleExists):
+		appErr = ErrResidencyRuleExists
+	case errors.Is(err, domain.ErrWebhookEventNotRetriable):
+		appErr = ErrWebhookEventNotRetriable
+	case errors.Is(err, domain.ErrLoginChallengeRequired):
+		appErr = ErrLoginChallengeRequired
+	case errors.Is(err, domain.ErrLoginChallengeFailed):
+		appErr = ErrLoginChallengeFailed
+	case errors.Is(err, domain.ErrLoginChallengeUnavailable):
+		appErr = ErrLoginChallengeUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		appErr = ErrRequestTimeout
 	default:
 		slog.Error("unhandled domain error", "error", err)
-		appErr = ErrInternalError
+		return ErrInternalError, nil
 	}
 
-	RespondAppError(w, appErr, nil)
+	if details == nil {
+		if ctx := domain.ErrorContext(err); ctx != nil {
+			details = ctx
+		}
+	}
+	return appErr, details
 }
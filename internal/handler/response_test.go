@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestDomainAppError_Details(t *testing.T) {
+	appErr, details := DomainAppError(fmt.Errorf("validateTransfer: %w",
+		domain.NewDomainError(domain.ErrLimitExceeded, "currency", domain.CurrencyUSD, "limit", int64(10000), "amount", int64(12000))))
+	assert.Equal(t, ErrLimitExceeded, appErr)
+	assert.Equal(t, map[string]any{"currency": domain.CurrencyUSD, "limit": int64(10000), "amount": int64(12000)}, details)
+
+	appErr, details = DomainAppError(fmt.Errorf("x: %w", domain.NewDomainError(
+		&domain.AccountFrozenError{Reason: domain.FreezeReasonUserRequested}, "account_id", "a")))
+	assert.Equal(t, ErrAccountFrozen, appErr)
+	assert.IsType(t, accountFrozenDetails{}, details, "an error's own details type comes first")
+
+	_, details = DomainAppError(fmt.Errorf("x: %w", domain.ErrInsufficientFunds))
+	assert.Nil(t, details)
+
+	appErr, details = DomainAppError(domain.NewDomainError(errors.New("boom"), "query", "SELECT"))
+	assert.Equal(t, ErrInternalError, appErr)
+	assert.Nil(t, details, "unmapped errors show nothing")
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+const (
+	defaultSettlementLimit = 50
+	maxSettlementLimit     = 200
+)
+
+type settlementService interface {
+	BuildBatch(ctx context.Context, currency domain.Currency, date time.Time) (*domain.SettlementBatch, error)
+	CloseBatch(ctx context.Context, batchID uuid.UUID, closedBy uuid.UUID) (*domain.SettlementBatch, error)
+	List(ctx context.Context, currency domain.Currency, status domain.SettlementStatus, limit, offset int) ([]domain.SettlementBatch, error)
+	Report(ctx context.Context, batchID uuid.UUID) (*service.SettlementReport, error)
+}
+
+type SettlementHandler struct {
+	settlements settlementService
+}
+
+func NewSettlementHandler(settlements settlementService) *SettlementHandler {
+	return &SettlementHandler{settlements: settlements}
+}
+
+type buildSettlementRequest struct {
+	Currency string `json:"currency"`
+	Date     string `json:"date"`
+}
+
+func (r buildSettlementRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !domain.Currency(r.Currency).IsValid() {
+		errs = append(errs, FieldError{Field: "currency", Message: "must be one of " + domain.CurrencyList()})
+	}
+	if _, err := time.Parse(time.DateOnly, r.Date); err != nil {
+		errs = append(errs, FieldError{Field: "date", Message: "must be a date in YYYY-MM-DD format"})
+	}
+	return errs
+}
+
+type settlementBatchDTO struct {
+	ID             uuid.UUID  `json:"id"`
+	Currency       string     `json:"currency"`
+	SettlementDate string     `json:"settlement_date"`
+	Status         string     `json:"status"`
+	PaymentCount   int        `json:"payment_count"`
+	TotalAmount    string     `json:"total_amount"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	ClosedBy       *uuid.UUID `json:"closed_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type settlementItemDTO struct {
+	PaymentID   uuid.UUID  `json:"payment_id"`
+	Amount      string     `json:"amount"`
+	Provider    *string    `json:"provider,omitempty"`
+	ProviderRef *string    `json:"provider_ref,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type settlementReportDTO struct {
+	Batch settlementBatchDTO  `json:"batch"`
+	Items []settlementItemDTO `json:"items"`
+}
+
+func toSettlementBatchDTO(b *domain.SettlementBatch) settlementBatchDTO {
+	return settlementBatchDTO{
+		ID:             b.ID,
+		Currency:       string(b.Currency),
+		SettlementDate: b.SettlementDate.Format(time.DateOnly),
+		Status:         string(b.Status),
+		PaymentCount:   b.PaymentCount,
+		TotalAmount:    formatAmount(b.TotalAmount, b.Currency),
+		ClosedAt:       b.ClosedAt,
+		ClosedBy:       b.ClosedBy,
+		CreatedAt:      b.CreatedAt,
+	}
+}
+
+func (h *SettlementHandler) Build(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	var req buildSettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	date, _ := time.Parse(time.DateOnly, req.Date)
+	batch, err := h.settlements.BuildBatch(r.Context(), domain.Currency(req.Currency), date)
+	if err != nil {
+		log.Warn("settlement batch build failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toSettlementBatchDTO(batch))
+}
+
+func (h *SettlementHandler) List(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	q := r.URL.Query()
+
+	currency := domain.Currency(q.Get("currency"))
+	if currency != "" && !currency.IsValid() {
+		RespondAppError(w, ErrInvalidCurrency, nil)
+		return
+	}
+
+	status := domain.SettlementStatus(q.Get("status"))
+	if status != "" && status != domain.SettlementStatusOpen && status != domain.SettlementStatusClosed {
+		RespondValidationError(w, []FieldError{{Field: "status", Message: "must be open or closed"}})
+		return
+	}
+
+	limit, offset, fields := parsePage(q.Get("limit"), q.Get("offset"))
+	if len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	batches, err := h.settlements.List(r.Context(), currency, status, limit, offset)
+	if err != nil {
+		log.Error("failed to list settlement batches", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]settlementBatchDTO, len(batches))
+	for i := range batches {
+		dtos[i] = toSettlementBatchDTO(&batches[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+func (h *SettlementHandler) Get(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	batchID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	report, err := h.settlements.Report(r.Context(), batchID)
+	if err != nil {
+		log.Warn("settlement report failed", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeSettlementCSV(w, report)
+		return
+	}
+
+	dto := settlementReportDTO{
+		Batch: toSettlementBatchDTO(report.Batch),
+		Items: make([]settlementItemDTO, len(report.Items)),
+	}
+	for i, it := range report.Items {
+		dto.Items[i] = settlementItemDTO{
+			PaymentID:   it.PaymentID,
+			Amount:      formatAmount(it.Amount, report.Batch.Currency),
+			Provider:    it.Provider,
+			ProviderRef: it.ProviderRef,
+			CompletedAt: it.CompletedAt,
+		}
+	}
+	RespondSuccess(w, http.StatusOK, dto)
+}
+
+func (h *SettlementHandler) Close(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+
+	adminID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	batchID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	batch, err := h.settlements.CloseBatch(r.Context(), batchID, adminID)
+	if err != nil {
+		log.Warn("settlement batch close failed", "batch_id", batchID, "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toSettlementBatchDTO(batch))
+}
+
+func writeSettlementCSV(w http.ResponseWriter, report *service.SettlementReport) {
+	b := report.Batch
+	filename := fmt.Sprintf("settlement_%s_%s.csv", b.Currency, b.SettlementDate.Format(time.DateOnly))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"payment_id", "currency", "amount", "provider", "provider_ref", "completed_at"})
+	for _, it := range report.Items {
+		completedAt := ""
+		if it.CompletedAt != nil {
+			completedAt = it.CompletedAt.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			it.PaymentID.String(),
+			string(b.Currency),
+			formatAmount(it.Amount, b.Currency),
+			derefString(it.Provider),
+			derefString(it.ProviderRef),
+			completedAt,
+		})
+	}
+	cw.Flush()
+}
+
+func parsePage(limitParam, offsetParam string) (int, int, []FieldError) {
+	var errs []FieldError
+	limit, offset := defaultSettlementLimit, 0
+
+	if limitParam != "" {
+		n, err := strconv.Atoi(limitParam)
+		if err != nil || n < 1 || n > maxSettlementLimit {
+			errs = append(errs, FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxSettlementLimit)})
+		}
+		limit = n
+	}
+	if offsetParam != "" {
+		n, err := strconv.Atoi(offsetParam)
+		if err != nil || n < 0 {
+			errs = append(errs, FieldError{Field: "offset", Message: "must be a non-negative integer"})
+		}
+		offset = n
+	}
+	return limit, offset, errs
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type supportNoteService interface {
+	AddNote(ctx context.Context, req service.AddNoteRequest) (*domain.SupportNote, error)
+	ListNotes(ctx context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error)
+}
+
+type SupportNoteHandler struct {
+	notes supportNoteService
+}
+
+func NewSupportNoteHandler(notes supportNoteService) *SupportNoteHandler {
+	return &SupportNoteHandler{notes: notes}
+}
+
+type createNoteRequest struct {
+	Body    string `json:"body"`
+	Flagged bool   `json:"flagged"`
+}
+
+func (r createNoteRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.Body == "" {
+		errs = append(errs, FieldError{Field: "body", Message: "required"})
+	}
+	return errs
+}
+
+type supportNoteDTO struct {
+	ID               uuid.UUID   `json:"id"`
+	SubjectType      string      `json:"subject_type"`
+	SubjectID        uuid.UUID   `json:"subject_id"`
+	AuthorID         uuid.UUID   `json:"author_id"`
+	Body             string      `json:"body"`
+	MentionedUserIDs []uuid.UUID `json:"mentioned_user_ids"`
+	Flagged          bool        `json:"flagged"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+func toSupportNoteDTO(n *domain.SupportNote) supportNoteDTO {
+	mentioned := n.MentionedUserIDs
+	if mentioned == nil {
+		mentioned = []uuid.UUID{}
+	}
+	return supportNoteDTO{
+		ID:               n.ID,
+		SubjectType:      string(n.SubjectType),
+		SubjectID:        n.SubjectID,
+		AuthorID:         n.AuthorID,
+		Body:             n.Body,
+		MentionedUserIDs: mentioned,
+		Flagged:          n.Flagged,
+		CreatedAt:        n.CreatedAt,
+	}
+}
+
+func toSupportNoteDTOs(notes []domain.SupportNote) []supportNoteDTO {
+	dtos := make([]supportNoteDTO, len(notes))
+	for i := range notes {
+		dtos[i] = toSupportNoteDTO(&notes[i])
+	}
+	return dtos
+}
+
+func (h *SupportNoteHandler) CreatePaymentNote(w http.ResponseWriter, r *http.Request) {
+	h.create(w, r, domain.NoteSubjectPayment)
+}
+
+func (h *SupportNoteHandler) ListPaymentNotes(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, domain.NoteSubjectPayment)
+}
+
+func (h *SupportNoteHandler) CreateUserNote(w http.ResponseWriter, r *http.Request) {
+	h.create(w, r, domain.NoteSubjectUser)
+}
+
+func (h *SupportNoteHandler) ListUserNotes(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, domain.NoteSubjectUser)
+}
+
+func (h *SupportNoteHandler) create(w http.ResponseWriter, r *http.Request, subjectType domain.NoteSubjectType) {
+	authorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondAppError(w, ErrMissingToken, nil)
+		return
+	}
+
+	subjectID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	var req createNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	note, err := h.notes.AddNote(r.Context(), service.AddNoteRequest{
+		AuthorID:    authorID,
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Body:        req.Body,
+		Flagged:     req.Flagged,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to add support note", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, toSupportNoteDTO(note))
+}
+
+func (h *SupportNoteHandler) list(w http.ResponseWriter, r *http.Request, subjectType domain.NoteSubjectType) {
+	subjectID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	notes, err := h.notes.ListNotes(r.Context(), subjectType, subjectID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list support notes", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toSupportNoteDTOs(notes))
+}
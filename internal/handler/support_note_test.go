@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type stubSupportNoteService struct {
+	mentioned []uuid.UUID
+	err       error
+
+	added []service.AddNoteRequest
+	notes []domain.SupportNote
+}
+
+func (s *stubSupportNoteService) AddNote(_ context.Context, req service.AddNoteRequest) (*domain.SupportNote, error) {
+	s.added = append(s.added, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	note := domain.SupportNote{
+		ID: uuid.New(), SubjectType: req.SubjectType, SubjectID: req.SubjectID, AuthorID: req.AuthorID,
+		Body: req.Body, MentionedUserIDs: s.mentioned, Flagged: req.Flagged, CreatedAt: time.Now().UTC(),
+	}
+	s.notes = append(s.notes, note)
+	return &note, nil
+}
+
+func (s *stubSupportNoteService) ListNotes(_ context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error) {
+	out := []domain.SupportNote{}
+	for _, n := range s.notes {
+		if n.SubjectType == subjectType && n.SubjectID == subjectID {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func noteRequest(method, subjectID string, authorID uuid.UUID, body string) *http.Request {
+	req := httptest.NewRequest(method, "/api/v1/admin/payments/"+subjectID+"/notes", strings.NewReader(body))
+	req.SetPathValue("id", subjectID)
+	return req.WithContext(auth.ContextWithUserID(req.Context(), authorID))
+}
+
+func TestSupportNoteHandler_CreateAndList(t *testing.T) {
+	mentionedID := uuid.New()
+	stub := &stubSupportNoteService{mentioned: []uuid.UUID{mentionedID}}
+	h := NewSupportNoteHandler(stub)
+	authorID, paymentID := uuid.New(), uuid.New()
+
+	rec := httptest.NewRecorder()
+	h.CreatePaymentNote(rec, noteRequest(http.MethodPost, paymentID.String(), authorID, `{"body":"@ops_lead please check","flagged":true}`))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Len(t, stub.added, 1)
+	assert.Equal(t, service.AddNoteRequest{
+		AuthorID: authorID, SubjectType: domain.NoteSubjectPayment, SubjectID: paymentID,
+		Body: "@ops_lead please check", Flagged: true,
+	}, stub.added[0])
+
+	var created struct {
+		Data supportNoteDTO `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "payment", created.Data.SubjectType)
+	assert.Equal(t, []uuid.UUID{mentionedID}, created.Data.MentionedUserIDs)
+
+	stub.mentioned = nil
+	rec = httptest.NewRecorder()
+	h.CreatePaymentNote(rec, noteRequest(http.MethodPost, paymentID.String(), authorID, `{"body":"refund sent to alice@example.com"}`))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"mentioned_user_ids":[]`, "no mentions is an empty list, not null")
+
+	rec = httptest.NewRecorder()
+	h.ListPaymentNotes(rec, noteRequest(http.MethodGet, paymentID.String(), authorID, ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Data []supportNoteDTO `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, created.Data.ID, list.Data[0].ID)
+
+	rec = httptest.NewRecorder()
+	h.ListUserNotes(rec, noteRequest(http.MethodGet, paymentID.String(), authorID, ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"data":[]`, "notes are listed per subject type")
+}
+
+func TestSupportNoteHandler_CreateRejects(t *testing.T) {
+	authorID, userID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		err      error
+		wantCode string
+		wantCall bool
+		mention  string
+	}{
+		{"empty body", userID.String(), `{"body":""}`, nil, "VALIDATION_FAILED", false, ""},
+		{"malformed json", userID.String(), `{"body":`, nil, "INVALID_REQUEST", false, ""},
+		{"bad subject id", "not-a-uuid", `{"body":"hi"}`, nil, "RESOURCE_NOT_FOUND", false, ""},
+		{
+			"unknown mention", userID.String(), `{"body":"cc @nobody"}`,
+			fmt.Errorf("AddNote: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "body", "mention", "nobody")),
+			"INVALID_REQUEST", true, "nobody",
+		},
+		{
+			"customer mention", userID.String(), `{"body":"ask @alice"}`,
+			fmt.Errorf("AddNote: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "body", "mention", "alice")),
+			"INVALID_REQUEST", true, "alice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubSupportNoteService{err: tt.err}
+			h := NewSupportNoteHandler(stub)
+
+			rec := httptest.NewRecorder()
+			h.CreateUserNote(rec, noteRequest(http.MethodPost, tt.id, authorID, tt.body))
+
+			var resp APIResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, tt.wantCode, resp.Error.Code)
+			assert.Equal(t, tt.wantCall, len(stub.added) == 1)
+			assert.Empty(t, stub.notes)
+			if tt.mention != "" {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Equal(t, map[string]any{"field": "body", "mention": tt.mention}, resp.Error.Details)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+// TOTPCodeHeader carries an authenticator code on requests that need step-up
+// authentication, such as large payouts.
+const TOTPCodeHeader = "X-TOTP-Code"
+
+type totpService interface {
+	Status(ctx context.Context, userID uuid.UUID) (*service.TOTPStatus, error)
+	Enroll(ctx context.Context, userID uuid.UUID) (*service.TOTPEnrollment, error)
+	Confirm(ctx context.Context, userID uuid.UUID, code string) error
+	Disable(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+type TOTPHandler struct {
+	totp totpService
+}
+
+func NewTOTPHandler(totp totpService) *TOTPHandler {
+	return &TOTPHandler{totp: totp}
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+func (r confirmTOTPRequest) Validate() []FieldError {
+	if r.Code == "" {
+		return []FieldError{{Field: "code", Message: "is required"}}
+	}
+	return nil
+}
+
+type totpStatusDTO struct {
+	Enabled     bool       `json:"enabled"`
+	Pending     bool       `json:"pending"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+}
+
+type totpEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+func (h *TOTPHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	status, err := h.totp.Status(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to get totp status", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, totpStatusDTO{
+		Enabled:     status.Enabled,
+		Pending:     status.Pending,
+		ConfirmedAt: status.ConfirmedAt,
+	})
+}
+
+// Enroll returns a new secret. Two-factor authentication stays off until
+// Confirm is called with a code from it.
+func (h *TOTPHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	enrollment, err := h.totp.Enroll(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to start totp enrollment", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, totpEnrollmentDTO{Secret: enrollment.Secret, OTPAuthURI: enrollment.URI})
+}
+
+func (h *TOTPHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req confirmTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	if err := h.totp.Confirm(r.Context(), userID, req.Code); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to confirm totp enrollment", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	h.Status(w, r)
+}
+
+// Disable turns two-factor authentication off. The current code comes in
+// the X-TOTP-Code header.
+func (h *TOTPHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	if err := h.totp.Disable(r.Context(), userID, r.Header.Get(TOTPCodeHeader)); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to disable totp", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	h.Status(w, r)
+}
@@ -35,10 +35,5 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondSuccess(w, http.StatusOK, userDTO{
-		ID:         user.ID,
-		Email:      user.Email,
-		Name:       user.Name,
-		UniqueName: user.UniqueName,
-	})
+	RespondSuccess(w, http.StatusOK, toUserDTO(user))
 }
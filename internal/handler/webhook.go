@@ -1,71 +1,64 @@
 package handler
 
 import (
+	"compress/gzip"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
 )
 
 type webhookEventRepository interface {
 	Create(ctx context.Context, event *domain.WebhookEvent) error
 }
 
-type WebhookHandler struct {
-	webhooks webhookEventRepository
-	secret   string
-}
-
-func NewWebhookHandler(webhooks webhookEventRepository, secret string) *WebhookHandler {
-	return &WebhookHandler{webhooks: webhooks, secret: secret}
-}
-
-type webhookPayload struct {
-	EventID     string `json:"event_id"`
-	PaymentID   string `json:"payment_id"`
-	Status      string `json:"status"`
-	ProviderRef string `json:"provider_ref,omitempty"`
-	Reason      string `json:"reason,omitempty"`
-	Timestamp   string `json:"timestamp"`
+type webhookPrioritizer interface {
+	Priority(ctx context.Context, paymentID string, eventType domain.WebhookEventType) domain.WebhookPriority
 }
 
-func (p webhookPayload) validate() []FieldError {
-	var errs []FieldError
+type WebhookVerifier func(body []byte, header http.Header) bool
 
-	if p.EventID == "" {
-		errs = append(errs, FieldError{Field: "event_id", Message: "required"})
-	} else if _, err := uuid.Parse(p.EventID); err != nil {
-		errs = append(errs, FieldError{Field: "event_id", Message: "must be a valid UUID"})
+func HMACVerifier(secret, signatureHeader string) WebhookVerifier {
+	return func(body []byte, header http.Header) bool {
+		return verifyHMAC(body, header.Get(signatureHeader), secret)
 	}
+}
 
-	if p.PaymentID == "" {
-		errs = append(errs, FieldError{Field: "payment_id", Message: "required"})
-	} else if _, err := uuid.Parse(p.PaymentID); err != nil {
-		errs = append(errs, FieldError{Field: "payment_id", Message: "must be a valid UUID"})
-	}
+type WebhookHandler struct {
+	webhooks        webhookEventRepository
+	priorities      webhookPrioritizer
+	verifiers       map[string]WebhookVerifier
+	maxBodyBytes    map[string]int64
+	defaultProvider string
+	tolerance       time.Duration
+}
 
-	if p.Status == "" {
-		errs = append(errs, FieldError{Field: "status", Message: "required"})
-	} else if p.Status != "completed" && p.Status != "failed" {
-		errs = append(errs, FieldError{Field: "status", Message: "must be completed or failed"})
+// NewWebhookHandler builds the provider webhook receiver. maxBodyBytes caps
+// each provider's payload after decompression; providers missing from it get
+// webhookverify.MaxBodyBytes. A non-zero tolerance rejects events whose
+// timestamp is further than that from now. A nil priorities stores every
+// event at normal priority.
+func NewWebhookHandler(webhooks webhookEventRepository, priorities webhookPrioritizer, verifiers map[string]WebhookVerifier, maxBodyBytes map[string]int64, defaultProvider string, tolerance time.Duration) *WebhookHandler {
+	return &WebhookHandler{
+		webhooks:        webhooks,
+		priorities:      priorities,
+		verifiers:       verifiers,
+		maxBodyBytes:    maxBodyBytes,
+		defaultProvider: defaultProvider,
+		tolerance:       tolerance,
 	}
-
-	return errs
 }
 
-func (p webhookPayload) eventType() domain.WebhookEventType {
-	if p.Status == "completed" {
+func eventType(e *webhookverify.Event) domain.WebhookEventType {
+	if e.Status == webhookverify.StatusCompleted {
 		return domain.WebhookEventTypePaymentCompleted
 	}
 	return domain.WebhookEventTypePaymentFailed
@@ -74,43 +67,77 @@ func (p webhookPayload) eventType() domain.WebhookEventType {
 func (h *WebhookHandler) ReceiveProviderWebhook(w http.ResponseWriter, r *http.Request) {
 	log := logging.FromContext(r.Context())
 
-	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	provider := r.PathValue("provider")
+	if provider == "" {
+		provider = h.defaultProvider
+	}
+
+	verify, ok := h.verifiers[provider]
+	if !ok {
+		log.Warn("webhook received for unknown provider", "provider", provider)
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	body, err := h.readBody(r, provider)
 	if err != nil {
-		log.Error("failed to read webhook body", "error", err)
+		if errors.Is(err, errWebhookTooLarge) {
+			log.Warn("webhook body over size limit", "provider", provider, "limit", h.maxBody(provider))
+			RespondAppError(w, ErrPayloadTooLarge, nil)
+			return
+		}
+		log.Warn("failed to read webhook body", "provider", provider, "error", err)
 		RespondAppError(w, ErrInvalidRequest, nil)
 		return
 	}
 
-	sig := r.Header.Get("X-Webhook-Signature")
-	if !verifyHMAC(body, sig, h.secret) {
-		log.Warn("webhook signature verification failed")
+	if !verify(body, r.Header) {
+		log.Warn("webhook signature verification failed", "provider", provider)
 		RespondAppError(w, ErrInvalidSignature, nil)
 		return
 	}
 
-	var payload webhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
+	payload, err := webhookverify.Parse(body)
+	if err != nil {
+		var perr *webhookverify.PayloadError
+		if errors.As(err, &perr) {
+			fields := make([]FieldError, len(perr.Fields))
+			for i, f := range perr.Fields {
+				fields[i] = FieldError{Field: f.Field, Message: f.Message}
+			}
+			RespondValidationError(w, fields)
+			return
+		}
 		log.Warn("failed to parse webhook payload", "error", err)
 		RespondAppError(w, ErrInvalidRequest, nil)
 		return
 	}
 
-	if fields := payload.validate(); len(fields) > 0 {
-		RespondValidationError(w, fields)
+	if err := webhookverify.CheckTimestamp(payload, time.Now().UTC(), h.tolerance); err != nil {
+		log.Warn("webhook timestamp outside tolerance", "provider", provider, "event_id", payload.EventID, "error", err)
+		RespondAppError(w, ErrStaleWebhook, nil)
 		return
 	}
 
 	event := &domain.WebhookEvent{
 		ID:             uuid.New(),
 		IdempotencyKey: payload.EventID,
-		EventType:      payload.eventType(),
+		EventType:      eventType(payload),
 		Payload:        body,
+		Provider:       &provider,
 		Status:         domain.WebhookEventStatusPending,
+		Priority:       domain.WebhookPriorityNormal,
 		CreatedAt:      time.Now().UTC(),
 	}
+	if paymentID, err := uuid.Parse(payload.PaymentID); err == nil {
+		event.PaymentID = &paymentID
+	}
+	if h.priorities != nil {
+		event.Priority = h.priorities.Priority(r.Context(), payload.PaymentID, event.EventType)
+	}
 
 	if err := h.webhooks.Create(r.Context(), event); err != nil {
-		if isDuplicateKey(err) {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
 			log.Info("duplicate webhook received", "event_id", payload.EventID, "payment_id", payload.PaymentID)
 			RespondSuccess(w, http.StatusOK, map[string]string{"status": "already_received"})
 			return
@@ -125,25 +152,48 @@ func (h *WebhookHandler) ReceiveProviderWebhook(w http.ResponseWriter, r *http.R
 		"provider_event_id", payload.EventID,
 		"payment_id", payload.PaymentID,
 		"event_type", event.EventType,
+		"priority", event.Priority,
+		"provider", provider,
 	)
 
 	RespondSuccess(w, http.StatusOK, map[string]string{"status": "received"})
 }
 
-func verifyHMAC(body []byte, signature, secret string) bool {
-	if signature == "" {
-		return false
+var errWebhookTooLarge = errors.New("webhook body over size limit")
+
+func (h *WebhookHandler) maxBody(provider string) int64 {
+	if n, ok := h.maxBodyBytes[provider]; ok && n > 0 {
+		return n
 	}
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	expected := hex.EncodeToString(mac.Sum(nil))
-	return hmac.Equal([]byte(expected), []byte(signature))
+	return webhookverify.MaxBodyBytes
 }
 
-func isDuplicateKey(err error) bool {
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
-		return true
+// readBody reads the request body, inflating it first when it was sent with
+// Content-Encoding: gzip. Signatures are checked against the inflated JSON,
+// and the size limit applies to it too, so a small compressed body can't
+// expand past the limit.
+func (h *WebhookHandler) readBody(r *http.Request, provider string) ([]byte, error) {
+	var src io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		src = zr
+	}
+
+	limit := h.maxBody(provider)
+	body, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return nil, err
 	}
-	return false
+	if int64(len(body)) > limit {
+		return nil, errWebhookTooLarge
+	}
+	return body, nil
+}
+
+func verifyHMAC(body []byte, signature, secret string) bool {
+	return webhookverify.Verify(secret, body, signature) == nil
 }
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service"
+)
+
+type webhookEndpointService interface {
+	Get(ctx context.Context, userID uuid.UUID) (*service.WebhookEndpointWithSecret, error)
+	Set(ctx context.Context, userID uuid.UUID, rawURL string) (*service.WebhookEndpointWithSecret, error)
+	RotateSecret(ctx context.Context, userID uuid.UUID) (*service.WebhookEndpointWithSecret, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+	ListAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]domain.WebhookDeliveryAttempt, error)
+	SendTest(ctx context.Context, userID uuid.UUID) (*domain.WebhookDeliveryAttempt, error)
+}
+
+type WebhookEndpointHandler struct {
+	endpoints webhookEndpointService
+}
+
+func NewWebhookEndpointHandler(endpoints webhookEndpointService) *WebhookEndpointHandler {
+	return &WebhookEndpointHandler{endpoints: endpoints}
+}
+
+type setWebhookEndpointRequest struct {
+	URL string `json:"url"`
+}
+
+func (r setWebhookEndpointRequest) Validate() []FieldError {
+	var errs []FieldError
+	if r.URL == "" {
+		errs = append(errs, FieldError{Field: "url", Message: "is required"})
+	} else if len(r.URL) > 2048 {
+		errs = append(errs, FieldError{Field: "url", Message: "must be at most 2048 characters"})
+	}
+	return errs
+}
+
+type webhookEndpointDTO struct {
+	URL             string     `json:"url"`
+	Secret          string     `json:"secret"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	SecretRotatedAt *time.Time `json:"secret_rotated_at"`
+}
+
+func toWebhookEndpointDTO(e *service.WebhookEndpointWithSecret) webhookEndpointDTO {
+	return webhookEndpointDTO{
+		URL:             e.URL,
+		Secret:          e.Secret,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+		SecretRotatedAt: e.SecretRotatedAt,
+	}
+}
+
+type webhookAttemptDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	DeliveryID *uuid.UUID `json:"delivery_id"`
+	EventID    uuid.UUID  `json:"event_id"`
+	EventType  string     `json:"event_type"`
+	Attempt    int        `json:"attempt"`
+	URL        string     `json:"url"`
+	Succeeded  bool       `json:"succeeded"`
+	StatusCode *int       `json:"status_code"`
+	LatencyMS  int        `json:"latency_ms"`
+	Error      *string    `json:"error"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func toWebhookAttemptDTO(a *domain.WebhookDeliveryAttempt) webhookAttemptDTO {
+	return webhookAttemptDTO{
+		ID:         a.ID,
+		DeliveryID: a.DeliveryID,
+		EventID:    a.EventID,
+		EventType:  a.EventType,
+		Attempt:    a.Attempt,
+		URL:        a.URL,
+		Succeeded:  a.Succeeded(),
+		StatusCode: a.StatusCode,
+		LatencyMS:  a.LatencyMS,
+		Error:      a.Error,
+		CreatedAt:  a.CreatedAt,
+	}
+}
+
+// Get returns the user's endpoint with its signing secret.
+func (h *WebhookEndpointHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	e, err := h.endpoints.Get(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to get webhook endpoint", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toWebhookEndpointDTO(e))
+}
+
+func (h *WebhookEndpointHandler) Set(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	var req setWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, ErrInvalidRequest, nil)
+		return
+	}
+
+	if fields := req.Validate(); len(fields) > 0 {
+		RespondValidationError(w, fields)
+		return
+	}
+
+	e, err := h.endpoints.Set(r.Context(), userID, req.URL)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to set webhook endpoint", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toWebhookEndpointDTO(e))
+}
+
+func (h *WebhookEndpointHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	e, err := h.endpoints.RotateSecret(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to rotate webhook secret", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toWebhookEndpointDTO(e))
+}
+
+func (h *WebhookEndpointHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	if err := h.endpoints.Delete(r.Context(), userID); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to delete webhook endpoint", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListAttempts returns the most recent requests to the user's endpoint,
+// newest first. limit is at most 100.
+func (h *WebhookEndpointHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			RespondValidationError(w, []FieldError{{Field: "limit", Message: "must be between 1 and 100"}})
+			return
+		}
+		limit = n
+	}
+
+	attempts, err := h.endpoints.ListAttempts(r.Context(), userID, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list webhook attempts", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	dtos := make([]webhookAttemptDTO, len(attempts))
+	for i := range attempts {
+		dtos[i] = toWebhookAttemptDTO(&attempts[i])
+	}
+	RespondSuccess(w, http.StatusOK, dtos)
+}
+
+// SendTest sends a signed test event to the user's endpoint. The response
+// is the attempt, whether or not the endpoint accepted it.
+func (h *WebhookEndpointHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userID, appErr := ownerFromPath(r)
+	if appErr != nil {
+		RespondAppError(w, appErr, nil)
+		return
+	}
+
+	a, err := h.endpoints.SendTest(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to send test webhook", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, toWebhookAttemptDTO(a))
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
+)
+
+type providerRegistrationRepository interface {
+	Get(ctx context.Context, provider string) (*domain.ProviderRegistration, error)
+	List(ctx context.Context) ([]domain.ProviderRegistration, error)
+	RecordChallenge(ctx context.Context, provider string, ok bool, at time.Time) (*domain.ProviderRegistration, error)
+}
+
+// WebhookHandshakeHandler answers callback handshakes from providers that
+// verify a callback URL before using it, and holds back their events until
+// they have.
+type WebhookHandshakeHandler struct {
+	registrations   providerRegistrationRepository
+	secrets         map[string]string
+	defaultProvider string
+}
+
+// NewWebhookHandshakeHandler takes the webhook secret of each provider that
+// uses the handshake. Other providers get 404 on the handshake endpoint and
+// their events are let through unchecked.
+func NewWebhookHandshakeHandler(registrations providerRegistrationRepository, secrets map[string]string, defaultProvider string) *WebhookHandshakeHandler {
+	return &WebhookHandshakeHandler{registrations: registrations, secrets: secrets, defaultProvider: defaultProvider}
+}
+
+type providerRegistrationDTO struct {
+	Provider        string     `json:"provider"`
+	Status          string     `json:"status"`
+	Challenges      int        `json:"challenges"`
+	Failures        int        `json:"failures"`
+	LastChallengeAt *time.Time `json:"last_challenge_at,omitempty"`
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+	LastFailureAt   *time.Time `json:"last_failure_at,omitempty"`
+}
+
+func (h *WebhookHandshakeHandler) provider(r *http.Request) string {
+	if p := r.PathValue("provider"); p != "" {
+		return p
+	}
+	return h.defaultProvider
+}
+
+// Challenge answers GET <callback>?challenge=<token>. The token must be
+// signed with the provider's webhook secret; the reply carries the token and
+// our own signature over it. Every attempt is recorded against the provider.
+func (h *WebhookHandshakeHandler) Challenge(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	provider := h.provider(r)
+
+	secret, ok := h.secrets[provider]
+	if !ok {
+		RespondAppError(w, ErrResourceNotFound, nil)
+		return
+	}
+
+	challenge := r.URL.Query().Get(webhookverify.ChallengeParam)
+	if challenge == "" || len(challenge) > webhookverify.MaxChallengeLen {
+		RespondValidationError(w, []FieldError{{Field: webhookverify.ChallengeParam, Message: "required, at most " + strconv.Itoa(webhookverify.MaxChallengeLen) + " characters"}})
+		return
+	}
+
+	signed := webhookverify.Verify(secret, []byte(challenge), r.Header.Get(webhookverify.SignatureHeader)) == nil
+	reg, err := h.registrations.RecordChallenge(r.Context(), provider, signed, time.Now().UTC())
+	if err != nil {
+		log.Error("failed to record provider handshake", "provider", provider, "error", err)
+		RespondAppError(w, ErrInternalError, nil)
+		return
+	}
+	if !signed {
+		log.Warn("provider handshake signature verification failed", "provider", provider, "status", reg.Status)
+		RespondAppError(w, ErrInvalidSignature, nil)
+		return
+	}
+
+	log.Info("provider handshake verified", "provider", provider, "challenges", reg.Challenges)
+	RespondSuccess(w, http.StatusOK, webhookverify.ChallengeReply{
+		Challenge: challenge,
+		Signature: webhookverify.ChallengeResponse(secret, challenge),
+	})
+}
+
+// RequireVerified rejects events from a handshake provider until it has
+// completed the handshake.
+func (h *WebhookHandshakeHandler) RequireVerified(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := h.provider(r)
+		if _, ok := h.secrets[provider]; !ok {
+			next(w, r)
+			return
+		}
+
+		reg, err := h.registrations.Get(r.Context(), provider)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			logging.FromContext(r.Context()).Error("failed to read provider registration", "provider", provider, "error", err)
+			RespondAppError(w, ErrInternalError, nil)
+			return
+		}
+		if reg == nil || reg.Status != domain.ProviderRegistrationVerified {
+			logging.FromContext(r.Context()).Warn("webhook from unverified provider", "provider", provider)
+			RespondAppError(w, ErrProviderNotVerified, nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Registrations lists the handshake state of every provider that uses the
+// handshake, plus any that did one before being taken off the list.
+func (h *WebhookHandshakeHandler) Registrations(w http.ResponseWriter, r *http.Request) {
+	regs, err := h.registrations.List(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list provider registrations", "error", err)
+		RespondDomainError(w, err)
+		return
+	}
+
+	seen := make(map[string]bool, len(regs))
+	dtos := make([]providerRegistrationDTO, 0, len(regs)+len(h.secrets))
+	for _, reg := range regs {
+		seen[reg.Provider] = true
+		dtos = append(dtos, providerRegistrationDTO{
+			Provider:        reg.Provider,
+			Status:          string(reg.Status),
+			Challenges:      reg.Challenges,
+			Failures:        reg.Failures,
+			LastChallengeAt: reg.LastChallengeAt,
+			VerifiedAt:      reg.VerifiedAt,
+			LastFailureAt:   reg.LastFailureAt,
+		})
+	}
+	for provider := range h.secrets {
+		if !seen[provider] {
+			dtos = append(dtos, providerRegistrationDTO{Provider: provider, Status: string(domain.ProviderRegistrationPending)})
+		}
+	}
+	sort.Slice(dtos, func(i, j int) bool { return dtos[i].Provider < dtos[j].Provider })
+
+	RespondSuccess(w, http.StatusOK, dtos)
+}
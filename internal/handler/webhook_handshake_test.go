@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
+)
+
+// stubRegistrations mirrors the repository's upsert: success verifies, and
+// failure only marks a provider failed if it was never verified.
+type stubRegistrations struct {
+	regs map[string]*domain.ProviderRegistration
+}
+
+func (s *stubRegistrations) Get(_ context.Context, provider string) (*domain.ProviderRegistration, error) {
+	if reg, ok := s.regs[provider]; ok {
+		return reg, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *stubRegistrations) List(context.Context) ([]domain.ProviderRegistration, error) {
+	var out []domain.ProviderRegistration
+	for _, reg := range s.regs {
+		out = append(out, *reg)
+	}
+	return out, nil
+}
+
+func (s *stubRegistrations) RecordChallenge(_ context.Context, provider string, ok bool, at time.Time) (*domain.ProviderRegistration, error) {
+	reg, exists := s.regs[provider]
+	if !exists {
+		reg = &domain.ProviderRegistration{Provider: provider, Status: domain.ProviderRegistrationFailed}
+		s.regs[provider] = reg
+	}
+	reg.Challenges++
+	reg.LastChallengeAt = &at
+	if ok {
+		reg.Status = domain.ProviderRegistrationVerified
+		reg.VerifiedAt = &at
+	} else {
+		reg.Failures++
+		reg.LastFailureAt = &at
+	}
+	return reg, nil
+}
+
+func challengeRequest(provider, challenge, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/provider/"+provider+"?challenge="+challenge, nil)
+	req.SetPathValue("provider", provider)
+	if signature != "" {
+		req.Header.Set(webhookverify.SignatureHeader, signature)
+	}
+	return req
+}
+
+func TestWebhookHandshake(t *testing.T) {
+	regs := &stubRegistrations{regs: map[string]*domain.ProviderRegistration{}}
+	h := NewWebhookHandshakeHandler(regs, map[string]string{"mock_provider": testWebhookSecret}, "mock_provider")
+
+	received := 0
+	receive := h.RequireVerified(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusOK)
+	})
+	postEvent := func(provider string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/provider/"+provider, strings.NewReader("{}"))
+		req.SetPathValue("provider", provider)
+		rr := httptest.NewRecorder()
+		receive(rr, req)
+		return rr.Code
+	}
+
+	t.Run("events held back before the handshake", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, postEvent("mock_provider"))
+		assert.Equal(t, http.StatusOK, postEvent("other_provider"), "providers without a handshake pass through")
+		assert.Equal(t, 1, received)
+	})
+
+	t.Run("bad signature is recorded as a failure", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.Challenge(rr, challengeRequest("mock_provider", "abc123", webhookverify.SignChallenge("wrong-secret", "abc123")))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, domain.ProviderRegistrationFailed, regs.regs["mock_provider"].Status)
+		assert.Equal(t, http.StatusForbidden, postEvent("mock_provider"))
+	})
+
+	t.Run("signed challenge is answered and verifies the provider", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.Challenge(rr, challengeRequest("mock_provider", "abc123", webhookverify.SignChallenge(testWebhookSecret, "abc123")))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Data webhookverify.ChallengeReply `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NoError(t, webhookverify.VerifyChallengeReply(testWebhookSecret, "abc123", resp.Data))
+
+		assert.Equal(t, domain.ProviderRegistrationVerified, regs.regs["mock_provider"].Status)
+		assert.Equal(t, http.StatusOK, postEvent("mock_provider"))
+	})
+
+	t.Run("missing challenge and unknown provider", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.Challenge(rr, challengeRequest("mock_provider", "", "sig"))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = httptest.NewRecorder()
+		h.Challenge(rr, challengeRequest("other_provider", "abc123", webhookverify.SignChallenge(testWebhookSecret, "abc123")))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -1,19 +1,22 @@
 package handler
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -22,6 +25,12 @@ import (
 
 const testWebhookSecret = "test-secret-key"
 
+func newTestWebhookHandler(repo *mockWebhookRepo) *WebhookHandler {
+	return NewWebhookHandler(repo, nil, map[string]WebhookVerifier{
+		"mock_provider": HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+	}, nil, "mock_provider", 0)
+}
+
 type mockWebhookRepo struct {
 	created *domain.WebhookEvent
 	err     error
@@ -39,13 +48,16 @@ func signPayload(body, secret string) string {
 }
 
 func validWebhookBody() string {
-	p := webhookPayload{
-		EventID:   uuid.NewString(),
-		PaymentID: uuid.NewString(),
-		Status:    "completed",
-		Timestamp: "2026-02-20T00:00:00Z",
-	}
-	b, _ := json.Marshal(p)
+	return webhookBodyAt("2026-02-20T00:00:00Z")
+}
+
+func webhookBodyAt(timestamp string) string {
+	b, _ := json.Marshal(map[string]string{
+		"event_id":   uuid.NewString(),
+		"payment_id": uuid.NewString(),
+		"status":     "completed",
+		"timestamp":  timestamp,
+	})
 	return string(b)
 }
 
@@ -152,7 +164,7 @@ func TestReceiveProviderWebhook(t *testing.T) {
 			name:       "duplicate webhook returns OK",
 			body:       validWebhookBody(),
 			setupSig:   func(body string) string { return signPayload(body, testWebhookSecret) },
-			repoErr:    &pq.Error{Code: "23505"},
+			repoErr:    domain.ErrDuplicateIdempotencyKey,
 			wantStatus: http.StatusOK,
 		},
 		{
@@ -168,7 +180,7 @@ func TestReceiveProviderWebhook(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := &mockWebhookRepo{err: tc.repoErr}
-			h := NewWebhookHandler(repo, testWebhookSecret)
+			h := newTestWebhookHandler(repo)
 
 			req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(tc.body))
 			if tc.setupSig != nil {
@@ -196,7 +208,7 @@ func TestReceiveProviderWebhook(t *testing.T) {
 
 func TestReceiveProviderWebhook_StoresCorrectEvent(t *testing.T) {
 	repo := &mockWebhookRepo{}
-	h := NewWebhookHandler(repo, testWebhookSecret)
+	h := newTestWebhookHandler(repo)
 
 	body := validWebhookBody()
 	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
@@ -211,4 +223,169 @@ func TestReceiveProviderWebhook_StoresCorrectEvent(t *testing.T) {
 	assert.Equal(t, domain.WebhookEventTypePaymentCompleted, repo.created.EventType)
 	assert.NotEqual(t, uuid.Nil, repo.created.ID)
 	assert.Equal(t, json.RawMessage(body), repo.created.Payload)
+	assert.Equal(t, domain.WebhookPriorityNormal, repo.created.Priority, "without a prioritizer")
+	require.NotNil(t, repo.created.Provider)
+	assert.Equal(t, "mock_provider", *repo.created.Provider, "the default provider")
+}
+
+type stubPrioritizer domain.WebhookPriority
+
+func (s stubPrioritizer) Priority(context.Context, string, domain.WebhookEventType) domain.WebhookPriority {
+	return domain.WebhookPriority(s)
+}
+
+func TestReceiveProviderWebhook_StoresPriority(t *testing.T) {
+	repo := &mockWebhookRepo{}
+	h := NewWebhookHandler(repo, stubPrioritizer(domain.WebhookPriorityHigh), map[string]WebhookVerifier{
+		"mock_provider": HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+	}, nil, "mock_provider", 0)
+
+	body := validWebhookBody()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Signature", signPayload(body, testWebhookSecret))
+	rr := httptest.NewRecorder()
+
+	h.ReceiveProviderWebhook(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, repo.created)
+	assert.Equal(t, domain.WebhookPriorityHigh, repo.created.Priority)
+}
+
+func TestReceiveProviderWebhook_ProviderSpecificVerification(t *testing.T) {
+	repo := &mockWebhookRepo{}
+	h := NewWebhookHandler(repo, nil, map[string]WebhookVerifier{
+		"mock_provider": HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+		"acme":          HMACVerifier("acme-secret", "X-Acme-Signature"),
+	}, nil, "mock_provider", 0)
+
+	tests := []struct {
+		name       string
+		provider   string
+		header     string
+		secret     string
+		wantStatus int
+	}{
+		{name: "default provider", provider: "", header: "X-Webhook-Signature", secret: testWebhookSecret, wantStatus: http.StatusOK},
+		{name: "named provider", provider: "acme", header: "X-Acme-Signature", secret: "acme-secret", wantStatus: http.StatusOK},
+		{name: "named provider with default secret", provider: "acme", header: "X-Acme-Signature", secret: testWebhookSecret, wantStatus: http.StatusUnauthorized},
+		{name: "named provider with default header", provider: "acme", header: "X-Webhook-Signature", secret: "acme-secret", wantStatus: http.StatusUnauthorized},
+		{name: "unknown provider", provider: "nope", header: "X-Webhook-Signature", secret: testWebhookSecret, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := validWebhookBody()
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+			if tc.provider != "" {
+				req.SetPathValue("provider", tc.provider)
+			}
+			req.Header.Set(tc.header, signPayload(body, tc.secret))
+			rr := httptest.NewRecorder()
+
+			repo.created = nil
+			h.ReceiveProviderWebhook(rr, req)
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			if tc.wantStatus == http.StatusOK {
+				want := tc.provider
+				if want == "" {
+					want = "mock_provider"
+				}
+				require.NotNil(t, repo.created)
+				require.NotNil(t, repo.created.Provider)
+				assert.Equal(t, want, *repo.created.Provider, "stored with the provider it was verified against")
+			}
+		})
+	}
+}
+
+func TestReceiveProviderWebhook_TimestampTolerance(t *testing.T) {
+	h := NewWebhookHandler(&mockWebhookRepo{}, nil, map[string]WebhookVerifier{
+		"mock_provider": HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+	}, nil, "mock_provider", 5*time.Minute)
+
+	now := time.Now().UTC()
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "fresh", body: webhookBodyAt(now.Add(-time.Minute).Format(time.RFC3339)), wantStatus: http.StatusOK},
+		{name: "stale", body: webhookBodyAt(now.Add(-time.Hour).Format(time.RFC3339)), wantStatus: http.StatusUnauthorized},
+		{name: "future", body: webhookBodyAt(now.Add(time.Hour).Format(time.RFC3339)), wantStatus: http.StatusUnauthorized},
+		{name: "bad timestamp", body: webhookBodyAt("yesterday"), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(tc.body))
+			req.Header.Set("X-Webhook-Signature", signPayload(tc.body, testWebhookSecret))
+			rr := httptest.NewRecorder()
+
+			h.ReceiveProviderWebhook(rr, req)
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+		})
+	}
+}
+
+func gzipString(t *testing.T, s string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func TestReceiveProviderWebhook_GzipAndSizeLimits(t *testing.T) {
+	body := validWebhookBody()
+	limit := int64(len(body))
+
+	tests := []struct {
+		name       string
+		provider   string
+		gzipped    bool
+		padding    int
+		wantStatus int
+	}{
+		{name: "plain within limit", provider: "acme", wantStatus: http.StatusOK},
+		{name: "gzip within limit", provider: "acme", gzipped: true, wantStatus: http.StatusOK},
+		{name: "plain over provider limit", provider: "acme", padding: 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "gzip inflating over provider limit", provider: "acme", gzipped: true, padding: 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "provider without a limit gets the default", provider: "mock_provider", padding: 1, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockWebhookRepo{}
+			h := NewWebhookHandler(repo, nil, map[string]WebhookVerifier{
+				"mock_provider": HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+				"acme":          HMACVerifier(testWebhookSecret, "X-Webhook-Signature"),
+			}, map[string]int64{"acme": limit}, "mock_provider", 0)
+
+			payload := body + strings.Repeat(" ", tc.padding)
+			var reqBody io.Reader = strings.NewReader(payload)
+			if tc.gzipped {
+				reqBody = gzipString(t, payload)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", reqBody)
+			req.SetPathValue("provider", tc.provider)
+			req.Header.Set("X-Webhook-Signature", signPayload(payload, testWebhookSecret))
+			if tc.gzipped {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			rr := httptest.NewRecorder()
+
+			h.ReceiveProviderWebhook(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			if tc.wantStatus == http.StatusOK {
+				require.NotNil(t, repo.created)
+				assert.Equal(t, json.RawMessage(payload), repo.created.Payload, "stored payload is the inflated JSON")
+			}
+		})
+	}
 }
@@ -0,0 +1,28 @@
+package metrics
+
+type LedgerMetrics struct {
+	verified *CounterVec
+	breaks   *CounterVec
+}
+
+func NewLedgerMetrics(reg *Registry) *LedgerMetrics {
+	return &LedgerMetrics{
+		verified: reg.NewCounterVec(
+			"ledger_chain_entries_verified_total",
+			"Ledger entries checked against the previous entry on their account.",
+		),
+		breaks: reg.NewCounterVec(
+			"ledger_chain_breaks_total",
+			"New ledger entries whose balance_before doesn't match the previous entry's balance_after.",
+			"currency",
+		),
+	}
+}
+
+func (m *LedgerMetrics) EntriesVerified(n int) {
+	m.verified.Add(float64(n))
+}
+
+func (m *LedgerMetrics) ChainBreakDetected(currency string) {
+	m.breaks.Inc(currency)
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestHistogram_CumulativeBuckets(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.NewHistogramVec("test_hist", "test", []float64{10, 1, 5}, "pair")
+
+	h.Observe(0.5, "USD_EUR")
+	h.Observe(3, "USD_EUR")
+	h.Observe(7, "USD_EUR")
+	h.Observe(20, "USD_EUR")
+
+	buckets, count, sum := h.Snapshot("USD_EUR")
+	assert.Equal(t, []uint64{1, 2, 3}, buckets)
+	assert.Equal(t, uint64(4), count)
+	assert.Equal(t, 30.5, sum)
+
+	_, count, _ = h.Snapshot("EUR_GBP")
+	assert.Equal(t, uint64(0), count)
+}
+
+func TestRegistry_WritesPrometheusText(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounterVec("test_total", "a counter", "kind")
+	g := reg.NewGaugeVec("test_gauge", "a gauge", "currency")
+	h := reg.NewHistogramVec("test_hist", "a histogram", []float64{1}, "pair")
+
+	c.Inc("a")
+	c.Add(2, "a")
+	g.Set(5, "USD")
+	g.Set(4, "USD")
+	h.Observe(0.5, "USD_EUR")
+
+	var sb strings.Builder
+	_, err := reg.WriteTo(&sb)
+	require.NoError(t, err)
+	out := sb.String()
+
+	assert.Contains(t, out, "# TYPE test_total counter\n")
+	assert.Contains(t, out, `test_total{kind="a"} 3`)
+	assert.Contains(t, out, "# TYPE test_gauge gauge\n")
+	assert.Contains(t, out, `test_gauge{currency="USD"} 4`)
+	assert.Contains(t, out, "# TYPE test_hist histogram\n")
+	assert.Contains(t, out, `test_hist_bucket{pair="USD_EUR",le="1"} 1`)
+	assert.Contains(t, out, `test_hist_bucket{pair="USD_EUR",le="+Inf"} 1`)
+	assert.Contains(t, out, `test_hist_sum{pair="USD_EUR"} 0.5`)
+	assert.Contains(t, out, `test_hist_count{pair="USD_EUR"} 1`)
+}
+
+func TestPaymentMetrics_RecordsSlippageForConversions(t *testing.T) {
+	reg := NewRegistry()
+	m := NewPaymentMetrics(reg)
+
+	slippage := domain.NewMoney(46, domain.CurrencyEUR)
+	m.PaymentCreated(&domain.Payment{
+		ID:       uuid.New(),
+		Type:     domain.PaymentTypeInternalTransfer,
+		Source:   domain.NewMoney(10000, domain.CurrencyUSD),
+		Dest:     domain.NewMoney(9154, domain.CurrencyEUR),
+		Slippage: &slippage,
+	})
+	m.PaymentCreated(&domain.Payment{
+		ID:     uuid.New(),
+		Type:   domain.PaymentTypeExternalPayout,
+		Source: domain.NewMoney(5000, domain.CurrencyUSD),
+		Dest:   domain.NewMoney(5000, domain.CurrencyUSD),
+	})
+
+	assert.Equal(t, float64(1), m.created.Value("internal_transfer", "USD", "EUR"))
+	assert.Equal(t, float64(1), m.created.Value("external_payout", "USD", "USD"))
+
+	_, count, sum := m.slippage.Snapshot("USD_EUR")
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 50.0, sum)
+
+	_, count, _ = m.slippage.Snapshot("USD_USD")
+	assert.Equal(t, uint64(0), count)
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+var SlippageBpsBuckets = []float64{0, 10, 25, 50, 75, 100, 150, 200, 300, 500}
+
+var BalancePathSecondsBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+type PaymentMetrics struct {
+	created     *CounterVec
+	slippage    *HistogramVec
+	balancePath *CounterVec
+	balanceTime *HistogramVec
+}
+
+func NewPaymentMetrics(reg *Registry) *PaymentMetrics {
+	return &PaymentMetrics{
+		created: reg.NewCounterVec(
+			"payments_created_total",
+			"Payments created, by type and currency pair.",
+			"type", "source_currency", "dest_currency",
+		),
+		slippage: reg.NewHistogramVec(
+			"fx_conversion_slippage_bps",
+			"Difference between the mid-market and effective destination amount per conversion, in basis points of the mid-market amount.",
+			SlippageBpsBuckets,
+			"pair",
+		),
+		balancePath: reg.NewCounterVec(
+			"balance_path_transfers_total",
+			"Same-currency transfers by balance update path (locked or lockless) and outcome.",
+			"path", "outcome",
+		),
+		balanceTime: reg.NewHistogramVec(
+			"balance_path_duration_seconds",
+			"Time to execute a same-currency transfer transaction, by balance update path.",
+			BalancePathSecondsBuckets,
+			"path",
+		),
+	}
+}
+
+func (m *PaymentMetrics) PaymentCreated(p *domain.Payment) {
+	m.created.Inc(string(p.Type), string(p.Source.Currency), string(p.Dest.Currency))
+
+	if bps, ok := p.SlippageBps(); ok {
+		v, _ := bps.Float64()
+		m.slippage.Observe(v, string(p.Source.Currency)+"_"+string(p.Dest.Currency))
+	}
+}
+
+// BalancePathResult records one transfer on the given balance update path.
+// Comparing rates and outcomes across paths is how the lockless rollout is
+// judged.
+func (m *PaymentMetrics) BalancePathResult(path, outcome string, d time.Duration) {
+	m.balancePath.Inc(path, outcome)
+	m.balanceTime.Observe(d.Seconds(), path)
+}
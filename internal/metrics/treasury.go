@@ -0,0 +1,42 @@
+package metrics
+
+type TreasuryMetrics struct {
+	poolBalance *GaugeVec
+	poolLow     *GaugeVec
+	lowAlerts   *CounterVec
+}
+
+func NewTreasuryMetrics(reg *Registry) *TreasuryMetrics {
+	return &TreasuryMetrics{
+		poolBalance: reg.NewGaugeVec(
+			"fx_pool_balance",
+			"FX pool balance in minor units, as of the last treasury check.",
+			"currency",
+		),
+		poolLow: reg.NewGaugeVec(
+			"fx_pool_below_watermark",
+			"1 while the FX pool is below its configured low-watermark.",
+			"currency",
+		),
+		lowAlerts: reg.NewCounterVec(
+			"fx_pool_low_watermark_alerts_total",
+			"Times an FX pool dropped below its low-watermark.",
+			"currency",
+		),
+	}
+}
+
+// PoolChecked records one pool's balance and whether it is below its
+// watermark.
+func (m *TreasuryMetrics) PoolChecked(currency string, balance int64, low bool) {
+	m.poolBalance.Set(float64(balance), currency)
+	v := 0.0
+	if low {
+		v = 1
+	}
+	m.poolLow.Set(v, currency)
+}
+
+func (m *TreasuryMetrics) PoolLowAlerted(currency string) {
+	m.lowAlerts.Inc(currency)
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import "time"
+
+var WebhookWaitSecondsBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+type WebhookMetrics struct {
+	processed *CounterVec
+	wait      *HistogramVec
+}
+
+func NewWebhookMetrics(reg *Registry) *WebhookMetrics {
+	return &WebhookMetrics{
+		processed: reg.NewCounterVec(
+			"webhook_events_processed_total",
+			"Provider callbacks processed, by priority class and outcome (ok, or error when left pending for another attempt).",
+			"priority", "outcome",
+		),
+		wait: reg.NewHistogramVec(
+			"webhook_event_wait_seconds",
+			"Time from a provider callback arriving to its processing, by priority class.",
+			WebhookWaitSecondsBuckets,
+			"priority",
+		),
+	}
+}
+
+// WebhookProcessed records one processing attempt. A low class whose wait
+// keeps growing while the others' doesn't is being starved.
+func (m *WebhookMetrics) WebhookProcessed(priority, outcome string, wait time.Duration) {
+	m.processed.Inc(priority, outcome)
+	m.wait.Observe(wait.Seconds(), priority)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+)
+
+// maxAuditBody is the most of a request body kept in the audit log. Larger
+// bodies are recorded without it.
+const maxAuditBody = 16 << 10
+
+type auditRecorder interface {
+	Record(ctx context.Context, e domain.AuditEntry)
+}
+
+// ClientIP puts the address the request came from into the context, for
+// the audit log, rate limits and handlers. Behind proxies it is read from
+// X-Forwarded-For as far as proxies trusts it.
+func ClientIP(proxies handler.TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.ContextWithClientIP(r.Context(), proxies.ClientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Audit records each request to the wrapped admin route in the audit log,
+// with its route, JSON body and response status, whether it succeeded or
+// not. It goes inside the auth middleware so the admin is known.
+func Audit(recorder auditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			after := map[string]any{
+				"method": r.Method,
+				"route":  r.Pattern,
+				"status": rec.status,
+			}
+			if len(body) > 0 && len(body) <= maxAuditBody && json.Valid(body) {
+				after["request"] = json.RawMessage(body)
+			}
+			recorder.Record(r.Context(), domain.AuditEntry{
+				Action:       domain.AuditActionAdminRequest,
+				ResourceType: "admin_route",
+				ResourceID:   r.URL.Path,
+				After:        after,
+			})
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type recordedAudit struct {
+	entries []domain.AuditEntry
+}
+
+func (r *recordedAudit) Record(_ context.Context, e domain.AuditEntry) {
+	r.entries = append(r.entries, e)
+}
+
+func TestAudit_RecordsRequestAndKeepsBody(t *testing.T) {
+	rec := &recordedAudit{}
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		seen = string(b)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("PUT /api/v1/admin/users/{id}/limits/{currency}", Audit(rec)(next))
+	body := `{"tx_limit":500,"reason":"fraud review"}`
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/u1/limits/USD", strings.NewReader(body)))
+
+	assert.Equal(t, body, seen, "the handler still reads the whole body")
+	require.Len(t, rec.entries, 1)
+	e := rec.entries[0]
+	assert.Equal(t, domain.AuditActionAdminRequest, e.Action)
+	assert.Equal(t, "/api/v1/admin/users/u1/limits/USD", e.ResourceID)
+
+	after, err := json.Marshal(e.After)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"method": "PUT",
+		"route": "PUT /api/v1/admin/users/{id}/limits/{currency}",
+		"status": 422,
+		"request": {"tx_limit": 500, "reason": "fraud review"}
+	}`, string(after))
+}
+
+func TestAudit_LeavesOutLargeBodies(t *testing.T) {
+	rec := &recordedAudit{}
+	var n int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		n = len(b)
+	})
+
+	body := `{"note":"` + strings.Repeat("x", maxAuditBody) + `"}`
+	Audit(rec)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/admin/denylist", strings.NewReader(body)))
+
+	assert.Equal(t, len(body), n)
+	require.Len(t, rec.entries, 1)
+	assert.NotContains(t, rec.entries[0].After, "request")
+}
@@ -1,36 +1,147 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 )
 
-func Auth(secret string) func(http.Handler) http.Handler {
+type apiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*domain.APIKey, error)
+}
+
+// Auth admits requests with a valid JWT issued at the user's current
+// session version, which goes up when the password changes, so a password
+// reset ends every earlier session. API keys are turned away; routes that
+// accept them use AuthOrAPIKey.
+func Auth(secret string, sessions sessionVersionLookup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			header := r.Header.Get("Authorization")
-			if header == "" {
-				handler.RespondAppError(w, handler.ErrMissingToken, nil)
+			token, appErr := bearerToken(r)
+			if appErr != nil {
+				handler.RespondAppError(w, appErr, nil)
+				return
+			}
+			if strings.HasPrefix(token, domain.APIKeyPrefix) {
+				handler.RespondAppError(w, handler.ErrAPIKeyNotAllowed, nil)
 				return
 			}
 
-			token, found := strings.CutPrefix(header, "Bearer ")
-			if !found || token == "" {
+			claims, err := auth.ValidateToken(token, secret)
+			if err != nil {
 				handler.RespondAppError(w, handler.ErrInvalidToken, nil)
 				return
 			}
 
-			claims, err := auth.ValidateToken(token, secret)
+			version, err := sessions.SessionVersion(r.Context(), claims.UserID)
 			if err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					handler.RespondAppError(w, handler.ErrInvalidToken, nil)
+					return
+				}
+				logging.FromContext(r.Context()).Error("session check failed", "error", err)
+				handler.RespondAppError(w, handler.ErrInternalError, nil)
+				return
+			}
+			if claims.SessionVersion < version {
 				handler.RespondAppError(w, handler.ErrInvalidToken, nil)
 				return
 			}
 
 			ctx := auth.ContextWithUserID(r.Context(), claims.UserID)
+			ctx = auth.ContextWithRole(ctx, claims.Role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthOrAPIKey admits a JWT as Auth does, or an API key that has scope. A
+// key acts as its owner with the user role, whatever the owner's role is, so
+// a staff member's key can't reach staff endpoints.
+func AuthOrAPIKey(secret string, sessions sessionVersionLookup, keys apiKeyAuthenticator, scope domain.APIKeyScope) func(http.Handler) http.Handler {
+	jwtAuth := Auth(secret, sessions)
+	return func(next http.Handler) http.Handler {
+		withJWT := jwtAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, appErr := bearerToken(r)
+			if appErr != nil || !strings.HasPrefix(token, domain.APIKeyPrefix) {
+				withJWT.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			key, err := keys.Authenticate(ctx, token)
+			if err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					handler.RespondAppError(w, handler.ErrInvalidAPIKey, nil)
+					return
+				}
+				logging.FromContext(ctx).Error("api key lookup failed", "error", err)
+				handler.RespondAppError(w, handler.ErrInternalError, nil)
+				return
+			}
+			if !key.HasScope(scope) {
+				handler.RespondAppError(w, handler.ErrAPIKeyScope, nil)
+				return
+			}
+
+			log := logging.FromContext(ctx).With("api_key_id", key.ID)
+			log.Info("api key used", "user_id", key.UserID, "scope", scope, "method", r.Method, "path", r.URL.Path)
+
+			ctx = auth.ContextWithUserID(ctx, key.UserID)
+			ctx = auth.ContextWithRole(ctx, domain.UserRoleUser)
+			ctx = logging.WithLogger(ctx, log)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func bearerToken(r *http.Request) (string, *handler.AppError) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", handler.ErrMissingToken
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return "", handler.ErrInvalidToken
+	}
+	return token, nil
+}
+
+func RequireStaff(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RoleFromContext(r.Context()).IsStaff() {
+			handler.RespondAppError(w, handler.ErrForbidden, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RoleFromContext(r.Context()).IsAdmin() {
+			handler.RespondAppError(w, handler.ErrForbidden, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePaymentOps admits only the payment_ops role, for settling payouts
+// by hand. Plain admins get 403.
+func RequirePaymentOps(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.RoleFromContext(r.Context()) != domain.UserRolePaymentOps {
+			handler.RespondAppError(w, handler.ErrForbidden, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
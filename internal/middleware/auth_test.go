@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const testJWTSecret = "test-jwt-secret"
+
+type stubAPIKeys map[string]*domain.APIKey
+
+func (s stubAPIKeys) Authenticate(_ context.Context, key string) (*domain.APIKey, error) {
+	if k, ok := s[key]; ok {
+		return k, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// stubSessions maps users to their session version; users missing from it
+// don't exist.
+type stubSessions map[uuid.UUID]int
+
+func (s stubSessions) SessionVersion(_ context.Context, userID uuid.UUID) (int, error) {
+	if v, ok := s[userID]; ok {
+		return v, nil
+	}
+	return 0, domain.ErrNotFound
+}
+
+func TestAuthOrAPIKey(t *testing.T) {
+	staffID := uuid.New()
+	keys := stubAPIKeys{
+		"grey_000000000001_reader": {ID: uuid.New(), UserID: staffID, Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}},
+	}
+	jwt, err := auth.GenerateToken(staffID, "ops@test.com", domain.UserRoleSupport, 0, testJWTSecret, time.Hour)
+	require.NoError(t, err)
+
+	var gotUser uuid.UUID
+	var gotRole domain.UserRole
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = auth.UserIDFromContext(r.Context())
+		gotRole = auth.RoleFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func(mw func(http.Handler) http.Handler, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/fx/rates", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w
+	}
+
+	sessions := stubSessions{staffID: 0}
+	read := AuthOrAPIKey(testJWTSecret, sessions, keys, domain.APIKeyScopeRead)
+	write := AuthOrAPIKey(testJWTSecret, sessions, keys, domain.APIKeyScopePaymentsWrite)
+	jwtOnly := Auth(testJWTSecret, sessions)
+
+	w := send(read, "grey_000000000001_reader")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, staffID, gotUser)
+	assert.Equal(t, domain.UserRoleUser, gotRole, "keys never carry staff roles")
+
+	w = send(read, jwt)
+	assert.Equal(t, http.StatusOK, w.Code, "sessions are still accepted")
+	assert.Equal(t, domain.UserRoleSupport, gotRole)
+
+	w = send(write, "grey_000000000001_reader")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+
+	w = send(read, "grey_000000000002_unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_API_KEY")
+
+	w = send(read, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "MISSING_TOKEN")
+
+	w = send(jwtOnly, "grey_000000000001_reader")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "API_KEY_NOT_ALLOWED")
+}
+
+func TestAuth_PasswordChangeEndsSessions(t *testing.T) {
+	userID, goneID := uuid.New(), uuid.New()
+	token := func(id uuid.UUID, version int) string {
+		tok, err := auth.GenerateToken(id, "alice@test.com", domain.UserRoleUser, version, testJWTSecret, time.Hour)
+		require.NoError(t, err)
+		return tok
+	}
+
+	send := func(sessions stubSessions, token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		Auth(testJWTSecret, sessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(stubSessions{userID: 0}, token(userID, 0)), "password never changed")
+	assert.Equal(t, http.StatusOK, send(stubSessions{userID: 2}, token(userID, 2)), "issued after the change")
+	assert.Equal(t, http.StatusUnauthorized, send(stubSessions{userID: 2}, token(userID, 1)), "issued before the change")
+	assert.Equal(t, http.StatusUnauthorized, send(stubSessions{userID: 1}, token(userID, 0)),
+		"issued in the same second as the change, or before the claim existed")
+	assert.Equal(t, http.StatusUnauthorized, send(stubSessions{userID: 0}, token(goneID, 0)), "unknown user")
+}
+
+// countingSessions counts lookups and returns the current version.
+type countingSessions struct {
+	version int
+	calls   int
+}
+
+func (s *countingSessions) SessionVersion(context.Context, uuid.UUID) (int, error) {
+	s.calls++
+	return s.version, nil
+}
+
+func TestSessionVersions_CachesForTTL(t *testing.T) {
+	lookup := &countingSessions{version: 1}
+	sessions := NewSessionVersions(lookup, 30*time.Second)
+	now := time.Now()
+	sessions.now = func() time.Time { return now }
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for range 3 {
+		v, err := sessions.SessionVersion(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.Equal(t, 1, lookup.calls, "repeat requests are served from the cache")
+
+	lookup.version = 2
+	now = now.Add(31 * time.Second)
+	v, err := sessions.SessionVersion(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v, "an expired entry is read again")
+	assert.Equal(t, 2, lookup.calls)
+
+	uncached := NewSessionVersions(lookup, 0)
+	_, err = uncached.SessionVersion(ctx, userID)
+	require.NoError(t, err)
+	_, err = uncached.SessionVersion(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, lookup.calls, "a zero ttl reads every time")
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/capture"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type captureStore interface {
+	Save(e *capture.Exchange) (string, error)
+}
+
+// Capture saves every request under one of the route prefixes, and the
+// response it got, to store for replaying later with cmd/replay.
+// Credentials are redacted before anything is written. It is a development
+// tool; the app won't install it in production.
+func Capture(store captureStore, prefixes []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !capturedPath(r.URL.Path, prefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, capture.MaxBody+1))
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			}
+
+			rec := &captureRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			e := &capture.Exchange{
+				ID:         uuid.New(),
+				CapturedAt: start.UTC(),
+				RequestID:  TraceIDFromContext(r.Context()),
+				DurationMS: time.Since(start).Milliseconds(),
+				Request: capture.Request{
+					Method: r.Method,
+					URL:    r.URL.RequestURI(),
+					Header: capture.RedactHeader(r.Header),
+				},
+				Response: capture.Response{
+					Status: rec.status,
+					Header: capture.RedactHeader(w.Header()),
+				},
+			}
+			e.Request.SetBody(body)
+			e.Response.SetBody(rec.body.Bytes())
+			if rec.truncated {
+				e.Response.Truncated = true
+			}
+
+			path, err := store.Save(e)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("failed to save request capture", "error", err)
+				return
+			}
+			logging.FromContext(r.Context()).Debug("request captured", "capture_id", e.ID, "file", path)
+		})
+	}
+}
+
+func capturedPath(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureRecorder passes the response through and keeps a copy of it, up
+// to capture.MaxBody.
+type captureRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *captureRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *captureRecorder) Write(b []byte) (int, error) {
+	if room := capture.MaxBody - r.body.Len(); room > 0 {
+		r.body.Write(b[:min(len(b), room)])
+		r.truncated = r.truncated || len(b) > room
+	} else if len(b) > 0 {
+		r.truncated = true
+	}
+	return r.ResponseWriter.Write(b)
+}
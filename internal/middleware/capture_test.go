@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/capture"
+)
+
+type recordedCaptures struct {
+	exchanges []*capture.Exchange
+}
+
+func (r *recordedCaptures) Save(e *capture.Exchange) (string, error) {
+	r.exchanges = append(r.exchanges, e)
+	return "", nil
+}
+
+func TestCapture_SavesSelectedRoutesRedacted(t *testing.T) {
+	store := &recordedCaptures{}
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		seen = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":"p1","access_token":"abc"}}`))
+	})
+	h := Tracing(Capture(store, []string{"/api/v1/payments"})(next))
+
+	body := `{"amount":500,"password":"hunter2"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments/external?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-jwt")
+	req.Header.Set("Idempotency-Key", "k1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, body, seen, "the handler still reads the whole body")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"data":{"id":"p1","access_token":"abc"}}`, rec.Body.String(), "the client gets the real response")
+
+	require.Len(t, store.exchanges, 1)
+	e := store.exchanges[0]
+	assert.Equal(t, http.MethodPost, e.Request.Method)
+	assert.Equal(t, "/api/v1/payments/external?dry_run=true", e.Request.URL)
+	assert.Equal(t, capture.Redacted, e.Request.Header.Get("Authorization"))
+	assert.Equal(t, "k1", e.Request.Header.Get("Idempotency-Key"))
+	assert.JSONEq(t, `{"amount":500,"password":"REDACTED"}`, string(e.Request.Body))
+	assert.Equal(t, http.StatusCreated, e.Response.Status)
+	assert.JSONEq(t, `{"data":{"id":"p1","access_token":"REDACTED"}}`, string(e.Response.Body))
+	assert.Equal(t, rec.Header().Get(traceIDHeader), e.RequestID)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil))
+	assert.Len(t, store.exchanges, 1, "routes not selected are not captured")
+}
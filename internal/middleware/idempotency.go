@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"path"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/deadline"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
@@ -23,7 +27,7 @@ type idempotencyRepository interface {
 
 const idempotencyTTL = 24 * time.Hour
 
-func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
+func Idempotency(repo idempotencyRepository, cfg IdempotencyConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
@@ -32,9 +36,20 @@ func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
 			}
 
 			key := r.Header.Get("Idempotency-Key")
-			if key == "" {
+			switch {
+			case key == "" && cfg.GenerateMissing:
+				key = uuid.NewString()
+				r.Header.Set("Idempotency-Key", key)
+				w.Header().Set("Idempotency-Key", key)
+				w.Header().Set("X-Idempotency-Key-Generated", "true")
+			case key == "":
 				handler.RespondAppError(w, handler.ErrMissingIdempotencyKey, nil)
 				return
+			default:
+				if reason := cfg.check(key); reason != "" {
+					handler.RespondAppError(w, handler.ErrInvalidIdempotencyKey, []handler.FieldError{{Field: "Idempotency-Key", Message: reason}})
+					return
+				}
 			}
 
 			userID, ok := auth.UserIDFromContext(r.Context())
@@ -51,6 +66,7 @@ func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
 			r.Body = io.NopCloser(bytes.NewReader(body))
 
 			reqHash := computeHash(r.Method, r.URL.Path, body)
+			deadline.Stage(r.Context(), "idempotency")
 
 			cached, err := repo.Get(r.Context(), key, userID)
 			if err != nil {
@@ -62,11 +78,14 @@ func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
 
 			if cached != nil {
 				if cached.RequestHash != reqHash {
-					handler.RespondAppError(w, handler.ErrIdempotencyConflict, nil)
+					handler.RespondAppError(w, handler.ErrIdempotencyConflict, conflictDetails(cached, r, body))
 					return
 				}
 
 				w.Header().Set("Content-Type", "application/json")
+				if cached.ResourceLocation != "" {
+					w.Header().Set("Location", cached.ResourceLocation)
+				}
 				w.Header().Set("X-Idempotent-Replayed", "true")
 				w.WriteHeader(cached.StatusCode)
 				if _, err := w.Write(cached.ResponseBody); err != nil {
@@ -77,8 +96,22 @@ func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
 			}
 
 			rec := &responseRecorder{ResponseWriter: w, body: &bytes.Buffer{}, statusCode: http.StatusOK}
+			deadline.Stage(r.Context(), "handler")
 			next.ServeHTTP(rec, r)
 
+			// A 401 means nothing was done and the client is expected to
+			// retry with credentials, such as an authenticator code, under
+			// the same key.
+			if rec.statusCode == http.StatusUnauthorized {
+				return
+			}
+			// Nor is a failure caused by the client's X-Request-Timeout: the
+			// work was cut short, so a retry under the same key runs it again.
+			// A payment that did commit is caught by its own idempotency key.
+			if rec.statusCode >= http.StatusInternalServerError && expired(r.Context()) {
+				return
+			}
+
 			entry := &repository.IdempotencyCacheEntry{
 				Key:          key,
 				UserID:       userID,
@@ -87,8 +120,14 @@ func Idempotency(repo idempotencyRepository) func(http.Handler) http.Handler {
 				ResponseBody: rec.body.Bytes(),
 				CreatedAt:    time.Now().UTC(),
 				ExpiresAt:    time.Now().UTC().Add(idempotencyTTL),
+
+				RequestMethod:    r.Method,
+				RequestPath:      r.URL.Path,
+				RequestFields:    fieldHashes(body),
+				ResourceLocation: w.Header().Get("Location"),
 			}
-			if err := repo.Set(r.Context(), entry); err != nil {
+			// A response that finished after the deadline is still stored.
+			if err := repo.Set(context.WithoutCancel(r.Context()), entry); err != nil {
 				log := logging.FromContext(r.Context())
 				log.Error("idempotency cache store failed", "error", err, "idempotency_key", key)
 			}
@@ -104,6 +143,90 @@ func computeHash(method, path string, body []byte) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// idempotencyConflictDetails points a client that reused a key with a
+// different request at what the key was first used for.
+type idempotencyConflictDetails struct {
+	OriginalID     string `json:"original_id,omitempty"`
+	OriginalURL    string `json:"original_url,omitempty"`
+	OriginalStatus int    `json:"original_status"`
+	// Set only when the key was first used on another endpoint.
+	OriginalMethod string `json:"original_method,omitempty"`
+	OriginalPath   string `json:"original_path,omitempty"`
+	// Top-level body fields added, removed or changed since the first
+	// request. Left out when the bodies can't be compared field by field.
+	DifferingFields []string `json:"differing_fields,omitempty"`
+}
+
+func conflictDetails(cached *repository.IdempotencyCacheEntry, r *http.Request, body []byte) idempotencyConflictDetails {
+	d := idempotencyConflictDetails{
+		OriginalID:     resourceID(cached),
+		OriginalURL:    cached.ResourceLocation,
+		OriginalStatus: cached.StatusCode,
+	}
+	if cached.RequestMethod != "" && (cached.RequestMethod != r.Method || cached.RequestPath != r.URL.Path) {
+		d.OriginalMethod = cached.RequestMethod
+		d.OriginalPath = cached.RequestPath
+		return d
+	}
+
+	var before, after map[string]string
+	if json.Unmarshal(cached.RequestFields, &before) != nil || json.Unmarshal(fieldHashes(body), &after) != nil {
+		return d
+	}
+	for name, h := range after {
+		if before[name] != h {
+			d.DifferingFields = append(d.DifferingFields, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			d.DifferingFields = append(d.DifferingFields, name)
+		}
+	}
+	sort.Strings(d.DifferingFields)
+	return d
+}
+
+// resourceID is the id of what the original request created: data.id in
+// its response, or failing that the last segment of its Location.
+func resourceID(cached *repository.IdempotencyCacheEntry) string {
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(cached.ResponseBody, &resp) == nil && resp.Data.ID != "" {
+		return resp.Data.ID
+	}
+	if cached.ResourceLocation != "" {
+		return path.Base(cached.ResourceLocation)
+	}
+	return ""
+}
+
+// fieldHashes maps each top-level field of a JSON object body to a hash of
+// its value, so a later request can be compared field by field without the
+// cache holding the request itself. It returns nil for any other body.
+func fieldHashes(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil || fields == nil {
+		return nil
+	}
+	hashes := make(map[string]string, len(fields))
+	for name, v := range fields {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, v); err != nil {
+			return nil
+		}
+		hashes[name] = fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+	}
+	out, err := json.Marshal(hashes)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyConfig struct {
+	RequireUUID    bool
+	MinEntropyBits float64
+	MaxKeyLength   int
+	// GenerateMissing lets the server mint a key when the client sends none.
+	// Only enable it on endpoints where a blind client retry is harmless.
+	GenerateMissing bool
+}
+
+// WithGeneratedKeys returns a copy of the policy that mints missing keys.
+func (c IdempotencyConfig) WithGeneratedKeys() IdempotencyConfig {
+	c.GenerateMissing = true
+	return c
+}
+
+// check returns a client-facing reason when the key does not meet the policy.
+func (c IdempotencyConfig) check(key string) string {
+	if c.MaxKeyLength > 0 && len(key) > c.MaxKeyLength {
+		return fmt.Sprintf("must be at most %d characters", c.MaxKeyLength)
+	}
+	if c.RequireUUID {
+		if _, err := uuid.Parse(key); err != nil {
+			return "must be a UUID"
+		}
+	}
+	if c.MinEntropyBits > 0 && keyEntropyBits(key) < c.MinEntropyBits {
+		return "is too predictable, use a random value such as a UUID v4"
+	}
+	return ""
+}
+
+// keyEntropyBits estimates the key's entropy as its length times the Shannon
+// entropy of its character distribution. Repeated or sequential-looking keys
+// like "aaaa" or "order-1" score low; random UUIDs score well above 100.
+func keyEntropyBits(key string) float64 {
+	if key == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range key {
+		counts[r]++
+		n++
+	}
+	var perChar float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(n)
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+type memIdempotencyRepo struct {
+	entries map[string]*repository.IdempotencyCacheEntry
+}
+
+func (m *memIdempotencyRepo) Get(_ context.Context, key string, userID uuid.UUID) (*repository.IdempotencyCacheEntry, error) {
+	return m.entries[key+userID.String()], nil
+}
+
+func (m *memIdempotencyRepo) Set(_ context.Context, e *repository.IdempotencyCacheEntry) error {
+	m.entries[e.Key+e.UserID.String()] = e
+	return nil
+}
+
+func TestIdempotencyConfig_Check(t *testing.T) {
+	policy := IdempotencyConfig{MinEntropyBits: 64, MaxKeyLength: 64}
+
+	tests := []struct {
+		name string
+		cfg  IdempotencyConfig
+		key  string
+		ok   bool
+	}{
+		{name: "uuid", cfg: policy, key: uuid.NewString(), ok: true},
+		{name: "random token", cfg: policy, key: "k9Xq2LmP7vRt4WzB8nYc", ok: true},
+		{name: "repeated chars", cfg: policy, key: strings.Repeat("a", 40), ok: false},
+		{name: "short sequential", cfg: policy, key: "order-1", ok: false},
+		{name: "too long", cfg: policy, key: strings.Repeat(uuid.NewString(), 2), ok: false},
+		{name: "uuid required", cfg: IdempotencyConfig{RequireUUID: true}, key: "k9Xq2LmP7vRt4WzB8nYc", ok: false},
+		{name: "no policy", cfg: IdempotencyConfig{}, key: "1", ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ok, tc.cfg.check(tc.key) == "")
+		})
+	}
+}
+
+func TestIdempotency_KeyPolicy(t *testing.T) {
+	userID := uuid.New()
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.NotEmpty(t, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusCreated)
+	})
+	policy := IdempotencyConfig{MinEntropyBits: 64, MaxKeyLength: 128}
+
+	send := func(mw func(http.Handler) http.Handler, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/things", strings.NewReader(`{}`))
+		r = r.WithContext(auth.ContextWithUserID(r.Context(), userID))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w
+	}
+
+	strict := Idempotency(&memIdempotencyRepo{entries: map[string]*repository.IdempotencyCacheEntry{}}, policy)
+
+	w := send(strict, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "MISSING_IDEMPOTENCY_KEY")
+
+	w = send(strict, "abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_IDEMPOTENCY_KEY")
+	assert.Equal(t, 0, calls)
+
+	lenient := Idempotency(&memIdempotencyRepo{entries: map[string]*repository.IdempotencyCacheEntry{}}, policy.WithGeneratedKeys())
+
+	w = send(lenient, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	generated := w.Header().Get("Idempotency-Key")
+	_, err := uuid.Parse(generated)
+	require.NoError(t, err)
+	assert.Equal(t, "true", w.Header().Get("X-Idempotency-Key-Generated"))
+
+	w = send(lenient, generated)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	w = send(lenient, "abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "client-supplied keys are still checked")
+}
+
+func TestIdempotency_ChallengeNotCached(t *testing.T) {
+	userID := uuid.New()
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-TOTP-Code") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mw := Idempotency(&memIdempotencyRepo{entries: map[string]*repository.IdempotencyCacheEntry{}}, IdempotencyConfig{})
+	key := uuid.NewString()
+
+	send := func(code string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/payments/external", strings.NewReader(`{}`))
+		r = r.WithContext(auth.ContextWithUserID(r.Context(), userID))
+		r.Header.Set("Idempotency-Key", key)
+		if code != "" {
+			r.Header.Set("X-TOTP-Code", code)
+		}
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("").Code)
+	w := send("123456")
+	assert.Equal(t, http.StatusAccepted, w.Code, "the retry with a code is not answered from the cache")
+	assert.Empty(t, w.Header().Get("X-Idempotent-Replayed"))
+
+	w = send("123456")
+	assert.Equal(t, "true", w.Header().Get("X-Idempotent-Replayed"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ConflictPointsAtOriginal(t *testing.T) {
+	userID := uuid.New()
+	paymentID := uuid.NewString()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/api/v1/payments/"+paymentID)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":"` + paymentID + `"}}`))
+	})
+	mw := Idempotency(&memIdempotencyRepo{entries: map[string]*repository.IdempotencyCacheEntry{}}, IdempotencyConfig{})
+	key := uuid.NewString()
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r = r.WithContext(auth.ContextWithUserID(r.Context(), userID))
+		r.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusCreated, send("/api/v1/payments", `{"amount":1000,"currency":"USD","note":"rent"}`).Code)
+
+	w := send("/api/v1/payments", `{"amount":1000,"currency":"USD","note":"rent"}`)
+	assert.Equal(t, "true", w.Header().Get("X-Idempotent-Replayed"))
+	assert.Equal(t, "/api/v1/payments/"+paymentID, w.Header().Get("Location"))
+
+	var resp struct {
+		Error struct {
+			Code    string                     `json:"code"`
+			Details idempotencyConflictDetails `json:"details"`
+		} `json:"error"`
+	}
+	w = send("/api/v1/payments", `{"amount": 2000, "currency":"USD", "memo":"rent"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "IDEMPOTENCY_CONFLICT", resp.Error.Code)
+	assert.Equal(t, idempotencyConflictDetails{
+		OriginalID:      paymentID,
+		OriginalURL:     "/api/v1/payments/" + paymentID,
+		OriginalStatus:  http.StatusCreated,
+		DifferingFields: []string{"amount", "memo", "note"},
+	}, resp.Error.Details, "currency is unchanged, and whitespace doesn't count")
+
+	w = send("/api/v1/payments/external", `{"amount":1000,"currency":"USD","note":"rent"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	resp.Error.Details = idempotencyConflictDetails{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "/api/v1/payments", resp.Error.Details.OriginalPath)
+	assert.Equal(t, http.MethodPost, resp.Error.Details.OriginalMethod)
+	assert.Empty(t, resp.Error.Details.DifferingFields)
+}
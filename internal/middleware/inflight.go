@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type inFlightTracker interface {
+	Track(kind domain.InFlightKind, ref string) func()
+}
+
+// InFlight registers each request with the tracker so the shutdown report
+// can name requests that were cut off. Must run inside Tracing.
+func InFlight(tracker inFlightTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := tracker.Track(domain.InFlightRequest, r.Method+" "+r.URL.Path+" "+TraceIDFromContext(r.Context()))
+			defer done()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityCritical
+)
+
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "critical":
+		return PriorityCritical, true
+	default:
+		return PriorityNormal, false
+	}
+}
+
+// PoolStats counts the acquires that had to wait for a free connection and
+// the total time they waited.
+type PoolStats struct {
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+type poolStatter interface {
+	Stats() PoolStats
+}
+
+// PgxPoolStats reads a pgx pool's acquire waits for LoadShedding.
+type PgxPoolStats struct {
+	Pool *pgxpool.Pool
+}
+
+func (p PgxPoolStats) Stats() PoolStats {
+	s := p.Pool.Stat()
+	return PoolStats{WaitCount: s.EmptyAcquireCount(), WaitDuration: s.EmptyAcquireWaitTime()}
+}
+
+type LoadShedConfig struct {
+	SoftInFlight int
+	HardInFlight int
+	MaxPoolWait  time.Duration
+	RetryAfter   time.Duration
+	// Keys are "METHOD /path/prefix" or "/path/prefix"; the longest match wins.
+	Priorities map[string]Priority
+}
+
+var DefaultPriorities = map[string]Priority{
+	"/health":                 PriorityCritical,
+	"POST /api/v1/webhooks/":  PriorityCritical,
+	"GET /api/v1/users/":      PriorityLow,
+	"GET /api/v1/admin/":      PriorityLow,
+	"GET /api/v1/fx/rates":    PriorityLow,
+	"POST /api/v1/payments":   PriorityNormal,
+	"POST /api/v1/auth/login": PriorityNormal,
+}
+
+func MergePriorities(overrides map[string]string) (map[string]Priority, error) {
+	merged := make(map[string]Priority, len(DefaultPriorities)+len(overrides))
+	for k, v := range DefaultPriorities {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		p, ok := ParsePriority(v)
+		if !ok {
+			return nil, fmt.Errorf("MergePriorities: invalid priority %q for %q", v, k)
+		}
+		merged[k] = p
+	}
+	return merged, nil
+}
+
+type priorityRule struct {
+	method   string
+	prefix   string
+	priority Priority
+}
+
+type loadShedder struct {
+	cfg      LoadShedConfig
+	pool     poolStatter
+	rules    []priorityRule
+	inFlight atomic.Int64
+
+	mu            sync.Mutex
+	lastSample    time.Time
+	lastWaitCount int64
+	lastWaitDur   time.Duration
+	avgWait       time.Duration
+}
+
+func LoadShedding(cfg LoadShedConfig, pool poolStatter) func(http.Handler) http.Handler {
+	ls := &loadShedder{cfg: cfg, pool: pool, rules: compileRules(cfg.Priorities)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			priority := ls.classify(r)
+			current := ls.inFlight.Add(1)
+			defer ls.inFlight.Add(-1)
+
+			if ls.shouldShed(priority, current) {
+				slog.Warn("request shed under load",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"priority", priority,
+					"in_flight", current,
+					"pool_wait_ms", ls.poolWait().Milliseconds(),
+				)
+				w.Header().Set("Retry-After", strconv.Itoa(int(ls.cfg.RetryAfter.Seconds())))
+				handler.RespondAppError(w, handler.ErrServiceOverloaded, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func compileRules(priorities map[string]Priority) []priorityRule {
+	rules := make([]priorityRule, 0, len(priorities))
+	for key, p := range priorities {
+		method, prefix, found := strings.Cut(strings.TrimSpace(key), " ")
+		if !found {
+			method, prefix = "", method
+		}
+		rules = append(rules, priorityRule{method: strings.ToUpper(method), prefix: strings.TrimSpace(prefix), priority: p})
+	}
+	return rules
+}
+
+func (ls *loadShedder) classify(r *http.Request) Priority {
+	best := PriorityNormal
+	bestLen := -1
+	for _, rule := range ls.rules {
+		if rule.method != "" && rule.method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, rule.prefix) {
+			continue
+		}
+		if len(rule.prefix) > bestLen || (len(rule.prefix) == bestLen && rule.method != "") {
+			best = rule.priority
+			bestLen = len(rule.prefix)
+		}
+	}
+	return best
+}
+
+func (ls *loadShedder) shouldShed(p Priority, inFlight int64) bool {
+	if p == PriorityCritical {
+		return false
+	}
+	if ls.cfg.HardInFlight > 0 && inFlight > int64(ls.cfg.HardInFlight) {
+		return true
+	}
+	if p != PriorityLow {
+		return false
+	}
+	if ls.cfg.SoftInFlight > 0 && inFlight > int64(ls.cfg.SoftInFlight) {
+		return true
+	}
+	return ls.cfg.MaxPoolWait > 0 && ls.poolWait() > ls.cfg.MaxPoolWait
+}
+
+func (ls *loadShedder) poolWait() time.Duration {
+	if ls.pool == nil {
+		return 0
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(ls.lastSample) < time.Second {
+		return ls.avgWait
+	}
+
+	stats := ls.pool.Stats()
+	waits := stats.WaitCount - ls.lastWaitCount
+	if waits > 0 {
+		ls.avgWait = (stats.WaitDuration - ls.lastWaitDur) / time.Duration(waits)
+	} else {
+		ls.avgWait = 0
+	}
+	ls.lastSample = now
+	ls.lastWaitCount = stats.WaitCount
+	ls.lastWaitDur = stats.WaitDuration
+	return ls.avgWait
+}
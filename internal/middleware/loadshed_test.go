@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePool struct {
+	mu    sync.Mutex
+	stats PoolStats
+}
+
+func (f *fakePool) Stats() PoolStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+func TestLoadShedding_Classify(t *testing.T) {
+	ls := &loadShedder{rules: compileRules(DefaultPriorities)}
+
+	tests := []struct {
+		method string
+		path   string
+		want   Priority
+	}{
+		{http.MethodGet, "/health/ready", PriorityCritical},
+		{http.MethodPost, "/api/v1/webhooks/provider", PriorityCritical},
+		{http.MethodGet, "/api/v1/users/abc/accounts", PriorityLow},
+		{http.MethodPost, "/api/v1/users/abc/accounts", PriorityNormal},
+		{http.MethodPost, "/api/v1/payments/external", PriorityNormal},
+		{http.MethodGet, "/api/v1/payments/abc", PriorityNormal},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			assert.Equal(t, tc.want, ls.classify(r))
+		})
+	}
+}
+
+func TestLoadShedding_ShedsByPriority(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+
+	mw := LoadShedding(LoadShedConfig{
+		SoftInFlight: 1,
+		HardInFlight: 2,
+		RetryAfter:   3 * time.Second,
+		Priorities:   DefaultPriorities,
+	}, nil)
+	h := mw(blocking)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil))
+		}()
+		<-started
+	}
+
+	low := httptest.NewRecorder()
+	h.ServeHTTP(low, httptest.NewRequest(http.MethodGet, "/api/v1/users/abc/accounts", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, low.Code)
+	assert.Equal(t, "3", low.Header().Get("Retry-After"))
+
+	normal := httptest.NewRecorder()
+	h.ServeHTTP(normal, httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, normal.Code)
+
+	critical := httptest.NewRecorder()
+	go func() { <-started }()
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(critical, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/provider", nil))
+		close(done)
+	}()
+
+	close(release)
+	<-done
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, critical.Code)
+}
+
+func TestLoadShedding_PoolWait(t *testing.T) {
+	pool := &fakePool{}
+	mw := LoadShedding(LoadShedConfig{MaxPoolWait: 50 * time.Millisecond, RetryAfter: time.Second, Priorities: DefaultPriorities}, pool)
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	pool.stats = PoolStats{WaitCount: 10, WaitDuration: 2 * time.Second}
+
+	low := httptest.NewRecorder()
+	h.ServeHTTP(low, httptest.NewRequest(http.MethodGet, "/api/v1/fx/rates", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, low.Code)
+
+	normal := httptest.NewRecorder()
+	h.ServeHTTP(normal, httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil))
+	assert.Equal(t, http.StatusOK, normal.Code)
+}
+
+func TestMergePriorities(t *testing.T) {
+	merged, err := MergePriorities(map[string]string{"GET /api/v1/fx/rates": "normal"})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityNormal, merged["GET /api/v1/fx/rates"])
+	assert.Equal(t, PriorityCritical, merged["/health"])
+
+	_, err = MergePriorities(map[string]string{"/x": "urgent"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// RequireOwner guards /users/{id} routes: the caller must be the user in
+// the path, or an admin. Anyone else, and an {id} that isn't a UUID, gets
+// 404 rather than 403, so user IDs can't be probed. It runs after Auth or
+// AuthOrAPIKey; API keys always carry the user role, so they never get the
+// admin bypass.
+func RequireOwner(next http.Handler) http.Handler {
+	return requireOwner(next, true)
+}
+
+// RequireSelf is RequireOwner without the admin bypass. It guards the routes
+// that mint or change the user's credentials (API keys, TOTP, login email),
+// where acting for the user would let an admin take over the account.
+func RequireSelf(next http.Handler) http.Handler {
+	return requireOwner(next, false)
+}
+
+func requireOwner(next http.Handler, adminBypass bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		callerID, ok := auth.UserIDFromContext(ctx)
+		if !ok {
+			handler.RespondAppError(w, handler.ErrMissingToken, nil)
+			return
+		}
+
+		ownerID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			handler.RespondAppError(w, handler.ErrResourceNotFound, nil)
+			return
+		}
+
+		if ownerID != callerID {
+			if !adminBypass || !auth.RoleFromContext(ctx).IsAdmin() {
+				handler.RespondAppError(w, handler.ErrResourceNotFound, nil)
+				return
+			}
+			logging.FromContext(ctx).Warn("security event",
+				"event", "admin_user_access",
+				"admin_id", callerID,
+				"user_id", ownerID,
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
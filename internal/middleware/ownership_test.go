@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestRequireOwner(t *testing.T) {
+	owner := uuid.New()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	send := func(mw func(http.Handler) http.Handler, pathID string, callerID uuid.UUID, role domain.UserRole) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+pathID, nil)
+		r.SetPathValue("id", pathID)
+		if callerID != uuid.Nil {
+			ctx := auth.ContextWithUserID(r.Context(), callerID)
+			r = r.WithContext(auth.ContextWithRole(ctx, role))
+		}
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	tests := []struct {
+		name     string
+		pathID   string
+		callerID uuid.UUID
+		role     domain.UserRole
+		wantOwn  int
+		wantSelf int
+	}{
+		{"owner", owner.String(), owner, domain.UserRoleUser, http.StatusOK, http.StatusOK},
+		{"other user", owner.String(), uuid.New(), domain.UserRoleUser, http.StatusNotFound, http.StatusNotFound},
+		{"support", owner.String(), uuid.New(), domain.UserRoleSupport, http.StatusNotFound, http.StatusNotFound},
+		{"admin", owner.String(), uuid.New(), domain.UserRoleAdmin, http.StatusOK, http.StatusNotFound},
+		{"id not a uuid", "alice", owner, domain.UserRoleUser, http.StatusNotFound, http.StatusNotFound},
+		{"id not a uuid, admin", "alice", uuid.New(), domain.UserRoleAdmin, http.StatusNotFound, http.StatusNotFound},
+		{"no caller", owner.String(), uuid.Nil, "", http.StatusUnauthorized, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantOwn, send(RequireOwner, tt.pathID, tt.callerID, tt.role), "RequireOwner")
+			assert.Equal(t, tt.wantSelf, send(RequireSelf, tt.pathID, tt.callerID, tt.role), "RequireSelf")
+		})
+	}
+}
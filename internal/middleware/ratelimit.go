@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/ratelimit"
+)
+
+// RateLimitRule limits one group of endpoints. Requests are counted per
+// client IP and, once authenticated, per user; a request must fit under both.
+// A disabled Limit skips that check.
+type RateLimitRule struct {
+	Name    string
+	PerIP   ratelimit.Limit
+	PerUser ratelimit.Limit
+}
+
+// RateLimit rejects requests over the rule's limits with 429 and a
+// Retry-After header. The limit is soft: if the store fails, requests are
+// let through. On authenticated routes it has to run after Auth.
+func RateLimit(store ratelimit.Store, rule RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			type check struct {
+				key   string
+				limit ratelimit.Limit
+			}
+			checks := []check{{"rl:" + rule.Name + ":ip:" + handler.ClientIP(r), rule.PerIP}}
+			if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+				checks = append(checks, check{"rl:" + rule.Name + ":user:" + userID.String(), rule.PerUser})
+			}
+
+			for _, c := range checks {
+				if !c.limit.Enabled() {
+					continue
+				}
+				ok, wait, err := store.Take(r.Context(), c.key, c.limit)
+				if err != nil {
+					logging.FromContext(r.Context()).Warn("rate limit store failed, allowing request", "rule", rule.Name, "error", err)
+					continue
+				}
+				if !ok {
+					logging.FromContext(r.Context()).Warn("request rate limited", "rule", rule.Name, "key", c.key, "retry_after_ms", wait.Milliseconds())
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+					handler.RespondAppError(w, handler.ErrRateLimited, nil)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// retryAfterSeconds rounds up, so a client that waits as told finds a token.
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
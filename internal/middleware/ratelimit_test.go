@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/ratelimit"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis down")
+}
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mw := RateLimit(ratelimit.NewMemoryStore(), RateLimitRule{
+		Name:    "payment",
+		PerIP:   ratelimit.PerMinute(60, 3),
+		PerUser: ratelimit.PerMinute(1, 1),
+	})(ok)
+
+	send := func(ip string, userID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil)
+		req.RemoteAddr = ip + ":40000"
+		if userID != uuid.Nil {
+			req = req.WithContext(auth.ContextWithUserID(req.Context(), userID))
+		}
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("per user", func(t *testing.T) {
+		alice := uuid.New()
+		assert.Equal(t, http.StatusOK, send("10.0.0.1", alice).Code)
+		rr := send("10.0.0.1", alice)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusOK, send("10.0.0.1", uuid.New()).Code, "other users have their own bucket")
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, send("10.0.0.2", uuid.Nil).Code)
+		}
+		rr := send("10.0.0.2", uuid.Nil)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusOK, send("10.0.0.3", uuid.Nil).Code)
+	})
+
+	t.Run("store failure lets requests through", func(t *testing.T) {
+		failOpen := RateLimit(failingStore{}, RateLimitRule{Name: "login", PerIP: ratelimit.PerMinute(1, 1)})(ok)
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			failOpen.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+	})
+}
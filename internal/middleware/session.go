@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCachedSessions bounds SessionVersions. When it is reached, expired
+// entries are dropped, and if none have expired the cache starts over.
+const maxCachedSessions = 100_000
+
+type sessionVersionLookup interface {
+	SessionVersion(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type cachedSessionVersion struct {
+	version int
+	expires time.Time
+}
+
+// SessionVersions keeps each user's session version for ttl, so the auth
+// middleware doesn't read it from the database on every request. A
+// password change bumps the version in the database; until the cached
+// entry expires, an instance keeps accepting the sessions it ended. A ttl
+// of zero reads the version every time.
+type SessionVersions struct {
+	lookup sessionVersionLookup
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedSessionVersion
+}
+
+func NewSessionVersions(lookup sessionVersionLookup, ttl time.Duration) *SessionVersions {
+	return &SessionVersions{
+		lookup: lookup,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  make(map[uuid.UUID]cachedSessionVersion),
+	}
+}
+
+func (s *SessionVersions) SessionVersion(ctx context.Context, userID uuid.UUID) (int, error) {
+	if s.ttl <= 0 {
+		return s.lookup.SessionVersion(ctx, userID)
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.version, nil
+	}
+
+	version, err := s.lookup.SessionVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCachedSessions {
+		for id, c := range s.cache {
+			if !now.Before(c.expires) {
+				delete(s.cache, id)
+			}
+		}
+		if len(s.cache) >= maxCachedSessions {
+			clear(s.cache)
+		}
+	}
+	s.cache[userID] = cachedSessionVersion{version: version, expires: now.Add(s.ttl)}
+	return version, nil
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/deadline"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// RequestTimeoutConfig bounds the deadlines clients may ask for.
+type RequestTimeoutConfig struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// RequestTimeout lets a client give the server less time than its default
+// with X-Request-Timeout, in milliseconds or as a duration such as "2.5s".
+// The value is clamped to [Min, Max] and applied to the request context,
+// so database queries and provider calls give up when it runs out. A
+// request that fails after its deadline gets a 504 REQUEST_TIMEOUT, and the
+// stage it was in is logged. One that finishes its work late still gets
+// its real response: a payment that committed is never reported as timed
+// out.
+func RequestTimeout(cfg RequestTimeoutConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get(requestTimeoutHeader)
+			if raw == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			timeout, ok := parseRequestTimeout(raw)
+			if !ok {
+				handler.RespondAppError(w, handler.ErrInvalidRequestTimeout, []handler.FieldError{
+					{Field: requestTimeoutHeader, Message: "must be milliseconds or a duration such as 2.5s"},
+				})
+				return
+			}
+			timeout = min(max(timeout, cfg.Min), cfg.Max)
+
+			ctx, budget, cancel := deadline.WithBudget(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(tw, r)
+			if !tw.wroteHeader && expired(ctx) {
+				tw.WriteHeader(http.StatusGatewayTimeout)
+			}
+
+			if tw.timedOut {
+				dl, _ := ctx.Deadline()
+				logging.FromContext(ctx).Warn("request deadline exceeded",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"budget_ms", timeout.Milliseconds(),
+					"elapsed_ms", time.Since(budget.Started()).Milliseconds(),
+					"stage", budget.StageAt(dl),
+					"stages", budget.Trace(time.Now()),
+				)
+			}
+		})
+	}
+}
+
+func parseRequestTimeout(raw string) (time.Duration, bool) {
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+	d, err := time.ParseDuration(raw)
+	return d, err == nil && d > 0
+}
+
+func expired(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// timeoutWriter replaces a server error written after the deadline with a
+// 504, since the error is almost always the deadline cutting a query or
+// provider call short.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code >= http.StatusInternalServerError && expired(w.ctx) {
+		w.timedOut = true
+		handler.RespondAppError(w.ResponseWriter, handler.ErrRequestTimeout, nil)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/deadline"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/handler"
+)
+
+func TestRequestTimeout_ClampsToConfig(t *testing.T) {
+	mw := RequestTimeout(RequestTimeoutConfig{Min: 500 * time.Millisecond, Max: 5 * time.Second})
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"2000", 2 * time.Second},
+		{"2.5s", 2500 * time.Millisecond},
+		{"10", 500 * time.Millisecond},
+		{"1m", 5 * time.Second},
+	}
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			var got time.Duration
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if b := deadline.FromContext(r.Context()); b != nil {
+					got = b.Timeout
+				}
+				_, hasDeadline := r.Context().Deadline()
+				assert.Equal(t, tc.want > 0, hasDeadline)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/abc", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Request-Timeout", tc.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRequestTimeout_RejectsInvalidHeader(t *testing.T) {
+	h := RequestTimeout(RequestTimeoutConfig{Min: time.Millisecond, Max: time.Second})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not run")
+		}))
+
+	for _, v := range []string{"soon", "-5", "0"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Timeout", v)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, v)
+	}
+}
+
+func TestRequestTimeout_ServerErrorAfterDeadlineBecomes504(t *testing.T) {
+	mw := RequestTimeout(RequestTimeoutConfig{Min: time.Millisecond, Max: time.Second})
+
+	slow := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline.Stage(r.Context(), "provider:mock")
+			<-r.Context().Done()
+			if status >= 500 {
+				handler.RespondDomainError(w, fmt.Errorf("submit: %w", r.Context().Err()))
+				return
+			}
+			handler.RespondSuccess(w, status, map[string]string{"id": "p1"})
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments/external", nil)
+	req.Header.Set("X-Request-Timeout", "20")
+	rec := httptest.NewRecorder()
+	mw(slow(http.StatusInternalServerError)).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	var body handler.APIResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "REQUEST_TIMEOUT", body.Error.Code)
+
+	rec = httptest.NewRecorder()
+	mw(slow(http.StatusCreated)).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code, "work that finished late keeps its response")
+
+	rec = httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code, "a handler that wrote nothing")
+}
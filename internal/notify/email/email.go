@@ -0,0 +1,54 @@
+// Package email sends email: over SMTP, or to the log when running without
+// a mail server. Messages are rendered from the templates package before
+// they get here; the template version travels with them as a header.
+package email
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+// Message is one plain-text email to one address.
+type Message struct {
+	To       string
+	Subject  string
+	Body     string
+	Template templates.Ref
+}
+
+// Sender delivers email. It returns once the message has been handed to
+// the mail server.
+type Sender interface {
+	SendEmail(ctx context.Context, m Message) error
+}
+
+// LogSender writes email to the log instead of sending it. It is meant for
+// development: bodies can contain secrets such as reset links.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) SendEmail(_ context.Context, m Message) error {
+	s.logger.Info("email",
+		"to", m.To,
+		"template", m.Template.String(),
+		"subject", m.Subject,
+		"body", m.Body,
+	)
+	return nil
+}
+
+// Render renders the named template in locale for the address to.
+func Render(to, name, locale string, data any) (Message, error) {
+	msg, err := templates.Render(name, locale, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: msg.Subject, Body: msg.Body, Template: msg.Ref}, nil
+}
@@ -0,0 +1,138 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type smtpSession struct {
+	from, rcpt string
+	data       string
+}
+
+// fakeSMTP accepts one connection and plays a plain (no STARTTLS, no AUTH)
+// mail server, sending what it received on the returned channel.
+func fakeSMTP(t *testing.T) (string, int, <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	got := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		var sess smtpSession
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				sess.from = strings.TrimPrefix(cmd, "MAIL FROM:")
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				sess.rcpt = strings.TrimPrefix(cmd, "RCPT TO:")
+				reply("250 ok")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				sess.data = data.String()
+				got <- sess
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unknown")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, got
+}
+
+func TestSMTPSender_SendsTemplatedMessage(t *testing.T) {
+	host, port, got := fakeSMTP(t)
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: port, From: "Grey <no-reply@grey.test>", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	body := "Payment of 25.00 € completed.\n" + strings.Repeat("x", 100)
+	err = sender.SendEmail(context.Background(), Message{
+		To:       "alice@test.com",
+		Subject:  "Payout complete ✓",
+		Body:     body,
+		Template: templates.Ref{Name: "payout.completed", Locale: "en", Version: "abc123"},
+	})
+	require.NoError(t, err)
+
+	sess := <-got
+	assert.Equal(t, "<no-reply@grey.test>", sess.from)
+	assert.Equal(t, "<alice@test.com>", sess.rcpt)
+
+	msg, err := mail.ReadMessage(strings.NewReader(sess.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Payout complete ✓", subject)
+	assert.Equal(t, "payout.completed@en:abc123", msg.Header.Get("X-Grey-Template"))
+	assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(body, "\n", "\r\n"), strings.TrimSuffix(string(decoded), "\r\n"))
+}
+
+func TestSMTPSender_RejectsBadHeaders(t *testing.T) {
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "no-reply@grey.test", Timeout: time.Second})
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), Message{To: "alice@test.com\r\nBcc: eve@test.com", Subject: "hi"})
+	require.Error(t, err)
+	err = sender.SendEmail(context.Background(), Message{To: "alice@test.com", Subject: "hi\r\nBcc: eve@test.com"})
+	require.Error(t, err)
+}
+
+func TestSMTPSender_RequireTLS(t *testing.T) {
+	host, port, _ := fakeSMTP(t)
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: port, From: "no-reply@grey.test", RequireTLS: true, Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), Message{To: "alice@test.com", Subject: "hi", Body: "hi"})
+	require.ErrorContains(t, err, "STARTTLS")
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := PerMinute(60, 3)
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := s.Take(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, ok, "burst request %d", i)
+	}
+	ok, wait, err := s.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	ok, _, _ = s.Take(ctx, "other", limit)
+	assert.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(1500 * time.Millisecond)
+	ok, _, _ = s.Take(ctx, "k", limit)
+	assert.True(t, ok, "one token refilled")
+	ok, wait, _ = s.Take(ctx, "k", limit)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(time.Hour)
+	_, _, _ = s.Take(ctx, "k", limit)
+	assert.Len(t, s.buckets, 1, "refilled buckets are swept")
+}
+
+func TestRedisStore_Take(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	s := NewRedisStore(mr.Addr(), "", time.Second, 1)
+	t.Cleanup(func() { s.Close() })
+	now := time.UnixMilli(1_000_000)
+	s.now = func() time.Time { return now }
+	limit := PerMinute(60, 2)
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := s.Take(ctx, "rl:login:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, ok, "burst request %d", i)
+	}
+	ok, wait, err := s.Take(ctx, "rl:login:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+	assert.Equal(t, 3*time.Second, mr.TTL("rl:login:ip:10.0.0.1"), "expires once refilled")
+
+	ok, _, _ = s.Take(ctx, "other", limit)
+	assert.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(1500 * time.Millisecond)
+	ok, _, _ = s.Take(ctx, "rl:login:ip:10.0.0.1", limit)
+	assert.True(t, ok, "one token refilled")
+
+	mr.Close()
+	_, _, err = s.Take(ctx, "k", limit)
+	assert.Error(t, err, "an unreachable Redis is reported")
+}
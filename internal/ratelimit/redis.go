@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeLua refills and takes from a bucket stored as a hash of tokens and
+// the time it was last updated, in milliseconds. It returns {1, 0} when a
+// token was taken and {0, wait_ms} otherwise. The bucket expires once it
+// would have refilled.
+const takeLua = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, wait}
+`
+
+var takeScript = redis.NewScript(takeLua)
+
+// RedisStore keeps buckets in Redis so every instance shares them.
+type RedisStore struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+// NewRedisStore connects lazily; nothing is dialled until the first Take.
+// timeout bounds each round trip, so a slow Redis can't stall requests.
+func NewRedisStore(addr, password string, timeout time.Duration, poolSize int) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:                  addr,
+			Password:              password,
+			DialTimeout:           timeout,
+			ReadTimeout:           timeout,
+			WriteTimeout:          timeout,
+			ContextTimeoutEnabled: true,
+			PoolSize:              poolSize,
+			DisableIdentity:       true,
+		}),
+		now: time.Now,
+	}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := s.now().UnixMilli()
+	ttl := limit.refillTime().Milliseconds() + 1000
+	vals, err := takeScript.Run(ctx, s.client, []string{key},
+		strconv.FormatFloat(limit.Rate/1000, 'g', -1, 64),
+		limit.Burst,
+		now,
+		ttl,
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("RedisStore.Take: %w", err)
+	}
+	if len(vals) != 2 {
+		return false, 0, fmt.Errorf("RedisStore.Take: unexpected reply %v", vals)
+	}
+	return vals[0] == 1, time.Duration(vals[1]) * time.Millisecond, nil
+}
+
+// Close closes the connection pool.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
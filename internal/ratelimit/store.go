@@ -0,0 +1,38 @@
+// Package ratelimit implements token bucket rate limits. Buckets live in a
+// Store, either in process memory or in Redis so every instance shares them.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Burst tokens and refills at Rate
+// tokens per second. Each request takes one token. A zero Rate disables the
+// limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute is a limit of n requests a minute with bursts of up to burst.
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// refillTime is how long an empty bucket takes to fill up again. A bucket
+// untouched for this long is full and can be forgotten.
+func (l Limit) refillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Store holds buckets by key.
+type Store interface {
+	// Take takes a token from the bucket for key. If the bucket is empty it
+	// reports false and how long until a token is available.
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
@@ -2,33 +2,37 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
 const accountColumns = `id, user_id, currency, account_type, balance, version,
 	account_number, routing_number, iban, swift_bic, provider, provider_ref,
-	status, created_at`
+	status, freeze_reason, frozen_at, frozen_by, created_at`
 
 type AccountRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewAccountRepository(db *sql.DB) *AccountRepository {
+func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
 	return &AccountRepository{db: db}
 }
 
 func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Account, error) {
-	row := r.db.QueryRowContext(ctx,
+	row := r.db.QueryRow(ctx,
 		`SELECT `+accountColumns+` FROM accounts WHERE id = $1`, id,
 	)
 	a, err := scanAccount(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByID: %w", err)
@@ -37,14 +41,14 @@ func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 }
 
 func (r *AccountRepository) GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error) {
-	row := r.db.QueryRowContext(ctx,
+	row := r.db.QueryRow(ctx,
 		`SELECT `+accountColumns+` FROM accounts
 		WHERE user_id = $1 AND currency = $2 AND account_type = $3`,
 		userID, currency, accountType,
 	)
 	a, err := scanAccount(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByUserAndCurrency: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByUserAndCurrency: %w", err)
@@ -53,7 +57,7 @@ func (r *AccountRepository) GetByUserAndCurrency(ctx context.Context, userID uui
 }
 
 func (r *AccountRepository) GetByUserIDAndType(ctx context.Context, userID uuid.UUID, accountType domain.AccountType) ([]domain.Account, error) {
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := r.db.Query(ctx,
 		`SELECT `+accountColumns+` FROM accounts WHERE user_id = $1 AND account_type = $2 ORDER BY created_at`,
 		userID, accountType,
 	)
@@ -76,8 +80,8 @@ func (r *AccountRepository) GetByUserIDAndType(ctx context.Context, userID uuid.
 	return accounts, nil
 }
 
-func (r *AccountRepository) Create(ctx context.Context, account *domain.Account) error {
-	_, err := r.db.ExecContext(ctx,
+func (r *AccountRepository) Create(ctx context.Context, tx pgx.Tx, account *domain.Account) error {
+	_, err := tx.Exec(ctx,
 		`INSERT INTO accounts (
 			id, user_id, currency, account_type, balance, version,
 			account_number, routing_number, iban, swift_bic, provider, provider_ref,
@@ -90,18 +94,21 @@ func (r *AccountRepository) Create(ctx context.Context, account *domain.Account)
 		account.Status, account.CreatedAt,
 	)
 	if err != nil {
+		if isUniqueViolation(err, "idx_accounts_user_currency_type") {
+			return fmt.Errorf("Create: %w", domain.ErrAccountExists)
+		}
 		return fmt.Errorf("Create: %w", err)
 	}
 	return nil
 }
 
-func (r *AccountRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.Account, error) {
-	row := tx.QueryRowContext(ctx,
+func (r *AccountRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Account, error) {
+	row := tx.QueryRow(ctx,
 		`SELECT `+accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, id,
 	)
 	a, err := scanAccount(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetForUpdate: %w", err)
@@ -109,25 +116,217 @@ func (r *AccountRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id uui
 	return a, nil
 }
 
-func (r *AccountRepository) UpdateBalance(ctx context.Context, tx *sql.Tx, id uuid.UUID, newBalance int64, newVersion int64) error {
-	res, err := tx.ExecContext(ctx,
+func (r *AccountRepository) UpdateBalance(ctx context.Context, tx pgx.Tx, id uuid.UUID, newBalance int64, newVersion int64) error {
+	res, err := tx.Exec(ctx,
 		`UPDATE accounts SET balance = $1, version = $2 WHERE id = $3 AND version = $4`,
 		newBalance, newVersion, id, newVersion-1,
 	)
 	if err != nil {
+		if isBalanceCheckViolation(err) {
+			return fmt.Errorf("UpdateBalance: %w", domain.ErrInsufficientFunds)
+		}
 		return fmt.Errorf("UpdateBalance: %w", err)
 	}
 
-	rows, err := res.RowsAffected()
+	rows := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("UpdateBalance: %w", domain.ErrVersionConflict)
+	}
+	return nil
+}
+
+// UpdateBalances applies several balance updates in one UPDATE ... FROM
+// (VALUES ...) statement and returns each account's new version. Every row is
+// version-checked as in UpdateBalance; if any has moved on, nothing is
+// updated and ErrVersionConflict is returned.
+func (r *AccountRepository) UpdateBalances(ctx context.Context, tx pgx.Tx, updates []domain.BalanceUpdate) (map[uuid.UUID]int64, error) {
+	if len(updates) == 0 {
+		return map[uuid.UUID]int64{}, nil
+	}
+
+	const cols = 3
+	rows := make([]string, len(updates))
+	args := make([]any, 0, len(updates)*cols)
+	for i, u := range updates {
+		n := i * cols
+		rows[i] = fmt.Sprintf("($%d::uuid, $%d::bigint, $%d::bigint)", n+1, n+2, n+3)
+		args = append(args, u.AccountID, u.Balance, u.Version)
+	}
+
+	res, err := tx.Query(ctx,
+		`UPDATE accounts a SET balance = v.balance, version = a.version + 1
+		 FROM (VALUES `+strings.Join(rows, ", ")+`) AS v(id, balance, version)
+		 WHERE a.id = v.id AND a.version = v.version
+		 RETURNING a.id, a.version`,
+		args...,
+	)
+	if err != nil {
+		if isBalanceCheckViolation(err) {
+			return nil, fmt.Errorf("UpdateBalances: %w", domain.ErrInsufficientFunds)
+		}
+		return nil, fmt.Errorf("UpdateBalances: %w", err)
+	}
+	defer res.Close()
+
+	versions := make(map[uuid.UUID]int64, len(updates))
+	for res.Next() {
+		var id uuid.UUID
+		var version int64
+		if err := res.Scan(&id, &version); err != nil {
+			return nil, fmt.Errorf("UpdateBalances: scan: %w", err)
+		}
+		versions[id] = version
+	}
+	if err := res.Err(); err != nil {
+		if isBalanceCheckViolation(err) {
+			return nil, fmt.Errorf("UpdateBalances: %w", domain.ErrInsufficientFunds)
+		}
+		return nil, fmt.Errorf("UpdateBalances: %w", err)
+	}
+	if len(versions) != len(updates) {
+		return nil, fmt.Errorf("UpdateBalances: %w", domain.ErrVersionConflict)
+	}
+	return versions, nil
+}
+
+// Close marks an active, empty account closed.
+func (r *AccountRepository) Close(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE accounts SET status = 'closed' WHERE id = $1 AND status = 'active' AND balance = 0`, id,
+	)
 	if err != nil {
-		return fmt.Errorf("UpdateBalance: rows affected: %w", err)
+		return fmt.Errorf("Close: %w", err)
 	}
+
+	rows := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("UpdateBalance: %w", domain.ErrVersionConflict)
+		return fmt.Errorf("Close: %w", domain.ErrAccountCloseBlocked)
+	}
+
+	// A closed account can't send, so it stops being the owner's default.
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET default_account_id = NULL WHERE default_account_id = $1`, id,
+	); err != nil {
+		return fmt.Errorf("Close: clear default account: %w", err)
 	}
 	return nil
 }
 
+// Reopen makes a closed account active again. A failed sweep payout uses it
+// when the money comes back.
+func (r *AccountRepository) Reopen(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE accounts SET status = 'active' WHERE id = $1 AND status = 'closed'`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("Reopen: %w", err)
+	}
+	return nil
+}
+
+// Freeze freezes an active user account, or changes the reason on one
+// that's already frozen, recording who did it and when.
+func (r *AccountRepository) Freeze(ctx context.Context, id uuid.UUID, reason domain.FreezeReason, by uuid.UUID, now time.Time) (*domain.Account, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE accounts SET status = 'frozen', freeze_reason = $2, frozen_at = $3, frozen_by = $4
+		WHERE id = $1 AND account_type = 'user' AND status IN ('active', 'frozen')
+		RETURNING `+accountColumns,
+		id, reason, now, by,
+	)
+	a, err := scanAccount(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Freeze: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Freeze: %w", err)
+	}
+	return a, nil
+}
+
+// Unfreeze makes a frozen account active again and clears its reason.
+func (r *AccountRepository) Unfreeze(ctx context.Context, id uuid.UUID) (*domain.Account, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE accounts SET status = 'active', freeze_reason = NULL, frozen_at = NULL, frozen_by = NULL
+		WHERE id = $1 AND status = 'frozen'
+		RETURNING `+accountColumns,
+		id,
+	)
+	a, err := scanAccount(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Unfreeze: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Unfreeze: %w", err)
+	}
+	return a, nil
+}
+
+// ListFrozen returns frozen accounts, most recently frozen first. An empty
+// reason lists them all.
+func (r *AccountRepository) ListFrozen(ctx context.Context, reason domain.FreezeReason, limit, offset int) ([]domain.Account, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+accountColumns+` FROM accounts
+		WHERE status = 'frozen' AND ($1 = '' OR freeze_reason = $1)
+		ORDER BY frozen_at DESC NULLS LAST, id LIMIT $2 OFFSET $3`,
+		string(reason), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListFrozen: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []domain.Account
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListFrozen: scan: %w", err)
+		}
+		accounts = append(accounts, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListFrozen: rows: %w", err)
+	}
+	return accounts, nil
+}
+
+// ApplyDelta adds delta to an active account's balance in one statement,
+// without a prior FOR UPDATE read, and returns the new balance. The update is
+// refused if it would leave the balance below floor; chk_accounts_balance
+// backs that up for floor 0.
+func (r *AccountRepository) ApplyDelta(ctx context.Context, tx pgx.Tx, id uuid.UUID, delta, floor int64) (int64, error) {
+	var balance int64
+	err := tx.QueryRow(ctx,
+		`UPDATE accounts SET balance = balance + $1, version = version + 1
+		 WHERE id = $2 AND status = 'active' AND balance + $1 >= $3
+		 RETURNING balance`,
+		delta, id, floor,
+	).Scan(&balance)
+	if err == nil {
+		return balance, nil
+	}
+
+	if isBalanceCheckViolation(err) {
+		return 0, fmt.Errorf("ApplyDelta: %w", domain.ErrInsufficientFunds)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("ApplyDelta: %w", err)
+	}
+
+	var status domain.AccountStatus
+	err = tx.QueryRow(ctx, `SELECT status FROM accounts WHERE id = $1`, id).Scan(&status)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, fmt.Errorf("ApplyDelta: %w", domain.ErrNotFound)
+	case err != nil:
+		return 0, fmt.Errorf("ApplyDelta: status: %w", err)
+	case status == domain.AccountStatusFrozen:
+		return 0, fmt.Errorf("ApplyDelta: %w", domain.ErrAccountFrozen)
+	case status != domain.AccountStatusActive:
+		return 0, fmt.Errorf("ApplyDelta: %w", domain.ErrAccountClosed)
+	}
+	return 0, fmt.Errorf("ApplyDelta: %w", domain.ErrInsufficientFunds)
+}
+
 func scanAccount(s scanner) (*domain.Account, error) {
 	var a domain.Account
 	err := s.Scan(
@@ -135,10 +334,17 @@ func scanAccount(s scanner) (*domain.Account, error) {
 		&a.Balance, &a.Version,
 		&a.AccountNumber, &a.RoutingNumber, &a.IBAN, &a.SwiftBIC,
 		&a.Provider, &a.ProviderRef,
-		&a.Status, &a.CreatedAt,
+		&a.Status, &a.FreezeReason, &a.FrozenAt, &a.FrozenBy, &a.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return &a, nil
 }
+
+// isBalanceCheckViolation reports whether err is chk_accounts_balance
+// refusing a negative balance. The services check funds before writing, so
+// this only fires if one of those checks is wrong or raced.
+func isBalanceCheckViolation(err error) bool {
+	return isCheckViolation(err, "chk_accounts_balance")
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestAccountBalanceCheck_RejectsNegativeBalance(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	repo := NewAccountRepository(db)
+
+	user := testutil.SeedTestUser(t, db, "guard@test.com", "Guard", "guard_user")
+	acct := testutil.SeedTestAccount(t, db, user.ID, "USD", 100)
+
+	t.Run("constraint", func(t *testing.T) {
+		_, err := db.Exec(ctx, `UPDATE accounts SET balance = -1 WHERE id = $1`, acct.ID)
+		require.Error(t, err)
+		assert.True(t, isBalanceCheckViolation(err), "chk_accounts_balance should refuse it: %v", err)
+
+		_, err = db.Exec(ctx, `UPDATE accounts SET balance = -1 WHERE id = $1`, testutil.FXPoolUSDID)
+		assert.True(t, isBalanceCheckViolation(err), "system accounts are covered too")
+	})
+
+	t.Run("UpdateBalance", func(t *testing.T) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		err = repo.UpdateBalance(ctx, tx, acct.ID, -50, acct.Version+1)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	})
+
+	t.Run("ApplyDelta", func(t *testing.T) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		// A floor below zero skips the statement's own guard, leaving the
+		// constraint as the only thing in the way.
+		_, err = repo.ApplyDelta(ctx, tx, acct.ID, -150, -1000)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	})
+
+	assert.Equal(t, int64(100), testutil.GetAccountBalance(t, db, acct.ID))
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, last_four, scopes, created_ip, created_user_agent,
+	created_at, last_used_at, revoked_at, rotated_from`
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var scopes []string
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &k.LastFour, &scopes,
+		&k.CreatedIP, &k.CreatedUA, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt, &k.RotatedFrom)
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = make([]domain.APIKeyScope, len(scopes))
+	for i, s := range scopes {
+		k.Scopes[i] = domain.APIKeyScope(s)
+	}
+	return &k, nil
+}
+
+// LockUser serializes key changes for one user until the transaction ends,
+// so concurrent creates can't both slip under the active key limit.
+func (r *APIKeyRepository) LockUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('api_keys:' || $1::text))`, userID,
+	); err != nil {
+		return fmt.Errorf("LockUser: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, tx pgx.Tx, k *domain.APIKey) error {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, last_four, scopes,
+			created_ip, created_user_agent, created_at, rotated_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		k.ID, k.UserID, k.Name, k.Prefix, k.KeyHash, k.LastFour, scopes,
+		k.CreatedIP, k.CreatedUA, k.CreatedAt, k.RotatedFrom,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) CountActive(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (int, error) {
+	var n int
+	err := tx.QueryRow(ctx,
+		`SELECT count(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL`, userID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("CountActive: %w", err)
+	}
+	return n, nil
+}
+
+// SeenOrigin reports whether the user has created a key before from this IP
+// address and user agent.
+func (r *APIKeyRepository) SeenOrigin(ctx context.Context, tx pgx.Tx, userID uuid.UUID, ip, userAgent string) (bool, error) {
+	var seen bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM api_keys
+			WHERE user_id = $1 AND created_ip = $2 AND created_user_agent = $3)`,
+		userID, ip, userAgent,
+	).Scan(&seen)
+	if err != nil {
+		return false, fmt.Errorf("SeenOrigin: %w", err)
+	}
+	return seen, nil
+}
+
+// GetForUpdate locks a key belonging to userID. Keys of other users are
+// reported as not found.
+func (r *APIKeyRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, userID, id uuid.UUID) (*domain.APIKey, error) {
+	k, err := scanAPIKey(tx.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return k, nil
+}
+
+// GetByPrefix looks a key up by the prefix at the start of the full key.
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByPrefix: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByPrefix: %w", err)
+	}
+	return k, nil
+}
+
+// TouchLastUsed sets last_used_at to at unless it was set within the last
+// minute, so a busy key doesn't write its row on every request.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - interval '1 minute')`,
+		id, at,
+	)
+	if err != nil {
+		return fmt.Errorf("TouchLastUsed: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, tx pgx.Tx, id uuid.UUID, at time.Time) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, at,
+	)
+	if err != nil {
+		return fmt.Errorf("Revoke: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll revokes every unrevoked key of the user and returns how many
+// there were.
+func (r *APIKeyRepository) RevokeAll(ctx context.Context, tx pgx.Tx, userID uuid.UUID, at time.Time) (int64, error) {
+	res, err := tx.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`, userID, at,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAll: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// ListByUser returns the user's keys, revoked ones included, newest first.
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListByUser: scan: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListByUser: rows: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const auditLogColumns = `id, actor_id, action, resource_type, resource_id, ip, before, after, created_at`
+
+type AuditLogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditLogRepository(db *pgxpool.Pool) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func scanAuditLog(row interface{ Scan(...any) error }) (*domain.AuditLog, error) {
+	var l domain.AuditLog
+	err := row.Scan(&l.ID, &l.ActorID, &l.Action, &l.ResourceType, &l.ResourceID, &l.IP, &l.Before, &l.After, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, l *domain.AuditLog) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO audit_logs (id, actor_id, action, resource_type, resource_id, ip, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		l.ID, l.ActorID, l.Action, l.ResourceType, l.ResourceID, l.IP, []byte(l.Before), []byte(l.After), l.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit rows matching f, newest first, starting after
+// before (nil for the first page). The bool reports whether more follow.
+func (r *AuditLogRepository) List(ctx context.Context, f domain.AuditFilter, before *domain.AuditCursor, limit int) ([]domain.AuditLog, bool, error) {
+	query := `SELECT ` + auditLogColumns + ` FROM audit_logs WHERE true`
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if f.ActorID != nil {
+		query += ` AND actor_id = ` + arg(*f.ActorID)
+	}
+	if f.Action != "" {
+		query += ` AND action = ` + arg(f.Action)
+	}
+	if f.ResourceType != "" {
+		query += ` AND resource_type = ` + arg(f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		query += ` AND resource_id = ` + arg(f.ResourceID)
+	}
+	if f.From != nil {
+		query += ` AND created_at >= ` + arg(*f.From)
+	}
+	if f.To != nil {
+		query += ` AND created_at < ` + arg(*f.To)
+	}
+	if before != nil {
+		query += ` AND (created_at, id) < (` + arg(before.CreatedAt) + `, ` + arg(before.ID) + `)`
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ` + arg(limit+1)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.AuditLog
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("List: scan: %w", err)
+		}
+		out = append(out, *l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("List: rows: %w", err)
+	}
+
+	if len(out) > limit {
+		return out[:limit], true, nil
+	}
+	return out, false, nil
+}
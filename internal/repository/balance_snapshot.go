@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// signedAmount is a ledger entry's effect on its account's balance.
+const signedAmount = `CASE l.entry_type WHEN 'credit' THEN l.amount ELSE -l.amount END`
+
+type BalanceSnapshotRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBalanceSnapshotRepository(db *pgxpool.Pool) *BalanceSnapshotRepository {
+	return &BalanceSnapshotRepository{db: db}
+}
+
+// BalanceAt returns the account's ledger balance as of at: the first
+// snapshot at or after at, or the current balance when there is none, less
+// the entries between at and that point. Entries are never edited, so
+// working back gives the same answer as summing forward, without needing
+// the opening balance. It is one statement, so the balance and the entries
+// come from the same view of the database.
+func (r *BalanceSnapshotRepository) BalanceAt(ctx context.Context, accountID uuid.UUID, at time.Time) (int64, error) {
+	var balance int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(
+			(SELECT s.balance - COALESCE((SELECT SUM(`+signedAmount+`) FROM ledger_entries l
+					WHERE l.account_id = $1 AND l.created_at > $2 AND l.created_at <= s.as_of), 0)
+				FROM balance_snapshots s
+				WHERE s.account_id = $1 AND s.as_of >= $2
+				ORDER BY s.as_of LIMIT 1),
+			(SELECT a.balance - COALESCE((SELECT SUM(`+signedAmount+`) FROM ledger_entries l
+					WHERE l.account_id = $1 AND l.created_at > $2), 0)
+				FROM accounts a WHERE a.id = $1))`,
+		accountID, at,
+	).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("BalanceAt: %w", err)
+	}
+	return balance, nil
+}
+
+// Snapshot records the balance as of asOf of every account opened by then
+// that doesn't have one yet, and returns how many it wrote.
+func (r *BalanceSnapshotRepository) Snapshot(ctx context.Context, asOf time.Time) (int64, error) {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO balance_snapshots (account_id, as_of, balance)
+		SELECT a.id, $1, a.balance - COALESCE((SELECT SUM(`+signedAmount+`) FROM ledger_entries l
+				WHERE l.account_id = a.id AND l.created_at > $1), 0)
+		FROM accounts a
+		WHERE a.created_at <= $1
+		ON CONFLICT (account_id, as_of) DO NOTHING`,
+		asOf,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Snapshot: %w", err)
+	}
+	n := res.RowsAffected()
+	return n, nil
+}
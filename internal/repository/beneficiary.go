@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const beneficiaryColumns = `id, user_id, name, iban, bank_name, created_at, updated_at, deleted_at`
+
+type BeneficiaryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBeneficiaryRepository(db *pgxpool.Pool) *BeneficiaryRepository {
+	return &BeneficiaryRepository{db: db}
+}
+
+func scanBeneficiary(row interface{ Scan(...any) error }) (*domain.Beneficiary, error) {
+	var b domain.Beneficiary
+	err := row.Scan(&b.ID, &b.UserID, &b.Name, &b.IBAN, &b.BankName, &b.CreatedAt, &b.UpdatedAt, &b.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *BeneficiaryRepository) Create(ctx context.Context, b *domain.Beneficiary) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO beneficiaries (id, user_id, name, iban, bank_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		b.ID, b.UserID, b.Name, b.IBAN, b.BankName, b.CreatedAt, b.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_beneficiaries_user_iban") {
+			return fmt.Errorf("Create: %w", domain.ErrBeneficiaryExists)
+		}
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns a beneficiary belonging to userID, deleted or not.
+// Beneficiaries of other users are reported as not found.
+func (r *BeneficiaryRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error) {
+	b, err := scanBeneficiary(r.db.QueryRow(ctx,
+		`SELECT `+beneficiaryColumns+` FROM beneficiaries WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return b, nil
+}
+
+// GetForUpdate locks a beneficiary belonging to userID. The lock also waits
+// for payouts being inserted against it, whose foreign key check holds a
+// share lock on the row until they commit.
+func (r *BeneficiaryRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, userID, id uuid.UUID) (*domain.Beneficiary, error) {
+	b, err := scanBeneficiary(tx.QueryRow(ctx,
+		`SELECT `+beneficiaryColumns+` FROM beneficiaries WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return b, nil
+}
+
+// ListByUser returns the user's beneficiaries that haven't been deleted,
+// newest first.
+func (r *BeneficiaryRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.Beneficiary, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+beneficiaryColumns+` FROM beneficiaries
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Beneficiary
+	for rows.Next() {
+		b, err := scanBeneficiary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListByUser: scan: %w", err)
+		}
+		out = append(out, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListByUser: rows: %w", err)
+	}
+	return out, nil
+}
+
+// SetDeleted sets or clears deleted_at. Restoring fails with
+// domain.ErrBeneficiaryExists if the user has since saved the same IBAN again.
+func (r *BeneficiaryRepository) SetDeleted(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedAt *time.Time) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE beneficiaries SET deleted_at = $2, updated_at = now() WHERE id = $1`,
+		id, deletedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_beneficiaries_user_iban") {
+			return fmt.Errorf("SetDeleted: %w", domain.ErrBeneficiaryExists)
+		}
+		return fmt.Errorf("SetDeleted: %w", err)
+	}
+	return nil
+}
+
+// HasPayoutsInFlight reports whether any payout to the beneficiary is still
+// in a non-terminal state.
+func (r *BeneficiaryRepository) HasPayoutsInFlight(ctx context.Context, tx pgx.Tx, id uuid.UUID) (bool, error) {
+	var inFlight bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM payments
+			WHERE beneficiary_id = $1 AND status NOT IN ('completed', 'failed', 'reversed'))`,
+		id,
+	).Scan(&inFlight)
+	if err != nil {
+		return false, fmt.Errorf("HasPayoutsInFlight: %w", err)
+	}
+	return inFlight, nil
+}
+
+// Delete removes the row. Payouts that went to it keep their own copy of
+// the destination and have beneficiary_id cleared.
+func (r *BeneficiaryRepository) Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM beneficiaries WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// corridorBuckets maps a granularity to the expression that buckets
+// corridor_daily_stats.day; $1 is the start of the report range.
+var corridorBuckets = map[domain.CorridorGranularity]string{
+	domain.CorridorGranularityDay:   `day`,
+	domain.CorridorGranularityWeek:  `date_trunc('week', day)::date`,
+	domain.CorridorGranularityMonth: `date_trunc('month', day)::date`,
+	domain.CorridorGranularityTotal: `$1::date`,
+}
+
+type CorridorStatsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCorridorStatsRepository(db *pgxpool.Pool) *CorridorStatsRepository {
+	return &CorridorStatsRepository{db: db}
+}
+
+// Refresh recomputes the rollup for payments created in [from, to) and
+// returns the number of day/corridor rows written.
+func (r *CorridorStatsRepository) Refresh(ctx context.Context, from, to, now time.Time) (int64, error) {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO corridor_daily_stats (
+			day, source_currency, dest_currency, payments, succeeded, failed,
+			volume, fees, timed, completion_seconds, refreshed_at
+		)
+		SELECT (created_at AT TIME ZONE 'UTC')::date, source_currency, dest_currency,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status IN ('completed', 'reversed')),
+			COUNT(*) FILTER (WHERE status IN ('failed', 'pending_reversal')),
+			COALESCE(SUM(source_amount) FILTER (WHERE status IN ('completed', 'reversed')), 0),
+			COALESCE(SUM(fee_amount) FILTER (WHERE status IN ('completed', 'reversed')), 0),
+			COUNT(*) FILTER (WHERE status IN ('completed', 'reversed') AND completed_at IS NOT NULL),
+			COALESCE(SUM(EXTRACT(EPOCH FROM completed_at - created_at))
+				FILTER (WHERE status IN ('completed', 'reversed') AND completed_at IS NOT NULL), 0),
+			$3
+		FROM payments
+		WHERE type IN ('internal_transfer', 'external_payout')
+			AND created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2, 3
+		ON CONFLICT (day, source_currency, dest_currency) DO UPDATE SET
+			payments = EXCLUDED.payments,
+			succeeded = EXCLUDED.succeeded,
+			failed = EXCLUDED.failed,
+			volume = EXCLUDED.volume,
+			fees = EXCLUDED.fees,
+			timed = EXCLUDED.timed,
+			completion_seconds = EXCLUDED.completion_seconds,
+			refreshed_at = EXCLUDED.refreshed_at`,
+		from, to, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Refresh: %w", err)
+	}
+	n := res.RowsAffected()
+	return n, nil
+}
+
+// LastRefreshed returns when the rollup was last written, or nil if it is
+// empty.
+func (r *CorridorStatsRepository) LastRefreshed(ctx context.Context) (*time.Time, error) {
+	var at *time.Time
+	if err := r.db.QueryRow(ctx, `SELECT MAX(refreshed_at) FROM corridor_daily_stats`).Scan(&at); err != nil {
+		return nil, fmt.Errorf("LastRefreshed: %w", err)
+	}
+	return at, nil
+}
+
+// Stats sums the rollup for the days in [from, to), bucketed by g. Weeks
+// start on Monday.
+func (r *CorridorStatsRepository) Stats(ctx context.Context, from, to time.Time, g domain.CorridorGranularity) ([]domain.CorridorStats, error) {
+	bucket, ok := corridorBuckets[g]
+	if !ok {
+		return nil, fmt.Errorf("Stats: granularity %q: %w", g, domain.ErrInvalidRequest)
+	}
+	rows, err := r.db.Query(ctx,
+		`SELECT `+bucket+`, source_currency, dest_currency,
+			SUM(payments)::bigint, SUM(succeeded)::bigint, SUM(failed)::bigint,
+			SUM(volume)::bigint, SUM(fees)::bigint, SUM(timed)::bigint, SUM(completion_seconds)
+		FROM corridor_daily_stats
+		WHERE day >= $1::date AND day < $2::date
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`,
+		from.Format(time.DateOnly), to.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.CorridorStats
+	for rows.Next() {
+		var s domain.CorridorStats
+		if err := rows.Scan(
+			&s.PeriodStart, &s.SourceCurrency, &s.DestCurrency,
+			&s.Payments, &s.Succeeded, &s.Failed, &s.Volume, &s.Fees, &s.Timed, &s.CompletionSeconds,
+		); err != nil {
+			return nil, fmt.Errorf("Stats: scan: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Stats: rows: %w", err)
+	}
+	return stats, nil
+}
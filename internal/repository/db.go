@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type scanner interface {
@@ -11,18 +13,18 @@ type scanner interface {
 }
 
 type DB struct {
-	pool *sql.DB
+	pool *pgxpool.Pool
 }
 
-func NewDB(pool *sql.DB) *DB {
+func NewDB(pool *pgxpool.Pool) *DB {
 	return &DB{pool: pool}
 }
 
-func (d *DB) Conn() *sql.DB {
+func (d *DB) Conn() *pgxpool.Pool {
 	return d.pool
 }
 
-func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
 	tx, err := d.pool.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("BeginTx: %w", err)
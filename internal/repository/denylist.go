@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const denylistColumns = `id, field, value, reason, created_by, created_at, updated_at`
+
+type DenylistRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDenylistRepository(db *pgxpool.Pool) *DenylistRepository {
+	return &DenylistRepository{db: db}
+}
+
+func (r *DenylistRepository) Create(ctx context.Context, e *domain.DenylistEntry) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO denylist_entries (id, field, value, reason, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.ID, e.Field, e.Value, e.Reason, e.CreatedBy, e.CreatedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_denylist_entries_field_value") {
+			return fmt.Errorf("Create: %w", domain.ErrDenylistEntryExists)
+		}
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *DenylistRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.DenylistEntry, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+denylistColumns+` FROM denylist_entries WHERE id = $1`, id,
+	)
+	e, err := scanDenylistEntry(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return e, nil
+}
+
+func (r *DenylistRepository) List(ctx context.Context, field domain.DenylistField, limit, offset int) ([]domain.DenylistEntry, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+denylistColumns+` FROM denylist_entries
+		WHERE ($1 = '' OR field = $1)
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
+		string(field), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.DenylistEntry
+	for rows.Next() {
+		e, err := scanDenylistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *DenylistRepository) UpdateReason(ctx context.Context, id uuid.UUID, reason string, now time.Time) (*domain.DenylistEntry, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE denylist_entries SET reason = $1, updated_at = $2 WHERE id = $3
+		RETURNING `+denylistColumns,
+		reason, now, id,
+	)
+	e, err := scanDenylistEntry(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("UpdateReason: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+	return e, nil
+}
+
+func (r *DenylistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM denylist_entries WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	n := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Delete: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Match returns the oldest entry matching either normalized value, or
+// ErrNotFound when the destination is clear.
+func (r *DenylistRepository) Match(ctx context.Context, iban, bankName string) (*domain.DenylistEntry, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+denylistColumns+` FROM denylist_entries
+		WHERE (field = 'iban' AND value = $1) OR (field = 'bank_name' AND value = $2)
+		ORDER BY created_at LIMIT 1`,
+		iban, bankName,
+	)
+	e, err := scanDenylistEntry(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Match: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Match: %w", err)
+	}
+	return e, nil
+}
+
+func scanDenylistEntry(s scanner) (*domain.DenylistEntry, error) {
+	var e domain.DenylistEntry
+	err := s.Scan(&e.ID, &e.Field, &e.Value, &e.Reason, &e.CreatedBy, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
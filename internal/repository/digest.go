@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const digestColumns = `id, user_id, period, period_start, period_end, totals, delivered_at, created_at`
+
+type DigestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDigestRepository(db *pgxpool.Pool) *DigestRepository {
+	return &DigestRepository{db: db}
+}
+
+type digestTotalsJSON struct {
+	Currency      string `json:"currency"`
+	SentCount     int64  `json:"sent_count"`
+	Sent          int64  `json:"sent"`
+	ReceivedCount int64  `json:"received_count"`
+	Received      int64  `json:"received"`
+	Fees          int64  `json:"fees"`
+	FXCount       int64  `json:"fx_count"`
+	FXSent        int64  `json:"fx_sent"`
+}
+
+// GetPreference returns the user's digest settings. Users who never set them
+// are opted out of everything.
+func (r *DigestRepository) GetPreference(ctx context.Context, userID uuid.UUID) (*domain.DigestPreference, error) {
+	var p domain.DigestPreference
+	err := r.db.QueryRow(ctx,
+		`SELECT user_id, weekly, monthly, updated_at FROM digest_preferences WHERE user_id = $1`, userID,
+	).Scan(&p.UserID, &p.Weekly, &p.Monthly, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.DigestPreference{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("GetPreference: %w", err)
+	}
+	return &p, nil
+}
+
+func (r *DigestRepository) UpsertPreference(ctx context.Context, p *domain.DigestPreference) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO digest_preferences (user_id, weekly, monthly, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET weekly = $2, monthly = $3, updated_at = $4`,
+		p.UserID, p.Weekly, p.Monthly, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("UpsertPreference: %w", err)
+	}
+	return nil
+}
+
+// ListDue returns active users opted in to the period who have no digest for
+// the period starting at start yet.
+func (r *DigestRepository) ListDue(ctx context.Context, period domain.DigestPeriod, start time.Time, limit int) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT p.user_id FROM digest_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE u.status = 'active'
+			AND CASE $1 WHEN 'weekly' THEN p.weekly ELSE p.monthly END
+			AND NOT EXISTS (
+				SELECT 1 FROM digests d
+				WHERE d.user_id = p.user_id AND d.period = $1 AND d.period_start = $2
+			)
+		ORDER BY p.user_id
+		LIMIT $3`,
+		period, start, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListDue: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ListDue: scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDue: rows: %w", err)
+	}
+	return ids, nil
+}
+
+// Summarize totals a user's payment activity per currency between start
+// (inclusive) and end (exclusive).
+func (r *DigestRepository) Summarize(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]domain.DigestTotals, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT currency, SUM(sent_count), SUM(sent), SUM(received_count), SUM(received), SUM(fees), SUM(fx_count), SUM(fx_sent)
+		FROM (
+			SELECT p.source_currency AS currency,
+				1 AS sent_count, p.source_amount AS sent, 0 AS received_count, 0 AS received, 0 AS fees,
+				CASE WHEN p.source_currency <> p.dest_currency THEN 1 ELSE 0 END AS fx_count,
+				CASE WHEN p.source_currency <> p.dest_currency THEN p.source_amount ELSE 0 END AS fx_sent
+			FROM payments p JOIN accounts a ON a.id = p.source_account_id
+			WHERE a.user_id = $1 AND p.created_at >= $2 AND p.created_at < $3
+				AND p.status NOT IN ('failed', 'reversed') AND p.type NOT IN ('reversal', 'refund')
+			UNION ALL
+			SELECT p.fee_currency, 0, 0, 0, 0, p.fee_amount, 0, 0
+			FROM payments p JOIN accounts a ON a.id = p.source_account_id
+			WHERE a.user_id = $1 AND p.created_at >= $2 AND p.created_at < $3
+				AND p.status NOT IN ('failed', 'reversed')
+				AND p.fee_amount > 0 AND p.fee_currency IS NOT NULL
+			UNION ALL
+			SELECT p.dest_currency, 0, 0, 1, p.dest_amount, 0, 0, 0
+			FROM payments p JOIN accounts a ON a.id = p.dest_account_id
+			WHERE a.user_id = $1 AND p.created_at >= $2 AND p.created_at < $3
+				AND p.status = 'completed' AND p.type NOT IN ('reversal', 'refund')
+		) activity
+		GROUP BY currency
+		ORDER BY currency`,
+		userID, start, end,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Summarize: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.DigestTotals
+	for rows.Next() {
+		var t domain.DigestTotals
+		if err := rows.Scan(&t.Currency, &t.SentCount, &t.Sent, &t.ReceivedCount, &t.Received, &t.Fees, &t.FXCount, &t.FXSent); err != nil {
+			return nil, fmt.Errorf("Summarize: scan: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Summarize: rows: %w", err)
+	}
+	return totals, nil
+}
+
+// Create stores the digest unless one already exists for the same user and
+// period. It reports whether a row was inserted.
+func (r *DigestRepository) Create(ctx context.Context, d *domain.Digest) (bool, error) {
+	totals := make([]digestTotalsJSON, len(d.Totals))
+	for i, t := range d.Totals {
+		totals[i] = digestTotalsJSON{
+			Currency: string(t.Currency), SentCount: t.SentCount, Sent: t.Sent,
+			ReceivedCount: t.ReceivedCount, Received: t.Received, Fees: t.Fees,
+			FXCount: t.FXCount, FXSent: t.FXSent,
+		}
+	}
+	raw, err := json.Marshal(totals)
+	if err != nil {
+		return false, fmt.Errorf("Create: marshal totals: %w", err)
+	}
+
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO digests (id, user_id, period, period_start, period_end, totals, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, period, period_start) DO NOTHING`,
+		d.ID, d.UserID, d.Period, d.PeriodStart, d.PeriodEnd, raw, d.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("Create: %w", err)
+	}
+	rows := res.RowsAffected()
+	return rows == 1, nil
+}
+
+func (r *DigestRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Digest, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+digestColumns+` FROM digests WHERE id = $1`, id,
+	)
+	d, err := scanDigest(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return d, nil
+}
+
+// ListByUser returns the user's digests, newest period first. An empty
+// period lists both kinds.
+func (r *DigestRepository) ListByUser(ctx context.Context, userID uuid.UUID, period domain.DigestPeriod, limit, offset int) ([]domain.Digest, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+digestColumns+` FROM digests
+		WHERE user_id = $1 AND ($2 = '' OR period = $2)
+		ORDER BY period_start DESC, period
+		LIMIT $3 OFFSET $4`,
+		userID, period, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	return collectDigests(rows, "ListByUser")
+}
+
+// ListUndelivered returns digests whose notification has not gone out yet,
+// oldest first.
+func (r *DigestRepository) ListUndelivered(ctx context.Context, limit int) ([]domain.Digest, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+digestColumns+` FROM digests
+		WHERE delivered_at IS NULL
+		ORDER BY created_at
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListUndelivered: %w", err)
+	}
+	return collectDigests(rows, "ListUndelivered")
+}
+
+func (r *DigestRepository) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE digests SET delivered_at = $1 WHERE id = $2 AND delivered_at IS NULL`, at, id,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkDelivered: %w", err)
+	}
+	return nil
+}
+
+func collectDigests(rows pgx.Rows, op string) ([]domain.Digest, error) {
+	defer rows.Close()
+
+	var digests []domain.Digest
+	for rows.Next() {
+		d, err := scanDigest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		digests = append(digests, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+	return digests, nil
+}
+
+func scanDigest(s scanner) (*domain.Digest, error) {
+	var d domain.Digest
+	var raw []byte
+	err := s.Scan(&d.ID, &d.UserID, &d.Period, &d.PeriodStart, &d.PeriodEnd, &raw, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals []digestTotalsJSON
+	if err := json.Unmarshal(raw, &totals); err != nil {
+		return nil, fmt.Errorf("totals: %w", err)
+	}
+	d.Totals = make([]domain.DigestTotals, len(totals))
+	for i, t := range totals {
+		d.Totals[i] = domain.DigestTotals{
+			Currency: domain.Currency(t.Currency), SentCount: t.SentCount, Sent: t.Sent,
+			ReceivedCount: t.ReceivedCount, Received: t.Received, Fees: t.Fees,
+			FXCount: t.FXCount, FXSent: t.FXSent,
+		}
+	}
+	return &d, nil
+}
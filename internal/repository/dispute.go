@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const disputeColumns = `id, user_id, payment_id, ledger_entry_id, reason, description, status,
+	respond_by, resolve_by, first_response_at, escalated_at, resolved_at, resolved_by,
+	resolution_note, created_at, updated_at`
+
+type DisputeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDisputeRepository(db *pgxpool.Pool) *DisputeRepository {
+	return &DisputeRepository{db: db}
+}
+
+func (r *DisputeRepository) Create(ctx context.Context, d *domain.Dispute) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO disputes (
+			id, user_id, payment_id, ledger_entry_id, ledger_entry_created_at, reason, description, status,
+			respond_by, resolve_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4,
+			(SELECT created_at FROM ledger_entries
+				WHERE id = $4 AND payment_id = $3 AND created_at >= `+paymentCreatedAt("$3")+`),
+			$5, $6, $7, $8, $9, $10, $11
+		)`,
+		d.ID, d.UserID, d.PaymentID, d.LedgerEntryID, d.Reason, d.Description, d.Status,
+		d.RespondBy, d.ResolveBy, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_disputes_active_payment") {
+			return fmt.Errorf("Create: %w", domain.ErrDisputeExists)
+		}
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *DisputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispute, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+disputeColumns+` FROM disputes WHERE id = $1`, id,
+	)
+	d, err := scanDispute(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return d, nil
+}
+
+func (r *DisputeRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.Dispute, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+disputeColumns+` FROM disputes
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	return collectDisputes(rows, "ListByUser")
+}
+
+// List returns disputes for the staff queue, most urgent response deadline first.
+func (r *DisputeRepository) List(ctx context.Context, status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+disputeColumns+` FROM disputes
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY respond_by, created_at LIMIT $2 OFFSET $3`,
+		string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return collectDisputes(rows, "List")
+}
+
+func (r *DisputeRepository) ListOverdueUnescalated(ctx context.Context, now time.Time, limit int) ([]domain.Dispute, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+disputeColumns+` FROM disputes
+		WHERE status = 'open' AND escalated_at IS NULL AND respond_by < $1
+		ORDER BY respond_by LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListOverdueUnescalated: %w", err)
+	}
+	return collectDisputes(rows, "ListOverdueUnescalated")
+}
+
+func (r *DisputeRepository) MarkEscalated(ctx context.Context, id uuid.UUID, now time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE disputes SET escalated_at = $1, updated_at = $1
+		WHERE id = $2 AND escalated_at IS NULL`,
+		now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkEscalated: %w", err)
+	}
+	return nil
+}
+
+func (r *DisputeRepository) MarkInReview(ctx context.Context, id uuid.UUID, now time.Time) (*domain.Dispute, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE disputes SET status = 'in_review',
+			first_response_at = COALESCE(first_response_at, $1), updated_at = $1
+		WHERE id = $2 AND status IN ('open', 'in_review')
+		RETURNING `+disputeColumns,
+		now, id,
+	)
+	d, err := scanDispute(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.closedOrMissing(ctx, "MarkInReview", id)
+		}
+		return nil, fmt.Errorf("MarkInReview: %w", err)
+	}
+	return d, nil
+}
+
+func (r *DisputeRepository) Resolve(ctx context.Context, id uuid.UUID, status domain.DisputeStatus, resolvedBy uuid.UUID, note string, now time.Time) (*domain.Dispute, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE disputes SET status = $1, resolved_at = $2, resolved_by = $3, resolution_note = $4,
+			first_response_at = COALESCE(first_response_at, $2), updated_at = $2
+		WHERE id = $5 AND status IN ('open', 'in_review')
+		RETURNING `+disputeColumns,
+		status, now, resolvedBy, note, id,
+	)
+	d, err := scanDispute(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.closedOrMissing(ctx, "Resolve", id)
+		}
+		return nil, fmt.Errorf("Resolve: %w", err)
+	}
+	return d, nil
+}
+
+func (r *DisputeRepository) closedOrMissing(ctx context.Context, op string, id uuid.UUID) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fmt.Errorf("%s: %w", op, domain.ErrDisputeClosed)
+}
+
+func collectDisputes(rows pgx.Rows, op string) ([]domain.Dispute, error) {
+	defer rows.Close()
+
+	var disputes []domain.Dispute
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		disputes = append(disputes, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+	return disputes, nil
+}
+
+func scanDispute(s scanner) (*domain.Dispute, error) {
+	var d domain.Dispute
+	var ledgerEntryID, resolvedBy uuid.NullUUID
+	err := s.Scan(
+		&d.ID, &d.UserID, &d.PaymentID, &ledgerEntryID, &d.Reason, &d.Description, &d.Status,
+		&d.RespondBy, &d.ResolveBy, &d.FirstResponseAt, &d.EscalatedAt, &d.ResolvedAt, &resolvedBy,
+		&d.ResolutionNote, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if ledgerEntryID.Valid {
+		d.LedgerEntryID = &ledgerEntryID.UUID
+	}
+	if resolvedBy.Valid {
+		d.ResolvedBy = &resolvedBy.UUID
+	}
+	return &d, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const fxPoolWatermarkColumns = `currency, low_watermark, updated_by, created_at, updated_at`
+
+type FXPoolWatermarkRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFXPoolWatermarkRepository(db *pgxpool.Pool) *FXPoolWatermarkRepository {
+	return &FXPoolWatermarkRepository{db: db}
+}
+
+func (r *FXPoolWatermarkRepository) List(ctx context.Context) ([]domain.FXPoolWatermark, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+fxPoolWatermarkColumns+` FROM fx_pool_watermarks ORDER BY currency`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var marks []domain.FXPoolWatermark
+	for rows.Next() {
+		var m domain.FXPoolWatermark
+		if err := rows.Scan(&m.Currency, &m.LowWatermark, &m.UpdatedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		marks = append(marks, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return marks, nil
+}
+
+// Upsert sets the watermark, keeping the original created_at when one
+// already exists.
+func (r *FXPoolWatermarkRepository) Upsert(ctx context.Context, m *domain.FXPoolWatermark) error {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO fx_pool_watermarks (currency, low_watermark, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (currency) DO UPDATE
+		SET low_watermark = EXCLUDED.low_watermark, updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`,
+		m.Currency, m.LowWatermark, m.UpdatedBy, m.UpdatedAt,
+	).Scan(&m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("Upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *FXPoolWatermarkRepository) Delete(ctx context.Context, currency domain.Currency) error {
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM fx_pool_watermarks WHERE currency = $1`,
+		currency,
+	)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	n := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Delete: %w", domain.ErrNotFound)
+	}
+	return nil
+}
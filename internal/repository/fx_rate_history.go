@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type FXRateHistoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFXRateHistoryRepository(db *pgxpool.Pool) *FXRateHistoryRepository {
+	return &FXRateHistoryRepository{db: db}
+}
+
+// Record writes one refresh's rates in a single statement, so a refresh is
+// recorded whole or not at all.
+func (r *FXRateHistoryRepository) Record(ctx context.Context, rates []domain.FXRateRecord) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	values := make([]string, len(rates))
+	for i, rate := range rates {
+		var via *domain.Currency
+		if rate.Via != "" {
+			via = &rate.Via
+		}
+		values[i] = "(" + strings.Join([]string{
+			arg(rate.FromCurrency), arg(rate.ToCurrency), arg(rate.Tier), arg(rate.MidMarketRate),
+			arg(rate.SpreadPct), arg(via), arg(rate.RecordedAt),
+		}, ", ") + ")"
+	}
+
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO fx_rates_history (from_currency, to_currency, tier, mid_market_rate, spread_pct, via, recorded_at)
+		VALUES `+strings.Join(values, ", "),
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	return nil
+}
+
+// Latest returns the most recent record of every pair and tier.
+func (r *FXRateHistoryRepository) Latest(ctx context.Context) ([]domain.FXRateRecord, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT DISTINCT ON (from_currency, to_currency, tier)
+			from_currency, to_currency, tier, mid_market_rate, spread_pct, COALESCE(via, ''), recorded_at
+		FROM fx_rates_history
+		ORDER BY from_currency, to_currency, tier, recorded_at DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Latest: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.FXRateRecord
+	for rows.Next() {
+		rate, err := scanFXRateRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Latest: scan: %w", err)
+		}
+		out = append(out, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Latest: rows: %w", err)
+	}
+	return out, nil
+}
+
+// History returns the pair's rates for tier oldest first: the one in effect
+// at since, if any was recorded by then, followed by up to limit recorded
+// after since and before until. The bool reports whether more were recorded
+// in the range.
+func (r *FXRateHistoryRepository) History(ctx context.Context, from, to domain.Currency, tier domain.UserTier, since, until time.Time, limit int) ([]domain.FXRateRecord, bool, error) {
+	rows, err := r.db.Query(ctx,
+		`(SELECT from_currency, to_currency, tier, mid_market_rate, spread_pct, COALESCE(via, ''), recorded_at
+			FROM fx_rates_history
+			WHERE from_currency = $1 AND to_currency = $2 AND tier = $3 AND recorded_at <= $4
+			ORDER BY recorded_at DESC LIMIT 1)
+		UNION ALL
+		(SELECT from_currency, to_currency, tier, mid_market_rate, spread_pct, COALESCE(via, ''), recorded_at
+			FROM fx_rates_history
+			WHERE from_currency = $1 AND to_currency = $2 AND tier = $3 AND recorded_at > $4 AND recorded_at < $5
+			ORDER BY recorded_at LIMIT $6)
+		ORDER BY recorded_at`,
+		from, to, tier, since, until, limit+1,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("History: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.FXRateRecord
+	inEffect := false
+	for rows.Next() {
+		rate, err := scanFXRateRecord(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("History: scan: %w", err)
+		}
+		if !rate.RecordedAt.After(since) {
+			inEffect = true
+		}
+		out = append(out, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("History: rows: %w", err)
+	}
+
+	// The rate in effect at since doesn't count towards limit.
+	n := limit
+	if inEffect {
+		n++
+	}
+	if len(out) > n {
+		return out[:n], true, nil
+	}
+	return out, false, nil
+}
+
+func scanFXRateRecord(s scanner) (domain.FXRateRecord, error) {
+	var rate domain.FXRateRecord
+	err := s.Scan(
+		&rate.FromCurrency, &rate.ToCurrency, &rate.Tier, &rate.MidMarketRate, &rate.SpreadPct, &rate.Via, &rate.RecordedAt,
+	)
+	return rate, err
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const holdColumns = `id, account_id, amount, currency, status, reference, payment_id,
+	captured_amount, expires_at, created_at, resolved_at`
+
+type HoldRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHoldRepository(db *pgxpool.Pool) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+func (r *HoldRepository) Create(ctx context.Context, tx pgx.Tx, h *domain.Hold) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO holds (id, account_id, amount, currency, status, reference, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		h.ID, h.AccountID, h.Amount, h.Currency, h.Status, h.Reference, h.ExpiresAt, h.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *HoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Hold, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+holdColumns+` FROM holds WHERE id = $1`, id,
+	)
+	h, err := scanHold(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return h, nil
+}
+
+func (r *HoldRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Hold, error) {
+	row := tx.QueryRow(ctx,
+		`SELECT `+holdColumns+` FROM holds WHERE id = $1 FOR UPDATE`, id,
+	)
+	h, err := scanHold(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return h, nil
+}
+
+// ListForAccount returns an account's holds, newest first. An empty status
+// returns all of them.
+func (r *HoldRepository) ListForAccount(ctx context.Context, accountID uuid.UUID, status domain.HoldStatus, limit, offset int) ([]domain.Hold, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+holdColumns+` FROM holds
+		WHERE account_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4`,
+		accountID, string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListForAccount: %w", err)
+	}
+	return collectHolds(rows, "ListForAccount")
+}
+
+const sumActiveHoldsQuery = `SELECT COALESCE(SUM(amount), 0) FROM holds
+	WHERE account_id = $1 AND status = 'active' AND expires_at > $2`
+
+// SumActive totals the live holds on an account inside tx. Callers lock the
+// account first so no hold can be placed between the sum and their debit.
+func (r *HoldRepository) SumActive(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, now time.Time) (int64, error) {
+	var total int64
+	if err := tx.QueryRow(ctx, sumActiveHoldsQuery, accountID, now).Scan(&total); err != nil {
+		return 0, fmt.Errorf("SumActive: %w", err)
+	}
+	return total, nil
+}
+
+// SumHeld is SumActive outside a transaction, for read models.
+func (r *HoldRepository) SumHeld(ctx context.Context, accountID uuid.UUID, now time.Time) (int64, error) {
+	var total int64
+	if err := r.db.QueryRow(ctx, sumActiveHoldsQuery, accountID, now).Scan(&total); err != nil {
+		return 0, fmt.Errorf("SumHeld: %w", err)
+	}
+	return total, nil
+}
+
+// Capture marks a live hold as used by a payment. Any part of the hold the
+// payment didn't use is freed with it.
+func (r *HoldRepository) Capture(ctx context.Context, tx pgx.Tx, id, paymentID uuid.UUID, amount int64, now time.Time) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE holds SET status = 'captured', payment_id = $1, captured_amount = $2, resolved_at = $3
+		WHERE id = $4 AND status = 'active' AND expires_at > $3 AND amount >= $2`,
+		paymentID, amount, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("Capture: %w", err)
+	}
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Capture: %w", domain.ErrHoldNotActive)
+	}
+	return nil
+}
+
+func (r *HoldRepository) Release(ctx context.Context, id uuid.UUID, now time.Time) (*domain.Hold, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE holds SET status = 'released', resolved_at = $1
+		WHERE id = $2 AND status = 'active'
+		RETURNING `+holdColumns,
+		now, id,
+	)
+	h, err := scanHold(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Release: %w", domain.ErrHoldNotActive)
+		}
+		return nil, fmt.Errorf("Release: %w", err)
+	}
+	return h, nil
+}
+
+func collectHolds(rows pgx.Rows, op string) ([]domain.Hold, error) {
+	defer rows.Close()
+
+	var holds []domain.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		holds = append(holds, *h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+	return holds, nil
+}
+
+func scanHold(s scanner) (*domain.Hold, error) {
+	var h domain.Hold
+	err := s.Scan(&h.ID, &h.AccountID, &h.Amount, &h.Currency, &h.Status, &h.Reference, &h.PaymentID,
+		&h.CapturedAmount, &h.ExpiresAt, &h.CreatedAt, &h.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -2,12 +2,14 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type IdempotencyCacheEntry struct {
@@ -18,39 +20,55 @@ type IdempotencyCacheEntry struct {
 	ResponseBody []byte
 	CreatedAt    time.Time
 	ExpiresAt    time.Time
+
+	// What a conflicting request is told about this one. RequestFields is
+	// a JSON object of the body's top-level fields to hashes of their
+	// values. All are empty for entries stored before they were recorded.
+	RequestMethod    string
+	RequestPath      string
+	RequestFields    []byte
+	ResourceLocation string
 }
 
 type IdempotencyRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
 	return &IdempotencyRepository{db: db}
 }
 
 func (r *IdempotencyRepository) Get(ctx context.Context, key string, userID uuid.UUID) (*IdempotencyCacheEntry, error) {
 	var e IdempotencyCacheEntry
-	err := r.db.QueryRowContext(ctx,
-		`SELECT idempotency_key, user_id, request_hash, status_code, response_body, created_at, expires_at
+	var method, path, location pgtype.Text
+	err := r.db.QueryRow(ctx,
+		`SELECT idempotency_key, user_id, request_hash, status_code, response_body, created_at, expires_at,
+			request_method, request_path, request_fields, resource_location
 		FROM idempotency_cache
 		WHERE idempotency_key = $1 AND user_id = $2 AND expires_at > now()`,
 		key, userID,
-	).Scan(&e.Key, &e.UserID, &e.RequestHash, &e.StatusCode, &e.ResponseBody, &e.CreatedAt, &e.ExpiresAt)
-	if errors.Is(err, sql.ErrNoRows) {
+	).Scan(&e.Key, &e.UserID, &e.RequestHash, &e.StatusCode, &e.ResponseBody, &e.CreatedAt, &e.ExpiresAt,
+		&method, &path, &e.RequestFields, &location)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("Get: %w", err)
 	}
+	e.RequestMethod = method.String
+	e.RequestPath = path.String
+	e.ResourceLocation = location.String
 	return &e, nil
 }
 
 func (r *IdempotencyRepository) Set(ctx context.Context, entry *IdempotencyCacheEntry) error {
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO idempotency_cache (idempotency_key, user_id, request_hash, status_code, response_body, created_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO idempotency_cache (idempotency_key, user_id, request_hash, status_code, response_body, created_at, expires_at,
+			request_method, request_path, request_fields, resource_location)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, NULLIF($11, ''))
 		ON CONFLICT (idempotency_key, user_id) DO NOTHING`,
 		entry.Key, entry.UserID, entry.RequestHash, entry.StatusCode, entry.ResponseBody, entry.CreatedAt, entry.ExpiresAt,
+		entry.RequestMethod, entry.RequestPath, entry.RequestFields, entry.ResourceLocation,
 	)
 	if err != nil {
 		return fmt.Errorf("Set: %w", err)
@@ -59,15 +77,12 @@ func (r *IdempotencyRepository) Set(ctx context.Context, entry *IdempotencyCache
 }
 
 func (r *IdempotencyRepository) CleanExpired(ctx context.Context) (int64, error) {
-	res, err := r.db.ExecContext(ctx,
+	res, err := r.db.Exec(ctx,
 		`DELETE FROM idempotency_cache WHERE expires_at < now()`,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("CleanExpired: %w", err)
 	}
-	n, err := res.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("CleanExpired: rows affected: %w", err)
-	}
+	n := res.RowsAffected()
 	return n, nil
 }
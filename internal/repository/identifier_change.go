@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const identifierChangeColumns = `id, user_id, kind, old_value, new_value, changed_at`
+
+type IdentifierChangeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentifierChangeRepository(db *pgxpool.Pool) *IdentifierChangeRepository {
+	return &IdentifierChangeRepository{db: db}
+}
+
+// Lock serializes changes touching the given values until the transaction
+// ends, so a release and a re-claim of the same value can't interleave.
+// Values are locked in sorted order to avoid deadlocks.
+func (r *IdentifierChangeRepository) Lock(ctx context.Context, tx pgx.Tx, kind domain.IdentifierKind, values ...string) error {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	for _, v := range sorted {
+		if _, err := tx.Exec(ctx,
+			`SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, string(kind), v,
+		); err != nil {
+			return fmt.Errorf("Lock: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *IdentifierChangeRepository) Create(ctx context.Context, tx pgx.Tx, c *domain.IdentifierChange) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO identifier_changes (id, user_id, kind, old_value, new_value, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.ID, c.UserID, c.Kind, c.OldValue, c.NewValue, c.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+// LastReleased returns the most recent change in which another user gave up
+// the value.
+func (r *IdentifierChangeRepository) LastReleased(ctx context.Context, tx pgx.Tx, kind domain.IdentifierKind, value string, claimant uuid.UUID) (*domain.IdentifierChange, error) {
+	row := tx.QueryRow(ctx,
+		`SELECT `+identifierChangeColumns+` FROM identifier_changes
+		WHERE kind = $1 AND old_value = $2 AND user_id <> $3
+		ORDER BY changed_at DESC LIMIT 1`,
+		kind, value, claimant,
+	)
+	c, err := scanIdentifierChange(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("LastReleased: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("LastReleased: %w", err)
+	}
+	return c, nil
+}
+
+// ReassignedSince returns the holder's claim of the value if it happened
+// after since and someone else held the value before them.
+func (r *IdentifierChangeRepository) ReassignedSince(ctx context.Context, kind domain.IdentifierKind, value string, holder uuid.UUID, since time.Time) (*domain.IdentifierChange, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+identifierChangeColumns+` FROM identifier_changes c
+		WHERE c.kind = $1 AND c.new_value = $2 AND c.user_id = $3 AND c.changed_at >= $4
+			AND EXISTS (
+				SELECT 1 FROM identifier_changes prev
+				WHERE prev.kind = c.kind AND prev.old_value = c.new_value
+					AND prev.user_id <> c.user_id AND prev.changed_at <= c.changed_at
+			)
+		ORDER BY c.changed_at DESC LIMIT 1`,
+		kind, value, holder, since,
+	)
+	c, err := scanIdentifierChange(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ReassignedSince: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("ReassignedSince: %w", err)
+	}
+	return c, nil
+}
+
+func (r *IdentifierChangeRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.IdentifierChange, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+identifierChangeColumns+` FROM identifier_changes
+		WHERE user_id = $1 ORDER BY changed_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []domain.IdentifierChange
+	for rows.Next() {
+		c, err := scanIdentifierChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListByUser: scan: %w", err)
+		}
+		changes = append(changes, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListByUser: rows: %w", err)
+	}
+	return changes, nil
+}
+
+func scanIdentifierChange(s scanner) (*domain.IdentifierChange, error) {
+	var c domain.IdentifierChange
+	err := s.Scan(&c.ID, &c.UserID, &c.Kind, &c.OldValue, &c.NewValue, &c.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const kycSubmissionColumns = `id, user_id, requested_tier, document_type, document_ref, status,
+	reviewed_by, reviewed_at, review_note, created_at`
+
+type KYCRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewKYCRepository(db *pgxpool.Pool) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+func (r *KYCRepository) Create(ctx context.Context, s *domain.KYCSubmission) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO kyc_submissions (
+			id, user_id, requested_tier, document_type, document_ref, status, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		s.ID, s.UserID, s.RequestedTier, s.DocumentType, s.DocumentRef, s.Status, s.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_kyc_submissions_pending_user") {
+			return fmt.Errorf("Create: %w", domain.ErrKYCSubmissionPending)
+		}
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *KYCRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.KYCSubmission, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+kycSubmissionColumns+` FROM kyc_submissions WHERE id = $1`, id,
+	)
+	s, err := scanKYCSubmission(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return s, nil
+}
+
+func (r *KYCRepository) LatestForUser(ctx context.Context, userID uuid.UUID) (*domain.KYCSubmission, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+kycSubmissionColumns+` FROM kyc_submissions
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, userID,
+	)
+	s, err := scanKYCSubmission(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("LatestForUser: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("LatestForUser: %w", err)
+	}
+	return s, nil
+}
+
+// List returns submissions for the review queue, oldest first.
+func (r *KYCRepository) List(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+kycSubmissionColumns+` FROM kyc_submissions
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at LIMIT $2 OFFSET $3`,
+		string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []domain.KYCSubmission
+	for rows.Next() {
+		s, err := scanKYCSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		subs = append(subs, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return subs, nil
+}
+
+// Review closes a pending submission. It runs in the caller's transaction so
+// the decision and the user's tier change commit together.
+func (r *KYCRepository) Review(ctx context.Context, tx pgx.Tx, id uuid.UUID, status domain.KYCStatus, reviewer uuid.UUID, note string, now time.Time) (*domain.KYCSubmission, error) {
+	row := tx.QueryRow(ctx,
+		`UPDATE kyc_submissions SET status = $1, reviewed_by = $2, reviewed_at = $3, review_note = $4
+		WHERE id = $5 AND status = 'pending'
+		RETURNING `+kycSubmissionColumns,
+		status, reviewer, now, note, id,
+	)
+	s, err := scanKYCSubmission(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := r.GetByID(ctx, id); err != nil {
+				return nil, fmt.Errorf("Review: %w", err)
+			}
+			return nil, fmt.Errorf("Review: %w", domain.ErrKYCSubmissionClosed)
+		}
+		return nil, fmt.Errorf("Review: %w", err)
+	}
+	return s, nil
+}
+
+func scanKYCSubmission(s scanner) (*domain.KYCSubmission, error) {
+	var k domain.KYCSubmission
+	var reviewedBy uuid.NullUUID
+	err := s.Scan(
+		&k.ID, &k.UserID, &k.RequestedTier, &k.DocumentType, &k.DocumentRef, &k.Status,
+		&reviewedBy, &k.ReviewedAt, &k.ReviewNote, &k.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedBy.Valid {
+		k.ReviewedBy = &reviewedBy.UUID
+	}
+	return &k, nil
+}
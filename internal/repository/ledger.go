@@ -2,32 +2,35 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
-const ledgerColumns = `id, payment_id, account_id, entry_type, amount, currency,
+const ledgerColumns = `id, payment_id, account_id, entry_type, entry_category, amount, currency,
 	balance_before, balance_after, created_at`
 
 type LedgerRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+func NewLedgerRepository(db *pgxpool.Pool) *LedgerRepository {
 	return &LedgerRepository{db: db}
 }
 
-func (r *LedgerRepository) Create(ctx context.Context, tx *sql.Tx, entry *domain.LedgerEntry) error {
-	_, err := tx.ExecContext(ctx,
+func (r *LedgerRepository) Create(ctx context.Context, tx pgx.Tx, entry *domain.LedgerEntry) error {
+	_, err := tx.Exec(ctx,
 		`INSERT INTO ledger_entries (
-			id, payment_id, account_id, entry_type, amount, currency,
+			id, payment_id, account_id, entry_type, entry_category, amount, currency,
 			balance_before, balance_after, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-		entry.ID, entry.PaymentID, entry.AccountID, entry.EntryType,
-		entry.Amount, entry.Currency, entry.BalanceBefore, entry.BalanceAfter,
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.ID, entry.PaymentID, entry.AccountID, entry.EntryType, entry.Category,
+		entry.Amount.Amount, entry.Amount.Currency, entry.BalanceBefore, entry.BalanceAfter,
 		entry.CreatedAt,
 	)
 	if err != nil {
@@ -36,22 +39,59 @@ func (r *LedgerRepository) Create(ctx context.Context, tx *sql.Tx, entry *domain
 	return nil
 }
 
-func (r *LedgerRepository) GetByAccountID(ctx context.Context, accountID uuid.UUID, limit, offset int) ([]domain.LedgerEntry, int, error) {
-	var total int
-	err := r.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM ledger_entries WHERE account_id = $1`, accountID,
-	).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("GetByAccountID: count: %w", err)
+// CreateBatch inserts all of a payment's entries in one multi-row INSERT,
+// so a cross-currency transfer makes one round trip inside the transaction
+// instead of four or five.
+func (r *LedgerRepository) CreateBatch(ctx context.Context, tx pgx.Tx, entries []*domain.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
 	}
 
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT `+ledgerColumns+` FROM ledger_entries
-		WHERE account_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
-		accountID, limit, offset,
+	const cols = 10
+	rows := make([]string, len(entries))
+	args := make([]any, 0, len(entries)*cols)
+	for i, e := range entries {
+		n := i * cols
+		rows[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+		args = append(args,
+			e.ID, e.PaymentID, e.AccountID, e.EntryType, e.Category,
+			e.Amount.Amount, e.Amount.Currency, e.BalanceBefore, e.BalanceAfter,
+			e.CreatedAt,
+		)
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO ledger_entries (
+			id, payment_id, account_id, entry_type, entry_category, amount, currency,
+			balance_before, balance_after, created_at
+		) VALUES `+strings.Join(rows, ", "),
+		args...,
 	)
 	if err != nil {
-		return nil, 0, fmt.Errorf("GetByAccountID: %w", err)
+		return fmt.Errorf("CreateBatch: %w", err)
+	}
+	return nil
+}
+
+// GetByAccountID returns up to limit of the account's entries, newest
+// first, starting after before (nil for the first page). The bool reports
+// whether more entries follow. Pages are keyed on (created_at, id), so
+// entries written between requests never shift a page.
+func (r *LedgerRepository) GetByAccountID(ctx context.Context, accountID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error) {
+	query := `SELECT ` + ledgerColumns + ` FROM ledger_entries WHERE account_id = $1`
+	args := []any{accountID}
+	if before != nil {
+		// The plain bound on created_at lets Postgres skip newer partitions.
+		query += ` AND created_at <= $2 AND (created_at, id) < ($2, $3)`
+		args = append(args, before.CreatedAt, before.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("GetByAccountID: %w", err)
 	}
 	defer rows.Close()
 
@@ -59,20 +99,25 @@ func (r *LedgerRepository) GetByAccountID(ctx context.Context, accountID uuid.UU
 	for rows.Next() {
 		e, err := scanLedgerEntry(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("GetByAccountID: scan: %w", err)
+			return nil, false, fmt.Errorf("GetByAccountID: scan: %w", err)
 		}
 		entries = append(entries, *e)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("GetByAccountID: rows: %w", err)
+		return nil, false, fmt.Errorf("GetByAccountID: rows: %w", err)
+	}
+
+	if len(entries) > limit {
+		return entries[:limit], true, nil
 	}
-	return entries, total, nil
+	return entries, false, nil
 }
 
 func (r *LedgerRepository) GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error) {
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := r.db.Query(ctx,
 		`SELECT `+ledgerColumns+` FROM ledger_entries
-		WHERE payment_id = $1 ORDER BY created_at`, paymentID,
+		WHERE payment_id = $1 AND created_at >= `+paymentCreatedAt("$1")+`
+		ORDER BY created_at`, paymentID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("GetByPaymentID: %w", err)
@@ -93,11 +138,163 @@ func (r *LedgerRepository) GetByPaymentID(ctx context.Context, paymentID uuid.UU
 	return entries, nil
 }
 
+// GetByPaymentIDs returns the ledger entries of the given payments, in
+// creation order.
+func (r *LedgerRepository) GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.LedgerEntry, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+ledgerColumns+` FROM ledger_entries
+		WHERE payment_id = ANY($1::uuid[])
+		AND created_at >= (SELECT min(created_at) FROM payment_keys WHERE id = ANY($1::uuid[]))
+		ORDER BY created_at`,
+		paymentIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.LedgerEntry
+	for rows.Next() {
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetByPaymentIDs: scan: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: rows: %w", err)
+	}
+	return entries, nil
+}
+
+// AccountsWithEntriesSince returns the accounts that have ledger entries
+// created at or after since.
+func (r *LedgerRepository) AccountsWithEntriesSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT DISTINCT account_id FROM ledger_entries WHERE created_at >= $1`, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("AccountsWithEntriesSince: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("AccountsWithEntriesSince: scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("AccountsWithEntriesSince: rows: %w", err)
+	}
+	return ids, nil
+}
+
+// ListByAccountSince returns an account's entries created at or after since,
+// oldest first, preceded by the last entry before since so the first one in
+// the window has something to chain from.
+func (r *LedgerRepository) ListByAccountSince(ctx context.Context, accountID uuid.UUID, since time.Time) ([]domain.LedgerEntry, error) {
+	rows, err := r.db.Query(ctx,
+		`(SELECT `+ledgerColumns+` FROM ledger_entries
+			WHERE account_id = $1 AND created_at < $2
+			ORDER BY created_at DESC, id DESC LIMIT 1)
+		UNION ALL
+		(SELECT `+ledgerColumns+` FROM ledger_entries
+			WHERE account_id = $1 AND created_at >= $2)
+		ORDER BY created_at, id`,
+		accountID, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByAccountSince: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.LedgerEntry
+	for rows.Next() {
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListByAccountSince: scan: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListByAccountSince: rows: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *LedgerRepository) FeeRevenueByCurrency(ctx context.Context, from, to time.Time) ([]domain.FeeRevenue, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT a.currency, a.id, a.balance,
+			COALESCE(SUM(l.amount) FILTER (WHERE l.entry_category = 'fee'), 0),
+			COALESCE(SUM(l.amount) FILTER (WHERE l.entry_category = 'reversal'), 0),
+			COUNT(l.id)
+		FROM accounts a
+		LEFT JOIN ledger_entries l ON l.account_id = a.id AND l.created_at >= $1 AND l.created_at < $2
+		WHERE a.account_type = 'revenue'
+		GROUP BY a.currency, a.id, a.balance
+		ORDER BY a.currency`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FeeRevenueByCurrency: %w", err)
+	}
+	defer rows.Close()
+
+	var report []domain.FeeRevenue
+	for rows.Next() {
+		var f domain.FeeRevenue
+		if err := rows.Scan(&f.Currency, &f.AccountID, &f.Balance, &f.Earned, &f.Reversed, &f.EntryCount); err != nil {
+			return nil, fmt.Errorf("FeeRevenueByCurrency: scan: %w", err)
+		}
+		report = append(report, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FeeRevenueByCurrency: rows: %w", err)
+	}
+	return report, nil
+}
+
+// TotalsByCategory sums the debits and credits written from..to per
+// currency and entry category, ordered by currency.
+func (r *LedgerRepository) TotalsByCategory(ctx context.Context, from, to time.Time) ([]domain.LedgerCategoryTotal, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT currency, entry_category,
+			COALESCE(SUM(amount) FILTER (WHERE entry_type = 'debit'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE entry_type = 'credit'), 0),
+			COUNT(*)
+		FROM ledger_entries
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY currency, entry_category
+		ORDER BY currency, entry_category`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("TotalsByCategory: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.LedgerCategoryTotal
+	for rows.Next() {
+		var t domain.LedgerCategoryTotal
+		if err := rows.Scan(&t.Currency, &t.Category, &t.Debits, &t.Credits, &t.EntryCount); err != nil {
+			return nil, fmt.Errorf("TotalsByCategory: scan: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("TotalsByCategory: rows: %w", err)
+	}
+	return totals, nil
+}
+
 func scanLedgerEntry(s scanner) (*domain.LedgerEntry, error) {
 	var e domain.LedgerEntry
 	err := s.Scan(
-		&e.ID, &e.PaymentID, &e.AccountID, &e.EntryType,
-		&e.Amount, &e.Currency, &e.BalanceBefore, &e.BalanceAfter,
+		&e.ID, &e.PaymentID, &e.AccountID, &e.EntryType, &e.Category,
+		&e.Amount.Amount, &e.Amount.Currency, &e.BalanceBefore, &e.BalanceAfter,
 		&e.CreatedAt,
 	)
 	if err != nil {
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const ledgerChainBreakColumns = `id, account_id, entry_id, entry_created_at, prev_entry_id, prev_created_at,
+	expected_before, actual_before, detected_at, annotation, repair_payment_id, annotated_by, annotated_at`
+
+type LedgerChainBreakRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLedgerChainBreakRepository(db *pgxpool.Pool) *LedgerChainBreakRepository {
+	return &LedgerChainBreakRepository{db: db}
+}
+
+// Record stores a break. It reports false when the entry already has a
+// break recorded, so a break seen again on a later run isn't reported twice.
+func (r *LedgerChainBreakRepository) Record(ctx context.Context, b *domain.LedgerChainBreak) (bool, error) {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO ledger_chain_breaks (
+			id, account_id, entry_id, entry_created_at, prev_entry_id, prev_created_at,
+			expected_before, actual_before, detected_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (entry_id) DO NOTHING
+		RETURNING id`,
+		b.ID, b.AccountID, b.EntryID, b.EntryCreatedAt, b.PrevEntryID, b.PrevCreatedAt,
+		b.ExpectedBefore, b.ActualBefore, b.DetectedAt,
+	).Scan(&b.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("Record: %w", err)
+	}
+	return true, nil
+}
+
+// List returns breaks, newest first. A nil annotated returns all of them.
+func (r *LedgerChainBreakRepository) List(ctx context.Context, annotated *bool, limit, offset int) ([]domain.LedgerChainBreak, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+ledgerChainBreakColumns+` FROM ledger_chain_breaks
+		WHERE $1::boolean IS NULL OR (annotated_at IS NOT NULL) = $1::boolean
+		ORDER BY detected_at DESC, id
+		LIMIT $2 OFFSET $3`,
+		annotated, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var breaks []domain.LedgerChainBreak
+	for rows.Next() {
+		b, err := scanLedgerChainBreak(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		breaks = append(breaks, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return breaks, nil
+}
+
+func (r *LedgerChainBreakRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LedgerChainBreak, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+ledgerChainBreakColumns+` FROM ledger_chain_breaks WHERE id = $1`, id,
+	)
+	b, err := scanLedgerChainBreak(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return b, nil
+}
+
+// Annotate records how an open break was dealt with. A break can only be
+// annotated once.
+func (r *LedgerChainBreakRepository) Annotate(ctx context.Context, id uuid.UUID, note string, repairPaymentID *uuid.UUID, staffID uuid.UUID, at time.Time) (*domain.LedgerChainBreak, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE ledger_chain_breaks
+		SET annotation = $1, repair_payment_id = $2, annotated_by = $3, annotated_at = $4
+		WHERE id = $5 AND annotated_at IS NULL
+		RETURNING `+ledgerChainBreakColumns,
+		note, repairPaymentID, staffID, at, id,
+	)
+	b, err := scanLedgerChainBreak(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := r.GetByID(ctx, id); err != nil {
+				return nil, fmt.Errorf("Annotate: %w", err)
+			}
+			return nil, fmt.Errorf("Annotate: %w", domain.ErrChainBreakAnnotated)
+		}
+		return nil, fmt.Errorf("Annotate: %w", err)
+	}
+	return b, nil
+}
+
+func scanLedgerChainBreak(s scanner) (*domain.LedgerChainBreak, error) {
+	var b domain.LedgerChainBreak
+	err := s.Scan(
+		&b.ID, &b.AccountID, &b.EntryID, &b.EntryCreatedAt, &b.PrevEntryID, &b.PrevCreatedAt,
+		&b.ExpectedBefore, &b.ActualBefore, &b.DetectedAt, &b.Annotation, &b.RepairPaymentID,
+		&b.AnnotatedBy, &b.AnnotatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
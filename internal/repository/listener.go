@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WebhookEventsChannel is notified after every insert into webhook_events.
+const WebhookEventsChannel = "webhook_events"
+
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+	// A connection that died without a TCP reset is only noticed on use, so
+	// an idle listener pings this often.
+	listenerPingInterval = 90 * time.Second
+)
+
+// Listener turns Postgres notifications on one channel into wake-ups. It
+// holds its own connection outside the pool and reconnects by itself.
+// Wake-ups are coalesced: notifications that arrive while the consumer is
+// busy leave a single one pending. A reconnect sends one too, since anything
+// notified while the connection was down is lost.
+type Listener struct {
+	databaseURL string
+	channel     string
+	logger      *slog.Logger
+	wake        chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewListener connects and starts listening on channel. It gives up when ctx
+// is done before the connection is up.
+func NewListener(ctx context.Context, databaseURL, channel string, logger *slog.Logger) (*Listener, error) {
+	l := &Listener{
+		databaseURL: databaseURL,
+		channel:     channel,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NewListener: listen %s: %w", channel, err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	go l.run(runCtx, conn)
+	return l, nil
+}
+
+// Notifications receives a value when the channel has been notified since
+// the last receive.
+func (l *Listener) Notifications() <-chan struct{} {
+	return l.wake
+}
+
+func (l *Listener) Close() error {
+	l.once.Do(func() {
+		l.cancel()
+		<-l.done
+	})
+	return nil
+}
+
+func (l *Listener) connect(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, l.databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (l *Listener) run(ctx context.Context, conn *pgx.Conn) {
+	defer close(l.done)
+	defer func() {
+		if conn != nil {
+			conn.Close(context.Background())
+		}
+	}()
+
+	backoff := listenerMinBackoff
+	for {
+		if conn == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			c, err := l.connect(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				l.logger.Warn("listener reconnect failed", "channel", l.channel, "error", err)
+				backoff = min(backoff*2, listenerMaxBackoff)
+				continue
+			}
+			conn, backoff = c, listenerMinBackoff
+			l.logger.Info("listener reconnected", "channel", l.channel)
+			l.notify()
+		}
+
+		if err := l.wait(ctx, conn); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.logger.Warn("listener disconnected", "channel", l.channel, "error", err)
+			conn.Close(context.Background())
+			conn = nil
+		}
+	}
+}
+
+// wait blocks until a notification arrives, pinging the connection when none
+// has for listenerPingInterval. It returns an error when the connection is
+// lost or ctx is done.
+func (l *Listener) wait(ctx context.Context, conn *pgx.Conn) error {
+	waitCtx, cancel := context.WithTimeout(ctx, listenerPingInterval)
+	defer cancel()
+
+	_, err := conn.WaitForNotification(waitCtx)
+	switch {
+	case err == nil:
+		l.notify()
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		return conn.Ping(ctx)
+	default:
+		return err
+	}
+}
+
+func (l *Listener) notify() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const loginThrottleColumns = `subject, failures, window_start, locked_until, lockouts`
+
+type LoginThrottleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginThrottleRepository(db *pgxpool.Pool) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func scanLoginThrottle(row interface{ Scan(...any) error }) (*domain.LoginThrottle, error) {
+	var t domain.LoginThrottle
+	if err := row.Scan(&t.Subject, &t.Failures, &t.WindowStart, &t.LockedUntil, &t.Lockouts); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns the throttles that exist among subjects.
+func (r *LoginThrottleRepository) List(ctx context.Context, subjects ...string) ([]domain.LoginThrottle, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+loginThrottleColumns+` FROM login_throttles WHERE subject = ANY($1)`,
+		subjects,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var throttles []domain.LoginThrottle
+	for rows.Next() {
+		t, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		throttles = append(throttles, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return throttles, nil
+}
+
+// GetForUpdate locks the subject's throttle, creating an empty one first so
+// concurrent failures for a new subject queue on the same row.
+func (r *LoginThrottleRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, subject string) (*domain.LoginThrottle, error) {
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO login_throttles (subject) VALUES ($1) ON CONFLICT (subject) DO NOTHING`, subject,
+	); err != nil {
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	t, err := scanLoginThrottle(tx.QueryRow(ctx,
+		`SELECT `+loginThrottleColumns+` FROM login_throttles WHERE subject = $1 FOR UPDATE`, subject,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return t, nil
+}
+
+func (r *LoginThrottleRepository) Update(ctx context.Context, tx pgx.Tx, t *domain.LoginThrottle) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE login_throttles SET failures = $2, window_start = $3, locked_until = $4, lockouts = $5
+		WHERE subject = $1`,
+		t.Subject, t.Failures, t.WindowStart, t.LockedUntil, t.Lockouts,
+	)
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	return nil
+}
+
+// Reset forgets a subject's failures after a successful login.
+func (r *LoginThrottleRepository) Reset(ctx context.Context, subject string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM login_throttles WHERE subject = $1`, subject); err != nil {
+		return fmt.Errorf("Reset: %w", err)
+	}
+	return nil
+}
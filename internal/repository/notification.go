@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const notificationColumns = `id, user_id, kind, subject, body, payment_id,
+	template_name, template_locale, template_version, created_at, read_at`
+
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func scanNotification(row interface{ Scan(...any) error }) (*domain.Notification, error) {
+	var n domain.Notification
+	err := row.Scan(
+		&n.ID, &n.UserID, &n.Kind, &n.Subject, &n.Body, &n.PaymentID,
+		&n.TemplateName, &n.TemplateLocale, &n.TemplateVersion, &n.CreatedAt, &n.ReadAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Create adds n to its user's feed. A second notification of the same kind
+// about the same payment for the same user is dropped.
+func (r *NotificationRepository) Create(ctx context.Context, tx pgx.Tx, n *domain.Notification) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO notifications (id, user_id, kind, subject, body, payment_id,
+			template_name, template_locale, template_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (payment_id, kind, user_id) WHERE payment_id IS NOT NULL DO NOTHING`,
+		n.ID, n.UserID, n.Kind, n.Subject, n.Body, n.PaymentID,
+		n.TemplateName, n.TemplateLocale, n.TemplateVersion, n.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+// PaymentParties reads who a payment was between inside tx, so it sees a
+// payment created earlier in the same transaction.
+func (r *NotificationRepository) PaymentParties(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID) (*domain.PaymentParties, error) {
+	var p domain.PaymentParties
+	err := tx.QueryRow(ctx,
+		`SELECT p.id, p.type, p.source_amount, p.source_currency, p.dest_amount, p.dest_currency,
+			sa.user_id, su.unique_name, da.user_id
+		FROM payments p
+		JOIN accounts sa ON sa.id = p.source_account_id
+		JOIN users su ON su.id = sa.user_id
+		LEFT JOIN accounts da ON da.id = p.dest_account_id
+		WHERE p.id = $1 AND p.created_at = `+paymentCreatedAt("$1"),
+		paymentID,
+	).Scan(
+		&p.PaymentID, &p.Type, &p.Source.Amount, &p.Source.Currency, &p.Dest.Amount, &p.Dest.Currency,
+		&p.SenderID, &p.SenderTag, &p.RecipientID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("PaymentParties: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("PaymentParties: %w", err)
+	}
+	return &p, nil
+}
+
+// List returns up to limit of the user's notifications, newest first,
+// starting after before (nil for the first page). The bool reports whether
+// more follow.
+func (r *NotificationRepository) List(ctx context.Context, userID uuid.UUID, unreadOnly bool, before *domain.NotificationCursor, limit int) ([]domain.Notification, bool, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1`
+	args := []any{userID}
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	if before != nil {
+		args = append(args, before.CreatedAt, before.ID)
+		query += fmt.Sprintf(` AND (created_at, id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("List: scan: %w", err)
+		}
+		out = append(out, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("List: rows: %w", err)
+	}
+
+	if len(out) > limit {
+		return out[:limit], true, nil
+	}
+	return out, false, nil
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx,
+		`SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("CountUnread: %w", err)
+	}
+	return n, nil
+}
+
+// MarkRead marks one of the user's notifications read. Marking it again
+// keeps the first read time.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uuid.UUID, now time.Time) (*domain.Notification, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE notifications SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+notificationColumns,
+		id, userID, now,
+	)
+	n, err := scanNotification(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("MarkRead: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("MarkRead: %w", err)
+	}
+	return n, nil
+}
+
+// MarkAllRead marks every unread notification of the user's read and
+// returns how many there were.
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error) {
+	res, err := r.db.Exec(ctx,
+		`UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`,
+		userID, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("MarkAllRead: %w", err)
+	}
+	n := res.RowsAffected()
+	return n, nil
+}
+
+// ListUnemailed returns notifications not yet emailed that have failed
+// fewer than maxAttempts times, oldest first, with their user's address.
+func (r *NotificationRepository) ListUnemailed(ctx context.Context, maxAttempts, limit int) ([]domain.NotificationEmail, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT n.id, n.user_id, n.kind, n.subject, n.body, n.payment_id,
+			n.template_name, n.template_locale, n.template_version, n.created_at, n.read_at,
+			u.email, n.email_attempts
+		FROM notifications n
+		JOIN users u ON u.id = n.user_id
+		WHERE n.emailed_at IS NULL AND n.email_attempts < $1
+		ORDER BY n.created_at, n.id
+		LIMIT $2`,
+		maxAttempts, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListUnemailed: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.NotificationEmail
+	for rows.Next() {
+		var e domain.NotificationEmail
+		n := &e.Notification
+		err := rows.Scan(
+			&n.ID, &n.UserID, &n.Kind, &n.Subject, &n.Body, &n.PaymentID,
+			&n.TemplateName, &n.TemplateLocale, &n.TemplateVersion, &n.CreatedAt, &n.ReadAt,
+			&e.Email, &e.Attempts,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ListUnemailed: scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListUnemailed: rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *NotificationRepository) MarkEmailed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE notifications SET emailed_at = $2, email_error = NULL WHERE id = $1`,
+		id, now,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkEmailed: %w", err)
+	}
+	return nil
+}
+
+// EmailFailed counts a failed send and keeps its error.
+func (r *NotificationRepository) EmailFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE notifications SET email_attempts = email_attempts + 1, email_error = $2 WHERE id = $1`,
+		id, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("EmailFailed: %w", err)
+	}
+	return nil
+}
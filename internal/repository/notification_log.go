@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type NotificationLogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationLogRepository(db *pgxpool.Pool) *NotificationLogRepository {
+	return &NotificationLogRepository{db: db}
+}
+
+func (r *NotificationLogRepository) Record(ctx context.Context, userID uuid.UUID, kind string, tmpl templates.Ref, sentAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO notification_log (id, user_id, kind, template_name, template_locale, template_version, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New(), userID, kind, tmpl.Name, tmpl.Locale, tmpl.Version, sentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const outboxEventColumns = `id, topic, key, payload, attempts, last_error, created_at, published_at, fanned_out_at`
+
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func scanOutboxEvent(row interface{ Scan(...any) error }) (*domain.OutboxEvent, error) {
+	var e domain.OutboxEvent
+	err := row.Scan(&e.ID, &e.Topic, &e.Key, &e.Payload, &e.Attempts, &e.LastError, &e.CreatedAt, &e.PublishedAt, &e.FannedOutAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Create writes e in tx, so it is only published if tx commits.
+func (r *OutboxRepository) Create(ctx context.Context, tx pgx.Tx, e *domain.OutboxEvent) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO outbox_events (id, topic, key, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		e.ID, e.Topic, e.Key, []byte(e.Payload), e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+// ListUnpublished returns up to limit unpublished events, oldest first,
+// starting after after (nil for the first page).
+func (r *OutboxRepository) ListUnpublished(ctx context.Context, after *domain.OutboxCursor, limit int) ([]domain.OutboxEvent, error) {
+	query := `SELECT ` + outboxEventColumns + ` FROM outbox_events WHERE published_at IS NULL`
+	args := []any{limit}
+	if after != nil {
+		query += ` AND (created_at, id) > ($2, $3)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	rows, err := r.db.Query(ctx, query+` ORDER BY created_at, id LIMIT $1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ListUnpublished: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListUnpublished: scan: %w", err)
+		}
+		out = append(out, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListUnpublished: rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uuid.UUID, now time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE outbox_events SET published_at = $2, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1 AND published_at IS NULL`, id, now,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkPublished: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish. The event stays unpublished and is
+// retried on the next poll.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE outbox_events SET attempts = attempts + 1, last_error = $2
+		WHERE id = $1 AND published_at IS NULL`, id, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkFailed: %w", err)
+	}
+	return nil
+}
+
+// ListPendingFanOut returns up to limit events the fan-out stage hasn't
+// handled yet, oldest first.
+func (r *OutboxRepository) ListPendingFanOut(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+outboxEventColumns+` FROM outbox_events WHERE fanned_out_at IS NULL
+		ORDER BY created_at, id LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListPendingFanOut: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListPendingFanOut: scan: %w", err)
+		}
+		out = append(out, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListPendingFanOut: rows: %w", err)
+	}
+	return out, nil
+}
+
+// MarkFannedOut stamps the event in tx, so it commits with whatever the
+// fan-out stage wrote for it.
+func (r *OutboxRepository) MarkFannedOut(ctx context.Context, tx pgx.Tx, id uuid.UUID, now time.Time) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE outbox_events SET fanned_out_at = $2 WHERE id = $1 AND fanned_out_at IS NULL`, id, now,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkFannedOut: %w", err)
+	}
+	return nil
+}
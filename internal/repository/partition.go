@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PartitionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPartitionRepository(db *pgxpool.Pool) *PartitionRepository {
+	return &PartitionRepository{db: db}
+}
+
+// EnsureMonthly creates any missing monthly partitions of table covering
+// from..to and returns the names of those it created.
+func (r *PartitionRepository) EnsureMonthly(ctx context.Context, table string, from, to time.Time) ([]string, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT ensure_monthly_partitions($1, $2, $3)`,
+		table, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("EnsureMonthly: %w", err)
+	}
+	defer rows.Close()
+
+	var created []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("EnsureMonthly: scan: %w", err)
+		}
+		created = append(created, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("EnsureMonthly: rows: %w", err)
+	}
+	return created, nil
+}
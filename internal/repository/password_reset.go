@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const passwordResetColumns = `id, user_id, token_hash, created_ip, created_at, expires_at, used_at`
+
+type PasswordResetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(db *pgxpool.Pool) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func scanPasswordResetToken(row interface{ Scan(...any) error }) (*domain.PasswordResetToken, error) {
+	var t domain.PasswordResetToken
+	if err := row.Scan(&t.ID, &t.UserID, &t.TokenHash, &t.CreatedIP, &t.CreatedAt, &t.ExpiresAt, &t.UsedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, t *domain.PasswordResetToken) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO password_reset_tokens (id, user_id, token_hash, created_ip, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.ID, t.UserID, t.TokenHash, t.CreatedIP, t.CreatedAt, t.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *PasswordResetRepository) GetByHashForUpdate(ctx context.Context, tx pgx.Tx, hash []byte) (*domain.PasswordResetToken, error) {
+	t, err := scanPasswordResetToken(tx.QueryRow(ctx,
+		`SELECT `+passwordResetColumns+` FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`, hash,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByHashForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByHashForUpdate: %w", err)
+	}
+	return t, nil
+}
+
+// SpendAll marks every unused token of the user as used.
+func (r *PasswordResetRepository) SpendAll(ctx context.Context, tx pgx.Tx, userID uuid.UUID, at time.Time) error {
+	if _, err := tx.Exec(ctx,
+		`UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`, userID, at,
+	); err != nil {
+		return fmt.Errorf("SpendAll: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,13 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
@@ -18,52 +18,85 @@ const paymentColumns = `id, idempotency_key, type, status, source_account_id,
 	dest_account_id, dest_account_number, dest_iban, dest_swift_bic, dest_bank_name,
 	source_amount, source_currency, dest_amount, dest_currency, exchange_rate,
 	fee_amount, fee_currency, provider, provider_ref, failure_reason, metadata,
-	created_at, updated_at, completed_at`
+	created_at, updated_at, completed_at, failure_code, retry_of_payment_id,
+	mid_market_rate, slippage_amount, submitted_at, review_reason, reversal_of_payment_id,
+	refund_of_payment_id, refunded_amount, beneficiary_id, user_tier`
+
+// paymentCreatedAt resolves a payment's partition key from payment_keys so
+// lookups by id touch a single partition.
+func paymentCreatedAt(idParam string) string {
+	return `(SELECT created_at FROM payment_keys WHERE id = ` + idParam + `)`
+}
+
+// bankPayoutPredicate matches payments that leave through a provider:
+// external payouts, and sweeps of a closing account out to a bank.
+const bankPayoutPredicate = `(type = 'external_payout' OR (type = 'sweep' AND dest_account_id IS NULL))`
 
 type PaymentRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+func NewPaymentRepository(db *pgxpool.Pool) *PaymentRepository {
 	return &PaymentRepository{db: db}
 }
 
-func (r *PaymentRepository) Create(ctx context.Context, tx *sql.Tx, payment *domain.Payment) error {
-	_, err := tx.ExecContext(ctx,
+// Create registers the payment in payment_keys, which enforces idempotency,
+// retry and reversal uniqueness across partitions, then inserts the payment row.
+func (r *PaymentRepository) Create(ctx context.Context, tx pgx.Tx, payment *domain.Payment) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO payment_keys (id, idempotency_key, source_account_id, retry_of_payment_id, reversal_of_payment_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		payment.ID, payment.IdempotencyKey, payment.SourceAccountID, payment.RetryOfPaymentID, payment.ReversalOfPaymentID, payment.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_payment_keys_idempotency") {
+			return fmt.Errorf("Create: %w", domain.ErrDuplicateIdempotencyKey)
+		}
+		if isUniqueViolation(err, "idx_payment_keys_retry_of") {
+			return fmt.Errorf("Create: %w", domain.ErrPaymentAlreadyRetried)
+		}
+		if isUniqueViolation(err, "idx_payment_keys_reversal_of") {
+			return fmt.Errorf("Create: %w", domain.ErrPaymentNotReversible)
+		}
+		return fmt.Errorf("Create: key: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
 		`INSERT INTO payments (
 			id, idempotency_key, type, status, source_account_id,
 			dest_account_id, dest_account_number, dest_iban, dest_swift_bic, dest_bank_name,
 			source_amount, source_currency, dest_amount, dest_currency, exchange_rate,
 			fee_amount, fee_currency, provider, provider_ref, failure_reason, metadata,
-			created_at, updated_at, completed_at
+			created_at, updated_at, completed_at, failure_code, retry_of_payment_id,
+			mid_market_rate, slippage_amount, review_reason, reversal_of_payment_id,
+			refund_of_payment_id, beneficiary_id, user_tier
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
 			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
-			$22, $23, $24
+			$22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33
 		)`,
 		payment.ID, payment.IdempotencyKey, payment.Type, payment.Status, payment.SourceAccountID,
 		payment.DestAccountID, payment.DestAccountNumber, payment.DestIBAN, payment.DestSwiftBIC, payment.DestBankName,
-		payment.SourceAmount, payment.SourceCurrency, payment.DestAmount, payment.DestCurrency, payment.ExchangeRate,
-		payment.FeeAmount, payment.FeeCurrency, payment.Provider, payment.ProviderRef, payment.FailureReason, payment.Metadata,
-		payment.CreatedAt, payment.UpdatedAt, payment.CompletedAt,
+		payment.Source.Amount, payment.Source.Currency, payment.Dest.Amount, payment.Dest.Currency, payment.ExchangeRate,
+		payment.Fee.Amount, feeCurrency(payment.Fee), payment.Provider, payment.ProviderRef, payment.FailureReason, payment.Metadata,
+		payment.CreatedAt, payment.UpdatedAt, payment.CompletedAt, payment.FailureCode, payment.RetryOfPaymentID,
+		payment.MidMarketRate, slippageAmount(payment.Slippage), payment.ReviewReason, payment.ReversalOfPaymentID,
+		payment.RefundOfPaymentID, payment.BeneficiaryID, payment.UserTier,
 	)
 	if err != nil {
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_payments_idempotency_key" {
-			return fmt.Errorf("Create: %w", domain.ErrDuplicateIdempotencyKey)
-		}
 		return fmt.Errorf("Create: %w", err)
 	}
 	return nil
 }
 
 func (r *PaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
-	row := r.db.QueryRowContext(ctx,
-		`SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id,
+	row := r.db.QueryRow(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE id = $1 AND created_at = `+paymentCreatedAt("$1"), id,
 	)
 	p, err := scanPayment(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByID: %w", err)
@@ -71,23 +104,396 @@ func (r *PaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 	return p, nil
 }
 
+// GetByIDs returns the payments with the given IDs. IDs with no payment
+// are left out; order is unspecified.
+func (r *PaymentRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Payment, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE (id, created_at) IN (
+			SELECT id, created_at FROM payment_keys WHERE id = ANY($1::uuid[])
+		)`,
+		ids,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetByIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetByIDs: scan: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetByIDs: rows: %w", err)
+	}
+	return payments, nil
+}
+
+// GetByIdempotencyKey finds the payment an account created with the given key,
+// in whichever partition it landed.
+func (r *PaymentRepository) GetByIdempotencyKey(ctx context.Context, sourceAccountID uuid.UUID, key string) (*domain.Payment, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE (id, created_at) = (
+			SELECT id, created_at FROM payment_keys
+			WHERE idempotency_key = $1 AND source_account_id = $2
+		)`,
+		key, sourceAccountID,
+	)
+	p, err := scanPayment(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByIdempotencyKey: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByIdempotencyKey: %w", err)
+	}
+	return p, nil
+}
+
+// GetByUserIdempotencyKey finds the payment created with the given key from
+// any of the user's accounts. Keys are unique per account, so a key the user
+// reused on two accounts gives the newer payment.
+func (r *PaymentRepository) GetByUserIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.Payment, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE (id, created_at) = (
+			SELECT k.id, k.created_at FROM payment_keys k
+			JOIN accounts a ON a.id = k.source_account_id
+			WHERE k.idempotency_key = $1 AND a.user_id = $2
+			ORDER BY k.created_at DESC
+			LIMIT 1
+		)`,
+		key, userID,
+	)
+	p, err := scanPayment(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByUserIdempotencyKey: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByUserIdempotencyKey: %w", err)
+	}
+	return p, nil
+}
+
+// UpdateStatus moves a payment from status from, the one the caller read,
+// to status to. It is a compare-and-set: the update only applies if the
+// payment still has from, so of two webhooks racing on one payment only the
+// first applies. The move itself must be allowed (see
+// domain.PaymentStatus.CanTransition), or domain.ErrInvalidTransition is
+// returned. When the payment has moved on, the error is
+// domain.ErrPaymentTerminal if it has reached an outcome and
+// domain.ErrVersionConflict otherwise, so the caller can read it again.
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, tx pgx.Tx, id uuid.UUID, from, to domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error {
+	if !from.CanTransition(to) {
+		return fmt.Errorf("UpdateStatus: %s to %s: %w", from, to, domain.ErrInvalidTransition)
+	}
 
-func (r *PaymentRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error {
-	res, err := tx.ExecContext(ctx,
+	res, err := tx.Exec(ctx,
 		`UPDATE payments SET status = $1, provider_ref = $2, failure_reason = $3, completed_at = $4, updated_at = now()
-		WHERE id = $5 AND status NOT IN ('completed', 'failed', 'reversed')`,
-		status, providerRef, failureReason, completedAt, id,
+		WHERE id = $5 AND created_at = `+paymentCreatedAt("$5")+` AND status = $6`,
+		to, providerRef, failureReason, completedAt, id, from,
 	)
 	if err != nil {
 		return fmt.Errorf("UpdateStatus: %w", err)
 	}
 
-	rows, err := res.RowsAffected()
+	rows := res.RowsAffected()
+	if rows == 0 {
+		var current domain.PaymentStatus
+		err := tx.QueryRow(ctx,
+			`SELECT status FROM payments WHERE id = $1 AND created_at = `+paymentCreatedAt("$1"), id,
+		).Scan(&current)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("UpdateStatus: %w", domain.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("UpdateStatus: %w", err)
+		}
+		if current.IsTerminal() {
+			return fmt.Errorf("UpdateStatus: %w", domain.ErrPaymentTerminal)
+		}
+		return fmt.Errorf("UpdateStatus: expected %s, found %s: %w", from, current, domain.ErrVersionConflict)
+	}
+	return nil
+}
+
+func (r *PaymentRepository) ListStalePending(ctx context.Context, olderThan time.Time, limit int) ([]domain.Payment, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE `+bankPayoutPredicate+` AND status IN ('pending', 'processing')
+			AND provider IS NOT NULL AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2`,
+		olderThan, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListStalePending: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListStalePending: scan: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListStalePending: rows: %w", err)
+	}
+	return payments, nil
+}
+
+// ListPendingReview returns payouts waiting in the review queue, oldest
+// first. An empty reason lists every payout under review.
+func (r *PaymentRepository) ListPendingReview(ctx context.Context, reason domain.ReviewReason, limit, offset int) ([]domain.Payment, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE status = 'pending_review' AND ($1 = '' OR review_reason = $1)
+		ORDER BY created_at
+		LIMIT $2 OFFSET $3`,
+		reason, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListPendingReview: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListPendingReview: scan: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListPendingReview: rows: %w", err)
+	}
+	return payments, nil
+}
+
+// ListPendingReversal returns failed payouts whose reversal is still waiting
+// on funds, oldest first.
+func (r *PaymentRepository) ListPendingReversal(ctx context.Context, limit int) ([]domain.Payment, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE status = 'pending_reversal'
+		ORDER BY updated_at
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListPendingReversal: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListPendingReversal: scan: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListPendingReversal: rows: %w", err)
+	}
+	return payments, nil
+}
+
+// MarkPendingReversal records a payout failure whose reversal can't run yet.
+// from is the status the caller read; the update only applies if the
+// payment still has it.
+func (r *PaymentRepository) MarkPendingReversal(ctx context.Context, tx pgx.Tx, id uuid.UUID, from domain.PaymentStatus, failureReason string) error {
+	if !from.CanTransition(domain.PaymentStatusPendingReversal) {
+		return fmt.Errorf("MarkPendingReversal: %s to %s: %w", from, domain.PaymentStatusPendingReversal, domain.ErrInvalidTransition)
+	}
+	res, err := tx.Exec(ctx,
+		`UPDATE payments SET status = 'pending_reversal', failure_reason = $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2")+` AND status = $3`,
+		failureReason, id, from,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkPendingReversal: %w", err)
+	}
+
+	rows := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("MarkPendingReversal: %w", domain.ErrPaymentTerminal)
+	}
+	return nil
+}
+
+// ApproveReview moves a payout out of review to pending and records the
+// provider it will be submitted to.
+func (r *PaymentRepository) ApproveReview(ctx context.Context, tx pgx.Tx, id uuid.UUID, provider *string) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE payments SET status = 'pending', provider = $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2")+` AND status = 'pending_review'`,
+		provider, id,
+	)
+	if err != nil {
+		return fmt.Errorf("ApproveReview: %w", err)
+	}
+
+	rows := res.RowsAffected()
+	if rows == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return fmt.Errorf("ApproveReview: %w", err)
+		}
+		return fmt.Errorf("ApproveReview: %w", domain.ErrPaymentNotPendingReview)
+	}
+	return nil
+}
+
+// RejectReview fails a payout that is still pending review.
+func (r *PaymentRepository) RejectReview(ctx context.Context, tx pgx.Tx, id uuid.UUID, failureReason string) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE payments SET status = 'failed', failure_reason = $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2")+` AND status = 'pending_review'`,
+		failureReason, id,
+	)
+	if err != nil {
+		return fmt.Errorf("RejectReview: %w", err)
+	}
+
+	rows := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("RejectReview: %w", domain.ErrPaymentNotPendingReview)
+	}
+	return nil
+}
+
+// MarkReversed moves a completed internal transfer to reversed. The row lock
+// it takes serializes concurrent reversals of the same payment. Transfers
+// that were already partly refunded can't be reversed.
+func (r *PaymentRepository) MarkReversed(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE payments SET status = 'reversed', updated_at = now()
+		WHERE id = $1 AND created_at = `+paymentCreatedAt("$1")+`
+			AND type = 'internal_transfer' AND status = 'completed' AND refunded_amount = 0`,
+		id,
+	)
 	if err != nil {
-		return fmt.Errorf("UpdateStatus: rows affected: %w", err)
+		return fmt.Errorf("MarkReversed: %w", err)
 	}
+
+	rows := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("UpdateStatus: %w", domain.ErrPaymentTerminal)
+		return fmt.Errorf("MarkReversed: %w", domain.ErrPaymentNotReversible)
+	}
+	return nil
+}
+
+// AddRefunded adds amount to a completed internal transfer's refunded total
+// and returns the new total. It fails if the total would exceed what the
+// recipient received; the row lock serializes concurrent refunds.
+func (r *PaymentRepository) AddRefunded(ctx context.Context, tx pgx.Tx, id uuid.UUID, amount int64) (int64, error) {
+	var total int64
+	err := tx.QueryRow(ctx,
+		`UPDATE payments SET refunded_amount = refunded_amount + $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2")+`
+			AND type = 'internal_transfer' AND status = 'completed'
+			AND refunded_amount + $1 <= dest_amount
+		RETURNING refunded_amount`,
+		amount, id,
+	).Scan(&total)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("AddRefunded: %w", domain.ErrRefundExceedsPayment)
+		}
+		return 0, fmt.Errorf("AddRefunded: %w", err)
+	}
+	return total, nil
+}
+
+// ListRefunds returns the refund payments issued against a payment, oldest
+// first.
+func (r *PaymentRepository) ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]domain.Payment, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE refund_of_payment_id = $1
+		ORDER BY created_at`,
+		paymentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListRefunds: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListRefunds: scan: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListRefunds: rows: %w", err)
+	}
+	return payments, nil
+}
+
+func (r *PaymentRepository) SetFailureCode(ctx context.Context, tx pgx.Tx, id uuid.UUID, code domain.FailureCode) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE payments SET failure_code = $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2"),
+		code, id,
+	)
+	if err != nil {
+		return fmt.Errorf("SetFailureCode: %w", err)
+	}
+	return nil
+}
+
+// SumSentSince totals what an account has sent since the given time, ignoring
+// payments that failed or were reversed since that money came back. Reversals
+// and refunds are returns, not spending, so they don't count either.
+func (r *PaymentRepository) SumSentSince(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, since time.Time) (int64, error) {
+	var total int64
+	err := tx.QueryRow(ctx,
+		`SELECT COALESCE(SUM(source_amount), 0) FROM payments
+		WHERE source_account_id = $1 AND created_at >= $2
+			AND status NOT IN ('failed', 'reversed') AND type NOT IN ('reversal', 'refund')`,
+		accountID, since,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("SumSentSince: %w", err)
+	}
+	return total, nil
+}
+
+// SumPendingOutgoing totals the payouts from an account that have been
+// debited but not yet completed or failed.
+func (r *PaymentRepository) SumPendingOutgoing(ctx context.Context, accountID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(SUM(source_amount), 0) FROM payments
+		WHERE source_account_id = $1 AND `+bankPayoutPredicate+`
+			AND status IN ('pending', 'processing', 'pending_review')`,
+		accountID,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("SumPendingOutgoing: %w", err)
+	}
+	return total, nil
+}
+
+func (r *PaymentRepository) MarkSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE payments SET submitted_at = $1, updated_at = now()
+		WHERE id = $2 AND created_at = `+paymentCreatedAt("$2")+` AND submitted_at IS NULL`,
+		at, id,
+	)
+	if err != nil {
+		return fmt.Errorf("MarkSubmitted: %w", err)
 	}
 	return nil
 }
@@ -97,14 +503,25 @@ func scanPayment(s scanner) (*domain.Payment, error) {
 	var destAccountID uuid.NullUUID
 	var exchangeRate decimal.NullDecimal
 	var feeCurrency *string
+	var slippage *int64
 	var metadata *[]byte
+	var failureCode *string
+	var retryOf uuid.NullUUID
+	var midMarketRate decimal.NullDecimal
+	var reviewReason *string
+	var reversalOf uuid.NullUUID
+	var refundOf uuid.NullUUID
+	var beneficiaryID uuid.NullUUID
+	var userTier *string
 
 	err := s.Scan(
 		&p.ID, &p.IdempotencyKey, &p.Type, &p.Status, &p.SourceAccountID,
 		&destAccountID, &p.DestAccountNumber, &p.DestIBAN, &p.DestSwiftBIC, &p.DestBankName,
-		&p.SourceAmount, &p.SourceCurrency, &p.DestAmount, &p.DestCurrency, &exchangeRate,
-		&p.FeeAmount, &feeCurrency, &p.Provider, &p.ProviderRef, &p.FailureReason, &metadata,
-		&p.CreatedAt, &p.UpdatedAt, &p.CompletedAt,
+		&p.Source.Amount, &p.Source.Currency, &p.Dest.Amount, &p.Dest.Currency, &exchangeRate,
+		&p.Fee.Amount, &feeCurrency, &p.Provider, &p.ProviderRef, &p.FailureReason, &metadata,
+		&p.CreatedAt, &p.UpdatedAt, &p.CompletedAt, &failureCode, &retryOf,
+		&midMarketRate, &slippage, &p.SubmittedAt, &reviewReason, &reversalOf,
+		&refundOf, &p.Refunded.Amount, &beneficiaryID, &userTier,
 	)
 	if err != nil {
 		return nil, err
@@ -116,13 +533,110 @@ func scanPayment(s scanner) (*domain.Payment, error) {
 	if exchangeRate.Valid {
 		p.ExchangeRate = &exchangeRate.Decimal
 	}
+	if midMarketRate.Valid {
+		p.MidMarketRate = &midMarketRate.Decimal
+	}
+	// fee_currency is NULL when no fee was charged. Fees are taken in the
+	// destination currency, so that is what a zero fee is counted in.
+	p.Fee.Currency = p.Dest.Currency
 	if feeCurrency != nil {
-		c := domain.Currency(*feeCurrency)
-		p.FeeCurrency = &c
+		p.Fee.Currency = domain.Currency(*feeCurrency)
+	}
+	p.Refunded.Currency = p.Dest.Currency
+	if slippage != nil {
+		s := domain.NewMoney(*slippage, p.Dest.Currency)
+		p.Slippage = &s
 	}
 	if metadata != nil {
 		p.Metadata = *metadata
 	}
+	if failureCode != nil {
+		c := domain.FailureCode(*failureCode)
+		p.FailureCode = &c
+	}
+	if reviewReason != nil {
+		r := domain.ReviewReason(*reviewReason)
+		p.ReviewReason = &r
+	}
+	if retryOf.Valid {
+		p.RetryOfPaymentID = &retryOf.UUID
+	}
+	if reversalOf.Valid {
+		p.ReversalOfPaymentID = &reversalOf.UUID
+	}
+	if refundOf.Valid {
+		p.RefundOfPaymentID = &refundOf.UUID
+	}
+	if beneficiaryID.Valid {
+		p.BeneficiaryID = &beneficiaryID.UUID
+	}
+	if userTier != nil {
+		t := domain.UserTier(*userTier)
+		p.UserTier = &t
+	}
 
 	return &p, nil
 }
+
+// feeCurrency is the fee_currency column's value: NULL for a payment that
+// charged no fee.
+func feeCurrency(fee domain.Money) *domain.Currency {
+	if fee.IsZero() {
+		return nil
+	}
+	return &fee.Currency
+}
+
+// slippageAmount is the slippage column's value: NULL for a payment that
+// wasn't converted.
+func slippageAmount(m *domain.Money) *int64 {
+	if m == nil {
+		return nil
+	}
+	return &m.Amount
+}
+
+func (r *PaymentRepository) FXRevenueByCorridor(ctx context.Context, from, to time.Time) ([]domain.FXCorridorRevenue, error) {
+	rows, err := r.db.Query(ctx,
+		`WITH conversions AS (
+			SELECT source_currency, dest_currency, source_amount, dest_amount, fee_amount, slippage_amount,
+				slippage_amount * 10000.0 / NULLIF(dest_amount + slippage_amount, 0) AS slippage_bps
+			FROM payments
+			WHERE mid_market_rate IS NOT NULL
+				AND status NOT IN ('failed', 'reversed')
+				AND created_at >= $1 AND created_at < $2
+		)
+		SELECT source_currency, dest_currency, COUNT(*),
+			COALESCE(SUM(source_amount), 0), COALESCE(SUM(dest_amount), 0),
+			COALESCE(SUM(fee_amount), 0), COALESCE(SUM(slippage_amount), 0),
+			COALESCE(ROUND(AVG(slippage_bps), 2), 0),
+			COALESCE(ROUND(percentile_cont(0.5) WITHIN GROUP (ORDER BY slippage_bps)::numeric, 2), 0),
+			COALESCE(ROUND(percentile_cont(0.95) WITHIN GROUP (ORDER BY slippage_bps)::numeric, 2), 0),
+			COALESCE(ROUND(MAX(slippage_bps), 2), 0)
+		FROM conversions
+		GROUP BY source_currency, dest_currency
+		ORDER BY source_currency, dest_currency`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FXRevenueByCorridor: %w", err)
+	}
+	defer rows.Close()
+
+	var report []domain.FXCorridorRevenue
+	for rows.Next() {
+		var c domain.FXCorridorRevenue
+		if err := rows.Scan(
+			&c.SourceCurrency, &c.DestCurrency, &c.ConversionCount,
+			&c.SourceVolume, &c.DestVolume, &c.FeeTotal, &c.SlippageTotal,
+			&c.AvgSlippageBps, &c.P50SlippageBps, &c.P95SlippageBps, &c.MaxSlippageBps,
+		); err != nil {
+			return nil, fmt.Errorf("FXRevenueByCorridor: scan: %w", err)
+		}
+		report = append(report, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FXRevenueByCorridor: rows: %w", err)
+	}
+	return report, nil
+}
@@ -2,25 +2,27 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
 const paymentEventColumns = `id, payment_id, event_type, actor, payload, created_at`
 
 type PaymentEventRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewPaymentEventRepository(db *sql.DB) *PaymentEventRepository {
+func NewPaymentEventRepository(db *pgxpool.Pool) *PaymentEventRepository {
 	return &PaymentEventRepository{db: db}
 }
 
-func (r *PaymentEventRepository) Create(ctx context.Context, tx *sql.Tx, event *domain.PaymentEvent) error {
-	_, err := tx.ExecContext(ctx,
+func (r *PaymentEventRepository) Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error {
+	_, err := tx.Exec(ctx,
 		`INSERT INTO payment_events (id, payment_id, event_type, actor, payload, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)`,
 		event.ID, event.PaymentID, event.EventType, event.Actor,
@@ -33,7 +35,7 @@ func (r *PaymentEventRepository) Create(ctx context.Context, tx *sql.Tx, event *
 }
 
 func (r *PaymentEventRepository) GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.PaymentEvent, error) {
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := r.db.Query(ctx,
 		`SELECT `+paymentEventColumns+` FROM payment_events
 		WHERE payment_id = $1 ORDER BY created_at`, paymentID,
 	)
@@ -56,6 +58,75 @@ func (r *PaymentEventRepository) GetByPaymentID(ctx context.Context, paymentID u
 	return events, nil
 }
 
+// GetByPaymentIDs returns the events of the given payments, in creation
+// order.
+func (r *PaymentEventRepository) GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.PaymentEvent, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentEventColumns+` FROM payment_events
+		WHERE payment_id = ANY($1::uuid[]) ORDER BY created_at`,
+		paymentIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.PaymentEvent
+	for rows.Next() {
+		e, err := scanPaymentEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetByPaymentIDs: scan: %w", err)
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: rows: %w", err)
+	}
+	return events, nil
+}
+
+// ListBefore returns up to limit events created before the cutoff, oldest
+// first.
+func (r *PaymentEventRepository) ListBefore(ctx context.Context, before time.Time, limit int) ([]domain.PaymentEvent, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentEventColumns+` FROM payment_events
+		WHERE created_at < $1 ORDER BY created_at, id LIMIT $2`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListBefore: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.PaymentEvent
+	for rows.Next() {
+		e, err := scanPaymentEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListBefore: scan: %w", err)
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListBefore: rows: %w", err)
+	}
+	return events, nil
+}
+
+// DeleteThrough deletes the events ListBefore returned: everything created
+// before the cutoff up to and including last in (created_at, id) order.
+func (r *PaymentEventRepository) DeleteThrough(ctx context.Context, tx pgx.Tx, before time.Time, last *domain.PaymentEvent) (int64, error) {
+	res, err := tx.Exec(ctx,
+		`DELETE FROM payment_events
+		WHERE created_at < $1 AND (created_at, id) <= ($2, $3)`,
+		before, last.CreatedAt, last.ID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteThrough: %w", err)
+	}
+	n := res.RowsAffected()
+	return n, nil
+}
+
 func scanPaymentEvent(s scanner) (*domain.PaymentEvent, error) {
 	var e domain.PaymentEvent
 	var payload *[]byte
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type PaymentEventArchiveRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentEventArchiveRepository(db *pgxpool.Pool) *PaymentEventArchiveRepository {
+	return &PaymentEventArchiveRepository{db: db}
+}
+
+// Record registers an archived batch and the payments it holds events for.
+func (r *PaymentEventArchiveRepository) Record(ctx context.Context, tx pgx.Tx, a *domain.PaymentEventArchive, paymentIDs []uuid.UUID) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO payment_event_archives (object_key, event_count, first_created_at, last_created_at, archived_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		a.ObjectKey, a.EventCount, a.FirstCreatedAt, a.LastCreatedAt, a.ArchivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+
+	for _, id := range paymentIDs {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO payment_event_archive_payments (payment_id, object_key) VALUES ($1, $2)`,
+			id, a.ObjectKey,
+		); err != nil {
+			return fmt.Errorf("Record: payment %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ObjectKeysForPayments lists, for each of the given payments, the archived
+// batches holding its events, oldest first. Payments with nothing archived
+// are left out.
+func (r *PaymentEventArchiveRepository) ObjectKeysForPayments(ctx context.Context, paymentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT p.payment_id, a.object_key FROM payment_event_archive_payments p
+		JOIN payment_event_archives a ON a.object_key = p.object_key
+		WHERE p.payment_id = ANY($1::uuid[])
+		ORDER BY a.first_created_at`,
+		paymentIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ObjectKeysForPayments: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, fmt.Errorf("ObjectKeysForPayments: scan: %w", err)
+		}
+		keys[id] = append(keys[id], key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ObjectKeysForPayments: rows: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const paymentRequestColumns = `id, requester_user_id, payer_user_id, amount, currency, note, status,
+	payment_id, expires_at, responded_at, created_at, updated_at`
+
+type PaymentRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRequestRepository(db *pgxpool.Pool) *PaymentRequestRepository {
+	return &PaymentRequestRepository{db: db}
+}
+
+func (r *PaymentRequestRepository) Create(ctx context.Context, pr *domain.PaymentRequest) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO payment_requests (id, requester_user_id, payer_user_id, amount, currency, note, status,
+			expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		pr.ID, pr.RequesterUserID, pr.PayerUserID, pr.Amount, pr.Currency, pr.Note, pr.Status,
+		pr.ExpiresAt, pr.CreatedAt, pr.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *PaymentRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentRequest, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+paymentRequestColumns+` FROM payment_requests WHERE id = $1`, id,
+	)
+	pr, err := scanPaymentRequest(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return pr, nil
+}
+
+// ListForUser returns requests the user sent (outgoing), was asked to pay
+// (incoming) or both, newest first.
+func (r *PaymentRequestRepository) ListForUser(ctx context.Context, userID uuid.UUID, incoming, outgoing bool, status domain.PaymentRequestStatus, limit, offset int) ([]domain.PaymentRequest, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+paymentRequestColumns+` FROM payment_requests
+		WHERE (($2 AND payer_user_id = $1) OR ($3 AND requester_user_id = $1))
+			AND ($4 = '' OR status = $4)
+		ORDER BY created_at DESC LIMIT $5 OFFSET $6`,
+		userID, incoming, outgoing, string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListForUser: %w", err)
+	}
+	return collectPaymentRequests(rows, "ListForUser")
+}
+
+// Respond moves a pending, unexpired request to status. The status check in
+// the same statement means only one of accept, decline and cancel can win.
+func (r *PaymentRequestRepository) Respond(ctx context.Context, id uuid.UUID, status domain.PaymentRequestStatus, now time.Time) (*domain.PaymentRequest, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE payment_requests SET status = $1, responded_at = $2, updated_at = $2
+		WHERE id = $3 AND status = 'pending' AND expires_at > $2
+		RETURNING `+paymentRequestColumns,
+		status, now, id,
+	)
+	pr, err := scanPaymentRequest(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Respond: %w", domain.ErrPaymentRequestClosed)
+		}
+		return nil, fmt.Errorf("Respond: %w", err)
+	}
+	return pr, nil
+}
+
+// ReopenAccepted puts an accepted request whose transfer failed back to
+// pending.
+func (r *PaymentRequestRepository) ReopenAccepted(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE payment_requests SET status = 'pending', responded_at = NULL, updated_at = now()
+		WHERE id = $1 AND status = 'accepted' AND payment_id IS NULL`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("ReopenAccepted: %w", err)
+	}
+	return nil
+}
+
+func (r *PaymentRequestRepository) SetPayment(ctx context.Context, id, paymentID uuid.UUID) (*domain.PaymentRequest, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE payment_requests SET payment_id = $1, updated_at = now()
+		WHERE id = $2 AND status = 'accepted'
+		RETURNING `+paymentRequestColumns,
+		paymentID, id,
+	)
+	pr, err := scanPaymentRequest(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("SetPayment: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("SetPayment: %w", err)
+	}
+	return pr, nil
+}
+
+// ExpireDue marks up to limit pending requests past their expiry as expired
+// and returns them. SKIP LOCKED lets several instances run it at once.
+func (r *PaymentRequestRepository) ExpireDue(ctx context.Context, now time.Time, limit int) ([]domain.PaymentRequest, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE payment_requests SET status = 'expired', updated_at = $1
+		WHERE id IN (
+			SELECT id FROM payment_requests
+			WHERE status = 'pending' AND expires_at <= $1
+			ORDER BY expires_at LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		) AND status = 'pending'
+		RETURNING `+paymentRequestColumns,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ExpireDue: %w", err)
+	}
+	return collectPaymentRequests(rows, "ExpireDue")
+}
+
+func collectPaymentRequests(rows pgx.Rows, op string) ([]domain.PaymentRequest, error) {
+	defer rows.Close()
+
+	var requests []domain.PaymentRequest
+	for rows.Next() {
+		pr, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		requests = append(requests, *pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+	return requests, nil
+}
+
+func scanPaymentRequest(s scanner) (*domain.PaymentRequest, error) {
+	var pr domain.PaymentRequest
+	err := s.Scan(&pr.ID, &pr.RequesterUserID, &pr.PayerUserID, &pr.Amount, &pr.Currency, &pr.Note, &pr.Status,
+		&pr.PaymentID, &pr.ExpiresAt, &pr.RespondedAt, &pr.CreatedAt, &pr.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
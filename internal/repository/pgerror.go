@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// Postgres SQLSTATE codes the repositories map to domain errors.
+const (
+	pgUniqueViolation      = "23505"
+	pgCheckViolation       = "23514"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// This file is the only place that knows the driver's error type, so a
+// driver swap changes these helpers and nothing else.
+
+// isUniqueViolation reports whether err is a unique violation on constraint,
+// or on any constraint when constraint is empty.
+func isUniqueViolation(err error, constraint string) bool {
+	return isViolation(err, pgUniqueViolation, constraint)
+}
+
+func isCheckViolation(err error, constraint string) bool {
+	return isViolation(err, pgCheckViolation, constraint)
+}
+
+func isViolation(err error, code, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != code {
+		return false
+	}
+	return constraint == "" || pgErr.ConstraintName == constraint
+}
+
+// txError marks a transaction Postgres aborted for a deadlock or a
+// serialization failure with domain.ErrTxContention: contention a fresh
+// transaction can get past. Other errors, and nil, are returned unchanged.
+func txError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case pgSerializationFailure, pgDeadlockDetected:
+		return fmt.Errorf("%w: %w", domain.ErrTxContention, err)
+	}
+	return err
+}
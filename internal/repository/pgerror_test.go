@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestTxError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		contention bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: pgSerializationFailure}, true},
+		{"deadlock", fmt.Errorf("lock: %w", &pgconn.PgError{Code: pgDeadlockDetected}), true},
+		{"unique violation", &pgconn.PgError{Code: pgUniqueViolation}, false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"other", errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := txError(tt.err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.contention, errors.Is(err, domain.ErrTxContention))
+		})
+	}
+
+	assert.NoError(t, txError(nil))
+}
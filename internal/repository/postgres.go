@@ -2,32 +2,36 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PoolConfig struct {
-	MaxOpenConns    int
-	MaxIdleConns    int
+	MaxConns         int
+	MinConns         int
 	ConnMaxLifetimeS int
 	ConnMaxIdleTimeS int
 }
 
-func NewPostgresDB(ctx context.Context, databaseURL string, pool PoolConfig) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+func NewPostgresDB(ctx context.Context, databaseURL string, pool PoolConfig) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("NewPostgresDB: open: %w", err)
+		return nil, fmt.Errorf("NewPostgresDB: parse: %w", err)
 	}
 
-	db.SetMaxOpenConns(pool.MaxOpenConns)
-	db.SetMaxIdleConns(pool.MaxIdleConns)
-	db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetimeS) * time.Second)
-	db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTimeS) * time.Second)
+	cfg.MaxConns = int32(pool.MaxConns)
+	cfg.MinConns = int32(pool.MinConns)
+	cfg.MaxConnLifetime = time.Duration(pool.ConnMaxLifetimeS) * time.Second
+	cfg.MaxConnIdleTime = time.Duration(pool.ConnMaxIdleTimeS) * time.Second
+
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewPostgresDB: open: %w", err)
+	}
 
-	if err := db.PingContext(ctx); err != nil {
+	if err := db.Ping(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("NewPostgresDB: ping: %w", err)
 	}
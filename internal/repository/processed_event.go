@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type ProcessedEventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProcessedEventRepository(db *pgxpool.Pool) *ProcessedEventRepository {
+	return &ProcessedEventRepository{db: db}
+}
+
+// Mark records in tx that consumer has applied eventID, and returns
+// domain.ErrAlreadyProcessed if it already has. Call it before the side
+// effects: a concurrent transaction marking the same event waits here
+// until this one ends, then sees it processed if this one committed.
+func (r *ProcessedEventRepository) Mark(ctx context.Context, tx pgx.Tx, consumer string, eventID uuid.UUID) error {
+	res, err := tx.Exec(ctx,
+		`INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2)
+		ON CONFLICT (consumer, event_id) DO NOTHING`,
+		consumer, eventID,
+	)
+	if err != nil {
+		return fmt.Errorf("Mark: %w", err)
+	}
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Mark: %w", domain.ErrAlreadyProcessed)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type ProviderLatencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProviderLatencyRepository(db *pgxpool.Pool) *ProviderLatencyRepository {
+	return &ProviderLatencyRepository{db: db}
+}
+
+// Record stores one sample per payment; a second terminal outcome for the
+// same payment (e.g. a late webhook after polling) is ignored.
+func (r *ProviderLatencyRepository) Record(ctx context.Context, tx pgx.Tx, l *domain.ProviderLatency) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO provider_latencies (
+			payment_id, provider, source_currency, dest_currency, outcome,
+			submitted_at, resolved_at, latency_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (payment_id) DO NOTHING`,
+		l.PaymentID, l.Provider, l.SourceCurrency, l.DestCurrency, l.Outcome,
+		l.SubmittedAt, l.ResolvedAt, l.LatencyMS,
+	)
+	if err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	return nil
+}
+
+func (r *ProviderLatencyRepository) Stats(ctx context.Context, from, to time.Time) ([]domain.ProviderSLAStats, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT provider, source_currency, dest_currency, COUNT(*),
+			COUNT(*) FILTER (WHERE outcome = 'failed'),
+			percentile_disc(0.50) WITHIN GROUP (ORDER BY latency_ms),
+			percentile_disc(0.95) WITHIN GROUP (ORDER BY latency_ms),
+			percentile_disc(0.99) WITHIN GROUP (ORDER BY latency_ms),
+			MAX(latency_ms)
+		FROM provider_latencies
+		WHERE resolved_at >= $1 AND resolved_at < $2
+		GROUP BY provider, source_currency, dest_currency
+		ORDER BY provider, source_currency, dest_currency`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.ProviderSLAStats
+	for rows.Next() {
+		var s domain.ProviderSLAStats
+		if err := rows.Scan(
+			&s.Provider, &s.SourceCurrency, &s.DestCurrency, &s.Samples, &s.Failed,
+			&s.P50MS, &s.P95MS, &s.P99MS, &s.MaxMS,
+		); err != nil {
+			return nil, fmt.Errorf("Stats: scan: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Stats: rows: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const providerRegistrationColumns = `provider, status, challenges, failures, last_challenge_at, verified_at, last_failure_at`
+
+type ProviderRegistrationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProviderRegistrationRepository(db *pgxpool.Pool) *ProviderRegistrationRepository {
+	return &ProviderRegistrationRepository{db: db}
+}
+
+func scanProviderRegistration(row interface{ Scan(...any) error }) (*domain.ProviderRegistration, error) {
+	var reg domain.ProviderRegistration
+	err := row.Scan(&reg.Provider, &reg.Status, &reg.Challenges, &reg.Failures,
+		&reg.LastChallengeAt, &reg.VerifiedAt, &reg.LastFailureAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
+
+func (r *ProviderRegistrationRepository) Get(ctx context.Context, provider string) (*domain.ProviderRegistration, error) {
+	reg, err := scanProviderRegistration(r.db.QueryRow(ctx,
+		`SELECT `+providerRegistrationColumns+` FROM provider_webhook_registrations WHERE provider = $1`,
+		provider,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return reg, nil
+}
+
+func (r *ProviderRegistrationRepository) List(ctx context.Context) ([]domain.ProviderRegistration, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+providerRegistrationColumns+` FROM provider_webhook_registrations ORDER BY provider`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var regs []domain.ProviderRegistration
+	for rows.Next() {
+		reg, err := scanProviderRegistration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		regs = append(regs, *reg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return regs, nil
+}
+
+// RecordChallenge counts one handshake attempt. A successful one verifies
+// the provider; a failed one only marks it failed if it was never verified.
+func (r *ProviderRegistrationRepository) RecordChallenge(ctx context.Context, provider string, ok bool, at time.Time) (*domain.ProviderRegistration, error) {
+	status := domain.ProviderRegistrationFailed
+	var verifiedAt, failedAt *time.Time
+	failures := 1
+	if ok {
+		status = domain.ProviderRegistrationVerified
+		verifiedAt = &at
+		failures = 0
+	} else {
+		failedAt = &at
+	}
+
+	reg, err := scanProviderRegistration(r.db.QueryRow(ctx,
+		`INSERT INTO provider_webhook_registrations
+			(provider, status, challenges, failures, last_challenge_at, verified_at, last_failure_at)
+		VALUES ($1, $2, 1, $3, $4, $5, $6)
+		ON CONFLICT (provider) DO UPDATE
+		SET status = CASE WHEN EXCLUDED.status = 'verified' THEN 'verified'
+				ELSE provider_webhook_registrations.status END,
+			challenges = provider_webhook_registrations.challenges + 1,
+			failures = provider_webhook_registrations.failures + EXCLUDED.failures,
+			last_challenge_at = EXCLUDED.last_challenge_at,
+			verified_at = COALESCE(EXCLUDED.verified_at, provider_webhook_registrations.verified_at),
+			last_failure_at = COALESCE(EXCLUDED.last_failure_at, provider_webhook_registrations.last_failure_at)
+		RETURNING `+providerRegistrationColumns,
+		provider, status, failures, at, verifiedAt, failedAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("RecordChallenge: %w", err)
+	}
+	return reg, nil
+}
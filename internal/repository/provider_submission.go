@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const providerSubmissionColumns = `id, payment_id, provider, attempt, error_class, status_code, error, created_at`
+
+type ProviderSubmissionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProviderSubmissionRepository(db *pgxpool.Pool) *ProviderSubmissionRepository {
+	return &ProviderSubmissionRepository{db: db}
+}
+
+// Record stores an attempt as the payment's next one and sets s.Attempt.
+func (r *ProviderSubmissionRepository) Record(ctx context.Context, s *domain.ProviderSubmission) error {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO provider_submissions (`+providerSubmissionColumns+`)
+		SELECT $1, $2, $3, COALESCE(MAX(attempt), 0) + 1, $4, $5, $6, $7
+		FROM provider_submissions WHERE payment_id = $2
+		RETURNING attempt`,
+		s.ID, s.PaymentID, s.Provider, s.ErrorClass, s.StatusCode, s.Error, s.CreatedAt,
+	).Scan(&s.Attempt)
+	if err != nil {
+		return fmt.Errorf("Record: %w", err)
+	}
+	return nil
+}
+
+// ListDue returns, oldest first, the last attempt of payouts still waiting
+// to be handed to their provider whose last attempt failed and that need
+// acting on: it can't be retried, maxAttempts have been made, or the
+// backoff since it (backoff doubled for each attempt after the first) has
+// passed.
+func (r *ProviderSubmissionRepository) ListDue(ctx context.Context, now time.Time, backoff time.Duration, maxAttempts, limit int) ([]domain.ProviderSubmission, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT s.id, s.payment_id, s.provider, s.attempt, s.error_class, s.status_code, s.error, s.created_at
+		FROM (
+			SELECT DISTINCT ON (payment_id) `+providerSubmissionColumns+`
+			FROM provider_submissions
+			ORDER BY payment_id, attempt DESC
+		) s
+		JOIN payments p ON p.id = s.payment_id
+		WHERE s.error_class IS NOT NULL
+			AND p.status = 'pending' AND p.submitted_at IS NULL
+			AND (s.error_class NOT IN ('network', 'retriable')
+				OR s.attempt >= $2
+				OR s.created_at + make_interval(secs => $3 * power(2, LEAST(s.attempt - 1, 10))) <= $1)
+		ORDER BY s.created_at
+		LIMIT $4`,
+		now, maxAttempts, backoff.Seconds(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListDue: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.ProviderSubmission
+	for rows.Next() {
+		var s domain.ProviderSubmission
+		if err := rows.Scan(&s.ID, &s.PaymentID, &s.Provider, &s.Attempt, &s.ErrorClass, &s.StatusCode, &s.Error, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListDue: scan: %w", err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDue: rows: %w", err)
+	}
+	return out, nil
+}
+
+// FailureStats counts each provider's attempts in [from, to) by outcome.
+func (r *ProviderSubmissionRepository) FailureStats(ctx context.Context, from, to time.Time) ([]domain.ProviderFailureStats, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT provider, COUNT(*),
+			COUNT(*) FILTER (WHERE error_class IS NULL),
+			COUNT(*) FILTER (WHERE error_class = 'network'),
+			COUNT(*) FILTER (WHERE error_class = 'retriable'),
+			COUNT(*) FILTER (WHERE error_class = 'permanent'),
+			COUNT(*) FILTER (WHERE error_class = 'validation')
+		FROM provider_submissions
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY provider
+		ORDER BY provider`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FailureStats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.ProviderFailureStats
+	for rows.Next() {
+		var s domain.ProviderFailureStats
+		if err := rows.Scan(&s.Provider, &s.Attempts, &s.Accepted, &s.Network, &s.Retriable, &s.Permanent, &s.Validation); err != nil {
+			return nil, fmt.Errorf("FailureStats: scan: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FailureStats: rows: %w", err)
+	}
+	return stats, nil
+}
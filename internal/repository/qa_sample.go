@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const qaSampleColumns = `id, payment_id, payment_type, amount, currency, reason, weight, probability,
+	status, outcome, review_note, reviewed_by, reviewed_at, sampled_at`
+
+type QASampleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewQASampleRepository(db *pgxpool.Pool) *QASampleRepository {
+	return &QASampleRepository{db: db}
+}
+
+// ListCandidates returns completed transfers and payouts that completed after
+// the (afterAt, afterID) cursor and no later than until, in completion order.
+// Each carries the sender's average completed payment over the 90 days
+// before it, in the same account.
+func (r *QASampleRepository) ListCandidates(ctx context.Context, afterAt time.Time, afterID uuid.UUID, until time.Time, limit int) ([]domain.QACandidate, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT p.id, p.type, p.source_amount, p.source_currency,
+			COALESCE(h.avg_amount, 0)::BIGINT, COALESCE(h.n, 0), p.completed_at
+		FROM payments p
+		LEFT JOIN LATERAL (
+			SELECT AVG(prev.source_amount) AS avg_amount, COUNT(*) AS n
+			FROM payments prev
+			WHERE prev.source_account_id = p.source_account_id
+				AND prev.created_at >= p.created_at - interval '90 days'
+				AND prev.created_at < p.created_at
+				AND prev.status = 'completed'
+		) h ON true
+		WHERE p.status = 'completed'
+			AND p.type IN ('internal_transfer', 'external_payout')
+			AND p.created_at <= $3
+			AND (p.completed_at, p.id) > ($1, $2) AND p.completed_at <= $3
+			AND NOT EXISTS (SELECT 1 FROM qa_samples q WHERE q.payment_id = p.id)
+		ORDER BY p.completed_at, p.id
+		LIMIT $4`,
+		afterAt, afterID, until, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListCandidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []domain.QACandidate
+	for rows.Next() {
+		var c domain.QACandidate
+		if err := rows.Scan(&c.PaymentID, &c.PaymentType, &c.Amount, &c.Currency,
+			&c.SenderAvgAmount, &c.SenderPriorCount, &c.CompletedAt); err != nil {
+			return nil, fmt.Errorf("ListCandidates: scan: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListCandidates: rows: %w", err)
+	}
+	return candidates, nil
+}
+
+// Create stores a sample. It reports false when the payment was already
+// sampled, which happens when a restarted sampler rescans a window.
+func (r *QASampleRepository) Create(ctx context.Context, s *domain.QASample) (bool, error) {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO qa_samples (id, payment_id, payment_type, amount, currency, reason, weight, probability, status, sampled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (payment_id) DO NOTHING`,
+		s.ID, s.PaymentID, s.PaymentType, s.Amount, s.Currency, s.Reason, s.Weight, s.Probability, s.Status, s.SampledAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("Create: %w", err)
+	}
+	n := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *QASampleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.QASample, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+qaSampleColumns+` FROM qa_samples WHERE id = $1`, id,
+	)
+	s, err := scanQASample(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return s, nil
+}
+
+// List returns samples oldest first, optionally filtered by status and
+// outcome.
+func (r *QASampleRepository) List(ctx context.Context, status domain.QASampleStatus, outcome domain.QAOutcome, limit, offset int) ([]domain.QASample, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+qaSampleColumns+` FROM qa_samples
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR outcome = $2)
+		ORDER BY sampled_at, id LIMIT $3 OFFSET $4`,
+		string(status), string(outcome), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []domain.QASample
+	for rows.Next() {
+		s, err := scanQASample(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		samples = append(samples, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return samples, nil
+}
+
+// MarkReviewed records the outcome of a pending sample. Reviewed samples are
+// compliance evidence and can't be reviewed again.
+func (r *QASampleRepository) MarkReviewed(ctx context.Context, id, staffID uuid.UUID, outcome domain.QAOutcome, note *string, at time.Time) (*domain.QASample, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE qa_samples
+		SET status = 'reviewed', outcome = $1, review_note = $2, reviewed_by = $3, reviewed_at = $4
+		WHERE id = $5 AND status = 'pending'
+		RETURNING `+qaSampleColumns,
+		outcome, note, staffID, at, id,
+	)
+	s, err := scanQASample(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := r.GetByID(ctx, id); err != nil {
+				return nil, fmt.Errorf("MarkReviewed: %w", err)
+			}
+			return nil, fmt.Errorf("MarkReviewed: %w", domain.ErrQASampleAlreadyReviewed)
+		}
+		return nil, fmt.Errorf("MarkReviewed: %w", err)
+	}
+	return s, nil
+}
+
+func scanQASample(s scanner) (*domain.QASample, error) {
+	var q domain.QASample
+	err := s.Scan(&q.ID, &q.PaymentID, &q.PaymentType, &q.Amount, &q.Currency, &q.Reason, &q.Weight, &q.Probability,
+		&q.Status, &q.Outcome, &q.ReviewNote, &q.ReviewedBy, &q.ReviewedAt, &q.SampledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const residencyRuleColumns = `id, country, source_currency, dest_currency, dest_country, reason, created_by, created_at, updated_at`
+
+type ResidencyRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewResidencyRuleRepository(db *pgxpool.Pool) *ResidencyRuleRepository {
+	return &ResidencyRuleRepository{db: db}
+}
+
+func (r *ResidencyRuleRepository) Create(ctx context.Context, rule *domain.ResidencyRule) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO residency_rules (id, country, source_currency, dest_currency, dest_country, reason, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		rule.ID, rule.Country, rule.SourceCurrency, rule.DestCurrency, rule.DestCountry,
+		rule.Reason, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "idx_residency_rules_scope") {
+			return fmt.Errorf("Create: %w", domain.ErrResidencyRuleExists)
+		}
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *ResidencyRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ResidencyRule, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+residencyRuleColumns+` FROM residency_rules WHERE id = $1`, id,
+	)
+	rule, err := scanResidencyRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *ResidencyRuleRepository) List(ctx context.Context, country string, limit, offset int) ([]domain.ResidencyRule, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+residencyRuleColumns+` FROM residency_rules
+		WHERE ($1 = '' OR country = $1)
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
+		country, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.ResidencyRule
+	for rows.Next() {
+		rule, err := scanResidencyRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *ResidencyRuleRepository) UpdateReason(ctx context.Context, id uuid.UUID, reason string, now time.Time) (*domain.ResidencyRule, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE residency_rules SET reason = $1, updated_at = $2 WHERE id = $3
+		RETURNING `+residencyRuleColumns,
+		reason, now, id,
+	)
+	rule, err := scanResidencyRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("UpdateReason: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *ResidencyRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM residency_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	n := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Delete: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Match returns the oldest rule for country that covers the payment, or
+// ErrNotFound when none does. An empty destCountry only matches rules that
+// don't name one.
+func (r *ResidencyRuleRepository) Match(ctx context.Context, country string, source, dest domain.Currency, destCountry string) (*domain.ResidencyRule, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+residencyRuleColumns+` FROM residency_rules
+		WHERE country = $1
+			AND (source_currency IS NULL OR source_currency = $2)
+			AND (dest_currency IS NULL OR dest_currency = $3)
+			AND (dest_country IS NULL OR dest_country = $4)
+		ORDER BY created_at LIMIT 1`,
+		country, source, dest, destCountry,
+	)
+	rule, err := scanResidencyRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Match: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Match: %w", err)
+	}
+	return rule, nil
+}
+
+func scanResidencyRule(s scanner) (*domain.ResidencyRule, error) {
+	var rule domain.ResidencyRule
+	err := s.Scan(
+		&rule.ID, &rule.Country, &rule.SourceCurrency, &rule.DestCurrency, &rule.DestCountry,
+		&rule.Reason, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
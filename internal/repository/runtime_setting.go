@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type RuntimeSettingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRuntimeSettingRepository(db *pgxpool.Pool) *RuntimeSettingRepository {
+	return &RuntimeSettingRepository{db: db}
+}
+
+func (r *RuntimeSettingRepository) Get(ctx context.Context, key string) (*domain.RuntimeSetting, error) {
+	var s domain.RuntimeSetting
+	err := r.db.QueryRow(ctx,
+		`SELECT key, value, updated_by, updated_at FROM runtime_settings WHERE key = $1`,
+		key,
+	).Scan(&s.Key, &s.Value, &s.UpdatedBy, &s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *RuntimeSettingRepository) Upsert(ctx context.Context, s *domain.RuntimeSetting) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO runtime_settings (key, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		s.Key, []byte(s.Value), s.UpdatedBy, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Upsert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const settlementColumns = `id, currency, settlement_date, status, payment_count, total_amount,
+	closed_at, closed_by, created_at`
+
+type SettlementRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSettlementRepository(db *pgxpool.Pool) *SettlementRepository {
+	return &SettlementRepository{db: db}
+}
+
+func (r *SettlementRepository) GetOrCreateOpen(ctx context.Context, tx pgx.Tx, currency domain.Currency, date time.Time) (*domain.SettlementBatch, error) {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO settlement_batches (id, currency, settlement_date, status)
+		VALUES ($1, $2, $3, 'open')
+		ON CONFLICT (currency, settlement_date) WHERE status = 'open' DO NOTHING`,
+		uuid.New(), currency, date,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrCreateOpen: insert: %w", err)
+	}
+
+	row := tx.QueryRow(ctx,
+		`SELECT `+settlementColumns+` FROM settlement_batches
+		WHERE currency = $1 AND settlement_date = $2 AND status = 'open'
+		FOR UPDATE`,
+		currency, date,
+	)
+	b, err := scanSettlementBatch(row)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrCreateOpen: %w", err)
+	}
+	return b, nil
+}
+
+func (r *SettlementRepository) AttachEligiblePayments(ctx context.Context, tx pgx.Tx, batch *domain.SettlementBatch) (int64, error) {
+	dayStart := batch.SettlementDate
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	res, err := tx.Exec(ctx,
+		`INSERT INTO settlement_batch_payments (batch_id, payment_id, amount)
+		SELECT $1, p.id, p.dest_amount FROM payments p
+		WHERE (p.type = 'external_payout' OR (p.type = 'sweep' AND p.dest_account_id IS NULL)) AND p.status = 'completed'
+			AND p.dest_currency = $2 AND p.completed_at >= $3 AND p.completed_at < $4
+			AND p.created_at < $4
+			AND NOT EXISTS (SELECT 1 FROM settlement_batch_payments s WHERE s.payment_id = p.id)`,
+		batch.ID, batch.Currency, dayStart, dayEnd,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("AttachEligiblePayments: insert: %w", err)
+	}
+	added := res.RowsAffected()
+
+	err = tx.QueryRow(ctx,
+		`UPDATE settlement_batches b SET
+			payment_count = t.cnt,
+			total_amount = t.total
+		FROM (
+			SELECT COUNT(*) AS cnt, COALESCE(SUM(amount), 0) AS total
+			FROM settlement_batch_payments WHERE batch_id = $1
+		) t
+		WHERE b.id = $1
+		RETURNING b.payment_count, b.total_amount`,
+		batch.ID,
+	).Scan(&batch.PaymentCount, &batch.TotalAmount)
+	if err != nil {
+		return 0, fmt.Errorf("AttachEligiblePayments: update totals: %w", err)
+	}
+
+	return added, nil
+}
+
+func (r *SettlementRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SettlementBatch, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+settlementColumns+` FROM settlement_batches WHERE id = $1`, id,
+	)
+	b, err := scanSettlementBatch(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return b, nil
+}
+
+func (r *SettlementRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.SettlementBatch, error) {
+	row := tx.QueryRow(ctx,
+		`SELECT `+settlementColumns+` FROM settlement_batches WHERE id = $1 FOR UPDATE`, id,
+	)
+	b, err := scanSettlementBatch(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return b, nil
+}
+
+func (r *SettlementRepository) List(ctx context.Context, currency domain.Currency, status domain.SettlementStatus, limit, offset int) ([]domain.SettlementBatch, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+settlementColumns+` FROM settlement_batches
+		WHERE ($1 = '' OR currency = $1) AND ($2 = '' OR status = $2)
+		ORDER BY settlement_date DESC, currency
+		LIMIT $3 OFFSET $4`,
+		string(currency), string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []domain.SettlementBatch
+	for rows.Next() {
+		b, err := scanSettlementBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		batches = append(batches, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return batches, nil
+}
+
+func (r *SettlementRepository) ListItems(ctx context.Context, batchID uuid.UUID) ([]domain.SettlementItem, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT s.payment_id, s.amount, p.provider, p.provider_ref, p.completed_at
+		FROM settlement_batch_payments s
+		JOIN payment_keys k ON k.id = s.payment_id
+		JOIN payments p ON p.id = k.id AND p.created_at = k.created_at
+		WHERE s.batch_id = $1
+		ORDER BY p.completed_at, s.payment_id`,
+		batchID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListItems: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.SettlementItem
+	for rows.Next() {
+		var it domain.SettlementItem
+		if err := rows.Scan(&it.PaymentID, &it.Amount, &it.Provider, &it.ProviderRef, &it.CompletedAt); err != nil {
+			return nil, fmt.Errorf("ListItems: scan: %w", err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListItems: rows: %w", err)
+	}
+	return items, nil
+}
+
+func (r *SettlementRepository) Close(ctx context.Context, tx pgx.Tx, id uuid.UUID, closedBy uuid.UUID, closedAt time.Time) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE settlement_batches SET status = 'closed', closed_at = $1, closed_by = $2
+		WHERE id = $3 AND status = 'open'`,
+		closedAt, closedBy, id,
+	)
+	if err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+	rows := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("Close: %w", domain.ErrSettlementClosed)
+	}
+	return nil
+}
+
+func scanSettlementBatch(s scanner) (*domain.SettlementBatch, error) {
+	var b domain.SettlementBatch
+	var closedBy uuid.NullUUID
+	err := s.Scan(
+		&b.ID, &b.Currency, &b.SettlementDate, &b.Status, &b.PaymentCount, &b.TotalAmount,
+		&b.ClosedAt, &closedBy, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedBy.Valid {
+		b.ClosedBy = &closedBy.UUID
+	}
+	return &b, nil
+}
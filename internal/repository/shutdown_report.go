@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type ShutdownReportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewShutdownReportRepository(db *pgxpool.Pool) *ShutdownReportRepository {
+	return &ShutdownReportRepository{db: db}
+}
+
+type shutdownCountJSON struct {
+	Kind               string `json:"kind"`
+	InFlight           int    `json:"in_flight"`
+	Completed          int    `json:"completed"`
+	Abandoned          int    `json:"abandoned"`
+	StartedDuringDrain int    `json:"started_during_drain"`
+}
+
+type abandonedOperationJSON struct {
+	Kind      string    `json:"kind"`
+	Ref       string    `json:"ref"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+func (r *ShutdownReportRepository) Create(ctx context.Context, report *domain.ShutdownReport) error {
+	counts := make([]shutdownCountJSON, len(report.Counts))
+	for i, c := range report.Counts {
+		counts[i] = shutdownCountJSON{
+			Kind: string(c.Kind), InFlight: c.InFlight, Completed: c.Completed,
+			Abandoned: c.Abandoned, StartedDuringDrain: c.StartedDuringDrain,
+		}
+	}
+	abandoned := make([]abandonedOperationJSON, len(report.Abandoned))
+	for i, a := range report.Abandoned {
+		abandoned[i] = abandonedOperationJSON{Kind: string(a.Kind), Ref: a.Ref, StartedAt: a.StartedAt}
+	}
+
+	rawCounts, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("Create: marshal counts: %w", err)
+	}
+	rawAbandoned, err := json.Marshal(abandoned)
+	if err != nil {
+		return fmt.Errorf("Create: marshal abandoned: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx,
+		`INSERT INTO shutdown_reports (
+			id, instance, signal, grace_period_ms, started_at, finished_at, clean, counts, abandoned
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		report.ID, report.Instance, report.Signal, report.GracePeriod.Milliseconds(),
+		report.StartedAt, report.FinishedAt, report.Clean(), rawCounts, rawAbandoned,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const supportNoteColumns = `id, subject_type, subject_id, author_id, body,
+	mentioned_user_ids, flagged, created_at`
+
+type SupportNoteRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSupportNoteRepository(db *pgxpool.Pool) *SupportNoteRepository {
+	return &SupportNoteRepository{db: db}
+}
+
+func (r *SupportNoteRepository) Create(ctx context.Context, note *domain.SupportNote) error {
+	// A nil slice is sent as NULL, and the column is NOT NULL.
+	mentioned := note.MentionedUserIDs
+	if mentioned == nil {
+		mentioned = []uuid.UUID{}
+	}
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO support_notes (
+			id, subject_type, subject_id, author_id, body,
+			mentioned_user_ids, flagged, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		note.ID, note.SubjectType, note.SubjectID, note.AuthorID, note.Body,
+		mentioned, note.Flagged, note.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	return nil
+}
+
+func (r *SupportNoteRepository) ListBySubject(ctx context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+supportNoteColumns+` FROM support_notes
+		WHERE subject_type = $1 AND subject_id = $2 ORDER BY created_at DESC`,
+		subjectType, subjectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListBySubject: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []domain.SupportNote
+	for rows.Next() {
+		n, err := scanSupportNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListBySubject: scan: %w", err)
+		}
+		notes = append(notes, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListBySubject: rows: %w", err)
+	}
+	return notes, nil
+}
+
+func scanSupportNote(s scanner) (*domain.SupportNote, error) {
+	var n domain.SupportNote
+	err := s.Scan(
+		&n.ID, &n.SubjectType, &n.SubjectID, &n.AuthorID, &n.Body,
+		&n.MentionedUserIDs, &n.Flagged, &n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
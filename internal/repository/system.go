@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// SystemRepository creates the system user and its accounts when they are
+// missing, and leaves them alone when they exist.
+type SystemRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSystemRepository(db *pgxpool.Pool) *SystemRepository {
+	return &SystemRepository{db: db}
+}
+
+// EnsureUser inserts u unless a user with its ID exists, and reports whether
+// it did.
+func (r *SystemRepository) EnsureUser(ctx context.Context, u *domain.User) (bool, error) {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO users (id, email, name, password_hash, unique_name, status, role, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING`,
+		u.ID, u.Email, u.Name, u.PasswordHash, u.UniqueName, u.Status, u.Role, u.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("EnsureUser: %w", err)
+	}
+	n := res.RowsAffected()
+	return n > 0, nil
+}
+
+// EnsureAccount inserts a unless its owner already has an account of the
+// same currency and type, and reports whether it did. An existing account
+// keeps its balance.
+func (r *SystemRepository) EnsureAccount(ctx context.Context, a *domain.Account) (bool, error) {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO accounts (id, user_id, currency, account_type, balance, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, currency, account_type) DO NOTHING`,
+		a.ID, a.UserID, a.Currency, a.AccountType, a.Balance, a.Status, a.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("EnsureAccount: %w", err)
+	}
+	n := res.RowsAffected()
+	return n > 0, nil
+}
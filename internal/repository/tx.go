@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Begin starts a transaction on pool whose statements and commit report
+// deadlocks and serialization failures as domain.ErrTxContention, so callers
+// can retry them without knowing the driver's error type.
+func Begin(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	t, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, txError(err)
+	}
+	return classifiedTx{t}, nil
+}
+
+// classifiedTx classifies the errors of the pgx.Tx it wraps with txError.
+type classifiedTx struct {
+	pgx.Tx
+}
+
+func (t classifiedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := t.Tx.Begin(ctx)
+	if err != nil {
+		return nil, txError(err)
+	}
+	return classifiedTx{nested}, nil
+}
+
+func (t classifiedTx) Commit(ctx context.Context) error {
+	return txError(t.Tx.Commit(ctx))
+}
+
+func (t classifiedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := t.Tx.Exec(ctx, sql, args...)
+	return tag, txError(err)
+}
+
+func (t classifiedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r, err := t.Tx.Query(ctx, sql, args...)
+	if err != nil {
+		return r, txError(err)
+	}
+	return classifiedRows{r}, nil
+}
+
+func (t classifiedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return classifiedRow{t.Tx.QueryRow(ctx, sql, args...)}
+}
+
+type classifiedRows struct {
+	pgx.Rows
+}
+
+func (r classifiedRows) Err() error {
+	return txError(r.Rows.Err())
+}
+
+type classifiedRow struct {
+	pgx.Row
+}
+
+func (r classifiedRow) Scan(dest ...any) error {
+	return txError(r.Row.Scan(dest...))
+}
@@ -2,31 +2,33 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
-const userColumns = `id, email, name, password_hash, unique_name, status, created_at`
+const userColumns = `id, email, name, password_hash, unique_name, status, role, kyc_tier, tier, default_account_id, country, session_version, created_at`
 
 type UserRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	return &UserRepository{db: db}
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	row := r.db.QueryRowContext(ctx,
+	row := r.db.QueryRow(ctx,
 		`SELECT `+userColumns+` FROM users WHERE id = $1`, id,
 	)
 	u, err := scanUser(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByID: %w", err)
@@ -35,12 +37,12 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 }
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	row := r.db.QueryRowContext(ctx,
+	row := r.db.QueryRow(ctx,
 		`SELECT `+userColumns+` FROM users WHERE email = $1`, email,
 	)
 	u, err := scanUser(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByEmail: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByEmail: %w", err)
@@ -49,12 +51,12 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 }
 
 func (r *UserRepository) GetByUniqueName(ctx context.Context, uniqueName string) (*domain.User, error) {
-	row := r.db.QueryRowContext(ctx,
+	row := r.db.QueryRow(ctx,
 		`SELECT `+userColumns+` FROM users WHERE unique_name = $1`, uniqueName,
 	)
 	u, err := scanUser(row)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetByUniqueName: %w", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("GetByUniqueName: %w", err)
@@ -66,10 +68,135 @@ func scanUser(s scanner) (*domain.User, error) {
 	var u domain.User
 	err := s.Scan(
 		&u.ID, &u.Email, &u.Name, &u.PasswordHash,
-		&u.UniqueName, &u.Status, &u.CreatedAt,
+		&u.UniqueName, &u.Status, &u.Role, &u.KYCTier, &u.Tier, &u.DefaultAccountID, &u.Country, &u.SessionVersion, &u.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
+
+func (r *UserRepository) SetKYCTier(ctx context.Context, tx pgx.Tx, id uuid.UUID, tier domain.KYCTier) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE users SET kyc_tier = $1 WHERE id = $2`,
+		tier, id,
+	)
+	if err != nil {
+		return fmt.Errorf("SetKYCTier: %w", err)
+	}
+
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("SetKYCTier: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.User, error) {
+	row := tx.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1 FOR UPDATE`, id,
+	)
+	u, err := scanUser(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return u, nil
+}
+
+func (r *UserRepository) SetUniqueName(ctx context.Context, tx pgx.Tx, id uuid.UUID, uniqueName string) error {
+	_, err := tx.Exec(ctx, `UPDATE users SET unique_name = $1 WHERE id = $2`, uniqueName, id)
+	if err != nil {
+		if isUniqueViolation(err, "idx_users_unique_name") {
+			return fmt.Errorf("SetUniqueName: %w", domain.ErrUniqueNameTaken)
+		}
+		return fmt.Errorf("SetUniqueName: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) SetEmail(ctx context.Context, tx pgx.Tx, id uuid.UUID, email string) error {
+	_, err := tx.Exec(ctx, `UPDATE users SET email = $1 WHERE id = $2`, email, id)
+	if err != nil {
+		if isUniqueViolation(err, "idx_users_email") {
+			return fmt.Errorf("SetEmail: %w", domain.ErrEmailTaken)
+		}
+		return fmt.Errorf("SetEmail: %w", err)
+	}
+	return nil
+}
+
+// SetPasswordHash changes the user's password as of at and bumps the
+// session version, so sessions issued before are no longer accepted.
+func (r *UserRepository) SetPasswordHash(ctx context.Context, tx pgx.Tx, id uuid.UUID, hash string, at time.Time) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE users SET password_hash = $1, password_changed_at = $3, session_version = session_version + 1 WHERE id = $2`,
+		hash, id, at,
+	)
+	if err != nil {
+		return fmt.Errorf("SetPasswordHash: %w", err)
+	}
+	return nil
+}
+
+// SessionVersion is the version a session must have been issued at to
+// still be accepted.
+func (r *UserRepository) SessionVersion(ctx context.Context, id uuid.UUID) (int, error) {
+	var version int
+	err := r.db.QueryRow(ctx, `SELECT session_version FROM users WHERE id = $1`, id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("SessionVersion: %w", domain.ErrNotFound)
+		}
+		return 0, fmt.Errorf("SessionVersion: %w", err)
+	}
+	return version, nil
+}
+
+// SetDefaultAccount sets the account the user's payments default to. A nil
+// accountID clears it.
+func (r *UserRepository) SetDefaultAccount(ctx context.Context, id uuid.UUID, accountID *uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `UPDATE users SET default_account_id = $1 WHERE id = $2`, accountID, id)
+	if err != nil {
+		return fmt.Errorf("SetDefaultAccount: %w", err)
+	}
+
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("SetDefaultAccount: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// SetCountry sets the user's country of residence. A nil country clears it.
+func (r *UserRepository) SetTier(ctx context.Context, id uuid.UUID, tier domain.UserTier) (*domain.User, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE users SET tier = $1 WHERE id = $2 RETURNING `+userColumns,
+		tier, id,
+	)
+	u, err := scanUser(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("SetTier: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("SetTier: %w", err)
+	}
+	return u, nil
+}
+
+func (r *UserRepository) SetCountry(ctx context.Context, id uuid.UUID, country *string) (*domain.User, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE users SET country = $1 WHERE id = $2 RETURNING `+userColumns,
+		country, id,
+	)
+	u, err := scanUser(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("SetCountry: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("SetCountry: %w", err)
+	}
+	return u, nil
+}
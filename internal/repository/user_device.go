@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type UserDeviceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserDeviceRepository(db *pgxpool.Pool) *UserDeviceRepository {
+	return &UserDeviceRepository{db: db}
+}
+
+// Touch records a sign-in from d, adding the device if it is new, and
+// reports whether it was.
+func (r *UserDeviceRepository) Touch(ctx context.Context, tx pgx.Tx, d *domain.UserDevice) (bool, error) {
+	var added bool
+	err := tx.QueryRow(ctx,
+		`INSERT INTO user_devices (user_id, user_agent_hash, user_agent, last_ip, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, user_agent_hash) DO UPDATE
+		SET last_ip = EXCLUDED.last_ip, last_seen_at = EXCLUDED.last_seen_at
+		RETURNING xmax = 0`,
+		d.UserID, d.UserAgentHash, d.UserAgent, d.LastIP, d.LastSeenAt,
+	).Scan(&added)
+	if err != nil {
+		return false, fmt.Errorf("Touch: %w", err)
+	}
+	return added, nil
+}
+
+// HasOther reports whether the user has signed in from any device but the
+// one with userAgentHash.
+func (r *UserDeviceRepository) HasOther(ctx context.Context, tx pgx.Tx, userID uuid.UUID, userAgentHash string) (bool, error) {
+	var other bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM user_devices WHERE user_id = $1 AND user_agent_hash <> $2)`,
+		userID, userAgentHash,
+	).Scan(&other)
+	if err != nil {
+		return false, fmt.Errorf("HasOther: %w", err)
+	}
+	return other, nil
+}
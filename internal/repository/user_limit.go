@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const userLimitColumns = `user_id, currency, tx_limit, reason, updated_by, created_at, updated_at`
+
+type UserLimitRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserLimitRepository(db *pgxpool.Pool) *UserLimitRepository {
+	return &UserLimitRepository{db: db}
+}
+
+func (r *UserLimitRepository) Get(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.UserLimit, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+userLimitColumns+` FROM user_limits WHERE user_id = $1 AND currency = $2`,
+		userID, currency,
+	)
+	l, err := scanUserLimit(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return l, nil
+}
+
+func (r *UserLimitRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.UserLimit, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+userLimitColumns+` FROM user_limits WHERE user_id = $1 ORDER BY currency`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	defer rows.Close()
+
+	var limits []domain.UserLimit
+	for rows.Next() {
+		l, err := scanUserLimit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListByUser: scan: %w", err)
+		}
+		limits = append(limits, *l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListByUser: rows: %w", err)
+	}
+	return limits, nil
+}
+
+// Upsert sets the override, keeping the original created_at when one
+// already exists.
+func (r *UserLimitRepository) Upsert(ctx context.Context, l *domain.UserLimit) error {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO user_limits (user_id, currency, tx_limit, reason, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (user_id, currency) DO UPDATE
+		SET tx_limit = EXCLUDED.tx_limit, reason = EXCLUDED.reason,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`,
+		l.UserID, l.Currency, l.TxLimit, l.Reason, l.UpdatedBy, l.UpdatedAt,
+	).Scan(&l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("Upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *UserLimitRepository) Delete(ctx context.Context, userID uuid.UUID, currency domain.Currency) error {
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM user_limits WHERE user_id = $1 AND currency = $2`,
+		userID, currency,
+	)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	n := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Delete: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func scanUserLimit(s scanner) (*domain.UserLimit, error) {
+	var l domain.UserLimit
+	err := s.Scan(&l.UserID, &l.Currency, &l.TxLimit, &l.Reason, &l.UpdatedBy, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const userTOTPColumns = `user_id, secret_encrypted, confirmed_at, last_used_step, created_at`
+
+type UserTOTPRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserTOTPRepository(db *pgxpool.Pool) *UserTOTPRepository {
+	return &UserTOTPRepository{db: db}
+}
+
+func scanUserTOTP(row interface{ Scan(...any) error }) (*domain.UserTOTP, error) {
+	var t domain.UserTOTP
+	if err := row.Scan(&t.UserID, &t.SecretEncrypted, &t.ConfirmedAt, &t.LastUsedStep, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *UserTOTPRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error) {
+	t, err := scanUserTOTP(r.db.QueryRow(ctx,
+		`SELECT `+userTOTPColumns+` FROM user_totp WHERE user_id = $1`, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return t, nil
+}
+
+func (r *UserTOTPRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (*domain.UserTOTP, error) {
+	t, err := scanUserTOTP(tx.QueryRow(ctx,
+		`SELECT `+userTOTPColumns+` FROM user_totp WHERE user_id = $1 FOR UPDATE`, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetForUpdate: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetForUpdate: %w", err)
+	}
+	return t, nil
+}
+
+// Enroll stores a new pending secret for the user, replacing any earlier
+// pending one. It returns domain.ErrTOTPAlreadyEnabled if the user has a
+// confirmed enrollment, which is left untouched.
+func (r *UserTOTPRepository) Enroll(ctx context.Context, userID uuid.UUID, secret []byte, at time.Time) error {
+	res, err := r.db.Exec(ctx,
+		`INSERT INTO user_totp (user_id, secret_encrypted, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_totp.confirmed_at IS NULL`,
+		userID, secret, at,
+	)
+	if err != nil {
+		return fmt.Errorf("Enroll: %w", err)
+	}
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Enroll: %w", domain.ErrTOTPAlreadyEnabled)
+	}
+	return nil
+}
+
+// UseStep records the time step of an accepted code, and confirms the
+// enrollment if it wasn't already.
+func (r *UserTOTPRepository) UseStep(ctx context.Context, tx pgx.Tx, userID uuid.UUID, step int64, at time.Time) error {
+	if _, err := tx.Exec(ctx,
+		`UPDATE user_totp SET last_used_step = $2, confirmed_at = COALESCE(confirmed_at, $3) WHERE user_id = $1`,
+		userID, step, at,
+	); err != nil {
+		return fmt.Errorf("UseStep: %w", err)
+	}
+	return nil
+}
+
+func (r *UserTOTPRepository) Delete(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	return nil
+}
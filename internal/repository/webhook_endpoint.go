@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+const webhookEndpointColumns = `user_id, url, secret_encrypted, created_at, updated_at, secret_rotated_at`
+
+const webhookAttemptColumns = `id, delivery_id, user_id, event_id, event_type, attempt, url, status_code, latency_ms, error, created_at`
+
+type WebhookEndpointRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookEndpointRepository(db *pgxpool.Pool) *WebhookEndpointRepository {
+	return &WebhookEndpointRepository{db: db}
+}
+
+func scanWebhookEndpoint(row scanner) (*domain.WebhookEndpoint, error) {
+	var e domain.WebhookEndpoint
+	if err := row.Scan(&e.UserID, &e.URL, &e.SecretEncrypted, &e.CreatedAt, &e.UpdatedAt, &e.SecretRotatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *WebhookEndpointRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.WebhookEndpoint, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE user_id = $1`, userID)
+	e, err := scanWebhookEndpoint(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return e, nil
+}
+
+// Save creates the user's endpoint with e's URL and secret, or points an
+// existing one at e's URL and keeps its secret.
+func (r *WebhookEndpointRepository) Save(ctx context.Context, e *domain.WebhookEndpoint) (*domain.WebhookEndpoint, error) {
+	row := r.db.QueryRow(ctx,
+		`INSERT INTO webhook_endpoints (user_id, url, secret_encrypted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE SET url = EXCLUDED.url, updated_at = EXCLUDED.updated_at
+		RETURNING `+webhookEndpointColumns,
+		e.UserID, e.URL, e.SecretEncrypted, e.UpdatedAt,
+	)
+	saved, err := scanWebhookEndpoint(row)
+	if err != nil {
+		return nil, fmt.Errorf("Save: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *WebhookEndpointRepository) SetSecret(ctx context.Context, userID uuid.UUID, secret []byte, at time.Time) (*domain.WebhookEndpoint, error) {
+	row := r.db.QueryRow(ctx,
+		`UPDATE webhook_endpoints SET secret_encrypted = $2, secret_rotated_at = $3, updated_at = $3
+		WHERE user_id = $1
+		RETURNING `+webhookEndpointColumns,
+		userID, secret, at,
+	)
+	e, err := scanWebhookEndpoint(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("SetSecret: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("SetSecret: %w", err)
+	}
+	return e, nil
+}
+
+// Delete removes the endpoint along with its pending deliveries and
+// attempt history.
+func (r *WebhookEndpointRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `DELETE FROM webhook_endpoints WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	n := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("Delete: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Enqueue queues d in tx if its user has an endpoint. An event already
+// queued for the user is left as it is.
+func (r *WebhookEndpointRepository) Enqueue(ctx context.Context, tx pgx.Tx, d *domain.WebhookDelivery) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO webhook_deliveries (id, user_id, event_id, event_type, payload, next_attempt_at, created_at)
+		SELECT $1, user_id, $3, $4, $5, $6, $6 FROM webhook_endpoints WHERE user_id = $2
+		ON CONFLICT (user_id, event_id) DO NOTHING`,
+		d.ID, d.UserID, d.EventID, d.EventType, d.Payload, d.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Enqueue: %w", err)
+	}
+	return nil
+}
+
+// ListDue returns up to limit pending deliveries whose next attempt is due,
+// oldest first, with the endpoint to send them to.
+func (r *WebhookEndpointRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT d.id, d.user_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.created_at, d.delivered_at, e.url, e.secret_encrypted
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON e.user_id = d.user_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+		ORDER BY d.next_attempt_at
+		LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListDue: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.UserID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt, &d.URL, &d.SecretEncrypted); err != nil {
+			return nil, fmt.Errorf("ListDue: scan: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDue: rows: %w", err)
+	}
+	return out, nil
+}
+
+// RecordAttempt stores a and, for a queued delivery, moves the delivery to
+// status. next is when a pending delivery is tried again.
+func (r *WebhookEndpointRepository) RecordAttempt(ctx context.Context, a *domain.WebhookDeliveryAttempt, status domain.WebhookDeliveryStatus, next time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO webhook_delivery_attempts (`+webhookAttemptColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		a.ID, a.DeliveryID, a.UserID, a.EventID, a.EventType, a.Attempt, a.URL, a.StatusCode, a.LatencyMS, a.Error, a.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt: %w", err)
+	}
+
+	if a.DeliveryID != nil {
+		var deliveredAt *time.Time
+		if status == domain.WebhookDeliveryDelivered {
+			deliveredAt = &a.CreatedAt
+		}
+		_, err = tx.Exec(ctx,
+			`UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, delivered_at = $5
+			WHERE id = $1`,
+			*a.DeliveryID, status, a.Attempt, next, deliveredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("RecordAttempt: update delivery: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("RecordAttempt: commit: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts returns the user's most recent attempts, newest first.
+func (r *WebhookEndpointRepository) ListAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]domain.WebhookDeliveryAttempt, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookAttemptColumns+` FROM webhook_delivery_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.WebhookDeliveryAttempt
+	for rows.Next() {
+		var a domain.WebhookDeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.UserID, &a.EventID, &a.EventType, &a.Attempt, &a.URL,
+			&a.StatusCode, &a.LatencyMS, &a.Error, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListAttempts: scan: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListAttempts: rows: %w", err)
+	}
+	return out, nil
+}
@@ -1,92 +1,417 @@
 package repository
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
-	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
-const webhookEventColumns = `id, idempotency_key, event_type, payload, status,
-	attempts, last_attempt, created_at`
+const webhookEventColumns = `id, idempotency_key, event_type, payload, payload_gzip, payment_id, provider, status,
+	priority, attempts, last_attempt, last_error, claimed_by, lease_expires_at, created_at`
 
+// WebhookEventRepository stores payloads larger than compressAbove bytes
+// gzipped in payload_gzip, and inflates them again on read, so callers always
+// see the JSON payload.
 type WebhookEventRepository struct {
-	db *sql.DB
+	db            *pgxpool.Pool
+	compressAbove int
+	aging         time.Duration
+	lease         time.Duration
 }
 
-func NewWebhookEventRepository(db *sql.DB) *WebhookEventRepository {
-	return &WebhookEventRepository{db: db}
+// NewWebhookEventRepository builds the repository. A compressAbove of zero
+// or less stores every payload uncompressed. aging is how long a pending
+// event waits before Claim treats it as one priority class higher; zero or
+// less orders by priority alone. lease is how long a claim lasts before
+// another processor may take the event over.
+func NewWebhookEventRepository(db *pgxpool.Pool, compressAbove int, aging, lease time.Duration) *WebhookEventRepository {
+	return &WebhookEventRepository{db: db, compressAbove: compressAbove, aging: aging, lease: lease}
 }
 
 func (r *WebhookEventRepository) Create(ctx context.Context, event *domain.WebhookEvent) error {
-	_, err := r.db.ExecContext(ctx,
+	payload, compressed, err := r.encodePayload(event.Payload)
+	if err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx,
 		`INSERT INTO webhook_events (
-			id, idempotency_key, event_type, payload, status, attempts, last_attempt, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		event.ID, event.IdempotencyKey, event.EventType, event.Payload,
-		event.Status, event.Attempts, event.LastAttempt, event.CreatedAt,
+			id, idempotency_key, event_type, payload, payload_gzip, payment_id, provider, status, priority, attempts, last_attempt, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		event.ID, event.IdempotencyKey, event.EventType, payload, compressed, event.PaymentID, event.Provider,
+		event.Status, event.Priority, event.Attempts, event.LastAttempt, event.CreatedAt,
 	)
 	if err != nil {
+		if isUniqueViolation(err, "") {
+			return fmt.Errorf("Create: %w", domain.ErrDuplicateIdempotencyKey)
+		}
 		return fmt.Errorf("Create: %w", err)
 	}
 	return nil
 }
 
-func (r *WebhookEventRepository) GetPending(ctx context.Context, limit int) ([]domain.WebhookEvent, error) {
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT `+webhookEventColumns+` FROM webhook_events
-		WHERE status = $1 ORDER BY created_at LIMIT $2`,
-		domain.WebhookEventStatusPending, limit,
+// webhookClaimLock is the advisory lock claims are taken under. Row locks
+// alone can't stop two processors each claiming a different event for the
+// same payment, so claims are serialized instead; each is one short query.
+const webhookClaimLock = 0x77686b63 // "whkc"
+
+// Claim takes up to limit events for owner and leases them for the
+// repository's lease: pending events, and processing events whose lease has
+// expired because the processor holding them stopped. Events come back
+// highest priority first and oldest first within a priority. Each aging
+// interval an event has waited counts as one class higher, so a backlog of
+// high-priority events delays low ones by at most two intervals before they
+// compete on age. An event whose payment has another event under an
+// unexpired lease is left for a later claim.
+func (r *WebhookEventRepository) Claim(ctx context.Context, owner string, limit int) ([]domain.WebhookEvent, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Claim: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, webhookClaimLock); err != nil {
+		return nil, fmt.Errorf("Claim: lock: %w", err)
+	}
+
+	order := `e.priority, e.created_at`
+	args := []any{domain.WebhookEventStatusPending, domain.WebhookEventStatusProcessing, limit}
+	if r.aging > 0 {
+		order = `GREATEST(e.priority - floor(extract(epoch FROM now() - e.created_at) / $4::float8)::int, 0), e.created_at`
+		args = append(args, r.aging.Seconds())
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT e.id FROM webhook_events e
+		WHERE (e.status = $1 OR (e.status = $2 AND e.lease_expires_at < now()))
+			AND NOT EXISTS (
+				SELECT 1 FROM webhook_events o
+				WHERE o.payment_id = e.payment_id AND o.status = $2 AND o.lease_expires_at >= now()
+			)
+		ORDER BY `+order+` LIMIT $3
+		FOR UPDATE OF e SKIP LOCKED`,
+		args...,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("GetPending: %w", err)
+		return nil, fmt.Errorf("Claim: select: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Claim: scan id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Claim: rows: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	defer rows.Close()
 
-	var events []domain.WebhookEvent
+	rows, err = tx.Query(ctx,
+		`UPDATE webhook_events
+		SET status = $1, claimed_by = $2, lease_expires_at = now() + $3::float8 * interval '1 second'
+		WHERE id = ANY($4::uuid[])
+		RETURNING `+webhookEventColumns,
+		domain.WebhookEventStatusProcessing, owner, r.lease.Seconds(), ids,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Claim: update: %w", err)
+	}
+	claimed := make(map[uuid.UUID]domain.WebhookEvent, len(ids))
 	for rows.Next() {
 		e, err := scanWebhookEvent(rows)
 		if err != nil {
-			return nil, fmt.Errorf("GetPending: scan: %w", err)
+			rows.Close()
+			return nil, fmt.Errorf("Claim: scan: %w", err)
 		}
-		events = append(events, *e)
+		claimed[e.ID] = *e
 	}
+	rows.Close()
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("GetPending: rows: %w", err)
+		return nil, fmt.Errorf("Claim: rows: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("Claim: commit: %w", err)
+	}
+
+	// RETURNING doesn't keep the order the events were selected in.
+	events := make([]domain.WebhookEvent, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, claimed[id])
 	}
 	return events, nil
 }
 
-func (r *WebhookEventRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.WebhookEventStatus) error {
-	res, err := r.db.ExecContext(ctx,
-		`UPDATE webhook_events SET status = $1, attempts = attempts + 1, last_attempt = now()
-		WHERE id = $2`,
-		status, id,
+// Renew extends owner's lease on a claimed event by the repository's lease.
+// It returns ErrNotFound when owner no longer holds the claim, because the
+// lease ran out and another processor took the event over.
+func (r *WebhookEventRepository) Renew(ctx context.Context, id uuid.UUID, owner string) error {
+	res, err := r.db.Exec(ctx,
+		`UPDATE webhook_events SET lease_expires_at = now() + $1::float8 * interval '1 second'
+		WHERE id = $2 AND status = $3 AND claimed_by = $4`,
+		r.lease.Seconds(), id, domain.WebhookEventStatusProcessing, owner,
 	)
 	if err != nil {
-		return fmt.Errorf("UpdateStatus: %w", err)
+		return fmt.Errorf("Renew: %w", err)
 	}
 
-	rows, err := res.RowsAffected()
+	rows := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("Renew: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Finish records an attempt at an event owner has claimed and gives up the
+// claim, leaving the event in status. Pending puts it back in the queue.
+// lastError is why the attempt didn't succeed, empty when it did. It returns
+// ErrNotFound when owner no longer holds the claim.
+func (r *WebhookEventRepository) Finish(ctx context.Context, id uuid.UUID, owner string, status domain.WebhookEventStatus, lastError string) error {
+	res, err := r.db.Exec(ctx,
+		`UPDATE webhook_events
+		SET status = $1, claimed_by = NULL, lease_expires_at = NULL, attempts = attempts + 1, last_attempt = now(),
+			last_error = NULLIF($5, '')
+		WHERE id = $2 AND status = $3 AND claimed_by = $4`,
+		status, id, domain.WebhookEventStatusProcessing, owner, lastError,
+	)
 	if err != nil {
-		return fmt.Errorf("UpdateStatus: rows affected: %w", err)
+		return fmt.Errorf("Finish: %w", err)
 	}
+
+	rows := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("UpdateStatus: %w", domain.ErrNotFound)
+		return fmt.Errorf("Finish: %w", domain.ErrNotFound)
 	}
 	return nil
 }
 
+// Release gives up owner's claim on events it hasn't processed, putting them
+// back in the queue without counting an attempt. Events whose claim has
+// passed to another processor are left alone.
+func (r *WebhookEventRepository) Release(ctx context.Context, ids []uuid.UUID, owner string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE webhook_events SET status = $1, claimed_by = NULL, lease_expires_at = NULL
+		WHERE id = ANY($2::uuid[]) AND status = $3 AND claimed_by = $4`,
+		domain.WebhookEventStatusPending, ids, domain.WebhookEventStatusProcessing, owner,
+	)
+	if err != nil {
+		return fmt.Errorf("Release: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookEventRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.WebhookEvent, error) {
+	e, err := scanWebhookEvent(r.db.QueryRow(ctx,
+		`SELECT `+webhookEventColumns+` FROM webhook_events WHERE id = $1`, id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("GetByID: %w", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return e, nil
+}
+
+// List returns the events matching filter, newest first.
+func (r *WebhookEventRepository) List(ctx context.Context, filter domain.WebhookEventFilter, limit, offset int) ([]domain.WebhookEvent, error) {
+	var statuses []string
+	for _, s := range filter.Statuses {
+		statuses = append(statuses, string(s))
+	}
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookEventColumns+` FROM webhook_events
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::uuid IS NULL OR payment_id = $2)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4`,
+		statuses, filter.PaymentID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.WebhookEvent{}
+	for rows.Next() {
+		e, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("List: scan: %w", err)
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("List: rows: %w", err)
+	}
+	return events, nil
+}
+
+// Requeue puts a failed event back in the queue and wakes the processors.
+// Its attempts and last error are kept for the record. It returns
+// ErrWebhookEventNotRetriable for an event that hasn't failed.
+func (r *WebhookEventRepository) Requeue(ctx context.Context, id uuid.UUID) (*domain.WebhookEvent, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Requeue: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	e, err := scanWebhookEvent(tx.QueryRow(ctx,
+		`UPDATE webhook_events SET status = $1
+		WHERE id = $2 AND status = $3
+		RETURNING `+webhookEventColumns,
+		domain.WebhookEventStatusPending, id, domain.WebhookEventStatusFailed,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, fmt.Errorf("Requeue: %w", err)
+		}
+		return nil, fmt.Errorf("Requeue: %w", domain.ErrWebhookEventNotRetriable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Requeue: %w", err)
+	}
+
+	// The insert trigger only fires for new events.
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, WebhookEventsChannel); err != nil {
+		return nil, fmt.Errorf("Requeue: notify: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("Requeue: commit: %w", err)
+	}
+	return e, nil
+}
+
+// Stats counts events by status and finds the oldest pending one, then
+// buckets the last n intervals ending at now: events received by created_at,
+// and events processed by last_attempt. Intervals come back oldest first.
+func (r *WebhookEventRepository) Stats(ctx context.Context, now time.Time, interval time.Duration, n int) (*domain.WebhookStats, error) {
+	stats := &domain.WebhookStats{Counts: make(map[domain.WebhookEventStatus]int)}
+
+	rows, err := r.db.Query(ctx, `SELECT status, COUNT(*) FROM webhook_events GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: counts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status domain.WebhookEventStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("Stats: scan count: %w", err)
+		}
+		stats.Counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Stats: counts rows: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx,
+		`SELECT MIN(created_at) FROM webhook_events WHERE status = $1`,
+		domain.WebhookEventStatusPending,
+	).Scan(&stats.OldestPendingAt)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: oldest pending: %w", err)
+	}
+
+	since := now.Add(-time.Duration(n) * interval)
+	buckets, err := r.db.Query(ctx,
+		`WITH buckets AS (
+			SELECT i, $1::timestamptz + i * ($3::float8 * interval '1 second') AS start_at
+			FROM generate_series(0, $4::int - 1) AS i
+		),
+		received AS (
+			SELECT floor(extract(epoch FROM created_at - $1::timestamptz) / $3::float8)::int AS i, COUNT(*) AS n
+			FROM webhook_events
+			WHERE created_at >= $1 AND created_at < $2
+			GROUP BY 1
+		),
+		processed AS (
+			SELECT floor(extract(epoch FROM last_attempt - $1::timestamptz) / $3::float8)::int AS i,
+				COUNT(*) FILTER (WHERE status = 'dispatched') AS dispatched,
+				COUNT(*) FILTER (WHERE status = 'failed') AS failed
+			FROM webhook_events
+			WHERE last_attempt >= $1 AND last_attempt < $2
+			GROUP BY 1
+		)
+		SELECT b.start_at, COALESCE(r.n, 0), COALESCE(p.dispatched, 0), COALESCE(p.failed, 0)
+		FROM buckets b
+		LEFT JOIN received r ON r.i = b.i
+		LEFT JOIN processed p ON p.i = b.i
+		ORDER BY b.i`,
+		since, now, interval.Seconds(), n,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: intervals: %w", err)
+	}
+	defer buckets.Close()
+	for buckets.Next() {
+		var iv domain.WebhookInterval
+		if err := buckets.Scan(&iv.Start, &iv.Received, &iv.Dispatched, &iv.Failed); err != nil {
+			return nil, fmt.Errorf("Stats: scan interval: %w", err)
+		}
+		iv.Start = iv.Start.UTC()
+		iv.End = iv.Start.Add(interval)
+		stats.Intervals = append(stats.Intervals, iv)
+	}
+	if err := buckets.Err(); err != nil {
+		return nil, fmt.Errorf("Stats: intervals rows: %w", err)
+	}
+	return stats, nil
+}
+
+// encodePayload returns the value for exactly one of the payload and
+// payload_gzip columns; the other is nil.
+func (r *WebhookEventRepository) encodePayload(payload json.RawMessage) (any, any, error) {
+	if r.compressAbove <= 0 || len(payload) <= r.compressAbove {
+		return []byte(payload), nil, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, nil, fmt.Errorf("compress payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("compress payload: %w", err)
+	}
+	return nil, buf.Bytes(), nil
+}
+
 func scanWebhookEvent(s scanner) (*domain.WebhookEvent, error) {
 	var e domain.WebhookEvent
+	var compressed []byte
 	err := s.Scan(
-		&e.ID, &e.IdempotencyKey, &e.EventType, &e.Payload,
-		&e.Status, &e.Attempts, &e.LastAttempt, &e.CreatedAt,
+		&e.ID, &e.IdempotencyKey, &e.EventType, &e.Payload, &compressed, &e.PaymentID, &e.Provider,
+		&e.Status, &e.Priority, &e.Attempts, &e.LastAttempt, &e.LastError, &e.ClaimedBy, &e.LeaseExpiresAt, &e.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if compressed != nil {
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("decompress payload: %w", err)
+		}
+		defer zr.Close()
+		if e.Payload, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("decompress payload: %w", err)
+		}
+	}
 	return &e, nil
 }
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 )
 
@@ -17,20 +20,38 @@ type accountRepo interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Account, error)
 	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error)
 	GetByUserIDAndType(ctx context.Context, userID uuid.UUID, accountType domain.AccountType) ([]domain.Account, error)
-	Create(ctx context.Context, account *domain.Account) error
+	Create(ctx context.Context, tx pgx.Tx, account *domain.Account) error
 }
 
 type userChecker interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	SetDefaultAccount(ctx context.Context, id uuid.UUID, accountID *uuid.UUID) error
+}
+
+type pendingPayoutSummer interface {
+	SumPendingOutgoing(ctx context.Context, accountID uuid.UUID) (int64, error)
+}
+
+type heldSummer interface {
+	SumHeld(ctx context.Context, accountID uuid.UUID, now time.Time) (int64, error)
+}
+
+type accountLedgerReader interface {
+	GetByAccountID(ctx context.Context, accountID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error)
 }
 
 type AccountService struct {
 	accounts accountRepo
 	users    userChecker
+	payments pendingPayoutSummer
+	holds    heldSummer
+	ledger   accountLedgerReader
+	outbox   outboxWriter
+	db       *pgxpool.Pool
 }
 
-func NewAccountService(accounts accountRepo, users userChecker) *AccountService {
-	return &AccountService{accounts: accounts, users: users}
+func NewAccountService(accounts accountRepo, users userChecker, payments pendingPayoutSummer, holds heldSummer, ledger accountLedgerReader, outbox outboxWriter, db *pgxpool.Pool) *AccountService {
+	return &AccountService{accounts: accounts, users: users, payments: payments, holds: holds, ledger: ledger, outbox: outbox, db: db}
 }
 
 func (s *AccountService) CreateAccount(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.Account, error) {
@@ -44,6 +65,9 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID uuid.UUID, cu
 		return nil, fmt.Errorf("CreateAccount: %w", domain.ErrInvalidCurrency)
 	}
 
+	// The lookup answers the common case without generating an account
+	// number; two requests racing past it are settled by the unique index,
+	// which Create reports as ErrAccountExists too.
 	_, err := s.accounts.GetByUserAndCurrency(ctx, userID, currency, domain.AccountTypeUser)
 	if err == nil {
 		return nil, fmt.Errorf("CreateAccount: %w", domain.ErrAccountExists)
@@ -71,10 +95,31 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID uuid.UUID, cu
 		CreatedAt:     time.Now().UTC(),
 	}
 
-	if err := s.accounts.Create(ctx, account); err != nil {
+	payload, err := events.Marshal(events.NewAccountCreated(account))
+	if err != nil {
+		return nil, fmt.Errorf("CreateAccount: %w", err)
+	}
+	created, err := newOutboxEvent(account.ID.String(), payload, account.CreatedAt)
+	if err != nil {
 		return nil, fmt.Errorf("CreateAccount: %w", err)
 	}
 
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CreateAccount: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.accounts.Create(ctx, tx, account); err != nil {
+		return nil, fmt.Errorf("CreateAccount: %w", err)
+	}
+	if err := s.outbox.Create(ctx, tx, created); err != nil {
+		return nil, fmt.Errorf("CreateAccount: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("CreateAccount: commit: %w", err)
+	}
+
 	log.Info("account created",
 		"account_id", account.ID,
 		"user_id", userID,
@@ -84,12 +129,22 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID uuid.UUID, cu
 	return account, nil
 }
 
-func (s *AccountService) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]domain.Account, error) {
+func (s *AccountService) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]domain.AccountSummary, error) {
 	accounts, err := s.accounts.GetByUserIDAndType(ctx, userID, domain.AccountTypeUser)
 	if err != nil {
 		return nil, fmt.Errorf("GetUserAccounts: %w", err)
 	}
-	return accounts, nil
+
+	now := time.Now().UTC()
+	summaries := make([]domain.AccountSummary, len(accounts))
+	for i, a := range accounts {
+		held, err := s.holds.SumHeld(ctx, a.ID, now)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserAccounts: %w", err)
+		}
+		summaries[i] = domain.AccountSummary{Account: a, Available: a.Balance - held}
+	}
+	return summaries, nil
 }
 
 func (s *AccountService) GetAccountByID(ctx context.Context, accountID uuid.UUID) (*domain.Account, error) {
@@ -100,6 +155,90 @@ func (s *AccountService) GetAccountByID(ctx context.Context, accountID uuid.UUID
 	return account, nil
 }
 
+// GetBalance returns the balance breakdown of one of the user's accounts.
+// Accounts of other users look the same as missing ones.
+func (s *AccountService) GetBalance(ctx context.Context, accountID, userID uuid.UUID) (*domain.AccountBalance, error) {
+	account, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("GetBalance: %w", err)
+	}
+	if account.UserID != userID || account.AccountType != domain.AccountTypeUser {
+		return nil, fmt.Errorf("GetBalance: %w", domain.ErrNotFound)
+	}
+
+	pending, err := s.payments.SumPendingOutgoing(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetBalance: %w", err)
+	}
+
+	now := time.Now().UTC()
+	held, err := s.holds.SumHeld(ctx, account.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("GetBalance: %w", err)
+	}
+
+	return &domain.AccountBalance{
+		AccountID:       account.ID,
+		Currency:        account.Currency,
+		Ledger:          account.Balance,
+		Held:            held,
+		PendingOutgoing: pending,
+		Available:       account.Balance - held,
+		AsOf:            now,
+	}, nil
+}
+
+// ListTransactions returns a page of the ledger entries on one of the
+// user's accounts, newest first, and whether another page follows.
+func (s *AccountService) ListTransactions(ctx context.Context, accountID, userID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error) {
+	account, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, false, fmt.Errorf("ListTransactions: %w", err)
+	}
+	if account.UserID != userID || account.AccountType != domain.AccountTypeUser {
+		return nil, false, fmt.Errorf("ListTransactions: %w", domain.ErrNotFound)
+	}
+
+	entries, more, err := s.ledger.GetByAccountID(ctx, account.ID, before, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("ListTransactions: %w", err)
+	}
+	return entries, more, nil
+}
+
+// SetDefaultAccount makes one of the user's active accounts the one their
+// payments are sent from when a request names no source currency. A nil
+// accountID clears the default.
+func (s *AccountService) SetDefaultAccount(ctx context.Context, userID uuid.UUID, accountID *uuid.UUID) (*domain.User, error) {
+	if accountID != nil {
+		account, err := s.accounts.GetByID(ctx, *accountID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("SetDefaultAccount: %w", domain.ErrAccountNotFound)
+			}
+			return nil, fmt.Errorf("SetDefaultAccount: %w", err)
+		}
+		if account.UserID != userID || account.AccountType != domain.AccountTypeUser {
+			return nil, fmt.Errorf("SetDefaultAccount: %w", domain.ErrAccountNotFound)
+		}
+		if account.Status == domain.AccountStatusClosed {
+			return nil, fmt.Errorf("SetDefaultAccount: %w", domain.NewDomainError(domain.ErrAccountClosed, "account_id", account.ID))
+		}
+	}
+
+	if err := s.users.SetDefaultAccount(ctx, userID, accountID); err != nil {
+		return nil, fmt.Errorf("SetDefaultAccount: %w", err)
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("SetDefaultAccount: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("default account set", "user_id", userID, "account_id", accountID)
+	return user, nil
+}
+
 func generateAccountNumber() (string, error) {
 	digits := make([]byte, 10)
 	for i := range digits {
@@ -114,13 +253,8 @@ func generateAccountNumber() (string, error) {
 
 func generateIBAN(currency domain.Currency, acctNum string) string {
 	prefix := "XX"
-	switch currency {
-	case domain.CurrencyGBP:
-		prefix = "GB"
-	case domain.CurrencyEUR:
-		prefix = "DE"
-	case domain.CurrencyUSD:
-		prefix = "US"
+	if spec, ok := domain.LookupCurrency(currency); ok && spec.Country != "" {
+		prefix = spec.Country
 	}
 	return fmt.Sprintf("%s82GREY0000%s", prefix, acctNum)
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestAccountFreezeService_FreezeListUnfreeze(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	audit := NewAuditService(repository.NewAuditLogRepository(db))
+	freezes := NewAccountFreezeService(repository.NewAccountRepository(db), audit)
+
+	admin := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops")
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+	aliceUSD := testutil.SeedTestAccount(t, db, alice.ID, "USD", 1000)
+	bobUSD := testutil.SeedTestAccount(t, db, bob.ID, "USD", 1000)
+	ctx := auth.ContextWithUserID(context.Background(), admin.ID)
+
+	acct, err := freezes.Freeze(ctx, FreezeAccountRequest{AccountID: aliceUSD.ID, Reason: domain.FreezeReasonComplianceReview, ActorID: admin.ID})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusFrozen, acct.Status)
+	require.NotNil(t, acct.FreezeReason)
+	assert.Equal(t, domain.FreezeReasonComplianceReview, *acct.FreezeReason)
+	assert.Equal(t, admin.ID, *acct.FrozenBy)
+
+	acct, err = freezes.Freeze(ctx, FreezeAccountRequest{AccountID: aliceUSD.ID, Reason: domain.FreezeReasonFraudSuspected, Note: "chargebacks", ActorID: admin.ID})
+	require.NoError(t, err)
+	assert.Equal(t, domain.FreezeReasonFraudSuspected, *acct.FreezeReason, "refreezing replaces the reason")
+
+	_, err = freezes.Freeze(ctx, FreezeAccountRequest{AccountID: bobUSD.ID, Reason: domain.FreezeReasonUserRequested, ActorID: admin.ID})
+	require.NoError(t, err)
+
+	fraud, err := freezes.ListFrozen(ctx, domain.FreezeReasonFraudSuspected, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, fraud, 1)
+	assert.Equal(t, aliceUSD.ID, fraud[0].ID)
+
+	all, err := freezes.ListFrozen(ctx, "", 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	acct, err = freezes.Unfreeze(ctx, aliceUSD.ID, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusActive, acct.Status)
+	assert.Nil(t, acct.FreezeReason)
+	assert.Nil(t, acct.FrozenAt)
+
+	_, err = freezes.Unfreeze(ctx, aliceUSD.ID, admin.ID)
+	require.ErrorIs(t, err, domain.ErrAccountNotFrozen)
+
+	_, err = freezes.Freeze(ctx, FreezeAccountRequest{AccountID: testutil.FXPoolGBPID, Reason: domain.FreezeReasonComplianceReview, ActorID: admin.ID})
+	require.ErrorIs(t, err, domain.ErrNotFound, "system accounts can't be frozen")
+
+	logs, _, err := audit.List(ctx, domain.AuditFilter{ResourceType: "account", ResourceID: aliceUSD.ID.String()}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	assert.Equal(t, domain.AuditActionAccountUnfrozen, logs[0].Action)
+	assert.JSONEq(t, `{"status":"frozen","freeze_reason":"fraud_suspected"}`, string(logs[0].Before))
+	assert.JSONEq(t, `{"status":"frozen","freeze_reason":"fraud_suspected","note":"chargebacks"}`, string(logs[1].After))
+}
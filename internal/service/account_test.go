@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubBalanceAccounts struct {
+	accountRepo
+	accounts map[uuid.UUID]*domain.Account
+}
+
+func (s stubBalanceAccounts) GetByID(_ context.Context, id uuid.UUID) (*domain.Account, error) {
+	if a, ok := s.accounts[id]; ok {
+		return a, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s stubBalanceAccounts) GetByUserIDAndType(_ context.Context, userID uuid.UUID, accountType domain.AccountType) ([]domain.Account, error) {
+	var out []domain.Account
+	for _, a := range s.accounts {
+		if a.UserID == userID && a.AccountType == accountType {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+type stubHeld map[uuid.UUID]int64
+
+func (s stubHeld) SumHeld(_ context.Context, accountID uuid.UUID, _ time.Time) (int64, error) {
+	return s[accountID], nil
+}
+
+type stubPendingPayouts map[uuid.UUID]int64
+
+func (s stubPendingPayouts) SumPendingOutgoing(_ context.Context, accountID uuid.UUID) (int64, error) {
+	return s[accountID], nil
+}
+
+func TestAccountService_GetBalance(t *testing.T) {
+	owner := uuid.New()
+	acct := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyGBP, AccountType: domain.AccountTypeUser, Balance: 7000}
+	pool := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyGBP, AccountType: domain.AccountTypeFXPool, Balance: 1}
+
+	svc := NewAccountService(
+		stubBalanceAccounts{accounts: map[uuid.UUID]*domain.Account{acct.ID: acct, pool.ID: pool}},
+		nil,
+		stubPendingPayouts{acct.ID: 2500},
+		stubHeld{acct.ID: 1500},
+		nil, nil, nil,
+	)
+	ctx := context.Background()
+
+	b, err := svc.GetBalance(ctx, acct.ID, owner)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyGBP, b.Currency)
+	assert.Equal(t, int64(7000), b.Ledger)
+	assert.Equal(t, int64(1500), b.Held)
+	assert.Equal(t, int64(2500), b.PendingOutgoing)
+	assert.Equal(t, int64(5500), b.Available)
+
+	_, err = svc.GetBalance(ctx, acct.ID, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = svc.GetBalance(ctx, pool.ID, owner)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestAccountService_GetUserAccountsAvailable(t *testing.T) {
+	owner := uuid.New()
+	held := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyUSD, AccountType: domain.AccountTypeUser, Balance: 10000}
+	free := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyEUR, AccountType: domain.AccountTypeUser, Balance: 300}
+
+	svc := NewAccountService(
+		stubBalanceAccounts{accounts: map[uuid.UUID]*domain.Account{held.ID: held, free.ID: free}},
+		nil, nil, stubHeld{held.ID: 4000}, nil, nil, nil,
+	)
+
+	accounts, err := svc.GetUserAccounts(context.Background(), owner)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	available := map[uuid.UUID]int64{}
+	for _, a := range accounts {
+		available[a.ID] = a.Available
+	}
+	assert.Equal(t, int64(6000), available[held.ID])
+	assert.Equal(t, int64(300), available[free.ID])
+}
+
+type stubDefaultUsers struct {
+	userChecker
+	users map[uuid.UUID]*domain.User
+}
+
+func (s stubDefaultUsers) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.users[id], nil
+}
+
+func (s stubDefaultUsers) SetDefaultAccount(_ context.Context, id uuid.UUID, accountID *uuid.UUID) error {
+	s.users[id].DefaultAccountID = accountID
+	return nil
+}
+
+func TestAccountService_SetDefaultAccount(t *testing.T) {
+	owner := uuid.New()
+	acct := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyEUR, AccountType: domain.AccountTypeUser, Status: domain.AccountStatusActive}
+	closed := &domain.Account{ID: uuid.New(), UserID: owner, Currency: domain.CurrencyGBP, AccountType: domain.AccountTypeUser, Status: domain.AccountStatusClosed}
+	other := &domain.Account{ID: uuid.New(), UserID: uuid.New(), Currency: domain.CurrencyUSD, AccountType: domain.AccountTypeUser, Status: domain.AccountStatusActive}
+
+	users := stubDefaultUsers{users: map[uuid.UUID]*domain.User{owner: {ID: owner}}}
+	svc := NewAccountService(
+		stubBalanceAccounts{accounts: map[uuid.UUID]*domain.Account{acct.ID: acct, closed.ID: closed, other.ID: other}},
+		users, nil, nil, nil, nil, nil,
+	)
+	ctx := context.Background()
+
+	u, err := svc.SetDefaultAccount(ctx, owner, &acct.ID)
+	require.NoError(t, err)
+	require.NotNil(t, u.DefaultAccountID)
+	assert.Equal(t, acct.ID, *u.DefaultAccountID)
+
+	_, err = svc.SetDefaultAccount(ctx, owner, &other.ID)
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound, "another user's account")
+
+	_, err = svc.SetDefaultAccount(ctx, owner, &closed.ID)
+	assert.ErrorIs(t, err, domain.ErrAccountClosed)
+
+	missing := uuid.New()
+	_, err = svc.SetDefaultAccount(ctx, owner, &missing)
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+
+	u, err = svc.SetDefaultAccount(ctx, owner, nil)
+	require.NoError(t, err)
+	assert.Nil(t, u.DefaultAccountID, "nil clears the default")
+}
+
+func TestCreateAccount_ConcurrentRequestsCreateOne(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	testutil.SeedSystemUser(t, db)
+	user := testutil.SeedTestUser(t, db, "race@test.com", "Race", "race")
+
+	svc := NewAccountService(repository.NewAccountRepository(db), repository.NewUserRepository(db),
+		nil, nil, nil, repository.NewOutboxRepository(db), db)
+
+	// Released together, most requests get past the existence check before
+	// any has inserted, leaving the unique index to pick the winner.
+	const n = 8
+	start := make(chan struct{})
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = svc.CreateAccount(ctx, user.ID, domain.CurrencyEUR)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, domain.ErrAccountExists):
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	var count int
+	require.NoError(t, db.QueryRow(ctx,
+		`SELECT count(*) FROM accounts WHERE user_id = $1 AND currency = 'EUR'`, user.ID).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	var published int
+	require.NoError(t, db.QueryRow(ctx,
+		`SELECT count(*) FROM outbox_events WHERE topic = 'account.created'`).Scan(&published))
+	assert.Equal(t, 1, published, "the losers' outbox events roll back with them")
+}
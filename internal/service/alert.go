@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Alert is an operational problem that needs someone on the team to act,
+// as opposed to a Notification, which goes to a customer.
+type Alert struct {
+	Kind    string
+	Subject string
+	// Attrs are slog-style key/value pairs identifying what is affected.
+	Attrs []any
+}
+
+// Alerter routes alerts to operators.
+type Alerter interface {
+	Alert(ctx context.Context, a Alert)
+}
+
+// LogAlerter writes alerts to the log at error level, tagged so log-based
+// alerting can pick them up. It stands in until a paging channel exists.
+type LogAlerter struct {
+	logger *slog.Logger
+}
+
+func NewLogAlerter(logger *slog.Logger) *LogAlerter {
+	return &LogAlerter{logger: logger}
+}
+
+func (a *LogAlerter) Alert(_ context.Context, alert Alert) {
+	attrs := append([]any{"alert", alert.Kind, "subject", alert.Subject}, alert.Attrs...)
+	a.logger.Error("operator alert", attrs...)
+}
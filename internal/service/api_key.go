@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+const notificationKindAPIKeyCreated = templates.APIKeyCreated
+
+type apiKeyRepo interface {
+	LockUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error
+	Create(ctx context.Context, tx pgx.Tx, k *domain.APIKey) error
+	CountActive(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (int, error)
+	SeenOrigin(ctx context.Context, tx pgx.Tx, userID uuid.UUID, ip, userAgent string) (bool, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, userID, id uuid.UUID) (*domain.APIKey, error)
+	Revoke(ctx context.Context, tx pgx.Tx, id uuid.UUID, at time.Time) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// APIKeyService lets users manage their own API keys. The full key is only
+// returned when it is created or rotated; afterwards only its hash is kept.
+// Creating a key from an IP address and user agent the user hasn't created
+// a key from before sends them a notification.
+type APIKeyService struct {
+	keys      apiKeyRepo
+	notifier  Notifier
+	db        *pgxpool.Pool
+	maxActive int
+}
+
+func NewAPIKeyService(keys apiKeyRepo, notifier Notifier, db *pgxpool.Pool, maxActive int) *APIKeyService {
+	return &APIKeyService{keys: keys, notifier: notifier, db: db, maxActive: maxActive}
+}
+
+func (s *APIKeyService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []domain.APIKeyScope, ip, userAgent string) (*domain.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("Create: name is required: %w", domain.ErrInvalidRequest)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", fmt.Errorf("Create: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("Create: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.keys.LockUser(ctx, tx, userID); err != nil {
+		return nil, "", fmt.Errorf("Create: %w", err)
+	}
+	active, err := s.keys.CountActive(ctx, tx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Create: %w", err)
+	}
+	if active >= s.maxActive {
+		return nil, "", fmt.Errorf("Create: %w", domain.NewDomainError(domain.ErrAPIKeyLimitReached, "active", active, "max_active", s.maxActive))
+	}
+
+	key, secret, newOrigin, err := s.issue(ctx, tx, userID, name, scopes, ip, userAgent, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("Create: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", fmt.Errorf("Create: commit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("api key created", "user_id", userID, "api_key_id", key.ID, "prefix", key.Prefix)
+	if newOrigin {
+		s.notifyCreated(ctx, key)
+	}
+	return key, secret, nil
+}
+
+// Rotate replaces a key with a new one of the same name and scopes and
+// revokes the old one.
+func (s *APIKeyService) Rotate(ctx context.Context, userID, keyID uuid.UUID, ip, userAgent string) (*domain.APIKey, string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("Rotate: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.keys.LockUser(ctx, tx, userID); err != nil {
+		return nil, "", fmt.Errorf("Rotate: %w", err)
+	}
+	old, err := s.keys.GetForUpdate(ctx, tx, userID, keyID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Rotate: %w", err)
+	}
+	if old.RevokedAt != nil {
+		return nil, "", fmt.Errorf("Rotate: %w", domain.ErrAPIKeyRevoked)
+	}
+
+	key, secret, newOrigin, err := s.issue(ctx, tx, userID, old.Name, old.Scopes, ip, userAgent, &old.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Rotate: %w", err)
+	}
+	if err := s.keys.Revoke(ctx, tx, old.ID, key.CreatedAt); err != nil {
+		return nil, "", fmt.Errorf("Rotate: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", fmt.Errorf("Rotate: commit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("api key rotated", "user_id", userID, "api_key_id", key.ID, "rotated_from", old.ID)
+	if newOrigin {
+		s.notifyCreated(ctx, key)
+	}
+	return key, secret, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID) (*domain.APIKey, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Revoke: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	key, err := s.keys.GetForUpdate(ctx, tx, userID, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("Revoke: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("Revoke: %w", domain.ErrAPIKeyRevoked)
+	}
+
+	now := time.Now().UTC()
+	if err := s.keys.Revoke(ctx, tx, key.ID, now); err != nil {
+		return nil, fmt.Errorf("Revoke: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("Revoke: commit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("api key revoked", "user_id", userID, "api_key_id", key.ID)
+	key.RevokedAt = &now
+	return key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	keys, err := s.keys.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return keys, nil
+}
+
+// Authenticate returns the active key plain is. Malformed, unknown and
+// revoked keys all return domain.ErrNotFound, so a caller can't tell them
+// apart. A match updates the key's last_used_at.
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (*domain.APIKey, error) {
+	prefix, ok := apiKeyPrefixOf(plain)
+	if !ok {
+		return nil, fmt.Errorf("Authenticate: malformed key: %w", domain.ErrNotFound)
+	}
+	key, err := s.keys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("Authenticate: %w", err)
+	}
+	if subtle.ConstantTimeCompare(key.KeyHash, hashAPIKey(plain)) != 1 {
+		securityEvent(ctx, "api_key_mismatch", "api_key_id", key.ID, "prefix", prefix)
+		return nil, fmt.Errorf("Authenticate: %w", domain.ErrNotFound)
+	}
+	if key.RevokedAt != nil {
+		securityEvent(ctx, "api_key_revoked_used", "api_key_id", key.ID, "user_id", key.UserID)
+		return nil, fmt.Errorf("Authenticate: revoked: %w", domain.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	if err := s.keys.TouchLastUsed(ctx, key.ID, now); err != nil {
+		logging.FromContext(ctx).Warn("failed to record api key use", "api_key_id", key.ID, "error", err)
+	}
+	key.LastUsedAt = &now
+	return key, nil
+}
+
+// issue generates and stores a key. newOrigin reports whether the user had
+// never created a key from this IP address and user agent.
+func (s *APIKeyService) issue(ctx context.Context, tx pgx.Tx, userID uuid.UUID, name string, scopes []domain.APIKeyScope, ip, userAgent string, rotatedFrom *uuid.UUID) (*domain.APIKey, string, bool, error) {
+	seen, err := s.keys.SeenOrigin(ctx, tx, userID, ip, userAgent)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", false, err
+	}
+	key := &domain.APIKey{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     hashAPIKey(plain),
+		LastFour:    plain[len(plain)-4:],
+		Scopes:      scopes,
+		CreatedIP:   ip,
+		CreatedUA:   userAgent,
+		CreatedAt:   time.Now().UTC(),
+		RotatedFrom: rotatedFrom,
+	}
+	if err := s.keys.Create(ctx, tx, key); err != nil {
+		return nil, "", false, err
+	}
+	return key, plain, !seen, nil
+}
+
+// notifyCreated tells the user a key was created from somewhere new. The key
+// already exists by now, so a failed notification is only logged.
+func (s *APIKeyService) notifyCreated(ctx context.Context, key *domain.APIKey) {
+	n, err := renderNotification(key.UserID, notificationKindAPIKeyCreated, templates.APIKeyCreatedData{
+		Name:      key.Name,
+		MaskedKey: key.Masked(),
+		CreatedAt: key.CreatedAt,
+		IP:        key.CreatedIP,
+		UserAgent: key.CreatedUA,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to render api key notification", "api_key_id", key.ID, "error", err)
+		return
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		logging.FromContext(ctx).Warn("failed to send api key notification", "api_key_id", key.ID, "error", err)
+	}
+}
+
+// normalizeScopes rejects unknown scopes and returns the rest sorted and
+// without duplicates.
+func normalizeScopes(scopes []domain.APIKeyScope) ([]domain.APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required: %w", domain.ErrInvalidRequest)
+	}
+	for _, sc := range scopes {
+		if !sc.Valid() {
+			return nil, fmt.Errorf("scopes: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "scopes", "value", sc))
+		}
+	}
+	out := slices.Clone(scopes)
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// generateAPIKey returns a new key of the form grey_<prefix id>_<secret> and
+// the part of it up to the secret, which is stored for lookup.
+func generateAPIKey() (key, prefix string, err error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("generateAPIKey: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("generateAPIKey: %w", err)
+	}
+	prefix = domain.APIKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// apiKeyPrefixOf returns the stored prefix of a key of the form
+// grey_<12 hex>_<secret>.
+func apiKeyPrefixOf(key string) (string, bool) {
+	n := len(domain.APIKeyPrefix) + 12
+	if len(key) <= n+1 || !strings.HasPrefix(key, domain.APIKeyPrefix) || key[n] != '_' {
+		return "", false
+	}
+	return key[:n], true
+}
+
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
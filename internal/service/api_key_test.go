@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestAPIKeys_CreateRotateRevoke(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	notifier := &stubNotifier{}
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(db), notifier, db, 2)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+
+	_, _, err := keys.Create(ctx, alice.ID, "ci", []domain.APIKeyScope{"admin"}, "10.0.0.1", "curl/8.0")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	ci, plain, err := keys.Create(ctx, alice.ID, "ci",
+		[]domain.APIKeyScope{domain.APIKeyScopeRead, domain.APIKeyScopePaymentsWrite, domain.APIKeyScopeRead},
+		"10.0.0.1", "curl/8.0")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, ci.Prefix+"_"))
+	assert.True(t, strings.HasSuffix(plain, ci.LastFour))
+	assert.Equal(t, hashAPIKey(plain), ci.KeyHash)
+	assert.Equal(t, []domain.APIKeyScope{domain.APIKeyScopePaymentsWrite, domain.APIKeyScopeRead}, ci.Scopes)
+	require.Len(t, notifier.sent, 1, "first key from an origin notifies")
+	assert.Equal(t, alice.ID, notifier.sent[0].UserID)
+	assert.NotContains(t, notifier.sent[0].Body, plain)
+
+	_, _, err = keys.Create(ctx, alice.ID, "reports", []domain.APIKeyScope{domain.APIKeyScopeRead}, "10.0.0.1", "curl/8.0")
+	require.NoError(t, err)
+	assert.Len(t, notifier.sent, 1, "same origin doesn't notify again")
+
+	_, _, err = keys.Create(ctx, alice.ID, "third", []domain.APIKeyScope{domain.APIKeyScopeRead}, "10.0.0.2", "curl/8.0")
+	assert.ErrorIs(t, err, domain.ErrAPIKeyLimitReached)
+
+	_, _, err = keys.Rotate(ctx, bob.ID, ci.ID, "10.0.0.1", "curl/8.0")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "other users' keys are invisible")
+
+	rotated, newPlain, err := keys.Rotate(ctx, alice.ID, ci.ID, "192.0.2.7", "Mozilla/5.0")
+	require.NoError(t, err)
+	assert.NotEqual(t, plain, newPlain)
+	assert.Equal(t, ci.Name, rotated.Name)
+	assert.Equal(t, ci.Scopes, rotated.Scopes)
+	require.NotNil(t, rotated.RotatedFrom)
+	assert.Equal(t, ci.ID, *rotated.RotatedFrom)
+	assert.Len(t, notifier.sent, 2, "rotating from a new origin notifies")
+
+	_, _, err = keys.Rotate(ctx, alice.ID, ci.ID, "10.0.0.1", "curl/8.0")
+	assert.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+
+	revoked, err := keys.Revoke(ctx, alice.ID, rotated.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = keys.Revoke(ctx, alice.ID, rotated.ID)
+	assert.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+
+	list, err := keys.List(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, rotated.ID, list[0].ID)
+	assert.Nil(t, list[0].LastUsedAt)
+
+	_, _, err = keys.Create(ctx, alice.ID, "third", []domain.APIKeyScope{domain.APIKeyScopeRead}, "10.0.0.1", "curl/8.0")
+	assert.NoError(t, err, "revoked keys free up the limit")
+}
+
+func TestAPIKeyPrefixOf(t *testing.T) {
+	prefix, ok := apiKeyPrefixOf("grey_3f9a1c2e4b5d_c2VjcmV0")
+	assert.True(t, ok)
+	assert.Equal(t, "grey_3f9a1c2e4b5d", prefix)
+
+	for _, key := range []string{"", "grey_3f9a1c2e4b5d_", "grey_3f9a1c2e4b5dXsecret", "eyJhbGciOiJIUzI1NiJ9.e30.sig"} {
+		_, ok := apiKeyPrefixOf(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(db), &stubNotifier{}, db, 5)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	key, plain, err := keys.Create(ctx, alice.ID, "ci", []domain.APIKeyScope{domain.APIKeyScopeRead}, "10.0.0.1", "curl/8.0")
+	require.NoError(t, err)
+
+	got, err := keys.Authenticate(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.True(t, got.HasScope(domain.APIKeyScopeRead))
+	assert.False(t, got.HasScope(domain.APIKeyScopePaymentsWrite))
+
+	list, err := keys.List(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, list[0].LastUsedAt, "use is recorded")
+
+	_, err = keys.Authenticate(ctx, plain+"x")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "a wrong secret with a real prefix is rejected")
+	_, err = keys.Authenticate(ctx, "grey_000000000000_secret")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = keys.Revoke(ctx, alice.ID, key.ID)
+	require.NoError(t, err)
+	_, err = keys.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "revoked keys stop working")
+}
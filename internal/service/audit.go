@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// MaxAuditExport caps the rows one export returns. Narrow the filter to get
+// the rest.
+const MaxAuditExport = 10000
+
+const auditExportPage = 500
+
+type auditLogRepo interface {
+	Create(ctx context.Context, l *domain.AuditLog) error
+	List(ctx context.Context, f domain.AuditFilter, before *domain.AuditCursor, limit int) ([]domain.AuditLog, bool, error)
+}
+
+// auditRecorder is the part of AuditService other services record with.
+type auditRecorder interface {
+	Record(ctx context.Context, e domain.AuditEntry)
+}
+
+// AuditService keeps the audit log of sensitive actions: who did what to
+// which resource, from where, and the resource before and after.
+type AuditService struct {
+	logs auditLogRepo
+}
+
+func NewAuditService(logs auditLogRepo) *AuditService {
+	return &AuditService{logs: logs}
+}
+
+// Record writes e, taking the actor and client IP from ctx. It runs after
+// the action has happened, so a failure to write is logged rather than
+// returned.
+func (s *AuditService) Record(ctx context.Context, e domain.AuditEntry) {
+	log := logging.FromContext(ctx)
+
+	l := &domain.AuditLog{
+		ID:           uuid.New(),
+		ActorID:      e.ActorID,
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		IP:           auth.ClientIPFromContext(ctx),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if l.ActorID == nil {
+		if id, ok := auth.UserIDFromContext(ctx); ok {
+			l.ActorID = &id
+		}
+	}
+
+	var err error
+	if l.Before, err = auditJSON(e.Before); err != nil {
+		log.Error("failed to encode audit log", "action", e.Action, "error", err)
+		return
+	}
+	if l.After, err = auditJSON(e.After); err != nil {
+		log.Error("failed to encode audit log", "action", e.Action, "error", err)
+		return
+	}
+
+	if err := s.logs.Create(ctx, l); err != nil {
+		log.Error("failed to write audit log", "action", e.Action, "resource_type", e.ResourceType, "resource_id", e.ResourceID, "error", err)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, f domain.AuditFilter, before *domain.AuditCursor, limit int) ([]domain.AuditLog, bool, error) {
+	logs, more, err := s.logs.List(ctx, f, before, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("List: %w", err)
+	}
+	return logs, more, nil
+}
+
+// Export returns up to MaxAuditExport rows matching f, newest first, and
+// reports whether more were left out. The export is itself recorded.
+func (s *AuditService) Export(ctx context.Context, f domain.AuditFilter) ([]domain.AuditLog, bool, error) {
+	var out []domain.AuditLog
+	var before *domain.AuditCursor
+	for {
+		page, more, err := s.logs.List(ctx, f, before, min(auditExportPage, MaxAuditExport-len(out)))
+		if err != nil {
+			return nil, false, fmt.Errorf("Export: %w", err)
+		}
+		out = append(out, page...)
+		if !more || len(page) == 0 {
+			break
+		}
+		if len(out) >= MaxAuditExport {
+			s.recordExport(ctx, f, len(out))
+			return out, true, nil
+		}
+		c := page[len(page)-1].Cursor()
+		before = &c
+	}
+
+	s.recordExport(ctx, f, len(out))
+	return out, false, nil
+}
+
+func (s *AuditService) recordExport(ctx context.Context, f domain.AuditFilter, rows int) {
+	s.Record(ctx, domain.AuditEntry{
+		Action:       domain.AuditActionExported,
+		ResourceType: "audit_log",
+		After: map[string]any{
+			"actor_id":      f.ActorID,
+			"action":        f.Action,
+			"resource_type": f.ResourceType,
+			"resource_id":   f.ResourceID,
+			"from":          f.From,
+			"to":            f.To,
+			"rows":          rows,
+		},
+	})
+}
+
+// auditJSON marshals v, leaving nil (and anything that marshals to null) as
+// no value.
+func auditJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == "null" {
+		return nil, nil
+	}
+	return b, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestAuditJSON(t *testing.T) {
+	var none *domain.UserLimit
+	b, err := auditJSON(none)
+	require.NoError(t, err)
+	assert.Nil(t, b, "a nil pointer is no value, not JSON null")
+
+	b, err = auditJSON(map[string]int{"tx_limit": 5})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tx_limit":5}`, string(b))
+}
+
+func TestAuditService_LimitChangesAndQueries(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	audit := NewAuditService(repository.NewAuditLogRepository(db))
+	limits := NewUserLimitService(repository.NewUserLimitRepository(db), repository.NewUserRepository(db),
+		map[domain.Currency]int64{domain.CurrencyUSD: 10000}, audit)
+
+	admin := testutil.SeedTestUser(t, db, "admin@test.com", "Admin", "admin")
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	ctx := auth.ContextWithClientIP(auth.ContextWithUserID(context.Background(), admin.ID), "203.0.113.9")
+
+	_, err := limits.SetLimit(ctx, SetLimitRequest{UserID: alice.ID, Currency: domain.CurrencyUSD, TxLimit: 500, Reason: "fraud review", ActorID: admin.ID})
+	require.NoError(t, err)
+	_, err = limits.ResetLimit(ctx, alice.ID, domain.CurrencyUSD, admin.ID)
+	require.NoError(t, err)
+
+	logs, more, err := audit.List(ctx, domain.AuditFilter{ResourceType: "user_limit"}, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.True(t, more)
+	reset := logs[0]
+	assert.Equal(t, domain.AuditActionLimitReset, reset.Action)
+	assert.Equal(t, admin.ID, *reset.ActorID)
+	assert.Equal(t, "203.0.113.9", reset.IP)
+	assert.Equal(t, alice.ID.String()+"/USD", reset.ResourceID)
+	assert.JSONEq(t, `{"tx_limit":500,"overridden":true,"reason":"fraud review","updated_by":"`+admin.ID.String()+`"}`, string(reset.Before))
+	assert.JSONEq(t, `{"tx_limit":10000,"overridden":false}`, string(reset.After))
+
+	c := reset.Cursor()
+	logs, more, err = audit.List(ctx, domain.AuditFilter{ResourceType: "user_limit"}, &c, 1)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.False(t, more)
+	assert.Equal(t, domain.AuditActionLimitSet, logs[0].Action)
+	assert.JSONEq(t, `{"tx_limit":10000,"overridden":false}`, string(logs[0].Before))
+
+	exported, truncated, err := audit.Export(ctx, domain.AuditFilter{ActorID: &admin.ID})
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, exported, 2)
+
+	logs, _, err = audit.List(ctx, domain.AuditFilter{Action: domain.AuditActionExported}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1, "exports are audited too")
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// balanceSettleTime is how long after an instant its balance is taken as
+// final. Entries are dated when their transaction starts, so one still
+// committing can land behind an instant that has already been read.
+const balanceSettleTime = 15 * time.Minute
+
+type balanceAccountReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Account, error)
+}
+
+type balanceSnapshotRepo interface {
+	BalanceAt(ctx context.Context, accountID uuid.UUID, at time.Time) (int64, error)
+	Snapshot(ctx context.Context, asOf time.Time) (int64, error)
+}
+
+type balanceCacheKey struct {
+	accountID uuid.UUID
+	at        int64
+}
+
+// BalanceHistoryService answers what an account's balance was at a past
+// instant, for statements, disputes and audits. Every interval it snapshots
+// every account's balance at the last UTC midnight that has settled, so a
+// query only works back through the entries between its instant and the
+// next snapshot. Settled answers never change, and the same period ends are
+// asked for again and again, so up to cacheSize of them are kept in memory,
+// oldest dropped first.
+type BalanceHistoryService struct {
+	accounts  balanceAccountReader
+	snapshots balanceSnapshotRepo
+	logger    *slog.Logger
+	interval  time.Duration
+	cacheSize int
+
+	mu    sync.Mutex
+	cache map[balanceCacheKey]int64
+	order []balanceCacheKey
+	// snapshotted is the last midnight snapshotted by this instance.
+	snapshotted time.Time
+}
+
+func NewBalanceHistoryService(accounts balanceAccountReader, snapshots balanceSnapshotRepo, logger *slog.Logger, interval time.Duration, cacheSize int) *BalanceHistoryService {
+	return &BalanceHistoryService{
+		accounts:  accounts,
+		snapshots: snapshots,
+		logger:    logger,
+		interval:  interval,
+		cacheSize: cacheSize,
+		cache:     make(map[balanceCacheKey]int64),
+	}
+}
+
+func (s *BalanceHistoryService) Start(ctx context.Context) {
+	s.logger.Info("balance snapshotter started", "interval", s.interval)
+
+	s.snapshot(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("balance snapshotter stopped")
+			return
+		case <-ticker.C:
+			s.snapshot(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (s *BalanceHistoryService) snapshot(ctx context.Context, now time.Time) {
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if now.Sub(asOf) < balanceSettleTime {
+		asOf = asOf.AddDate(0, 0, -1)
+	}
+	if asOf.Equal(s.snapshotted) {
+		return
+	}
+
+	n, err := s.snapshots.Snapshot(ctx, asOf)
+	if err != nil {
+		s.logger.Error("failed to snapshot balances", "as_of", asOf, "error", err)
+		return
+	}
+	s.snapshotted = asOf
+	s.logger.Info("balances snapshotted", "as_of", asOf, "accounts", n)
+}
+
+// BalanceAt returns the ledger balance of one of the user's accounts as of
+// at, which must not be in the future. Before the account was opened it
+// is zero. Accounts of other users look the same as missing ones.
+func (s *BalanceHistoryService) BalanceAt(ctx context.Context, accountID, userID uuid.UUID, at time.Time) (*domain.HistoricalBalance, error) {
+	account, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("BalanceAt: %w", err)
+	}
+	if account.UserID != userID || account.AccountType != domain.AccountTypeUser {
+		return nil, fmt.Errorf("BalanceAt: %w", domain.ErrNotFound)
+	}
+
+	at = at.UTC()
+	now := time.Now().UTC()
+	if at.After(now) {
+		return nil, fmt.Errorf("BalanceAt: at is in the future: %w", domain.ErrInvalidRequest)
+	}
+
+	b := &domain.HistoricalBalance{AccountID: account.ID, Currency: account.Currency, AsOf: at}
+	if at.Before(account.CreatedAt) {
+		return b, nil
+	}
+
+	settled := now.Sub(at) >= balanceSettleTime
+	key := balanceCacheKey{accountID: account.ID, at: at.UnixMicro()}
+	if settled {
+		if ledger, ok := s.cached(key); ok {
+			b.Ledger = ledger
+			return b, nil
+		}
+	}
+
+	b.Ledger, err = s.snapshots.BalanceAt(ctx, account.ID, at)
+	if err != nil {
+		return nil, fmt.Errorf("BalanceAt: %w", err)
+	}
+	if settled {
+		s.store(key, b.Ledger)
+	}
+	return b, nil
+}
+
+func (s *BalanceHistoryService) cached(key balanceCacheKey) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ledger, ok := s.cache[key]
+	return ledger, ok
+}
+
+func (s *BalanceHistoryService) store(key balanceCacheKey, ledger int64) {
+	if s.cacheSize <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cache[key]; ok {
+		return
+	}
+	if len(s.order) >= s.cacheSize {
+		delete(s.cache, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.cache[key] = ledger
+	s.order = append(s.order, key)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubBalanceSnapshots struct {
+	balance   int64
+	queries   int
+	snapshots []time.Time
+}
+
+func (s *stubBalanceSnapshots) BalanceAt(_ context.Context, _ uuid.UUID, _ time.Time) (int64, error) {
+	s.queries++
+	return s.balance, nil
+}
+
+func (s *stubBalanceSnapshots) Snapshot(_ context.Context, asOf time.Time) (int64, error) {
+	s.snapshots = append(s.snapshots, asOf)
+	return 1, nil
+}
+
+func TestBalanceHistoryService_BalanceAt(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	account := &domain.Account{
+		ID: uuid.New(), UserID: uuid.New(), Currency: domain.CurrencyUSD,
+		AccountType: domain.AccountTypeUser, CreatedAt: now.AddDate(0, -1, 0),
+	}
+	snapshots := &stubBalanceSnapshots{balance: 4200}
+	svc := NewBalanceHistoryService(stubBalanceAccounts{accounts: map[uuid.UUID]*domain.Account{account.ID: account}}, snapshots, slog.Default(), time.Hour, 10)
+
+	periodEnd := now.AddDate(0, 0, -7)
+	for range 2 {
+		b, err := svc.BalanceAt(ctx, account.ID, account.UserID, periodEnd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4200), b.Ledger)
+		assert.Equal(t, domain.CurrencyUSD, b.Currency)
+	}
+	assert.Equal(t, 1, snapshots.queries, "a settled balance is served from the cache")
+
+	recent := now.Add(-time.Minute)
+	for range 2 {
+		_, err := svc.BalanceAt(ctx, account.ID, account.UserID, recent)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, snapshots.queries, "an unsettled balance isn't cached")
+
+	b, err := svc.BalanceAt(ctx, account.ID, account.UserID, account.CreatedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, b.Ledger, "nothing before the account was opened")
+	assert.Equal(t, 3, snapshots.queries)
+
+	_, err = svc.BalanceAt(ctx, account.ID, account.UserID, now.Add(time.Hour))
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	_, err = svc.BalanceAt(ctx, account.ID, uuid.New(), periodEnd)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "another user's account")
+}
+
+func TestBalanceHistoryService_CacheDropsOldest(t *testing.T) {
+	ctx := context.Background()
+	account := &domain.Account{ID: uuid.New(), UserID: uuid.New(), AccountType: domain.AccountTypeUser}
+	snapshots := &stubBalanceSnapshots{}
+	svc := NewBalanceHistoryService(stubBalanceAccounts{accounts: map[uuid.UUID]*domain.Account{account.ID: account}}, snapshots, slog.Default(), time.Hour, 2)
+
+	day := time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC)
+	for _, at := range []time.Time{day, day.AddDate(0, 0, -1), day.AddDate(0, 0, -2), day.AddDate(0, 0, -2)} {
+		_, err := svc.BalanceAt(ctx, account.ID, account.UserID, at)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, snapshots.queries)
+
+	_, err := svc.BalanceAt(ctx, account.ID, account.UserID, day)
+	require.NoError(t, err)
+	assert.Equal(t, 4, snapshots.queries, "the oldest entry was dropped")
+}
+
+func TestBalanceHistoryService_SnapshotsLastSettledMidnight(t *testing.T) {
+	snapshots := &stubBalanceSnapshots{}
+	svc := NewBalanceHistoryService(nil, snapshots, slog.Default(), time.Hour, 0)
+	ctx := context.Background()
+
+	svc.snapshot(ctx, time.Date(2026, 2, 1, 0, 5, 0, 0, time.UTC))
+	svc.snapshot(ctx, time.Date(2026, 2, 1, 0, 50, 0, 0, time.UTC))
+	svc.snapshot(ctx, time.Date(2026, 2, 1, 1, 50, 0, 0, time.UTC))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}, snapshots.snapshots)
+}
+
+func TestBalanceHistory_WorksBackFromSnapshots(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	snapshotRepo := repository.NewBalanceSnapshotRepository(db)
+	svc := NewBalanceHistoryService(repository.NewAccountRepository(db), snapshotRepo, slog.Default(), time.Hour, 10)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+	aliceUSD := testutil.SeedTestAccount(t, db, alice.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, bob.ID, "USD", 0)
+
+	transfer := func(amount int64) time.Time {
+		t.Helper()
+		_, err := paymentSvc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+			SenderUserID: alice.ID, RecipientUniqueName: "bob",
+			SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD,
+			Amount: amount, IdempotencyKey: uuid.NewString(),
+		})
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		return time.Now().UTC()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	opened := time.Now().UTC()
+	afterFirst := transfer(2500)
+	afterSecond := transfer(1000)
+
+	balanceAt := func(at time.Time) int64 {
+		t.Helper()
+		b, err := svc.BalanceAt(ctx, aliceUSD.ID, alice.ID, at)
+		require.NoError(t, err)
+		return b.Ledger
+	}
+	assert.Equal(t, int64(10000), balanceAt(opened), "the seeded balance, worked back from the current one")
+	assert.Equal(t, int64(7500), balanceAt(afterFirst))
+	assert.Equal(t, int64(6500), balanceAt(afterSecond))
+
+	// A snapshot between the transfers is what earlier instants work back
+	// from; a later transfer doesn't change them.
+	n, err := snapshotRepo.Snapshot(ctx, afterFirst)
+	require.NoError(t, err)
+	assert.Positive(t, n)
+	transfer(500)
+
+	got, err := snapshotRepo.BalanceAt(ctx, aliceUSD.ID, opened)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), got)
+	got, err = snapshotRepo.BalanceAt(ctx, aliceUSD.ID, afterFirst)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7500), got)
+	got, err = snapshotRepo.BalanceAt(ctx, aliceUSD.ID, afterSecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6500), got)
+
+	n, err = snapshotRepo.Snapshot(ctx, afterFirst)
+	require.NoError(t, err)
+	assert.Zero(t, n, "an instant is snapshotted once")
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type beneficiaryRepo interface {
+	Create(ctx context.Context, b *domain.Beneficiary) error
+	GetForUpdate(ctx context.Context, tx pgx.Tx, userID, id uuid.UUID) (*domain.Beneficiary, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.Beneficiary, error)
+	SetDeleted(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedAt *time.Time) error
+	HasPayoutsInFlight(ctx context.Context, tx pgx.Tx, id uuid.UUID) (bool, error)
+	Delete(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+}
+
+// BeneficiaryService manages the bank accounts users save to pay out to.
+// Deleting a beneficiary hides it from listings and stops new payouts to it,
+// but keeps the row so payouts that went to it still join to it, and so it
+// can be restored. Removing one for good is refused while payouts to it are
+// in flight.
+type BeneficiaryService struct {
+	beneficiaries beneficiaryRepo
+	db            *pgxpool.Pool
+}
+
+func NewBeneficiaryService(beneficiaries beneficiaryRepo, db *pgxpool.Pool) *BeneficiaryService {
+	return &BeneficiaryService{beneficiaries: beneficiaries, db: db}
+}
+
+func (s *BeneficiaryService) Create(ctx context.Context, userID uuid.UUID, name, iban, bankName string) (*domain.Beneficiary, error) {
+	name = strings.TrimSpace(name)
+	iban = normalizeIBAN(iban)
+	bankName = strings.TrimSpace(bankName)
+	if name == "" || iban == "" || bankName == "" {
+		return nil, fmt.Errorf("Create: name, iban and bank name are required: %w", domain.ErrInvalidRequest)
+	}
+
+	now := time.Now().UTC()
+	b := &domain.Beneficiary{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		IBAN:      iban,
+		BankName:  bankName,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.beneficiaries.Create(ctx, b); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("beneficiary created", "beneficiary_id", b.ID, "user_id", userID)
+	return b, nil
+}
+
+func (s *BeneficiaryService) List(ctx context.Context, userID uuid.UUID) ([]domain.Beneficiary, error) {
+	out, err := s.beneficiaries.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return out, nil
+}
+
+// Delete soft-deletes a beneficiary. Deleting one that is already deleted
+// returns it unchanged.
+func (s *BeneficiaryService) Delete(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error) {
+	b, err := s.withBeneficiary(ctx, userID, id, func(tx pgx.Tx, b *domain.Beneficiary) error {
+		if b.Deleted() {
+			return nil
+		}
+		now := time.Now().UTC()
+		if err := s.beneficiaries.SetDeleted(ctx, tx, b.ID, &now); err != nil {
+			return err
+		}
+		b.DeletedAt = &now
+		b.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Delete: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("beneficiary deleted", "beneficiary_id", id, "user_id", userID)
+	return b, nil
+}
+
+// Restore undoes Delete. It fails with domain.ErrBeneficiaryExists if the
+// user has saved the same IBAN again since.
+func (s *BeneficiaryService) Restore(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error) {
+	b, err := s.withBeneficiary(ctx, userID, id, func(tx pgx.Tx, b *domain.Beneficiary) error {
+		if !b.Deleted() {
+			return nil
+		}
+		if err := s.beneficiaries.SetDeleted(ctx, tx, b.ID, nil); err != nil {
+			return err
+		}
+		b.DeletedAt = nil
+		b.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Restore: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("beneficiary restored", "beneficiary_id", id, "user_id", userID)
+	return b, nil
+}
+
+// Purge removes a beneficiary for good. While a payout to it hasn't
+// finished it returns domain.ErrBeneficiaryInUse, since reconciling that
+// payout needs the beneficiary. Finished payouts keep their own copy of the
+// destination.
+func (s *BeneficiaryService) Purge(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error) {
+	b, err := s.withBeneficiary(ctx, userID, id, func(tx pgx.Tx, b *domain.Beneficiary) error {
+		inFlight, err := s.beneficiaries.HasPayoutsInFlight(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+		if inFlight {
+			return domain.ErrBeneficiaryInUse
+		}
+		return s.beneficiaries.Delete(ctx, tx, b.ID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Purge: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("beneficiary purged", "beneficiary_id", id, "user_id", userID)
+	return b, nil
+}
+
+// withBeneficiary runs fn with the beneficiary locked and commits if fn
+// succeeds.
+func (s *BeneficiaryService) withBeneficiary(ctx context.Context, userID, id uuid.UUID, fn func(tx pgx.Tx, b *domain.Beneficiary) error) (*domain.Beneficiary, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, err := s.beneficiaries.GetForUpdate(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(tx, b); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return b, nil
+}
+
+// normalizeIBAN drops the spaces IBANs are usually printed with and
+// upper-cases the rest, so the same account isn't saved twice.
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestNormalizeIBAN(t *testing.T) {
+	assert.Equal(t, "DE89370400440532013000", normalizeIBAN(" de89 3704 0044 0532 0130 00 "))
+	assert.Equal(t, "", normalizeIBAN("   "))
+}
+
+func TestBeneficiaries_DeleteRestorePurge(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	beneficiaries := repository.NewBeneficiaryRepository(db)
+	payments := repository.NewPaymentRepository(db)
+	svc := NewBeneficiaryService(beneficiaries, db)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+	acct := testutil.SeedTestAccount(t, db, alice.ID, "EUR", 10000)
+
+	b, err := svc.Create(ctx, alice.ID, "Landlord", "de89 3704 0044 0532 0130 00", "Deutsche Bank")
+	require.NoError(t, err)
+	assert.Equal(t, "DE89370400440532013000", b.IBAN)
+	_, err = svc.Create(ctx, alice.ID, "Landlord again", "DE89370400440532013000", "Deutsche Bank")
+	assert.ErrorIs(t, err, domain.ErrBeneficiaryExists)
+
+	// A payout to the beneficiary that hasn't finished yet.
+	now := time.Now().UTC()
+	payout := &domain.Payment{
+		ID: uuid.New(), IdempotencyKey: uuid.NewString(), Type: domain.PaymentTypeExternalPayout,
+		Status: domain.PaymentStatusPending, SourceAccountID: acct.ID,
+		DestIBAN: &b.IBAN, DestBankName: &b.BankName, BeneficiaryID: &b.ID,
+		Source: domain.NewMoney(500, domain.CurrencyEUR), Dest: domain.NewMoney(500, domain.CurrencyEUR),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, payments.Create(ctx, tx, payout))
+	require.NoError(t, tx.Commit(ctx))
+
+	_, err = svc.Delete(ctx, bob.ID, b.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "other users' beneficiaries are invisible")
+
+	deleted, err := svc.Delete(ctx, alice.ID, b.ID)
+	require.NoError(t, err, "soft delete is allowed with payouts in flight")
+	assert.True(t, deleted.Deleted())
+
+	list, err := svc.List(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list, "deleted beneficiaries aren't listed")
+
+	got, err := payments.GetByID(ctx, payout.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.BeneficiaryID)
+	assert.Equal(t, b.ID, *got.BeneficiaryID, "payouts keep pointing at a deleted beneficiary")
+
+	_, err = svc.Purge(ctx, alice.ID, b.ID)
+	assert.ErrorIs(t, err, domain.ErrBeneficiaryInUse)
+
+	replacement, err := svc.Create(ctx, alice.ID, "Landlord", "DE89370400440532013000", "Deutsche Bank")
+	require.NoError(t, err, "a deleted beneficiary's IBAN can be saved again")
+	_, err = svc.Restore(ctx, alice.ID, b.ID)
+	assert.ErrorIs(t, err, domain.ErrBeneficiaryExists)
+
+	_, err = svc.Purge(ctx, alice.ID, replacement.ID)
+	require.NoError(t, err)
+	restored, err := svc.Restore(ctx, alice.ID, b.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.Deleted())
+
+	tx, err = db.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, payments.UpdateStatus(ctx, tx, payout.ID, payout.Status, domain.PaymentStatusCompleted, nil, nil, &now))
+	require.NoError(t, tx.Commit(ctx))
+
+	_, err = svc.Purge(ctx, alice.ID, b.ID)
+	require.NoError(t, err)
+	_, err = svc.Restore(ctx, alice.ID, b.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	got, err = payments.GetByID(ctx, payout.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.BeneficiaryID)
+	assert.Equal(t, "DE89370400440532013000", *got.DestIBAN, "the payout keeps its own copy of the destination")
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+// systemPasswordHash matches no password, so the system user can't log in.
+const systemPasswordHash = "!"
+
+// systemAccountTypes are the accounts the system user holds in every
+// currency.
+var systemAccountTypes = []domain.AccountType{
+	domain.AccountTypeFXPool,
+	domain.AccountTypeOutgoing,
+	domain.AccountTypeSettled,
+	domain.AccountTypeRevenue,
+}
+
+type systemRepo interface {
+	EnsureUser(ctx context.Context, u *domain.User) (bool, error)
+	EnsureAccount(ctx context.Context, a *domain.Account) (bool, error)
+}
+
+// SystemBootstrap makes sure the system user and its accounts exist for
+// every enabled currency. Migrations seed them for the original currencies;
+// this covers currencies enabled later and databases restored without them.
+// Running it again creates nothing and never touches an existing balance.
+type SystemBootstrap struct {
+	repo         systemRepo
+	currencies   []domain.Currency
+	poolBalances map[domain.Currency]int64
+}
+
+// NewSystemBootstrap takes the balance each FX pool is opened with. Pools
+// are only opened with one outside production; pass nil there.
+func NewSystemBootstrap(repo systemRepo, currencies []domain.Currency, poolBalances map[domain.Currency]int64) *SystemBootstrap {
+	return &SystemBootstrap{repo: repo, currencies: currencies, poolBalances: poolBalances}
+}
+
+// Run creates whatever is missing and returns how many rows it created.
+func (b *SystemBootstrap) Run(ctx context.Context) (int, error) {
+	log := logging.FromContext(ctx)
+	now := time.Now().UTC()
+	uniqueName := "system"
+
+	created := 0
+	ok, err := b.repo.EnsureUser(ctx, &domain.User{
+		ID:           payment.SystemUserID,
+		Email:        "system@grey.internal",
+		Name:         "System",
+		PasswordHash: systemPasswordHash,
+		UniqueName:   &uniqueName,
+		Status:       domain.UserStatusActive,
+		Role:         domain.UserRoleUser,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Run: %w", err)
+	}
+	if ok {
+		created++
+		log.Info("bootstrap created system user", "user_id", payment.SystemUserID)
+	}
+
+	for _, currency := range b.currencies {
+		for _, accountType := range systemAccountTypes {
+			var balance int64
+			if accountType == domain.AccountTypeFXPool {
+				balance = b.poolBalances[currency]
+			}
+			acct := &domain.Account{
+				ID:          uuid.New(),
+				UserID:      payment.SystemUserID,
+				Currency:    currency,
+				AccountType: accountType,
+				Balance:     balance,
+				Status:      domain.AccountStatusActive,
+				CreatedAt:   now,
+			}
+			ok, err := b.repo.EnsureAccount(ctx, acct)
+			if err != nil {
+				return created, fmt.Errorf("Run: %s %s account: %w", currency, accountType, err)
+			}
+			if ok {
+				created++
+				log.Info("bootstrap created system account",
+					"account_id", acct.ID, "account_type", accountType, "currency", currency, "balance", balance)
+			}
+		}
+	}
+
+	return created, nil
+}
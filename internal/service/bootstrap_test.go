@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestSystemBootstrap_CreatesOnlyWhatIsMissing(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	accounts := repository.NewAccountRepository(db)
+
+	_, err := db.Exec(context.Background(), `DELETE FROM accounts WHERE id IN ($1, $2)`, testutil.SettledGBPID, testutil.RevenueGBPID)
+	require.NoError(t, err)
+	_, err = db.Exec(context.Background(), `UPDATE accounts SET balance = 777 WHERE id = $1`, testutil.FXPoolGBPID)
+	require.NoError(t, err)
+
+	bootstrap := NewSystemBootstrap(repository.NewSystemRepository(db), domain.SupportedCurrencies,
+		map[domain.Currency]int64{domain.CurrencyGBP: 5000})
+
+	created, err := bootstrap.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created, "only the deleted GBP accounts are recreated")
+
+	settled, err := accounts.GetByUserAndCurrency(ctx, testutil.SystemUserID, domain.CurrencyGBP, domain.AccountTypeSettled)
+	require.NoError(t, err)
+	assert.Zero(t, settled.Balance)
+
+	pool, err := accounts.GetByID(ctx, testutil.FXPoolGBPID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(777), pool.Balance, "an existing pool keeps its balance")
+
+	created, err = bootstrap.Run(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, created, "a second run creates nothing")
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type corridorStatsRepo interface {
+	Refresh(ctx context.Context, from, to, now time.Time) (int64, error)
+	LastRefreshed(ctx context.Context) (*time.Time, error)
+	Stats(ctx context.Context, from, to time.Time, g domain.CorridorGranularity) ([]domain.CorridorStats, error)
+}
+
+// CorridorReport is corridor activity for a date range. AsOf is when the
+// rollup behind it was last refreshed.
+type CorridorReport struct {
+	AsOf      *time.Time
+	Corridors []domain.CorridorStats
+}
+
+// CorridorAnalyticsService maintains the daily corridor rollup and reports
+// from it. Every interval it recomputes the last lookback days, which picks
+// up payments that finished since the previous run. A payment that finishes
+// more than lookback days after it was created isn't reflected until a
+// rebuild. An empty rollup is rebuilt from the first payment.
+type CorridorAnalyticsService struct {
+	stats    corridorStatsRepo
+	logger   *slog.Logger
+	interval time.Duration
+	lookback int
+}
+
+func NewCorridorAnalyticsService(stats corridorStatsRepo, logger *slog.Logger, interval time.Duration, lookbackDays int) *CorridorAnalyticsService {
+	return &CorridorAnalyticsService{
+		stats:    stats,
+		logger:   logger,
+		interval: interval,
+		lookback: lookbackDays,
+	}
+}
+
+func (s *CorridorAnalyticsService) Start(ctx context.Context) {
+	s.logger.Info("corridor projector started", "interval", s.interval, "lookback_days", s.lookback)
+
+	s.refresh(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("corridor projector stopped")
+			return
+		case <-ticker.C:
+			s.refresh(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (s *CorridorAnalyticsService) refresh(ctx context.Context, now time.Time) {
+	last, err := s.stats.LastRefreshed(ctx)
+	if err != nil {
+		s.logger.Error("failed to read corridor rollup state", "error", err)
+		return
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, -s.lookback)
+	if last == nil {
+		from = time.Time{}
+	}
+
+	n, err := s.stats.Refresh(ctx, from, today.AddDate(0, 0, 1), now)
+	if err != nil {
+		s.logger.Error("failed to refresh corridor rollup", "error", err)
+		return
+	}
+	s.logger.Info("corridor rollup refreshed", "from", from.Format(time.DateOnly), "rows", n)
+}
+
+// Report returns corridor stats for the UTC days from..to inclusive.
+func (s *CorridorAnalyticsService) Report(ctx context.Context, from, to time.Time, g domain.CorridorGranularity) (*CorridorReport, error) {
+	if !g.IsValid() {
+		return nil, fmt.Errorf("Report: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "granularity", "value", g))
+	}
+	asOf, err := s.stats.LastRefreshed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Report: %w", err)
+	}
+	stats, err := s.stats.Stats(ctx, from, to.AddDate(0, 0, 1), g)
+	if err != nil {
+		return nil, fmt.Errorf("Report: %w", err)
+	}
+	return &CorridorReport{AsOf: asOf, Corridors: stats}, nil
+}
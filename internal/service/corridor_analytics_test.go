@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type refreshCall struct {
+	from, to time.Time
+}
+
+type stubCorridorStats struct {
+	last      *time.Time
+	refreshes []refreshCall
+	stats     []domain.CorridorStats
+	statsTo   time.Time
+}
+
+func (s *stubCorridorStats) Refresh(_ context.Context, from, to, now time.Time) (int64, error) {
+	s.refreshes = append(s.refreshes, refreshCall{from, to})
+	s.last = &now
+	return 1, nil
+}
+
+func (s *stubCorridorStats) LastRefreshed(context.Context) (*time.Time, error) {
+	return s.last, nil
+}
+
+func (s *stubCorridorStats) Stats(_ context.Context, _, to time.Time, _ domain.CorridorGranularity) ([]domain.CorridorStats, error) {
+	s.statsTo = to
+	return s.stats, nil
+}
+
+func TestCorridorAnalytics_RefreshWindow(t *testing.T) {
+	repo := &stubCorridorStats{}
+	svc := NewCorridorAnalyticsService(repo, slog.Default(), time.Minute, 7)
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	svc.refresh(context.Background(), now)
+	svc.refresh(context.Background(), now.Add(time.Hour))
+
+	require.Len(t, repo.refreshes, 2)
+	assert.True(t, repo.refreshes[0].from.IsZero(), "an empty rollup is rebuilt in full")
+	assert.Equal(t, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), repo.refreshes[0].to)
+	assert.Equal(t, time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC), repo.refreshes[1].from)
+}
+
+func TestCorridorAnalytics_Report(t *testing.T) {
+	repo := &stubCorridorStats{stats: []domain.CorridorStats{{
+		SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyEUR,
+		Payments: 10, Succeeded: 6, Failed: 2, Volume: 60000, Fees: 300,
+		Timed: 4, CompletionSeconds: 480,
+	}}}
+	svc := NewCorridorAnalyticsService(repo, slog.Default(), time.Minute, 7)
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.Report(context.Background(), day, day, "hourly")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	report, err := svc.Report(context.Background(), day, day, domain.CorridorGranularityTotal)
+	require.NoError(t, err)
+	assert.Nil(t, report.AsOf)
+	assert.Equal(t, day.AddDate(0, 0, 1), repo.statsTo, "to is inclusive")
+
+	s := report.Corridors[0]
+	assert.InDelta(t, 50, s.AvgFee(), 0.001)
+	assert.InDelta(t, 0.25, s.FailureRate(), 0.001)
+	assert.InDelta(t, 120, s.AvgCompletionSeconds(), 0.001)
+	assert.Zero(t, domain.CorridorStats{}.FailureRate())
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+const maxDenylistReasonLength = 500
+
+type denylistRepo interface {
+	Create(ctx context.Context, e *domain.DenylistEntry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.DenylistEntry, error)
+	List(ctx context.Context, field domain.DenylistField, limit, offset int) ([]domain.DenylistEntry, error)
+	UpdateReason(ctx context.Context, id uuid.UUID, reason string, now time.Time) (*domain.DenylistEntry, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Match(ctx context.Context, iban, bankName string) (*domain.DenylistEntry, error)
+}
+
+// DenylistService manages the payout destination denylist and screens
+// external payouts against it.
+type DenylistService struct {
+	entries denylistRepo
+}
+
+func NewDenylistService(entries denylistRepo) *DenylistService {
+	return &DenylistService{entries: entries}
+}
+
+type AddDenylistEntryRequest struct {
+	Field   domain.DenylistField
+	Value   string
+	Reason  string
+	ActorID uuid.UUID
+}
+
+func (s *DenylistService) Add(ctx context.Context, req AddDenylistEntryRequest) (*domain.DenylistEntry, error) {
+	log := logging.FromContext(ctx)
+
+	if !req.Field.IsValid() {
+		return nil, fmt.Errorf("Add: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "field", "value", req.Field))
+	}
+	value := req.Field.Normalize(req.Value)
+	if value == "" {
+		return nil, fmt.Errorf("Add: value is required: %w", domain.ErrInvalidRequest)
+	}
+	reason, err := checkDenylistReason(req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("Add: %w", err)
+	}
+
+	now := time.Now().UTC()
+	entry := &domain.DenylistEntry{
+		ID:        uuid.New(),
+		Field:     req.Field,
+		Value:     value,
+		Reason:    reason,
+		CreatedBy: req.ActorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.entries.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("Add: %w", err)
+	}
+
+	log.Info("denylist entry added", "entry_id", entry.ID, "field", entry.Field, "actor_id", req.ActorID)
+	return entry, nil
+}
+
+func (s *DenylistService) Get(ctx context.Context, id uuid.UUID) (*domain.DenylistEntry, error) {
+	e, err := s.entries.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return e, nil
+}
+
+func (s *DenylistService) List(ctx context.Context, field domain.DenylistField, limit, offset int) ([]domain.DenylistEntry, error) {
+	if field != "" && !field.IsValid() {
+		return nil, fmt.Errorf("List: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "field", "value", field))
+	}
+	entries, err := s.entries.List(ctx, field, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *DenylistService) UpdateReason(ctx context.Context, id uuid.UUID, reason string, actorID uuid.UUID) (*domain.DenylistEntry, error) {
+	reason, err := checkDenylistReason(reason)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+
+	e, err := s.entries.UpdateReason(ctx, id, reason, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("denylist entry updated", "entry_id", e.ID, "actor_id", actorID)
+	return e, nil
+}
+
+// Remove deletes the entry and returns it as it was. Payouts it already
+// sent to review stay in the queue.
+func (s *DenylistService) Remove(ctx context.Context, id uuid.UUID, actorID uuid.UUID) (*domain.DenylistEntry, error) {
+	e, err := s.entries.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Remove: %w", err)
+	}
+	if err := s.entries.Delete(ctx, id); err != nil {
+		return nil, fmt.Errorf("Remove: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("denylist entry removed", "entry_id", id, "field", e.Field, "actor_id", actorID)
+	return e, nil
+}
+
+// Screen implements payment.Screener.
+func (s *DenylistService) Screen(ctx context.Context, req payment.ScreeningRequest) (*payment.ScreeningMatch, error) {
+	e, err := s.entries.Match(ctx,
+		domain.DenylistFieldIBAN.Normalize(req.DestIBAN),
+		domain.DenylistFieldBankName.Normalize(req.DestBankName),
+	)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Screen: %w", err)
+	}
+
+	return &payment.ScreeningMatch{
+		RuleID: "denylist:" + e.ID.String(),
+		Reason: fmt.Sprintf("destination %s is denylisted", strings.ReplaceAll(string(e.Field), "_", " ")),
+	}, nil
+}
+
+func checkDenylistReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxDenylistReasonLength {
+		return "", fmt.Errorf("reason: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "max_length", maxDenylistReasonLength))
+	}
+	return reason, nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+const (
+	digestBatchSize        = 100
+	notificationKindDigest = templates.PaymentsDigest
+)
+
+var digestPeriods = []domain.DigestPeriod{domain.DigestPeriodWeekly, domain.DigestPeriodMonthly}
+
+type digestRepo interface {
+	GetPreference(ctx context.Context, userID uuid.UUID) (*domain.DigestPreference, error)
+	UpsertPreference(ctx context.Context, p *domain.DigestPreference) error
+	ListDue(ctx context.Context, period domain.DigestPeriod, start time.Time, limit int) ([]uuid.UUID, error)
+	Summarize(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]domain.DigestTotals, error)
+	Create(ctx context.Context, d *domain.Digest) (bool, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Digest, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, period domain.DigestPeriod, limit, offset int) ([]domain.Digest, error)
+	ListUndelivered(ctx context.Context, limit int) ([]domain.Digest, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// DigestService builds weekly and monthly payment summaries for users who
+// opted in and hands them to the notifier. Generation is keyed on user,
+// period and period start, so reruns never produce duplicates, and digests
+// whose delivery failed are retried on the next run.
+type DigestService struct {
+	digests  digestRepo
+	notifier Notifier
+	logger   *slog.Logger
+	interval time.Duration
+}
+
+func NewDigestService(digests digestRepo, notifier Notifier, logger *slog.Logger, interval time.Duration) *DigestService {
+	return &DigestService{digests: digests, notifier: notifier, logger: logger, interval: interval}
+}
+
+func (s *DigestService) Start(ctx context.Context) {
+	s.logger.Info("digest scheduler started", "interval", s.interval)
+
+	s.run(ctx, time.Now())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("digest scheduler stopped")
+			return
+		case <-ticker.C:
+			s.run(ctx, time.Now())
+		}
+	}
+}
+
+func (s *DigestService) run(ctx context.Context, now time.Time) {
+	for _, period := range digestPeriods {
+		s.generateDue(ctx, period, now)
+	}
+	s.deliverPending(ctx)
+}
+
+func (s *DigestService) generateDue(ctx context.Context, period domain.DigestPeriod, now time.Time) {
+	start, end := period.LastComplete(now)
+	for ctx.Err() == nil {
+		due, err := s.digests.ListDue(ctx, period, start, digestBatchSize)
+		if err != nil {
+			s.logger.Error("failed to list users due a digest", "period", period, "error", err)
+			return
+		}
+
+		generated := 0
+		for _, userID := range due {
+			if _, err := s.Generate(ctx, userID, period, start, end); err != nil {
+				s.logger.Error("failed to generate digest", "user_id", userID, "period", period, "error", err)
+				continue
+			}
+			generated++
+		}
+		// A batch that made no progress would come back unchanged.
+		if len(due) < digestBatchSize || generated == 0 {
+			return
+		}
+	}
+}
+
+// Generate builds and stores the user's digest for one period. It returns
+// false if the digest already existed.
+func (s *DigestService) Generate(ctx context.Context, userID uuid.UUID, period domain.DigestPeriod, start, end time.Time) (bool, error) {
+	totals, err := s.digests.Summarize(ctx, userID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("Generate: %w", err)
+	}
+
+	d := &domain.Digest{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Totals:      totals,
+		CreatedAt:   time.Now().UTC(),
+	}
+	created, err := s.digests.Create(ctx, d)
+	if err != nil {
+		return false, fmt.Errorf("Generate: %w", err)
+	}
+	if created {
+		s.logger.Info("digest generated", "digest_id", d.ID, "user_id", userID, "period", period, "period_start", start)
+	}
+	return created, nil
+}
+
+func (s *DigestService) deliverPending(ctx context.Context) {
+	pending, err := s.digests.ListUndelivered(ctx, digestBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list undelivered digests", "error", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		d := &pending[i]
+		n, err := digestNotification(d)
+		if err != nil {
+			s.logger.Error("failed to render digest", "digest_id", d.ID, "error", err)
+			continue
+		}
+		if err := s.notifier.Notify(ctx, n); err != nil {
+			s.logger.Warn("failed to deliver digest", "digest_id", d.ID, "user_id", d.UserID, "error", err)
+			continue
+		}
+		if err := s.digests.MarkDelivered(ctx, d.ID, time.Now().UTC()); err != nil {
+			s.logger.Error("failed to mark digest delivered", "digest_id", d.ID, "error", err)
+		}
+	}
+}
+
+func digestNotification(d *domain.Digest) (Notification, error) {
+	return renderNotification(d.UserID, notificationKindDigest, templates.DigestData{
+		Period: d.Period,
+		Start:  d.PeriodStart,
+		Last:   d.PeriodEnd.AddDate(0, 0, -1),
+		Totals: d.Totals,
+	})
+}
+
+func (s *DigestService) GetPreference(ctx context.Context, userID uuid.UUID) (*domain.DigestPreference, error) {
+	p, err := s.digests.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPreference: %w", err)
+	}
+	return p, nil
+}
+
+func (s *DigestService) UpdatePreference(ctx context.Context, userID uuid.UUID, weekly, monthly bool) (*domain.DigestPreference, error) {
+	p := &domain.DigestPreference{
+		UserID:    userID,
+		Weekly:    weekly,
+		Monthly:   monthly,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := s.digests.UpsertPreference(ctx, p); err != nil {
+		return nil, fmt.Errorf("UpdatePreference: %w", err)
+	}
+	return p, nil
+}
+
+func (s *DigestService) List(ctx context.Context, userID uuid.UUID, period domain.DigestPeriod, limit, offset int) ([]domain.Digest, error) {
+	if period != "" && !period.IsValid() {
+		return nil, fmt.Errorf("List: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "period", "value", period))
+	}
+	digests, err := s.digests.ListByUser(ctx, userID, period, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return digests, nil
+}
+
+// GetForUser returns one of the user's digests. Other users' digests look
+// missing.
+func (s *DigestService) GetForUser(ctx context.Context, userID, digestID uuid.UUID) (*domain.Digest, error) {
+	d, err := s.digests.GetByID(ctx, digestID)
+	if err != nil {
+		return nil, fmt.Errorf("GetForUser: %w", err)
+	}
+	if d.UserID != userID {
+		return nil, fmt.Errorf("GetForUser: %w", domain.ErrNotFound)
+	}
+	return d, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type digestKey struct {
+	userID uuid.UUID
+	period domain.DigestPeriod
+	start  time.Time
+}
+
+type stubDigestRepo struct {
+	digestRepo
+	optedIn   map[uuid.UUID]domain.DigestPreference
+	digests   map[digestKey]*domain.Digest
+	delivered map[uuid.UUID]bool
+}
+
+func (s *stubDigestRepo) ListDue(_ context.Context, period domain.DigestPeriod, start time.Time, _ int) ([]uuid.UUID, error) {
+	var due []uuid.UUID
+	for id, p := range s.optedIn {
+		if _, ok := s.digests[digestKey{id, period, start}]; p.Wants(period) && !ok {
+			due = append(due, id)
+		}
+	}
+	return due, nil
+}
+
+func (s *stubDigestRepo) Summarize(context.Context, uuid.UUID, time.Time, time.Time) ([]domain.DigestTotals, error) {
+	return []domain.DigestTotals{{Currency: domain.CurrencyUSD, SentCount: 2, Sent: 5000, Fees: 25}}, nil
+}
+
+func (s *stubDigestRepo) Create(_ context.Context, d *domain.Digest) (bool, error) {
+	key := digestKey{d.UserID, d.Period, d.PeriodStart}
+	if _, ok := s.digests[key]; ok {
+		return false, nil
+	}
+	s.digests[key] = d
+	return true, nil
+}
+
+func (s *stubDigestRepo) ListUndelivered(context.Context, int) ([]domain.Digest, error) {
+	var out []domain.Digest
+	for _, d := range s.digests {
+		if !s.delivered[d.ID] {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubDigestRepo) MarkDelivered(_ context.Context, id uuid.UUID, _ time.Time) error {
+	s.delivered[id] = true
+	return nil
+}
+
+type stubNotifier struct {
+	sent []Notification
+	fail bool
+}
+
+func (n *stubNotifier) Notify(_ context.Context, msg Notification) error {
+	if n.fail {
+		return errors.New("smtp down")
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestDigestPeriod_LastComplete(t *testing.T) {
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC) // Wednesday
+
+	start, end := domain.DigestPeriodWeekly.LastComplete(now)
+	assert.Equal(t, time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), end)
+
+	start, end = domain.DigestPeriodMonthly.LastComplete(now)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestDigestService_GeneratesOnceAndRetriesDelivery(t *testing.T) {
+	weekly, both, none := uuid.New(), uuid.New(), uuid.New()
+	repo := &stubDigestRepo{
+		optedIn: map[uuid.UUID]domain.DigestPreference{
+			weekly: {UserID: weekly, Weekly: true},
+			both:   {UserID: both, Weekly: true, Monthly: true},
+			none:   {UserID: none},
+		},
+		digests:   map[digestKey]*domain.Digest{},
+		delivered: map[uuid.UUID]bool{},
+	}
+	notifier := &stubNotifier{fail: true}
+	svc := NewDigestService(repo, notifier, slog.Default(), time.Hour)
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	svc.run(context.Background(), now)
+	assert.Len(t, repo.digests, 3)
+	assert.Empty(t, repo.delivered, "failed deliveries must stay pending")
+
+	notifier.fail = false
+	svc.run(context.Background(), now.Add(time.Hour))
+	assert.Len(t, repo.digests, 3, "a rerun in the same period must not regenerate")
+	require.Len(t, notifier.sent, 3)
+	assert.Len(t, repo.delivered, 3)
+	for _, n := range notifier.sent {
+		assert.Equal(t, "payments_digest", n.Kind)
+		assert.Equal(t, "payments_digest", n.Template.Name)
+		assert.NotEmpty(t, n.Template.Version)
+		assert.NotEqual(t, none, n.UserID)
+		assert.Contains(t, n.Body, "USD: sent 5000 in 2 payments")
+	}
+
+	svc.run(context.Background(), now.Add(2*time.Hour))
+	assert.Len(t, notifier.sent, 3)
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+const maxDisputeDescriptionLength = 2000
+
+type disputeRepo interface {
+	Create(ctx context.Context, d *domain.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispute, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.Dispute, error)
+	List(ctx context.Context, status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error)
+	MarkInReview(ctx context.Context, id uuid.UUID, now time.Time) (*domain.Dispute, error)
+	Resolve(ctx context.Context, id uuid.UUID, status domain.DisputeStatus, resolvedBy uuid.UUID, note string, now time.Time) (*domain.Dispute, error)
+}
+
+type disputePaymentRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+}
+
+type disputeAccountRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Account, error)
+}
+
+type disputeLedgerRepo interface {
+	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error)
+}
+
+type disputeNoteRepo interface {
+	Create(ctx context.Context, note *domain.SupportNote) error
+}
+
+type DisputeService struct {
+	disputes   disputeRepo
+	payments   disputePaymentRepo
+	accounts   disputeAccountRepo
+	ledger     disputeLedgerRepo
+	notes      disputeNoteRepo
+	respondSLA time.Duration
+	resolveSLA time.Duration
+}
+
+func NewDisputeService(
+	disputes disputeRepo,
+	payments disputePaymentRepo,
+	accounts disputeAccountRepo,
+	ledger disputeLedgerRepo,
+	notes disputeNoteRepo,
+	respondSLA time.Duration,
+	resolveSLA time.Duration,
+) *DisputeService {
+	return &DisputeService{
+		disputes:   disputes,
+		payments:   payments,
+		accounts:   accounts,
+		ledger:     ledger,
+		notes:      notes,
+		respondSLA: respondSLA,
+		resolveSLA: resolveSLA,
+	}
+}
+
+type OpenDisputeRequest struct {
+	UserID        uuid.UUID
+	PaymentID     uuid.UUID
+	LedgerEntryID *uuid.UUID
+	Reason        domain.DisputeReason
+	Description   string
+}
+
+// OpenDispute records a user's challenge against one of their payments, or a
+// single ledger entry of it. Payments the user is not party to are reported as
+// not found so dispute creation cannot be used to probe payment IDs.
+func (s *DisputeService) OpenDispute(ctx context.Context, req OpenDisputeRequest) (*domain.Dispute, error) {
+	log := logging.FromContext(ctx)
+
+	if !req.Reason.IsValid() {
+		return nil, fmt.Errorf("OpenDispute: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "value", req.Reason))
+	}
+	description := strings.TrimSpace(req.Description)
+	if len(description) > maxDisputeDescriptionLength {
+		return nil, fmt.Errorf("OpenDispute: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "description", "max_length", maxDisputeDescriptionLength))
+	}
+
+	pmt, err := s.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("OpenDispute: %w", err)
+	}
+
+	owned, err := s.ownedAccounts(ctx, req.UserID, pmt)
+	if err != nil {
+		return nil, fmt.Errorf("OpenDispute: %w", err)
+	}
+	if len(owned) == 0 {
+		return nil, fmt.Errorf("OpenDispute: user is not party to payment: %w", domain.ErrNotFound)
+	}
+
+	if req.LedgerEntryID != nil {
+		if err := s.checkEntry(ctx, pmt.ID, *req.LedgerEntryID, owned); err != nil {
+			return nil, fmt.Errorf("OpenDispute: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	dispute := &domain.Dispute{
+		ID:            uuid.New(),
+		UserID:        req.UserID,
+		PaymentID:     pmt.ID,
+		LedgerEntryID: req.LedgerEntryID,
+		Reason:        req.Reason,
+		Description:   description,
+		Status:        domain.DisputeStatusOpen,
+		RespondBy:     now.Add(s.respondSLA),
+		ResolveBy:     now.Add(s.resolveSLA),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := s.disputes.Create(ctx, dispute); err != nil {
+		return nil, fmt.Errorf("OpenDispute: %w", err)
+	}
+
+	log.Info("dispute opened",
+		"dispute_id", dispute.ID,
+		"payment_id", dispute.PaymentID,
+		"user_id", dispute.UserID,
+		"reason", dispute.Reason,
+		"respond_by", dispute.RespondBy,
+	)
+
+	body := fmt.Sprintf("Dispute %s opened by customer (reason: %s). First response due by %s.",
+		dispute.ID, dispute.Reason, dispute.RespondBy.Format(time.RFC3339))
+	if err := addSystemNote(ctx, s.notes, pmt.ID, body); err != nil {
+		log.Error("failed to notify staff of dispute", "dispute_id", dispute.ID, "error", err)
+	}
+
+	return dispute, nil
+}
+
+// GetForUser returns a dispute only to the user who raised it.
+func (s *DisputeService) GetForUser(ctx context.Context, userID, disputeID uuid.UUID) (*domain.Dispute, error) {
+	d, err := s.disputes.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("GetForUser: %w", err)
+	}
+	if d.UserID != userID {
+		return nil, fmt.Errorf("GetForUser: %w", domain.ErrNotFound)
+	}
+	return d, nil
+}
+
+func (s *DisputeService) ListForUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.Dispute, error) {
+	disputes, err := s.disputes.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ListForUser: %w", err)
+	}
+	return disputes, nil
+}
+
+func (s *DisputeService) Get(ctx context.Context, disputeID uuid.UUID) (*domain.Dispute, error) {
+	d, err := s.disputes.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return d, nil
+}
+
+func (s *DisputeService) List(ctx context.Context, status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error) {
+	disputes, err := s.disputes.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return disputes, nil
+}
+
+// Respond moves a dispute into review and stops the first-response SLA clock.
+func (s *DisputeService) Respond(ctx context.Context, disputeID, staffID uuid.UUID) (*domain.Dispute, error) {
+	log := logging.FromContext(ctx)
+
+	d, err := s.disputes.MarkInReview(ctx, disputeID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("Respond: %w", err)
+	}
+
+	log.Info("dispute in review",
+		"dispute_id", d.ID,
+		"staff_id", staffID,
+		"response_overdue", d.ResponseOverdue(time.Now().UTC()),
+	)
+	return d, nil
+}
+
+func (s *DisputeService) Resolve(ctx context.Context, disputeID, staffID uuid.UUID, outcome domain.DisputeStatus, note string) (*domain.Dispute, error) {
+	log := logging.FromContext(ctx)
+
+	if outcome != domain.DisputeStatusResolved && outcome != domain.DisputeStatusRejected {
+		return nil, fmt.Errorf("Resolve: outcome must be resolved or rejected: %w", domain.ErrInvalidRequest)
+	}
+	note = strings.TrimSpace(note)
+	if note == "" || len(note) > maxDisputeDescriptionLength {
+		return nil, fmt.Errorf("Resolve: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "note", "max_length", maxDisputeDescriptionLength))
+	}
+
+	d, err := s.disputes.Resolve(ctx, disputeID, outcome, staffID, note, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("Resolve: %w", err)
+	}
+
+	log.Info("dispute closed",
+		"dispute_id", d.ID,
+		"staff_id", staffID,
+		"outcome", d.Status,
+		"resolution_overdue", d.ResolutionOverdue(time.Now().UTC()),
+	)
+	return d, nil
+}
+
+func (s *DisputeService) ownedAccounts(ctx context.Context, userID uuid.UUID, pmt *domain.Payment) (map[uuid.UUID]bool, error) {
+	owned := make(map[uuid.UUID]bool)
+	ids := []uuid.UUID{pmt.SourceAccountID}
+	if pmt.DestAccountID != nil {
+		ids = append(ids, *pmt.DestAccountID)
+	}
+
+	for _, id := range ids {
+		acct, err := s.accounts.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("ownedAccounts: %w", err)
+		}
+		if acct.UserID == userID {
+			owned[acct.ID] = true
+		}
+	}
+	return owned, nil
+}
+
+func (s *DisputeService) checkEntry(ctx context.Context, paymentID, entryID uuid.UUID, owned map[uuid.UUID]bool) error {
+	entries, err := s.ledger.GetByPaymentID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("checkEntry: %w", err)
+	}
+	for _, e := range entries {
+		if e.ID == entryID && owned[e.AccountID] {
+			return nil
+		}
+	}
+	return fmt.Errorf("checkEntry: not on user's side of payment: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "ledger_entry_id", "value", entryID))
+}
+
+// addSystemNote leaves a flagged support note on a payment so disputes
+// surface in the staff tooling that already tracks flagged payments.
+func addSystemNote(ctx context.Context, notes disputeNoteRepo, paymentID uuid.UUID, body string) error {
+	return notes.Create(ctx, &domain.SupportNote{
+		ID:          uuid.New(),
+		SubjectType: domain.NoteSubjectPayment,
+		SubjectID:   paymentID,
+		AuthorID:    payment.SystemUserID,
+		Body:        body,
+		Flagged:     true,
+		CreatedAt:   time.Now().UTC(),
+	})
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type overdueDisputeRepo interface {
+	ListOverdueUnescalated(ctx context.Context, now time.Time, limit int) ([]domain.Dispute, error)
+	MarkEscalated(ctx context.Context, id uuid.UUID, now time.Time) error
+}
+
+// DisputeSLAMonitor escalates open disputes that have passed their
+// first-response deadline without a staff response.
+type DisputeSLAMonitor struct {
+	disputes  overdueDisputeRepo
+	notes     disputeNoteRepo
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+func NewDisputeSLAMonitor(disputes overdueDisputeRepo, notes disputeNoteRepo, logger *slog.Logger, interval time.Duration) *DisputeSLAMonitor {
+	return &DisputeSLAMonitor{
+		disputes:  disputes,
+		notes:     notes,
+		logger:    logger,
+		interval:  interval,
+		batchSize: 50,
+	}
+}
+
+func (m *DisputeSLAMonitor) Start(ctx context.Context) {
+	m.logger.Info("dispute SLA monitor started", "interval", m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("dispute SLA monitor stopped")
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *DisputeSLAMonitor) check(ctx context.Context) {
+	now := time.Now().UTC()
+	overdue, err := m.disputes.ListOverdueUnescalated(ctx, now, m.batchSize)
+	if err != nil {
+		m.logger.Error("failed to list overdue disputes", "error", err)
+		return
+	}
+
+	for i := range overdue {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := m.escalate(ctx, &overdue[i], now); err != nil {
+			m.logger.Error("failed to escalate dispute", "dispute_id", overdue[i].ID, "error", err)
+		}
+	}
+}
+
+func (m *DisputeSLAMonitor) escalate(ctx context.Context, d *domain.Dispute, now time.Time) error {
+	if err := m.disputes.MarkEscalated(ctx, d.ID, now); err != nil {
+		return fmt.Errorf("escalate: %w", err)
+	}
+
+	m.logger.Warn("dispute response SLA breached",
+		"dispute_id", d.ID,
+		"payment_id", d.PaymentID,
+		"respond_by", d.RespondBy,
+		"overdue_s", int(now.Sub(d.RespondBy).Seconds()),
+	)
+
+	body := fmt.Sprintf("Dispute %s breached its first-response SLA (due %s).", d.ID, d.RespondBy.Format(time.RFC3339))
+	if err := addSystemNote(ctx, m.notes, d.PaymentID, body); err != nil {
+		return fmt.Errorf("escalate: %w", err)
+	}
+	return nil
+}
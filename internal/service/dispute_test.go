@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func setupDisputeTest(t *testing.T, db *pgxpool.Pool, respondSLA time.Duration) *DisputeService {
+	t.Helper()
+	return NewDisputeService(
+		repository.NewDisputeRepository(db),
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewSupportNoteRepository(db),
+		respondSLA,
+		10*24*time.Hour,
+	)
+}
+
+func TestDispute_Lifecycle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	disputes := setupDisputeTest(t, db, 48*time.Hour)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_dp")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	other := testutil.SeedTestUser(t, db, "other@test.com", "Other", "other_dp")
+	staff := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_dp")
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         2500,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	entries, err := repository.NewLedgerRepository(db).GetByPaymentID(ctx, p.ID)
+	require.NoError(t, err)
+	var senderEntry, systemEntry uuid.UUID
+	for _, e := range entries {
+		if e.AccountID == senderAcct.ID {
+			senderEntry = e.ID
+		} else {
+			systemEntry = e.ID
+		}
+	}
+
+	_, err = disputes.OpenDispute(ctx, OpenDisputeRequest{
+		UserID: other.ID, PaymentID: p.ID, Reason: domain.DisputeReasonUnrecognized,
+	})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = disputes.OpenDispute(ctx, OpenDisputeRequest{
+		UserID: sender.ID, PaymentID: p.ID, LedgerEntryID: &systemEntry, Reason: domain.DisputeReasonUnrecognized,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	d, err := disputes.OpenDispute(ctx, OpenDisputeRequest{
+		UserID: sender.ID, PaymentID: p.ID, LedgerEntryID: &senderEntry,
+		Reason: domain.DisputeReasonUnrecognized, Description: "I did not make this payout",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusOpen, d.Status)
+	assert.False(t, d.ResponseOverdue(time.Now().UTC()))
+
+	_, err = disputes.OpenDispute(ctx, OpenDisputeRequest{
+		UserID: sender.ID, PaymentID: p.ID, Reason: domain.DisputeReasonDuplicate,
+	})
+	assert.ErrorIs(t, err, domain.ErrDisputeExists)
+
+	notes, err := repository.NewSupportNoteRepository(db).ListBySubject(ctx, domain.NoteSubjectPayment, p.ID)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.True(t, notes[0].Flagged)
+
+	_, err = disputes.GetForUser(ctx, other.ID, d.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	reviewed, err := disputes.Respond(ctx, d.ID, staff.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusInReview, reviewed.Status)
+	require.NotNil(t, reviewed.FirstResponseAt)
+
+	resolved, err := disputes.Resolve(ctx, d.ID, staff.ID, domain.DisputeStatusRejected, "Payout confirmed with customer's device")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusRejected, resolved.Status)
+	require.NotNil(t, resolved.ResolvedBy)
+	assert.Equal(t, staff.ID, *resolved.ResolvedBy)
+
+	_, err = disputes.Resolve(ctx, d.ID, staff.ID, domain.DisputeStatusResolved, "again")
+	assert.ErrorIs(t, err, domain.ErrDisputeClosed)
+
+	_, err = db.Exec(ctx, `DELETE FROM ledger_entries WHERE id = $1`, senderEntry)
+	assert.Error(t, err, "disputed ledger entries must not be deletable")
+}
+
+func TestDisputeSLAMonitor_EscalatesOverdue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	disputes := setupDisputeTest(t, db, -time.Minute)
+	disputeRepo := repository.NewDisputeRepository(db)
+	noteRepo := repository.NewSupportNoteRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_sla")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         1000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	d, err := disputes.OpenDispute(ctx, OpenDisputeRequest{
+		UserID: sender.ID, PaymentID: p.ID, Reason: domain.DisputeReasonIncorrectAmount,
+	})
+	require.NoError(t, err)
+
+	monitor := NewDisputeSLAMonitor(disputeRepo, noteRepo, slog.Default(), time.Minute)
+	monitor.check(ctx)
+	monitor.check(ctx)
+
+	escalated, err := disputeRepo.GetByID(ctx, d.ID)
+	require.NoError(t, err)
+	require.NotNil(t, escalated.EscalatedAt)
+	assert.True(t, escalated.ResponseOverdue(time.Now().UTC()))
+
+	notes, err := noteRepo.ListBySubject(ctx, domain.NoteSubjectPayment, p.ID)
+	require.NoError(t, err)
+	assert.Len(t, notes, 2, "one note on open, one on escalation")
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/josh-kwaku/grey-backend-assessment/internal/notify/email"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+// Email is a message to an address that may not belong to a known user yet,
+// or whose delivery must not depend on notification preferences, such as a
+// password reset.
+type Email = email.Message
+
+// EmailSender delivers email.
+type EmailSender = email.Sender
+
+func renderEmail(to, name string, data any) (Email, error) {
+	return email.Render(to, name, templates.DefaultLocale, data)
+}
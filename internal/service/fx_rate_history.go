@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+)
+
+// defaultFXHistoryWindow is how far back rate history goes when the caller
+// gives no start.
+const defaultFXHistoryWindow = 7 * 24 * time.Hour
+
+type rateQuoter interface {
+	Quotes(ctx context.Context, currencies []domain.Currency) []fx.Quote
+}
+
+type fxRateHistoryRepo interface {
+	Record(ctx context.Context, rates []domain.FXRateRecord) error
+	Latest(ctx context.Context) ([]domain.FXRateRecord, error)
+	History(ctx context.Context, from, to domain.Currency, tier domain.UserTier, since, until time.Time, limit int) ([]domain.FXRateRecord, bool, error)
+}
+
+// fxRateTiers are the tiers rates are recorded for, in the order they are
+// written.
+var fxRateTiers = []domain.UserTier{domain.UserTierStandard, domain.UserTierPlus, domain.UserTierBusiness}
+
+// FXRateHistory is a pair's recorded rates over a time range.
+type FXRateHistory struct {
+	Since   time.Time
+	Until   time.Time
+	Rates   []domain.FXRateRecord
+	HasMore bool
+}
+
+// FXRateHistoryService checks the rate of every pair of enabled currencies
+// on each rate refresh, at startup and then every interval, and records
+// each pair and tier whose rate or spread changed since it was last
+// recorded. It reads them back for a pair and tier.
+type FXRateHistoryService struct {
+	rates      rateQuoter
+	repo       fxRateHistoryRepo
+	currencies []domain.Currency
+	spreads    map[domain.UserTier]decimal.Decimal
+	logger     *slog.Logger
+	interval   time.Duration
+
+	// last is the latest record of each pair and tier, loaded from the
+	// repository on the first refresh.
+	last map[fxRateKey]domain.FXRateRecord
+}
+
+type fxRateKey struct {
+	from, to domain.Currency
+	tier     domain.UserTier
+}
+
+// NewFXRateHistoryService records each tier at its spread in spreads, the
+// same spreads payments by that tier are converted at.
+func NewFXRateHistoryService(rates rateQuoter, repo fxRateHistoryRepo, currencies []domain.Currency, spreads map[domain.UserTier]decimal.Decimal, logger *slog.Logger, interval time.Duration) *FXRateHistoryService {
+	return &FXRateHistoryService{
+		rates:      rates,
+		repo:       repo,
+		currencies: currencies,
+		spreads:    spreads,
+		logger:     logger,
+		interval:   interval,
+	}
+}
+
+func (s *FXRateHistoryService) Start(ctx context.Context) {
+	s.logger.Info("fx rate recorder started", "interval", s.interval)
+
+	s.refresh(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("fx rate recorder stopped")
+			return
+		case <-ticker.C:
+			s.refresh(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (s *FXRateHistoryService) refresh(ctx context.Context, now time.Time) {
+	if s.last == nil {
+		latest, err := s.repo.Latest(ctx)
+		if err != nil {
+			s.logger.Error("failed to load recorded fx rates", "error", err)
+			return
+		}
+		s.last = make(map[fxRateKey]domain.FXRateRecord, len(latest))
+		for _, r := range latest {
+			s.last[fxRateKey{r.FromCurrency, r.ToCurrency, r.Tier}] = r
+		}
+	}
+
+	var changed []domain.FXRateRecord
+	for _, q := range s.rates.Quotes(ctx, s.currencies) {
+		for _, tier := range fxRateTiers {
+			spread, ok := s.spreads[tier]
+			if !ok {
+				continue
+			}
+			r := domain.FXRateRecord{
+				FromCurrency:  q.FromCurrency,
+				ToCurrency:    q.ToCurrency,
+				Tier:          tier,
+				MidMarketRate: q.MidMarketRate,
+				SpreadPct:     spread,
+				Via:           q.Via,
+				RecordedAt:    now,
+			}
+			prev, seen := s.last[fxRateKey{r.FromCurrency, r.ToCurrency, tier}]
+			if seen && prev.MidMarketRate.Equal(r.MidMarketRate) && prev.SpreadPct.Equal(r.SpreadPct) && prev.Via == r.Via {
+				continue
+			}
+			changed = append(changed, r)
+		}
+	}
+	if len(changed) == 0 {
+		s.logger.Debug("fx rates unchanged")
+		return
+	}
+
+	if err := s.repo.Record(ctx, changed); err != nil {
+		s.logger.Error("failed to record fx rates", "error", err)
+		return
+	}
+	for _, r := range changed {
+		s.last[fxRateKey{r.FromCurrency, r.ToCurrency, r.Tier}] = r
+	}
+	s.logger.Debug("fx rates recorded", "changed", len(changed))
+}
+
+// History returns the pair's rates for tier from since to until, led by
+// the rate in effect at since. A zero until is now and a zero since is a
+// week before until.
+func (s *FXRateHistoryService) History(ctx context.Context, from, to domain.Currency, tier domain.UserTier, since, until time.Time, limit int) (*FXRateHistory, error) {
+	if !from.IsValid() || !to.IsValid() || from == to {
+		return nil, fmt.Errorf("History: pair %s/%s: %w", from, to, domain.ErrInvalidCurrency)
+	}
+	if !tier.IsValid() {
+		return nil, fmt.Errorf("History: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "tier", "value", tier))
+	}
+	if until.IsZero() {
+		until = time.Now().UTC()
+	}
+	if since.IsZero() {
+		since = until.Add(-defaultFXHistoryWindow)
+	}
+	if !since.Before(until) {
+		return nil, fmt.Errorf("History: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "since", "value", since))
+	}
+
+	rates, more, err := s.repo.History(ctx, from, to, tier, since, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("History: %w", err)
+	}
+	return &FXRateHistory{Since: since, Until: until, Rates: rates, HasMore: more}, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubFXRateHistory struct {
+	latest       []domain.FXRateRecord
+	recorded     [][]domain.FXRateRecord
+	tier         domain.UserTier
+	since, until time.Time
+}
+
+func (s *stubFXRateHistory) Record(_ context.Context, rates []domain.FXRateRecord) error {
+	s.recorded = append(s.recorded, rates)
+	return nil
+}
+
+func (s *stubFXRateHistory) Latest(context.Context) ([]domain.FXRateRecord, error) {
+	return s.latest, nil
+}
+
+func (s *stubFXRateHistory) History(_ context.Context, _, _ domain.Currency, tier domain.UserTier, since, until time.Time, _ int) ([]domain.FXRateRecord, bool, error) {
+	s.tier, s.since, s.until = tier, since, until
+	return nil, false, nil
+}
+
+type stubRateQuoter struct {
+	quotes []fx.Quote
+}
+
+func (s *stubRateQuoter) Quotes(context.Context, []domain.Currency) []fx.Quote {
+	return s.quotes
+}
+
+func testTierSpreads() map[domain.UserTier]decimal.Decimal {
+	return map[domain.UserTier]decimal.Decimal{
+		domain.UserTierStandard: decimal.RequireFromString("0.005"),
+		domain.UserTierPlus:     decimal.RequireFromString("0.0035"),
+		domain.UserTierBusiness: decimal.RequireFromString("0.002"),
+	}
+}
+
+func TestFXRateHistory_RecordsEveryPairAndTier(t *testing.T) {
+	repo := &stubFXRateHistory{}
+	currencies := []domain.Currency{domain.CurrencyUSD, domain.CurrencyEUR, domain.CurrencyNGN}
+	rates := fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates())
+	svc := NewFXRateHistoryService(rates, repo, currencies, testTierSpreads(), slog.Default(), time.Hour)
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	svc.refresh(context.Background(), now)
+
+	require.Len(t, repo.recorded, 1)
+	recorded := repo.recorded[0]
+	assert.Len(t, recorded, 18, "every ordered pair, cross rates included, for each tier")
+	for _, r := range recorded {
+		assert.Equal(t, now, r.RecordedAt, "one refresh, one timestamp")
+		assert.True(t, testTierSpreads()[r.Tier].Equal(r.SpreadPct), "%s records its own spread", r.Tier)
+		if r.FromCurrency == domain.CurrencyNGN && r.ToCurrency == domain.CurrencyEUR {
+			assert.Equal(t, domain.CurrencyUSD, r.Via)
+			assert.Equal(t, "0.0005934", r.MidMarketRate.String())
+		}
+	}
+}
+
+func TestFXRateHistory_RecordsOnlyChanges(t *testing.T) {
+	usdEUR := fx.Quote{FromCurrency: domain.CurrencyUSD, ToCurrency: domain.CurrencyEUR, MidMarketRate: decimal.RequireFromString("0.92")}
+	eurUSD := fx.Quote{FromCurrency: domain.CurrencyEUR, ToCurrency: domain.CurrencyUSD, MidMarketRate: decimal.RequireFromString("1.087")}
+	quoter := &stubRateQuoter{quotes: []fx.Quote{usdEUR, eurUSD}}
+	spreads := map[domain.UserTier]decimal.Decimal{domain.UserTierStandard: decimal.RequireFromString("0.005")}
+	repo := &stubFXRateHistory{latest: []domain.FXRateRecord{{
+		FromCurrency: domain.CurrencyUSD, ToCurrency: domain.CurrencyEUR, Tier: domain.UserTierStandard,
+		MidMarketRate: decimal.RequireFromString("0.920"), SpreadPct: decimal.RequireFromString("0.005"),
+	}}}
+	svc := NewFXRateHistoryService(quoter, repo, nil, spreads, slog.Default(), time.Hour)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+
+	svc.refresh(ctx, now)
+	require.Len(t, repo.recorded, 1)
+	require.Len(t, repo.recorded[0], 1, "USD/EUR is already recorded at this rate")
+	assert.Equal(t, domain.CurrencyEUR, repo.recorded[0][0].FromCurrency)
+
+	svc.refresh(ctx, now.Add(time.Hour))
+	assert.Len(t, repo.recorded, 1, "nothing changed, nothing written")
+
+	quoter.quotes[0].MidMarketRate = decimal.RequireFromString("0.93")
+	svc.refresh(ctx, now.Add(2*time.Hour))
+	require.Len(t, repo.recorded, 2)
+	require.Len(t, repo.recorded[1], 1)
+	assert.Equal(t, "0.93", repo.recorded[1][0].MidMarketRate.String())
+
+	spreads[domain.UserTierStandard] = decimal.RequireFromString("0.004")
+	svc.refresh(ctx, now.Add(3*time.Hour))
+	require.Len(t, repo.recorded, 3)
+	assert.Len(t, repo.recorded[2], 2, "a spread change is a change for every pair")
+}
+
+func TestFXRateHistory_History(t *testing.T) {
+	repo := &stubFXRateHistory{}
+	svc := NewFXRateHistoryService(&stubRateQuoter{}, repo, nil, testTierSpreads(), slog.Default(), time.Hour)
+	ctx := context.Background()
+	until := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	h, err := svc.History(ctx, domain.CurrencyUSD, domain.CurrencyEUR, domain.UserTierPlus, time.Time{}, until, 100)
+	require.NoError(t, err)
+	assert.Equal(t, until.AddDate(0, 0, -7), h.Since, "a week back by default")
+	assert.Equal(t, h.Since, repo.since)
+	assert.Equal(t, domain.UserTierPlus, repo.tier)
+
+	_, err = svc.History(ctx, domain.CurrencyUSD, domain.CurrencyUSD, domain.UserTierStandard, time.Time{}, until, 100)
+	assert.ErrorIs(t, err, domain.ErrInvalidCurrency)
+
+	_, err = svc.History(ctx, domain.CurrencyUSD, domain.CurrencyEUR, domain.UserTierStandard, until, until, 100)
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	_, err = svc.History(ctx, domain.CurrencyUSD, domain.CurrencyEUR, "gold", time.Time{}, until, 100)
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+var uniqueNamePattern = regexp.MustCompile(`^[a-z0-9_]{3,20}$`)
+
+type identifierChangeRepo interface {
+	Lock(ctx context.Context, tx pgx.Tx, kind domain.IdentifierKind, values ...string) error
+	Create(ctx context.Context, tx pgx.Tx, c *domain.IdentifierChange) error
+	LastReleased(ctx context.Context, tx pgx.Tx, kind domain.IdentifierKind, value string, claimant uuid.UUID) (*domain.IdentifierChange, error)
+	ReassignedSince(ctx context.Context, kind domain.IdentifierKind, value string, holder uuid.UUID, since time.Time) (*domain.IdentifierChange, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.IdentifierChange, error)
+}
+
+type identityUserRepo interface {
+	GetByUniqueName(ctx context.Context, uniqueName string) (*domain.User, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.User, error)
+	SetUniqueName(ctx context.Context, tx pgx.Tx, id uuid.UUID, uniqueName string) error
+	SetEmail(ctx context.Context, tx pgx.Tx, id uuid.UUID, email string) error
+}
+
+// IdentityService changes a user's email and grey tag, keeping a history so
+// a released tag can't be re-claimed straight away and senders are warned
+// when a tag has recently changed hands.
+type IdentityService struct {
+	changes       identifierChangeRepo
+	users         identityUserRepo
+	db            *pgxpool.Pool
+	cooldown      time.Duration
+	warningWindow time.Duration
+}
+
+func NewIdentityService(changes identifierChangeRepo, users identityUserRepo, db *pgxpool.Pool, cooldown, warningWindow time.Duration) *IdentityService {
+	return &IdentityService{
+		changes:       changes,
+		users:         users,
+		db:            db,
+		cooldown:      cooldown,
+		warningWindow: warningWindow,
+	}
+}
+
+func (s *IdentityService) ChangeUniqueName(ctx context.Context, userID uuid.UUID, uniqueName string) (*domain.User, error) {
+	log := logging.FromContext(ctx)
+
+	uniqueName = strings.ToLower(strings.TrimSpace(uniqueName))
+	if !uniqueNamePattern.MatchString(uniqueName) {
+		return nil, fmt.Errorf("ChangeUniqueName: grey tag must be 3-20 lowercase letters, digits or underscores: %w", domain.ErrInvalidRequest)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := s.users.GetForUpdate(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", err)
+	}
+	if user.UniqueName != nil && *user.UniqueName == uniqueName {
+		return user, nil
+	}
+
+	locks := []string{uniqueName}
+	if user.UniqueName != nil {
+		locks = append(locks, *user.UniqueName)
+	}
+	if err := s.changes.Lock(ctx, tx, domain.IdentifierUniqueName, locks...); err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", err)
+	}
+
+	now := time.Now().UTC()
+	released, err := s.changes.LastReleased(ctx, tx, domain.IdentifierUniqueName, uniqueName, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", err)
+	}
+	if released != nil && now.Sub(released.ChangedAt) < s.cooldown {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", domain.NewDomainError(domain.ErrUniqueNameCoolingDown, "available_at", released.ChangedAt.Add(s.cooldown)))
+	}
+
+	if err := s.users.SetUniqueName(ctx, tx, userID, uniqueName); err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", err)
+	}
+	if err := s.changes.Create(ctx, tx, &domain.IdentifierChange{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Kind:      domain.IdentifierUniqueName,
+		OldValue:  user.UniqueName,
+		NewValue:  &uniqueName,
+		ChangedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ChangeUniqueName: commit: %w", err)
+	}
+
+	log.Info("grey tag changed", "user_id", userID, "unique_name", uniqueName)
+	user.UniqueName = &uniqueName
+	return user, nil
+}
+
+func (s *IdentityService) ChangeEmail(ctx context.Context, userID uuid.UUID, email string) (*domain.User, error) {
+	log := logging.FromContext(ctx)
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return nil, fmt.Errorf("ChangeEmail: invalid email address: %w", domain.ErrInvalidRequest)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ChangeEmail: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := s.users.GetForUpdate(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ChangeEmail: %w", err)
+	}
+	if user.Email == email {
+		return user, nil
+	}
+
+	if err := s.users.SetEmail(ctx, tx, userID, email); err != nil {
+		return nil, fmt.Errorf("ChangeEmail: %w", err)
+	}
+	old := user.Email
+	if err := s.changes.Create(ctx, tx, &domain.IdentifierChange{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Kind:      domain.IdentifierEmail,
+		OldValue:  &old,
+		NewValue:  &email,
+		ChangedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, fmt.Errorf("ChangeEmail: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ChangeEmail: commit: %w", err)
+	}
+
+	log.Info("email changed", "user_id", userID)
+	user.Email = email
+	return user, nil
+}
+
+// VerifyRecipient resolves a grey tag for a sender, flagging tags that were
+// claimed from another user within the warning window.
+func (s *IdentityService) VerifyRecipient(ctx context.Context, uniqueName string) (*domain.RecipientCheck, error) {
+	uniqueName = strings.ToLower(strings.TrimSpace(uniqueName))
+
+	user, err := s.users.GetByUniqueName(ctx, uniqueName)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyRecipient: %w", err)
+	}
+	if user.Status != domain.UserStatusActive {
+		return nil, fmt.Errorf("VerifyRecipient: recipient is %s: %w", user.Status, domain.ErrNotFound)
+	}
+
+	check := &domain.RecipientCheck{UniqueName: uniqueName, Name: user.Name}
+	since := time.Now().UTC().Add(-s.warningWindow)
+	claim, err := s.changes.ReassignedSince(ctx, domain.IdentifierUniqueName, uniqueName, user.ID, since)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("VerifyRecipient: %w", err)
+	}
+	if claim != nil {
+		check.ReassignedAt = &claim.ChangedAt
+	}
+	return check, nil
+}
+
+func (s *IdentityService) History(ctx context.Context, userID uuid.UUID) ([]domain.IdentifierChange, error) {
+	changes, err := s.changes.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("History: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestIdentity_HandleReclaimCooldownAndWarning(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	identity := NewIdentityService(
+		repository.NewIdentifierChangeRepository(db), repository.NewUserRepository(db), db,
+		24*time.Hour, 90*24*time.Hour,
+	)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+
+	_, err := identity.ChangeUniqueName(ctx, bob.ID, "alice")
+	assert.ErrorIs(t, err, domain.ErrUniqueNameTaken)
+
+	_, err = identity.ChangeUniqueName(ctx, alice.ID, "alice_new")
+	require.NoError(t, err)
+
+	_, err = identity.ChangeUniqueName(ctx, bob.ID, "alice")
+	assert.ErrorIs(t, err, domain.ErrUniqueNameCoolingDown)
+
+	check, err := identity.VerifyRecipient(ctx, "alice_new")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", check.Name)
+	assert.Nil(t, check.ReassignedAt)
+
+	_, err = db.Exec(ctx,
+		`UPDATE identifier_changes SET changed_at = changed_at - interval '2 days' WHERE user_id = $1`, alice.ID)
+	require.NoError(t, err)
+
+	updated, err := identity.ChangeUniqueName(ctx, bob.ID, "Alice")
+	require.NoError(t, err)
+	require.NotNil(t, updated.UniqueName)
+	assert.Equal(t, "alice", *updated.UniqueName)
+
+	check, err = identity.VerifyRecipient(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", check.Name)
+	assert.NotNil(t, check.ReassignedAt)
+
+	_, err = identity.ChangeEmail(ctx, bob.ID, "alice@test.com")
+	assert.ErrorIs(t, err, domain.ErrEmailTaken)
+	_, err = identity.ChangeEmail(ctx, bob.ID, "not-an-email")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+	_, err = identity.ChangeEmail(ctx, bob.ID, "bob2@test.com")
+	require.NoError(t, err)
+
+	history, err := identity.History(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, domain.IdentifierEmail, history[0].Kind)
+	assert.Equal(t, domain.IdentifierUniqueName, history[1].Kind)
+	require.NotNil(t, history[1].OldValue)
+	assert.Equal(t, "bob", *history[1].OldValue)
+}
@@ -2,10 +2,10 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 )
 
@@ -20,29 +20,30 @@ type accountRepository interface {
 	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Account, error)
 	Create(ctx context.Context, account *domain.Account) error
-	GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.Account, error)
-	UpdateBalance(ctx context.Context, tx *sql.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Account, error)
+	UpdateBalance(ctx context.Context, tx pgx.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
 }
 
 type paymentRepository interface {
-	Create(ctx context.Context, tx *sql.Tx, payment *domain.Payment) error
+	Create(ctx context.Context, tx pgx.Tx, payment *domain.Payment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
-	UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error
+	UpdateStatus(ctx context.Context, tx pgx.Tx, id uuid.UUID, from, to domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error
 }
 
 type ledgerRepository interface {
-	Create(ctx context.Context, tx *sql.Tx, entry *domain.LedgerEntry) error
-	GetByAccountID(ctx context.Context, accountID uuid.UUID, limit, offset int) ([]domain.LedgerEntry, int, error)
+	Create(ctx context.Context, tx pgx.Tx, entry *domain.LedgerEntry) error
+	GetByAccountID(ctx context.Context, accountID uuid.UUID, before *domain.LedgerCursor, limit int) ([]domain.LedgerEntry, bool, error)
 	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error)
 }
 
 type paymentEventRepository interface {
-	Create(ctx context.Context, tx *sql.Tx, event *domain.PaymentEvent) error
+	Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error
 	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.PaymentEvent, error)
 }
 
 type webhookEventRepository interface {
 	Create(ctx context.Context, event *domain.WebhookEvent) error
-	GetPending(ctx context.Context, limit int) ([]domain.WebhookEvent, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.WebhookEventStatus) error
+	Claim(ctx context.Context, owner string, limit int) ([]domain.WebhookEvent, error)
+	Renew(ctx context.Context, id uuid.UUID, owner string) error
+	Finish(ctx context.Context, id uuid.UUID, owner string, status domain.WebhookEventStatus, lastError string) error
 }
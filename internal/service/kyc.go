@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	maxKYCDocumentRefLength = 200
+	maxKYCReviewNoteLength  = 2000
+)
+
+type kycRepo interface {
+	Create(ctx context.Context, s *domain.KYCSubmission) error
+	LatestForUser(ctx context.Context, userID uuid.UUID) (*domain.KYCSubmission, error)
+	List(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, error)
+	Review(ctx context.Context, tx pgx.Tx, id uuid.UUID, status domain.KYCStatus, reviewer uuid.UUID, note string, now time.Time) (*domain.KYCSubmission, error)
+}
+
+type kycUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	SetKYCTier(ctx context.Context, tx pgx.Tx, id uuid.UUID, tier domain.KYCTier) error
+}
+
+// KYCService handles verification submissions and the staff review that
+// moves a user between KYC tiers.
+type KYCService struct {
+	submissions kycRepo
+	users       kycUserRepo
+	db          *pgxpool.Pool
+}
+
+func NewKYCService(submissions kycRepo, users kycUserRepo, db *pgxpool.Pool) *KYCService {
+	return &KYCService{submissions: submissions, users: users, db: db}
+}
+
+type SubmitKYCRequest struct {
+	UserID        uuid.UUID
+	RequestedTier domain.KYCTier
+	DocumentType  domain.KYCDocumentType
+	DocumentRef   string
+}
+
+// KYCStatus is a user's current tier and their most recent submission, if any.
+type KYCStatus struct {
+	Tier   domain.KYCTier
+	Latest *domain.KYCSubmission
+}
+
+func (s *KYCService) Submit(ctx context.Context, req SubmitKYCRequest) (*domain.KYCSubmission, error) {
+	log := logging.FromContext(ctx)
+
+	if req.RequestedTier != domain.KYCTierBasic && req.RequestedTier != domain.KYCTierFull {
+		return nil, fmt.Errorf("Submit: requested tier must be basic or full: %w", domain.ErrInvalidRequest)
+	}
+	if !req.DocumentType.IsValid() {
+		return nil, fmt.Errorf("Submit: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "document_type", "value", req.DocumentType))
+	}
+	ref := strings.TrimSpace(req.DocumentRef)
+	if ref == "" || len(ref) > maxKYCDocumentRefLength {
+		return nil, fmt.Errorf("Submit: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "document_ref", "max_length", maxKYCDocumentRefLength))
+	}
+
+	user, err := s.users.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("Submit: %w", err)
+	}
+	if user.KYCTier.AtLeast(req.RequestedTier) {
+		return nil, fmt.Errorf("Submit: already at this tier: %w", domain.NewDomainError(domain.ErrInvalidRequest, "kyc_tier", user.KYCTier))
+	}
+
+	sub := &domain.KYCSubmission{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		RequestedTier: req.RequestedTier,
+		DocumentType:  req.DocumentType,
+		DocumentRef:   ref,
+		Status:        domain.KYCStatusPending,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.submissions.Create(ctx, sub); err != nil {
+		return nil, fmt.Errorf("Submit: %w", err)
+	}
+
+	log.Info("kyc submission received",
+		"submission_id", sub.ID,
+		"user_id", sub.UserID,
+		"current_tier", user.KYCTier,
+		"requested_tier", sub.RequestedTier,
+	)
+	return sub, nil
+}
+
+func (s *KYCService) GetStatus(ctx context.Context, userID uuid.UUID) (*KYCStatus, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetStatus: %w", err)
+	}
+
+	latest, err := s.submissions.LatestForUser(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("GetStatus: %w", err)
+	}
+	return &KYCStatus{Tier: user.KYCTier, Latest: latest}, nil
+}
+
+func (s *KYCService) ListSubmissions(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("ListSubmissions: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "status", "value", status))
+	}
+	subs, err := s.submissions.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubmissions: %w", err)
+	}
+	return subs, nil
+}
+
+// Review closes a pending submission. Approval raises the user to the
+// requested tier in the same transaction.
+func (s *KYCService) Review(ctx context.Context, submissionID, staffID uuid.UUID, outcome domain.KYCStatus, note string) (*domain.KYCSubmission, error) {
+	log := logging.FromContext(ctx)
+
+	if outcome != domain.KYCStatusApproved && outcome != domain.KYCStatusRejected {
+		return nil, fmt.Errorf("Review: outcome must be approved or rejected: %w", domain.ErrInvalidRequest)
+	}
+	note = strings.TrimSpace(note)
+	if len(note) > maxKYCReviewNoteLength {
+		return nil, fmt.Errorf("Review: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "note", "max_length", maxKYCReviewNoteLength))
+	}
+	if outcome == domain.KYCStatusRejected && note == "" {
+		return nil, fmt.Errorf("Review: rejection requires a note: %w", domain.ErrInvalidRequest)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Review: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sub, err := s.submissions.Review(ctx, tx, submissionID, outcome, staffID, note, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("Review: %w", err)
+	}
+	if outcome == domain.KYCStatusApproved {
+		if err := s.users.SetKYCTier(ctx, tx, sub.UserID, sub.RequestedTier); err != nil {
+			return nil, fmt.Errorf("Review: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("Review: commit: %w", err)
+	}
+
+	log.Info("kyc submission reviewed",
+		"submission_id", sub.ID,
+		"user_id", sub.UserID,
+		"staff_id", staffID,
+		"outcome", sub.Status,
+		"requested_tier", sub.RequestedTier,
+	)
+	return sub, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestKYC_ApprovalUnlocksPayouts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	users := repository.NewUserRepository(db)
+	kyc := NewKYCService(repository.NewKYCRepository(db), users, db)
+
+	user := testutil.SeedTestUser(t, db, "new@test.com", "New", "new_kyc")
+	testutil.SeedTestAccount(t, db, user.ID, "USD", 10000)
+	staff := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_kyc")
+	_, err := db.Exec(ctx, `UPDATE users SET kyc_tier = 'unverified' WHERE id = $1`, user.ID)
+	require.NoError(t, err)
+
+	payout := func() error {
+		_, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+			SenderUserID:   user.ID,
+			SourceCurrency: domain.CurrencyUSD,
+			DestCurrency:   domain.CurrencyUSD,
+			Amount:         1000,
+			DestIBAN:       "DE89370400440532013000",
+			DestBankName:   "Deutsche Bank",
+			IdempotencyKey: uuid.NewString(),
+		})
+		return err
+	}
+	assert.ErrorIs(t, payout(), domain.ErrKYCTierInsufficient)
+
+	sub, err := kyc.Submit(ctx, SubmitKYCRequest{
+		UserID: user.ID, RequestedTier: domain.KYCTierBasic,
+		DocumentType: domain.KYCDocumentPassport, DocumentRef: "uploads/passport-1.pdf",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.KYCStatusPending, sub.Status)
+
+	_, err = kyc.Submit(ctx, SubmitKYCRequest{
+		UserID: user.ID, RequestedTier: domain.KYCTierFull,
+		DocumentType: domain.KYCDocumentNationalID, DocumentRef: "uploads/id-1.pdf",
+	})
+	assert.ErrorIs(t, err, domain.ErrKYCSubmissionPending)
+
+	approved, err := kyc.Review(ctx, sub.ID, staff.ID, domain.KYCStatusApproved, "")
+	require.NoError(t, err)
+	assert.Equal(t, domain.KYCStatusApproved, approved.Status)
+	require.NotNil(t, approved.ReviewedBy)
+	assert.Equal(t, staff.ID, *approved.ReviewedBy)
+
+	_, err = kyc.Review(ctx, sub.ID, staff.ID, domain.KYCStatusRejected, "too late")
+	assert.ErrorIs(t, err, domain.ErrKYCSubmissionClosed)
+
+	status, err := kyc.GetStatus(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.KYCTierBasic, status.Tier)
+	require.NotNil(t, status.Latest)
+	assert.Equal(t, sub.ID, status.Latest.ID)
+
+	assert.NoError(t, payout())
+
+	_, err = kyc.Submit(ctx, SubmitKYCRequest{
+		UserID: user.ID, RequestedTier: domain.KYCTierBasic,
+		DocumentType: domain.KYCDocumentPassport, DocumentRef: "uploads/passport-2.pdf",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+}
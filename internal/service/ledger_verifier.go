@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type ledgerChainReader interface {
+	AccountsWithEntriesSince(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	ListByAccountSince(ctx context.Context, accountID uuid.UUID, since time.Time) ([]domain.LedgerEntry, error)
+}
+
+type chainBreakRepo interface {
+	Record(ctx context.Context, b *domain.LedgerChainBreak) (bool, error)
+	Annotate(ctx context.Context, id uuid.UUID, note string, repairPaymentID *uuid.UUID, staffID uuid.UUID, at time.Time) (*domain.LedgerChainBreak, error)
+}
+
+type ledgerMetrics interface {
+	EntriesVerified(n int)
+	ChainBreakDetected(currency string)
+}
+
+// LedgerVerifier walks recent ledger entries account by account and checks
+// that each entry's BalanceBefore is the previous entry's BalanceAfter.
+// Snapshots are computed in Go before commit, so a bug that lets two writers
+// interleave on an account would show up here as a break in the chain.
+type LedgerVerifier struct {
+	ledger   ledgerChainReader
+	breaks   chainBreakRepo
+	alerter  Alerter
+	metrics  ledgerMetrics
+	logger   *slog.Logger
+	interval time.Duration
+	// window is how far back each run looks. It should be longer than
+	// interval so consecutive runs overlap.
+	window time.Duration
+}
+
+func NewLedgerVerifier(
+	ledger ledgerChainReader,
+	breaks chainBreakRepo,
+	alerter Alerter,
+	metrics ledgerMetrics,
+	logger *slog.Logger,
+	interval, window time.Duration,
+) *LedgerVerifier {
+	return &LedgerVerifier{
+		ledger:   ledger,
+		breaks:   breaks,
+		alerter:  alerter,
+		metrics:  metrics,
+		logger:   logger,
+		interval: interval,
+		window:   window,
+	}
+}
+
+type AnnotateChainBreakRequest struct {
+	BreakID         uuid.UUID
+	StaffID         uuid.UUID
+	Note            string
+	RepairPaymentID *uuid.UUID
+}
+
+// Annotate closes out a break with a note on how it was repaired and,
+// optionally, the payment holding the compensating entries.
+func (v *LedgerVerifier) Annotate(ctx context.Context, req AnnotateChainBreakRequest) (*domain.LedgerChainBreak, error) {
+	b, err := v.breaks.Annotate(ctx, req.BreakID, req.Note, req.RepairPaymentID, req.StaffID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("Annotate: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("ledger chain break annotated",
+		"break_id", b.ID,
+		"account_id", b.AccountID,
+		"staff_id", req.StaffID,
+		"repair_payment_id", req.RepairPaymentID,
+	)
+	return b, nil
+}
+
+func (v *LedgerVerifier) Start(ctx context.Context) {
+	v.logger.Info("ledger verifier started", "interval", v.interval, "window", v.window)
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			v.logger.Info("ledger verifier stopped")
+			return
+		case <-ticker.C:
+			v.check(ctx)
+		}
+	}
+}
+
+func (v *LedgerVerifier) check(ctx context.Context) {
+	now := time.Now().UTC()
+	since := now.Add(-v.window)
+
+	accounts, err := v.ledger.AccountsWithEntriesSince(ctx, since)
+	if err != nil {
+		v.logger.Error("failed to list accounts for ledger verification", "error", err)
+		return
+	}
+
+	for _, accountID := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+		v.checkAccount(ctx, accountID, since, now)
+	}
+}
+
+func (v *LedgerVerifier) checkAccount(ctx context.Context, accountID uuid.UUID, since, now time.Time) {
+	entries, err := v.ledger.ListByAccountSince(ctx, accountID, since)
+	if err != nil {
+		v.logger.Error("failed to load ledger entries", "account_id", accountID, "error", err)
+		return
+	}
+	if v.metrics != nil && len(entries) > 1 {
+		v.metrics.EntriesVerified(len(entries) - 1)
+	}
+
+	var fresh []domain.LedgerChainBreak
+	for _, b := range findChainBreaks(entries) {
+		b.ID = uuid.New()
+		b.DetectedAt = now
+		recorded, err := v.breaks.Record(ctx, &b)
+		if err != nil {
+			v.logger.Error("failed to record ledger chain break", "account_id", accountID, "entry_id", b.EntryID, "error", err)
+			continue
+		}
+		if recorded {
+			fresh = append(fresh, b)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	currency := entries[0].Amount.Currency
+	if v.metrics != nil {
+		for range fresh {
+			v.metrics.ChainBreakDetected(string(currency))
+		}
+	}
+
+	first, last := fresh[0], fresh[len(fresh)-1]
+	v.alerter.Alert(ctx, Alert{
+		Kind:    "ledger_chain_break",
+		Subject: fmt.Sprintf("Ledger balance chain broken on account %s", accountID),
+		Attrs: []any{
+			"account_id", accountID,
+			"currency", currency,
+			"breaks", len(fresh),
+			"span_from_entry_id", first.PrevEntryID,
+			"span_from", first.PrevCreatedAt,
+			"span_to_entry_id", last.EntryID,
+			"span_to", last.EntryCreatedAt,
+			"expected_before", first.ExpectedBefore,
+			"actual_before", first.ActualBefore,
+		},
+	})
+}
+
+// findChainBreaks walks entries, oldest first, and returns each entry whose
+// BalanceBefore differs from the BalanceAfter of the entry before it.
+// Entries written in the same instant have no defined order, so within a
+// run of equal timestamps the walk takes the entry that continues the chain
+// when there is one.
+func findChainBreaks(entries []domain.LedgerEntry) []domain.LedgerChainBreak {
+	if len(entries) < 2 {
+		return nil
+	}
+
+	ordered := make([]domain.LedgerEntry, len(entries))
+	copy(ordered, entries)
+
+	var breaks []domain.LedgerChainBreak
+	for i := 1; i < len(ordered); i++ {
+		prev := ordered[i-1]
+		for j := i; j < len(ordered) && ordered[j].CreatedAt.Equal(ordered[i].CreatedAt); j++ {
+			if ordered[j].BalanceBefore == prev.BalanceAfter {
+				ordered[i], ordered[j] = ordered[j], ordered[i]
+				break
+			}
+		}
+
+		e := ordered[i]
+		if e.BalanceBefore != prev.BalanceAfter {
+			breaks = append(breaks, domain.LedgerChainBreak{
+				AccountID:      e.AccountID,
+				EntryID:        e.ID,
+				EntryCreatedAt: e.CreatedAt,
+				PrevEntryID:    prev.ID,
+				PrevCreatedAt:  prev.CreatedAt,
+				ExpectedBefore: prev.BalanceAfter,
+				ActualBefore:   e.BalanceBefore,
+			})
+		}
+	}
+	return breaks
+}
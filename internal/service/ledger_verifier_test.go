@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/metrics"
+)
+
+type stubLedgerChain struct {
+	entries map[uuid.UUID][]domain.LedgerEntry
+}
+
+func (s *stubLedgerChain) AccountsWithEntriesSince(context.Context, time.Time) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(s.entries))
+	for id := range s.entries {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *stubLedgerChain) ListByAccountSince(_ context.Context, accountID uuid.UUID, _ time.Time) ([]domain.LedgerEntry, error) {
+	return s.entries[accountID], nil
+}
+
+type stubChainBreaks struct {
+	recorded map[uuid.UUID]domain.LedgerChainBreak
+}
+
+func (s *stubChainBreaks) Record(_ context.Context, b *domain.LedgerChainBreak) (bool, error) {
+	if _, ok := s.recorded[b.EntryID]; ok {
+		return false, nil
+	}
+	s.recorded[b.EntryID] = *b
+	return true, nil
+}
+
+func (s *stubChainBreaks) Annotate(context.Context, uuid.UUID, string, *uuid.UUID, uuid.UUID, time.Time) (*domain.LedgerChainBreak, error) {
+	return nil, domain.ErrNotFound
+}
+
+func chainEntry(accountID uuid.UUID, at time.Time, before, after int64) domain.LedgerEntry {
+	return domain.LedgerEntry{
+		ID: uuid.New(), AccountID: accountID, Amount: domain.NewMoney(0, domain.CurrencyUSD),
+		BalanceBefore: before, BalanceAfter: after, CreatedAt: at,
+	}
+}
+
+func TestFindChainBreaks(t *testing.T) {
+	acct := uuid.New()
+	t0 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("intact chain", func(t *testing.T) {
+		entries := []domain.LedgerEntry{
+			chainEntry(acct, t0, 0, 100),
+			chainEntry(acct, t0.Add(time.Second), 100, 40),
+			chainEntry(acct, t0.Add(2*time.Second), 40, 90),
+		}
+		assert.Empty(t, findChainBreaks(entries))
+	})
+
+	t.Run("entries in the same instant are chained in either order", func(t *testing.T) {
+		entries := []domain.LedgerEntry{
+			chainEntry(acct, t0, 0, 100),
+			chainEntry(acct, t0.Add(time.Second), 70, 50),
+			chainEntry(acct, t0.Add(time.Second), 100, 70),
+		}
+		assert.Empty(t, findChainBreaks(entries))
+	})
+
+	t.Run("break reports the span", func(t *testing.T) {
+		entries := []domain.LedgerEntry{
+			chainEntry(acct, t0, 0, 100),
+			chainEntry(acct, t0.Add(time.Second), 100, 40),
+			chainEntry(acct, t0.Add(2*time.Second), 100, 150),
+			chainEntry(acct, t0.Add(3*time.Second), 150, 120),
+		}
+		breaks := findChainBreaks(entries)
+		require.Len(t, breaks, 1)
+		assert.Equal(t, entries[1].ID, breaks[0].PrevEntryID)
+		assert.Equal(t, entries[2].ID, breaks[0].EntryID)
+		assert.Equal(t, int64(40), breaks[0].ExpectedBefore)
+		assert.Equal(t, int64(100), breaks[0].ActualBefore)
+	})
+}
+
+func TestLedgerVerifier_AlertsOncePerBreak(t *testing.T) {
+	acct := uuid.New()
+	t0 := time.Now().UTC().Add(-time.Hour)
+	ledger := &stubLedgerChain{entries: map[uuid.UUID][]domain.LedgerEntry{
+		acct: {
+			chainEntry(acct, t0, 0, 100),
+			chainEntry(acct, t0.Add(time.Second), 90, 50),
+		},
+	}}
+	breaks := &stubChainBreaks{recorded: map[uuid.UUID]domain.LedgerChainBreak{}}
+	alerter := &recordingAlerter{}
+	reg := metrics.NewRegistry()
+	v := NewLedgerVerifier(ledger, breaks, alerter, metrics.NewLedgerMetrics(reg), slog.Default(), time.Minute, 24*time.Hour)
+
+	ctx := context.Background()
+	v.check(ctx)
+	v.check(ctx)
+
+	assert.Len(t, breaks.recorded, 1)
+	require.Len(t, alerter.alerts, 1, "a break seen again on the next run isn't re-alerted")
+	assert.Equal(t, "ledger_chain_break", alerter.alerts[0].Kind)
+}
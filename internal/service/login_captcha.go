@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// Captcha checks CAPTCHA response tokens with the provider's siteverify
+// endpoint. hCaptcha, Turnstile and reCAPTCHA all take the same form POST
+// and answer with a success flag.
+type Captcha struct {
+	verifyURL  string
+	siteKey    string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewCaptcha(verifyURL, siteKey, secret string) *Captcha {
+	return &Captcha{
+		verifyURL: verifyURL,
+		siteKey:   siteKey,
+		secret:    secret,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// Issue only tells the client which site key to show the widget with; the
+// provider issues the puzzle itself.
+func (c *Captcha) Issue(context.Context, string, domain.LoginChallengeSetting) (*domain.LoginChallenge, error) {
+	return &domain.LoginChallenge{Mode: domain.LoginChallengeCaptcha, SiteKey: c.siteKey}, nil
+}
+
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (c *Captcha) Verify(ctx context.Context, ip string, _ domain.LoginChallengeSetting, proof domain.LoginChallengeProof) error {
+	form := url.Values{"secret": {c.secret}, "response": {proof.Solution}, "remoteip": {ip}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("Verify: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Verify: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Verify: unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result siteverifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("Verify: decode: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("Verify: %v: %w", result.ErrorCodes, domain.ErrLoginChallengeFailed)
+	}
+	return nil
+}
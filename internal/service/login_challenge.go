@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// loginChallengeCacheTTL is how long an instance keeps the setting before
+// reading it again, so a login under attack doesn't cost a query for it.
+// Other instances see a change within this long.
+const loginChallengeCacheTTL = 5 * time.Second
+
+// DefaultProofOfWorkDifficulty is used when staff turn proof of work on
+// without choosing a difficulty: about a quarter of a million hashes, well
+// under a second in a browser.
+const DefaultProofOfWorkDifficulty = 18
+
+type runtimeSettingRepo interface {
+	Get(ctx context.Context, key string) (*domain.RuntimeSetting, error)
+	Upsert(ctx context.Context, s *domain.RuntimeSetting) error
+}
+
+// LoginChallengeVerifier issues and checks one mode of login challenge.
+type LoginChallengeVerifier interface {
+	// Issue returns a challenge for the client at ip.
+	Issue(ctx context.Context, ip string, s domain.LoginChallengeSetting) (*domain.LoginChallenge, error)
+	// Verify checks the proof the client at ip sent with its login, returning
+	// domain.ErrLoginChallengeFailed if it doesn't pass. Any other error
+	// means it couldn't be checked.
+	Verify(ctx context.Context, ip string, s domain.LoginChallengeSetting, proof domain.LoginChallengeProof) error
+}
+
+// LoginChallengeService makes clients solve a challenge before their login's
+// password is checked, while staff have it turned on. It keeps bcrypt and the
+// lockout tables for clients willing to spend something per attempt. With the
+// setting off, or when it can't be read, login works as it always has.
+type LoginChallengeService struct {
+	settings  runtimeSettingRepo
+	verifiers map[domain.LoginChallengeMode]LoginChallengeVerifier
+
+	mu       sync.Mutex
+	cached   domain.LoginChallengeSetting
+	cachedAt time.Time
+	now      func() time.Time
+}
+
+// NewLoginChallengeService takes a verifier for each mode that can be turned
+// on. A mode without one can't be chosen.
+func NewLoginChallengeService(settings runtimeSettingRepo, verifiers map[domain.LoginChallengeMode]LoginChallengeVerifier) *LoginChallengeService {
+	return &LoginChallengeService{settings: settings, verifiers: verifiers, now: time.Now}
+}
+
+// Setting reads the setting, off if staff have never set it.
+func (s *LoginChallengeService) Setting(ctx context.Context) (*domain.LoginChallengeSetting, error) {
+	row, err := s.settings.Get(ctx, domain.RuntimeSettingLoginChallenge)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.LoginChallengeSetting{Mode: domain.LoginChallengeOff}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Setting: %w", err)
+	}
+
+	var setting domain.LoginChallengeSetting
+	if err := json.Unmarshal(row.Value, &setting); err != nil {
+		return nil, fmt.Errorf("Setting: decode: %w", err)
+	}
+	setting.UpdatedBy = &row.UpdatedBy
+	setting.UpdatedAt = &row.UpdatedAt
+	return &setting, nil
+}
+
+// Set turns challenges on or off. It takes effect on this instance at once
+// and on the others within loginChallengeCacheTTL.
+func (s *LoginChallengeService) Set(ctx context.Context, setting domain.LoginChallengeSetting, actorID uuid.UUID) (*domain.LoginChallengeSetting, error) {
+	if setting.Mode != domain.LoginChallengeOff && s.verifiers[setting.Mode] == nil {
+		return nil, fmt.Errorf("Set: %s: %w", setting.Mode, domain.ErrLoginChallengeUnavailable)
+	}
+	if setting.Mode != domain.LoginChallengeProofOfWork {
+		setting.Difficulty = 0
+	} else if setting.Difficulty == 0 {
+		setting.Difficulty = DefaultProofOfWorkDifficulty
+	}
+
+	value, err := json.Marshal(setting)
+	if err != nil {
+		return nil, fmt.Errorf("Set: encode: %w", err)
+	}
+	now := s.now().UTC()
+	if err := s.settings.Upsert(ctx, &domain.RuntimeSetting{
+		Key:       domain.RuntimeSettingLoginChallenge,
+		Value:     value,
+		UpdatedBy: actorID,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("Set: %w", err)
+	}
+	setting.UpdatedBy = &actorID
+	setting.UpdatedAt = &now
+
+	s.mu.Lock()
+	s.cached, s.cachedAt = setting, now
+	s.mu.Unlock()
+
+	logging.FromContext(ctx).Info("login challenge setting changed",
+		"mode", setting.Mode, "difficulty", setting.Difficulty, "actor_id", actorID)
+	return &setting, nil
+}
+
+// Issue returns a challenge for the client at ip, or one with mode off if
+// none is needed.
+func (s *LoginChallengeService) Issue(ctx context.Context, ip string) (*domain.LoginChallenge, error) {
+	setting, v, err := s.current(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Issue: %w", err)
+	}
+	if v == nil {
+		return &domain.LoginChallenge{Mode: domain.LoginChallengeOff}, nil
+	}
+
+	c, err := v.Issue(ctx, ip, setting)
+	if err != nil {
+		return nil, fmt.Errorf("Issue: %w", err)
+	}
+	return c, nil
+}
+
+// Verify checks the proof sent with a login from ip. It returns
+// domain.ErrLoginChallengeRequired when challenges are on and none was
+// solved, and domain.ErrLoginChallengeFailed when the solution is wrong. Any
+// other error means the check couldn't be made; the caller lets the login
+// through rather than lock everyone out.
+func (s *LoginChallengeService) Verify(ctx context.Context, ip string, proof domain.LoginChallengeProof) error {
+	setting, v, err := s.current(ctx)
+	if err != nil {
+		return fmt.Errorf("Verify: %w", err)
+	}
+	if v == nil {
+		return nil
+	}
+
+	if proof.Solution == "" {
+		securityEvent(ctx, "login_challenge_missing", "ip", ip, "mode", setting.Mode)
+		return fmt.Errorf("Verify: %w", domain.ErrLoginChallengeRequired)
+	}
+	if err := v.Verify(ctx, ip, setting, proof); err != nil {
+		if errors.Is(err, domain.ErrLoginChallengeFailed) {
+			securityEvent(ctx, "login_challenge_failed", "ip", ip, "mode", setting.Mode)
+		}
+		return fmt.Errorf("Verify: %w", err)
+	}
+	return nil
+}
+
+// current returns the cached setting and its verifier, nil when challenges
+// are off. A mode whose verifier has since been removed from the config
+// counts as off.
+func (s *LoginChallengeService) current(ctx context.Context) (domain.LoginChallengeSetting, LoginChallengeVerifier, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	setting, fresh := s.cached, !s.cachedAt.IsZero() && now.Sub(s.cachedAt) < loginChallengeCacheTTL
+	s.mu.Unlock()
+
+	if !fresh {
+		read, err := s.Setting(ctx)
+		if err != nil {
+			return domain.LoginChallengeSetting{}, nil, err
+		}
+		setting = *read
+
+		s.mu.Lock()
+		s.cached, s.cachedAt = setting, now
+		s.mu.Unlock()
+	}
+
+	if setting.Mode == domain.LoginChallengeOff {
+		return setting, nil, nil
+	}
+	v := s.verifiers[setting.Mode]
+	if v == nil {
+		logging.FromContext(ctx).Error("login challenge mode has no verifier, logins are not challenged", "mode", setting.Mode)
+	}
+	return setting, v, nil
+}
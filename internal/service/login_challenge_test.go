@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/ratelimit"
+)
+
+type stubRuntimeSettings struct {
+	rows map[string]domain.RuntimeSetting
+}
+
+func (s *stubRuntimeSettings) Get(_ context.Context, key string) (*domain.RuntimeSetting, error) {
+	row, ok := s.rows[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &row, nil
+}
+
+func (s *stubRuntimeSettings) Upsert(_ context.Context, row *domain.RuntimeSetting) error {
+	s.rows[row.Key] = *row
+	return nil
+}
+
+// solve brute-forces a proof-of-work challenge.
+func solve(t *testing.T, c *domain.LoginChallenge) string {
+	t.Helper()
+	for i := 0; i < 1<<24; i++ {
+		solution := strconv.Itoa(i)
+		sum := sha256.Sum256([]byte(c.Token + ":" + solution))
+		if leadingZeroBits(sum[:]) >= c.Difficulty {
+			return solution
+		}
+	}
+	t.Fatal("no solution found")
+	return ""
+}
+
+func TestProofOfWork_EachChallengeLetsOneLoginThrough(t *testing.T) {
+	ctx := context.Background()
+	pow := NewProofOfWork("secret", time.Minute, ratelimit.NewMemoryStore())
+	setting := domain.LoginChallengeSetting{Mode: domain.LoginChallengeProofOfWork, Difficulty: 8}
+
+	c, err := pow.Issue(ctx, "203.0.113.7", setting)
+	require.NoError(t, err)
+	proof := domain.LoginChallengeProof{Challenge: c.Token, Solution: solve(t, c)}
+
+	require.ErrorIs(t, pow.Verify(ctx, "198.51.100.1", setting, proof), domain.ErrLoginChallengeFailed, "issued to another IP")
+	require.ErrorIs(t, pow.Verify(ctx, "203.0.113.7", setting, domain.LoginChallengeProof{Challenge: c.Token, Solution: "x"}),
+		domain.ErrLoginChallengeFailed, "wrong solution")
+	raised := setting
+	raised.Difficulty = 12
+	require.ErrorIs(t, pow.Verify(ctx, "203.0.113.7", raised, proof), domain.ErrLoginChallengeFailed, "difficulty raised since")
+
+	require.NoError(t, pow.Verify(ctx, "203.0.113.7", setting, proof))
+	require.ErrorIs(t, pow.Verify(ctx, "203.0.113.7", setting, proof), domain.ErrLoginChallengeFailed, "already used")
+
+	c, err = pow.Issue(ctx, "203.0.113.7", setting)
+	require.NoError(t, err)
+	proof = domain.LoginChallengeProof{Challenge: c.Token, Solution: solve(t, c)}
+	pow.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	require.ErrorIs(t, pow.Verify(ctx, "203.0.113.7", setting, proof), domain.ErrLoginChallengeFailed, "expired")
+}
+
+func TestLoginChallengeService_OnlyChallengesWhileTurnedOn(t *testing.T) {
+	ctx := context.Background()
+	ip := "203.0.113.7"
+	svc := NewLoginChallengeService(&stubRuntimeSettings{rows: map[string]domain.RuntimeSetting{}},
+		map[domain.LoginChallengeMode]LoginChallengeVerifier{
+			domain.LoginChallengeProofOfWork: NewProofOfWork("secret", time.Minute, ratelimit.NewMemoryStore()),
+		})
+
+	c, err := svc.Issue(ctx, ip)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoginChallengeOff, c.Mode)
+	require.NoError(t, svc.Verify(ctx, ip, domain.LoginChallengeProof{}), "off: plain login")
+
+	_, err = svc.Set(ctx, domain.LoginChallengeSetting{Mode: domain.LoginChallengeCaptcha}, uuid.New())
+	require.ErrorIs(t, err, domain.ErrLoginChallengeUnavailable, "no CAPTCHA verifier configured")
+
+	setting, err := svc.Set(ctx, domain.LoginChallengeSetting{Mode: domain.LoginChallengeProofOfWork}, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProofOfWorkDifficulty, setting.Difficulty)
+	_, err = svc.Set(ctx, domain.LoginChallengeSetting{Mode: domain.LoginChallengeProofOfWork, Difficulty: 8}, uuid.New())
+	require.NoError(t, err)
+
+	require.ErrorIs(t, svc.Verify(ctx, ip, domain.LoginChallengeProof{}), domain.ErrLoginChallengeRequired)
+
+	c, err = svc.Issue(ctx, ip)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoginChallengeProofOfWork, c.Mode)
+	assert.Equal(t, 8, c.Difficulty)
+	require.NoError(t, svc.Verify(ctx, ip, domain.LoginChallengeProof{Challenge: c.Token, Solution: solve(t, c)}))
+
+	stored, err := svc.Setting(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, stored.Difficulty)
+	assert.NotNil(t, stored.UpdatedBy)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type userDeviceRepo interface {
+	Touch(ctx context.Context, tx pgx.Tx, d *domain.UserDevice) (bool, error)
+	HasOther(ctx context.Context, tx pgx.Tx, userID uuid.UUID, userAgentHash string) (bool, error)
+}
+
+type feedWriter interface {
+	Create(ctx context.Context, tx pgx.Tx, n *domain.Notification) error
+}
+
+// LoginDeviceService remembers which devices each user signs in from and
+// tells them when one they haven't used before appears. The notice goes to
+// their feed, and from there by email. A user's first device is not news.
+type LoginDeviceService struct {
+	devices       userDeviceRepo
+	notifications feedWriter
+	db            *pgxpool.Pool
+}
+
+func NewLoginDeviceService(devices userDeviceRepo, notifications feedWriter, db *pgxpool.Pool) *LoginDeviceService {
+	return &LoginDeviceService{devices: devices, notifications: notifications, db: db}
+}
+
+// SignedIn records a successful sign-in and reports whether it came from a
+// new device.
+func (s *LoginDeviceService) SignedIn(ctx context.Context, userID uuid.UUID, ip, userAgent string) (bool, error) {
+	sum := sha256.Sum256([]byte(userAgent))
+	now := time.Now().UTC()
+	d := &domain.UserDevice{
+		UserID:        userID,
+		UserAgentHash: hex.EncodeToString(sum[:]),
+		UserAgent:     userAgent,
+		LastIP:        ip,
+		FirstSeenAt:   now,
+		LastSeenAt:    now,
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("SignedIn: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	added, err := s.devices.Touch(ctx, tx, d)
+	if err != nil {
+		return false, fmt.Errorf("SignedIn: %w", err)
+	}
+	if !added {
+		return false, tx.Commit(ctx)
+	}
+	other, err := s.devices.HasOther(ctx, tx, userID, d.UserAgentHash)
+	if err != nil {
+		return false, fmt.Errorf("SignedIn: %w", err)
+	}
+	if !other {
+		return false, tx.Commit(ctx)
+	}
+
+	n, err := feedNotification(userID, templates.NewDeviceLogin, templates.NewDeviceLoginData{
+		IP: ip, UserAgent: userAgent, At: now,
+	}, nil, now)
+	if err != nil {
+		return false, fmt.Errorf("SignedIn: render: %w", err)
+	}
+	if err := s.notifications.Create(ctx, tx, n); err != nil {
+		return false, fmt.Errorf("SignedIn: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("SignedIn: commit: %w", err)
+	}
+	securityEvent(ctx, "login_new_device", "user_id", userID, "ip", ip, "user_agent", userAgent)
+	return true, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type loginThrottleRepo interface {
+	List(ctx context.Context, subjects ...string) ([]domain.LoginThrottle, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, subject string) (*domain.LoginThrottle, error)
+	Update(ctx context.Context, tx pgx.Tx, t *domain.LoginThrottle) error
+	Reset(ctx context.Context, subject string) error
+}
+
+type LoginGuardConfig struct {
+	// MaxFailures per email address and MaxIPFailures per client IP within
+	// Window lock the subject for Lockout. Zero disables that check.
+	MaxFailures   int
+	MaxIPFailures int
+	Window        time.Duration
+	Lockout       time.Duration
+}
+
+// LoginGuard counts failed logins per email address and per client IP and
+// locks a subject out for a while once it fails too often. The email lock
+// stops guessing one account's password; the IP lock stops one client trying
+// a password across many accounts. Addresses with no account are counted the
+// same way, so a lock doesn't reveal whether an account exists.
+type LoginGuard struct {
+	throttles loginThrottleRepo
+	db        *pgxpool.Pool
+	cfg       LoginGuardConfig
+}
+
+func NewLoginGuard(throttles loginThrottleRepo, db *pgxpool.Pool, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{throttles: throttles, db: db, cfg: cfg}
+}
+
+// Check returns how long the email address or IP is still locked out, or
+// zero if a login may be attempted.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	throttles, err := g.throttles.List(ctx, emailSubject(email), ipSubject(ip))
+	if err != nil {
+		return 0, fmt.Errorf("Check: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, t := range throttles {
+		if t.LockedAt(now) {
+			wait = max(wait, t.LockedUntil.Sub(now))
+		}
+	}
+	if wait > 0 {
+		securityEvent(ctx, "login_blocked", "email", normalizeEmail(email), "ip", ip, "retry_after_s", int(wait.Seconds()))
+	}
+	return wait, nil
+}
+
+// Failed records a failed login against the email address and the IP.
+func (g *LoginGuard) Failed(ctx context.Context, email, ip string) error {
+	securityEvent(ctx, "login_failed", "email", normalizeEmail(email), "ip", ip)
+
+	now := time.Now().UTC()
+	if err := g.recordFailure(ctx, emailSubject(email), g.cfg.MaxFailures, now); err != nil {
+		return fmt.Errorf("Failed: %w", err)
+	}
+	if err := g.recordFailure(ctx, ipSubject(ip), g.cfg.MaxIPFailures, now); err != nil {
+		return fmt.Errorf("Failed: %w", err)
+	}
+	return nil
+}
+
+// Succeeded clears the email address's failures. The IP's are kept, so an
+// attacker holding one valid password can't use it to reset the IP count.
+func (g *LoginGuard) Succeeded(ctx context.Context, email string) error {
+	if err := g.throttles.Reset(ctx, emailSubject(email)); err != nil {
+		return fmt.Errorf("Succeeded: %w", err)
+	}
+	return nil
+}
+
+func (g *LoginGuard) recordFailure(ctx context.Context, subject string, maxFailures int, now time.Time) error {
+	if maxFailures <= 0 {
+		return nil
+	}
+
+	tx, err := g.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := g.throttles.GetForUpdate(ctx, tx, subject)
+	if err != nil {
+		return err
+	}
+	locked := applyLoginFailure(t, now, maxFailures, g.cfg.Window, g.cfg.Lockout)
+	if err := g.throttles.Update(ctx, tx, t); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	if locked {
+		securityEvent(ctx, "login_locked", "subject", subject, "until", *t.LockedUntil, "lockouts", t.Lockouts)
+	}
+	return nil
+}
+
+// applyLoginFailure counts one failure at now. The count restarts when the
+// window has passed. Reaching maxFailures locks the subject and starts a
+// fresh count; it reports whether that happened.
+func applyLoginFailure(t *domain.LoginThrottle, now time.Time, maxFailures int, window, lockout time.Duration) bool {
+	if t.WindowStart == nil || now.Sub(*t.WindowStart) >= window {
+		t.Failures = 0
+		t.WindowStart = &now
+	}
+	t.Failures++
+	if t.Failures < maxFailures {
+		return false
+	}
+
+	until := now.Add(lockout)
+	t.LockedUntil = &until
+	t.Lockouts++
+	t.Failures = 0
+	t.WindowStart = nil
+	return true
+}
+
+// securityEvent logs an authentication event for security review.
+func securityEvent(ctx context.Context, event string, attrs ...any) {
+	logging.FromContext(ctx).Warn("security event", append([]any{"event", event}, attrs...)...)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailSubject(email string) string { return "email:" + normalizeEmail(email) }
+
+func ipSubject(ip string) string { return "ip:" + ip }
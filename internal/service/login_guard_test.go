@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestApplyLoginFailure(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	th := &domain.LoginThrottle{Subject: "email:a@test.com"}
+
+	for i := range 2 {
+		locked := applyLoginFailure(th, start.Add(time.Duration(i)*time.Minute), 3, 15*time.Minute, 10*time.Minute)
+		assert.False(t, locked)
+	}
+	assert.Equal(t, 2, th.Failures)
+	assert.Equal(t, start, *th.WindowStart)
+
+	at := start.Add(5 * time.Minute)
+	assert.True(t, applyLoginFailure(th, at, 3, 15*time.Minute, 10*time.Minute))
+	assert.Equal(t, at.Add(10*time.Minute), *th.LockedUntil)
+	assert.Equal(t, 1, th.Lockouts)
+	assert.Zero(t, th.Failures)
+	assert.True(t, th.LockedAt(at.Add(9*time.Minute)))
+	assert.False(t, th.LockedAt(at.Add(10*time.Minute)))
+
+	th.Failures = 2
+	late := start.Add(time.Hour)
+	th.WindowStart = &start
+	assert.False(t, applyLoginFailure(th, late, 3, 15*time.Minute, 10*time.Minute), "an expired window starts over")
+	assert.Equal(t, 1, th.Failures)
+	assert.Equal(t, late, *th.WindowStart)
+}
+
+func TestLoginGuard_LocksEmailAndIP(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	guard := NewLoginGuard(repository.NewLoginThrottleRepository(db), db, LoginGuardConfig{
+		MaxFailures:   3,
+		MaxIPFailures: 5,
+		Window:        15 * time.Minute,
+		Lockout:       15 * time.Minute,
+	})
+
+	for range 2 {
+		require.NoError(t, guard.Failed(ctx, "Alice@Test.com", "10.0.0.1"))
+	}
+	wait, err := guard.Check(ctx, "alice@test.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	require.NoError(t, guard.Succeeded(ctx, "alice@test.com"))
+	require.NoError(t, guard.Failed(ctx, "alice@test.com", "10.0.0.1"))
+	wait, err = guard.Check(ctx, "alice@test.com", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Zero(t, wait, "success resets the email count")
+
+	for range 2 {
+		require.NoError(t, guard.Failed(ctx, "alice@test.com", "10.0.0.1"))
+	}
+	wait, err = guard.Check(ctx, "alice@test.com", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Greater(t, wait, 14*time.Minute, "email locked from any IP")
+
+	wait, err = guard.Check(ctx, "bob@test.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Greater(t, wait, 14*time.Minute, "five failures lock the IP for every email")
+
+	wait, err = guard.Check(ctx, "bob@test.com", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/ratelimit"
+)
+
+// maxProofOfWorkSolution bounds the solution a client may send; a counter
+// never needs more.
+const maxProofOfWorkSolution = 64
+
+// ProofOfWork is a hashcash-style login challenge. The client is given a
+// signed token and must find a solution such that
+// SHA-256(token + ":" + solution) starts with difficulty zero bits. Tokens
+// are bound to the client IP they were issued to, expire after ttl and are
+// good for one login; the spent ones are remembered in a rate limit store, so
+// with Redis a token can't be replayed against another instance either.
+type ProofOfWork struct {
+	secret []byte
+	ttl    time.Duration
+	spent  ratelimit.Store
+	now    func() time.Time
+}
+
+func NewProofOfWork(secret string, ttl time.Duration, spent ratelimit.Store) *ProofOfWork {
+	return &ProofOfWork{secret: []byte(secret), ttl: ttl, spent: spent, now: time.Now}
+}
+
+func (p *ProofOfWork) Issue(_ context.Context, ip string, s domain.LoginChallengeSetting) (*domain.LoginChallenge, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("Issue: %w", err)
+	}
+
+	expiresAt := p.now().Add(p.ttl).UTC().Truncate(time.Second)
+	payload := strconv.FormatInt(expiresAt.Unix(), 10) + "." + strconv.Itoa(s.Difficulty) + "." + hex.EncodeToString(nonce)
+	return &domain.LoginChallenge{
+		Mode:       domain.LoginChallengeProofOfWork,
+		Token:      payload + "." + p.sign(ip, payload),
+		Difficulty: s.Difficulty,
+		ExpiresAt:  &expiresAt,
+	}, nil
+}
+
+func (p *ProofOfWork) Verify(ctx context.Context, ip string, s domain.LoginChallengeSetting, proof domain.LoginChallengeProof) error {
+	parts := strings.Split(proof.Challenge, ".")
+	if len(parts) != 4 || len(proof.Solution) > maxProofOfWorkSolution {
+		return fmt.Errorf("Verify: malformed: %w", domain.ErrLoginChallengeFailed)
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(p.sign(ip, payload))) {
+		return fmt.Errorf("Verify: bad signature: %w", domain.ErrLoginChallengeFailed)
+	}
+
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || !p.now().Before(time.Unix(expires, 0)) {
+		return fmt.Errorf("Verify: expired: %w", domain.ErrLoginChallengeFailed)
+	}
+	// A token issued before staff raised the difficulty isn't good enough
+	// any more.
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil || difficulty < s.Difficulty {
+		return fmt.Errorf("Verify: difficulty %s below %d: %w", parts[1], s.Difficulty, domain.ErrLoginChallengeFailed)
+	}
+
+	sum := sha256.Sum256([]byte(proof.Challenge + ":" + proof.Solution))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return fmt.Errorf("Verify: wrong solution: %w", domain.ErrLoginChallengeFailed)
+	}
+
+	// A bucket of one token that refills only after the challenge has
+	// expired lets each challenge through once.
+	fresh, _, err := p.spent.Take(ctx, "login-challenge:"+parts[2], ratelimit.Limit{Rate: 1 / p.ttl.Seconds(), Burst: 1})
+	if err != nil {
+		return fmt.Errorf("Verify: %w", err)
+	}
+	if !fresh {
+		return fmt.Errorf("Verify: already used: %w", domain.ErrLoginChallengeFailed)
+	}
+	return nil
+}
+
+func (p *ProofOfWork) sign(ip, payload string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(ip + "|" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c != 0 {
+			return n + bits.LeadingZeros8(c)
+		}
+		n += 8
+	}
+	return n
+}
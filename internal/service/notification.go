@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type notificationWriter interface {
+	Create(ctx context.Context, tx pgx.Tx, n *domain.Notification) error
+	PaymentParties(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID) (*domain.PaymentParties, error)
+}
+
+// fanOutConsumer is the notification fan-out's name in processed_events.
+const fanOutConsumer = "notification_fan_out"
+
+type fanOutOutbox interface {
+	ListPendingFanOut(ctx context.Context, limit int) ([]domain.OutboxEvent, error)
+	MarkFannedOut(ctx context.Context, tx pgx.Tx, id uuid.UUID, now time.Time) error
+}
+
+// NotificationFanOut adds the in-app notifications payment events call for
+// (transfers received, and payouts completed or failed) after the change has
+// committed. It reads the events from the outbox, so the transaction that
+// moves the money only writes the event and its outbox row; finding who is
+// involved, rendering and inserting the notification happen here. Each
+// notification commits with its event's fanned-out stamp, and the unique
+// index on notifications drops a replayed event, so an event notifies once.
+// Payment events are queued for the parties' webhook endpoints in the same
+// transaction, when webhooks is set. With processed set, each event is also
+// recorded in processed_events in that transaction, so when two fan-outs
+// list the same event (a second worker, say) only one writes for it.
+type NotificationFanOut struct {
+	outbox        fanOutOutbox
+	notifications notificationWriter
+	webhooks      webhookQueue
+	processed     processedEventRepo
+	db            *pgxpool.Pool
+	logger        *slog.Logger
+	interval      time.Duration
+	batchSize     int
+}
+
+func NewNotificationFanOut(outbox fanOutOutbox, notifications notificationWriter, webhooks webhookQueue, processed processedEventRepo, db *pgxpool.Pool, logger *slog.Logger, interval time.Duration, batchSize int) *NotificationFanOut {
+	return &NotificationFanOut{
+		outbox:        outbox,
+		notifications: notifications,
+		webhooks:      webhooks,
+		processed:     processed,
+		db:            db,
+		logger:        logger,
+		interval:      interval,
+		batchSize:     batchSize,
+	}
+}
+
+func (f *NotificationFanOut) Start(ctx context.Context) {
+	f.logger.Info("notification fan-out started", "interval", f.interval)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.logger.Info("notification fan-out stopped")
+			return
+		case <-ticker.C:
+			f.FanOut(ctx)
+		}
+	}
+}
+
+// FanOut handles pending events a batch at a time and returns how many it
+// handled. An event that fails stays pending for the next run, and ends
+// this one so it isn't retried in a tight loop.
+func (f *NotificationFanOut) FanOut(ctx context.Context) int {
+	handled := 0
+	for ctx.Err() == nil {
+		batch, err := f.outbox.ListPendingFanOut(ctx, f.batchSize)
+		if err != nil {
+			f.logger.Error("failed to list events to fan out", "error", err)
+			return handled
+		}
+		for i := range batch {
+			if err := f.fanOut(ctx, &batch[i]); err != nil {
+				f.logger.Error("failed to fan out event", "event_id", batch[i].ID, "topic", batch[i].Topic, "error", err)
+				return handled
+			}
+			handled++
+		}
+		if len(batch) < f.batchSize {
+			break
+		}
+	}
+	return handled
+}
+
+func (f *NotificationFanOut) fanOut(ctx context.Context, e *domain.OutboxEvent) error {
+	tx, err := f.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("fanOut: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := f.apply(ctx, tx, e); err != nil {
+		return fmt.Errorf("fanOut: %w", err)
+	}
+	if err := f.outbox.MarkFannedOut(ctx, tx, e.ID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("fanOut: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("fanOut: commit: %w", err)
+	}
+	return nil
+}
+
+// apply writes what e fans out to in tx, unless processed_events shows
+// another run already did; the event is stamped either way.
+func (f *NotificationFanOut) apply(ctx context.Context, tx pgx.Tx, e *domain.OutboxEvent) error {
+	if f.processed != nil {
+		err := f.processed.Mark(ctx, tx, fanOutConsumer, e.ID)
+		if errors.Is(err, domain.ErrAlreadyProcessed) {
+			f.logger.Info("outbox event already fanned out, skipping", "event_id", e.ID, "topic", e.Topic)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := f.notify(ctx, tx, e); err != nil {
+		return err
+	}
+	if f.webhooks != nil {
+		return f.queueWebhooks(ctx, tx, e)
+	}
+	return nil
+}
+
+// notify writes the notification e calls for, if any, in tx.
+func (f *NotificationFanOut) notify(ctx context.Context, tx pgx.Tx, e *domain.OutboxEvent) error {
+	// An event type this doesn't know can't call for a notification.
+	ev, err := events.Unmarshal(e.Payload)
+	if err != nil {
+		return nil
+	}
+	var paymentType string
+	switch ev := ev.(type) {
+	case *events.PaymentCompletedV1:
+		paymentType = ev.PaymentType
+	case *events.PaymentFailedV1:
+		paymentType = ev.PaymentType
+	default:
+		return nil
+	}
+	if paymentType != string(domain.PaymentTypeInternalTransfer) && paymentType != string(domain.PaymentTypeExternalPayout) {
+		return nil
+	}
+	paymentID, err := uuid.Parse(e.Key)
+	if err != nil {
+		return nil
+	}
+
+	parties, err := f.notifications.PaymentParties(ctx, tx, paymentID)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	userID, kind, data, ok := paymentNotification(parties, ev)
+	if !ok {
+		return nil
+	}
+
+	// A template that fails to render is a bug; retrying won't fix it, so
+	// the notification is dropped and logged.
+	n, err := feedNotification(userID, kind, data, &paymentID, e.CreatedAt)
+	if err != nil {
+		f.logger.Error("failed to render notification", "payment_id", paymentID, "kind", kind, "error", err)
+		return nil
+	}
+	if err := f.notifications.Create(ctx, tx, n); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+// queueWebhooks queues a payment event for its parties' webhook endpoints.
+func (f *NotificationFanOut) queueWebhooks(ctx context.Context, tx pgx.Tx, e *domain.OutboxEvent) error {
+	paymentID, err := uuid.Parse(e.Key)
+	if err != nil {
+		return nil
+	}
+	parties, err := f.notifications.PaymentParties(ctx, tx, paymentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("queueWebhooks: %w", err)
+	}
+	for _, userID := range webhookRecipients(parties, e.Topic) {
+		err := f.webhooks.Enqueue(ctx, tx, &domain.WebhookDelivery{
+			ID:        uuid.New(),
+			UserID:    userID,
+			EventID:   e.ID,
+			EventType: e.Topic,
+			Payload:   e.Payload,
+			CreatedAt: e.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("queueWebhooks: %w", err)
+		}
+	}
+	return nil
+}
+
+// feedNotification renders a notification for userID's feed. paymentID is
+// nil unless it is about a payment.
+func feedNotification(userID uuid.UUID, kind string, data any, paymentID *uuid.UUID, at time.Time) (*domain.Notification, error) {
+	msg, err := renderNotification(userID, kind, data)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Notification{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Kind:            kind,
+		Subject:         msg.Subject,
+		Body:            msg.Body,
+		PaymentID:       paymentID,
+		TemplateName:    msg.Template.Name,
+		TemplateLocale:  msg.Template.Locale,
+		TemplateVersion: msg.Template.Version,
+		CreatedAt:       at,
+	}, nil
+}
+
+// paymentNotification decides who, if anyone, hears about e and what they
+// are told. Moving money between one's own accounts isn't a transfer
+// received.
+func paymentNotification(p *domain.PaymentParties, e events.Event) (uuid.UUID, string, templates.PaymentNotificationData, bool) {
+	data := templates.PaymentNotificationData{PaymentID: p.PaymentID}
+
+	switch e := e.(type) {
+	case *events.PaymentCompletedV1:
+		switch p.Type {
+		case domain.PaymentTypeInternalTransfer:
+			if p.RecipientID == nil || *p.RecipientID == p.SenderID {
+				return uuid.Nil, "", data, false
+			}
+			data.Amount, data.Currency = p.Dest.Amount, p.Dest.Currency
+			if p.SenderTag != nil {
+				data.Sender = *p.SenderTag
+			}
+			return *p.RecipientID, templates.TransferReceived, data, true
+		case domain.PaymentTypeExternalPayout:
+			data.Amount, data.Currency = p.Dest.Amount, p.Dest.Currency
+			return p.SenderID, templates.PayoutCompleted, data, true
+		}
+	case *events.PaymentFailedV1:
+		if p.Type == domain.PaymentTypeExternalPayout {
+			data.Amount, data.Currency = p.Source.Amount, p.Source.Currency
+			data.Reason = e.Reason
+			data.Returned = e.Reversed
+			return p.SenderID, templates.PayoutFailed, data, true
+		}
+	}
+	return uuid.Nil, "", data, false
+}
+
+type notificationRepo interface {
+	List(ctx context.Context, userID uuid.UUID, unreadOnly bool, before *domain.NotificationCursor, limit int) ([]domain.Notification, bool, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userID, id uuid.UUID, now time.Time) (*domain.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error)
+}
+
+// NotificationFeed is one page of a user's notifications and how many they
+// have unread in total.
+type NotificationFeed struct {
+	Notifications []domain.Notification
+	HasMore       bool
+	Unread        int
+}
+
+// NotificationService serves users their in-app notification feed.
+type NotificationService struct {
+	notifications notificationRepo
+}
+
+func NewNotificationService(notifications notificationRepo) *NotificationService {
+	return &NotificationService{notifications: notifications}
+}
+
+func (s *NotificationService) List(ctx context.Context, userID uuid.UUID, unreadOnly bool, before *domain.NotificationCursor, limit int) (*NotificationFeed, error) {
+	items, more, err := s.notifications.List(ctx, userID, unreadOnly, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	unread, err := s.notifications.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return &NotificationFeed{Notifications: items, HasMore: more, Unread: unread}, nil
+}
+
+// MarkRead marks one of the user's notifications read. Another user's
+// notification is reported as not found.
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uuid.UUID) (*domain.Notification, error) {
+	n, err := s.notifications.MarkRead(ctx, userID, id, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("MarkRead: %w", err)
+	}
+	return n, nil
+}
+
+// MarkAllRead marks all the user's notifications read and returns how many
+// were unread.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	n, err := s.notifications.MarkAllRead(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("MarkAllRead: %w", err)
+	}
+	return n, nil
+}
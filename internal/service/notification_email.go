@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+const notificationEmailBatchSize = 100
+
+// MaxNotificationEmailAttempts is how many times a notification's email is
+// tried before it is left in the feed only.
+const MaxNotificationEmailAttempts = 5
+
+type notificationEmailRepo interface {
+	ListUnemailed(ctx context.Context, maxAttempts, limit int) ([]domain.NotificationEmail, error)
+	MarkEmailed(ctx context.Context, id uuid.UUID, now time.Time) error
+	EmailFailed(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// NotificationEmailDispatcher emails each new in-app notification to its
+// user: payments received, payouts completed and failed, and sign-ins from
+// new devices. It works from the notifications table rather than the event
+// bus, so an email goes out exactly when the feed entry committed, and a
+// send that fails is retried on the next run up to
+// MaxNotificationEmailAttempts times. Delivery is at least once: a crash
+// between sending and marking sends again.
+type NotificationEmailDispatcher struct {
+	notifications notificationEmailRepo
+	email         EmailSender
+	logger        *slog.Logger
+	interval      time.Duration
+}
+
+func NewNotificationEmailDispatcher(notifications notificationEmailRepo, email EmailSender, logger *slog.Logger, interval time.Duration) *NotificationEmailDispatcher {
+	return &NotificationEmailDispatcher{notifications: notifications, email: email, logger: logger, interval: interval}
+}
+
+func (d *NotificationEmailDispatcher) Start(ctx context.Context) {
+	d.logger.Info("notification email dispatcher started", "interval", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("notification email dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch emails pending notifications until none are left or a batch
+// makes no progress, and returns how many it sent.
+func (d *NotificationEmailDispatcher) Dispatch(ctx context.Context) int {
+	sent := 0
+	for ctx.Err() == nil {
+		pending, err := d.notifications.ListUnemailed(ctx, MaxNotificationEmailAttempts, notificationEmailBatchSize)
+		if err != nil {
+			d.logger.Error("failed to list notifications to email", "error", err)
+			return sent
+		}
+
+		batch := 0
+		for i := range pending {
+			if ctx.Err() != nil {
+				return sent
+			}
+			if d.send(ctx, &pending[i]) {
+				batch++
+			}
+		}
+		sent += batch
+		if len(pending) < notificationEmailBatchSize || batch == 0 {
+			return sent
+		}
+	}
+	return sent
+}
+
+func (d *NotificationEmailDispatcher) send(ctx context.Context, p *domain.NotificationEmail) bool {
+	log := d.logger.With("notification_id", p.ID, "user_id", p.UserID, "kind", p.Kind)
+
+	err := d.email.SendEmail(ctx, Email{
+		To:      p.Email,
+		Subject: p.Subject,
+		Body:    p.Body,
+		Template: templates.Ref{
+			Name:    p.TemplateName,
+			Locale:  p.TemplateLocale,
+			Version: p.TemplateVersion,
+		},
+	})
+	if err != nil {
+		log.Warn("failed to email notification", "attempt", p.Attempts+1, "error", err)
+		if err := d.notifications.EmailFailed(ctx, p.ID, err.Error()); err != nil {
+			log.Error("failed to record notification email failure", "error", err)
+		}
+		if p.Attempts+1 >= MaxNotificationEmailAttempts {
+			log.Error("giving up on emailing notification", "attempts", p.Attempts+1)
+		}
+		return false
+	}
+
+	if err := d.notifications.MarkEmailed(ctx, p.ID, time.Now().UTC()); err != nil {
+		log.Error("failed to mark notification emailed", "error", err)
+		return false
+	}
+	return true
+}
+
+type emailRecipients interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// EmailNotifier delivers notifications by email to the user's address. It
+// is the Notifier when an SMTP server is configured, so digests (the
+// weekly and monthly statements), payment requests and API key notices go
+// out by email.
+type EmailNotifier struct {
+	users emailRecipients
+	email EmailSender
+}
+
+func NewEmailNotifier(users emailRecipients, email EmailSender) *EmailNotifier {
+	return &EmailNotifier{users: users, email: email}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, msg Notification) error {
+	u, err := n.users.GetByID(ctx, msg.UserID)
+	if err != nil {
+		return fmt.Errorf("EmailNotifier.Notify: %w", err)
+	}
+	if err := n.email.SendEmail(ctx, Email{
+		To:       u.Email,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		Template: msg.Template,
+	}); err != nil {
+		return fmt.Errorf("EmailNotifier.Notify: %w", err)
+	}
+	return nil
+}
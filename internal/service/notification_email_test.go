@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubNotificationEmails struct {
+	pending []domain.NotificationEmail
+	emailed []uuid.UUID
+	failed  map[uuid.UUID]string
+}
+
+func (s *stubNotificationEmails) ListUnemailed(_ context.Context, maxAttempts, limit int) ([]domain.NotificationEmail, error) {
+	var out []domain.NotificationEmail
+	for _, p := range s.pending {
+		if p.Attempts < maxAttempts && len(out) < limit {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubNotificationEmails) MarkEmailed(_ context.Context, id uuid.UUID, _ time.Time) error {
+	s.emailed = append(s.emailed, id)
+	for i := range s.pending {
+		if s.pending[i].ID == id {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *stubNotificationEmails) EmailFailed(_ context.Context, id uuid.UUID, reason string) error {
+	s.failed[id] = reason
+	for i := range s.pending {
+		if s.pending[i].ID == id {
+			s.pending[i].Attempts++
+		}
+	}
+	return nil
+}
+
+type failingEmailSender struct{}
+
+func (failingEmailSender) SendEmail(context.Context, Email) error {
+	return errors.New("connection refused")
+}
+
+func TestNotificationEmailDispatcher_RetriesThenGivesUp(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubNotificationEmails{failed: map[uuid.UUID]string{}}
+	id := uuid.New()
+	repo.pending = []domain.NotificationEmail{{
+		Notification: domain.Notification{ID: id, UserID: uuid.New(), Kind: templates.PayoutFailed, Subject: "s", Body: "b", TemplateName: templates.PayoutFailed},
+		Email:        "alice@test.com",
+	}}
+
+	d := NewNotificationEmailDispatcher(repo, failingEmailSender{}, slog.Default(), time.Minute)
+	for range MaxNotificationEmailAttempts + 2 {
+		assert.Zero(t, d.Dispatch(ctx))
+	}
+	assert.Equal(t, MaxNotificationEmailAttempts, repo.pending[0].Attempts, "not tried again once given up")
+	assert.Equal(t, "connection refused", repo.failed[id])
+
+	repo.pending[0].Attempts = 0
+	emails := &stubEmailSender{}
+	d = NewNotificationEmailDispatcher(repo, emails, slog.Default(), time.Minute)
+	assert.Equal(t, 1, d.Dispatch(ctx))
+	assert.Equal(t, []uuid.UUID{id}, repo.emailed)
+	require.Len(t, emails.sent, 1)
+	assert.Equal(t, "alice@test.com", emails.sent[0].To)
+	assert.Equal(t, templates.PayoutFailed, emails.sent[0].Template.Name)
+}
+
+func TestLoginDevices_NewDeviceIsEmailed(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	notifications := repository.NewNotificationRepository(db)
+	devices := NewLoginDeviceService(repository.NewUserDeviceRepository(db), notifications, db)
+	emails := &stubEmailSender{}
+	dispatcher := NewNotificationEmailDispatcher(notifications, emails, slog.Default(), time.Minute)
+
+	laptop, phone := "Mozilla/5.0 (Macintosh)", "Mozilla/5.0 (iPhone)"
+	isNew, err := devices.SignedIn(ctx, alice.ID, "203.0.113.7", laptop)
+	require.NoError(t, err)
+	assert.False(t, isNew, "a first device isn't news")
+	isNew, err = devices.SignedIn(ctx, alice.ID, "198.51.100.1", laptop)
+	require.NoError(t, err)
+	assert.False(t, isNew, "same device from another address")
+	isNew, err = devices.SignedIn(ctx, alice.ID, "198.51.100.1", phone)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	assert.Equal(t, 1, dispatcher.Dispatch(ctx))
+	require.Len(t, emails.sent, 1)
+	sent := emails.sent[0]
+	assert.Equal(t, "alice@test.com", sent.To)
+	assert.Equal(t, templates.NewDeviceLogin, sent.Template.Name)
+	assert.Contains(t, sent.Body, phone)
+	assert.Contains(t, sent.Body, "198.51.100.1")
+
+	assert.Zero(t, dispatcher.Dispatch(ctx), "each notification is emailed once")
+
+	feed, err := NewNotificationService(notifications).List(ctx, alice.ID, true, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, feed.Notifications, 1, "it is in the feed too")
+	assert.Equal(t, templates.NewDeviceLogin, feed.Notifications[0].Kind)
+}
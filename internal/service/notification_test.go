@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestPaymentNotification(t *testing.T) {
+	sender, recipient := uuid.New(), uuid.New()
+	tag := "alice"
+	transfer := &domain.PaymentParties{
+		PaymentID: uuid.New(), Type: domain.PaymentTypeInternalTransfer,
+		Source: domain.NewMoney(1000, domain.CurrencyUSD), Dest: domain.NewMoney(900, domain.CurrencyEUR),
+		SenderID: sender, SenderTag: &tag, RecipientID: &recipient,
+	}
+	payout := &domain.PaymentParties{
+		PaymentID: uuid.New(), Type: domain.PaymentTypeExternalPayout,
+		Source: domain.NewMoney(1000, domain.CurrencyUSD), Dest: domain.NewMoney(900, domain.CurrencyEUR),
+		SenderID: sender,
+	}
+	conversion := *transfer
+	conversion.RecipientID = &sender
+
+	tests := []struct {
+		name     string
+		parties  *domain.PaymentParties
+		event    events.Event
+		wantUser uuid.UUID
+		wantKind string
+		wantData templates.PaymentNotificationData
+	}{
+		{
+			name: "transfer received", parties: transfer, event: &events.PaymentCompletedV1{},
+			wantUser: recipient, wantKind: templates.TransferReceived,
+			wantData: templates.PaymentNotificationData{PaymentID: transfer.PaymentID, Amount: 900, Currency: domain.CurrencyEUR, Sender: "alice"},
+		},
+		{name: "own conversion", parties: &conversion, event: &events.PaymentCompletedV1{}},
+		{
+			name: "payout completed", parties: payout, event: &events.PaymentCompletedV1{},
+			wantUser: sender, wantKind: templates.PayoutCompleted,
+			wantData: templates.PaymentNotificationData{PaymentID: payout.PaymentID, Amount: 900, Currency: domain.CurrencyEUR},
+		},
+		{
+			name: "payout failed", parties: payout, event: &events.PaymentFailedV1{Reason: "account closed", Reversed: true},
+			wantUser: sender, wantKind: templates.PayoutFailed,
+			wantData: templates.PaymentNotificationData{PaymentID: payout.PaymentID, Amount: 1000, Currency: domain.CurrencyUSD, Reason: "account closed", Returned: true},
+		},
+		{name: "failed transfer", parties: transfer, event: &events.PaymentFailedV1{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userID, kind, data, ok := paymentNotification(tc.parties, tc.event)
+			if tc.wantKind == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.wantUser, userID)
+			assert.Equal(t, tc.wantKind, kind)
+			assert.Equal(t, tc.wantData, data)
+		})
+	}
+}
+
+func TestNotifications_FannedOutAfterTheTransfer(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+	aliceUSD := testutil.SeedTestAccount(t, db, alice.ID, "USD", 10000)
+	bobUSD := testutil.SeedTestAccount(t, db, bob.ID, "USD", 0)
+
+	notificationRepo := repository.NewNotificationRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	w := NewPaymentEventOutbox(repository.NewPaymentEventRepository(db), outboxRepo)
+	fanOut := NewNotificationFanOut(outboxRepo, notificationRepo, nil, repository.NewProcessedEventRepository(db), db, slog.Default(), time.Second, 10)
+	feed := NewNotificationService(notificationRepo)
+
+	now := time.Now().UTC()
+	p := &domain.Payment{
+		ID: uuid.New(), IdempotencyKey: uuid.NewString(), Type: domain.PaymentTypeInternalTransfer,
+		Status: domain.PaymentStatusCompleted, SourceAccountID: aliceUSD.ID, DestAccountID: &bobUSD.ID,
+		Source: domain.NewMoney(2500, domain.CurrencyUSD), Dest: domain.NewMoney(2500, domain.CurrencyUSD),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	completed, err := events.Marshal(events.NewPaymentCompleted(p, "", now))
+	require.NoError(t, err)
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repository.NewPaymentRepository(db).Create(ctx, tx, p))
+	for range 2 {
+		// A replayed event doesn't notify twice.
+		require.NoError(t, w.Create(ctx, tx, &domain.PaymentEvent{ID: uuid.New(), PaymentID: p.ID, EventType: domain.PaymentEventTypeCompleted, Actor: "system", Payload: completed, CreatedAt: now}))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	got, err := feed.List(ctx, bob.ID, false, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, got.Notifications, "nothing is notified inside the payment's transaction")
+
+	assert.Equal(t, 2, fanOut.FanOut(ctx))
+	assert.Zero(t, fanOut.FanOut(ctx), "fanned-out events aren't handled again")
+
+	// A second fan-out that listed the events before they were stamped
+	// finds them in processed_events: it stamps them and writes nothing.
+	_, err = db.Exec(ctx, `UPDATE outbox_events SET fanned_out_at = NULL`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fanOut.FanOut(ctx))
+	assert.Zero(t, fanOut.FanOut(ctx))
+
+	got, err = feed.List(ctx, bob.ID, false, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, got.Notifications, 1)
+	assert.Equal(t, 1, got.Unread)
+	n := got.Notifications[0]
+	assert.Equal(t, templates.TransferReceived, n.Kind)
+	assert.Equal(t, "You received 2500 USD from @alice", n.Subject)
+	assert.Equal(t, p.ID, *n.PaymentID)
+
+	sent, err := feed.List(ctx, alice.ID, false, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, sent.Notifications, "the sender isn't notified of their own transfer")
+
+	_, err = feed.MarkRead(ctx, alice.ID, n.ID)
+	require.ErrorIs(t, err, domain.ErrNotFound, "another user's notification")
+
+	read, err := feed.MarkRead(ctx, bob.ID, n.ID)
+	require.NoError(t, err)
+	require.NotNil(t, read.ReadAt)
+
+	got, err = feed.List(ctx, bob.ID, true, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, got.Notifications)
+	assert.Zero(t, got.Unread)
+
+	marked, err := feed.MarkAllRead(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Zero(t, marked)
+}
+
+// BenchmarkPaymentEventWrite compares the transaction that records a
+// completed transfer with the notification written inline, as it was before
+// the fan-out stage, and with the event and outbox row alone. Run it with
+// -bench PaymentEventWrite; it needs Docker, like the other database tests.
+func BenchmarkPaymentEventWrite(b *testing.B) {
+	db := testutil.SetupTestDB(b)
+	ctx := context.Background()
+	alice := testutil.SeedTestUser(b, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(b, db, "bob@test.com", "Bob", "bob")
+	aliceUSD := testutil.SeedTestAccount(b, db, alice.ID, "USD", 10000)
+	bobUSD := testutil.SeedTestAccount(b, db, bob.ID, "USD", 0)
+
+	outboxRepo := repository.NewOutboxRepository(db)
+	w := NewPaymentEventOutbox(repository.NewPaymentEventRepository(db), outboxRepo)
+	fanOut := NewNotificationFanOut(outboxRepo, repository.NewNotificationRepository(db), nil, nil, db, slog.Default(), time.Second, 100)
+
+	now := time.Now().UTC()
+	p := &domain.Payment{
+		ID: uuid.New(), IdempotencyKey: uuid.NewString(), Type: domain.PaymentTypeInternalTransfer,
+		Status: domain.PaymentStatusCompleted, SourceAccountID: aliceUSD.ID, DestAccountID: &bobUSD.ID,
+		Source: domain.NewMoney(2500, domain.CurrencyUSD), Dest: domain.NewMoney(2500, domain.CurrencyUSD),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	completed, err := events.Marshal(events.NewPaymentCompleted(p, "", now))
+	require.NoError(b, err)
+	tx, err := db.Begin(ctx)
+	require.NoError(b, err)
+	require.NoError(b, repository.NewPaymentRepository(db).Create(ctx, tx, p))
+	require.NoError(b, tx.Commit(ctx))
+
+	write := func(b *testing.B, inline bool) {
+		tx, err := db.Begin(ctx)
+		require.NoError(b, err)
+		defer tx.Rollback(ctx)
+		event := &domain.PaymentEvent{ID: uuid.New(), PaymentID: p.ID, EventType: domain.PaymentEventTypeCompleted, Actor: "system", Payload: completed, CreatedAt: now}
+		require.NoError(b, w.Create(ctx, tx, event))
+		if inline {
+			e, err := newOutboxEvent(p.ID.String(), completed, now)
+			require.NoError(b, err)
+			require.NoError(b, fanOut.notify(ctx, tx, e))
+		}
+		require.NoError(b, tx.Commit(ctx))
+	}
+
+	b.Run("inline", func(b *testing.B) {
+		for b.Loop() {
+			write(b, true)
+		}
+	})
+	b.Run("deferred", func(b *testing.B) {
+		for b.Loop() {
+			write(b, false)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+// Notification is a message addressed to one user. Kind identifies the
+// template so channels can route or suppress it; Template pins the version
+// of it the text was rendered from.
+type Notification struct {
+	UserID   uuid.UUID
+	Kind     string
+	Subject  string
+	Body     string
+	Template templates.Ref
+}
+
+// Notifier delivers notifications to users. Implementations must be safe
+// to call again with the same notification after a failure.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the log. It stands in until a real
+// delivery channel is configured.
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg Notification) error {
+	n.logger.Info("notification",
+		"user_id", msg.UserID,
+		"kind", msg.Kind,
+		"template", msg.Template.String(),
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+type notificationLogRepo interface {
+	Record(ctx context.Context, userID uuid.UUID, kind string, tmpl templates.Ref, sentAt time.Time) error
+}
+
+// RecordingNotifier keeps an audit row of each notification next delivers,
+// including the template version. Failing to write the row doesn't fail
+// the notification, since it has already been delivered and a retry would
+// send it twice.
+type RecordingNotifier struct {
+	next   Notifier
+	log    notificationLogRepo
+	logger *slog.Logger
+}
+
+func NewRecordingNotifier(next Notifier, log notificationLogRepo, logger *slog.Logger) *RecordingNotifier {
+	return &RecordingNotifier{next: next, log: log, logger: logger}
+}
+
+func (n *RecordingNotifier) Notify(ctx context.Context, msg Notification) error {
+	if err := n.next.Notify(ctx, msg); err != nil {
+		return err
+	}
+	if err := n.log.Record(ctx, msg.UserID, msg.Kind, msg.Template, time.Now().UTC()); err != nil {
+		n.logger.Error("failed to record notification", "user_id", msg.UserID, "kind", msg.Kind, "error", err)
+	}
+	return nil
+}
+
+// renderNotification renders the template named after kind in the default
+// locale. Users have no locale preference yet.
+func renderNotification(userID uuid.UUID, kind string, data any) (Notification, error) {
+	msg, err := templates.Render(kind, templates.DefaultLocale, data)
+	if err != nil {
+		return Notification{}, err
+	}
+	return Notification{
+		UserID:   userID,
+		Kind:     kind,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		Template: msg.Ref,
+	}, nil
+}
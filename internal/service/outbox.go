@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+)
+
+// publishedPaymentEvents are the payment events that go to the event bus.
+// The rest stay in payment_events only.
+var publishedPaymentEvents = map[domain.PaymentEventType]bool{
+	domain.PaymentEventTypeCreated:   true,
+	domain.PaymentEventTypeCompleted: true,
+	domain.PaymentEventTypeFailed:    true,
+	domain.PaymentEventTypeReversed:  true,
+}
+
+type paymentEventWriter interface {
+	Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error
+}
+
+type outboxWriter interface {
+	Create(ctx context.Context, tx pgx.Tx, e *domain.OutboxEvent) error
+}
+
+// PaymentEventOutbox writes payment events and, for the ones published to
+// the event bus, an outbox row in the same transaction. Services write
+// their events through it, so an event is published exactly when the
+// change it describes commits.
+type PaymentEventOutbox struct {
+	events paymentEventWriter
+	outbox outboxWriter
+}
+
+func NewPaymentEventOutbox(events paymentEventWriter, outbox outboxWriter) *PaymentEventOutbox {
+	return &PaymentEventOutbox{events: events, outbox: outbox}
+}
+
+func (w *PaymentEventOutbox) Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error {
+	if err := w.events.Create(ctx, tx, event); err != nil {
+		return err
+	}
+	if !publishedPaymentEvents[event.EventType] || len(event.Payload) == 0 {
+		return nil
+	}
+
+	e, err := newOutboxEvent(event.PaymentID.String(), event.Payload, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("PaymentEventOutbox.Create: %w", err)
+	}
+	if err := w.outbox.Create(ctx, tx, e); err != nil {
+		return fmt.Errorf("PaymentEventOutbox.Create: %w", err)
+	}
+	return nil
+}
+
+// newOutboxEvent wraps an envelope for the bus: the topic is the event
+// type and the envelope gets the outbox ID, for consumers to drop
+// redeliveries by.
+func newOutboxEvent(key string, payload json.RawMessage, now time.Time) (*domain.OutboxEvent, error) {
+	var env events.Envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, fmt.Errorf("newOutboxEvent: %w", err)
+	}
+	id := uuid.New()
+	env.ID = &id
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("newOutboxEvent: %w", err)
+	}
+	return &domain.OutboxEvent{
+		ID:        id,
+		Topic:     string(env.Type),
+		Key:       key,
+		Payload:   raw,
+		CreatedAt: now,
+	}, nil
+}
+
+type outboxRepo interface {
+	ListUnpublished(ctx context.Context, after *domain.OutboxCursor, limit int) ([]domain.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID, now time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+type eventPublisher interface {
+	Publish(ctx context.Context, e *domain.OutboxEvent) error
+}
+
+// OutboxRelay publishes outbox events to the event bus, oldest first. An
+// event that fails to publish is retried on the next poll, and later events
+// with the same key wait behind it so consumers see each key's events in
+// order.
+type OutboxRelay struct {
+	outbox    outboxRepo
+	publisher eventPublisher
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+func NewOutboxRelay(outbox outboxRepo, publisher eventPublisher, logger *slog.Logger, interval time.Duration, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		logger:    logger,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) {
+	r.logger.Info("outbox relay started", "interval", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+// relay makes one pass over the outbox, a batch at a time. A key that
+// fails stays blocked for the rest of the pass.
+func (r *OutboxRelay) relay(ctx context.Context) {
+	blocked := make(map[string]bool)
+	var after *domain.OutboxCursor
+	for ctx.Err() == nil {
+		batch, err := r.outbox.ListUnpublished(ctx, after, r.batchSize)
+		if err != nil {
+			r.logger.Error("failed to list outbox events", "error", err)
+			return
+		}
+		r.publishBatch(ctx, batch, blocked)
+		if len(batch) < r.batchSize {
+			return
+		}
+		c := batch[len(batch)-1].Cursor()
+		after = &c
+	}
+}
+
+func (r *OutboxRelay) publishBatch(ctx context.Context, batch []domain.OutboxEvent, blocked map[string]bool) {
+	for i := range batch {
+		e := &batch[i]
+		if ctx.Err() != nil {
+			return
+		}
+		if blocked[e.Key] {
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, e); err != nil {
+			blocked[e.Key] = true
+			r.logger.Error("failed to publish event",
+				"event_id", e.ID, "topic", e.Topic, "key", e.Key, "attempts", e.Attempts+1, "error", err)
+			if err := r.outbox.MarkFailed(ctx, e.ID, err.Error()); err != nil {
+				r.logger.Error("failed to record outbox failure", "event_id", e.ID, "error", err)
+			}
+			continue
+		}
+
+		// If this fails the event is published again next poll; consumers
+		// drop the duplicate by ID.
+		if err := r.outbox.MarkPublished(ctx, e.ID, time.Now().UTC()); err != nil {
+			r.logger.Error("failed to mark outbox event published", "event_id", e.ID, "error", err)
+			blocked[e.Key] = true
+		}
+	}
+}
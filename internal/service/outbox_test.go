@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubOutbox struct {
+	pending   []domain.OutboxEvent
+	published []uuid.UUID
+	failed    map[uuid.UUID]string
+}
+
+func (s *stubOutbox) ListUnpublished(_ context.Context, after *domain.OutboxCursor, limit int) ([]domain.OutboxEvent, error) {
+	var out []domain.OutboxEvent
+	past := after == nil
+	for _, e := range s.pending {
+		if e.PublishedAt == nil && past && len(out) < limit {
+			out = append(out, e)
+		}
+		if after != nil && e.ID == after.ID {
+			past = true
+		}
+	}
+	return out, nil
+}
+
+func (s *stubOutbox) MarkPublished(_ context.Context, id uuid.UUID, now time.Time) error {
+	for i := range s.pending {
+		if s.pending[i].ID == id {
+			s.pending[i].PublishedAt = &now
+		}
+	}
+	s.published = append(s.published, id)
+	return nil
+}
+
+func (s *stubOutbox) MarkFailed(_ context.Context, id uuid.UUID, reason string) error {
+	s.failed[id] = reason
+	return nil
+}
+
+type stubPublisher struct {
+	failTopic string
+}
+
+func (p stubPublisher) Publish(_ context.Context, e *domain.OutboxEvent) error {
+	if e.Topic == p.failTopic {
+		return errors.New("bus unavailable")
+	}
+	return nil
+}
+
+func TestOutboxRelay_HoldsBackKeyAfterFailure(t *testing.T) {
+	ev := func(key, topic string) domain.OutboxEvent {
+		return domain.OutboxEvent{ID: uuid.New(), Key: key, Topic: topic}
+	}
+	outbox := &stubOutbox{
+		pending: []domain.OutboxEvent{
+			ev("a", "payment.created"),
+			ev("b", "payment.failed"),
+			ev("b", "payment.reversed"),
+			ev("c", "payment.created"),
+			ev("a", "payment.completed"),
+		},
+		failed: map[uuid.UUID]string{},
+	}
+	relay := NewOutboxRelay(outbox, stubPublisher{failTopic: "payment.failed"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second, 2)
+
+	relay.relay(context.Background())
+
+	assert.Equal(t, []uuid.UUID{outbox.pending[0].ID, outbox.pending[3].ID, outbox.pending[4].ID}, outbox.published,
+		"every key but b is drained, in order")
+	assert.Equal(t, "bus unavailable", outbox.failed[outbox.pending[1].ID])
+	assert.Nil(t, outbox.pending[2].PublishedAt, "b's later event waits behind the failed one")
+}
+
+func TestNewOutboxEvent_StampsID(t *testing.T) {
+	payload, err := events.Marshal(events.PaymentCompletedV1{PaymentID: uuid.New(), PaymentType: "internal_transfer"})
+	require.NoError(t, err)
+
+	e, err := newOutboxEvent("key-1", payload, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "payment.completed", e.Topic)
+
+	var env events.Envelope
+	require.NoError(t, json.Unmarshal(e.Payload, &env))
+	require.NotNil(t, env.ID)
+	assert.Equal(t, e.ID, *env.ID)
+
+	_, err = events.Unmarshal(e.Payload)
+	assert.NoError(t, err, "the stamped envelope still decodes")
+}
+
+func TestOutbox_WrittenWithTheChange(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	testutil.SeedSystemUser(t, db)
+	user := testutil.SeedTestUser(t, db, "outbox@test.com", "Outbox", "outbox")
+	outboxRepo := repository.NewOutboxRepository(db)
+
+	accounts := NewAccountService(repository.NewAccountRepository(db), repository.NewUserRepository(db),
+		nil, nil, nil, outboxRepo, db)
+	acct, err := accounts.CreateAccount(ctx, user.ID, domain.CurrencyEUR)
+	require.NoError(t, err)
+
+	pending, err := outboxRepo.ListUnpublished(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "account.created", pending[0].Topic)
+	assert.Equal(t, acct.ID.String(), pending[0].Key)
+
+	// A payout held for review and then completed: only the completion is
+	// published.
+	w := NewPaymentEventOutbox(repository.NewPaymentEventRepository(db), outboxRepo)
+	now := time.Now().UTC()
+	iban := "DE89370400440532013000"
+	p := &domain.Payment{
+		ID: uuid.New(), IdempotencyKey: uuid.NewString(), Type: domain.PaymentTypeExternalPayout,
+		Status: domain.PaymentStatusCompleted, SourceAccountID: acct.ID, DestIBAN: &iban,
+		Source: domain.NewMoney(500, domain.CurrencyEUR), Dest: domain.NewMoney(500, domain.CurrencyEUR),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	held, err := events.Marshal(events.NewPaymentHeld(p, domain.ReviewReasonScreening, "denylist:iban", "match", now))
+	require.NoError(t, err)
+	completed, err := events.Marshal(events.NewPaymentCompleted(p, "ref-1", now))
+	require.NoError(t, err)
+
+	writeEvents := func(commit bool) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+		if commit {
+			require.NoError(t, repository.NewPaymentRepository(db).Create(ctx, tx, p))
+		}
+		require.NoError(t, w.Create(ctx, tx, &domain.PaymentEvent{ID: uuid.New(), PaymentID: p.ID, EventType: domain.PaymentEventTypeHeld, Actor: "system", Payload: held, CreatedAt: now}))
+		require.NoError(t, w.Create(ctx, tx, &domain.PaymentEvent{ID: uuid.New(), PaymentID: p.ID, EventType: domain.PaymentEventTypeCompleted, Actor: "system", Payload: completed, CreatedAt: now}))
+		if commit {
+			require.NoError(t, tx.Commit(ctx))
+		}
+	}
+
+	writeEvents(true)
+	pending, err = outboxRepo.ListUnpublished(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "payment.completed", pending[1].Topic)
+	assert.Equal(t, p.ID.String(), pending[1].Key)
+
+	writeEvents(false)
+	pending, err = outboxRepo.ListUnpublished(ctx, nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2, "a rolled back change publishes nothing")
+}
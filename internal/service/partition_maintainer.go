@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// partitionedTables are range-partitioned by month on created_at.
+var partitionedTables = []string{"payments", "ledger_entries"}
+
+type partitionRepo interface {
+	EnsureMonthly(ctx context.Context, table string, from, to time.Time) ([]string, error)
+}
+
+// PartitionMaintainer keeps monthly partitions created ahead of time so
+// inserts never fall through to the default partition.
+type PartitionMaintainer struct {
+	partitions  partitionRepo
+	logger      *slog.Logger
+	interval    time.Duration
+	monthsAhead int
+}
+
+func NewPartitionMaintainer(partitions partitionRepo, logger *slog.Logger, interval time.Duration, monthsAhead int) *PartitionMaintainer {
+	return &PartitionMaintainer{
+		partitions:  partitions,
+		logger:      logger,
+		interval:    interval,
+		monthsAhead: monthsAhead,
+	}
+}
+
+func (m *PartitionMaintainer) Start(ctx context.Context) {
+	m.logger.Info("partition maintainer started", "interval", m.interval, "months_ahead", m.monthsAhead)
+
+	m.ensure(ctx)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("partition maintainer stopped")
+			return
+		case <-ticker.C:
+			m.ensure(ctx)
+		}
+	}
+}
+
+func (m *PartitionMaintainer) ensure(ctx context.Context) {
+	now := time.Now().UTC()
+	to := now.AddDate(0, m.monthsAhead, 0)
+
+	for _, table := range partitionedTables {
+		if ctx.Err() != nil {
+			return
+		}
+		created, err := m.partitions.EnsureMonthly(ctx, table, now, to)
+		if err != nil {
+			m.logger.Error("failed to ensure partitions", "table", table, "error", err)
+			continue
+		}
+		for _, name := range created {
+			m.logger.Info("partition created", "table", table, "partition", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ensureCall struct {
+	table    string
+	from, to time.Time
+}
+
+type stubPartitionRepo struct {
+	calls []ensureCall
+	fail  map[string]bool
+}
+
+func (s *stubPartitionRepo) EnsureMonthly(_ context.Context, table string, from, to time.Time) ([]string, error) {
+	s.calls = append(s.calls, ensureCall{table: table, from: from, to: to})
+	if s.fail[table] {
+		return nil, errors.New("boom")
+	}
+	return []string{table + "_" + to.Format("2006_01")}, nil
+}
+
+func TestPartitionMaintainer_EnsuresEveryTableAhead(t *testing.T) {
+	repo := &stubPartitionRepo{fail: map[string]bool{"payments": true}}
+	m := NewPartitionMaintainer(repo, slog.Default(), time.Hour, 3)
+
+	m.ensure(context.Background())
+
+	if assert.Len(t, repo.calls, 2, "a failure on one table must not skip the rest") {
+		assert.Equal(t, "payments", repo.calls[0].table)
+		assert.Equal(t, "ledger_entries", repo.calls[1].table)
+		for _, c := range repo.calls {
+			assert.Equal(t, c.from.AddDate(0, 3, 0), c.to)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+type passwordResetUserRepo interface {
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.User, error)
+	SetPasswordHash(ctx context.Context, tx pgx.Tx, id uuid.UUID, hash string, at time.Time) error
+}
+
+type passwordResetRepo interface {
+	Create(ctx context.Context, t *domain.PasswordResetToken) error
+	GetByHashForUpdate(ctx context.Context, tx pgx.Tx, hash []byte) (*domain.PasswordResetToken, error)
+	SpendAll(ctx context.Context, tx pgx.Tx, userID uuid.UUID, at time.Time) error
+}
+
+type passwordResetAPIKeyRepo interface {
+	RevokeAll(ctx context.Context, tx pgx.Tx, userID uuid.UUID, at time.Time) (int64, error)
+}
+
+// passwordResetQueueSize is how many requested resets can wait for the
+// worker. Requests beyond it are dropped; the user can ask again.
+const passwordResetQueueSize = 256
+
+// resetRequest is a reset asked for through Request that the worker hasn't
+// handled yet. It keeps the request's logger so what happens to it is
+// logged with the request ID.
+type resetRequest struct {
+	email string
+	ip    string
+	log   *slog.Logger
+}
+
+// PasswordResetService emails users a one-time link to set a new password.
+// The token in the link is random; only its hash is stored, so a database
+// leak doesn't expose usable links. Using a token spends every outstanding
+// token of that user, revokes the user's API keys and ends the sessions
+// issued before it.
+type PasswordResetService struct {
+	users    passwordResetUserRepo
+	tokens   passwordResetRepo
+	keys     passwordResetAPIKeyRepo
+	email    EmailSender
+	db       *pgxpool.Pool
+	ttl      time.Duration
+	resetURL string
+	queue    chan resetRequest
+}
+
+// NewPasswordResetService builds reset links by appending the token to
+// resetURL, e.g. https://app.example.com/reset-password?token=.
+func NewPasswordResetService(users passwordResetUserRepo, tokens passwordResetRepo, keys passwordResetAPIKeyRepo, email EmailSender, db *pgxpool.Pool, ttl time.Duration, resetURL string) *PasswordResetService {
+	return &PasswordResetService{
+		users:    users,
+		tokens:   tokens,
+		keys:     keys,
+		email:    email,
+		db:       db,
+		ttl:      ttl,
+		resetURL: resetURL,
+		queue:    make(chan resetRequest, passwordResetQueueSize),
+	}
+}
+
+// Request queues a reset link for email and returns at once. The address
+// is only looked up by the worker Start runs, so neither the answer nor how
+// long it took tells the caller whether the address has an account.
+func (s *PasswordResetService) Request(ctx context.Context, email, ip string) {
+	select {
+	case s.queue <- resetRequest{email: email, ip: ip, log: logging.FromContext(ctx)}:
+	default:
+		securityEvent(ctx, "password_reset_dropped", "email", normalizeEmail(email), "ip", ip)
+	}
+}
+
+// Start sends the queued reset emails until ctx is done, then sends what is
+// still queued. It runs in every process that serves the API, since that is
+// where the queue lives.
+func (s *PasswordResetService) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush(context.Background())
+			return
+		case req := <-s.queue:
+			s.handle(ctx, req)
+		}
+	}
+}
+
+// flush handles every queued request without waiting for more.
+func (s *PasswordResetService) flush(ctx context.Context) {
+	for {
+		select {
+		case req := <-s.queue:
+			s.handle(ctx, req)
+		default:
+			return
+		}
+	}
+}
+
+func (s *PasswordResetService) handle(ctx context.Context, req resetRequest) {
+	ctx = logging.WithLogger(ctx, req.log)
+	if err := s.send(ctx, req.email, req.ip); err != nil {
+		req.log.Error("failed to send password reset email", "error", err)
+	}
+}
+
+// send emails a reset link if the address belongs to a user. Unknown
+// addresses are only logged.
+func (s *PasswordResetService) send(ctx context.Context, email, ip string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			securityEvent(ctx, "password_reset_unknown_email", "email", normalizeEmail(email), "ip", ip)
+			return nil
+		}
+		return fmt.Errorf("send: %w", err)
+	}
+
+	plain, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	now := time.Now().UTC()
+	token := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashResetToken(plain),
+		CreatedIP: ip,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	securityEvent(ctx, "password_reset_requested", "user_id", user.ID, "ip", ip)
+	e, err := renderEmail(user.Email, templates.PasswordReset, templates.PasswordResetData{
+		IP:   ip,
+		TTL:  s.ttl,
+		Link: s.resetURL + plain,
+	})
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	if err := s.email.SendEmail(ctx, e); err != nil {
+		return fmt.Errorf("send: send email: %w", err)
+	}
+	return nil
+}
+
+// Confirm sets a new password using a token from Request. JWTs issued
+// before it stop working and the user's API keys are revoked, so whoever
+// had the old password loses access with it.
+func (s *PasswordResetService) Confirm(ctx context.Context, plain, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("Confirm: hash password: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Confirm: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	token, err := s.tokens.GetByHashForUpdate(ctx, tx, hashResetToken(plain))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("Confirm: %w", domain.ErrResetTokenInvalid)
+		}
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	now := time.Now().UTC()
+	if !token.UsableAt(now) {
+		return fmt.Errorf("Confirm: token %s: %w", token.ID, domain.ErrResetTokenInvalid)
+	}
+
+	user, err := s.users.GetForUpdate(ctx, tx, token.UserID)
+	if err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	if err := s.users.SetPasswordHash(ctx, tx, user.ID, string(hash), now); err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	if err := s.tokens.SpendAll(ctx, tx, user.ID, now); err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	revoked, err := s.keys.RevokeAll(ctx, tx, user.ID, now)
+	if err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Confirm: commit: %w", err)
+	}
+
+	securityEvent(ctx, "password_reset", "user_id", user.ID, "token_id", token.ID, "api_keys_revoked", revoked)
+
+	// The password has changed by now, so a failed notice is only logged.
+	e, err := renderEmail(user.Email, templates.PasswordChanged, templates.PasswordChangedData{At: now})
+	if err == nil {
+		err = s.email.SendEmail(ctx, e)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to send password changed email", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+func generateResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generateResetToken: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubEmailSender struct {
+	sent []Email
+}
+
+func (s *stubEmailSender) SendEmail(_ context.Context, e Email) error {
+	s.sent = append(s.sent, e)
+	return nil
+}
+
+// resetTokenFrom pulls the token off the end of the link in a reset email.
+func resetTokenFrom(t *testing.T, e Email) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(e.Body, "token=")
+	require.True(t, ok, "email has a reset link")
+	token, _, _ := strings.Cut(rest, "\n")
+	return token
+}
+
+func TestPasswordReset_RequestAndConfirm(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(db)
+	emails := &stubEmailSender{}
+	apiKeys := repository.NewAPIKeyRepository(db)
+	resets := NewPasswordResetService(users, repository.NewPasswordResetRepository(db), apiKeys, emails, db,
+		30*time.Minute, "https://app.test/reset?token=")
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	_, _, err := NewAPIKeyService(apiKeys, &stubNotifier{}, db, 5).Create(ctx, alice.ID, "ci",
+		[]domain.APIKeyScope{domain.APIKeyScopeRead}, "10.0.0.1", "curl/8.0")
+	require.NoError(t, err)
+
+	resets.Request(ctx, "nobody@test.com", "10.0.0.1")
+	assert.Empty(t, emails.sent, "nothing is sent before the worker runs")
+	resets.flush(ctx)
+	assert.Empty(t, emails.sent, "unknown addresses get no email")
+
+	resets.Request(ctx, "alice@test.com", "10.0.0.1")
+	resets.Request(ctx, "alice@test.com", "10.0.0.1")
+	resets.flush(ctx)
+	require.Len(t, emails.sent, 2)
+	assert.Equal(t, "alice@test.com", emails.sent[0].To)
+	first, second := resetTokenFrom(t, emails.sent[0]), resetTokenFrom(t, emails.sent[1])
+	assert.NotEqual(t, first, second)
+
+	err = resets.Confirm(ctx, "not-a-token", "new-password-1")
+	assert.ErrorIs(t, err, domain.ErrResetTokenInvalid)
+
+	require.NoError(t, resets.Confirm(ctx, second, "new-password-1"))
+	user, err := users.GetByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password-1")))
+	require.Len(t, emails.sent, 3, "a password changed notice is sent")
+	version, err := users.SessionVersion(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice.SessionVersion+1, version, "sessions issued before now are ended")
+	keys, err := apiKeys.ListByUser(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].RevokedAt, "API keys are revoked")
+
+	err = resets.Confirm(ctx, second, "new-password-2")
+	assert.ErrorIs(t, err, domain.ErrResetTokenInvalid, "tokens are single use")
+	err = resets.Confirm(ctx, first, "new-password-2")
+	assert.ErrorIs(t, err, domain.ErrResetTokenInvalid, "a reset spends older tokens")
+
+	_, err = db.Exec(context.Background(), `UPDATE password_reset_tokens SET expires_at = now() - interval '1 minute', used_at = NULL`)
+	require.NoError(t, err)
+	err = resets.Confirm(ctx, first, "new-password-2")
+	assert.ErrorIs(t, err, domain.ErrResetTokenInvalid, "expired tokens are rejected")
+}
@@ -0,0 +1,237 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+// CloseAccountRequest closes one of the user's accounts. An account with a
+// balance needs a sweep destination: another of the user's accounts
+// (SweepToAccountID), or a bank (DestIBAN and DestBankName).
+type CloseAccountRequest struct {
+	UserID           uuid.UUID
+	AccountID        uuid.UUID
+	SweepToAccountID *uuid.UUID
+	DestIBAN         string
+	DestBankName     string
+	IdempotencyKey   string
+	// TOTPCode is needed when a bank sweep is at or above the payout
+	// step-up threshold.
+	TOTPCode string
+}
+
+// CloseAccountResult is the closed account and the sweep payment that
+// emptied it, if there was anything to sweep.
+type CloseAccountResult struct {
+	Account *domain.Account
+	Sweep   *domain.Payment
+}
+
+// CloseAccount empties the account with a sweep payment and closes it in the
+// same transaction, so an account is never closed with money in it. Accounts
+// with live holds or payouts still in flight can't be closed.
+func (s *Service) CloseAccount(ctx context.Context, req CloseAccountRequest) (*CloseAccountResult, error) {
+	acct, err := s.accounts.GetByID(ctx, req.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseAccount: %w", err)
+	}
+	if acct.UserID != req.UserID || acct.AccountType != domain.AccountTypeUser {
+		return nil, fmt.Errorf("CloseAccount: %w", domain.ErrNotFound)
+	}
+	if err := verifyAccountActive(acct, "account"); err != nil {
+		return nil, fmt.Errorf("CloseAccount: %w", err)
+	}
+
+	pending, err := s.payments.SumPendingOutgoing(ctx, acct.ID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseAccount: %w", err)
+	}
+	if pending > 0 {
+		return nil, fmt.Errorf("CloseAccount: %w", domain.NewDomainError(domain.ErrAccountCloseBlocked, "pending_payouts", pending))
+	}
+
+	var sweep *domain.Payment
+	switch {
+	case acct.Balance == 0:
+		err = s.closeEmptyAccount(ctx, acct.ID)
+	case req.SweepToAccountID != nil:
+		sweep, err = s.sweepToAccount(ctx, req, acct)
+	case req.DestIBAN != "":
+		sweep, err = s.sweepToBank(ctx, req, acct)
+	default:
+		err = domain.NewDomainError(domain.ErrSweepRequired, "balance", domain.NewMoney(acct.Balance, acct.Currency).Decimal())
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return nil, fmt.Errorf("CloseAccount: %w", domain.ErrDuplicatePayment)
+		}
+		return nil, fmt.Errorf("CloseAccount: %w", err)
+	}
+
+	closed, err := s.accounts.GetByID(ctx, acct.ID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseAccount: reload: %w", err)
+	}
+
+	attrs := []any{"account_id", acct.ID, "user_id", req.UserID}
+	if sweep != nil {
+		attrs = append(attrs, "sweep_payment_id", sweep.ID, "sweep_amount", sweep.Source.Amount)
+	}
+	logging.FromContext(ctx).Info("account closed", attrs...)
+
+	return &CloseAccountResult{Account: closed, Sweep: sweep}, nil
+}
+
+func (s *Service) closeEmptyAccount(ctx context.Context, accountID uuid.UUID) error {
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("closeEmptyAccount: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	acct, err := s.accounts.GetForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return fmt.Errorf("closeEmptyAccount: %w", err)
+	}
+	if err := verifyAccountActive(acct, "account"); err != nil {
+		return fmt.Errorf("closeEmptyAccount: %w", err)
+	}
+	if acct.Balance != 0 {
+		return fmt.Errorf("closeEmptyAccount: %w", domain.ErrSweepRequired)
+	}
+	if err := s.checkSweepable(ctx, tx, acct, 0); err != nil {
+		return fmt.Errorf("closeEmptyAccount: %w", err)
+	}
+	if err := s.accounts.Close(ctx, tx, accountID); err != nil {
+		return fmt.Errorf("closeEmptyAccount: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("closeEmptyAccount: commit: %w", err)
+	}
+	return nil
+}
+
+// sweepToAccount converts the balance into another of the owner's accounts.
+// Accounts are one per currency, so this is always a cross-currency move.
+func (s *Service) sweepToAccount(ctx context.Context, req CloseAccountRequest, acct *domain.Account) (*domain.Payment, error) {
+	target, err := s.accounts.GetByID(ctx, *req.SweepToAccountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("sweepToAccount: %w", domain.ErrAccountNotFound)
+		}
+		return nil, fmt.Errorf("sweepToAccount: %w", err)
+	}
+	if target.UserID != req.UserID || target.AccountType != domain.AccountTypeUser {
+		return nil, fmt.Errorf("sweepToAccount: %w", domain.ErrAccountNotFound)
+	}
+	if target.ID == acct.ID || target.Currency == acct.Currency {
+		return nil, fmt.Errorf("sweepToAccount: cannot sweep into the account being closed: %w", domain.ErrInvalidRequest)
+	}
+
+	p, err := s.executeCrossCurrencyTransfer(ctx, InternalTransferRequest{
+		SenderUserID:   req.UserID,
+		SourceCurrency: acct.Currency,
+		DestCurrency:   target.Currency,
+		Amount:         acct.Balance,
+		IdempotencyKey: s.sweepKey(req),
+		sweep:          true,
+	}, acct.ID, target.ID)
+	if err != nil {
+		return nil, fmt.Errorf("sweepToAccount: %w", err)
+	}
+
+	s.recordCreated(p)
+	return p, nil
+}
+
+// sweepToBank pays the balance out to the owner's bank in the account's
+// currency. It goes through the same checks as any payout. The account
+// closes when the payout is created; if the payout later fails, the reversal
+// reopens it with the money back in it.
+func (s *Service) sweepToBank(ctx context.Context, req CloseAccountRequest, acct *domain.Account) (*domain.Payment, error) {
+	payout := ExternalPayoutRequest{
+		SenderUserID:   req.UserID,
+		SourceCurrency: acct.Currency,
+		DestCurrency:   acct.Currency,
+		Amount:         acct.Balance,
+		DestIBAN:       req.DestIBAN,
+		DestBankName:   req.DestBankName,
+		IdempotencyKey: s.sweepKey(req),
+		TOTPCode:       req.TOTPCode,
+		sweep:          true,
+	}
+
+	if err := s.validateExternalPayout(ctx, payout, acct); err != nil {
+		return nil, fmt.Errorf("sweepToBank: %w", err)
+	}
+	review, err := s.reviewFlag(ctx, payout)
+	if err != nil {
+		return nil, fmt.Errorf("sweepToBank: %w", err)
+	}
+	var provider Provider
+	if review == nil {
+		if provider, err = s.routeProvider(payout.SourceCurrency, payout.DestCurrency); err != nil {
+			return nil, fmt.Errorf("sweepToBank: %w", err)
+		}
+	}
+
+	p, err := s.executeSameCurrencyExternalPayout(ctx, payout, acct.ID, providerName(provider), review)
+	if err != nil {
+		return nil, fmt.Errorf("sweepToBank: %w", err)
+	}
+
+	s.recordCreated(p)
+	if review == nil {
+		s.submitToProvider(ctx, provider, p)
+	}
+	return p, nil
+}
+
+func (s *Service) sweepKey(req CloseAccountRequest) string {
+	if req.IdempotencyKey != "" {
+		return req.IdempotencyKey
+	}
+	return "account-close:" + uuid.NewString()
+}
+
+// checkSweepable confirms a closing account still holds exactly the amount
+// being swept, and that none of it is under a live hold.
+func (s *Service) checkSweepable(ctx context.Context, tx pgx.Tx, acct *domain.Account, amount int64) error {
+	if acct.Balance != amount {
+		return fmt.Errorf("checkSweepable: balance changed: %w", domain.ErrVersionConflict)
+	}
+	if s.holds == nil {
+		return nil
+	}
+	held, err := s.holds.SumActive(ctx, tx, acct.ID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("checkSweepable: %w", err)
+	}
+	if held > 0 {
+		return fmt.Errorf("checkSweepable: %w", domain.NewDomainError(domain.ErrAccountCloseBlocked, "held", held))
+	}
+	return nil
+}
+
+func transferType(req InternalTransferRequest) domain.PaymentType {
+	if req.sweep {
+		return domain.PaymentTypeSweep
+	}
+	return domain.PaymentTypeInternalTransfer
+}
+
+func payoutType(req ExternalPayoutRequest) domain.PaymentType {
+	if req.sweep {
+		return domain.PaymentTypeSweep
+	}
+	return domain.PaymentTypeExternalPayout
+}
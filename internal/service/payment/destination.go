@@ -0,0 +1,141 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// DestinationPath says how a transfer's destination account was chosen.
+type DestinationPath string
+
+const (
+	// DestinationRequested is the recipient's account in the currency the
+	// sender asked for.
+	DestinationRequested DestinationPath = "requested"
+	// DestinationSameCurrency is the recipient's account in the source
+	// currency, so nothing is converted.
+	DestinationSameCurrency DestinationPath = "same_currency"
+	// DestinationDefaultAccount is the recipient's default account, which
+	// holds another currency, so the transfer is converted.
+	DestinationDefaultAccount DestinationPath = "default_account"
+)
+
+// TransferPreview is what a transfer would do if it were sent now: where the
+// money would land and, across currencies, what the recipient would get. The
+// rate isn't held, so the transfer itself may convert at a newer one.
+type TransferPreview struct {
+	SourceAccountID uuid.UUID
+	Path            DestinationPath
+	Source          domain.Money
+	Dest            domain.Money
+	Fee             domain.Money
+	ExchangeRate    *decimal.Decimal
+}
+
+// transferPlan is a transfer request with its currencies and accounts
+// resolved and validated.
+type transferPlan struct {
+	req       InternalTransferRequest
+	sender    *domain.Account
+	recipient *domain.Account
+	path      DestinationPath
+}
+
+// PreviewInternalTransfer runs the same checks and account selection as
+// CreateInternalTransfer without moving any money, so the sender can confirm
+// the destination and conversion first. Sending the previewed dest currency
+// with the transfer pins it to that account.
+func (s *Service) PreviewInternalTransfer(ctx context.Context, req InternalTransferRequest) (*TransferPreview, error) {
+	plan, err := s.planTransfer(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("PreviewInternalTransfer: %w", err)
+	}
+	req = plan.req
+
+	preview := &TransferPreview{
+		SourceAccountID: plan.sender.ID,
+		Path:            plan.path,
+		Source:          domain.Money{Amount: req.Amount, Currency: req.SourceCurrency},
+		Dest:            domain.Money{Amount: req.Amount, Currency: req.DestCurrency},
+		Fee:             domain.Money{Currency: req.DestCurrency},
+	}
+	if req.SourceCurrency == req.DestCurrency {
+		return preview, nil
+	}
+
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("PreviewInternalTransfer: %w", err)
+	}
+	conversion, err := s.convert(ctx, req.Amount, req.SourceCurrency, req.DestCurrency, tier)
+	if err != nil {
+		return nil, fmt.Errorf("PreviewInternalTransfer: %w", err)
+	}
+	preview.Dest = conversion.Dest
+	preview.Fee = conversion.Fee
+	preview.ExchangeRate = &conversion.ExchangeRate
+	return preview, nil
+}
+
+func (s *Service) planTransfer(ctx context.Context, req InternalTransferRequest) (*transferPlan, error) {
+	pickDest := req.DestCurrency == ""
+
+	var err error
+	req.SourceCurrency, req.DestCurrency, err = s.resolveCurrencies(ctx, req.SenderUserID, req.SourceCurrency, req.DestCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("planTransfer: %w", err)
+	}
+
+	sender, recipient, path, err := s.resolveTransferAccounts(ctx, req, pickDest)
+	if err != nil {
+		return nil, fmt.Errorf("planTransfer: %w", err)
+	}
+	req.DestCurrency = recipient.Currency
+
+	if err := s.validateTransfer(ctx, req, sender, recipient); err != nil {
+		return nil, fmt.Errorf("planTransfer: %w", err)
+	}
+
+	return &transferPlan{req: req, sender: sender, recipient: recipient, path: path}, nil
+}
+
+// selectDestination picks the recipient's account for a transfer that named
+// no destination currency. An active account in the source currency comes
+// first, so nothing is converted; failing that the recipient's default
+// account, converting into its currency. When neither is active the
+// inactive one is still returned, so validation reports why it can't be
+// paid.
+func (s *Service) selectDestination(ctx context.Context, recipient *domain.User, source domain.Currency) (*domain.Account, DestinationPath, error) {
+	same, err := s.accounts.GetByUserAndCurrency(ctx, recipient.ID, source, domain.AccountTypeUser)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, "", fmt.Errorf("selectDestination: %w", err)
+	}
+	if same != nil && same.Status == domain.AccountStatusActive {
+		return same, DestinationSameCurrency, nil
+	}
+
+	var def *domain.Account
+	if recipient.DefaultAccountID != nil {
+		def, err = s.accounts.GetByID(ctx, *recipient.DefaultAccountID)
+		if err != nil {
+			return nil, "", fmt.Errorf("selectDestination: default account: %w", err)
+		}
+		if def.Status == domain.AccountStatusActive {
+			return def, DestinationDefaultAccount, nil
+		}
+	}
+
+	if same != nil {
+		return same, DestinationSameCurrency, nil
+	}
+	if def != nil {
+		return def, DestinationDefaultAccount, nil
+	}
+	return nil, "", fmt.Errorf("selectDestination: recipient has no default account: %w", domain.NewDomainError(domain.ErrAccountNotFound, "currency", source))
+}
@@ -2,16 +2,18 @@ package payment
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
 
+	"github.com/josh-kwaku/grey-backend-assessment/internal/deadline"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
 )
 
 type ExternalPayoutRequest struct {
@@ -22,11 +24,36 @@ type ExternalPayoutRequest struct {
 	DestIBAN       string
 	DestBankName   string
 	IdempotencyKey string
+	RetryOf        *uuid.UUID
+	// HoldID, when set, pays the payout out of that hold, which must be on
+	// the sender's account and cover Amount.
+	HoldID *uuid.UUID
+	// BeneficiaryID, when set, pays out to one of the sender's saved
+	// beneficiaries. Its IBAN and bank name replace DestIBAN and DestBankName.
+	BeneficiaryID *uuid.UUID
+	// TOTPCode is the sender's authenticator code, needed for payouts at or
+	// above the step-up threshold.
+	TOTPCode string
+
+	// sweep marks the payout as the sweep of an account being closed.
+	sweep bool
 }
 
+// CreateExternalPayout sends money to a bank account through a provider.
+// Like transfers, it requires req.IdempotencyKey.
 func (s *Service) CreateExternalPayout(ctx context.Context, req ExternalPayoutRequest) (*domain.Payment, error) {
 	log := logging.FromContext(ctx)
 
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("CreateExternalPayout: %w", domain.ErrMissingIdempotencyKey)
+	}
+
+	var err error
+	req.SourceCurrency, req.DestCurrency, err = s.resolveCurrencies(ctx, req.SenderUserID, req.SourceCurrency, req.DestCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("CreateExternalPayout: %w", err)
+	}
+
 	senderAcct, err := s.accounts.GetByUserAndCurrency(ctx, req.SenderUserID, req.SourceCurrency, domain.AccountTypeUser)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
@@ -35,11 +62,34 @@ func (s *Service) CreateExternalPayout(ctx context.Context, req ExternalPayoutRe
 		return nil, fmt.Errorf("CreateExternalPayout: %w", err)
 	}
 
-	if err := s.validateExternalPayout(req, senderAcct); err != nil {
+	if req.BeneficiaryID != nil {
+		if err := s.applyBeneficiary(ctx, &req); err != nil {
+			return nil, fmt.Errorf("CreateExternalPayout: %w", err)
+		}
+	}
+
+	if err := s.validateExternalPayout(ctx, req, senderAcct); err != nil {
 		return nil, fmt.Errorf("CreateExternalPayout: %w", err)
 	}
 
-	p, err := s.executeExternalPayout(ctx, req, senderAcct.ID)
+	review, err := s.reviewFlag(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("CreateExternalPayout: %w", err)
+	}
+
+	// Payouts under review are routed when approved, so routing changes made
+	// while they wait apply to them.
+	var provider Provider
+	if review == nil {
+		provider, err = s.routeProvider(req.SourceCurrency, req.DestCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("CreateExternalPayout: %w", err)
+		}
+	}
+
+	p, err := withTxRetry(ctx, "executeExternalPayout", func() (*domain.Payment, error) {
+		return s.executeExternalPayout(ctx, req, senderAcct.ID, providerName(provider), review)
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
 			return nil, fmt.Errorf("CreateExternalPayout: %w", domain.ErrDuplicatePayment)
@@ -47,24 +97,35 @@ func (s *Service) CreateExternalPayout(ctx context.Context, req ExternalPayoutRe
 		return nil, fmt.Errorf("CreateExternalPayout: %w", err)
 	}
 
-	s.submitToProvider(ctx, p)
+	s.recordCreated(p)
+	if review != nil {
+		log.Warn("external payout sent to review",
+			"payment_id", p.ID,
+			"sender_account", senderAcct.ID,
+			"review_reason", review.Reason,
+			"rule_id", review.RuleID,
+			"detail", review.Detail,
+		)
+		return p, nil
+	}
+	s.submitToProvider(ctx, provider, p)
 
 	log.Info("external payout created",
 		"payment_id", p.ID,
 		"sender_account", senderAcct.ID,
 		"source_amount", req.Amount,
 		"source_currency", req.SourceCurrency,
-		"dest_amount", p.DestAmount,
+		"dest_amount", p.Dest.Amount,
 		"dest_currency", req.DestCurrency,
+		"provider", stringVal(p.Provider),
 	)
 
 	return p, nil
 }
 
-
-func (s *Service) validateExternalPayout(req ExternalPayoutRequest, sender *domain.Account) error {
+func (s *Service) validateExternalPayout(ctx context.Context, req ExternalPayoutRequest, sender *domain.Account) error {
 	if req.Amount <= 0 {
-		return fmt.Errorf("validateExternalPayout: %w", domain.ErrInvalidAmount)
+		return fmt.Errorf("validateExternalPayout: %w", domain.NewDomainError(domain.ErrInvalidAmount, "amount", domain.NewMoney(req.Amount, req.SourceCurrency).Decimal()))
 	}
 
 	if req.DestIBAN == "" {
@@ -75,37 +136,117 @@ func (s *Service) validateExternalPayout(req ExternalPayoutRequest, sender *doma
 	}
 
 	if sender.Status == domain.AccountStatusFrozen {
-		return fmt.Errorf("validateExternalPayout: %w", domain.ErrAccountFrozen)
+		return fmt.Errorf("validateExternalPayout: %w", sender.FrozenError())
 	}
 	if sender.Status != domain.AccountStatusActive {
-		return fmt.Errorf("validateExternalPayout: %w", domain.ErrAccountClosed)
+		return fmt.Errorf("validateExternalPayout: %w", domain.NewDomainError(domain.ErrAccountClosed, "role", "sender", "account_id", sender.ID))
 	}
 
-	if req.Amount > s.txLimitForCurrency(req.SourceCurrency) {
-		return fmt.Errorf("validateExternalPayout: %w", domain.ErrLimitExceeded)
+	user, err := s.senderUser(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("validateExternalPayout: %w", err)
+	}
+	if !user.KYCTier.CanPayOut() {
+		return fmt.Errorf("validateExternalPayout: %w", domain.NewDomainError(domain.ErrKYCTierInsufficient, "kyc_tier", user.KYCTier))
+	}
+	limit, err := s.txLimitForCurrency(ctx, user, req.SourceCurrency)
+	if err != nil {
+		return fmt.Errorf("validateExternalPayout: %w", err)
+	}
+	if req.Amount > limit {
+		return fmt.Errorf("validateExternalPayout: %w", domain.NewDomainError(domain.ErrLimitExceeded,
+			"currency", req.SourceCurrency, "limit", domain.NewMoney(limit, req.SourceCurrency).Decimal(), "amount", domain.NewMoney(req.Amount, req.SourceCurrency).Decimal()))
+	}
+
+	if err := s.checkResidency(ctx, user, ResidencyCheck{
+		Type:           domain.PaymentTypeExternalPayout,
+		SourceCurrency: req.SourceCurrency,
+		DestCurrency:   req.DestCurrency,
+		DestCountry:    domain.IBANCountry(req.DestIBAN),
+	}); err != nil {
+		return fmt.Errorf("validateExternalPayout: %w", err)
+	}
+
+	if err := s.checkStepUp(ctx, req); err != nil {
+		return fmt.Errorf("validateExternalPayout: %w", err)
+	}
+
+	return nil
+}
+
+// applyBeneficiary fills in the destination from the saved beneficiary.
+// Deleted beneficiaries can't be paid until they are restored.
+func (s *Service) applyBeneficiary(ctx context.Context, req *ExternalPayoutRequest) error {
+	b, err := s.beneficiaries.GetByID(ctx, req.SenderUserID, *req.BeneficiaryID)
+	if err != nil {
+		return fmt.Errorf("applyBeneficiary: %w", err)
 	}
+	if b.Deleted() {
+		return fmt.Errorf("applyBeneficiary: %w", domain.ErrBeneficiaryDeleted)
+	}
+	req.DestIBAN = b.IBAN
+	req.DestBankName = b.BankName
+	return nil
+}
 
+// checkStepUp asks for an authenticator code on payouts at or above the
+// step-up threshold. It runs last, so a code isn't spent on a payout the
+// other checks would turn away.
+func (s *Service) checkStepUp(ctx context.Context, req ExternalPayoutRequest) error {
+	threshold := s.stepUpThreshold(req.SourceCurrency)
+	if s.stepUp == nil || threshold <= 0 || req.Amount < threshold {
+		return nil
+	}
+	if err := s.stepUp.Verify(ctx, req.SenderUserID, req.TOTPCode); err != nil {
+		return fmt.Errorf("checkStepUp: %w", err)
+	}
 	return nil
 }
 
-func (s *Service) executeExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID) (*domain.Payment, error) {
+func (s *Service) stepUpThreshold(c domain.Currency) int64 {
+	return s.config.TOTPStepUp(string(c))
+}
+
+// screen runs the configured screener. A screening failure blocks the payout
+// rather than letting an unchecked destination through.
+func (s *Service) screen(ctx context.Context, req ExternalPayoutRequest) (*ScreeningMatch, error) {
+	if s.screener == nil {
+		return nil, nil
+	}
+	deadline.Stage(ctx, "screening")
+	match, err := s.screener.Screen(ctx, ScreeningRequest{
+		SenderUserID: req.SenderUserID,
+		DestIBAN:     req.DestIBAN,
+		DestBankName: req.DestBankName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("screen: %w", err)
+	}
+	return match, nil
+}
+
+func (s *Service) executeExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID, provider *string, review *ReviewFlag) (*domain.Payment, error) {
 	if req.SourceCurrency != req.DestCurrency {
-		return s.executeCrossCurrencyExternalPayout(ctx, req, senderID)
+		return s.executeCrossCurrencyExternalPayout(ctx, req, senderID, provider, review)
 	}
-	return s.executeSameCurrencyExternalPayout(ctx, req, senderID)
+	return s.executeSameCurrencyExternalPayout(ctx, req, senderID, provider, review)
 }
 
-func (s *Service) executeSameCurrencyExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID) (*domain.Payment, error) {
+func (s *Service) executeSameCurrencyExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID, provider *string, review *ReviewFlag) (*domain.Payment, error) {
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+	}
 	outgoing, err := s.getSystemAccount(ctx, domain.AccountTypeOutgoing, req.DestCurrency)
 	if err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := repository.Begin(ctx, s.db)
 	if err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: begin tx: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
 	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, outgoing.ID)
 	if err != nil {
@@ -118,22 +259,40 @@ func (s *Service) executeSameCurrencyExternalPayout(ctx context.Context, req Ext
 	if err := verifyAccountActive(sender, "sender"); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
 	}
-	if sender.Balance < req.Amount {
-		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", domain.ErrInsufficientFunds)
+	if req.sweep {
+		if err := s.checkSweepable(ctx, tx, sender, req.Amount); err != nil {
+			return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+		}
+	}
+	if err := s.checkSpendable(ctx, tx, sender, req.HoldID, domain.NewMoney(req.Amount, req.SourceCurrency)); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+	}
+
+	if err := s.checkPeriodLimits(ctx, tx, senderID, domain.NewMoney(req.Amount, req.SourceCurrency), tier); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
 	}
 
 	now := time.Now().UTC()
-	p := buildExternalPayment(req, senderID, req.Amount, nil, nil, now)
+	p := buildExternalPayment(req, senderID, domain.NewMoney(req.Amount, req.DestCurrency), nil, domain.NewMoney(0, req.DestCurrency), now)
+	p.UserTier = &tier
+	p.Provider = provider
+	markForReview(p, review)
 
 	if err := s.payments.Create(ctx, tx, p); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: create payment: %w", err)
 	}
+	if err := s.captureHold(ctx, tx, req.HoldID, p, now); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+	}
 
 	if err := s.writeExternalLedgerEntries(ctx, tx, p, sender, outgoingAcct); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
 	}
 
-	if err := s.writePaymentEvent(ctx, tx, p.ID, domain.PaymentEventTypeCreated, req.SenderUserID, now); err != nil {
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCreated, req.SenderUserID, now); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+	}
+	if err := s.writeReviewEvent(ctx, tx, p, review, now); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
 	}
 
@@ -143,87 +302,105 @@ func (s *Service) executeSameCurrencyExternalPayout(ctx context.Context, req Ext
 	if err := s.accounts.UpdateBalance(ctx, tx, outgoingAcct.ID, outgoingAcct.Balance+req.Amount, outgoingAcct.Version+1); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: update outgoing: %w", err)
 	}
+	if req.sweep {
+		if err := s.accounts.Close(ctx, tx, sender.ID); err != nil {
+			return nil, fmt.Errorf("executeSameCurrencyExternalPayout: %w", err)
+		}
+	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyExternalPayout: commit: %w", err)
 	}
 
 	return p, nil
 }
 
-func buildExternalPayment(req ExternalPayoutRequest, senderID uuid.UUID, destAmount int64, exchangeRate *decimal.Decimal, feeCurrency *domain.Currency, now time.Time) *domain.Payment {
+func buildExternalPayment(req ExternalPayoutRequest, senderID uuid.UUID, dest domain.Money, exchangeRate *decimal.Decimal, fee domain.Money, now time.Time) *domain.Payment {
 	return &domain.Payment{
-		ID:              uuid.New(),
-		IdempotencyKey:  req.IdempotencyKey,
-		Type:            domain.PaymentTypeExternalPayout,
-		Status:          domain.PaymentStatusPending,
-		SourceAccountID: senderID,
-		DestIBAN:        &req.DestIBAN,
-		DestBankName:    &req.DestBankName,
-		SourceAmount:    req.Amount,
-		SourceCurrency:  req.SourceCurrency,
-		DestAmount:      destAmount,
-		DestCurrency:    req.DestCurrency,
-		ExchangeRate:    exchangeRate,
-		FeeCurrency:     feeCurrency,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+		ID:               uuid.New(),
+		IdempotencyKey:   req.IdempotencyKey,
+		Type:             payoutType(req),
+		Status:           domain.PaymentStatusPending,
+		SourceAccountID:  senderID,
+		DestIBAN:         &req.DestIBAN,
+		DestBankName:     &req.DestBankName,
+		Source:           domain.NewMoney(req.Amount, req.SourceCurrency),
+		Dest:             dest,
+		ExchangeRate:     exchangeRate,
+		Fee:              fee,
+		Refunded:         domain.NewMoney(0, dest.Currency),
+		RetryOfPaymentID: req.RetryOf,
+		BeneficiaryID:    req.BeneficiaryID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 }
 
-func (s *Service) writeExternalLedgerEntries(ctx context.Context, tx *sql.Tx, p *domain.Payment, sender, outgoing *domain.Account) error {
-	debit := &domain.LedgerEntry{
-		ID:            uuid.New(),
-		PaymentID:     p.ID,
-		AccountID:     sender.ID,
-		EntryType:     domain.EntryTypeDebit,
-		Amount:        p.SourceAmount,
-		Currency:      p.SourceCurrency,
-		BalanceBefore: sender.Balance,
-		BalanceAfter:  sender.Balance - p.SourceAmount,
-		CreatedAt:     p.CreatedAt,
-	}
-	if err := s.ledger.Create(ctx, tx, debit); err != nil {
-		return fmt.Errorf("writeExternalLedgerEntries: debit: %w", err)
-	}
-
-	credit := &domain.LedgerEntry{
-		ID:            uuid.New(),
-		PaymentID:     p.ID,
-		AccountID:     outgoing.ID,
-		EntryType:     domain.EntryTypeCredit,
-		Amount:        p.DestAmount,
-		Currency:      p.DestCurrency,
-		BalanceBefore: outgoing.Balance,
-		BalanceAfter:  outgoing.Balance + p.DestAmount,
-		CreatedAt:     p.CreatedAt,
-	}
-	if err := s.ledger.Create(ctx, tx, credit); err != nil {
-		return fmt.Errorf("writeExternalLedgerEntries: credit: %w", err)
+func (s *Service) writeExternalLedgerEntries(ctx context.Context, tx pgx.Tx, p *domain.Payment, sender, outgoing *domain.Account) error {
+	debit, err := domain.NewLedgerEntry(p.ID, sender, domain.EntryTypeDebit, domain.EntryCategoryPrincipal, p.Source, p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("writeExternalLedgerEntries: sender: %w", err)
+	}
+	credit, err := domain.NewLedgerEntry(p.ID, outgoing, domain.EntryTypeCredit, domain.EntryCategoryPrincipal, p.Dest, p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("writeExternalLedgerEntries: outgoing: %w", err)
+	}
+	if err := s.ledger.CreateBatch(ctx, tx, []*domain.LedgerEntry{debit, credit}); err != nil {
+		return fmt.Errorf("writeExternalLedgerEntries: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) submitToProvider(ctx context.Context, p *domain.Payment) {
-	if s.provider == nil {
+func (s *Service) routeProvider(source, dest domain.Currency) (Provider, error) {
+	if s.providers == nil {
+		return nil, nil
+	}
+	provider, err := s.providers.Route(source, dest)
+	if err != nil {
+		return nil, fmt.Errorf("routeProvider: %w", err)
+	}
+	return provider, nil
+}
+
+func providerName(p Provider) *string {
+	if p == nil {
+		return nil
+	}
+	name := p.Name()
+	return &name
+}
+
+func (s *Service) submitToProvider(ctx context.Context, provider Provider, p *domain.Payment) {
+	if provider == nil {
 		return
 	}
 
 	log := logging.FromContext(ctx)
-	err := s.provider.SubmitPayment(ctx, ProviderRequest{
+	deadline.Stage(ctx, "provider:"+provider.Name())
+	err := provider.SubmitPayment(ctx, ProviderRequest{
 		PaymentID:    p.ID,
-		Amount:       p.DestAmount,
-		Currency:     p.DestCurrency,
+		Amount:       p.Dest.Amount,
+		Currency:     p.Dest.Currency,
 		DestIBAN:     stringVal(p.DestIBAN),
 		DestBankName: stringVal(p.DestBankName),
 	})
 	if err != nil {
-		log.Warn("failed to submit to provider, payment stays pending",
+		log.Warn("failed to submit to provider, payment stays pending for the submission retrier",
 			"payment_id", p.ID,
+			"provider", provider.Name(),
+			"class", domain.ProviderErrorClassOf(err),
 			"error", err,
 		)
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := s.payments.MarkSubmitted(ctx, p.ID, now); err != nil {
+		log.Warn("failed to record provider submission time", "payment_id", p.ID, "error", err)
+		return
 	}
+	p.SubmittedAt = &now
 }
 
 func stringVal(s *string) string {
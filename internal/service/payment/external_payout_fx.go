@@ -2,16 +2,21 @@ package payment
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
 )
 
-func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID) (*domain.Payment, error) {
-	conversion, err := s.fx.Convert(ctx, req.Amount, req.SourceCurrency, req.DestCurrency)
+func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req ExternalPayoutRequest, senderID uuid.UUID, provider *string, review *ReviewFlag) (*domain.Payment, error) {
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
+	}
+	conversion, err := s.convert(ctx, req.Amount, req.SourceCurrency, req.DestCurrency, tier)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
@@ -28,14 +33,18 @@ func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req Ex
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: outgoing %s: %w", req.DestCurrency, err)
 	}
+	revenue, err := s.getSystemAccount(ctx, domain.AccountTypeRevenue, req.DestCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: revenue %s: %w", req.DestCurrency, err)
+	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := repository.Begin(ctx, s.db)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: begin tx: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
-	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, fxPoolSource.ID, fxPoolDest.ID, outgoing.ID)
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, fxPoolSource.ID, fxPoolDest.ID, outgoing.ID, revenue.ID)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
@@ -44,32 +53,48 @@ func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req Ex
 	fxSrc := locked[fxPoolSource.ID]
 	fxDst := locked[fxPoolDest.ID]
 	outgoingAcct := locked[outgoing.ID]
+	rev := locked[revenue.ID]
 
 	if err := verifyAccountActive(sender, "sender"); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
-	if sender.Balance < req.Amount {
-		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", domain.ErrInsufficientFunds)
+	if err := s.checkSpendable(ctx, tx, sender, req.HoldID, domain.NewMoney(req.Amount, req.SourceCurrency)); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
+	}
+	if fxDst.Balance < conversion.Dest.Amount+conversion.Fee.Amount {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", domain.NewDomainError(domain.ErrInsufficientFunds, "fx_pool", req.DestCurrency))
 	}
-	if fxDst.Balance < conversion.DestAmount {
-		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: fx pool %s: %w", req.DestCurrency, domain.ErrInsufficientFunds)
+
+	if err := s.checkPeriodLimits(ctx, tx, senderID, domain.NewMoney(req.Amount, req.SourceCurrency), tier); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
 
 	now := time.Now().UTC()
 	exchangeRate := conversion.ExchangeRate
-	feeCurrency := req.DestCurrency
-	p := buildExternalPayment(req, senderID, conversion.DestAmount, &exchangeRate, &feeCurrency, now)
-	p.FeeAmount = conversion.FeeAmount
+	p := buildExternalPayment(req, senderID, conversion.Dest, &exchangeRate, conversion.Fee, now)
+	midMarketRate := conversion.MidMarketRate
+	slippage := conversion.Slippage
+	p.MidMarketRate = &midMarketRate
+	p.Slippage = &slippage
+	p.UserTier = &tier
+	p.Provider = provider
+	markForReview(p, review)
 
 	if err := s.payments.Create(ctx, tx, p); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: create payment: %w", err)
 	}
+	if err := s.captureHold(ctx, tx, req.HoldID, p, now); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
+	}
 
-	if err := s.writeCrossCurrencyExternalLedgerEntries(ctx, tx, p, sender, fxSrc, fxDst, outgoingAcct); err != nil {
+	if err := s.writeCrossCurrencyExternalLedgerEntries(ctx, tx, p, sender, fxSrc, fxDst, outgoingAcct, rev); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
 
-	if err := s.writePaymentEvent(ctx, tx, p.ID, domain.PaymentEventTypeCreated, req.SenderUserID, now); err != nil {
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCreated, req.SenderUserID, now); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
+	}
+	if err := s.writeReviewEvent(ctx, tx, p, review, now); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
 	}
 
@@ -79,14 +104,17 @@ func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req Ex
 	if err := s.accounts.UpdateBalance(ctx, tx, fxSrc.ID, fxSrc.Balance+req.Amount, fxSrc.Version+1); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: update fx source: %w", err)
 	}
-	if err := s.accounts.UpdateBalance(ctx, tx, fxDst.ID, fxDst.Balance-conversion.DestAmount, fxDst.Version+1); err != nil {
+	if err := s.accounts.UpdateBalance(ctx, tx, fxDst.ID, fxDst.Balance-conversion.Dest.Amount-conversion.Fee.Amount, fxDst.Version+1); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: update fx dest: %w", err)
 	}
-	if err := s.accounts.UpdateBalance(ctx, tx, outgoingAcct.ID, outgoingAcct.Balance+conversion.DestAmount, outgoingAcct.Version+1); err != nil {
+	if err := s.creditFee(ctx, tx, rev, p.Fee); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: %w", err)
+	}
+	if err := s.accounts.UpdateBalance(ctx, tx, outgoingAcct.ID, outgoingAcct.Balance+conversion.Dest.Amount, outgoingAcct.Version+1); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: update outgoing: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyExternalPayout: commit: %w", err)
 	}
 
@@ -95,44 +123,40 @@ func (s *Service) executeCrossCurrencyExternalPayout(ctx context.Context, req Ex
 
 func (s *Service) writeCrossCurrencyExternalLedgerEntries(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx pgx.Tx,
 	p *domain.Payment,
-	sender, fxPoolSource, fxPoolDest, outgoing *domain.Account,
+	sender, fxPoolSource, fxPoolDest, outgoing, revenue *domain.Account,
 ) error {
-	entries := []struct {
+	type ledgerLine struct {
 		account   *domain.Account
 		entryType domain.EntryType
-		amount    int64
-		currency  domain.Currency
-	}{
-		{sender, domain.EntryTypeDebit, p.SourceAmount, p.SourceCurrency},
-		{fxPoolSource, domain.EntryTypeCredit, p.SourceAmount, p.SourceCurrency},
-		{fxPoolDest, domain.EntryTypeDebit, p.DestAmount, p.DestCurrency},
-		{outgoing, domain.EntryTypeCredit, p.DestAmount, p.DestCurrency},
-	}
-
-	for _, e := range entries {
-		var newBal int64
-		if e.entryType == domain.EntryTypeDebit {
-			newBal = e.account.Balance - e.amount
-		} else {
-			newBal = e.account.Balance + e.amount
-		}
+		category  domain.EntryCategory
+		amount    domain.Money
+	}
+	fxPoolDebit, err := p.Dest.Add(p.Fee)
+	if err != nil {
+		return fmt.Errorf("writeCrossCurrencyExternalLedgerEntries: %w", err)
+	}
+	entries := []ledgerLine{
+		{sender, domain.EntryTypeDebit, domain.EntryCategoryPrincipal, p.Source},
+		{fxPoolSource, domain.EntryTypeCredit, domain.EntryCategoryFXSpread, p.Source},
+		{fxPoolDest, domain.EntryTypeDebit, domain.EntryCategoryFXSpread, fxPoolDebit},
+		{outgoing, domain.EntryTypeCredit, domain.EntryCategoryPrincipal, p.Dest},
+	}
+	if p.Fee.IsPositive() {
+		entries = append(entries, ledgerLine{revenue, domain.EntryTypeCredit, domain.EntryCategoryFee, p.Fee})
+	}
 
-		entry := &domain.LedgerEntry{
-			ID:            uuid.New(),
-			PaymentID:     p.ID,
-			AccountID:     e.account.ID,
-			EntryType:     e.entryType,
-			Amount:        e.amount,
-			Currency:      e.currency,
-			BalanceBefore: e.account.Balance,
-			BalanceAfter:  newBal,
-			CreatedAt:     p.CreatedAt,
-		}
-		if err := s.ledger.Create(ctx, tx, entry); err != nil {
-			return fmt.Errorf("writeCrossCurrencyExternalLedgerEntries: %s %s: %w", e.entryType, e.account.ID, err)
+	batch := make([]*domain.LedgerEntry, len(entries))
+	for i, e := range entries {
+		entry, err := domain.NewLedgerEntry(p.ID, e.account, e.entryType, e.category, e.amount, p.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("writeCrossCurrencyExternalLedgerEntries: %w", err)
 		}
+		batch[i] = entry
+	}
+	if err := s.ledger.CreateBatch(ctx, tx, batch); err != nil {
+		return fmt.Errorf("writeCrossCurrencyExternalLedgerEntries: %w", err)
 	}
 
 	return nil
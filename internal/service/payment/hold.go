@@ -0,0 +1,223 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+type holdRepo interface {
+	Create(ctx context.Context, tx pgx.Tx, h *domain.Hold) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Hold, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Hold, error)
+	ListForAccount(ctx context.Context, accountID uuid.UUID, status domain.HoldStatus, limit, offset int) ([]domain.Hold, error)
+	SumActive(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, now time.Time) (int64, error)
+	Capture(ctx context.Context, tx pgx.Tx, id, paymentID uuid.UUID, amount int64, now time.Time) error
+	Release(ctx context.Context, id uuid.UUID, now time.Time) (*domain.Hold, error)
+}
+
+type PlaceHoldRequest struct {
+	UserID    uuid.UUID
+	AccountID uuid.UUID
+	Amount    int64
+	Reference string
+	// TTL defaults to HOLD_DEFAULT_TTL_M and is capped at HOLD_MAX_TTL_M.
+	TTL time.Duration
+}
+
+// PlaceHold reserves funds on one of the user's accounts. Nothing moves:
+// the hold only lowers what the account can spend until a payment captures
+// it, it's released, or it expires.
+func (s *Service) PlaceHold(ctx context.Context, req PlaceHoldRequest) (*domain.Hold, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("PlaceHold: %w", domain.ErrInvalidAmount)
+	}
+	if s.holds == nil {
+		return nil, fmt.Errorf("PlaceHold: holds not configured: %w", domain.ErrInvalidRequest)
+	}
+
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = time.Duration(s.config.HoldDefaultTTLM) * time.Minute
+	}
+	if maxTTL := time.Duration(s.config.HoldMaxTTLM) * time.Minute; maxTTL > 0 && ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("PlaceHold: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	acct, err := s.accounts.GetForUpdate(ctx, tx, req.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("PlaceHold: %w", err)
+	}
+	if acct.UserID != req.UserID || acct.AccountType != domain.AccountTypeUser {
+		return nil, fmt.Errorf("PlaceHold: %w", domain.ErrNotFound)
+	}
+	if err := verifyAccountActive(acct, "account"); err != nil {
+		return nil, fmt.Errorf("PlaceHold: %w", err)
+	}
+	if err := s.checkSpendable(ctx, tx, acct, nil, domain.NewMoney(req.Amount, acct.Currency)); err != nil {
+		return nil, fmt.Errorf("PlaceHold: %w", err)
+	}
+
+	now := time.Now().UTC()
+	h := &domain.Hold{
+		ID:        uuid.New(),
+		AccountID: acct.ID,
+		Amount:    req.Amount,
+		Currency:  acct.Currency,
+		Status:    domain.HoldStatusActive,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if ref := strings.TrimSpace(req.Reference); ref != "" {
+		h.Reference = &ref
+	}
+	if err := s.holds.Create(ctx, tx, h); err != nil {
+		return nil, fmt.Errorf("PlaceHold: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PlaceHold: commit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("hold placed",
+		"hold_id", h.ID,
+		"account_id", h.AccountID,
+		"amount", h.Amount,
+		"expires_at", h.ExpiresAt,
+	)
+	return h, nil
+}
+
+// AccountCurrency returns the currency of one of the user's accounts, so a
+// hold amount can be read in it before the hold is placed.
+func (s *Service) AccountCurrency(ctx context.Context, accountID, userID uuid.UUID) (domain.Currency, error) {
+	acct, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("AccountCurrency: %w", err)
+	}
+	if acct.UserID != userID || acct.AccountType != domain.AccountTypeUser {
+		return "", fmt.Errorf("AccountCurrency: %w", domain.ErrNotFound)
+	}
+	return acct.Currency, nil
+}
+
+// ReleaseHold frees a hold on one of the user's accounts.
+func (s *Service) ReleaseHold(ctx context.Context, holdID, userID uuid.UUID) (*domain.Hold, error) {
+	if _, err := s.ownHold(ctx, holdID, userID); err != nil {
+		return nil, fmt.Errorf("ReleaseHold: %w", err)
+	}
+	h, err := s.holds.Release(ctx, holdID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("ReleaseHold: %w", err)
+	}
+	logging.FromContext(ctx).Info("hold released", "hold_id", h.ID, "account_id", h.AccountID)
+	return h, nil
+}
+
+func (s *Service) ListHolds(ctx context.Context, accountID, userID uuid.UUID, status domain.HoldStatus, limit, offset int) ([]domain.Hold, error) {
+	if s.holds == nil {
+		return nil, nil
+	}
+	acct, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("ListHolds: %w", err)
+	}
+	if acct.UserID != userID {
+		return nil, fmt.Errorf("ListHolds: %w", domain.ErrNotFound)
+	}
+	holds, err := s.holds.ListForAccount(ctx, accountID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ListHolds: %w", err)
+	}
+	return holds, nil
+}
+
+func (s *Service) ownHold(ctx context.Context, holdID, userID uuid.UUID) (*domain.Hold, error) {
+	if s.holds == nil {
+		return nil, fmt.Errorf("ownHold: %w", domain.ErrNotFound)
+	}
+	h, err := s.holds.GetByID(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("ownHold: %w", err)
+	}
+	acct, err := s.accounts.GetByID(ctx, h.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("ownHold: %w", err)
+	}
+	if acct.UserID != userID {
+		return nil, fmt.Errorf("ownHold: %w", domain.ErrNotFound)
+	}
+	return h, nil
+}
+
+// checkSpendable is the insufficient-funds check for debits. The account
+// must already be locked in tx. Live holds are subtracted from the balance,
+// except holdID, which the debit is about to capture; that hold must be
+// live, on this account and at least amount.
+func (s *Service) checkSpendable(ctx context.Context, tx pgx.Tx, acct *domain.Account, holdID *uuid.UUID, amount domain.Money) error {
+	if amount.Currency != acct.Currency {
+		return fmt.Errorf("checkSpendable: spend %s from %s account: %w", amount.Currency, acct.Currency, domain.ErrCurrencyMismatch)
+	}
+	available := acct.Balance
+
+	if s.holds != nil {
+		now := time.Now().UTC()
+		held, err := s.holds.SumActive(ctx, tx, acct.ID, now)
+		if err != nil {
+			return fmt.Errorf("checkSpendable: %w", err)
+		}
+		available -= held
+
+		if holdID != nil {
+			h, err := s.holds.GetForUpdate(ctx, tx, *holdID)
+			if err != nil {
+				return fmt.Errorf("checkSpendable: hold: %w", err)
+			}
+			if h.AccountID != acct.ID {
+				return fmt.Errorf("checkSpendable: hold is on another account: %w", domain.ErrNotFound)
+			}
+			if !h.IsLive(now) {
+				return fmt.Errorf("checkSpendable: %w", domain.NewDomainError(domain.ErrHoldNotActive, "hold_id", h.ID, "status", h.Status))
+			}
+			if amount.Amount > h.Amount {
+				return fmt.Errorf("checkSpendable: %w", domain.NewDomainError(domain.ErrHoldAmountExceeded,
+					"hold_id", h.ID, "hold_amount", domain.NewMoney(h.Amount, h.Currency).Decimal(), "amount", amount.Decimal()))
+			}
+			available += h.Amount
+		}
+	} else if holdID != nil {
+		return fmt.Errorf("checkSpendable: hold: %w", domain.ErrNotFound)
+	}
+
+	if available < amount.Amount {
+		return fmt.Errorf("checkSpendable: %w", domain.NewDomainError(domain.ErrInsufficientFunds,
+			"account_id", acct.ID, "currency", acct.Currency, "available", domain.NewMoney(available, acct.Currency).Decimal(), "amount", amount.Decimal()))
+	}
+	return nil
+}
+
+// captureHold marks holdID as used by p. It runs in the same transaction as
+// the debit that checkSpendable allowed, after the payment row exists.
+func (s *Service) captureHold(ctx context.Context, tx pgx.Tx, holdID *uuid.UUID, p *domain.Payment, now time.Time) error {
+	if holdID == nil {
+		return nil
+	}
+	if err := s.holds.Capture(ctx, tx, *holdID, p.ID, p.Source.Amount, now); err != nil {
+		return fmt.Errorf("captureHold: %w", err)
+	}
+	return nil
+}
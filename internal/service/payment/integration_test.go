@@ -2,11 +2,15 @@ package payment_test
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -18,22 +22,31 @@ import (
 	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
 )
 
-func setupPaymentService(t *testing.T, db *sql.DB) *payment.Service {
+func setupPaymentService(t *testing.T, db *pgxpool.Pool) *payment.Service {
 	t.Helper()
+	return newPaymentService(db, &config.Config{
+		TxLimits: map[string]int64{"USD": 10_000_000, "EUR": 9_000_000, "GBP": 8_000_000},
+	})
+}
+
+func newPaymentService(db *pgxpool.Pool, cfg *config.Config) *payment.Service {
 	return payment.NewService(
 		repository.NewPaymentRepository(db),
 		repository.NewAccountRepository(db),
 		repository.NewLedgerRepository(db),
 		repository.NewPaymentEventRepository(db),
 		repository.NewUserRepository(db),
-		fx.NewRateService(0.005),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		nil,
+		nil,
+		nil,
 		nil,
 		db,
-		&config.Config{
-			TxLimitUSD: 10_000_000,
-			TxLimitEUR: 9_000_000,
-			TxLimitGBP: 8_000_000,
-		},
+		cfg,
 	)
 }
 
@@ -59,8 +72,8 @@ func TestSameCurrencyTransfer_HappyPath(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, domain.PaymentStatusCompleted, p.Status)
 	assert.Equal(t, domain.PaymentTypeInternalTransfer, p.Type)
-	assert.Equal(t, int64(3000), p.SourceAmount)
-	assert.Equal(t, int64(3000), p.DestAmount)
+	assert.Equal(t, int64(3000), p.Source.Amount)
+	assert.Equal(t, int64(3000), p.Dest.Amount)
 	assert.NotNil(t, p.CompletedAt)
 
 	assert.Equal(t, int64(7000), testutil.GetAccountBalance(t, db, senderAcct.ID))
@@ -87,6 +100,226 @@ func TestSameCurrencyTransfer_HappyPath(t *testing.T) {
 	assert.Equal(t, domain.PaymentEventTypeCompleted, events[0].EventType)
 }
 
+func TestGetPaymentForUser_EitherParty(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_gp")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_gp")
+	outsider := testutil.SeedTestUser(t, db, "outsider@test.com", "Outsider", "outsider_gp")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_gp",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              1000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, dir, err := svc.GetPaymentForUser(ctx, p.ID, sender.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentDirectionSent, dir)
+
+	got, dir, err := svc.GetPaymentForUser(ctx, p.ID, recipient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentDirectionReceived, dir)
+	assert.Equal(t, p.ID, got.ID)
+
+	_, _, err = svc.GetPaymentForUser(ctx, p.ID, outsider.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestGetPaymentByIdempotencyKey(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_ik")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_ik")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	key := uuid.NewString()
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_ik",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              1000,
+		IdempotencyKey:      key,
+	})
+	require.NoError(t, err)
+
+	got, err := svc.GetPaymentByIdempotencyKey(ctx, sender.ID, key)
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, got.ID)
+
+	_, err = svc.GetPaymentByIdempotencyKey(ctx, recipient.ID, key)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "the key belongs to the sender")
+
+	_, err = svc.GetPaymentByIdempotencyKey(ctx, sender.ID, uuid.NewString())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestLedgerByAccount_KeysetPages(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_kp")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_kp")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	for i := 0; i < 5; i++ {
+		_, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+			SenderUserID:        sender.ID,
+			RecipientUniqueName: "recipient_kp",
+			SourceCurrency:      domain.CurrencyUSD,
+			DestCurrency:        domain.CurrencyUSD,
+			Amount:              100,
+			IdempotencyKey:      uuid.NewString(),
+		})
+		require.NoError(t, err)
+	}
+
+	// Three entries in the same instant, so pages have to split a tie.
+	_, err := db.Exec(ctx,
+		`UPDATE ledger_entries SET created_at = (SELECT MAX(created_at) FROM ledger_entries WHERE account_id = $1)
+		WHERE id IN (SELECT id FROM ledger_entries WHERE account_id = $1 ORDER BY created_at LIMIT 3)`,
+		senderAcct.ID)
+	require.NoError(t, err)
+
+	repo := repository.NewLedgerRepository(db)
+	var (
+		seen   []domain.LedgerEntry
+		before *domain.LedgerCursor
+		pages  []bool
+	)
+	for {
+		page, more, err := repo.GetByAccountID(ctx, senderAcct.ID, before, 2)
+		require.NoError(t, err)
+		seen = append(seen, page...)
+		pages = append(pages, more)
+		if !more {
+			break
+		}
+		c := page[len(page)-1].Cursor()
+		before = &c
+	}
+
+	assert.Equal(t, []bool{true, true, false}, pages)
+	require.Len(t, seen, 5)
+	ids := make(map[uuid.UUID]bool)
+	for i, e := range seen {
+		ids[e.ID] = true
+		if i > 0 {
+			prev := seen[i-1]
+			assert.False(t, e.CreatedAt.After(prev.CreatedAt), "newest first")
+		}
+	}
+	assert.Len(t, ids, 5, "no entry repeated or skipped across pages")
+}
+
+func TestTransfer_DefaultAccountPrecedence(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_def")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_def")
+	senderUSD := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	senderEUR := testutil.SeedTestAccount(t, db, sender.ID, "EUR", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+	testutil.SeedTestAccount(t, db, recipient.ID, "EUR", 0)
+
+	req := payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_def",
+		Amount:              1000,
+		IdempotencyKey:      uuid.NewString(),
+	}
+	_, err := svc.CreateInternalTransfer(ctx, req)
+	require.ErrorIs(t, err, domain.ErrDefaultAccountNotSet)
+
+	users := repository.NewUserRepository(db)
+	require.NoError(t, users.SetDefaultAccount(ctx, sender.ID, &senderEUR.ID))
+
+	p, err := svc.CreateInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyEUR, p.Source.Currency, "omitted source currency comes from the default account")
+	assert.Equal(t, domain.CurrencyEUR, p.Dest.Currency, "omitted dest currency means no conversion")
+	assert.Equal(t, int64(9000), testutil.GetAccountBalance(t, db, senderEUR.ID))
+
+	req.IdempotencyKey = uuid.NewString()
+	req.SourceCurrency = domain.CurrencyUSD
+	p, err = svc.CreateInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyUSD, p.Source.Currency, "an explicit source currency wins over the default")
+	assert.Equal(t, int64(9000), testutil.GetAccountBalance(t, db, senderUSD.ID))
+}
+
+func TestTransfer_PicksRecipientAccount(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_pick")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_pick")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	req := payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_pick",
+		SourceCurrency:      domain.CurrencyUSD,
+		Amount:              1000,
+		IdempotencyKey:      uuid.NewString(),
+	}
+	recipientEUR := testutil.SeedTestAccount(t, db, recipient.ID, "EUR", 0)
+	_, err := svc.PreviewInternalTransfer(ctx, req)
+	require.ErrorIs(t, err, domain.ErrAccountNotFound, "no USD account and no default to fall back on")
+
+	users := repository.NewUserRepository(db)
+	require.NoError(t, users.SetDefaultAccount(ctx, recipient.ID, &recipientEUR.ID))
+
+	preview, err := svc.PreviewInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, payment.DestinationDefaultAccount, preview.Path)
+	assert.Equal(t, domain.CurrencyEUR, preview.Dest.Currency)
+	require.NotNil(t, preview.ExchangeRate)
+
+	p, err := svc.CreateInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyEUR, p.Dest.Currency)
+	assert.Equal(t, preview.Dest.Amount, p.Dest.Amount)
+	assert.Equal(t, preview.Dest.Amount, testutil.GetAccountBalance(t, db, recipientEUR.ID))
+
+	// Once the recipient holds the source currency, nothing is converted.
+	recipientUSD := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+	preview, err = svc.PreviewInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, payment.DestinationSameCurrency, preview.Path)
+	assert.Nil(t, preview.ExchangeRate)
+
+	req.IdempotencyKey = uuid.NewString()
+	p, err = svc.CreateInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CurrencyUSD, p.Dest.Currency)
+	assert.Equal(t, int64(1000), testutil.GetAccountBalance(t, db, recipientUSD.ID))
+
+	// Naming the currency pins the account.
+	req.DestCurrency = domain.CurrencyEUR
+	preview, err = svc.PreviewInternalTransfer(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, payment.DestinationRequested, preview.Path)
+	assert.Equal(t, domain.CurrencyEUR, preview.Dest.Currency)
+}
+
 func TestSameCurrencyTransfer_InsufficientFunds(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	svc := setupPaymentService(t, db)
@@ -111,11 +344,72 @@ func TestSameCurrencyTransfer_InsufficientFunds(t *testing.T) {
 	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, recipientAcct.ID))
 
 	var count int
-	err = db.QueryRow(`SELECT COUNT(*) FROM ledger_entries WHERE account_id = $1`, senderAcct.ID).Scan(&count)
+	err = db.QueryRow(context.Background(), `SELECT COUNT(*) FROM ledger_entries WHERE account_id = $1`, senderAcct.ID).Scan(&count)
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
 }
 
+func TestSameCurrencyTransfer_DailyLimit(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	svc := payment.NewService(
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewPaymentEventRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		db,
+		&config.Config{
+			TxLimits:      map[string]int64{"USD": 10_000_000},
+			DailyLimits:   map[string]int64{"USD": 5000},
+			MonthlyLimits: map[string]int64{"USD": 100_000},
+		},
+	)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_dl")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_dl")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	transfer := func(amount int64) error {
+		_, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+			SenderUserID:        sender.ID,
+			RecipientUniqueName: "recipient_dl",
+			SourceCurrency:      domain.CurrencyUSD,
+			DestCurrency:        domain.CurrencyUSD,
+			Amount:              amount,
+			IdempotencyKey:      uuid.NewString(),
+		})
+		return err
+	}
+
+	require.NoError(t, transfer(3000))
+
+	err := transfer(2500)
+	require.ErrorIs(t, err, domain.ErrPeriodLimitExceeded)
+	var ple *domain.PeriodLimitError
+	require.ErrorAs(t, err, &ple)
+	assert.Equal(t, domain.LimitPeriodDaily, ple.Period)
+	assert.Equal(t, int64(3000), ple.Used)
+	assert.Equal(t, int64(2000), ple.Remaining)
+	assert.Equal(t, int64(7000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+
+	require.NoError(t, transfer(2000))
+
+	_, err = db.Exec(context.Background(), `UPDATE payments SET created_at = now() - interval '2 days' WHERE source_account_id = $1`, senderAcct.ID)
+	require.NoError(t, err)
+	require.NoError(t, transfer(1000), "usage older than 24h no longer counts toward the daily cap")
+}
+
 func TestSameCurrencyTransfer_ConcurrentOverdraft(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	svc := setupPaymentService(t, db)
@@ -165,6 +459,61 @@ func TestSameCurrencyTransfer_ConcurrentOverdraft(t *testing.T) {
 	assert.Equal(t, int64(3000), balance, "balance must be 3000, not negative")
 }
 
+// BenchmarkSameCurrencyTransfer_ConcurrentOverdraft repeats the
+// concurrent-overdraft race and reports p99 latency of a transfer while the
+// two account rows are contended. Balance writes are a single UPDATE ... FROM
+// (VALUES ...), so the row locks are held for one fewer round trip.
+func BenchmarkSameCurrencyTransfer_ConcurrentOverdraft(b *testing.B) {
+	db := testutil.SetupTestDB(b)
+	svc := newPaymentService(db, &config.Config{TxLimits: map[string]int64{"USD": 10_000_000}})
+	ctx := context.Background()
+
+	recipient := testutil.SeedTestUser(b, db, "recipient@test.com", "Recipient", "recipient_bench")
+	testutil.SeedTestAccount(b, db, recipient.ID, "USD", 0)
+
+	latencies := make([]time.Duration, 0, 2*b.N)
+	var mu sync.Mutex
+
+	b.ResetTimer()
+	for i := range b.N {
+		b.StopTimer()
+		name := fmt.Sprintf("sender_bench_%d", i)
+		sender := testutil.SeedTestUser(b, db, name+"@test.com", "Sender", name)
+		testutil.SeedTestAccount(b, db, sender.ID, "USD", 10000)
+		b.StartTimer()
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				_, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+					SenderUserID:        sender.ID,
+					RecipientUniqueName: "recipient_bench",
+					SourceCurrency:      domain.CurrencyUSD,
+					DestCurrency:        domain.CurrencyUSD,
+					Amount:              7000,
+					IdempotencyKey:      uuid.NewString(),
+				})
+				elapsed := time.Since(start)
+				if err != nil && !errors.Is(err, domain.ErrInsufficientFunds) {
+					b.Error(err)
+				}
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+	b.StopTimer()
+
+	slices.Sort(latencies)
+	p99 := latencies[(len(latencies)*99)/100]
+	b.ReportMetric(float64(p99.Microseconds())/1000, "p99-ms")
+}
+
 func TestCrossCurrencyTransfer_HappyPath(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	svc := setupPaymentService(t, db)
@@ -177,6 +526,7 @@ func TestCrossCurrencyTransfer_HappyPath(t *testing.T) {
 
 	fxPoolUSDBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
 	fxPoolEURBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+	revenueEURBefore := testutil.GetAccountBalance(t, db, testutil.RevenueEURID)
 
 	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
 		SenderUserID:        sender.ID,
@@ -190,22 +540,31 @@ func TestCrossCurrencyTransfer_HappyPath(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, domain.PaymentStatusCompleted, p.Status)
 	assert.NotNil(t, p.ExchangeRate)
-	assert.True(t, p.FeeAmount > 0)
-	assert.Equal(t, int64(10000), p.SourceAmount)
-	assert.Equal(t, int64(9154), p.DestAmount)
+	assert.True(t, p.Fee.Amount > 0)
+	assert.Equal(t, int64(10000), p.Source.Amount)
+	assert.Equal(t, int64(9154), p.Dest.Amount)
+
+	stored, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.MidMarketRate)
+	assert.Equal(t, "0.92", stored.MidMarketRate.String())
+	require.NotNil(t, stored.Slippage)
+	assert.Equal(t, domain.NewMoney(46, domain.CurrencyEUR), *stored.Slippage)
 
 	assert.Equal(t, int64(0), testutil.GetAccountBalance(t, db, senderAcct.ID))
 	assert.Equal(t, int64(5000+9154), testutil.GetAccountBalance(t, db, recipientAcct.ID))
 	assert.Equal(t, fxPoolUSDBefore+10000, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
-	assert.Equal(t, fxPoolEURBefore-9154, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, fxPoolEURBefore-9200, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, revenueEURBefore+46, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
 
 	entries := getLedgerEntries(t, db, p.ID)
-	assert.Len(t, entries, 4)
+	assert.Len(t, entries, 5)
 
 	senderDebit := findEntryByAccount(entries, senderAcct.ID, domain.EntryTypeDebit)
 	fxUSDCredit := findEntryByAccount(entries, testutil.FXPoolUSDID, domain.EntryTypeCredit)
 	fxEURDebit := findEntryByAccount(entries, testutil.FXPoolEURID, domain.EntryTypeDebit)
 	recipientCredit := findEntryByAccount(entries, recipientAcct.ID, domain.EntryTypeCredit)
+	feeCredit := findEntryByAccount(entries, testutil.RevenueEURID, domain.EntryTypeCredit)
 
 	require.NotNil(t, senderDebit)
 	assert.Equal(t, int64(10000), senderDebit.BalanceBefore)
@@ -217,12 +576,23 @@ func TestCrossCurrencyTransfer_HappyPath(t *testing.T) {
 
 	require.NotNil(t, fxEURDebit)
 	assert.Equal(t, fxPoolEURBefore, fxEURDebit.BalanceBefore)
-	assert.Equal(t, fxPoolEURBefore-9154, fxEURDebit.BalanceAfter)
+	assert.Equal(t, int64(9200), fxEURDebit.Amount)
+	assert.Equal(t, fxPoolEURBefore-9200, fxEURDebit.BalanceAfter)
 
 	require.NotNil(t, recipientCredit)
 	assert.Equal(t, int64(5000), recipientCredit.BalanceBefore)
 	assert.Equal(t, int64(5000+9154), recipientCredit.BalanceAfter)
 
+	require.NotNil(t, feeCredit)
+	assert.Equal(t, int64(46), feeCredit.Amount)
+	assert.Equal(t, domain.CurrencyEUR, feeCredit.Amount.Currency)
+
+	assert.Equal(t, domain.EntryCategoryPrincipal, senderDebit.Category)
+	assert.Equal(t, domain.EntryCategoryFXSpread, fxUSDCredit.Category)
+	assert.Equal(t, domain.EntryCategoryFXSpread, fxEURDebit.Category)
+	assert.Equal(t, domain.EntryCategoryPrincipal, recipientCredit.Category)
+	assert.Equal(t, domain.EntryCategoryFee, feeCredit.Category)
+
 	events := getPaymentEvents(t, db, p.ID)
 	assert.Len(t, events, 1)
 	assert.Equal(t, domain.PaymentEventTypeCompleted, events[0].EventType)
@@ -239,6 +609,7 @@ func TestCrossCurrencyTransfer_SelfConversion(t *testing.T) {
 
 	fxPoolUSDBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
 	fxPoolEURBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+	revenueEURBefore := testutil.GetAccountBalance(t, db, testutil.RevenueEURID)
 
 	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
 		SenderUserID:        user.ID,
@@ -252,17 +623,65 @@ func TestCrossCurrencyTransfer_SelfConversion(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, domain.PaymentStatusCompleted, p.Status)
 	assert.Equal(t, domain.PaymentTypeInternalTransfer, p.Type)
-	assert.Equal(t, int64(5000), p.SourceAmount)
-	assert.Equal(t, int64(4577), p.DestAmount)
+	assert.Equal(t, int64(5000), p.Source.Amount)
+	assert.Equal(t, int64(4577), p.Dest.Amount)
 	assert.NotNil(t, p.ExchangeRate)
-	assert.True(t, p.FeeAmount > 0)
+	assert.True(t, p.Fee.Amount > 0)
 
 	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, usdAcct.ID))
 	assert.Equal(t, int64(5000+4577), testutil.GetAccountBalance(t, db, eurAcct.ID))
 	assert.Equal(t, fxPoolUSDBefore+5000, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
-	assert.Equal(t, fxPoolEURBefore-4577, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, fxPoolEURBefore-4600, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, revenueEURBefore+23, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
+
+	assert.Equal(t, 5, testutil.CountLedgerEntries(t, db, p.ID))
+}
+
+func TestCrossCurrencyTransfer_TierPricing(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := newPaymentService(db, &config.Config{
+		TxLimits:             map[string]int64{"USD": 100_000, "EUR": 100_000, "GBP": 100_000},
+		FXSpreadPctPlus:      0.002,
+		TierLimitPctPlus:     200,
+		TierLimitPctBusiness: 500,
+	})
+	ctx := context.Background()
 
-	assert.Equal(t, 4, testutil.CountLedgerEntries(t, db, p.ID))
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_tier")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_tier")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 300_000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "EUR", 0)
+
+	_, err := repository.NewUserRepository(db).SetTier(ctx, sender.ID, domain.UserTierPlus)
+	require.NoError(t, err)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_tier",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyEUR,
+		Amount:              10000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(9182), p.Dest.Amount)
+	assert.Equal(t, int64(18), p.Fee.Amount)
+
+	stored, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.UserTier)
+	assert.Equal(t, domain.UserTierPlus, *stored.UserTier)
+
+	// Plus doubles the 100,000 default limit.
+	_, err = svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_tier",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyEUR,
+		Amount:              150_000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
 }
 
 func TestExternalPayout_HappyPath(t *testing.T) {
@@ -288,8 +707,8 @@ func TestExternalPayout_HappyPath(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, domain.PaymentStatusPending, p.Status)
 	assert.Equal(t, domain.PaymentTypeExternalPayout, p.Type)
-	assert.Equal(t, int64(5000), p.SourceAmount)
-	assert.Equal(t, int64(5000), p.DestAmount)
+	assert.Equal(t, int64(5000), p.Source.Amount)
+	assert.Equal(t, int64(5000), p.Dest.Amount)
 	assert.Nil(t, p.CompletedAt)
 
 	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, senderAcct.ID))
@@ -314,7 +733,306 @@ func TestExternalPayout_HappyPath(t *testing.T) {
 	assert.Equal(t, domain.PaymentEventTypeCreated, events[0].EventType)
 }
 
-func getLedgerEntries(t *testing.T, db *sql.DB, paymentID uuid.UUID) []domain.LedgerEntry {
+func TestPaymentIdempotencyKey_AcrossPartitions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repository.NewPaymentRepository(db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_pt")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	otherAcct := testutil.SeedTestAccount(t, db, sender.ID, "EUR", 10000)
+
+	old := time.Now().UTC().AddDate(0, -2, 0)
+	_, err := db.Exec(context.Background(), `SELECT ensure_monthly_partitions('payments', $1, now())`, old)
+	require.NoError(t, err)
+
+	newPayment := func(accountID uuid.UUID, key string, createdAt time.Time) *domain.Payment {
+		return &domain.Payment{
+			ID:              uuid.New(),
+			IdempotencyKey:  key,
+			Type:            domain.PaymentTypeInternalTransfer,
+			Status:          domain.PaymentStatusCompleted,
+			SourceAccountID: accountID,
+			Source:          domain.NewMoney(100, domain.CurrencyUSD),
+			Dest:            domain.NewMoney(100, domain.CurrencyUSD),
+			CreatedAt:       createdAt,
+			UpdatedAt:       createdAt,
+		}
+	}
+	insert := func(p *domain.Payment) error {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+		if err := repo.Create(ctx, tx, p); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	key := uuid.NewString()
+	original := newPayment(senderAcct.ID, key, old)
+	require.NoError(t, insert(original))
+
+	var partition string
+	err = db.QueryRow(context.Background(), `SELECT tableoid::regclass::text FROM payments WHERE id = $1`, original.ID).Scan(&partition)
+	require.NoError(t, err)
+	assert.Equal(t, "payments_"+old.Format("2006_01"), partition)
+
+	err = insert(newPayment(senderAcct.ID, key, time.Now().UTC()))
+	require.ErrorIs(t, err, domain.ErrDuplicateIdempotencyKey)
+
+	found, err := repo.GetByIdempotencyKey(ctx, senderAcct.ID, key)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, found.ID)
+
+	found, err = repo.GetByID(ctx, original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, key, found.IdempotencyKey)
+
+	require.NoError(t, insert(newPayment(otherAcct.ID, key, time.Now().UTC())), "keys are scoped to the source account")
+
+	_, err = repo.GetByIdempotencyKey(ctx, senderAcct.ID, uuid.NewString())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestHold_BlocksSpendingAndIsCapturedByPayment(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_hold")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_hold")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	hold, err := svc.PlaceHold(ctx, payment.PlaceHoldRequest{
+		UserID:    sender.ID,
+		AccountID: senderAcct.ID,
+		Amount:    7000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldStatusActive, hold.Status)
+
+	transfer := func(amount int64, holdID *uuid.UUID) (*domain.Payment, error) {
+		return svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+			SenderUserID:        sender.ID,
+			RecipientUniqueName: "recipient_hold",
+			SourceCurrency:      domain.CurrencyUSD,
+			DestCurrency:        domain.CurrencyUSD,
+			Amount:              amount,
+			IdempotencyKey:      uuid.NewString(),
+			HoldID:              holdID,
+		})
+	}
+
+	_, err = transfer(5000, nil)
+	require.ErrorIs(t, err, domain.ErrInsufficientFunds, "only 3000 is unheld")
+
+	_, err = transfer(8000, &hold.ID)
+	require.ErrorIs(t, err, domain.ErrHoldAmountExceeded)
+
+	p, err := transfer(6000, &hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+
+	holds, err := svc.ListHolds(ctx, senderAcct.ID, sender.ID, domain.HoldStatusCaptured, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, holds, 1)
+	require.NotNil(t, holds[0].PaymentID)
+	assert.Equal(t, p.ID, *holds[0].PaymentID)
+	assert.Equal(t, int64(6000), *holds[0].CapturedAmount)
+
+	_, err = transfer(1000, &hold.ID)
+	require.ErrorIs(t, err, domain.ErrHoldNotActive)
+}
+
+func TestHold_Release(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	owner := testutil.SeedTestUser(t, db, "owner@test.com", "Owner", "owner_hold")
+	other := testutil.SeedTestUser(t, db, "other@test.com", "Other", "other_hold")
+	acct := testutil.SeedTestAccount(t, db, owner.ID, "USD", 5000)
+
+	hold, err := svc.PlaceHold(ctx, payment.PlaceHoldRequest{UserID: owner.ID, AccountID: acct.ID, Amount: 5000})
+	require.NoError(t, err)
+
+	_, err = svc.PlaceHold(ctx, payment.PlaceHoldRequest{UserID: owner.ID, AccountID: acct.ID, Amount: 1})
+	require.ErrorIs(t, err, domain.ErrInsufficientFunds)
+
+	_, err = svc.ReleaseHold(ctx, hold.ID, other.ID)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	released, err := svc.ReleaseHold(ctx, hold.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldStatusReleased, released.Status)
+
+	_, err = svc.ReleaseHold(ctx, hold.ID, owner.ID)
+	require.ErrorIs(t, err, domain.ErrHoldNotActive)
+
+	_, err = svc.PlaceHold(ctx, payment.PlaceHoldRequest{UserID: owner.ID, AccountID: acct.ID, Amount: 5000})
+	require.NoError(t, err)
+}
+
+func TestCloseAccount_SweepsIntoOwnAccount(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	owner := testutil.SeedTestUser(t, db, "owner@test.com", "Owner", "owner_close")
+	usd := testutil.SeedTestAccount(t, db, owner.ID, "USD", 10000)
+	eur := testutil.SeedTestAccount(t, db, owner.ID, "EUR", 0)
+
+	_, err := svc.CloseAccount(ctx, payment.CloseAccountRequest{UserID: owner.ID, AccountID: usd.ID})
+	require.ErrorIs(t, err, domain.ErrSweepRequired)
+
+	res, err := svc.CloseAccount(ctx, payment.CloseAccountRequest{
+		UserID:           owner.ID,
+		AccountID:        usd.ID,
+		SweepToAccountID: &eur.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusClosed, res.Account.Status)
+	assert.Equal(t, int64(0), res.Account.Balance)
+
+	require.NotNil(t, res.Sweep)
+	assert.Equal(t, domain.PaymentTypeSweep, res.Sweep.Type)
+	assert.Equal(t, int64(10000), res.Sweep.Source.Amount)
+	assert.Equal(t, int64(9154), testutil.GetAccountBalance(t, db, eur.ID))
+	assert.Len(t, getLedgerEntries(t, db, res.Sweep.ID), 5)
+
+	_, err = svc.CloseAccount(ctx, payment.CloseAccountRequest{UserID: owner.ID, AccountID: usd.ID})
+	require.ErrorIs(t, err, domain.ErrAccountClosed)
+}
+
+func TestCloseAccount_BlockedByHold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	owner := testutil.SeedTestUser(t, db, "owner@test.com", "Owner", "owner_close_hold")
+	usd := testutil.SeedTestAccount(t, db, owner.ID, "USD", 5000)
+	eur := testutil.SeedTestAccount(t, db, owner.ID, "EUR", 0)
+
+	_, err := svc.PlaceHold(ctx, payment.PlaceHoldRequest{UserID: owner.ID, AccountID: usd.ID, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = svc.CloseAccount(ctx, payment.CloseAccountRequest{
+		UserID:           owner.ID,
+		AccountID:        usd.ID,
+		SweepToAccountID: &eur.ID,
+	})
+	require.ErrorIs(t, err, domain.ErrAccountCloseBlocked)
+	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, usd.ID))
+
+	res, err := svc.CloseAccount(ctx, payment.CloseAccountRequest{UserID: owner.ID, AccountID: eur.ID})
+	require.NoError(t, err, "an empty account closes without a sweep")
+	assert.Nil(t, res.Sweep)
+	assert.Equal(t, domain.AccountStatusClosed, res.Account.Status)
+}
+
+// TestBalancePaths_ConcurrentStress runs the same contended workload through
+// the locked and lockless balance paths. Transfers go both ways between a
+// small set of accounts, so it exercises lock ordering as well as overdraft
+// protection. Throughput and outcome counts are logged for comparison.
+func TestBalancePaths_ConcurrentStress(t *testing.T) {
+	const (
+		users     = 4
+		seed      = int64(20_000)
+		amount    = int64(1_500)
+		workers   = 16
+		perWorker = 15
+	)
+
+	for _, tc := range []struct {
+		name string
+		pct  int
+	}{
+		{"locked", 0},
+		{"lockless", 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			svc := newPaymentService(db, &config.Config{
+				TxLimits:           map[string]int64{"USD": 10_000_000},
+				LocklessBalancePct: tc.pct,
+			})
+			ctx := context.Background()
+
+			ids := make([]uuid.UUID, users)
+			names := make([]string, users)
+			accts := make([]uuid.UUID, users)
+			for i := range users {
+				names[i] = fmt.Sprintf("stress_%s_%d", tc.name, i)
+				u := testutil.SeedTestUser(t, db, names[i]+"@test.com", "Stress", names[i])
+				ids[i] = u.ID
+				accts[i] = testutil.SeedTestAccount(t, db, u.ID, "USD", seed).ID
+			}
+
+			var (
+				wg               sync.WaitGroup
+				mu               sync.Mutex
+				ok, insufficient int
+				unexpected       []error
+			)
+			start := time.Now()
+			for w := range workers {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for n := range perWorker {
+						from := (w + n) % users
+						to := (from + 1 + n%(users-1)) % users
+						_, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+							SenderUserID:        ids[from],
+							RecipientUniqueName: names[to],
+							SourceCurrency:      domain.CurrencyUSD,
+							DestCurrency:        domain.CurrencyUSD,
+							Amount:              amount,
+							IdempotencyKey:      uuid.NewString(),
+						})
+						mu.Lock()
+						switch {
+						case err == nil:
+							ok++
+						case errors.Is(err, domain.ErrInsufficientFunds):
+							insufficient++
+						default:
+							unexpected = append(unexpected, err)
+						}
+						mu.Unlock()
+					}
+				}(w)
+			}
+			wg.Wait()
+			elapsed := time.Since(start)
+
+			t.Logf("%s: %d transfers in %s (%.0f/s), %d ok, %d insufficient funds",
+				tc.name, workers*perWorker, elapsed, float64(workers*perWorker)/elapsed.Seconds(), ok, insufficient)
+			require.Empty(t, unexpected)
+			require.Positive(t, ok)
+
+			var total int64
+			for _, id := range accts {
+				balance := testutil.GetAccountBalance(t, db, id)
+				assert.GreaterOrEqual(t, balance, int64(0))
+				total += balance
+
+				var net int64
+				err := db.QueryRow(context.Background(),
+					`SELECT COALESCE(SUM(CASE entry_type WHEN 'credit' THEN amount ELSE -amount END), 0)
+					 FROM ledger_entries WHERE account_id = $1`, id,
+				).Scan(&net)
+				require.NoError(t, err)
+				assert.Equal(t, seed+net, balance, "balance must match the ledger")
+			}
+			assert.Equal(t, seed*users, total, "transfers between the accounts conserve money")
+		})
+	}
+}
+
+func getLedgerEntries(t *testing.T, db *pgxpool.Pool, paymentID uuid.UUID) []domain.LedgerEntry {
 	t.Helper()
 	repo := repository.NewLedgerRepository(db)
 	entries, err := repo.GetByPaymentID(context.Background(), paymentID)
@@ -322,7 +1040,7 @@ func getLedgerEntries(t *testing.T, db *sql.DB, paymentID uuid.UUID) []domain.Le
 	return entries
 }
 
-func getPaymentEvents(t *testing.T, db *sql.DB, paymentID uuid.UUID) []domain.PaymentEvent {
+func getPaymentEvents(t *testing.T, db *pgxpool.Pool, paymentID uuid.UUID) []domain.PaymentEvent {
 	t.Helper()
 	repo := repository.NewPaymentEventRepository(db)
 	events, err := repo.GetByPaymentID(context.Background(), paymentID)
@@ -347,3 +1065,106 @@ func findEntryByAccount(entries []domain.LedgerEntry, accountID uuid.UUID, entry
 	}
 	return nil
 }
+
+func TestTransferBetweenPools(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	admin := testutil.SeedTestUser(t, db, "treasury@test.com", "Treasury", "treasury_admin")
+	usdBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
+	eurBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+
+	p, err := svc.TransferBetweenPools(ctx, payment.PoolTransferRequest{
+		ActorID:      admin.ID,
+		FromCurrency: domain.CurrencyUSD,
+		ToCurrency:   domain.CurrencyEUR,
+		Amount:       100000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentTypePoolTransfer, p.Type)
+	assert.Equal(t, int64(92000), p.Dest.Amount, "pools trade at mid-market")
+	assert.Equal(t, int64(0), p.Fee.Amount)
+
+	assert.Equal(t, usdBefore-100000, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
+	assert.Equal(t, eurBefore+92000, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Len(t, getLedgerEntries(t, db, p.ID), 2)
+
+	_, err = svc.TransferBetweenPools(ctx, payment.PoolTransferRequest{
+		ActorID:      admin.ID,
+		FromCurrency: domain.CurrencyUSD,
+		ToCurrency:   domain.CurrencyEUR,
+		Amount:       usdBefore,
+	})
+	require.ErrorIs(t, err, domain.ErrInsufficientFunds)
+}
+
+type stubStepUp struct {
+	codes []string
+}
+
+func (s *stubStepUp) Verify(_ context.Context, _ uuid.UUID, code string) error {
+	s.codes = append(s.codes, code)
+	switch code {
+	case "":
+		return domain.ErrTOTPRequired
+	case "123456":
+		return nil
+	default:
+		return domain.ErrInvalidTOTPCode
+	}
+}
+
+func TestExternalPayout_StepUpAboveThreshold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	stepUp := &stubStepUp{}
+	svc := payment.NewService(
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewPaymentEventRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		nil,
+		nil,
+		stepUp,
+		nil,
+		db,
+		&config.Config{
+			TxLimits:    map[string]int64{"USD": 10_000_000},
+			TOTPStepUps: map[string]int64{"USD": 5000},
+		},
+	)
+
+	sender := testutil.SeedTestUser(t, db, "stepup@test.com", "Step", "sender_stepup")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 20000)
+
+	payout := func(amount int64, code string) error {
+		_, err := svc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+			SenderUserID:   sender.ID,
+			SourceCurrency: domain.CurrencyUSD,
+			DestCurrency:   domain.CurrencyUSD,
+			Amount:         amount,
+			DestIBAN:       "DE89370400440532013000",
+			DestBankName:   "Deutsche Bank",
+			IdempotencyKey: uuid.NewString(),
+			TOTPCode:       code,
+		})
+		return err
+	}
+
+	require.NoError(t, payout(4999, ""))
+	assert.Empty(t, stepUp.codes, "payouts under the threshold need no code")
+
+	assert.ErrorIs(t, payout(5000, ""), domain.ErrTOTPRequired)
+	assert.ErrorIs(t, payout(5000, "000000"), domain.ErrInvalidTOTPCode)
+	assert.Equal(t, int64(15001), testutil.GetAccountBalance(t, db, senderAcct.ID), "rejected payouts move no money")
+
+	require.NoError(t, payout(5000, "123456"))
+	assert.Equal(t, int64(10001), testutil.GetAccountBalance(t, db, senderAcct.ID))
+}
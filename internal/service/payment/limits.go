@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func (s *Service) periodLimit(period domain.LimitPeriod, c domain.Currency) int64 {
+	switch period {
+	case domain.LimitPeriodDaily:
+		return s.config.DailyLimit(string(c))
+	case domain.LimitPeriodMonthly:
+		return s.config.MonthlyLimit(string(c))
+	}
+	return 0
+}
+
+// checkPeriodLimits enforces the rolling daily and monthly caps on what an
+// account sends. It must run after the sender row is locked so concurrent
+// payments from the same account see each other's usage. A zero limit
+// disables that period. The limits are in the currency of amount, scaled
+// for the sender's tier.
+func (s *Service) checkPeriodLimits(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, amount domain.Money, tier domain.UserTier) error {
+	now := time.Now().UTC()
+	for _, period := range []domain.LimitPeriod{domain.LimitPeriodDaily, domain.LimitPeriodMonthly} {
+		limit := s.periodLimit(period, amount.Currency) * s.tierLimitPct(tier) / 100
+		if limit <= 0 {
+			continue
+		}
+
+		used, err := s.payments.SumSentSince(ctx, tx, accountID, now.Add(-period.Window()))
+		if err != nil {
+			return fmt.Errorf("checkPeriodLimits: %w", err)
+		}
+
+		if used+amount.Amount > limit {
+			return fmt.Errorf("checkPeriodLimits: %w", &domain.PeriodLimitError{
+				Period:    period,
+				Currency:  amount.Currency,
+				Limit:     limit,
+				Used:      used,
+				Remaining: max(limit-used, 0),
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+const (
+	balancePathLocked   = "locked"
+	balancePathLockless = "lockless"
+)
+
+// useLocklessPath decides whether a same-currency transfer takes the
+// experimental lockless path. Transfers that capture a hold always take the
+// locked path, since the hold has to be read and updated under the lock.
+func (s *Service) useLocklessPath(req InternalTransferRequest) bool {
+	pct := s.config.LocklessBalancePct
+	if pct <= 0 || req.HoldID != nil {
+		return false
+	}
+	return pct >= 100 || rand.IntN(100) < pct
+}
+
+// executeSameCurrencyTransferLockless moves money without locking either
+// account first. Each balance changes in a single conditional UPDATE that
+// refuses to take the sender below its live holds, and chk_accounts_balance
+// backs that up. The ledger's before/after figures come from the balances
+// those updates return, so they stay exact.
+//
+// Both accounts are checked up front with the same rules as the locked path,
+// so a frozen or closed account is reported the same way on either path. The
+// UPDATEs only touch active accounts, which catches a freeze that lands after
+// the check.
+//
+// Period limits and holds are read without the account lock, so concurrent
+// transfers can overshoot a period limit, and a hold placed mid-transfer can
+// be spent past. That is the trade being measured.
+func (s *Service) executeSameCurrencyTransferLockless(ctx context.Context, req InternalTransferRequest, senderID, recipientID uuid.UUID) (*domain.Payment, error) {
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+
+	sender, err := s.accounts.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: sender: %w", err)
+	}
+	recipient, err := s.accounts.GetByID(ctx, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: recipient: %w", err)
+	}
+	if err := verifyAccountActive(sender, "sender"); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+	if err := verifyRecipientActive(recipient); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+
+	var held int64
+	if s.holds != nil {
+		if held, err = s.holds.SumActive(ctx, tx, senderID, now); err != nil {
+			return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+		}
+	}
+
+	if err := s.checkPeriodLimits(ctx, tx, senderID, domain.NewMoney(req.Amount, req.SourceCurrency), tier); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+
+	p := &domain.Payment{
+		ID:              uuid.New(),
+		IdempotencyKey:  req.IdempotencyKey,
+		Type:            domain.PaymentTypeInternalTransfer,
+		Status:          domain.PaymentStatusCompleted,
+		SourceAccountID: senderID,
+		DestAccountID:   &recipientID,
+		Source:          domain.NewMoney(req.Amount, req.SourceCurrency),
+		Dest:            domain.NewMoney(req.Amount, req.DestCurrency),
+		Fee:             domain.NewMoney(0, req.DestCurrency),
+		Refunded:        domain.NewMoney(0, req.DestCurrency),
+		UserTier:        &tier,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CompletedAt:     &now,
+	}
+
+	if err := s.payments.Create(ctx, tx, p); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: create payment: %w", err)
+	}
+
+	// Update in id order, as lockAccountsInOrder does, so two opposite
+	// transfers can't deadlock on the rows the UPDATEs lock.
+	type delta struct {
+		id       uuid.UUID
+		amount   int64
+		currency domain.Currency
+		floor    int64
+		role     string
+	}
+	deltas := []delta{
+		{senderID, -req.Amount, req.SourceCurrency, held, "sender"},
+		{recipientID, req.Amount, req.DestCurrency, 0, "recipient"},
+	}
+	if deltas[1].id.String() < deltas[0].id.String() {
+		deltas[0], deltas[1] = deltas[1], deltas[0]
+	}
+
+	before := make(map[uuid.UUID]*domain.Account, 2)
+	for _, d := range deltas {
+		after, err := s.accounts.ApplyDelta(ctx, tx, d.id, d.amount, d.floor)
+		if err != nil {
+			return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %s: %w", d.role, err)
+		}
+		before[d.id] = &domain.Account{ID: d.id, Currency: d.currency, Balance: after - d.amount}
+	}
+
+	if err := s.writeLedgerEntries(ctx, tx, p, before[senderID], before[recipientID]); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCompleted, req.SenderUserID, now); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransferLockless: commit: %w", err)
+	}
+
+	return p, nil
+}
+
+func (s *Service) recordBalancePath(path string, err error, d time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+
+	outcome := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		outcome = "insufficient_funds"
+	case errors.Is(err, domain.ErrVersionConflict):
+		outcome = "conflict"
+	default:
+		outcome = "error"
+	}
+	s.metrics.BalancePathResult(path, outcome, d)
+}
@@ -0,0 +1,132 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/config"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+// accountStatusErr is what a caller can tell about a frozen or closed
+// account error: which sentinel it is, the freeze reason the owner is given,
+// and the details the API reports.
+type accountStatusErr struct {
+	frozen  bool
+	closed  bool
+	reason  *domain.FreezeReason
+	details map[string]any
+}
+
+func describeAccountStatusErr(err error) accountStatusErr {
+	d := accountStatusErr{
+		frozen: errors.Is(err, domain.ErrAccountFrozen),
+		closed: errors.Is(err, domain.ErrAccountClosed),
+	}
+	var afe *domain.AccountFrozenError
+	if errors.As(err, &afe) {
+		d.reason = &afe.Reason
+	}
+	var de *domain.DomainError
+	if errors.As(err, &de) {
+		d.details = de.Context
+	}
+	return d
+}
+
+// TestBalancePaths_RejectInactiveAccountsAlike sends the same transfer from
+// or to a frozen or closed account down the locked and the lockless path,
+// and expects the same error from both.
+func TestBalancePaths_RejectInactiveAccountsAlike(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := NewService(
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewPaymentEventRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil, nil, nil, nil, nil,
+		db,
+		&config.Config{TxLimits: map[string]int64{"USD": 10_000_000}},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		role   string
+		status string
+		reason *domain.FreezeReason
+	}{
+		{"sender frozen", "sender", "frozen", ptr(domain.FreezeReasonFraudSuspected)},
+		{"sender closed", "sender", "closed", nil},
+		{"recipient frozen", "recipient", "frozen", ptr(domain.FreezeReasonComplianceReview)},
+		{"recipient closed", "recipient", "closed", nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := map[string]func(context.Context, InternalTransferRequest, uuid.UUID, uuid.UUID) (*domain.Payment, error){
+				balancePathLocked:   svc.executeSameCurrencyTransfer,
+				balancePathLockless: svc.executeSameCurrencyTransferLockless,
+			}
+			got := make(map[string]accountStatusErr, len(paths))
+			for path, execute := range paths {
+				name := fmt.Sprintf("inactive_%d_%s", i, path)
+				sender := testutil.SeedTestUser(t, db, name+"_s@test.com", "Sender", name+"_s")
+				recipient := testutil.SeedTestUser(t, db, name+"_r@test.com", "Recipient", name+"_r")
+				senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10_000)
+				recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+				target := senderAcct
+				if tt.role == "recipient" {
+					target = recipientAcct
+				}
+				setAccountStatus(t, db, target.ID, tt.status, tt.reason)
+
+				_, err := execute(ctx, InternalTransferRequest{
+					SenderUserID:   sender.ID,
+					SourceCurrency: domain.CurrencyUSD,
+					DestCurrency:   domain.CurrencyUSD,
+					Amount:         1_000,
+					IdempotencyKey: uuid.NewString(),
+				}, senderAcct.ID, recipientAcct.ID)
+				require.Error(t, err, path)
+				got[path] = describeAccountStatusErr(err)
+
+				assert.Equal(t, int64(10_000), testutil.GetAccountBalance(t, db, senderAcct.ID), path)
+			}
+
+			locked := got[balancePathLocked]
+			assert.Equal(t, tt.status == "frozen", locked.frozen)
+			assert.Equal(t, tt.status == "closed", locked.closed)
+			if tt.role == "sender" {
+				assert.Equal(t, tt.reason, locked.reason, "the owner is told why their account is frozen")
+			} else {
+				assert.Nil(t, locked.reason, "the sender isn't told why the recipient is frozen")
+			}
+			assert.Equal(t, locked, got[balancePathLockless])
+		})
+	}
+}
+
+func setAccountStatus(t *testing.T, db *pgxpool.Pool, id uuid.UUID, status string, reason *domain.FreezeReason) {
+	t.Helper()
+	_, err := db.Exec(context.Background(),
+		`UPDATE accounts SET status = $2, freeze_reason = $3 WHERE id = $1`, id, status, reason)
+	require.NoError(t, err)
+}
+
+func ptr[T any](v T) *T { return &v }
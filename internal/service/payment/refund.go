@@ -0,0 +1,286 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+type RefundRequest struct {
+	PaymentID      uuid.UUID
+	ActorID        uuid.UUID
+	ActorIsAdmin   bool
+	Amount         int64
+	Reason         string
+	IdempotencyKey string
+}
+
+// RefundPayment returns part of a completed internal transfer from the
+// recipient to the sender as a new refund payment linked to the original.
+// Amount is in the currency the recipient received. Refunds accumulate on
+// the original and can't exceed what the recipient received. Cross-currency
+// refunds unwind through the FX pools at the original rate with a matching
+// share of the fee, so refunding everything returns exactly what was sent.
+func (s *Service) RefundPayment(ctx context.Context, req RefundRequest) (*domain.Payment, error) {
+	log := logging.FromContext(ctx)
+
+	original, err := s.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+	if err := s.checkRefundAccess(ctx, original, req.ActorID, req.ActorIsAdmin); err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+	if err := checkRefundable(original, req.Amount); err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+
+	var fxPoolSource, fxPoolDest *domain.Account
+	fee := domain.NewMoney(0, original.Fee.Currency)
+	var revenueID uuid.UUID
+	if original.Source.Currency != original.Dest.Currency {
+		if fxPoolSource, err = s.getSystemAccount(ctx, domain.AccountTypeFXPool, original.Source.Currency); err != nil {
+			return nil, fmt.Errorf("RefundPayment: %w", err)
+		}
+		if fxPoolDest, err = s.getSystemAccount(ctx, domain.AccountTypeFXPool, original.Dest.Currency); err != nil {
+			return nil, fmt.Errorf("RefundPayment: %w", err)
+		}
+		if fee, revenueID, err = s.bookedFee(ctx, original); err != nil {
+			return nil, fmt.Errorf("RefundPayment: %w", err)
+		}
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("RefundPayment: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	total, err := s.payments.AddRefunded(ctx, tx, original.ID, req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+	original.Refunded = domain.NewMoney(total, original.Dest.Currency)
+	prev := total - req.Amount
+
+	// Prorating the running total rather than each refund on its own keeps
+	// rounding from drifting: the shares of a fully refunded payment add up
+	// to the original amounts.
+	refunded := domain.NewMoney(req.Amount, original.Dest.Currency)
+	senderAmount := prorate(original.Source, prev, total, original.Dest.Amount)
+
+	recipient := reversalLeg{*original.DestAccountID, "recipient", domain.EntryTypeDebit, refunded}
+	sender := reversalLeg{original.SourceAccountID, "sender", domain.EntryTypeCredit, senderAmount}
+	legs := []reversalLeg{recipient, sender}
+	if fxPoolSource != nil {
+		feeShare := prorate(fee, prev, total, original.Dest.Amount)
+		poolCredit, err := refunded.Add(feeShare)
+		if err != nil {
+			return nil, fmt.Errorf("RefundPayment: %w", err)
+		}
+		legs = []reversalLeg{recipient}
+		if feeShare.IsPositive() {
+			legs = append(legs, reversalLeg{revenueID, "revenue", domain.EntryTypeDebit, feeShare})
+		}
+		legs = append(legs,
+			reversalLeg{fxPoolDest.ID, "fx pool " + string(original.Dest.Currency), domain.EntryTypeCredit, poolCredit},
+			reversalLeg{fxPoolSource.ID, "fx pool " + string(original.Source.Currency), domain.EntryTypeDebit, senderAmount},
+			sender,
+		)
+	}
+
+	ids := make([]uuid.UUID, len(legs))
+	for i, l := range legs {
+		ids[i] = l.accountID
+	}
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+	for _, l := range legs {
+		acct := locked[l.accountID]
+		if acct.Status == domain.AccountStatusClosed {
+			return nil, fmt.Errorf("RefundPayment: %w", domain.NewDomainError(domain.ErrAccountClosed, "role", l.role))
+		}
+		if l.entryType == domain.EntryTypeDebit {
+			if err := s.checkSpendable(ctx, tx, acct, nil, l.amount); err != nil {
+				return nil, fmt.Errorf("RefundPayment: %s: %w", l.role, err)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	refund := &domain.Payment{
+		ID:                uuid.New(),
+		IdempotencyKey:    req.IdempotencyKey,
+		Type:              domain.PaymentTypeRefund,
+		Status:            domain.PaymentStatusCompleted,
+		SourceAccountID:   *original.DestAccountID,
+		DestAccountID:     &original.SourceAccountID,
+		Source:            refunded,
+		Dest:              senderAmount,
+		Fee:               domain.NewMoney(0, original.Source.Currency),
+		Refunded:          domain.NewMoney(0, original.Source.Currency),
+		RefundOfPaymentID: &original.ID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		CompletedAt:       &now,
+	}
+	if err := s.payments.Create(ctx, tx, refund); err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return nil, fmt.Errorf("RefundPayment: %w", domain.ErrDuplicatePayment)
+		}
+		return nil, fmt.Errorf("RefundPayment: create refund: %w", err)
+	}
+
+	if err := s.writeReversalLegs(ctx, tx, refund, legs, locked); err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+
+	payload, err := events.Marshal(events.NewPaymentRefunded(original, refund, req.Reason, now))
+	if err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+	event := &domain.PaymentEvent{
+		ID:        uuid.New(),
+		PaymentID: original.ID,
+		EventType: domain.PaymentEventTypeRefunded,
+		Actor:     fmt.Sprintf("user:%s", req.ActorID),
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	if err := s.events.Create(ctx, tx, event); err != nil {
+		return nil, fmt.Errorf("RefundPayment: create event: %w", err)
+	}
+	if err := s.writePaymentEvent(ctx, tx, refund, domain.PaymentEventTypeCompleted, req.ActorID, now); err != nil {
+		return nil, fmt.Errorf("RefundPayment: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("RefundPayment: commit: %w", err)
+	}
+
+	s.recordCreated(refund)
+
+	log.Info("payment refunded",
+		"payment_id", original.ID,
+		"refund_payment_id", refund.ID,
+		"actor_id", req.ActorID,
+		"amount", req.Amount,
+		"currency", original.Dest.Currency,
+		"refunded_total", total,
+		"sender_amount", senderAmount.Amount,
+	)
+	return refund, nil
+}
+
+// RefundCurrency returns the currency a refund of the payment is given in,
+// which is the currency the recipient was paid in. Callers who couldn't
+// refund the payment get not found.
+func (s *Service) RefundCurrency(ctx context.Context, paymentID, actorID uuid.UUID, isAdmin bool) (domain.Currency, error) {
+	p, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return "", fmt.Errorf("RefundCurrency: %w", err)
+	}
+	if err := s.checkRefundAccess(ctx, p, actorID, isAdmin); err != nil {
+		return "", fmt.Errorf("RefundCurrency: %w", err)
+	}
+	return p.Dest.Currency, nil
+}
+
+// ListRefunds returns the refunds issued against a payment. The sender, the
+// recipient and staff can see them.
+func (s *Service) ListRefunds(ctx context.Context, paymentID, userID uuid.UUID, isStaff bool) ([]domain.Payment, error) {
+	p, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("ListRefunds: %w", err)
+	}
+	if !isStaff {
+		if err := s.checkPartyAccess(ctx, p, userID); err != nil {
+			return nil, fmt.Errorf("ListRefunds: %w", err)
+		}
+	}
+	refunds, err := s.payments.ListRefunds(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("ListRefunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// checkRefundAccess lets admins refund any payment and otherwise only the
+// recipient. Everyone else gets not found, same as a payment they can't see.
+func (s *Service) checkRefundAccess(ctx context.Context, p *domain.Payment, actorID uuid.UUID, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+	if p.DestAccountID == nil {
+		return fmt.Errorf("checkRefundAccess: %w", domain.ErrNotFound)
+	}
+	acct, err := s.accounts.GetByID(ctx, *p.DestAccountID)
+	if err != nil {
+		return fmt.Errorf("checkRefundAccess: %w", err)
+	}
+	if acct.UserID != actorID {
+		return fmt.Errorf("checkRefundAccess: %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Service) checkPartyAccess(ctx context.Context, p *domain.Payment, userID uuid.UUID) error {
+	ids := []uuid.UUID{p.SourceAccountID}
+	if p.DestAccountID != nil {
+		ids = append(ids, *p.DestAccountID)
+	}
+	for _, id := range ids {
+		acct, err := s.accounts.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("checkPartyAccess: %w", err)
+		}
+		if acct.UserID == userID {
+			return nil
+		}
+	}
+	return fmt.Errorf("checkPartyAccess: %w", domain.ErrNotFound)
+}
+
+func checkRefundable(p *domain.Payment, amount int64) error {
+	if p.Type != domain.PaymentTypeInternalTransfer || p.DestAccountID == nil {
+		return fmt.Errorf("checkRefundable: %w", domain.NewDomainError(domain.ErrPaymentNotRefundable, "type", p.Type))
+	}
+	if p.Status != domain.PaymentStatusCompleted {
+		return fmt.Errorf("checkRefundable: %w", domain.NewDomainError(domain.ErrPaymentNotRefundable, "status", p.Status))
+	}
+	if amount <= 0 {
+		return fmt.Errorf("checkRefundable: %w", domain.NewDomainError(domain.ErrInvalidAmount, "amount", domain.NewMoney(amount, p.Dest.Currency).Decimal()))
+	}
+	refundable, err := p.Dest.Sub(p.Refunded)
+	if err != nil {
+		return fmt.Errorf("checkRefundable: %w", err)
+	}
+	if amount > refundable.Amount {
+		return fmt.Errorf("checkRefundable: %w", domain.NewDomainError(domain.ErrRefundExceedsPayment,
+			"refunded_amount", p.Refunded.Decimal(),
+			"refundable", refundable.Decimal(),
+			"amount", domain.NewMoney(amount, p.Dest.Currency).Decimal()))
+	}
+	return nil
+}
+
+// prorate returns the part of total that moves when the refunded share of
+// whole grows from prev to cur.
+func prorate(total domain.Money, prev, cur, whole int64) domain.Money {
+	share := func(n int64) int64 {
+		v := new(big.Int).Mul(big.NewInt(total.Amount), big.NewInt(n))
+		return v.Quo(v, big.NewInt(whole)).Int64()
+	}
+	return domain.NewMoney(share(cur)-share(prev), total.Currency)
+}
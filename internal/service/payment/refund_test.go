@@ -0,0 +1,160 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestRefundPayment_PartialRefundsAccumulate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_ref")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_ref")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_ref",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              3000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	refund := func(amount int64) (*domain.Payment, error) {
+		return svc.RefundPayment(ctx, payment.RefundRequest{
+			PaymentID:      p.ID,
+			ActorID:        recipient.ID,
+			Amount:         amount,
+			Reason:         "item returned",
+			IdempotencyKey: uuid.NewString(),
+		})
+	}
+
+	first, err := refund(1000)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentTypeRefund, first.Type)
+	assert.Equal(t, domain.PaymentStatusCompleted, first.Status)
+	require.NotNil(t, first.RefundOfPaymentID)
+	assert.Equal(t, p.ID, *first.RefundOfPaymentID)
+	assert.Equal(t, recipientAcct.ID, first.SourceAccountID)
+	assert.Equal(t, 2, testutil.CountLedgerEntries(t, db, first.ID))
+
+	_, err = refund(1500)
+	require.NoError(t, err)
+
+	_, err = refund(501)
+	require.ErrorIs(t, err, domain.ErrRefundExceedsPayment)
+
+	stored, err := svc.GetPaymentByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2500), stored.Refunded.Amount)
+	assert.Equal(t, domain.PaymentStatusCompleted, stored.Status)
+	assert.Equal(t, int64(9500), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(500), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+
+	refunds, err := svc.ListRefunds(ctx, p.ID, sender.ID, false)
+	require.NoError(t, err)
+	assert.Len(t, refunds, 2)
+
+	events := getPaymentEvents(t, db, p.ID)
+	require.Len(t, events, 3)
+	assert.Equal(t, domain.PaymentEventTypeRefunded, events[2].EventType)
+	assert.Equal(t, "user:"+recipient.ID.String(), events[2].Actor)
+
+	_, _, err = svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: sender.ID, Reason: "after refund",
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentNotReversible)
+}
+
+func TestRefundPayment_CrossCurrencyFullRefundRestoresSender(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_reffx")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_reffx")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "EUR", 0)
+
+	poolUSD := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
+	poolEUR := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+	revenueEUR := testutil.GetAccountBalance(t, db, testutil.RevenueEURID)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_reffx",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyEUR,
+		Amount:              5003,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	// Three uneven refunds exercise rounding on every share.
+	first := p.Dest.Amount / 3
+	for _, amount := range []int64{first, first, p.Dest.Amount - 2*first} {
+		r, err := svc.RefundPayment(ctx, payment.RefundRequest{
+			PaymentID:      p.ID,
+			ActorID:        recipient.ID,
+			Amount:         amount,
+			IdempotencyKey: uuid.NewString(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.CurrencyUSD, r.Dest.Currency)
+	}
+
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(0), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+	assert.Equal(t, poolUSD, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
+	assert.Equal(t, poolEUR, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, revenueEUR, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
+}
+
+func TestRefundPayment_OnlyRecipientOrAdmin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_refauth")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_refauth")
+	admin := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_refauth")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_refauth",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              3000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, err = svc.RefundPayment(ctx, payment.RefundRequest{
+		PaymentID: p.ID, ActorID: sender.ID, Amount: 1000, IdempotencyKey: uuid.NewString(),
+	})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = svc.RefundPayment(ctx, payment.RefundRequest{
+		PaymentID: p.ID, ActorID: admin.ID, ActorIsAdmin: true, Amount: 1000, IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, err = svc.ListRefunds(ctx, p.ID, admin.ID, false)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
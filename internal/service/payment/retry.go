@@ -0,0 +1,70 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type RetryPayoutRequest struct {
+	PaymentID      uuid.UUID
+	UserID         uuid.UUID
+	IdempotencyKey string
+	TOTPCode       string
+}
+
+func (s *Service) RetryExternalPayout(ctx context.Context, req RetryPayoutRequest) (*domain.Payment, error) {
+	original, dir, err := s.GetPaymentForUser(ctx, req.PaymentID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("RetryExternalPayout: %w", err)
+	}
+	if dir != domain.PaymentDirectionSent {
+		return nil, fmt.Errorf("RetryExternalPayout: %w", domain.ErrNotFound)
+	}
+
+	if err := checkRetriable(original); err != nil {
+		return nil, fmt.Errorf("RetryExternalPayout: %w", err)
+	}
+
+	p, err := s.CreateExternalPayout(ctx, ExternalPayoutRequest{
+		SenderUserID:   req.UserID,
+		SourceCurrency: original.Source.Currency,
+		DestCurrency:   original.Dest.Currency,
+		Amount:         original.Source.Amount,
+		DestIBAN:       stringVal(original.DestIBAN),
+		DestBankName:   stringVal(original.DestBankName),
+		IdempotencyKey: req.IdempotencyKey,
+		RetryOf:        &original.ID,
+		BeneficiaryID:  original.BeneficiaryID,
+		TOTPCode:       req.TOTPCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("RetryExternalPayout: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("external payout retried",
+		"payment_id", p.ID,
+		"retry_of", original.ID,
+		"original_rate", original.ExchangeRate,
+		"new_rate", p.ExchangeRate,
+	)
+
+	return p, nil
+}
+
+func checkRetriable(p *domain.Payment) error {
+	if p.Type != domain.PaymentTypeExternalPayout {
+		return fmt.Errorf("checkRetriable: not an external payout: %w", domain.ErrPaymentNotRetriable)
+	}
+	if p.Status != domain.PaymentStatusFailed {
+		return fmt.Errorf("checkRetriable: %w", domain.NewDomainError(domain.ErrPaymentNotRetriable, "status", p.Status))
+	}
+	if p.FailureCode != nil && !p.FailureCode.IsRetriable() {
+		return fmt.Errorf("checkRetriable: %w", domain.NewDomainError(domain.ErrPaymentNotRetriable, "failure_code", *p.FailureCode))
+	}
+	return nil
+}
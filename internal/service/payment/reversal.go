@@ -0,0 +1,243 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+type ReverseTransferRequest struct {
+	PaymentID uuid.UUID
+	StaffID   uuid.UUID
+	Reason    string
+}
+
+// ReverseInternalTransfer undoes a completed internal transfer. The money
+// moves back in a new reversal payment linked to the original, which is
+// marked reversed. Cross-currency transfers unwind through the FX pools at
+// the original rate and the fee is refunded, so the sender gets back exactly
+// what they sent.
+func (s *Service) ReverseInternalTransfer(ctx context.Context, req ReverseTransferRequest) (original, reversal *domain.Payment, err error) {
+	log := logging.FromContext(ctx)
+
+	original, err = s.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+	if err := checkReversible(original); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+
+	legs, err := s.reversalLegs(ctx, original)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.payments.MarkReversed(ctx, tx, original.ID); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+
+	ids := make([]uuid.UUID, len(legs))
+	for i, l := range legs {
+		ids[i] = l.accountID
+	}
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, ids...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+	for _, l := range legs {
+		acct := locked[l.accountID]
+		if acct.Status == domain.AccountStatusClosed {
+			return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", domain.NewDomainError(domain.ErrAccountClosed, "role", l.role, "account_id", acct.ID))
+		}
+		if l.entryType == domain.EntryTypeDebit {
+			if err := s.checkSpendable(ctx, tx, acct, nil, l.amount); err != nil {
+				return nil, nil, fmt.Errorf("ReverseInternalTransfer: %s: %w", l.role, err)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	reversal = &domain.Payment{
+		ID:                  uuid.New(),
+		IdempotencyKey:      "reversal:" + original.ID.String(),
+		Type:                domain.PaymentTypeReversal,
+		Status:              domain.PaymentStatusCompleted,
+		SourceAccountID:     *original.DestAccountID,
+		DestAccountID:       &original.SourceAccountID,
+		Source:              original.Dest,
+		Dest:                original.Source,
+		Fee:                 domain.NewMoney(0, original.Source.Currency),
+		Refunded:            domain.NewMoney(0, original.Source.Currency),
+		ReversalOfPaymentID: &original.ID,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		CompletedAt:         &now,
+	}
+	if err := s.payments.Create(ctx, tx, reversal); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: create reversal: %w", err)
+	}
+
+	if err := s.writeReversalLegs(ctx, tx, reversal, legs, locked); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+
+	original.Status = domain.PaymentStatusReversed
+	payload, err := events.Marshal(events.NewPaymentReversed(original, reversal.ID, req.Reason, now))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+	event := &domain.PaymentEvent{
+		ID:        uuid.New(),
+		PaymentID: original.ID,
+		EventType: domain.PaymentEventTypeReversed,
+		Actor:     fmt.Sprintf("user:%s", req.StaffID),
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	if err := s.events.Create(ctx, tx, event); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: create event: %w", err)
+	}
+	if err := s.writePaymentEvent(ctx, tx, reversal, domain.PaymentEventTypeCompleted, req.StaffID, now); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("ReverseInternalTransfer: commit: %w", err)
+	}
+
+	log.Info("internal transfer reversed",
+		"payment_id", original.ID,
+		"reversal_payment_id", reversal.ID,
+		"staff_id", req.StaffID,
+		"source_amount", original.Source.Amount,
+		"source_currency", original.Source.Currency,
+		"dest_amount", original.Dest.Amount,
+		"dest_currency", original.Dest.Currency,
+	)
+	return original, reversal, nil
+}
+
+func checkReversible(p *domain.Payment) error {
+	if p.Type != domain.PaymentTypeInternalTransfer || p.DestAccountID == nil {
+		return fmt.Errorf("checkReversible: %w", domain.NewDomainError(domain.ErrPaymentNotReversible, "type", p.Type))
+	}
+	if p.Status != domain.PaymentStatusCompleted {
+		return fmt.Errorf("checkReversible: %w", domain.NewDomainError(domain.ErrPaymentNotReversible, "status", p.Status))
+	}
+	if p.Refunded.IsPositive() {
+		return fmt.Errorf("checkReversible: %w", domain.NewDomainError(domain.ErrPaymentNotReversible, "refunded_amount", p.Refunded.Decimal()))
+	}
+	return nil
+}
+
+// reversalLeg is one compensating ledger line. role names the account in
+// errors.
+type reversalLeg struct {
+	accountID uuid.UUID
+	role      string
+	entryType domain.EntryType
+	amount    domain.Money
+}
+
+// reversalLegs mirrors the original transfer's ledger lines.
+//
+//	Same currency:  debit recipient, credit sender
+//	Cross currency: debit recipient, debit revenue (fee), credit FX dest pool,
+//	                debit FX source pool, credit sender
+//
+// Transfers made before fees were booked have no revenue line; the fee is
+// only clawed back when the original credited it.
+func (s *Service) reversalLegs(ctx context.Context, p *domain.Payment) ([]reversalLeg, error) {
+	recipient := reversalLeg{*p.DestAccountID, "recipient", domain.EntryTypeDebit, p.Dest}
+	sender := reversalLeg{p.SourceAccountID, "sender", domain.EntryTypeCredit, p.Source}
+
+	if p.Source.Currency == p.Dest.Currency {
+		return []reversalLeg{recipient, sender}, nil
+	}
+
+	fxPoolSource, err := s.getSystemAccount(ctx, domain.AccountTypeFXPool, p.Source.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("reversalLegs: %w", err)
+	}
+	fxPoolDest, err := s.getSystemAccount(ctx, domain.AccountTypeFXPool, p.Dest.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("reversalLegs: %w", err)
+	}
+	fee, revenueID, err := s.bookedFee(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("reversalLegs: %w", err)
+	}
+
+	poolCredit, err := p.Dest.Add(fee)
+	if err != nil {
+		return nil, fmt.Errorf("reversalLegs: %w", err)
+	}
+
+	legs := []reversalLeg{recipient}
+	if fee.IsPositive() {
+		legs = append(legs, reversalLeg{revenueID, "revenue", domain.EntryTypeDebit, fee})
+	}
+	legs = append(legs,
+		reversalLeg{fxPoolDest.ID, "fx pool " + string(p.Dest.Currency), domain.EntryTypeCredit, poolCredit},
+		reversalLeg{fxPoolSource.ID, "fx pool " + string(p.Source.Currency), domain.EntryTypeDebit, p.Source},
+		sender,
+	)
+	return legs, nil
+}
+
+// bookedFee returns the fee credited to revenue for the payment, or a zero
+// fee if none was.
+func (s *Service) bookedFee(ctx context.Context, p *domain.Payment) (domain.Money, uuid.UUID, error) {
+	none := domain.NewMoney(0, p.Fee.Currency)
+	if !p.Fee.IsPositive() {
+		return none, uuid.Nil, nil
+	}
+	revenue, err := s.getSystemAccount(ctx, domain.AccountTypeRevenue, p.Fee.Currency)
+	if err != nil {
+		return none, uuid.Nil, fmt.Errorf("bookedFee: %w", err)
+	}
+	entries, err := s.ledger.GetByPaymentID(ctx, p.ID)
+	if err != nil {
+		return none, uuid.Nil, fmt.Errorf("bookedFee: %w", err)
+	}
+	for _, e := range entries {
+		if e.AccountID == revenue.ID && e.EntryType == domain.EntryTypeCredit {
+			return e.Amount, revenue.ID, nil
+		}
+	}
+	return none, uuid.Nil, nil
+}
+
+func (s *Service) writeReversalLegs(ctx context.Context, tx pgx.Tx, reversal *domain.Payment, legs []reversalLeg, locked map[uuid.UUID]*domain.Account) error {
+	entries := make([]*domain.LedgerEntry, len(legs))
+	for i, l := range legs {
+		acct := locked[l.accountID]
+		entry, err := domain.NewLedgerEntry(reversal.ID, acct, l.entryType, domain.EntryCategoryReversal, l.amount, reversal.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("writeReversalLegs: %s: %w", l.role, err)
+		}
+		entries[i] = entry
+		if err := s.accounts.UpdateBalance(ctx, tx, acct.ID, entry.BalanceAfter, acct.Version+1); err != nil {
+			return fmt.Errorf("writeReversalLegs: update %s: %w", l.role, err)
+		}
+	}
+	if err := s.ledger.CreateBatch(ctx, tx, entries); err != nil {
+		return fmt.Errorf("writeReversalLegs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestReverseInternalTransfer_SameCurrency(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_rev")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_rev")
+	staff := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_rev")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 5000)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_rev",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              3000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	original, reversal, err := svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: staff.ID, Reason: "sent to the wrong person",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.PaymentStatusReversed, original.Status)
+	assert.Equal(t, domain.PaymentTypeReversal, reversal.Type)
+	assert.Equal(t, domain.PaymentStatusCompleted, reversal.Status)
+	require.NotNil(t, reversal.ReversalOfPaymentID)
+	assert.Equal(t, p.ID, *reversal.ReversalOfPaymentID)
+	assert.Equal(t, recipientAcct.ID, reversal.SourceAccountID)
+
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+	assert.Equal(t, 2, testutil.CountLedgerEntries(t, db, reversal.ID))
+
+	stored, err := svc.GetPaymentByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusReversed, stored.Status)
+
+	events := getPaymentEvents(t, db, p.ID)
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.PaymentEventTypeReversed, events[1].EventType)
+	assert.Equal(t, "user:"+staff.ID.String(), events[1].Actor)
+
+	_, _, err = svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: staff.ID, Reason: "again",
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentNotReversible)
+
+	_, _, err = svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: reversal.ID, StaffID: staff.ID, Reason: "undo the undo",
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentNotReversible)
+}
+
+func TestReverseInternalTransfer_CrossCurrencyUnwindsFXPools(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_revfx")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_revfx")
+	staff := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_revfx")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "EUR", 0)
+
+	poolUSD := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
+	poolEUR := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+	revenueEUR := testutil.GetAccountBalance(t, db, testutil.RevenueEURID)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_revfx",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyEUR,
+		Amount:              5000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+	require.Positive(t, p.Fee.Amount)
+
+	_, reversal, err := svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: staff.ID, Reason: "duplicate transfer",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, p.Dest.Amount, reversal.Source.Amount)
+	assert.Equal(t, p.Source.Amount, reversal.Dest.Amount)
+
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(0), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+	assert.Equal(t, poolUSD, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
+	assert.Equal(t, poolEUR, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+	assert.Equal(t, revenueEUR, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
+	assert.Equal(t, 5, testutil.CountLedgerEntries(t, db, reversal.ID))
+	for _, e := range getLedgerEntries(t, db, reversal.ID) {
+		assert.Equal(t, domain.EntryCategoryReversal, e.Category)
+	}
+}
+
+func TestReverseInternalTransfer_RecipientAlreadySpent(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_revspent")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_revspent")
+	third := testutil.SeedTestUser(t, db, "third@test.com", "Third", "third_revspent")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+	testutil.SeedTestAccount(t, db, third.ID, "USD", 0)
+
+	transfer := func(from uuid.UUID, to string, amount int64) *domain.Payment {
+		p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+			SenderUserID:        from,
+			RecipientUniqueName: to,
+			SourceCurrency:      domain.CurrencyUSD,
+			DestCurrency:        domain.CurrencyUSD,
+			Amount:              amount,
+			IdempotencyKey:      uuid.NewString(),
+		})
+		require.NoError(t, err)
+		return p
+	}
+	p := transfer(sender.ID, "recipient_revspent", 3000)
+	transfer(recipient.ID, "third_revspent", 2000)
+
+	_, _, err := svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: sender.ID, Reason: "fraud",
+	})
+	require.ErrorIs(t, err, domain.ErrInsufficientFunds)
+
+	stored, err := svc.GetPaymentByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, stored.Status)
+	assert.Equal(t, int64(7000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(1000), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+}
+
+func TestReverseInternalTransfer_RecipientFundsOnHold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	svc := setupPaymentService(t, db)
+	ctx := context.Background()
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_revhold")
+	recipient := testutil.SeedTestUser(t, db, "recipient@test.com", "Recipient", "recipient_revhold")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	recipientAcct := testutil.SeedTestAccount(t, db, recipient.ID, "USD", 0)
+
+	p, err := svc.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        sender.ID,
+		RecipientUniqueName: "recipient_revhold",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              3000,
+		IdempotencyKey:      uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, err = svc.PlaceHold(ctx, payment.PlaceHoldRequest{UserID: recipient.ID, AccountID: recipientAcct.ID, Amount: 2000})
+	require.NoError(t, err)
+
+	_, _, err = svc.ReverseInternalTransfer(ctx, payment.ReverseTransferRequest{
+		PaymentID: p.ID, StaffID: sender.ID, Reason: "fraud",
+	})
+	require.ErrorIs(t, err, domain.ErrInsufficientFunds, "only 1000 of the 3000 is unheld")
+
+	stored, err := svc.GetPaymentByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, stored.Status)
+	assert.Equal(t, int64(7000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, int64(3000), testutil.GetAccountBalance(t, db, recipientAcct.ID))
+}
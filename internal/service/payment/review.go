@@ -0,0 +1,148 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+// ReviewFlag explains why a payout was sent to the review queue.
+type ReviewFlag struct {
+	Reason domain.ReviewReason
+	RuleID string
+	Detail string
+}
+
+// reviewFlag decides whether a payout needs manual review before it is sent.
+// Screening matches take precedence over the amount threshold.
+func (s *Service) reviewFlag(ctx context.Context, req ExternalPayoutRequest) (*ReviewFlag, error) {
+	match, err := s.screen(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("reviewFlag: %w", err)
+	}
+	if match != nil {
+		return &ReviewFlag{Reason: domain.ReviewReasonScreening, RuleID: match.RuleID, Detail: match.Reason}, nil
+	}
+
+	if threshold := s.reviewThreshold(req.SourceCurrency); threshold > 0 && req.Amount >= threshold {
+		return &ReviewFlag{
+			Reason: domain.ReviewReasonAmountThreshold,
+			Detail: fmt.Sprintf("amount %d %s at or above review threshold %d", req.Amount, req.SourceCurrency, threshold),
+		}, nil
+	}
+	return nil, nil
+}
+
+func (s *Service) reviewThreshold(c domain.Currency) int64 {
+	return s.config.ReviewThreshold(string(c))
+}
+
+// ApproveReview clears a payout from the review queue and submits it to the
+// provider currently routed for its corridor.
+func (s *Service) ApproveReview(ctx context.Context, paymentID, staffID uuid.UUID) (*domain.Payment, error) {
+	log := logging.FromContext(ctx)
+
+	p, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("ApproveReview: %w", err)
+	}
+	if p.Status != domain.PaymentStatusPendingReview {
+		return nil, fmt.Errorf("ApproveReview: %w", domain.NewDomainError(domain.ErrPaymentNotPendingReview, "status", p.Status))
+	}
+
+	provider, err := s.routeProvider(p.Source.Currency, p.Dest.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("ApproveReview: %w", err)
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("ApproveReview: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+	if err := s.payments.ApproveReview(ctx, tx, p.ID, providerName(provider)); err != nil {
+		return nil, fmt.Errorf("ApproveReview: %w", err)
+	}
+	p.Status = domain.PaymentStatusPending
+	p.Provider = providerName(provider)
+
+	payload, err := events.Marshal(events.NewPaymentReleased(p, stringVal(p.Provider), now))
+	if err != nil {
+		return nil, fmt.Errorf("ApproveReview: %w", err)
+	}
+	event := &domain.PaymentEvent{
+		ID:        uuid.New(),
+		PaymentID: p.ID,
+		EventType: domain.PaymentEventTypeReleased,
+		Actor:     fmt.Sprintf("user:%s", staffID),
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	if err := s.events.Create(ctx, tx, event); err != nil {
+		return nil, fmt.Errorf("ApproveReview: create event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ApproveReview: commit: %w", err)
+	}
+
+	s.submitToProvider(ctx, provider, p)
+
+	log.Info("payout approved after review",
+		"payment_id", p.ID,
+		"staff_id", staffID,
+		"review_reason", reviewReasonVal(p.ReviewReason),
+		"provider", stringVal(p.Provider),
+	)
+	return p, nil
+}
+
+func (s *Service) writeReviewEvent(ctx context.Context, tx pgx.Tx, p *domain.Payment, flag *ReviewFlag, now time.Time) error {
+	if flag == nil {
+		return nil
+	}
+
+	payload, err := events.Marshal(events.NewPaymentHeld(p, flag.Reason, flag.RuleID, flag.Detail, now))
+	if err != nil {
+		return fmt.Errorf("writeReviewEvent: %w", err)
+	}
+	event := &domain.PaymentEvent{
+		ID:        uuid.New(),
+		PaymentID: p.ID,
+		EventType: domain.PaymentEventTypeHeld,
+		Actor:     "system",
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	if err := s.events.Create(ctx, tx, event); err != nil {
+		return fmt.Errorf("writeReviewEvent: %w", err)
+	}
+	return nil
+}
+
+// markForReview puts a payout being built into the review queue.
+func markForReview(p *domain.Payment, flag *ReviewFlag) {
+	if flag == nil {
+		return
+	}
+	reason := flag.Reason
+	p.Status = domain.PaymentStatusPendingReview
+	p.ReviewReason = &reason
+}
+
+func reviewReasonVal(r *domain.ReviewReason) string {
+	if r == nil {
+		return ""
+	}
+	return string(*r)
+}
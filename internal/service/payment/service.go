@@ -2,43 +2,74 @@ package payment
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/config"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+	"github.com/shopspring/decimal"
 )
 
 var SystemUserID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
 
 type paymentRepo interface {
-	Create(ctx context.Context, tx *sql.Tx, payment *domain.Payment) error
+	Create(ctx context.Context, tx pgx.Tx, payment *domain.Payment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	GetByUserIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.Payment, error)
+	MarkSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error
+	ApproveReview(ctx context.Context, tx pgx.Tx, id uuid.UUID, provider *string) error
+	SumSentSince(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, since time.Time) (int64, error)
+	MarkReversed(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+	AddRefunded(ctx context.Context, tx pgx.Tx, id uuid.UUID, amount int64) (int64, error)
+	ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]domain.Payment, error)
+	SumPendingOutgoing(ctx context.Context, accountID uuid.UUID) (int64, error)
 }
 
 type accountRepo interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Account, error)
 	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error)
-	GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.Account, error)
-	UpdateBalance(ctx context.Context, tx *sql.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Account, error)
+	UpdateBalance(ctx context.Context, tx pgx.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
+	UpdateBalances(ctx context.Context, tx pgx.Tx, updates []domain.BalanceUpdate) (map[uuid.UUID]int64, error)
+	Close(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+	ApplyDelta(ctx context.Context, tx pgx.Tx, id uuid.UUID, delta, floor int64) (int64, error)
 }
 
 type ledgerRepo interface {
-	Create(ctx context.Context, tx *sql.Tx, entry *domain.LedgerEntry) error
+	CreateBatch(ctx context.Context, tx pgx.Tx, entries []*domain.LedgerEntry) error
+	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error)
 }
 
 type eventRepo interface {
-	Create(ctx context.Context, tx *sql.Tx, event *domain.PaymentEvent) error
+	Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error
 }
 
 type userRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	GetByUniqueName(ctx context.Context, uniqueName string) (*domain.User, error)
 }
 
+type beneficiaryRepo interface {
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*domain.Beneficiary, error)
+}
+
+type userLimitRepo interface {
+	Get(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.UserLimit, error)
+}
+
 type fxService interface {
 	Convert(ctx context.Context, amount int64, from, to domain.Currency) (*fx.Conversion, error)
+	ConvertWithSpread(ctx context.Context, amount int64, from, to domain.Currency, spreadPct decimal.Decimal) (*fx.Conversion, error)
+}
+
+type paymentMetrics interface {
+	PaymentCreated(p *domain.Payment)
+	BalancePathResult(path, outcome string, d time.Duration)
 }
 
 type ProviderRequest struct {
@@ -49,20 +80,78 @@ type ProviderRequest struct {
 	DestBankName string
 }
 
-type providerClient interface {
+type Provider interface {
+	Name() string
 	SubmitPayment(ctx context.Context, req ProviderRequest) error
 }
 
+type providerRouter interface {
+	Route(source, dest domain.Currency) (Provider, error)
+}
+
+type ScreeningRequest struct {
+	SenderUserID uuid.UUID
+	DestIBAN     string
+	DestBankName string
+}
+
+// ScreeningMatch explains why screening flagged a payout.
+type ScreeningMatch struct {
+	RuleID string
+	Reason string
+}
+
+// Screener checks a payout destination before any money moves. A nil match
+// means the destination is clear.
+type Screener interface {
+	Screen(ctx context.Context, req ScreeningRequest) (*ScreeningMatch, error)
+}
+
+// ResidencyCheck is a payment to check against the sender's country of
+// residence. DestCountry is where the money lands: the payout IBAN's
+// country, or the recipient's residency for an internal transfer. It is
+// empty when that isn't known.
+type ResidencyCheck struct {
+	SenderUserID   uuid.UUID
+	Residency      string
+	Type           domain.PaymentType
+	SourceCurrency domain.Currency
+	DestCurrency   domain.Currency
+	DestCountry    string
+}
+
+// ResidencyChecker applies the restrictions on what residents of a country
+// may send. It returns a *domain.ResidencyRestrictedError for a payment a
+// rule forbids.
+type ResidencyChecker interface {
+	CheckResidency(ctx context.Context, check ResidencyCheck) error
+}
+
+// StepUpVerifier checks a one-time code from the user's authenticator app
+// before a large payout. It returns domain.ErrTOTPNotEnabled for a user
+// without two-factor authentication and domain.ErrTOTPRequired when no code
+// is given.
+type StepUpVerifier interface {
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+}
+
 type Service struct {
-	payments paymentRepo
-	accounts accountRepo
-	ledger   ledgerRepo
-	events   eventRepo
-	users    userRepo
-	fx       fxService
-	provider providerClient
-	db       *sql.DB
-	config   *config.Config
+	payments      paymentRepo
+	accounts      accountRepo
+	ledger        ledgerRepo
+	events        eventRepo
+	users         userRepo
+	limits        userLimitRepo
+	holds         holdRepo
+	beneficiaries beneficiaryRepo
+	fx            fxService
+	providers     providerRouter
+	screener      Screener
+	residency     ResidencyChecker
+	stepUp        StepUpVerifier
+	metrics       paymentMetrics
+	db            *pgxpool.Pool
+	config        *config.Config
 }
 
 func NewService(
@@ -71,21 +160,35 @@ func NewService(
 	ledger ledgerRepo,
 	events eventRepo,
 	users userRepo,
+	limits userLimitRepo,
+	holds holdRepo,
+	beneficiaries beneficiaryRepo,
 	fxSvc fxService,
-	provider providerClient,
-	db *sql.DB,
+	providers providerRouter,
+	screener Screener,
+	residency ResidencyChecker,
+	stepUp StepUpVerifier,
+	metrics paymentMetrics,
+	db *pgxpool.Pool,
 	cfg *config.Config,
 ) *Service {
 	return &Service{
-		payments: payments,
-		accounts: accounts,
-		ledger:   ledger,
-		events:   events,
-		users:    users,
-		fx:       fxSvc,
-		provider: provider,
-		db:       db,
-		config:   cfg,
+		payments:      payments,
+		accounts:      accounts,
+		ledger:        ledger,
+		events:        events,
+		users:         users,
+		limits:        limits,
+		holds:         holds,
+		beneficiaries: beneficiaries,
+		fx:            fxSvc,
+		providers:     providers,
+		screener:      screener,
+		residency:     residency,
+		stepUp:        stepUp,
+		metrics:       metrics,
+		db:            db,
+		config:        cfg,
 	}
 }
 
@@ -97,33 +200,112 @@ func (s *Service) GetPaymentByID(ctx context.Context, paymentID uuid.UUID) (*dom
 	return p, nil
 }
 
-func (s *Service) GetPaymentForUser(ctx context.Context, paymentID, userID uuid.UUID) (*domain.Payment, error) {
+// GetPaymentForUser returns a payment the user sent or received, and which
+// of the two it is. A payment between two of the user's own accounts counts
+// as sent. Anyone else gets ErrNotFound, so payment ids can't be probed.
+func (s *Service) GetPaymentForUser(ctx context.Context, paymentID, userID uuid.UUID) (*domain.Payment, domain.PaymentDirection, error) {
 	p, err := s.payments.GetByID(ctx, paymentID)
 	if err != nil {
-		return nil, fmt.Errorf("GetPaymentForUser: %w", err)
+		return nil, "", fmt.Errorf("GetPaymentForUser: %w", err)
 	}
 
-	acct, err := s.accounts.GetByID(ctx, p.SourceAccountID)
+	src, err := s.accounts.GetByID(ctx, p.SourceAccountID)
 	if err != nil {
-		return nil, fmt.Errorf("GetPaymentForUser: %w", err)
+		return nil, "", fmt.Errorf("GetPaymentForUser: %w", err)
+	}
+	if src.UserID == userID {
+		return p, domain.PaymentDirectionSent, nil
 	}
 
-	if acct.UserID != userID {
-		return nil, fmt.Errorf("GetPaymentForUser: %w", domain.ErrNotFound)
+	if p.DestAccountID != nil {
+		dest, err := s.accounts.GetByID(ctx, *p.DestAccountID)
+		if err != nil {
+			return nil, "", fmt.Errorf("GetPaymentForUser: %w", err)
+		}
+		if dest.UserID == userID {
+			return p, domain.PaymentDirectionReceived, nil
+		}
 	}
 
+	return nil, "", fmt.Errorf("GetPaymentForUser: %w", domain.ErrNotFound)
+}
+
+// GetPaymentByIdempotencyKey returns the payment the user created with the
+// given Idempotency-Key, so a client that lost the response can find it
+// without sending the request again. Only payments the user sent match.
+func (s *Service) GetPaymentByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.Payment, error) {
+	p, err := s.payments.GetByUserIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		return nil, fmt.Errorf("GetPaymentByIdempotencyKey: %w", err)
+	}
 	return p, nil
 }
 
-func (s *Service) txLimitForCurrency(c domain.Currency) int64 {
-	switch c {
-	case domain.CurrencyUSD:
-		return s.config.TxLimitUSD
-	case domain.CurrencyEUR:
-		return s.config.TxLimitEUR
-	case domain.CurrencyGBP:
-		return s.config.TxLimitGBP
-	default:
-		return 0
+func (s *Service) recordCreated(p *domain.Payment) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.PaymentCreated(p)
+}
+
+// txLimitForCurrency returns the user's per-transaction limit. An admin
+// override wins; otherwise the configured default is scaled for the user's
+// tier, and users below the full KYC tier get a reduced share of that.
+func (s *Service) txLimitForCurrency(ctx context.Context, user *domain.User, c domain.Currency) (int64, error) {
+	if s.limits != nil {
+		l, err := s.limits.Get(ctx, user.ID, c)
+		if err == nil {
+			return l.TxLimit, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return 0, fmt.Errorf("txLimitForCurrency: %w", err)
+		}
+	}
+
+	limit := s.defaultTxLimit(c) * s.tierLimitPct(user.Tier) / 100
+	if !user.KYCTier.HasFullLimits() {
+		limit = limit * int64(s.config.KYCReducedLimitPct) / 100
+	}
+	return limit, nil
+}
+
+// senderUser loads the user behind a sending account for tier and limit checks.
+func (s *Service) senderUser(ctx context.Context, acct *domain.Account) (*domain.User, error) {
+	u, err := s.users.GetByID(ctx, acct.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("senderUser: %w", err)
 	}
+	return u, nil
+}
+
+// checkResidency asks the residency checker whether the sender may make the
+// payment from their country of residence. Senders without a residency on
+// file aren't restricted.
+func (s *Service) checkResidency(ctx context.Context, sender *domain.User, check ResidencyCheck) error {
+	if s.residency == nil || sender.Country == nil {
+		return nil
+	}
+	check.SenderUserID = sender.ID
+	check.Residency = *sender.Country
+	if err := s.residency.CheckResidency(ctx, check); err != nil {
+		return fmt.Errorf("checkResidency: %w", err)
+	}
+	return nil
+}
+
+// recipientCountry is the residency of the user behind an account, or ""
+// when none is on file.
+func (s *Service) recipientCountry(ctx context.Context, acct *domain.Account) (string, error) {
+	u, err := s.users.GetByID(ctx, acct.UserID)
+	if err != nil {
+		return "", fmt.Errorf("recipientCountry: %w", err)
+	}
+	if u.Country == nil {
+		return "", nil
+	}
+	return *u.Country, nil
+}
+
+func (s *Service) defaultTxLimit(c domain.Currency) int64 {
+	return s.config.TxLimit(string(c))
 }
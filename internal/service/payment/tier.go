@@ -0,0 +1,66 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+)
+
+// senderTier reads the sender's tier when the payment executes, so a tier
+// change applies from the next payment on. Users without one are standard.
+func (s *Service) senderTier(ctx context.Context, userID uuid.UUID) (domain.UserTier, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("senderTier: %w", err)
+	}
+	if !u.Tier.IsValid() {
+		return domain.UserTierStandard, nil
+	}
+	return u.Tier, nil
+}
+
+// tierSpread is the FX spread a tier above standard pays; standard pays the
+// rate service's configured spread. The spread is the only fee the platform
+// charges, so it is the tier's whole fee schedule.
+func (s *Service) tierSpread(t domain.UserTier) (decimal.Decimal, bool) {
+	switch t {
+	case domain.UserTierPlus:
+		return decimal.NewFromFloat(s.config.FXSpreadPctPlus), true
+	case domain.UserTierBusiness:
+		return decimal.NewFromFloat(s.config.FXSpreadPctBusiness), true
+	default:
+		return decimal.Decimal{}, false
+	}
+}
+
+// tierLimitPct scales the configured default limits for a tier, in percent.
+func (s *Service) tierLimitPct(t domain.UserTier) int64 {
+	switch t {
+	case domain.UserTierPlus:
+		return int64(s.config.TierLimitPctPlus)
+	case domain.UserTierBusiness:
+		return int64(s.config.TierLimitPctBusiness)
+	default:
+		return 100
+	}
+}
+
+// convert prices a conversion at the tier's spread.
+func (s *Service) convert(ctx context.Context, amount int64, from, to domain.Currency, tier domain.UserTier) (*fx.Conversion, error) {
+	var conversion *fx.Conversion
+	var err error
+	if spread, ok := s.tierSpread(tier); ok {
+		conversion, err = s.fx.ConvertWithSpread(ctx, amount, from, to, spread)
+	} else {
+		conversion, err = s.fx.Convert(ctx, amount, from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("convert: %w", err)
+	}
+	return conversion, nil
+}
@@ -2,15 +2,18 @@ package payment
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
 )
 
 type InternalTransferRequest struct {
@@ -20,21 +23,33 @@ type InternalTransferRequest struct {
 	DestCurrency        domain.Currency
 	Amount              int64
 	IdempotencyKey      string
+	// HoldID, when set, pays the transfer out of that hold, which must be on
+	// the sender's account and cover Amount.
+	HoldID *uuid.UUID
+
+	// sweep marks the transfer as the sweep of an account being closed.
+	sweep bool
 }
 
+// CreateInternalTransfer moves money between two users. req.IdempotencyKey
+// is required: it is what makes a retried request return the first payment
+// instead of paying twice.
 func (s *Service) CreateInternalTransfer(ctx context.Context, req InternalTransferRequest) (*domain.Payment, error) {
 	log := logging.FromContext(ctx)
 
-	senderAcct, recipientAcct, err := s.resolveTransferAccounts(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("CreateInternalTransfer: %w", err)
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("CreateInternalTransfer: %w", domain.ErrMissingIdempotencyKey)
 	}
 
-	if err := s.validateTransfer(req, senderAcct, recipientAcct); err != nil {
+	plan, err := s.planTransfer(ctx, req)
+	if err != nil {
 		return nil, fmt.Errorf("CreateInternalTransfer: %w", err)
 	}
+	req, senderAcct, recipientAcct := plan.req, plan.sender, plan.recipient
 
-	p, err := s.executeTransfer(ctx, req, senderAcct.ID, recipientAcct.ID)
+	p, err := withTxRetry(ctx, "executeTransfer", func() (*domain.Payment, error) {
+		return s.executeTransfer(ctx, req, senderAcct.ID, recipientAcct.ID)
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
 			return nil, fmt.Errorf("CreateInternalTransfer: %w", domain.ErrDuplicatePayment)
@@ -42,51 +57,104 @@ func (s *Service) CreateInternalTransfer(ctx context.Context, req InternalTransf
 		return nil, fmt.Errorf("CreateInternalTransfer: %w", err)
 	}
 
+	s.recordCreated(p)
+
 	log.Info("internal transfer completed",
 		"payment_id", p.ID,
 		"sender_account", senderAcct.ID,
 		"recipient_account", recipientAcct.ID,
 		"source_amount", req.Amount,
 		"source_currency", req.SourceCurrency,
-		"dest_amount", p.DestAmount,
+		"dest_amount", p.Dest.Amount,
 		"dest_currency", req.DestCurrency,
+		"destination_path", plan.path,
 	)
 
 	return p, nil
 }
 
 
-func (s *Service) resolveTransferAccounts(ctx context.Context, req InternalTransferRequest) (*domain.Account, *domain.Account, error) {
+// resolveCurrencies fills in the currencies a payment request left out. An
+// explicit source currency always wins; without one the payment is sent from
+// the user's default account, and with neither it is rejected. A missing
+// destination currency means no conversion: the source currency. Transfers
+// then swap it for the currency of the account selectDestination picks.
+func (s *Service) resolveCurrencies(ctx context.Context, userID uuid.UUID, source, dest domain.Currency) (domain.Currency, domain.Currency, error) {
+	if source == "" {
+		user, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return "", "", fmt.Errorf("resolveCurrencies: %w", err)
+		}
+		if user.DefaultAccountID == nil {
+			return "", "", fmt.Errorf("resolveCurrencies: %w", domain.ErrDefaultAccountNotSet)
+		}
+		acct, err := s.accounts.GetByID(ctx, *user.DefaultAccountID)
+		if err != nil {
+			return "", "", fmt.Errorf("resolveCurrencies: default account: %w", err)
+		}
+		source = acct.Currency
+	}
+	if dest == "" {
+		dest = source
+	}
+	return source, dest, nil
+}
+
+// SourceCurrency is the currency a payment request from userID is sent
+// in: source when it names one, otherwise the user's default account's.
+// Handlers need it before sending to read the amount, whose minor units
+// depend on the currency.
+func (s *Service) SourceCurrency(ctx context.Context, userID uuid.UUID, source domain.Currency) (domain.Currency, error) {
+	source, _, err := s.resolveCurrencies(ctx, userID, source, source)
+	if err != nil {
+		return "", fmt.Errorf("SourceCurrency: %w", err)
+	}
+	return source, nil
+}
+
+// resolveTransferAccounts finds the sender's account and the recipient's.
+// With pickDest the request named no destination currency, and the
+// recipient's account is chosen by selectDestination.
+func (s *Service) resolveTransferAccounts(ctx context.Context, req InternalTransferRequest, pickDest bool) (*domain.Account, *domain.Account, DestinationPath, error) {
 	recipient, err := s.users.GetByUniqueName(ctx, req.RecipientUniqueName)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			return nil, nil, fmt.Errorf("resolveTransferAccounts: %w", domain.ErrRecipientNotFound)
+			return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", domain.ErrRecipientNotFound)
 		}
-		return nil, nil, fmt.Errorf("resolveTransferAccounts: %w", err)
+		return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", err)
 	}
 
-	recipientAcct, err := s.accounts.GetByUserAndCurrency(ctx, recipient.ID, req.DestCurrency, domain.AccountTypeUser)
-	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			return nil, nil, fmt.Errorf("resolveTransferAccounts: recipient has no %s account: %w", req.DestCurrency, domain.ErrAccountNotFound)
+	var recipientAcct *domain.Account
+	path := DestinationRequested
+	if pickDest {
+		recipientAcct, path, err = s.selectDestination(ctx, recipient, req.SourceCurrency)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", err)
+		}
+	} else {
+		recipientAcct, err = s.accounts.GetByUserAndCurrency(ctx, recipient.ID, req.DestCurrency, domain.AccountTypeUser)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, nil, "", fmt.Errorf("resolveTransferAccounts: recipient: %w", domain.NewDomainError(domain.ErrAccountNotFound, "currency", req.DestCurrency))
+			}
+			return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", err)
 		}
-		return nil, nil, fmt.Errorf("resolveTransferAccounts: %w", err)
 	}
 
 	senderAcct, err := s.accounts.GetByUserAndCurrency(ctx, req.SenderUserID, req.SourceCurrency, domain.AccountTypeUser)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			return nil, nil, fmt.Errorf("resolveTransferAccounts: %w", domain.ErrAccountNotFound)
+			return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", domain.ErrAccountNotFound)
 		}
-		return nil, nil, fmt.Errorf("resolveTransferAccounts: %w", err)
+		return nil, nil, "", fmt.Errorf("resolveTransferAccounts: %w", err)
 	}
 
-	return senderAcct, recipientAcct, nil
+	return senderAcct, recipientAcct, path, nil
 }
 
-func (s *Service) validateTransfer(req InternalTransferRequest, sender, recipient *domain.Account) error {
+func (s *Service) validateTransfer(ctx context.Context, req InternalTransferRequest, sender, recipient *domain.Account) error {
 	if req.Amount <= 0 {
-		return fmt.Errorf("validateTransfer: %w", domain.ErrInvalidAmount)
+		return fmt.Errorf("validateTransfer: %w", domain.NewDomainError(domain.ErrInvalidAmount, "amount", domain.NewMoney(req.Amount, req.SourceCurrency).Decimal()))
 	}
 
 	if sender.UserID == recipient.UserID && req.SourceCurrency == req.DestCurrency {
@@ -94,21 +162,45 @@ func (s *Service) validateTransfer(req InternalTransferRequest, sender, recipien
 	}
 
 	if sender.Status == domain.AccountStatusFrozen {
-		return fmt.Errorf("validateTransfer: sender: %w", domain.ErrAccountFrozen)
+		return fmt.Errorf("validateTransfer: sender: %w", sender.FrozenError())
 	}
 	if sender.Status != domain.AccountStatusActive {
-		return fmt.Errorf("validateTransfer: sender: %w", domain.ErrAccountClosed)
+		return fmt.Errorf("validateTransfer: %w", domain.NewDomainError(domain.ErrAccountClosed, "role", "sender", "account_id", sender.ID))
 	}
 
 	if recipient.Status == domain.AccountStatusFrozen {
 		return fmt.Errorf("validateTransfer: recipient: %w", domain.ErrAccountFrozen)
 	}
 	if recipient.Status != domain.AccountStatusActive {
-		return fmt.Errorf("validateTransfer: recipient: %w", domain.ErrAccountClosed)
+		return fmt.Errorf("validateTransfer: %w", domain.NewDomainError(domain.ErrAccountClosed, "role", "recipient"))
 	}
 
-	if req.Amount > s.txLimitForCurrency(req.SourceCurrency) {
-		return fmt.Errorf("validateTransfer: %w", domain.ErrLimitExceeded)
+	user, err := s.senderUser(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("validateTransfer: %w", err)
+	}
+	limit, err := s.txLimitForCurrency(ctx, user, req.SourceCurrency)
+	if err != nil {
+		return fmt.Errorf("validateTransfer: %w", err)
+	}
+	if req.Amount > limit {
+		return fmt.Errorf("validateTransfer: %w", domain.NewDomainError(domain.ErrLimitExceeded,
+			"currency", req.SourceCurrency, "limit", domain.NewMoney(limit, req.SourceCurrency).Decimal(), "amount", domain.NewMoney(req.Amount, req.SourceCurrency).Decimal()))
+	}
+
+	if s.residency != nil && user.Country != nil {
+		destCountry, err := s.recipientCountry(ctx, recipient)
+		if err != nil {
+			return fmt.Errorf("validateTransfer: %w", err)
+		}
+		if err := s.checkResidency(ctx, user, ResidencyCheck{
+			Type:           domain.PaymentTypeInternalTransfer,
+			SourceCurrency: req.SourceCurrency,
+			DestCurrency:   req.DestCurrency,
+			DestCountry:    destCountry,
+		}); err != nil {
+			return fmt.Errorf("validateTransfer: %w", err)
+		}
 	}
 
 	return nil
@@ -118,15 +210,28 @@ func (s *Service) executeTransfer(ctx context.Context, req InternalTransferReque
 	if req.SourceCurrency != req.DestCurrency {
 		return s.executeCrossCurrencyTransfer(ctx, req, senderID, recipientID)
 	}
-	return s.executeSameCurrencyTransfer(ctx, req, senderID, recipientID)
+
+	path, execute := balancePathLocked, s.executeSameCurrencyTransfer
+	if s.useLocklessPath(req) {
+		path, execute = balancePathLockless, s.executeSameCurrencyTransferLockless
+	}
+	start := time.Now()
+	p, err := execute(ctx, req, senderID, recipientID)
+	s.recordBalancePath(path, err, time.Since(start))
+	return p, err
 }
 
 func (s *Service) executeSameCurrencyTransfer(ctx context.Context, req InternalTransferRequest, senderID, recipientID uuid.UUID) (*domain.Payment, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
 	if err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: begin tx: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
 	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, recipientID)
 	if err != nil {
@@ -138,12 +243,16 @@ func (s *Service) executeSameCurrencyTransfer(ctx context.Context, req InternalT
 	if err := verifyAccountActive(sender, "sender"); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
 	}
-	if err := verifyAccountActive(recipient, "recipient"); err != nil {
+	if err := verifyRecipientActive(recipient); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
+	}
+
+	if err := s.checkSpendable(ctx, tx, sender, req.HoldID, domain.NewMoney(req.Amount, req.SourceCurrency)); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
 	}
 
-	if sender.Balance < req.Amount {
-		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", domain.ErrInsufficientFunds)
+	if err := s.checkPeriodLimits(ctx, tx, senderID, domain.NewMoney(req.Amount, req.SourceCurrency), tier); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
 	}
 
 	now := time.Now().UTC()
@@ -154,10 +263,11 @@ func (s *Service) executeSameCurrencyTransfer(ctx context.Context, req InternalT
 		Status:          domain.PaymentStatusCompleted,
 		SourceAccountID: senderID,
 		DestAccountID:   &recipientID,
-		SourceAmount:    req.Amount,
-		SourceCurrency:  req.SourceCurrency,
-		DestAmount:      req.Amount,
-		DestCurrency:    req.DestCurrency,
+		Source:          domain.NewMoney(req.Amount, req.SourceCurrency),
+		Dest:            domain.NewMoney(req.Amount, req.DestCurrency),
+		Fee:             domain.NewMoney(0, req.DestCurrency),
+		Refunded:        domain.NewMoney(0, req.DestCurrency),
+		UserTier:        &tier,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 		CompletedAt:     &now,
@@ -166,79 +276,99 @@ func (s *Service) executeSameCurrencyTransfer(ctx context.Context, req InternalT
 	if err := s.payments.Create(ctx, tx, p); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: create payment: %w", err)
 	}
+	if err := s.captureHold(ctx, tx, req.HoldID, p, now); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
+	}
 
 	if err := s.writeLedgerEntries(ctx, tx, p, sender, recipient); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
 	}
 
-	if err := s.writePaymentEvent(ctx, tx, p.ID, domain.PaymentEventTypeCompleted, req.SenderUserID, now); err != nil {
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCompleted, req.SenderUserID, now); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: %w", err)
 	}
 
-	if err := s.accounts.UpdateBalance(ctx, tx, senderID, sender.Balance-req.Amount, sender.Version+1); err != nil {
-		return nil, fmt.Errorf("executeSameCurrencyTransfer: update sender: %w", err)
-	}
-	if err := s.accounts.UpdateBalance(ctx, tx, recipientID, recipient.Balance+req.Amount, recipient.Version+1); err != nil {
-		return nil, fmt.Errorf("executeSameCurrencyTransfer: update recipient: %w", err)
+	if _, err := s.accounts.UpdateBalances(ctx, tx, []domain.BalanceUpdate{
+		{AccountID: senderID, Balance: sender.Balance - req.Amount, Version: sender.Version},
+		{AccountID: recipientID, Balance: recipient.Balance + req.Amount, Version: recipient.Version},
+	}); err != nil {
+		return nil, fmt.Errorf("executeSameCurrencyTransfer: update balances: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("executeSameCurrencyTransfer: commit: %w", err)
 	}
 
 	return p, nil
 }
 
+// verifyAccountActive checks an account the caller owns, so a frozen one
+// reports why it was frozen.
 func verifyAccountActive(acct *domain.Account, role string) error {
 	if acct.Status == domain.AccountStatusFrozen {
-		return fmt.Errorf("%s: %w", role, domain.ErrAccountFrozen)
+		return fmt.Errorf("%s: %w", role, acct.FrozenError())
 	}
 	if acct.Status != domain.AccountStatusActive {
-		return fmt.Errorf("%s: %w", role, domain.ErrAccountClosed)
+		return domain.NewDomainError(domain.ErrAccountClosed, "role", role, "account_id", acct.ID)
 	}
 	return nil
 }
 
-func (s *Service) writeLedgerEntries(ctx context.Context, tx *sql.Tx, p *domain.Payment, sender, recipient *domain.Account) error {
-	debit := &domain.LedgerEntry{
-		ID:            uuid.New(),
-		PaymentID:     p.ID,
-		AccountID:     sender.ID,
-		EntryType:     domain.EntryTypeDebit,
-		Amount:        p.SourceAmount,
-		Currency:      p.SourceCurrency,
-		BalanceBefore: sender.Balance,
-		BalanceAfter:  sender.Balance - p.SourceAmount,
-		CreatedAt:     p.CreatedAt,
-	}
-	if err := s.ledger.Create(ctx, tx, debit); err != nil {
-		return fmt.Errorf("writeLedgerEntries: debit: %w", err)
-	}
-
-	credit := &domain.LedgerEntry{
-		ID:            uuid.New(),
-		PaymentID:     p.ID,
-		AccountID:     recipient.ID,
-		EntryType:     domain.EntryTypeCredit,
-		Amount:        p.DestAmount,
-		Currency:      p.DestCurrency,
-		BalanceBefore: recipient.Balance,
-		BalanceAfter:  recipient.Balance + p.DestAmount,
-		CreatedAt:     p.CreatedAt,
-	}
-	if err := s.ledger.Create(ctx, tx, credit); err != nil {
-		return fmt.Errorf("writeLedgerEntries: credit: %w", err)
+// verifyRecipientActive checks the other side of a payment. The sender isn't
+// told why a recipient is frozen.
+func verifyRecipientActive(acct *domain.Account) error {
+	if acct.Status == domain.AccountStatusFrozen {
+		return fmt.Errorf("recipient: %w", domain.ErrAccountFrozen)
+	}
+	if acct.Status != domain.AccountStatusActive {
+		return domain.NewDomainError(domain.ErrAccountClosed, "role", "recipient")
+	}
+	return nil
+}
+
+func (s *Service) writeLedgerEntries(ctx context.Context, tx pgx.Tx, p *domain.Payment, sender, recipient *domain.Account) error {
+	category := domain.EntryCategoryPrincipal
+	if p.Type == domain.PaymentTypePoolTransfer {
+		category = domain.EntryCategoryAdjustment
+	}
+	debit, err := domain.NewLedgerEntry(p.ID, sender, domain.EntryTypeDebit, category, p.Source, p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("writeLedgerEntries: sender: %w", err)
+	}
+	credit, err := domain.NewLedgerEntry(p.ID, recipient, domain.EntryTypeCredit, category, p.Dest, p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("writeLedgerEntries: recipient: %w", err)
+	}
+	if err := s.ledger.CreateBatch(ctx, tx, []*domain.LedgerEntry{debit, credit}); err != nil {
+		return fmt.Errorf("writeLedgerEntries: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) writePaymentEvent(ctx context.Context, tx *sql.Tx, paymentID uuid.UUID, eventType domain.PaymentEventType, actorUserID uuid.UUID, now time.Time) error {
+func (s *Service) writePaymentEvent(ctx context.Context, tx pgx.Tx, p *domain.Payment, eventType domain.PaymentEventType, actorUserID uuid.UUID, now time.Time) error {
+	var typed events.Event
+	switch eventType {
+	case domain.PaymentEventTypeCreated:
+		typed = events.NewPaymentCreated(p)
+	case domain.PaymentEventTypeCompleted:
+		typed = events.NewPaymentCompleted(p, "", now)
+	}
+
+	var payload json.RawMessage
+	if typed != nil {
+		var err error
+		if payload, err = events.Marshal(typed); err != nil {
+			return fmt.Errorf("writePaymentEvent: %w", err)
+		}
+	}
+
 	event := &domain.PaymentEvent{
 		ID:        uuid.New(),
-		PaymentID: paymentID,
+		PaymentID: p.ID,
 		EventType: eventType,
 		Actor:     fmt.Sprintf("user:%s", actorUserID),
+		Payload:   payload,
 		CreatedAt: now,
 	}
 	if err := s.events.Create(ctx, tx, event); err != nil {
@@ -247,7 +377,7 @@ func (s *Service) writePaymentEvent(ctx context.Context, tx *sql.Tx, paymentID u
 	return nil
 }
 
-func lockAccountsInOrder(ctx context.Context, tx *sql.Tx, accounts accountRepo, ids ...uuid.UUID) (map[uuid.UUID]*domain.Account, error) {
+func lockAccountsInOrder(ctx context.Context, tx pgx.Tx, accounts accountRepo, ids ...uuid.UUID) (map[uuid.UUID]*domain.Account, error) {
 	sorted := make([]uuid.UUID, len(ids))
 	copy(sorted, ids)
 	sort.Slice(sorted, func(i, j int) bool {
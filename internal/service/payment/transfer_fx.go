@@ -2,16 +2,21 @@ package payment
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
 )
 
 func (s *Service) executeCrossCurrencyTransfer(ctx context.Context, req InternalTransferRequest, senderID, recipientID uuid.UUID) (*domain.Payment, error) {
-	conversion, err := s.fx.Convert(ctx, req.Amount, req.SourceCurrency, req.DestCurrency)
+	tier, err := s.senderTier(ctx, req.SenderUserID)
+	if err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+	}
+	conversion, err := s.convert(ctx, req.Amount, req.SourceCurrency, req.DestCurrency, tier)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
@@ -24,14 +29,18 @@ func (s *Service) executeCrossCurrencyTransfer(ctx context.Context, req Internal
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: fx pool %s: %w", req.DestCurrency, err)
 	}
+	revenue, err := s.getSystemAccount(ctx, domain.AccountTypeRevenue, req.DestCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: revenue %s: %w", req.DestCurrency, err)
+	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := repository.Begin(ctx, s.db)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: begin tx: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
-	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, fxPoolSource.ID, fxPoolDest.ID, recipientID)
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, senderID, fxPoolSource.ID, fxPoolDest.ID, recipientID, revenue.ID)
 	if err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
@@ -40,38 +49,54 @@ func (s *Service) executeCrossCurrencyTransfer(ctx context.Context, req Internal
 	recipient := locked[recipientID]
 	fxSrc := locked[fxPoolSource.ID]
 	fxDst := locked[fxPoolDest.ID]
+	rev := locked[revenue.ID]
 
 	if err := verifyAccountActive(sender, "sender"); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
-	if err := verifyAccountActive(recipient, "recipient"); err != nil {
+	if err := verifyRecipientActive(recipient); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
 
-	if sender.Balance < req.Amount {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", domain.ErrInsufficientFunds)
+	if req.sweep {
+		if err := s.checkSweepable(ctx, tx, sender, req.Amount); err != nil {
+			return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+		}
+	}
+	if err := s.checkSpendable(ctx, tx, sender, req.HoldID, domain.NewMoney(req.Amount, req.SourceCurrency)); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+	}
+	if fxDst.Balance < conversion.Dest.Amount+conversion.Fee.Amount {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", domain.NewDomainError(domain.ErrInsufficientFunds, "fx_pool", req.DestCurrency))
 	}
-	if fxDst.Balance < conversion.DestAmount {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: fx pool %s: %w", req.DestCurrency, domain.ErrInsufficientFunds)
+
+	// Sweeping between the owner's own accounts doesn't count towards their
+	// sending limits.
+	if !req.sweep {
+		if err := s.checkPeriodLimits(ctx, tx, senderID, domain.NewMoney(req.Amount, req.SourceCurrency), tier); err != nil {
+			return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+		}
 	}
 
 	now := time.Now().UTC()
 	exchangeRate := conversion.ExchangeRate
-	feeCurrency := req.DestCurrency
+	midMarketRate := conversion.MidMarketRate
+	slippage := conversion.Slippage
 	p := &domain.Payment{
 		ID:              uuid.New(),
 		IdempotencyKey:  req.IdempotencyKey,
-		Type:            domain.PaymentTypeInternalTransfer,
+		Type:            transferType(req),
 		Status:          domain.PaymentStatusCompleted,
 		SourceAccountID: senderID,
 		DestAccountID:   &recipientID,
-		SourceAmount:    req.Amount,
-		SourceCurrency:  req.SourceCurrency,
-		DestAmount:      conversion.DestAmount,
-		DestCurrency:    req.DestCurrency,
+		Source:          conversion.Source,
+		Dest:            conversion.Dest,
 		ExchangeRate:    &exchangeRate,
-		FeeAmount:       conversion.FeeAmount,
-		FeeCurrency:     &feeCurrency,
+		MidMarketRate:   &midMarketRate,
+		Slippage:        &slippage,
+		UserTier:        &tier,
+		Fee:             conversion.Fee,
+		Refunded:        domain.NewMoney(0, req.DestCurrency),
 		CreatedAt:       now,
 		UpdatedAt:       now,
 		CompletedAt:     &now,
@@ -80,29 +105,37 @@ func (s *Service) executeCrossCurrencyTransfer(ctx context.Context, req Internal
 	if err := s.payments.Create(ctx, tx, p); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: create payment: %w", err)
 	}
+	if err := s.captureHold(ctx, tx, req.HoldID, p, now); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+	}
 
-	if err := s.writeCrossCurrencyLedgerEntries(ctx, tx, p, sender, fxSrc, fxDst, recipient); err != nil {
+	if err := s.writeCrossCurrencyLedgerEntries(ctx, tx, p, sender, fxSrc, fxDst, recipient, rev); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
 
-	if err := s.writePaymentEvent(ctx, tx, p.ID, domain.PaymentEventTypeCompleted, req.SenderUserID, now); err != nil {
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCompleted, req.SenderUserID, now); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
 	}
 
-	if err := s.accounts.UpdateBalance(ctx, tx, sender.ID, sender.Balance-req.Amount, sender.Version+1); err != nil {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: update sender: %w", err)
+	updates := []domain.BalanceUpdate{
+		{AccountID: sender.ID, Balance: sender.Balance - req.Amount, Version: sender.Version},
+		{AccountID: fxSrc.ID, Balance: fxSrc.Balance + req.Amount, Version: fxSrc.Version},
+		{AccountID: fxDst.ID, Balance: fxDst.Balance - conversion.Dest.Amount - conversion.Fee.Amount, Version: fxDst.Version},
+		{AccountID: recipient.ID, Balance: recipient.Balance + conversion.Dest.Amount, Version: recipient.Version},
 	}
-	if err := s.accounts.UpdateBalance(ctx, tx, fxSrc.ID, fxSrc.Balance+req.Amount, fxSrc.Version+1); err != nil {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: update fx pool source: %w", err)
+	if p.Fee.IsPositive() {
+		updates = append(updates, domain.BalanceUpdate{AccountID: rev.ID, Balance: rev.Balance + p.Fee.Amount, Version: rev.Version})
 	}
-	if err := s.accounts.UpdateBalance(ctx, tx, fxDst.ID, fxDst.Balance-conversion.DestAmount, fxDst.Version+1); err != nil {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: update fx pool dest: %w", err)
+	if _, err := s.accounts.UpdateBalances(ctx, tx, updates); err != nil {
+		return nil, fmt.Errorf("executeCrossCurrencyTransfer: update balances: %w", err)
 	}
-	if err := s.accounts.UpdateBalance(ctx, tx, recipient.ID, recipient.Balance+conversion.DestAmount, recipient.Version+1); err != nil {
-		return nil, fmt.Errorf("executeCrossCurrencyTransfer: update recipient: %w", err)
+	if req.sweep {
+		if err := s.accounts.Close(ctx, tx, senderID); err != nil {
+			return nil, fmt.Errorf("executeCrossCurrencyTransfer: %w", err)
+		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("executeCrossCurrencyTransfer: commit: %w", err)
 	}
 
@@ -119,39 +152,56 @@ func (s *Service) getSystemAccount(ctx context.Context, accountType domain.Accou
 
 func (s *Service) writeCrossCurrencyLedgerEntries(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx pgx.Tx,
 	p *domain.Payment,
-	sender, fxPoolSource, fxPoolDest, recipient *domain.Account,
+	sender, fxPoolSource, fxPoolDest, recipient, revenue *domain.Account,
 ) error {
-	entries := []struct {
+	type ledgerLine struct {
 		account   *domain.Account
 		entryType domain.EntryType
-		amount    int64
-		currency  domain.Currency
-		newBal    int64
-	}{
-		{sender, domain.EntryTypeDebit, p.SourceAmount, p.SourceCurrency, sender.Balance - p.SourceAmount},
-		{fxPoolSource, domain.EntryTypeCredit, p.SourceAmount, p.SourceCurrency, fxPoolSource.Balance + p.SourceAmount},
-		{fxPoolDest, domain.EntryTypeDebit, p.DestAmount, p.DestCurrency, fxPoolDest.Balance - p.DestAmount},
-		{recipient, domain.EntryTypeCredit, p.DestAmount, p.DestCurrency, recipient.Balance + p.DestAmount},
-	}
-
-	for _, e := range entries {
-		entry := &domain.LedgerEntry{
-			ID:            uuid.New(),
-			PaymentID:     p.ID,
-			AccountID:     e.account.ID,
-			EntryType:     e.entryType,
-			Amount:        e.amount,
-			Currency:      e.currency,
-			BalanceBefore: e.account.Balance,
-			BalanceAfter:  e.newBal,
-			CreatedAt:     p.CreatedAt,
-		}
-		if err := s.ledger.Create(ctx, tx, entry); err != nil {
-			return fmt.Errorf("writeCrossCurrencyLedgerEntries: %s %s: %w", e.entryType, e.account.ID, err)
+		category  domain.EntryCategory
+		amount    domain.Money
+	}
+	fxPoolDebit, err := p.Dest.Add(p.Fee)
+	if err != nil {
+		return fmt.Errorf("writeCrossCurrencyLedgerEntries: %w", err)
+	}
+	entries := []ledgerLine{
+		{sender, domain.EntryTypeDebit, domain.EntryCategoryPrincipal, p.Source},
+		{fxPoolSource, domain.EntryTypeCredit, domain.EntryCategoryFXSpread, p.Source},
+		{fxPoolDest, domain.EntryTypeDebit, domain.EntryCategoryFXSpread, fxPoolDebit},
+		{recipient, domain.EntryTypeCredit, domain.EntryCategoryPrincipal, p.Dest},
+	}
+	if p.Fee.IsPositive() {
+		entries = append(entries, ledgerLine{revenue, domain.EntryTypeCredit, domain.EntryCategoryFee, p.Fee})
+	}
+
+	batch := make([]*domain.LedgerEntry, len(entries))
+	for i, e := range entries {
+		entry, err := domain.NewLedgerEntry(p.ID, e.account, e.entryType, e.category, e.amount, p.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("writeCrossCurrencyLedgerEntries: %w", err)
 		}
+		batch[i] = entry
 	}
+	if err := s.ledger.CreateBatch(ctx, tx, batch); err != nil {
+		return fmt.Errorf("writeCrossCurrencyLedgerEntries: %w", err)
+	}
+
+	return nil
+}
 
+// creditFee moves the FX spread into the revenue account. The matching debit
+// is folded into the FX pool debit, which is taken at the mid-market amount.
+func (s *Service) creditFee(ctx context.Context, tx pgx.Tx, revenue *domain.Account, fee domain.Money) error {
+	if !fee.IsPositive() {
+		return nil
+	}
+	if fee.Currency != revenue.Currency {
+		return fmt.Errorf("creditFee: %s fee to %s account: %w", fee.Currency, revenue.Currency, domain.ErrCurrencyMismatch)
+	}
+	if err := s.accounts.UpdateBalance(ctx, tx, revenue.ID, revenue.Balance+fee.Amount, revenue.Version+1); err != nil {
+		return fmt.Errorf("creditFee: %w", err)
+	}
 	return nil
 }
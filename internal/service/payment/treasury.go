@@ -0,0 +1,138 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+)
+
+// PoolTransferRequest moves Amount (in FromCurrency minor units) out of one
+// FX pool and into another.
+type PoolTransferRequest struct {
+	ActorID        uuid.UUID
+	FromCurrency   domain.Currency
+	ToCurrency     domain.Currency
+	Amount         int64
+	IdempotencyKey string
+}
+
+// TransferBetweenPools rebalances the FX pools. The pools trade with each
+// other at the mid-market rate with no spread, the same way they trade in
+// every conversion, so the pair's combined value is unchanged. The payment
+// has the source pool as its source and the destination pool as its
+// destination, with one debit and one credit on the ledger.
+func (s *Service) TransferBetweenPools(ctx context.Context, req PoolTransferRequest) (*domain.Payment, error) {
+	if !req.FromCurrency.IsValid() || !req.ToCurrency.IsValid() {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", domain.ErrInvalidCurrency)
+	}
+	if req.FromCurrency == req.ToCurrency {
+		return nil, fmt.Errorf("TransferBetweenPools: pools must differ: %w", domain.ErrInvalidRequest)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", domain.ErrInvalidAmount)
+	}
+
+	conversion, err := s.fx.Convert(ctx, req.Amount, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	midAmount := conversion.MidMarketDest().Amount
+
+	from, err := s.getSystemAccount(ctx, domain.AccountTypeFXPool, req.FromCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	to, err := s.getSystemAccount(ctx, domain.AccountTypeFXPool, req.ToCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+
+	tx, err := repository.Begin(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, from.ID, to.ID)
+	if err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	src := locked[from.ID]
+	dst := locked[to.ID]
+
+	if err := verifyAccountActive(src, "source pool"); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	if err := verifyAccountActive(dst, "destination pool"); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	if src.Balance < req.Amount {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", domain.NewDomainError(domain.ErrInsufficientFunds, "fx_pool", req.FromCurrency))
+	}
+
+	idempotencyKey := req.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = "pool-transfer:" + uuid.NewString()
+	}
+
+	now := time.Now().UTC()
+	rate := conversion.MidMarketRate
+	p := &domain.Payment{
+		ID:              uuid.New(),
+		IdempotencyKey:  idempotencyKey,
+		Type:            domain.PaymentTypePoolTransfer,
+		Status:          domain.PaymentStatusCompleted,
+		SourceAccountID: src.ID,
+		DestAccountID:   &dst.ID,
+		Source:          domain.NewMoney(req.Amount, req.FromCurrency),
+		Dest:            domain.NewMoney(midAmount, req.ToCurrency),
+		Fee:             domain.NewMoney(0, req.ToCurrency),
+		Refunded:        domain.NewMoney(0, req.ToCurrency),
+		ExchangeRate:    &rate,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CompletedAt:     &now,
+	}
+
+	if err := s.payments.Create(ctx, tx, p); err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return nil, fmt.Errorf("TransferBetweenPools: %w", domain.ErrDuplicatePayment)
+		}
+		return nil, fmt.Errorf("TransferBetweenPools: create payment: %w", err)
+	}
+
+	if err := s.writeLedgerEntries(ctx, tx, p, src, dst); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+	if err := s.writePaymentEvent(ctx, tx, p, domain.PaymentEventTypeCompleted, req.ActorID, now); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: %w", err)
+	}
+
+	if err := s.accounts.UpdateBalance(ctx, tx, src.ID, src.Balance-req.Amount, src.Version+1); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: update source pool: %w", err)
+	}
+	if err := s.accounts.UpdateBalance(ctx, tx, dst.ID, dst.Balance+midAmount, dst.Version+1); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: update destination pool: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("TransferBetweenPools: commit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("fx pool transfer",
+		"payment_id", p.ID,
+		"actor_id", req.ActorID,
+		"from_currency", req.FromCurrency,
+		"to_currency", req.ToCurrency,
+		"source_amount", p.Source.Amount,
+		"dest_amount", p.Dest.Amount,
+	)
+	s.recordCreated(p)
+	return p, nil
+}
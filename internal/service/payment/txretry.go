@@ -0,0 +1,52 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/deadline"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	maxTxAttempts  = 3
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// withTxRetry runs fn up to maxTxAttempts times while it fails on transient
+// contention: an optimistic version conflict, a deadlock or a serialization
+// failure. fn must begin its own transaction with repository.Begin, so a
+// retry starts from a clean read of the rows and a failed attempt has rolled
+// back and left nothing behind.
+func withTxRetry[T any](ctx context.Context, op string, fn func() (T, error)) (T, error) {
+	deadline.Stage(ctx, "db:"+op)
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || !isTransientTxError(err) || attempt == maxTxAttempts {
+			return v, err
+		}
+
+		logging.FromContext(ctx).Warn("retrying payment transaction after contention",
+			"op", op,
+			"attempt", attempt,
+			"error", err,
+		)
+
+		wait := time.Duration(attempt)*txRetryBackoff + rand.N(txRetryBackoff)
+		select {
+		case <-ctx.Done():
+			return v, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// isTransientTxError reports contention a fresh transaction can get past.
+// Deadlocks and serialization failures reach here as domain.ErrTxContention,
+// classified by the transaction repository.Begin started.
+func isTransientTxError(err error) bool {
+	return errors.Is(err, domain.ErrVersionConflict) || errors.Is(err, domain.ErrTxContention)
+}
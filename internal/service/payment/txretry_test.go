@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestWithTxRetry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"version conflict", fmt.Errorf("UpdateBalance: %w", domain.ErrVersionConflict), maxTxAttempts},
+		{"contention", fmt.Errorf("commit: %w", domain.ErrTxContention), maxTxAttempts},
+		{"duplicate key", fmt.Errorf("Create: %w", domain.ErrDuplicateIdempotencyKey), 1},
+		{"insufficient funds", domain.ErrInsufficientFunds, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			_, err := withTxRetry(ctx, "test", func() (int, error) {
+				calls++
+				return 0, tt.err
+			})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.attempts, calls)
+		})
+	}
+
+	t.Run("succeeds after contention", func(t *testing.T) {
+		calls := 0
+		v, err := withTxRetry(ctx, "test", func() (int, error) {
+			calls++
+			if calls == 1 {
+				return 0, domain.ErrVersionConflict
+			}
+			return 42, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, v)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("stops when the context ends", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		calls := 0
+		_, err := withTxRetry(ctx, "test", func() (int, error) {
+			calls++
+			return 0, domain.ErrVersionConflict
+		})
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -1,20 +1,38 @@
 package payment
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/config"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// stubUserRepo reports every user at the full KYC tier unless listed.
+type stubUserRepo map[uuid.UUID]domain.KYCTier
+
+func (s stubUserRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	tier, ok := s[id]
+	if !ok {
+		tier = domain.KYCTierFull
+	}
+	return &domain.User{ID: id, KYCTier: tier}, nil
+}
+
+func (s stubUserRepo) GetByUniqueName(context.Context, string) (*domain.User, error) {
+	return nil, domain.ErrNotFound
+}
+
 func newServiceWithConfig() *Service {
 	return &Service{
+		users: stubUserRepo{},
 		config: &config.Config{
-			TxLimitUSD: 10_000_000,
-			TxLimitEUR: 9_000_000,
-			TxLimitGBP: 8_000_000,
+			TxLimits:           map[string]int64{"USD": 10_000_000, "EUR": 9_000_000, "GBP": 8_000_000},
+			KYCReducedLimitPct: 20,
 		},
 	}
 }
@@ -142,7 +160,7 @@ func TestValidateTransfer(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := svc.validateTransfer(tc.req, tc.sender, tc.recipient)
+			err := svc.validateTransfer(context.Background(), tc.req, tc.sender, tc.recipient)
 
 			if tc.wantErr != nil {
 				require.ErrorIs(t, err, tc.wantErr)
@@ -227,7 +245,7 @@ func TestValidateExternalPayout(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := svc.validateExternalPayout(tc.req, tc.sender)
+			err := svc.validateExternalPayout(context.Background(), tc.req, tc.sender)
 
 			if tc.wantErr != nil {
 				require.ErrorIs(t, err, tc.wantErr)
@@ -238,3 +256,210 @@ func TestValidateExternalPayout(t *testing.T) {
 	}
 }
 
+func TestCheckRetriable(t *testing.T) {
+	code := func(c domain.FailureCode) *domain.FailureCode { return &c }
+
+	tests := []struct {
+		name    string
+		payment *domain.Payment
+		wantErr error
+	}{
+		{
+			name:    "failed payout without code",
+			payment: &domain.Payment{Type: domain.PaymentTypeExternalPayout, Status: domain.PaymentStatusFailed},
+		},
+		{
+			name:    "failed payout with retriable code",
+			payment: &domain.Payment{Type: domain.PaymentTypeExternalPayout, Status: domain.PaymentStatusFailed, FailureCode: code(domain.FailureCodeBankUnavailable)},
+		},
+		{
+			name:    "failed payout with non-retriable code",
+			payment: &domain.Payment{Type: domain.PaymentTypeExternalPayout, Status: domain.PaymentStatusFailed, FailureCode: code(domain.FailureCodeInvalidAccount)},
+			wantErr: domain.ErrPaymentNotRetriable,
+		},
+		{
+			name:    "pending payout",
+			payment: &domain.Payment{Type: domain.PaymentTypeExternalPayout, Status: domain.PaymentStatusPending},
+			wantErr: domain.ErrPaymentNotRetriable,
+		},
+		{
+			name:    "internal transfer",
+			payment: &domain.Payment{Type: domain.PaymentTypeInternalTransfer, Status: domain.PaymentStatusFailed},
+			wantErr: domain.ErrPaymentNotRetriable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkRetriable(tc.payment)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+type stubLimitRepo map[uuid.UUID]int64
+
+func (s stubLimitRepo) Get(_ context.Context, userID uuid.UUID, currency domain.Currency) (*domain.UserLimit, error) {
+	limit, ok := s[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.UserLimit{UserID: userID, Currency: currency, TxLimit: limit}, nil
+}
+
+func TestValidateTransfer_UserLimitOverride(t *testing.T) {
+	svc := newServiceWithConfig()
+	raised := uuid.New()
+	lowered := uuid.New()
+	svc.limits = stubLimitRepo{raised: 50_000_000, lowered: 1_000}
+
+	req := InternalTransferRequest{Amount: 20_000_000, SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD}
+	recipient := activeAccount(uuid.New(), domain.CurrencyUSD)
+
+	err := svc.validateTransfer(context.Background(), req, activeAccount(raised, domain.CurrencyUSD), recipient)
+	require.NoError(t, err)
+
+	err = svc.validateTransfer(context.Background(), req, activeAccount(uuid.New(), domain.CurrencyUSD), recipient)
+	require.ErrorIs(t, err, domain.ErrLimitExceeded)
+
+	req.Amount = 1_001
+	err = svc.validateTransfer(context.Background(), req, activeAccount(lowered, domain.CurrencyUSD), recipient)
+	require.ErrorIs(t, err, domain.ErrLimitExceeded)
+}
+
+func TestValidateExternalPayout_KYCTier(t *testing.T) {
+	svc := newServiceWithConfig()
+	unverified := uuid.New()
+	basic := uuid.New()
+	svc.users = stubUserRepo{unverified: domain.KYCTierUnverified, basic: domain.KYCTierBasic}
+
+	req := ExternalPayoutRequest{Amount: 1000, SourceCurrency: domain.CurrencyUSD, DestIBAN: "DE89370400440532013000", DestBankName: "Deutsche Bank"}
+
+	err := svc.validateExternalPayout(context.Background(), req, activeAccount(unverified, domain.CurrencyUSD))
+	require.ErrorIs(t, err, domain.ErrKYCTierInsufficient)
+
+	err = svc.validateExternalPayout(context.Background(), req, activeAccount(basic, domain.CurrencyUSD))
+	require.NoError(t, err)
+
+	// basic users get 20% of the 10,000,000 USD default
+	req.Amount = 2_000_001
+	err = svc.validateExternalPayout(context.Background(), req, activeAccount(basic, domain.CurrencyUSD))
+	require.ErrorIs(t, err, domain.ErrLimitExceeded)
+
+	err = svc.validateExternalPayout(context.Background(), req, activeAccount(uuid.New(), domain.CurrencyUSD))
+	require.NoError(t, err)
+}
+
+func TestValidateTransfer_UnverifiedReducedLimit(t *testing.T) {
+	svc := newServiceWithConfig()
+	unverified := uuid.New()
+	svc.users = stubUserRepo{unverified: domain.KYCTierUnverified}
+	recipient := activeAccount(uuid.New(), domain.CurrencyUSD)
+
+	req := InternalTransferRequest{Amount: 2_000_000, SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD}
+	require.NoError(t, svc.validateTransfer(context.Background(), req, activeAccount(unverified, domain.CurrencyUSD), recipient))
+
+	req.Amount = 2_000_001
+	err := svc.validateTransfer(context.Background(), req, activeAccount(unverified, domain.CurrencyUSD), recipient)
+	require.ErrorIs(t, err, domain.ErrLimitExceeded)
+}
+
+func TestValidateTransfer_FreezeReasonOnlyForOwnAccount(t *testing.T) {
+	svc := newServiceWithConfig()
+	req := InternalTransferRequest{Amount: 1000, SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD}
+	reason := domain.FreezeReasonFraudSuspected
+	frozen := func() *domain.Account {
+		a := activeAccount(uuid.New(), domain.CurrencyUSD)
+		a.Status = domain.AccountStatusFrozen
+		a.FreezeReason = &reason
+		return a
+	}
+
+	err := svc.validateTransfer(context.Background(), req, frozen(), activeAccount(uuid.New(), domain.CurrencyUSD))
+	var afe *domain.AccountFrozenError
+	require.ErrorAs(t, err, &afe)
+	require.Equal(t, domain.FreezeReasonFraudSuspected, afe.Reason)
+
+	err = svc.validateTransfer(context.Background(), req, activeAccount(uuid.New(), domain.CurrencyUSD), frozen())
+	require.ErrorIs(t, err, domain.ErrAccountFrozen)
+	require.False(t, errors.As(err, &afe), "the sender isn't told why the recipient is frozen")
+}
+
+// residentUsers gives each listed user a country of residence.
+type residentUsers map[uuid.UUID]string
+
+func (s residentUsers) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	u := &domain.User{ID: id, KYCTier: domain.KYCTierFull}
+	if country, ok := s[id]; ok {
+		u.Country = &country
+	}
+	return u, nil
+}
+
+func (s residentUsers) GetByUniqueName(context.Context, string) (*domain.User, error) {
+	return nil, domain.ErrNotFound
+}
+
+// blockedCountries forbids payments landing in the listed countries and
+// remembers what it was asked.
+type blockedCountries struct {
+	countries map[string]bool
+	checks    []ResidencyCheck
+}
+
+func (b *blockedCountries) CheckResidency(_ context.Context, check ResidencyCheck) error {
+	b.checks = append(b.checks, check)
+	if b.countries[check.DestCountry] {
+		return &domain.ResidencyRestrictedError{Residency: check.Residency, DestCountry: check.DestCountry}
+	}
+	return nil
+}
+
+func TestValidate_Residency(t *testing.T) {
+	svc := newServiceWithConfig()
+	resident, recipientUser, unset := uuid.New(), uuid.New(), uuid.New()
+	svc.users = residentUsers{resident: "NG", recipientUser: "IR"}
+	checker := &blockedCountries{countries: map[string]bool{"IR": true}}
+	svc.residency = checker
+
+	payout := ExternalPayoutRequest{Amount: 1000, SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyEUR, DestIBAN: "de89 3704 0044 0532 0130 00", DestBankName: "Deutsche Bank"}
+	require.NoError(t, svc.validateExternalPayout(context.Background(), payout, activeAccount(resident, domain.CurrencyUSD)))
+	require.Len(t, checker.checks, 1)
+	assert.Equal(t, ResidencyCheck{
+		SenderUserID:   resident,
+		Residency:      "NG",
+		Type:           domain.PaymentTypeExternalPayout,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyEUR,
+		DestCountry:    "DE",
+	}, checker.checks[0])
+
+	payout.DestIBAN = "IR062960000000100324200001"
+	err := svc.validateExternalPayout(context.Background(), payout, activeAccount(resident, domain.CurrencyUSD))
+	var rre *domain.ResidencyRestrictedError
+	require.ErrorAs(t, err, &rre)
+	assert.Equal(t, "IR", rre.DestCountry)
+
+	transfer := InternalTransferRequest{Amount: 1000, SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD}
+	err = svc.validateTransfer(context.Background(), transfer, activeAccount(resident, domain.CurrencyUSD), activeAccount(recipientUser, domain.CurrencyUSD))
+	require.ErrorIs(t, err, domain.ErrResidencyRestricted, "an internal transfer lands in the recipient's residency")
+
+	checker.checks = nil
+	require.NoError(t, svc.validateExternalPayout(context.Background(), payout, activeAccount(unset, domain.CurrencyUSD)))
+	assert.Empty(t, checker.checks, "senders without a residency aren't checked")
+}
+
+func TestCreate_RequiresIdempotencyKey(t *testing.T) {
+	svc := newServiceWithConfig()
+	ctx := context.Background()
+
+	_, err := svc.CreateInternalTransfer(ctx, InternalTransferRequest{SenderUserID: uuid.New(), RecipientUniqueName: "bob", Amount: 1000})
+	assert.ErrorIs(t, err, domain.ErrMissingIdempotencyKey)
+
+	_, err = svc.CreateExternalPayout(ctx, ExternalPayoutRequest{SenderUserID: uuid.New(), Amount: 1000, DestIBAN: "DE89370400440532013000", DestBankName: "Deutsche Bank"})
+	assert.ErrorIs(t, err, domain.ErrMissingIdempotencyKey)
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/archive"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type paymentEventStore interface {
+	GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.PaymentEvent, error)
+	ListBefore(ctx context.Context, before time.Time, limit int) ([]domain.PaymentEvent, error)
+	DeleteThrough(ctx context.Context, tx pgx.Tx, before time.Time, last *domain.PaymentEvent) (int64, error)
+}
+
+type paymentEventArchiveRepo interface {
+	Record(ctx context.Context, tx pgx.Tx, a *domain.PaymentEventArchive, paymentIDs []uuid.UUID) error
+	ObjectKeysForPayments(ctx context.Context, paymentIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+}
+
+// PaymentEventArchiver moves payment events older than the retention window
+// to the archive store as gzipped NDJSON, one object per batch, and deletes
+// them from Postgres. GetByPaymentID and GetByPaymentIDs read payment history
+// back from both places, so callers don't need to know where an event lives.
+type PaymentEventArchiver struct {
+	events    paymentEventStore
+	archives  paymentEventArchiveRepo
+	store     archive.Store
+	db        *pgxpool.Pool
+	logger    *slog.Logger
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+}
+
+// NewPaymentEventArchiver builds the archiver. A nil store disables
+// archiving; GetByPaymentID then reads Postgres only.
+func NewPaymentEventArchiver(
+	events paymentEventStore,
+	archives paymentEventArchiveRepo,
+	store archive.Store,
+	db *pgxpool.Pool,
+	logger *slog.Logger,
+	interval, retention time.Duration,
+	batchSize int,
+) *PaymentEventArchiver {
+	return &PaymentEventArchiver{
+		events:    events,
+		archives:  archives,
+		store:     store,
+		db:        db,
+		logger:    logger,
+		interval:  interval,
+		retention: retention,
+		batchSize: batchSize,
+	}
+}
+
+// archivedPaymentEvent is one NDJSON line in an archive object.
+type archivedPaymentEvent struct {
+	ID        uuid.UUID               `json:"id"`
+	PaymentID uuid.UUID               `json:"payment_id"`
+	EventType domain.PaymentEventType `json:"event_type"`
+	Actor     string                  `json:"actor"`
+	Payload   json.RawMessage         `json:"payload,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+func (a *PaymentEventArchiver) Start(ctx context.Context) {
+	if a.store == nil {
+		a.logger.Info("payment event archiver disabled, no archive store configured")
+		return
+	}
+	a.logger.Info("payment event archiver started", "interval", a.interval, "retention", a.retention)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.logger.Info("payment event archiver stopped")
+			return
+		case <-ticker.C:
+			a.run(ctx)
+		}
+	}
+}
+
+// run archives batches until nothing older than the retention window is
+// left or a batch fails.
+func (a *PaymentEventArchiver) run(ctx context.Context) {
+	cutoff := time.Now().UTC().Add(-a.retention)
+	total := 0
+	for ctx.Err() == nil {
+		n, err := a.archiveBatch(ctx, cutoff)
+		if err != nil {
+			a.logger.Error("failed to archive payment events", "error", err)
+			break
+		}
+		total += n
+		if n < a.batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		a.logger.Info("payment events archived", "events", total, "cutoff", cutoff)
+	}
+}
+
+// archiveBatch writes the oldest batch of events before cutoff to the store,
+// then records the batch and deletes the events in one transaction. If the
+// transaction fails the object is left orphaned and the events stay in
+// Postgres, to be archived again next run.
+func (a *PaymentEventArchiver) archiveBatch(ctx context.Context, cutoff time.Time) (int, error) {
+	events, err := a.events.ListBefore(ctx, cutoff, a.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("archiveBatch: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	first, last := events[0], events[len(events)-1]
+	key := fmt.Sprintf("payment_events/%s/%s.ndjson.gz", first.CreatedAt.UTC().Format("2006/01/02"), uuid.New())
+
+	body, err := encodePaymentEvents(events)
+	if err != nil {
+		return 0, fmt.Errorf("archiveBatch: %w", err)
+	}
+	if err := a.store.Put(ctx, key, bytes.NewReader(body)); err != nil {
+		return 0, fmt.Errorf("archiveBatch: %w", err)
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("archiveBatch: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	seen := make(map[uuid.UUID]bool)
+	var paymentIDs []uuid.UUID
+	for _, e := range events {
+		if !seen[e.PaymentID] {
+			seen[e.PaymentID] = true
+			paymentIDs = append(paymentIDs, e.PaymentID)
+		}
+	}
+
+	if err := a.archives.Record(ctx, tx, &domain.PaymentEventArchive{
+		ObjectKey:      key,
+		EventCount:     len(events),
+		FirstCreatedAt: first.CreatedAt,
+		LastCreatedAt:  last.CreatedAt,
+		ArchivedAt:     time.Now().UTC(),
+	}, paymentIDs); err != nil {
+		return 0, fmt.Errorf("archiveBatch: %w", err)
+	}
+
+	deleted, err := a.events.DeleteThrough(ctx, tx, cutoff, &last)
+	if err != nil {
+		return 0, fmt.Errorf("archiveBatch: %w", err)
+	}
+	if deleted != int64(len(events)) {
+		return 0, fmt.Errorf("archiveBatch: %s: wrote %d events but would delete %d", key, len(events), deleted)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("archiveBatch: commit: %w", err)
+	}
+	return len(events), nil
+}
+
+// GetByPaymentID returns a payment's events from Postgres and the archive,
+// oldest first.
+func (a *PaymentEventArchiver) GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.PaymentEvent, error) {
+	events, err := a.GetByPaymentIDs(ctx, []uuid.UUID{paymentID})
+	if err != nil {
+		return nil, fmt.Errorf("GetByPaymentID: %w", err)
+	}
+	return events, nil
+}
+
+// GetByPaymentIDs returns the events of several payments from Postgres and
+// the archive, oldest first. Each archive object is read once however many
+// of the payments it holds events for.
+func (a *PaymentEventArchiver) GetByPaymentIDs(ctx context.Context, paymentIDs []uuid.UUID) ([]domain.PaymentEvent, error) {
+	events, err := a.events.GetByPaymentIDs(ctx, paymentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: %w", err)
+	}
+	if a.store == nil {
+		return events, nil
+	}
+
+	keysByPayment, err := a.archives.ObjectKeysForPayments(ctx, paymentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("GetByPaymentIDs: %w", err)
+	}
+	if len(keysByPayment) == 0 {
+		return events, nil
+	}
+
+	wanted := make(map[string]map[uuid.UUID]bool)
+	var keys []string
+	for _, id := range paymentIDs {
+		for _, key := range keysByPayment[id] {
+			if wanted[key] == nil {
+				wanted[key] = make(map[uuid.UUID]bool)
+				keys = append(keys, key)
+			}
+			wanted[key][id] = true
+		}
+	}
+	for _, key := range keys {
+		archived, err := a.readArchived(ctx, key, wanted[key])
+		if err != nil {
+			return nil, fmt.Errorf("GetByPaymentIDs: %w", err)
+		}
+		events = append(events, archived...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func (a *PaymentEventArchiver) readArchived(ctx context.Context, key string, paymentIDs map[uuid.UUID]bool) ([]domain.PaymentEvent, error) {
+	rc, err := a.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("readArchived: %w", err)
+	}
+	defer rc.Close()
+
+	events, err := decodePaymentEvents(rc, paymentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("readArchived: %s: %w", key, err)
+	}
+	return events, nil
+}
+
+func encodePaymentEvents(events []domain.PaymentEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, e := range events {
+		if err := enc.Encode(archivedPaymentEvent{
+			ID:        e.ID,
+			PaymentID: e.PaymentID,
+			EventType: e.EventType,
+			Actor:     e.Actor,
+			Payload:   e.Payload,
+			CreatedAt: e.CreatedAt,
+		}); err != nil {
+			return nil, fmt.Errorf("encodePaymentEvents: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("encodePaymentEvents: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodePaymentEvents reads a gzipped NDJSON archive object and keeps the
+// events for the given payments.
+func decodePaymentEvents(r io.Reader, paymentIDs map[uuid.UUID]bool) ([]domain.PaymentEvent, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decodePaymentEvents: %w", err)
+	}
+	defer zr.Close()
+
+	var events []domain.PaymentEvent
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var line archivedPaymentEvent
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("decodePaymentEvents: %w", err)
+		}
+		if !paymentIDs[line.PaymentID] {
+			continue
+		}
+		events = append(events, domain.PaymentEvent{
+			ID:        line.ID,
+			PaymentID: line.PaymentID,
+			EventType: line.EventType,
+			Actor:     line.Actor,
+			Payload:   line.Payload,
+			CreatedAt: line.CreatedAt,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("decodePaymentEvents: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/archive"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubPaymentEvents struct {
+	live []domain.PaymentEvent
+}
+
+func (s *stubPaymentEvents) GetByPaymentIDs(_ context.Context, paymentIDs []uuid.UUID) ([]domain.PaymentEvent, error) {
+	var out []domain.PaymentEvent
+	for _, e := range s.live {
+		if slices.Contains(paymentIDs, e.PaymentID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubPaymentEvents) ListBefore(context.Context, time.Time, int) ([]domain.PaymentEvent, error) {
+	return nil, nil
+}
+
+func (s *stubPaymentEvents) DeleteThrough(context.Context, pgx.Tx, time.Time, *domain.PaymentEvent) (int64, error) {
+	return 0, nil
+}
+
+type stubPaymentEventArchives struct {
+	keys map[uuid.UUID][]string
+}
+
+func (s *stubPaymentEventArchives) Record(context.Context, pgx.Tx, *domain.PaymentEventArchive, []uuid.UUID) error {
+	return nil
+}
+
+func (s *stubPaymentEventArchives) ObjectKeysForPayments(_ context.Context, paymentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	out := make(map[uuid.UUID][]string)
+	for _, id := range paymentIDs {
+		if keys, ok := s.keys[id]; ok {
+			out[id] = keys
+		}
+	}
+	return out, nil
+}
+
+// countingStore counts the objects read from an archive store.
+type countingStore struct {
+	archive.Store
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.gets++
+	return s.Store.Get(ctx, key)
+}
+
+func TestPaymentEventArchiver_GetByPaymentIDMergesArchive(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	paymentID, otherID := uuid.New(), uuid.New()
+
+	created := domain.PaymentEvent{ID: uuid.New(), PaymentID: paymentID, EventType: domain.PaymentEventTypeCreated, Actor: "system", CreatedAt: t0}
+	other := domain.PaymentEvent{ID: uuid.New(), PaymentID: otherID, EventType: domain.PaymentEventTypeCreated, Actor: "system", CreatedAt: t0.Add(time.Second)}
+	processing := domain.PaymentEvent{ID: uuid.New(), PaymentID: paymentID, EventType: domain.PaymentEventTypeProcessing, Actor: "system", Payload: json.RawMessage(`{"provider":"mock_provider"}`), CreatedAt: t0.Add(time.Minute)}
+	completed := domain.PaymentEvent{ID: uuid.New(), PaymentID: paymentID, EventType: domain.PaymentEventTypeCompleted, Actor: "webhook", CreatedAt: t0.Add(time.Hour)}
+
+	store := archive.NewFSStore(t.TempDir())
+	body, err := encodePaymentEvents([]domain.PaymentEvent{created, other, processing})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, "payment_events/2025/03/01/a.ndjson.gz", bytes.NewReader(body)))
+
+	a := NewPaymentEventArchiver(
+		&stubPaymentEvents{live: []domain.PaymentEvent{completed}},
+		&stubPaymentEventArchives{keys: map[uuid.UUID][]string{paymentID: {"payment_events/2025/03/01/a.ndjson.gz"}}},
+		store, nil, slog.Default(), time.Hour, 24*time.Hour, 100,
+	)
+
+	events, err := a.GetByPaymentID(ctx, paymentID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, created.ID, events[0].ID)
+	assert.Equal(t, processing.ID, events[1].ID)
+	assert.JSONEq(t, `{"provider":"mock_provider"}`, string(events[1].Payload))
+	assert.True(t, processing.CreatedAt.Equal(events[1].CreatedAt))
+	assert.Equal(t, completed.ID, events[2].ID)
+}
+
+func TestPaymentEventArchiver_GetByPaymentIDMissingObject(t *testing.T) {
+	paymentID := uuid.New()
+	a := NewPaymentEventArchiver(
+		&stubPaymentEvents{},
+		&stubPaymentEventArchives{keys: map[uuid.UUID][]string{paymentID: {"payment_events/gone.ndjson.gz"}}},
+		archive.NewFSStore(t.TempDir()), nil, slog.Default(), time.Hour, 24*time.Hour, 100,
+	)
+
+	_, err := a.GetByPaymentID(context.Background(), paymentID)
+	assert.ErrorIs(t, err, archive.ErrObjectNotFound)
+}
+
+func TestPaymentEventArchiver_GetByPaymentIDsReadsEachObjectOnce(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	first, second, unrelated := uuid.New(), uuid.New(), uuid.New()
+
+	a1 := domain.PaymentEvent{ID: uuid.New(), PaymentID: first, EventType: domain.PaymentEventTypeCreated, Actor: "system", CreatedAt: t0}
+	b1 := domain.PaymentEvent{ID: uuid.New(), PaymentID: second, EventType: domain.PaymentEventTypeCreated, Actor: "system", CreatedAt: t0.Add(time.Second)}
+	c1 := domain.PaymentEvent{ID: uuid.New(), PaymentID: unrelated, EventType: domain.PaymentEventTypeCreated, Actor: "system", CreatedAt: t0.Add(2 * time.Second)}
+	a2 := domain.PaymentEvent{ID: uuid.New(), PaymentID: first, EventType: domain.PaymentEventTypeCompleted, Actor: "webhook", CreatedAt: t0.Add(time.Hour)}
+
+	store := &countingStore{Store: archive.NewFSStore(t.TempDir())}
+	body, err := encodePaymentEvents([]domain.PaymentEvent{a1, b1, c1})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, "payment_events/2025/03/01/a.ndjson.gz", bytes.NewReader(body)))
+
+	a := NewPaymentEventArchiver(
+		&stubPaymentEvents{live: []domain.PaymentEvent{a2}},
+		&stubPaymentEventArchives{keys: map[uuid.UUID][]string{
+			first:     {"payment_events/2025/03/01/a.ndjson.gz"},
+			second:    {"payment_events/2025/03/01/a.ndjson.gz"},
+			unrelated: {"payment_events/2025/03/01/a.ndjson.gz"},
+		}},
+		store, nil, slog.Default(), time.Hour, 24*time.Hour, 100,
+	)
+
+	events, err := a.GetByPaymentIDs(ctx, []uuid.UUID{first, second})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, a1.ID, events[0].ID)
+	assert.Equal(t, b1.ID, events[1].ID)
+	assert.Equal(t, a2.ID, events[2].ID)
+	assert.Equal(t, 1, store.gets)
+}
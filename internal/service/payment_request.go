@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/templates"
+)
+
+const (
+	maxPaymentRequestNoteLength = 500
+
+	notificationKindPaymentRequestReceived  = templates.PaymentRequestReceived
+	notificationKindPaymentRequestAccepted  = templates.PaymentRequestAccepted
+	notificationKindPaymentRequestDeclined  = templates.PaymentRequestDeclined
+	notificationKindPaymentRequestCancelled = templates.PaymentRequestCancelled
+	notificationKindPaymentRequestExpired   = templates.PaymentRequestExpired
+)
+
+type paymentRequestRepo interface {
+	Create(ctx context.Context, pr *domain.PaymentRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentRequest, error)
+	ListForUser(ctx context.Context, userID uuid.UUID, incoming, outgoing bool, status domain.PaymentRequestStatus, limit, offset int) ([]domain.PaymentRequest, error)
+	Respond(ctx context.Context, id uuid.UUID, status domain.PaymentRequestStatus, now time.Time) (*domain.PaymentRequest, error)
+	ReopenAccepted(ctx context.Context, id uuid.UUID) error
+	SetPayment(ctx context.Context, id, paymentID uuid.UUID) (*domain.PaymentRequest, error)
+	ExpireDue(ctx context.Context, now time.Time, limit int) ([]domain.PaymentRequest, error)
+}
+
+type paymentRequestUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetByUniqueName(ctx context.Context, uniqueName string) (*domain.User, error)
+}
+
+type paymentRequestAccountRepo interface {
+	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error)
+}
+
+type transferCreator interface {
+	CreateInternalTransfer(ctx context.Context, req payment.InternalTransferRequest) (*domain.Payment, error)
+}
+
+// PaymentRequestService lets users ask each other for money. Accepting a
+// request pays it with an ordinary internal transfer, so limits, KYC and
+// balance checks all apply to the payer as usual.
+type PaymentRequestService struct {
+	requests  paymentRequestRepo
+	users     paymentRequestUserRepo
+	accounts  paymentRequestAccountRepo
+	transfers transferCreator
+	notifier  Notifier
+	logger    *slog.Logger
+	ttl       time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+func NewPaymentRequestService(
+	requests paymentRequestRepo,
+	users paymentRequestUserRepo,
+	accounts paymentRequestAccountRepo,
+	transfers transferCreator,
+	notifier Notifier,
+	logger *slog.Logger,
+	ttl time.Duration,
+	interval time.Duration,
+) *PaymentRequestService {
+	return &PaymentRequestService{
+		requests:  requests,
+		users:     users,
+		accounts:  accounts,
+		transfers: transfers,
+		notifier:  notifier,
+		logger:    logger,
+		ttl:       ttl,
+		interval:  interval,
+		batchSize: 100,
+	}
+}
+
+type CreatePaymentRequestInput struct {
+	RequesterUserID uuid.UUID
+	PayerUniqueName string
+	Amount          int64
+	Currency        domain.Currency
+	Note            string
+}
+
+func (s *PaymentRequestService) Create(ctx context.Context, in CreatePaymentRequestInput) (*domain.PaymentRequest, error) {
+	if in.Amount <= 0 {
+		return nil, fmt.Errorf("Create: %w", domain.ErrInvalidAmount)
+	}
+	if !in.Currency.IsValid() {
+		return nil, fmt.Errorf("Create: %w", domain.ErrInvalidCurrency)
+	}
+	note := strings.TrimSpace(in.Note)
+	if len(note) > maxPaymentRequestNoteLength {
+		return nil, fmt.Errorf("Create: note too long: %w", domain.ErrInvalidRequest)
+	}
+
+	requester, err := s.users.GetByID(ctx, in.RequesterUserID)
+	if err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	if requester.UniqueName == nil {
+		return nil, fmt.Errorf("Create: requester has no unique name to be paid at: %w", domain.ErrInvalidRequest)
+	}
+
+	payer, err := s.users.GetByUniqueName(ctx, in.PayerUniqueName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("Create: %w", domain.ErrRecipientNotFound)
+		}
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	if payer.ID == requester.ID {
+		return nil, fmt.Errorf("Create: %w", domain.ErrSelfTransfer)
+	}
+
+	// The money lands in the requester's account in the requested currency,
+	// so it has to exist before anyone is asked to pay into it.
+	if _, err := s.accounts.GetByUserAndCurrency(ctx, requester.ID, in.Currency, domain.AccountTypeUser); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("Create: %w", domain.NewDomainError(domain.ErrAccountNotFound, "currency", in.Currency))
+		}
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+
+	now := time.Now().UTC()
+	pr := &domain.PaymentRequest{
+		ID:              uuid.New(),
+		RequesterUserID: requester.ID,
+		PayerUserID:     payer.ID,
+		Amount:          in.Amount,
+		Currency:        in.Currency,
+		Status:          domain.PaymentRequestStatusPending,
+		ExpiresAt:       now.Add(s.ttl),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if note != "" {
+		pr.Note = &note
+	}
+	if err := s.requests.Create(ctx, pr); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+
+	s.notify(ctx, pr, pr.PayerUserID, notificationKindPaymentRequestReceived, *requester.UniqueName)
+
+	logging.FromContext(ctx).Info("payment request created",
+		"payment_request_id", pr.ID,
+		"requester_id", pr.RequesterUserID,
+		"payer_id", pr.PayerUserID,
+		"amount", pr.Amount,
+		"currency", pr.Currency,
+	)
+	return pr, nil
+}
+
+// Get returns a request to either party.
+func (s *PaymentRequestService) Get(ctx context.Context, id, userID uuid.UUID) (*domain.PaymentRequest, error) {
+	pr, err := s.requests.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	if pr.RequesterUserID != userID && pr.PayerUserID != userID {
+		return nil, fmt.Errorf("Get: %w", domain.ErrNotFound)
+	}
+	return pr, nil
+}
+
+func (s *PaymentRequestService) List(ctx context.Context, userID uuid.UUID, incoming, outgoing bool, status domain.PaymentRequestStatus, limit, offset int) ([]domain.PaymentRequest, error) {
+	requests, err := s.requests.ListForUser(ctx, userID, incoming, outgoing, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return requests, nil
+}
+
+// Accept pays a request from the payer's account in the requested currency.
+// The request is claimed first so a concurrent decline, cancel or expiry
+// can't land while the transfer runs; if the transfer fails the claim is
+// released and the request stays open. The transfer's idempotency key is
+// derived from the request, so a request can never be paid twice.
+func (s *PaymentRequestService) Accept(ctx context.Context, id, payerID uuid.UUID) (*domain.PaymentRequest, *domain.Payment, error) {
+	log := logging.FromContext(ctx)
+
+	pr, err := s.pendingFor(ctx, id, payerID, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Accept: %w", err)
+	}
+
+	requester, err := s.users.GetByID(ctx, pr.RequesterUserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Accept: %w", err)
+	}
+	if requester.UniqueName == nil {
+		return nil, nil, fmt.Errorf("Accept: requester has no unique name: %w", domain.ErrRecipientNotFound)
+	}
+
+	if _, err := s.requests.Respond(ctx, pr.ID, domain.PaymentRequestStatusAccepted, time.Now().UTC()); err != nil {
+		return nil, nil, fmt.Errorf("Accept: %w", err)
+	}
+
+	p, err := s.transfers.CreateInternalTransfer(ctx, payment.InternalTransferRequest{
+		SenderUserID:        payerID,
+		RecipientUniqueName: *requester.UniqueName,
+		SourceCurrency:      pr.Currency,
+		DestCurrency:        pr.Currency,
+		Amount:              pr.Amount,
+		IdempotencyKey:      "payment-request:" + pr.ID.String(),
+	})
+	if err != nil {
+		if rerr := s.requests.ReopenAccepted(ctx, pr.ID); rerr != nil {
+			log.Error("failed to reopen payment request after transfer failure", "payment_request_id", pr.ID, "error", rerr)
+		}
+		return nil, nil, fmt.Errorf("Accept: %w", err)
+	}
+
+	pr, err = s.requests.SetPayment(ctx, pr.ID, p.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Accept: %w", err)
+	}
+
+	s.notify(ctx, pr, pr.RequesterUserID, notificationKindPaymentRequestAccepted, "")
+
+	log.Info("payment request accepted",
+		"payment_request_id", pr.ID,
+		"payment_id", p.ID,
+		"payer_id", payerID,
+	)
+	return pr, p, nil
+}
+
+func (s *PaymentRequestService) Decline(ctx context.Context, id, payerID uuid.UUID) (*domain.PaymentRequest, error) {
+	pr, err := s.close(ctx, id, payerID, false, domain.PaymentRequestStatusDeclined)
+	if err != nil {
+		return nil, fmt.Errorf("Decline: %w", err)
+	}
+	s.notify(ctx, pr, pr.RequesterUserID, notificationKindPaymentRequestDeclined, "")
+	return pr, nil
+}
+
+// Cancel withdraws a request. Only the requester can cancel.
+func (s *PaymentRequestService) Cancel(ctx context.Context, id, requesterID uuid.UUID) (*domain.PaymentRequest, error) {
+	pr, err := s.close(ctx, id, requesterID, true, domain.PaymentRequestStatusCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("Cancel: %w", err)
+	}
+	s.notify(ctx, pr, pr.PayerUserID, notificationKindPaymentRequestCancelled, "")
+	return pr, nil
+}
+
+func (s *PaymentRequestService) close(ctx context.Context, id, userID uuid.UUID, asRequester bool, status domain.PaymentRequestStatus) (*domain.PaymentRequest, error) {
+	if _, err := s.pendingFor(ctx, id, userID, asRequester); err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	pr, err := s.requests.Respond(ctx, id, status, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	logging.FromContext(ctx).Info("payment request closed",
+		"payment_request_id", pr.ID,
+		"status", status,
+		"user_id", userID,
+	)
+	return pr, nil
+}
+
+// pendingFor loads a request the user can act on. Requests where the user
+// isn't the acting party look the same as missing ones.
+func (s *PaymentRequestService) pendingFor(ctx context.Context, id, userID uuid.UUID, asRequester bool) (*domain.PaymentRequest, error) {
+	pr, err := s.requests.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("pendingFor: %w", err)
+	}
+	party := pr.PayerUserID
+	if asRequester {
+		party = pr.RequesterUserID
+	}
+	if party != userID {
+		return nil, fmt.Errorf("pendingFor: %w", domain.ErrNotFound)
+	}
+	if pr.IsExpired(time.Now().UTC()) {
+		return nil, fmt.Errorf("pendingFor: %w", domain.NewDomainError(domain.ErrPaymentRequestExpired, "expires_at", pr.ExpiresAt))
+	}
+	if pr.Status != domain.PaymentRequestStatusPending {
+		return nil, fmt.Errorf("pendingFor: %w", domain.NewDomainError(domain.ErrPaymentRequestClosed, "status", pr.Status))
+	}
+	return pr, nil
+}
+
+func (s *PaymentRequestService) Start(ctx context.Context) {
+	s.logger.Info("payment request expirer started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("payment request expirer stopped")
+			return
+		case <-ticker.C:
+			s.expire(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (s *PaymentRequestService) expire(ctx context.Context, now time.Time) {
+	for ctx.Err() == nil {
+		expired, err := s.requests.ExpireDue(ctx, now, s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to expire payment requests", "error", err)
+			return
+		}
+		for i := range expired {
+			pr := &expired[i]
+			s.notify(ctx, pr, pr.RequesterUserID, notificationKindPaymentRequestExpired, "")
+		}
+		if len(expired) > 0 {
+			s.logger.Info("payment requests expired", "count", len(expired))
+		}
+		if len(expired) < s.batchSize {
+			return
+		}
+	}
+}
+
+// notify is best effort: the request's state is already stored and visible
+// through the API, so a failed delivery is only logged. requester is only
+// shown in the received notification.
+func (s *PaymentRequestService) notify(ctx context.Context, pr *domain.PaymentRequest, userID uuid.UUID, kind, requester string) {
+	n, err := renderNotification(userID, kind, templates.PaymentRequestData{
+		ID:        pr.ID,
+		Requester: requester,
+		Amount:    pr.Amount,
+		Currency:  pr.Currency,
+		Status:    pr.Status,
+	})
+	if err != nil {
+		s.logger.Error("failed to render payment request notification",
+			"payment_request_id", pr.ID, "kind", kind, "error", err)
+		return
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		s.logger.Warn("failed to send payment request notification",
+			"payment_request_id", pr.ID, "user_id", userID, "kind", kind, "error", err)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type stubPaymentRequestRepo struct {
+	requests map[uuid.UUID]*domain.PaymentRequest
+}
+
+func (s *stubPaymentRequestRepo) Create(_ context.Context, pr *domain.PaymentRequest) error {
+	s.requests[pr.ID] = pr
+	return nil
+}
+
+func (s *stubPaymentRequestRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.PaymentRequest, error) {
+	pr, ok := s.requests[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	cp := *pr
+	return &cp, nil
+}
+
+func (s *stubPaymentRequestRepo) ListForUser(context.Context, uuid.UUID, bool, bool, domain.PaymentRequestStatus, int, int) ([]domain.PaymentRequest, error) {
+	return nil, nil
+}
+
+func (s *stubPaymentRequestRepo) Respond(_ context.Context, id uuid.UUID, status domain.PaymentRequestStatus, now time.Time) (*domain.PaymentRequest, error) {
+	pr, ok := s.requests[id]
+	if !ok || pr.Status != domain.PaymentRequestStatusPending || !now.Before(pr.ExpiresAt) {
+		return nil, domain.ErrPaymentRequestClosed
+	}
+	pr.Status = status
+	pr.RespondedAt = &now
+	cp := *pr
+	return &cp, nil
+}
+
+func (s *stubPaymentRequestRepo) ReopenAccepted(_ context.Context, id uuid.UUID) error {
+	pr := s.requests[id]
+	pr.Status = domain.PaymentRequestStatusPending
+	pr.RespondedAt = nil
+	return nil
+}
+
+func (s *stubPaymentRequestRepo) SetPayment(_ context.Context, id, paymentID uuid.UUID) (*domain.PaymentRequest, error) {
+	pr := s.requests[id]
+	pr.PaymentID = &paymentID
+	cp := *pr
+	return &cp, nil
+}
+
+func (s *stubPaymentRequestRepo) ExpireDue(_ context.Context, now time.Time, limit int) ([]domain.PaymentRequest, error) {
+	var out []domain.PaymentRequest
+	for _, pr := range s.requests {
+		if len(out) < limit && pr.IsExpired(now) {
+			pr.Status = domain.PaymentRequestStatusExpired
+			out = append(out, *pr)
+		}
+	}
+	return out, nil
+}
+
+type stubPaymentRequestUsers map[uuid.UUID]*domain.User
+
+func (s stubPaymentRequestUsers) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if u, ok := s[id]; ok {
+		return u, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s stubPaymentRequestUsers) GetByUniqueName(_ context.Context, name string) (*domain.User, error) {
+	for _, u := range s {
+		if u.UniqueName != nil && *u.UniqueName == name {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+type stubPaymentRequestAccounts struct{}
+
+func (stubPaymentRequestAccounts) GetByUserAndCurrency(_ context.Context, userID uuid.UUID, currency domain.Currency, _ domain.AccountType) (*domain.Account, error) {
+	return &domain.Account{ID: uuid.New(), UserID: userID, Currency: currency}, nil
+}
+
+type stubTransfers struct {
+	calls []payment.InternalTransferRequest
+	err   error
+}
+
+func (s *stubTransfers) CreateInternalTransfer(_ context.Context, req payment.InternalTransferRequest) (*domain.Payment, error) {
+	s.calls = append(s.calls, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.Payment{ID: uuid.New()}, nil
+}
+
+func newTestPaymentRequestService(t *testing.T) (*PaymentRequestService, *stubPaymentRequestRepo, *stubTransfers, *stubNotifier, *domain.User, *domain.User) {
+	t.Helper()
+	alice, bob := "alice", "bob"
+	requester := &domain.User{ID: uuid.New(), UniqueName: &alice}
+	payer := &domain.User{ID: uuid.New(), UniqueName: &bob}
+
+	repo := &stubPaymentRequestRepo{requests: map[uuid.UUID]*domain.PaymentRequest{}}
+	transfers := &stubTransfers{}
+	notifier := &stubNotifier{}
+	svc := NewPaymentRequestService(
+		repo, stubPaymentRequestUsers{requester.ID: requester, payer.ID: payer}, stubPaymentRequestAccounts{},
+		transfers, notifier, slog.Default(), time.Hour, time.Minute,
+	)
+	return svc, repo, transfers, notifier, requester, payer
+}
+
+func TestPaymentRequest_AcceptPaysRequester(t *testing.T) {
+	svc, _, transfers, notifier, requester, payer := newTestPaymentRequestService(t)
+	ctx := context.Background()
+
+	pr, err := svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "bob", Amount: 2500, Currency: domain.CurrencyUSD, Note: "dinner",
+	})
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, payer.ID, notifier.sent[0].UserID)
+	assert.Equal(t, notificationKindPaymentRequestReceived, notifier.sent[0].Kind)
+
+	accepted, p, err := svc.Accept(ctx, pr.ID, payer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestStatusAccepted, accepted.Status)
+	require.NotNil(t, accepted.PaymentID)
+	assert.Equal(t, p.ID, *accepted.PaymentID)
+
+	require.Len(t, transfers.calls, 1)
+	assert.Equal(t, payment.InternalTransferRequest{
+		SenderUserID:        payer.ID,
+		RecipientUniqueName: "alice",
+		SourceCurrency:      domain.CurrencyUSD,
+		DestCurrency:        domain.CurrencyUSD,
+		Amount:              2500,
+		IdempotencyKey:      "payment-request:" + pr.ID.String(),
+	}, transfers.calls[0])
+
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, requester.ID, notifier.sent[1].UserID)
+	assert.Equal(t, notificationKindPaymentRequestAccepted, notifier.sent[1].Kind)
+
+	_, _, err = svc.Accept(ctx, pr.ID, payer.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentRequestClosed)
+	assert.Len(t, transfers.calls, 1)
+}
+
+func TestPaymentRequest_FailedTransferReopensRequest(t *testing.T) {
+	svc, repo, transfers, _, requester, payer := newTestPaymentRequestService(t)
+	ctx := context.Background()
+
+	pr, err := svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "bob", Amount: 2500, Currency: domain.CurrencyUSD,
+	})
+	require.NoError(t, err)
+
+	transfers.err = domain.ErrInsufficientFunds
+	_, _, err = svc.Accept(ctx, pr.ID, payer.ID)
+	assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	assert.Equal(t, domain.PaymentRequestStatusPending, repo.requests[pr.ID].Status)
+
+	// The payer can still decline once the failed accept is rolled back.
+	declined, err := svc.Decline(ctx, pr.ID, payer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestStatusDeclined, declined.Status)
+}
+
+func TestPaymentRequest_OnlyTheActingPartyCanRespond(t *testing.T) {
+	svc, _, transfers, _, requester, payer := newTestPaymentRequestService(t)
+	ctx := context.Background()
+
+	pr, err := svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "bob", Amount: 100, Currency: domain.CurrencyEUR,
+	})
+	require.NoError(t, err)
+
+	_, _, err = svc.Accept(ctx, pr.ID, requester.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = svc.Decline(ctx, pr.ID, requester.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = svc.Cancel(ctx, pr.ID, payer.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = svc.Get(ctx, pr.ID, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Empty(t, transfers.calls)
+
+	cancelled, err := svc.Cancel(ctx, pr.ID, requester.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestStatusCancelled, cancelled.Status)
+}
+
+func TestPaymentRequest_CreateRejectsSelfAndUnknownPayer(t *testing.T) {
+	svc, _, _, _, requester, _ := newTestPaymentRequestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "alice", Amount: 100, Currency: domain.CurrencyUSD,
+	})
+	assert.ErrorIs(t, err, domain.ErrSelfTransfer)
+
+	_, err = svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "carol", Amount: 100, Currency: domain.CurrencyUSD,
+	})
+	assert.ErrorIs(t, err, domain.ErrRecipientNotFound)
+}
+
+func TestPaymentRequest_ExpiredRequestsCannotBeAccepted(t *testing.T) {
+	svc, repo, transfers, notifier, requester, payer := newTestPaymentRequestService(t)
+	ctx := context.Background()
+
+	pr, err := svc.Create(ctx, CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "bob", Amount: 100, Currency: domain.CurrencyGBP,
+	})
+	require.NoError(t, err)
+	repo.requests[pr.ID].ExpiresAt = time.Now().UTC().Add(-time.Second)
+
+	_, _, err = svc.Accept(ctx, pr.ID, payer.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentRequestExpired)
+	assert.Empty(t, transfers.calls)
+
+	svc.expire(ctx, time.Now().UTC())
+	assert.Equal(t, domain.PaymentRequestStatusExpired, repo.requests[pr.ID].Status)
+	last := notifier.sent[len(notifier.sent)-1]
+	assert.Equal(t, requester.ID, last.UserID)
+	assert.Equal(t, notificationKindPaymentRequestExpired, last.Kind)
+}
+
+func TestPaymentRequest_NotifierFailureIsNotFatal(t *testing.T) {
+	svc, _, _, notifier, requester, _ := newTestPaymentRequestService(t)
+	notifier.fail = true
+
+	_, err := svc.Create(context.Background(), CreatePaymentRequestInput{
+		RequesterUserID: requester.ID, PayerUniqueName: "bob", Amount: 100, Currency: domain.CurrencyUSD,
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// maxResolutionText caps the evidence and reason on a manual resolution.
+const maxResolutionText = 2000
+
+// manualFailureReason is the failure reason of a payout failed by hand
+// when the operator gives none.
+const manualFailureReason = "resolved_manually"
+
+// ResolvePaymentRequest is a provider outcome an operator confirmed through
+// some other channel, such as the provider's dashboard or a support ticket,
+// because its callback was lost.
+type ResolvePaymentRequest struct {
+	PaymentID  uuid.UUID
+	OperatorID uuid.UUID
+	// Status is completed or failed.
+	Status      domain.PaymentStatus
+	ProviderRef string
+	// FailureCode and Reason are for failed outcomes and optional.
+	FailureCode domain.FailureCode
+	Reason      string
+	// Evidence says where the outcome was confirmed. It goes into the audit
+	// log, not onto the payment.
+	Evidence string
+}
+
+func (r *ResolvePaymentRequest) normalize() error {
+	r.ProviderRef = strings.TrimSpace(r.ProviderRef)
+	r.Reason = strings.TrimSpace(r.Reason)
+	r.Evidence = strings.TrimSpace(r.Evidence)
+
+	switch {
+	case r.Status != domain.PaymentStatusCompleted && r.Status != domain.PaymentStatusFailed:
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "status", "value", r.Status)
+	case r.ProviderRef == "":
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "provider_ref")
+	case r.Evidence == "":
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "evidence")
+	case len(r.Evidence) > maxResolutionText:
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "evidence", "max_length", maxResolutionText)
+	case len(r.Reason) > maxResolutionText:
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "max_length", maxResolutionText)
+	case r.Status == domain.PaymentStatusCompleted && r.FailureCode != "":
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "failure_code", "value", r.FailureCode)
+	case r.Status == domain.PaymentStatusCompleted && r.Reason != "":
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "value", r.Reason)
+	case r.FailureCode != "" && !r.FailureCode.IsValid():
+		return domain.NewDomainError(domain.ErrInvalidRequest, "field", "failure_code", "value", r.FailureCode)
+	}
+	if r.Status == domain.PaymentStatusFailed && r.Reason == "" {
+		r.Reason = manualFailureReason
+	}
+	return nil
+}
+
+// ResolvePayment settles a payout whose provider callback was lost, with
+// the outcome the operator confirmed. It goes through applyOutcome like a
+// callback does, so the transition rules, reversal, ledger entries and
+// payment and outbox events are the callback's; the payment event names the
+// operator as its actor. Only payouts handed to a provider and still
+// waiting on it can be resolved. It returns the resolved payment and the
+// status it was in before.
+func (p *WebhookProcessor) ResolvePayment(ctx context.Context, req ResolvePaymentRequest) (*domain.Payment, domain.PaymentStatus, error) {
+	if err := req.normalize(); err != nil {
+		return nil, "", fmt.Errorf("ResolvePayment: %w", err)
+	}
+
+	pmt, err := p.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, "", fmt.Errorf("ResolvePayment: %w", err)
+	}
+	if err := checkResolvable(pmt); err != nil {
+		return nil, "", fmt.Errorf("ResolvePayment: %w", err)
+	}
+	before := pmt.Status
+
+	actor := fmt.Sprintf("user:%s", req.OperatorID)
+	err = p.applyOutcome(ctx, pmt, string(req.Status), req.ProviderRef, req.Reason, req.FailureCode, actor, uuid.Nil)
+	if errors.Is(err, domain.ErrPaymentTerminal) {
+		// A callback or the status poller settled it first.
+		err = domain.NewDomainError(domain.ErrPaymentNotResolvable, "reason", "resolved concurrently")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("ResolvePayment: %w", err)
+	}
+
+	resolved, err := p.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, "", fmt.Errorf("ResolvePayment: %w", err)
+	}
+
+	logging.FromContext(ctx).Warn("payout resolved by hand",
+		"payment_id", pmt.ID,
+		"operator_id", req.OperatorID,
+		"from", before,
+		"to", resolved.Status,
+		"provider_ref", req.ProviderRef,
+	)
+	if p.alerter != nil {
+		p.alerter.Alert(ctx, Alert{
+			Kind:    "payment_resolved_manually",
+			Subject: "Payout settled by hand instead of by its provider callback",
+			Attrs: []any{
+				"payment_id", pmt.ID,
+				"operator_id", req.OperatorID,
+				"status", resolved.Status,
+				"provider", *pmt.Provider,
+				"provider_ref", req.ProviderRef,
+			},
+		})
+	}
+	return resolved, before, nil
+}
+
+// checkResolvable admits the payouts the status poller would ask the
+// provider about: bank payouts submitted to a provider and not yet settled.
+// Anything else either never reached a provider or has an outcome already.
+func checkResolvable(p *domain.Payment) error {
+	bankPayout := p.Type == domain.PaymentTypeExternalPayout || (p.Type == domain.PaymentTypeSweep && p.DestAccountID == nil)
+	if !bankPayout {
+		return domain.NewDomainError(domain.ErrPaymentNotResolvable, "type", p.Type)
+	}
+	if p.Status != domain.PaymentStatusPending && p.Status != domain.PaymentStatusProcessing {
+		return domain.NewDomainError(domain.ErrPaymentNotResolvable, "status", p.Status)
+	}
+	if p.Provider == nil {
+		return domain.NewDomainError(domain.ErrPaymentNotResolvable, "reason", "not submitted to a provider")
+	}
+	return nil
+}
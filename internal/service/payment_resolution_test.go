@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+// createSubmittedPayout creates a USD payout and marks it handed to a
+// provider, which the test service has none of.
+func createSubmittedPayout(t *testing.T, db *pgxpool.Pool, paymentSvc *payment.Service, senderID uuid.UUID) *domain.Payment {
+	t.Helper()
+	p, err := paymentSvc.CreateExternalPayout(context.Background(), payment.ExternalPayoutRequest{
+		SenderUserID:   senderID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	_, err = db.Exec(context.Background(), `UPDATE payments SET provider = 'mock' WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+	return p
+}
+
+func TestWebhookProcessor_ResolvePayment_Failed(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_res")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	outgoingBefore := testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID)
+	p := createSubmittedPayout(t, db, paymentSvc, sender.ID)
+	operatorID := uuid.New()
+
+	resolved, before, err := processor.ResolvePayment(ctx, ResolvePaymentRequest{
+		PaymentID:   p.ID,
+		OperatorID:  operatorID,
+		Status:      domain.PaymentStatusFailed,
+		ProviderRef: "prov-ref-123",
+		FailureCode: domain.FailureCodeAccountClosed,
+		Evidence:    "provider ticket 4411",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, before)
+	assert.Equal(t, domain.PaymentStatusFailed, resolved.Status)
+	require.NotNil(t, resolved.FailureReason)
+	assert.Equal(t, "resolved_manually", *resolved.FailureReason)
+	require.NotNil(t, resolved.FailureCode)
+	assert.Equal(t, domain.FailureCodeAccountClosed, *resolved.FailureCode)
+
+	// Reversed exactly as a failure callback would be.
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, outgoingBefore, testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID))
+	assert.Equal(t, 4, testutil.CountLedgerEntries(t, db, p.ID))
+
+	events, err := repository.NewPaymentEventRepository(db).GetByPaymentID(ctx, p.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.PaymentEventTypeFailed, events[1].EventType)
+	assert.Equal(t, "user:"+operatorID.String(), events[1].Actor)
+
+	_, _, err = processor.ResolvePayment(ctx, ResolvePaymentRequest{
+		PaymentID:   p.ID,
+		OperatorID:  operatorID,
+		Status:      domain.PaymentStatusCompleted,
+		ProviderRef: "prov-ref-123",
+		Evidence:    "provider ticket 4411",
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentNotResolvable, "settled payouts cannot be resolved again")
+}
+
+func TestWebhookProcessor_ResolvePayment_RequiresProvider(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_unsub")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, _, err = processor.ResolvePayment(ctx, ResolvePaymentRequest{
+		PaymentID:   p.ID,
+		OperatorID:  uuid.New(),
+		Status:      domain.PaymentStatusCompleted,
+		ProviderRef: "prov-ref-123",
+		Evidence:    "provider ticket 4411",
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentNotResolvable)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+// RejectReview fails a payout in the review queue and returns the funds to
+// the sender through the same reversal used for provider failures.
+func (p *WebhookProcessor) RejectReview(ctx context.Context, paymentID, staffID uuid.UUID, reason string) (*domain.Payment, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("RejectReview: reason is required: %w", domain.ErrInvalidRequest)
+	}
+
+	pmt, err := p.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("RejectReview: %w", err)
+	}
+	if pmt.Status != domain.PaymentStatusPendingReview {
+		return nil, fmt.Errorf("RejectReview: %w", domain.NewDomainError(domain.ErrPaymentNotPendingReview, "status", pmt.Status))
+	}
+
+	if err := p.failPayout(ctx, pmt, reason, domain.FailureCodeComplianceRejected, fmt.Sprintf("user:%s", staffID), uuid.Nil); err != nil {
+		return nil, fmt.Errorf("RejectReview: %w", err)
+	}
+
+	rejected, err := p.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("RejectReview: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("payout rejected after review",
+		"payment_id", paymentID,
+		"staff_id", staffID,
+		"reason", reason,
+	)
+	return rejected, nil
+}
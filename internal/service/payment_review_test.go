@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/config"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/fx"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestReview_ScreeningRejectAndApprove(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	_, processor, _ := setupWebhookTest(t, db)
+
+	denylist := NewDenylistService(repository.NewDenylistRepository(db))
+	paymentSvc := payment.NewService(
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewPaymentEventRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		denylist,
+		nil,
+		nil,
+		nil,
+		db,
+		&config.Config{TxLimits: map[string]int64{"USD": 10_000_000}},
+	)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_review")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	staff := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_review")
+	outgoingBefore := testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID)
+
+	_, err := denylist.Add(ctx, AddDenylistEntryRequest{
+		Field: domain.DenylistFieldIBAN, Value: "de89 3704 0044 0532 0130 00", Reason: "sanctions list", ActorID: staff.ID,
+	})
+	require.NoError(t, err)
+
+	payout := func() *domain.Payment {
+		p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+			SenderUserID:   sender.ID,
+			SourceCurrency: domain.CurrencyUSD,
+			DestCurrency:   domain.CurrencyUSD,
+			Amount:         3000,
+			DestIBAN:       "DE89370400440532013000",
+			DestBankName:   "Deutsche Bank",
+			IdempotencyKey: uuid.NewString(),
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	held := payout()
+	assert.Equal(t, domain.PaymentStatusPendingReview, held.Status)
+	require.NotNil(t, held.ReviewReason)
+	assert.Equal(t, domain.ReviewReasonScreening, *held.ReviewReason)
+	assert.Nil(t, held.Provider)
+	assert.Equal(t, int64(7000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+
+	rejected, err := processor.RejectReview(ctx, held.ID, staff.ID, "confirmed sanctions match")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, rejected.Status)
+	require.NotNil(t, rejected.FailureCode)
+	assert.Equal(t, domain.FailureCodeComplianceRejected, *rejected.FailureCode)
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, outgoingBefore, testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID))
+
+	_, err = paymentSvc.ApproveReview(ctx, held.ID, staff.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentNotPendingReview)
+
+	second := payout()
+	require.Equal(t, domain.PaymentStatusPendingReview, second.Status)
+
+	approved, err := paymentSvc.ApproveReview(ctx, second.ID, staff.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, approved.Status)
+
+	_, err = processor.RejectReview(ctx, second.ID, staff.ID, "too late")
+	assert.ErrorIs(t, err, domain.ErrPaymentNotPendingReview)
+
+	events, err := repository.NewPaymentEventRepository(db).GetByPaymentID(ctx, second.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, domain.PaymentEventTypeHeld, events[1].EventType)
+	assert.Equal(t, domain.PaymentEventTypeReleased, events[2].EventType)
+	assert.Equal(t, "user:"+staff.ID.String(), events[2].Actor)
+}
+
+func TestReview_AmountThreshold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc := payment.NewService(
+		repository.NewPaymentRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewPaymentEventRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		db,
+		&config.Config{
+			TxLimits:         map[string]int64{"USD": 10_000_000},
+			ReviewThresholds: map[string]int64{"USD": 5000},
+		},
+	)
+
+	sender := testutil.SeedTestUser(t, db, "big@test.com", "Big", "big_sender")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 20000)
+
+	payout := func(amount int64) *domain.Payment {
+		p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+			SenderUserID:   sender.ID,
+			SourceCurrency: domain.CurrencyUSD,
+			DestCurrency:   domain.CurrencyUSD,
+			Amount:         amount,
+			DestIBAN:       "GB29NWBK60161331926819",
+			DestBankName:   "NatWest",
+			IdempotencyKey: uuid.NewString(),
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	flagged := payout(5000)
+	assert.Equal(t, domain.PaymentStatusPendingReview, flagged.Status)
+	require.NotNil(t, flagged.ReviewReason)
+	assert.Equal(t, domain.ReviewReasonAmountThreshold, *flagged.ReviewReason)
+	assert.Equal(t, int64(15000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+
+	queue, err := repository.NewPaymentRepository(db).ListPendingReview(ctx, domain.ReviewReasonAmountThreshold, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, flagged.ID, queue[0].ID)
+
+	queue, err = repository.NewPaymentRepository(db).ListPendingReview(ctx, domain.ReviewReasonScreening, 50, 0)
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+}
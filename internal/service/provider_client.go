@@ -4,31 +4,49 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
 )
 
+type submissionRecorder interface {
+	Record(ctx context.Context, s *domain.ProviderSubmission) error
+}
+
 type ProviderClient struct {
+	name        string
 	baseURL     string
 	callbackURL string
 	httpClient  *http.Client
+	tracker     *InFlightTracker
+	submissions submissionRecorder
 }
 
-func NewProviderClient(baseURL, callbackURL string) *ProviderClient {
+func NewProviderClient(name, baseURL, callbackURL string, tracker *InFlightTracker, submissions submissionRecorder) *ProviderClient {
 	return &ProviderClient{
+		name:        name,
 		baseURL:     baseURL,
 		callbackURL: callbackURL,
+		tracker:     tracker,
+		submissions: submissions,
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
 	}
 }
 
+func (c *ProviderClient) Name() string {
+	return c.name
+}
+
 type providerPayload struct {
 	PaymentID    string `json:"payment_id"`
 	Amount       int64  `json:"amount"`
@@ -38,7 +56,18 @@ type providerPayload struct {
 	CallbackURL  string `json:"callback_url"`
 }
 
+// SubmitPayment hands the payout to the provider and records the attempt.
+// A failure is returned as a *domain.ProviderError saying whether another
+// attempt can help.
 func (c *ProviderClient) SubmitPayment(ctx context.Context, req payment.ProviderRequest) error {
+	defer c.tracker.Track(domain.InFlightProviderSubmission, req.PaymentID.String())()
+
+	err := c.submit(ctx, req)
+	c.record(ctx, req.PaymentID, err)
+	return err
+}
+
+func (c *ProviderClient) submit(ctx context.Context, req payment.ProviderRequest) error {
 	log := logging.FromContext(ctx)
 
 	payload := providerPayload{
@@ -52,34 +81,102 @@ func (c *ProviderClient) SubmitPayment(ctx context.Context, req payment.Provider
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("SubmitPayment: marshal: %w", err)
+		return &domain.ProviderError{Class: domain.ProviderErrorValidation, Err: fmt.Errorf("SubmitPayment: marshal: %w", err)}
 	}
 
 	url := c.baseURL + "/process"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("SubmitPayment: build request: %w", err)
+		return &domain.ProviderError{Class: domain.ProviderErrorValidation, Err: fmt.Errorf("SubmitPayment: build request: %w", err)}
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	start := time.Now()
-	log.Info("provider request sent", "provider", "mock_provider", "payment_id", req.PaymentID)
+	log.Info("provider request sent", "provider", c.name, "payment_id", req.PaymentID)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("SubmitPayment: send: %w", err)
+		return &domain.ProviderError{Class: domain.ProviderErrorNetwork, Err: fmt.Errorf("SubmitPayment: send: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	log.Info("provider response received",
+		"provider", c.name,
 		"status", resp.StatusCode,
 		"duration_ms", time.Since(start).Milliseconds(),
 	)
 
 	if resp.StatusCode != http.StatusAccepted {
 		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return fmt.Errorf("SubmitPayment: unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return &domain.ProviderError{
+			Class:      domain.ProviderErrorClassForStatus(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("SubmitPayment: unexpected status %d: %s", resp.StatusCode, string(respBody)),
+		}
 	}
 
 	return nil
 }
+
+// record stores the attempt. Failing to is logged and otherwise ignored:
+// the payout is with the provider or not regardless.
+func (c *ProviderClient) record(ctx context.Context, paymentID uuid.UUID, err error) {
+	if c.submissions == nil {
+		return
+	}
+	s := &domain.ProviderSubmission{
+		ID:        uuid.New(),
+		PaymentID: paymentID,
+		Provider:  c.name,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		class := domain.ProviderErrorClassOf(err)
+		msg := err.Error()
+		s.ErrorClass = &class
+		s.Error = &msg
+		var pe *domain.ProviderError
+		if errors.As(err, &pe) && pe.StatusCode != 0 {
+			s.StatusCode = &pe.StatusCode
+		}
+	}
+	// A submission cut short by the caller's deadline is still recorded.
+	if err := c.submissions.Record(context.WithoutCancel(ctx), s); err != nil {
+		logging.FromContext(ctx).Error("failed to record provider submission", "payment_id", paymentID, "provider", c.name, "error", err)
+	}
+}
+
+type ProviderStatus struct {
+	Status      string `json:"status"`
+	ProviderRef string `json:"provider_ref,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Code        string `json:"code,omitempty"`
+}
+
+func (c *ProviderClient) GetStatus(ctx context.Context, paymentID uuid.UUID) (*ProviderStatus, error) {
+	url := c.baseURL + "/status/" + paymentID.String()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GetStatus: build request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("GetStatus: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("GetStatus: %w", domain.ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GetStatus: unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var status ProviderStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("GetStatus: decode: %w", err)
+	}
+	return &status, nil
+}
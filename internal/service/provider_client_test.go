@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type recordedSubmissions struct {
+	got []domain.ProviderSubmission
+}
+
+func (r *recordedSubmissions) Record(_ context.Context, s *domain.ProviderSubmission) error {
+	r.got = append(r.got, *s)
+	return nil
+}
+
+func TestProviderClient_ClassifiesSubmissionFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		class  domain.ProviderErrorClass
+	}{
+		{"server error", http.StatusServiceUnavailable, domain.ProviderErrorRetriable},
+		{"rate limited", http.StatusTooManyRequests, domain.ProviderErrorRetriable},
+		{"malformed", http.StatusUnprocessableEntity, domain.ProviderErrorValidation},
+		{"refused", http.StatusForbidden, domain.ProviderErrorPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			rec := &recordedSubmissions{}
+			client := NewProviderClient("mock", srv.URL, "http://cb", NewInFlightTracker(), rec)
+			err := client.SubmitPayment(context.Background(), payment.ProviderRequest{PaymentID: uuid.New()})
+
+			var pe *domain.ProviderError
+			require.True(t, errors.As(err, &pe))
+			assert.Equal(t, tt.class, pe.Class)
+			assert.Equal(t, tt.status, pe.StatusCode)
+
+			require.Len(t, rec.got, 1)
+			require.NotNil(t, rec.got[0].ErrorClass)
+			assert.Equal(t, tt.class, *rec.got[0].ErrorClass)
+			require.NotNil(t, rec.got[0].StatusCode)
+			assert.Equal(t, tt.status, *rec.got[0].StatusCode)
+		})
+	}
+}
+
+func TestProviderClient_NetworkFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	rec := &recordedSubmissions{}
+	client := NewProviderClient("mock", url, "http://cb", NewInFlightTracker(), rec)
+	err := client.SubmitPayment(context.Background(), payment.ProviderRequest{PaymentID: uuid.New()})
+
+	assert.Equal(t, domain.ProviderErrorNetwork, domain.ProviderErrorClassOf(err))
+	require.Len(t, rec.got, 1)
+	assert.Nil(t, rec.got[0].StatusCode)
+}
+
+func TestProviderClient_RecordsAcceptedSubmission(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	rec := &recordedSubmissions{}
+	client := NewProviderClient("mock", srv.URL, "http://cb", NewInFlightTracker(), rec)
+	require.NoError(t, client.SubmitPayment(context.Background(), payment.ProviderRequest{PaymentID: uuid.New()}))
+
+	require.Len(t, rec.got, 1)
+	assert.Nil(t, rec.got[0].ErrorClass)
+	assert.Equal(t, "mock", rec.got[0].Provider)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+// Routes are keyed by corridor ("USD-EUR") or destination currency ("EUR");
+// a corridor match wins, anything unmatched goes to the default provider.
+// A provider marked degraded for a corridor is skipped in favour of the next
+// candidate in that order, if one is healthy.
+type ProviderRouter struct {
+	providers       map[string]payment.Provider
+	routes          map[string]string
+	defaultProvider string
+
+	mu       sync.RWMutex
+	degraded map[string]bool
+}
+
+func NewProviderRouter(defaultProvider string, routes map[string]string) *ProviderRouter {
+	normalized := make(map[string]string, len(routes))
+	for k, v := range routes {
+		normalized[strings.ToUpper(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return &ProviderRouter{
+		providers:       make(map[string]payment.Provider),
+		routes:          normalized,
+		defaultProvider: defaultProvider,
+		degraded:        make(map[string]bool),
+	}
+}
+
+func (r *ProviderRouter) Register(p payment.Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *ProviderRouter) Get(name string) (payment.Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *ProviderRouter) Validate() error {
+	if _, ok := r.providers[r.defaultProvider]; r.defaultProvider != "" && !ok {
+		return fmt.Errorf("Validate: default provider %q not registered", r.defaultProvider)
+	}
+	for key, name := range r.routes {
+		if _, ok := r.providers[name]; !ok {
+			return fmt.Errorf("Validate: route %s references unknown provider %q", key, name)
+		}
+	}
+	return nil
+}
+
+func (r *ProviderRouter) Route(source, dest domain.Currency) (payment.Provider, error) {
+	candidates := r.candidates(source, dest)
+
+	p, ok := r.providers[candidates[0]]
+	if !ok {
+		return nil, fmt.Errorf("Route: %w", domain.NewDomainError(domain.ErrNoProviderRoute, "source_currency", source, "dest_currency", dest))
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.degraded[degradedKey(p.Name(), source, dest)] {
+		return p, nil
+	}
+	for _, name := range candidates[1:] {
+		alt, ok := r.providers[name]
+		if ok && !r.degraded[degradedKey(name, source, dest)] {
+			return alt, nil
+		}
+	}
+	return p, nil
+}
+
+// SetDegraded replaces the set of provider corridors currently breaching
+// their SLA. Keys are built with degradedKey.
+func (r *ProviderRouter) SetDegraded(keys map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.degraded = keys
+}
+
+func (r *ProviderRouter) IsDegraded(provider string, source, dest domain.Currency) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.degraded[degradedKey(provider, source, dest)]
+}
+
+func (r *ProviderRouter) candidates(source, dest domain.Currency) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string, ok bool) {
+		if ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	name, ok := r.routes[string(source)+"-"+string(dest)]
+	add(name, ok)
+	name, ok = r.routes[string(dest)]
+	add(name, ok)
+	add(r.defaultProvider, true)
+	return names
+}
+
+func degradedKey(provider string, source, dest domain.Currency) string {
+	return provider + "/" + string(source) + "-" + string(dest)
+}
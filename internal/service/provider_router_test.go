@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type stubProvider struct {
+	name string
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) SubmitPayment(context.Context, payment.ProviderRequest) error { return nil }
+
+func TestProviderRouter_Route(t *testing.T) {
+	router := NewProviderRouter("default", map[string]string{
+		"GBP":      "uk_rails",
+		"usd-eur ": "sepa",
+	})
+	router.Register(stubProvider{name: "default"})
+	router.Register(stubProvider{name: "uk_rails"})
+	router.Register(stubProvider{name: "sepa"})
+	require.NoError(t, router.Validate())
+
+	tests := []struct {
+		name   string
+		source domain.Currency
+		dest   domain.Currency
+		want   string
+	}{
+		{name: "dest currency route", source: domain.CurrencyUSD, dest: domain.CurrencyGBP, want: "uk_rails"},
+		{name: "corridor route", source: domain.CurrencyUSD, dest: domain.CurrencyEUR, want: "sepa"},
+		{name: "unmatched corridor falls back to default", source: domain.CurrencyGBP, dest: domain.CurrencyEUR, want: "default"},
+		{name: "same currency falls back to default", source: domain.CurrencyUSD, dest: domain.CurrencyUSD, want: "default"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := router.Route(tc.source, tc.dest)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, p.Name())
+		})
+	}
+}
+
+func TestProviderRouter_UnknownProvider(t *testing.T) {
+	router := NewProviderRouter("default", map[string]string{"EUR": "missing"})
+	router.Register(stubProvider{name: "default"})
+
+	assert.Error(t, router.Validate())
+
+	_, err := router.Route(domain.CurrencyUSD, domain.CurrencyEUR)
+	assert.ErrorIs(t, err, domain.ErrNoProviderRoute)
+}
+
+func TestProviderRouter_SkipsDegradedProvider(t *testing.T) {
+	router := NewProviderRouter("default", map[string]string{
+		"USD-EUR": "sepa",
+		"EUR":     "eu_rails",
+	})
+	router.Register(stubProvider{name: "default"})
+	router.Register(stubProvider{name: "sepa"})
+	router.Register(stubProvider{name: "eu_rails"})
+
+	router.SetDegraded(map[string]bool{degradedKey("sepa", domain.CurrencyUSD, domain.CurrencyEUR): true})
+	p, err := router.Route(domain.CurrencyUSD, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, "eu_rails", p.Name())
+
+	p, err = router.Route(domain.CurrencyGBP, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, "eu_rails", p.Name(), "degradation is per corridor")
+
+	router.SetDegraded(map[string]bool{
+		degradedKey("sepa", domain.CurrencyUSD, domain.CurrencyEUR):     true,
+		degradedKey("eu_rails", domain.CurrencyUSD, domain.CurrencyEUR): true,
+		degradedKey("default", domain.CurrencyUSD, domain.CurrencyEUR):  true,
+	})
+	p, err = router.Route(domain.CurrencyUSD, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, "sepa", p.Name(), "falls back to the configured route when every candidate is degraded")
+
+	router.SetDegraded(nil)
+	p, err = router.Route(domain.CurrencyUSD, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, "sepa", p.Name())
+}
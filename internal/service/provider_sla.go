@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type providerSLAStatsRepo interface {
+	Stats(ctx context.Context, from, to time.Time) ([]domain.ProviderSLAStats, error)
+}
+
+type degradableRouter interface {
+	SetDegraded(keys map[string]bool)
+	IsDegraded(provider string, source, dest domain.Currency) bool
+}
+
+type ProviderSLAConfig struct {
+	// P95Threshold is the callback latency a corridor's p95 must stay under.
+	P95Threshold time.Duration
+	// Window is how far back samples are considered when checking breaches.
+	Window time.Duration
+	// MinSamples avoids flagging a corridor off a handful of slow payouts.
+	MinSamples int
+	Interval   time.Duration
+}
+
+type ProviderSLAReport struct {
+	Stats     domain.ProviderSLAStats
+	Breaching bool
+	Degraded  bool
+}
+
+// ProviderSLAMonitor periodically computes callback latency percentiles per
+// provider and corridor and tells the router which corridors to route around.
+type ProviderSLAMonitor struct {
+	stats  providerSLAStatsRepo
+	router degradableRouter
+	logger *slog.Logger
+	cfg    ProviderSLAConfig
+}
+
+func NewProviderSLAMonitor(stats providerSLAStatsRepo, router degradableRouter, logger *slog.Logger, cfg ProviderSLAConfig) *ProviderSLAMonitor {
+	return &ProviderSLAMonitor{stats: stats, router: router, logger: logger, cfg: cfg}
+}
+
+func (m *ProviderSLAMonitor) Start(ctx context.Context) {
+	m.logger.Info("provider SLA monitor started",
+		"interval", m.cfg.Interval,
+		"p95_threshold", m.cfg.P95Threshold,
+		"window", m.cfg.Window,
+	)
+
+	m.check(ctx)
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("provider SLA monitor stopped")
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *ProviderSLAMonitor) check(ctx context.Context) {
+	now := time.Now().UTC()
+	stats, err := m.stats.Stats(ctx, now.Add(-m.cfg.Window), now)
+	if err != nil {
+		m.logger.Error("failed to compute provider SLA stats", "error", err)
+		return
+	}
+
+	degraded := make(map[string]bool)
+	for _, s := range stats {
+		breaching := m.breaching(s)
+		was := m.router.IsDegraded(s.Provider, s.SourceCurrency, s.DestCurrency)
+		if breaching {
+			degraded[degradedKey(s.Provider, s.SourceCurrency, s.DestCurrency)] = true
+		}
+
+		switch {
+		case breaching && !was:
+			m.logger.Warn("provider SLA breached, deprioritizing corridor",
+				"provider", s.Provider,
+				"corridor", s.Corridor(),
+				"p95_ms", s.P95MS,
+				"samples", s.Samples,
+			)
+		case !breaching && was:
+			m.logger.Info("provider SLA recovered, restoring corridor",
+				"provider", s.Provider,
+				"corridor", s.Corridor(),
+				"p95_ms", s.P95MS,
+			)
+		}
+	}
+
+	m.router.SetDegraded(degraded)
+}
+
+// Report returns latency percentiles for the period along with whether each
+// corridor breaches the SLA and whether routing currently avoids it.
+func (m *ProviderSLAMonitor) Report(ctx context.Context, from, to time.Time) ([]ProviderSLAReport, error) {
+	stats, err := m.stats.Stats(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Report: %w", err)
+	}
+
+	reports := make([]ProviderSLAReport, len(stats))
+	for i, s := range stats {
+		reports[i] = ProviderSLAReport{
+			Stats:     s,
+			Breaching: m.breaching(s),
+			Degraded:  m.router.IsDegraded(s.Provider, s.SourceCurrency, s.DestCurrency),
+		}
+	}
+	return reports, nil
+}
+
+func (m *ProviderSLAMonitor) breaching(s domain.ProviderSLAStats) bool {
+	return s.Samples >= m.cfg.MinSamples && time.Duration(s.P95MS)*time.Millisecond > m.cfg.P95Threshold
+}
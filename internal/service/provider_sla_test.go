@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubSLAStats struct {
+	stats []domain.ProviderSLAStats
+}
+
+func (s stubSLAStats) Stats(context.Context, time.Time, time.Time) ([]domain.ProviderSLAStats, error) {
+	return s.stats, nil
+}
+
+func TestProviderSLAMonitor_DegradesBreachingCorridors(t *testing.T) {
+	router := NewProviderRouter("default", map[string]string{"EUR": "sepa"})
+	router.Register(stubProvider{name: "default"})
+	router.Register(stubProvider{name: "sepa"})
+
+	stats := stubSLAStats{stats: []domain.ProviderSLAStats{
+		{Provider: "sepa", SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyEUR, Samples: 50, P95MS: 20 * 60 * 1000},
+		{Provider: "sepa", SourceCurrency: domain.CurrencyGBP, DestCurrency: domain.CurrencyEUR, Samples: 50, P95MS: 30 * 1000},
+		{Provider: "default", SourceCurrency: domain.CurrencyUSD, DestCurrency: domain.CurrencyUSD, Samples: 3, P95MS: 60 * 60 * 1000},
+	}}
+	monitor := NewProviderSLAMonitor(stats, router, slog.Default(), ProviderSLAConfig{
+		P95Threshold: 15 * time.Minute,
+		Window:       time.Hour,
+		MinSamples:   20,
+		Interval:     time.Minute,
+	})
+
+	monitor.check(context.Background())
+
+	assert.True(t, router.IsDegraded("sepa", domain.CurrencyUSD, domain.CurrencyEUR))
+	assert.False(t, router.IsDegraded("sepa", domain.CurrencyGBP, domain.CurrencyEUR))
+	assert.False(t, router.IsDegraded("default", domain.CurrencyUSD, domain.CurrencyUSD), "too few samples to judge")
+
+	p, err := router.Route(domain.CurrencyUSD, domain.CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, "default", p.Name())
+
+	reports, err := monitor.Report(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+	assert.True(t, reports[0].Breaching)
+	assert.True(t, reports[0].Degraded)
+	assert.False(t, reports[1].Breaching)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type submissionRepo interface {
+	ListDue(ctx context.Context, now time.Time, backoff time.Duration, maxAttempts, limit int) ([]domain.ProviderSubmission, error)
+}
+
+type submissionPaymentRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	MarkSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// ProviderSubmissionRetrier acts on payouts whose hand-off to their
+// provider failed. Network and retriable failures are sent again, with the
+// wait doubling after each attempt; permanent and validation failures, and
+// payouts that run out of attempts, are failed and their debit reversed.
+type ProviderSubmissionRetrier struct {
+	submissions submissionRepo
+	payments    submissionPaymentRepo
+	providers   providerLookup
+	processor   *WebhookProcessor
+	logger      *slog.Logger
+	interval    time.Duration
+	backoff     time.Duration
+	maxAttempts int
+	batchSize   int
+}
+
+func NewProviderSubmissionRetrier(
+	submissions submissionRepo,
+	payments submissionPaymentRepo,
+	providers providerLookup,
+	processor *WebhookProcessor,
+	logger *slog.Logger,
+	interval, backoff time.Duration,
+	maxAttempts int,
+) *ProviderSubmissionRetrier {
+	return &ProviderSubmissionRetrier{
+		submissions: submissions,
+		payments:    payments,
+		providers:   providers,
+		processor:   processor,
+		logger:      logger,
+		interval:    interval,
+		backoff:     backoff,
+		maxAttempts: maxAttempts,
+		batchSize:   50,
+	}
+}
+
+func (r *ProviderSubmissionRetrier) Start(ctx context.Context) {
+	r.logger.Info("provider submission retrier started", "interval", r.interval, "backoff", r.backoff, "max_attempts", r.maxAttempts)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("provider submission retrier stopped")
+			return
+		case <-ticker.C:
+			r.poll(ctx)
+		}
+	}
+}
+
+func (r *ProviderSubmissionRetrier) poll(ctx context.Context) {
+	due, err := r.submissions.ListDue(ctx, time.Now().UTC(), r.backoff, r.maxAttempts, r.batchSize)
+	if err != nil {
+		r.logger.Error("failed to list due provider submissions", "error", err)
+		return
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := r.handle(ctx, &due[i]); err != nil {
+			r.logger.Error("failed to act on provider submission", "payment_id", due[i].PaymentID, "error", err)
+		}
+	}
+}
+
+func (r *ProviderSubmissionRetrier) handle(ctx context.Context, last *domain.ProviderSubmission) error {
+	pmt, err := r.payments.GetByID(ctx, last.PaymentID)
+	if err != nil {
+		return fmt.Errorf("handle: %w", err)
+	}
+
+	// A callback for it in progress settles it either way.
+	if r.processor.paymentBusy(pmt.ID) {
+		return nil
+	}
+
+	class := *last.ErrorClass
+	if !class.IsRetriable() || last.Attempt >= r.maxAttempts {
+		return r.giveUp(ctx, pmt, last)
+	}
+
+	provider, ok := r.providers.Get(last.Provider)
+	if !ok {
+		r.logger.Warn("provider submission references unknown provider", "payment_id", pmt.ID, "provider", last.Provider)
+		return nil
+	}
+	err = provider.SubmitPayment(ctx, payment.ProviderRequest{
+		PaymentID:    pmt.ID,
+		Amount:       pmt.Dest.Amount,
+		Currency:     pmt.Dest.Currency,
+		DestIBAN:     stringValue(pmt.DestIBAN),
+		DestBankName: stringValue(pmt.DestBankName),
+	})
+	if err != nil {
+		r.logger.Warn("provider submission retry failed",
+			"payment_id", pmt.ID,
+			"provider", last.Provider,
+			"attempt", last.Attempt+1,
+			"class", domain.ProviderErrorClassOf(err),
+			"error", err,
+		)
+		return nil
+	}
+
+	r.logger.Info("provider submission retry accepted", "payment_id", pmt.ID, "provider", last.Provider, "attempt", last.Attempt+1)
+	if err := r.payments.MarkSubmitted(ctx, pmt.ID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("handle: %w", err)
+	}
+	return nil
+}
+
+// giveUp fails the payout and reverses its debit, as a failed callback
+// from the provider would.
+func (r *ProviderSubmissionRetrier) giveUp(ctx context.Context, pmt *domain.Payment, last *domain.ProviderSubmission) error {
+	class := *last.ErrorClass
+	reason := fmt.Sprintf("provider submission failed after %d attempt(s): %s error", last.Attempt, class)
+	if last.StatusCode != nil {
+		reason += fmt.Sprintf(" (HTTP %d)", *last.StatusCode)
+	}
+
+	r.logger.Warn("giving up on provider submission",
+		"payment_id", pmt.ID,
+		"provider", last.Provider,
+		"attempts", last.Attempt,
+		"class", class,
+	)
+	err := r.processor.handleFailed(ctx, pmt, reason, class.FailureCode(), "system", uuid.Nil)
+	// Settled some other way since it was listed.
+	if errors.Is(err, domain.ErrPaymentTerminal) || errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrVersionConflict) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func recordFailedSubmission(t *testing.T, db *pgxpool.Pool, paymentID uuid.UUID, provider string, class domain.ProviderErrorClass, statusCode int) {
+	t.Helper()
+
+	msg := "submission failed"
+	err := repository.NewProviderSubmissionRepository(db).Record(context.Background(), &domain.ProviderSubmission{
+		ID:         uuid.New(),
+		PaymentID:  paymentID,
+		Provider:   provider,
+		ErrorClass: &class,
+		StatusCode: &statusCode,
+		Error:      &msg,
+		CreatedAt:  time.Now().UTC().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+}
+
+func TestProviderSubmissionRetrier_ResubmitsRetriableFailure(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+	submissions := repository.NewProviderSubmissionRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_psr")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p := createStalePayout(t, db, paymentSvc, sender.ID, "flaky")
+	recordFailedSubmission(t, db, p.ID, "flaky", domain.ProviderErrorRetriable, 503)
+
+	router := NewProviderRouter("flaky", nil)
+	router.Register(stubProvider{name: "flaky"})
+
+	retrier := NewProviderSubmissionRetrier(submissions, repository.NewPaymentRepository(db), router, processor, slog.Default(), time.Second, time.Minute, 5)
+	retrier.poll(ctx)
+
+	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, updated.Status)
+	assert.NotNil(t, updated.SubmittedAt)
+
+	due, err := submissions.ListDue(ctx, time.Now().UTC(), time.Minute, 5, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestProviderSubmissionRetrier_WaitsOutBackoff(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	submissions := repository.NewProviderSubmissionRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_psr2")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p := createStalePayout(t, db, paymentSvc, sender.ID, "flaky")
+	recordFailedSubmission(t, db, p.ID, "flaky", domain.ProviderErrorNetwork, 0)
+
+	due, err := submissions.ListDue(ctx, time.Now().UTC(), 2*time.Hour, 5, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = submissions.ListDue(ctx, time.Now().UTC(), 30*time.Minute, 5, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, p.ID, due[0].PaymentID)
+	assert.Equal(t, 1, due[0].Attempt)
+}
+
+func TestProviderSubmissionRetrier_FailsPermanentRejection(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+	submissions := repository.NewProviderSubmissionRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_psr3")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p := createStalePayout(t, db, paymentSvc, sender.ID, "strict")
+	recordFailedSubmission(t, db, p.ID, "strict", domain.ProviderErrorPermanent, 403)
+
+	router := NewProviderRouter("strict", nil)
+	router.Register(stubProvider{name: "strict"})
+
+	retrier := NewProviderSubmissionRetrier(submissions, repository.NewPaymentRepository(db), router, processor, slog.Default(), time.Second, time.Hour, 5)
+	retrier.poll(ctx)
+
+	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, updated.Status)
+	require.NotNil(t, updated.FailureCode)
+	assert.Equal(t, domain.FailureCodeProviderRejected, *updated.FailureCode)
+	assert.Nil(t, updated.SubmittedAt)
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+
+	stats, err := submissions.FailureStats(ctx, time.Now().Add(-2*time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, domain.ProviderFailureStats{Provider: "strict", Attempts: 1, Permanent: 1}, stats[0])
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const (
+	// unusualAmountMultiple is how many times the sender's average a payment
+	// must be to count as unusual; unusualMinHistory is how many prior
+	// payments the average needs before it means anything.
+	unusualAmountMultiple = 5
+	unusualMinHistory     = 3
+	unusualWeightFactor   = 4
+	// qaSettleDelay keeps each scan a little behind the clock so payments
+	// whose transaction commits after their completed_at aren't skipped.
+	qaSettleDelay = time.Minute
+)
+
+type qaSampleRepo interface {
+	ListCandidates(ctx context.Context, afterAt time.Time, afterID uuid.UUID, until time.Time, limit int) ([]domain.QACandidate, error)
+	Create(ctx context.Context, s *domain.QASample) (bool, error)
+	MarkReviewed(ctx context.Context, id, staffID uuid.UUID, outcome domain.QAOutcome, note *string, at time.Time) (*domain.QASample, error)
+}
+
+type QASamplerConfig struct {
+	// RatePct is the chance, in percent, that an ordinary payment is picked.
+	RatePct float64
+	// LargeAmount is the per-currency amount each multiple of which adds one
+	// to a payment's weight. Missing or zero disables the boost.
+	LargeAmount map[domain.Currency]int64
+	// Lookback is how far back the first run after startup scans.
+	Lookback time.Duration
+	Interval time.Duration
+}
+
+// QASampler picks a small share of completed payments for manual QA review,
+// weighted toward large amounts and amounts far above what the sender
+// usually sends. The pick is a hash of the payment ID, so rescanning a window
+// after a restart picks exactly the same payments.
+type QASampler struct {
+	samples   qaSampleRepo
+	logger    *slog.Logger
+	cfg       QASamplerConfig
+	batchSize int
+
+	cursorAt time.Time
+	cursorID uuid.UUID
+}
+
+func NewQASampler(samples qaSampleRepo, logger *slog.Logger, cfg QASamplerConfig) *QASampler {
+	return &QASampler{samples: samples, logger: logger, cfg: cfg, batchSize: 500}
+}
+
+func (s *QASampler) Start(ctx context.Context) {
+	s.logger.Info("qa sampler started", "interval", s.cfg.Interval, "rate_pct", s.cfg.RatePct)
+
+	s.cursorAt = time.Now().UTC().Add(-s.cfg.Lookback)
+	s.run(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("qa sampler stopped")
+			return
+		case <-ticker.C:
+			s.run(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// run scans payments completed since the cursor. The cursor only advances
+// past candidates that were handled, so a failed insert is retried on the
+// next tick.
+func (s *QASampler) run(ctx context.Context, now time.Time) {
+	until := now.Add(-qaSettleDelay)
+	considered, sampled := 0, 0
+	for ctx.Err() == nil {
+		candidates, err := s.samples.ListCandidates(ctx, s.cursorAt, s.cursorID, until, s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to list qa candidates", "error", err)
+			return
+		}
+		for i := range candidates {
+			c := &candidates[i]
+			picked, err := s.consider(ctx, c, now)
+			if err != nil {
+				s.logger.Error("failed to record qa sample", "payment_id", c.PaymentID, "error", err)
+				return
+			}
+			if picked {
+				sampled++
+			}
+			considered++
+			s.cursorAt, s.cursorID = c.CompletedAt, c.PaymentID
+		}
+		if len(candidates) < s.batchSize {
+			break
+		}
+	}
+	if considered > 0 {
+		s.logger.Info("qa sampling run finished", "considered", considered, "sampled", sampled)
+	}
+}
+
+func (s *QASampler) consider(ctx context.Context, c *domain.QACandidate, now time.Time) (bool, error) {
+	weight, reason := s.weight(c)
+	p := math.Min(1, s.cfg.RatePct/100*weight)
+	if sampleDraw(c.PaymentID) >= p {
+		return false, nil
+	}
+
+	sample := &domain.QASample{
+		ID:          uuid.New(),
+		PaymentID:   c.PaymentID,
+		PaymentType: c.PaymentType,
+		Amount:      c.Amount,
+		Currency:    c.Currency,
+		Reason:      reason,
+		Weight:      weight,
+		Probability: p,
+		Status:      domain.QASampleStatusPending,
+		SampledAt:   now,
+	}
+	created, err := s.samples.Create(ctx, sample)
+	if err != nil {
+		return false, fmt.Errorf("consider: %w", err)
+	}
+	return created, nil
+}
+
+// weight starts every payment at 1. Each multiple of the currency's large
+// amount adds one, so a payment at ten times the threshold is eleven times as
+// likely to be picked. Payments far above the sender's usual size are then
+// multiplied again. The reason is whichever factor mattered most.
+func (s *QASampler) weight(c *domain.QACandidate) (float64, domain.QASampleReason) {
+	weight, reason := 1.0, domain.QASampleReasonRandom
+
+	if large := s.cfg.LargeAmount[c.Currency]; large > 0 && c.Amount >= large {
+		weight += float64(c.Amount) / float64(large)
+		reason = domain.QASampleReasonLargeAmount
+	}
+	if c.SenderPriorCount >= unusualMinHistory && c.SenderAvgAmount > 0 &&
+		c.Amount >= unusualAmountMultiple*c.SenderAvgAmount {
+		weight *= unusualWeightFactor
+		reason = domain.QASampleReasonUnusualAmount
+	}
+	return weight, reason
+}
+
+// sampleDraw maps a payment ID to a fixed point in [0, 1).
+func sampleDraw(id uuid.UUID) float64 {
+	sum := sha256.Sum256(id[:])
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// Review records a staff member's QA outcome. Finding an issue requires a
+// note explaining it.
+func (s *QASampler) Review(ctx context.Context, sampleID, staffID uuid.UUID, outcome domain.QAOutcome, note string) (*domain.QASample, error) {
+	if !outcome.IsValid() {
+		return nil, fmt.Errorf("Review: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "outcome", "value", outcome))
+	}
+	note = strings.TrimSpace(note)
+	if outcome == domain.QAOutcomeIssueFound && note == "" {
+		return nil, fmt.Errorf("Review: note is required for issue_found: %w", domain.ErrInvalidRequest)
+	}
+	var notePtr *string
+	if note != "" {
+		notePtr = &note
+	}
+
+	sample, err := s.samples.MarkReviewed(ctx, sampleID, staffID, outcome, notePtr, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("Review: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("qa sample reviewed",
+		"sample_id", sample.ID,
+		"payment_id", sample.PaymentID,
+		"staff_id", staffID,
+		"outcome", outcome,
+	)
+	return sample, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubQASampleRepo struct {
+	qaSampleRepo
+	candidates []domain.QACandidate
+	samples    map[uuid.UUID]*domain.QASample
+	failCreate bool
+}
+
+func (s *stubQASampleRepo) ListCandidates(_ context.Context, afterAt time.Time, afterID uuid.UUID, until time.Time, limit int) ([]domain.QACandidate, error) {
+	var out []domain.QACandidate
+	for _, c := range s.candidates {
+		after := c.CompletedAt.After(afterAt) || (c.CompletedAt.Equal(afterAt) && c.PaymentID.String() > afterID.String())
+		if after && !c.CompletedAt.After(until) && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubQASampleRepo) Create(_ context.Context, q *domain.QASample) (bool, error) {
+	if s.failCreate {
+		return false, errors.New("db down")
+	}
+	if _, ok := s.samples[q.PaymentID]; ok {
+		return false, nil
+	}
+	s.samples[q.PaymentID] = q
+	return true, nil
+}
+
+func newTestQASampler(repo *stubQASampleRepo, ratePct float64) *QASampler {
+	return NewQASampler(repo, slog.Default(), QASamplerConfig{
+		RatePct:     ratePct,
+		LargeAmount: map[domain.Currency]int64{domain.CurrencyUSD: 1_000_000},
+	})
+}
+
+func TestQASampler_Weight(t *testing.T) {
+	s := newTestQASampler(&stubQASampleRepo{}, 1)
+
+	tests := []struct {
+		name       string
+		c          domain.QACandidate
+		wantWeight float64
+		wantReason domain.QASampleReason
+	}{
+		{"small", domain.QACandidate{Amount: 5_000, Currency: domain.CurrencyUSD}, 1, domain.QASampleReasonRandom},
+		{"at large threshold", domain.QACandidate{Amount: 1_000_000, Currency: domain.CurrencyUSD}, 2, domain.QASampleReasonLargeAmount},
+		{"ten times large", domain.QACandidate{Amount: 10_000_000, Currency: domain.CurrencyUSD}, 11, domain.QASampleReasonLargeAmount},
+		{"no threshold for currency", domain.QACandidate{Amount: 10_000_000, Currency: domain.CurrencyGBP}, 1, domain.QASampleReasonRandom},
+		{"unusual for sender", domain.QACandidate{Amount: 50_000, Currency: domain.CurrencyUSD, SenderAvgAmount: 10_000, SenderPriorCount: 3}, 4, domain.QASampleReasonUnusualAmount},
+		{"too little history", domain.QACandidate{Amount: 50_000, Currency: domain.CurrencyUSD, SenderAvgAmount: 10_000, SenderPriorCount: 2}, 1, domain.QASampleReasonRandom},
+		{"large and unusual", domain.QACandidate{Amount: 1_000_000, Currency: domain.CurrencyUSD, SenderAvgAmount: 10_000, SenderPriorCount: 10}, 8, domain.QASampleReasonUnusualAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reason := s.weight(&tt.c)
+			assert.InDelta(t, tt.wantWeight, w, 1e-9)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestQASampler_RunSamplesDeterministicallyAndWeighted(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var candidates []domain.QACandidate
+	for i := 0; i < 4000; i++ {
+		amount := int64(5_000)
+		if i%2 == 1 {
+			amount = 10_000_000
+		}
+		candidates = append(candidates, domain.QACandidate{
+			PaymentID:   uuid.New(),
+			PaymentType: domain.PaymentTypeInternalTransfer,
+			Amount:      amount,
+			Currency:    domain.CurrencyUSD,
+			CompletedAt: now.Add(-time.Hour).Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+
+	repo := &stubQASampleRepo{candidates: candidates, samples: map[uuid.UUID]*domain.QASample{}}
+	s := newTestQASampler(repo, 2)
+	s.batchSize = 300
+	s.cursorAt = now.Add(-24 * time.Hour)
+	s.run(context.Background(), now)
+
+	small, large := 0, 0
+	for _, q := range repo.samples {
+		if q.Reason == domain.QASampleReasonLargeAmount {
+			large++
+		} else {
+			small++
+		}
+	}
+	// 2000 of each: ~40 small at 2%, ~440 large at 22%.
+	assert.Positive(t, small)
+	assert.Greater(t, large, 5*small)
+	assert.Equal(t, candidates[len(candidates)-1].PaymentID, s.cursorID)
+
+	// A restarted sampler rescanning the same window picks the same payments.
+	again := &stubQASampleRepo{candidates: candidates, samples: map[uuid.UUID]*domain.QASample{}}
+	s2 := newTestQASampler(again, 2)
+	s2.cursorAt = now.Add(-24 * time.Hour)
+	s2.run(context.Background(), now)
+	require.Len(t, again.samples, len(repo.samples))
+	for id := range repo.samples {
+		assert.Contains(t, again.samples, id)
+	}
+}
+
+func TestQASampler_CursorStopsAtFailure(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	c := domain.QACandidate{PaymentID: uuid.New(), Amount: 5_000, Currency: domain.CurrencyUSD, CompletedAt: now.Add(-time.Hour)}
+	repo := &stubQASampleRepo{candidates: []domain.QACandidate{c}, samples: map[uuid.UUID]*domain.QASample{}, failCreate: true}
+
+	s := newTestQASampler(repo, 100)
+	start := now.Add(-24 * time.Hour)
+	s.cursorAt = start
+	s.run(context.Background(), now)
+
+	assert.Equal(t, start, s.cursorAt)
+	assert.Empty(t, repo.samples)
+}
+
+func TestQASampler_ReviewRequiresNoteForIssues(t *testing.T) {
+	s := newTestQASampler(&stubQASampleRepo{}, 1)
+
+	_, err := s.Review(context.Background(), uuid.New(), uuid.New(), domain.QAOutcomeIssueFound, "  ")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	_, err = s.Review(context.Background(), uuid.New(), uuid.New(), "maybe", "")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+}
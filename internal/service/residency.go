@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+const maxResidencyReasonLength = 500
+
+type residencyRuleRepo interface {
+	Create(ctx context.Context, rule *domain.ResidencyRule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ResidencyRule, error)
+	List(ctx context.Context, country string, limit, offset int) ([]domain.ResidencyRule, error)
+	UpdateReason(ctx context.Context, id uuid.UUID, reason string, now time.Time) (*domain.ResidencyRule, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Match(ctx context.Context, country string, source, dest domain.Currency, destCountry string) (*domain.ResidencyRule, error)
+}
+
+type residencyUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	SetCountry(ctx context.Context, id uuid.UUID, country *string) (*domain.User, error)
+}
+
+// ResidencyService manages users' countries of residence and the rules on
+// which currency pairs and destinations each residency may send to, and
+// checks payments against them.
+type ResidencyService struct {
+	rules residencyRuleRepo
+	users residencyUserRepo
+	audit auditRecorder
+}
+
+func NewResidencyService(rules residencyRuleRepo, users residencyUserRepo, audit auditRecorder) *ResidencyService {
+	return &ResidencyService{rules: rules, users: users, audit: audit}
+}
+
+type AddResidencyRuleRequest struct {
+	Country        string
+	SourceCurrency *domain.Currency
+	DestCurrency   *domain.Currency
+	DestCountry    *string
+	Reason         string
+	ActorID        uuid.UUID
+}
+
+func (s *ResidencyService) Add(ctx context.Context, req AddResidencyRuleRequest) (*domain.ResidencyRule, error) {
+	country, ok := domain.NormalizeCountry(req.Country)
+	if !ok {
+		return nil, fmt.Errorf("Add: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "country", "value", req.Country))
+	}
+	for _, c := range []*domain.Currency{req.SourceCurrency, req.DestCurrency} {
+		if c != nil && !c.IsValid() {
+			return nil, fmt.Errorf("Add: %w", domain.NewDomainError(domain.ErrInvalidCurrency, "currency", *c))
+		}
+	}
+	var destCountry *string
+	if req.DestCountry != nil {
+		c, ok := domain.NormalizeCountry(*req.DestCountry)
+		if !ok {
+			return nil, fmt.Errorf("Add: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "dest_country", "value", *req.DestCountry))
+		}
+		destCountry = &c
+	}
+	reason, err := checkResidencyReason(req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("Add: %w", err)
+	}
+
+	now := time.Now().UTC()
+	rule := &domain.ResidencyRule{
+		ID:             uuid.New(),
+		Country:        country,
+		SourceCurrency: req.SourceCurrency,
+		DestCurrency:   req.DestCurrency,
+		DestCountry:    destCountry,
+		Reason:         reason,
+		CreatedBy:      req.ActorID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.rules.Create(ctx, rule); err != nil {
+		return nil, fmt.Errorf("Add: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("residency rule added", "rule_id", rule.ID, "country", rule.Country, "actor_id", req.ActorID)
+	return rule, nil
+}
+
+func (s *ResidencyService) Get(ctx context.Context, id uuid.UUID) (*domain.ResidencyRule, error) {
+	rule, err := s.rules.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return rule, nil
+}
+
+// List returns rules newest first. An empty country lists them all.
+func (s *ResidencyService) List(ctx context.Context, country string, limit, offset int) ([]domain.ResidencyRule, error) {
+	if country != "" {
+		c, ok := domain.NormalizeCountry(country)
+		if !ok {
+			return nil, fmt.Errorf("List: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "country", "value", country))
+		}
+		country = c
+	}
+	rules, err := s.rules.List(ctx, country, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *ResidencyService) UpdateReason(ctx context.Context, id uuid.UUID, reason string, actorID uuid.UUID) (*domain.ResidencyRule, error) {
+	reason, err := checkResidencyReason(reason)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+
+	rule, err := s.rules.UpdateReason(ctx, id, reason, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("UpdateReason: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("residency rule updated", "rule_id", rule.ID, "actor_id", actorID)
+	return rule, nil
+}
+
+// Remove deletes the rule and returns it as it was.
+func (s *ResidencyService) Remove(ctx context.Context, id uuid.UUID, actorID uuid.UUID) (*domain.ResidencyRule, error) {
+	rule, err := s.rules.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Remove: %w", err)
+	}
+	if err := s.rules.Delete(ctx, id); err != nil {
+		return nil, fmt.Errorf("Remove: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("residency rule removed", "rule_id", id, "country", rule.Country, "actor_id", actorID)
+	return rule, nil
+}
+
+// SetUserResidency records the user's verified country of residence. A nil
+// country clears it, lifting every residency rule from the user.
+func (s *ResidencyService) SetUserResidency(ctx context.Context, userID uuid.UUID, country *string, actorID uuid.UUID) (*domain.User, error) {
+	if country != nil {
+		c, ok := domain.NormalizeCountry(*country)
+		if !ok {
+			return nil, fmt.Errorf("SetUserResidency: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "country", "value", *country))
+		}
+		country = &c
+	}
+
+	before, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("SetUserResidency: %w", err)
+	}
+	u, err := s.users.SetCountry(ctx, userID, country)
+	if err != nil {
+		return nil, fmt.Errorf("SetUserResidency: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("user residency set", "user_id", userID, "country", stringValue(u.Country), "actor_id", actorID)
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:       domain.AuditActionResidencySet,
+		ResourceType: "user",
+		ResourceID:   userID.String(),
+		Before:       map[string]any{"country": before.Country},
+		After:        map[string]any{"country": u.Country},
+	})
+	return u, nil
+}
+
+// CheckResidency implements payment.ResidencyChecker. Every payment it turns
+// away is recorded in the audit log against the sender.
+func (s *ResidencyService) CheckResidency(ctx context.Context, check payment.ResidencyCheck) error {
+	rule, err := s.rules.Match(ctx, check.Residency, check.SourceCurrency, check.DestCurrency, check.DestCountry)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("CheckResidency: %w", err)
+	}
+
+	logging.FromContext(ctx).Warn("payment blocked by residency rule",
+		"user_id", check.SenderUserID,
+		"rule_id", rule.ID,
+		"residency", check.Residency,
+		"source_currency", check.SourceCurrency,
+		"dest_currency", check.DestCurrency,
+		"dest_country", check.DestCountry,
+	)
+	s.audit.Record(ctx, domain.AuditEntry{
+		ActorID:      &check.SenderUserID,
+		Action:       domain.AuditActionResidencyBlocked,
+		ResourceType: "user",
+		ResourceID:   check.SenderUserID.String(),
+		After: map[string]any{
+			"rule_id":         rule.ID,
+			"residency":       check.Residency,
+			"payment_type":    check.Type,
+			"source_currency": check.SourceCurrency,
+			"dest_currency":   check.DestCurrency,
+			"dest_country":    check.DestCountry,
+		},
+	})
+
+	return fmt.Errorf("CheckResidency: %w", &domain.ResidencyRestrictedError{
+		RuleID:         rule.ID,
+		Residency:      check.Residency,
+		SourceCurrency: check.SourceCurrency,
+		DestCurrency:   check.DestCurrency,
+		DestCountry:    check.DestCountry,
+	})
+}
+
+func checkResidencyReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxResidencyReasonLength {
+		return "", fmt.Errorf("reason: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "max_length", maxResidencyReasonLength))
+	}
+	return reason, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func TestResidencyService_RulesBlockAndAudit(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	audit := NewAuditService(repository.NewAuditLogRepository(db))
+	residency := NewResidencyService(repository.NewResidencyRuleRepository(db), repository.NewUserRepository(db), audit)
+
+	admin := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops")
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	ctx := auth.ContextWithUserID(context.Background(), admin.ID)
+
+	ng := "ng"
+	u, err := residency.SetUserResidency(ctx, alice.ID, &ng, admin.ID)
+	require.NoError(t, err)
+	require.NotNil(t, u.Country)
+	assert.Equal(t, "NG", *u.Country)
+
+	gbp := domain.CurrencyGBP
+	pairRule, err := residency.Add(ctx, AddResidencyRuleRequest{Country: "NG", SourceCurrency: &gbp, Reason: "no GBP outflows", ActorID: admin.ID})
+	require.NoError(t, err)
+	_, err = residency.Add(ctx, AddResidencyRuleRequest{Country: "ng", SourceCurrency: &gbp, ActorID: admin.ID})
+	require.ErrorIs(t, err, domain.ErrResidencyRuleExists, "same scope, different case")
+	ir := "IR"
+	_, err = residency.Add(ctx, AddResidencyRuleRequest{Country: "NG", DestCountry: &ir, ActorID: admin.ID})
+	require.NoError(t, err)
+
+	check := payment.ResidencyCheck{
+		SenderUserID:   alice.ID,
+		Residency:      "NG",
+		Type:           domain.PaymentTypeExternalPayout,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyEUR,
+		DestCountry:    "DE",
+	}
+	require.NoError(t, residency.CheckResidency(ctx, check))
+
+	check.SourceCurrency = domain.CurrencyGBP
+	err = residency.CheckResidency(ctx, check)
+	var rre *domain.ResidencyRestrictedError
+	require.ErrorAs(t, err, &rre)
+	assert.Equal(t, pairRule.ID, rre.RuleID)
+
+	check.SourceCurrency, check.DestCountry = domain.CurrencyUSD, "IR"
+	require.ErrorIs(t, residency.CheckResidency(ctx, check), domain.ErrResidencyRestricted)
+
+	check.Residency = "GH"
+	require.NoError(t, residency.CheckResidency(ctx, check), "rules only bind their own residency")
+
+	blocked, _, err := audit.List(ctx, domain.AuditFilter{Action: domain.AuditActionResidencyBlocked}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, blocked, 2)
+	require.NotNil(t, blocked[0].ActorID)
+	assert.Equal(t, alice.ID, *blocked[0].ActorID)
+	assert.Equal(t, alice.ID.String(), blocked[0].ResourceID)
+
+	_, err = residency.Remove(ctx, pairRule.ID, admin.ID)
+	require.NoError(t, err)
+	check.Residency, check.SourceCurrency, check.DestCountry = "NG", domain.CurrencyGBP, "DE"
+	require.NoError(t, residency.CheckResidency(ctx, check))
+
+	u, err = residency.SetUserResidency(ctx, alice.ID, nil, admin.ID)
+	require.NoError(t, err)
+	assert.Nil(t, u.Country)
+
+	set, _, err := audit.List(ctx, domain.AuditFilter{Action: domain.AuditActionResidencySet}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, set, 2)
+	assert.JSONEq(t, `{"country":"NG"}`, string(set[0].Before))
+	assert.JSONEq(t, `{"country":null}`, string(set[0].After))
+}
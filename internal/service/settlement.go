@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type settlementRepo interface {
+	GetOrCreateOpen(ctx context.Context, tx pgx.Tx, currency domain.Currency, date time.Time) (*domain.SettlementBatch, error)
+	AttachEligiblePayments(ctx context.Context, tx pgx.Tx, batch *domain.SettlementBatch) (int64, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SettlementBatch, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.SettlementBatch, error)
+	List(ctx context.Context, currency domain.Currency, status domain.SettlementStatus, limit, offset int) ([]domain.SettlementBatch, error)
+	ListItems(ctx context.Context, batchID uuid.UUID) ([]domain.SettlementItem, error)
+	Close(ctx context.Context, tx pgx.Tx, id uuid.UUID, closedBy uuid.UUID, closedAt time.Time) error
+}
+
+type SettlementService struct {
+	batches  settlementRepo
+	accounts wpAccountRepo
+	ledger   wpLedgerRepo
+	db       *pgxpool.Pool
+}
+
+func NewSettlementService(batches settlementRepo, accounts wpAccountRepo, ledger wpLedgerRepo, db *pgxpool.Pool) *SettlementService {
+	return &SettlementService{batches: batches, accounts: accounts, ledger: ledger, db: db}
+}
+
+type SettlementReport struct {
+	Batch *domain.SettlementBatch
+	Items []domain.SettlementItem
+}
+
+// BuildBatch attaches every completed payout for the currency and UTC day
+// that is not yet part of a batch to the day's open batch, creating it if needed.
+func (s *SettlementService) BuildBatch(ctx context.Context, currency domain.Currency, date time.Time) (*domain.SettlementBatch, error) {
+	log := logging.FromContext(ctx)
+
+	if !currency.IsValid() {
+		return nil, fmt.Errorf("BuildBatch: %w", domain.ErrInvalidCurrency)
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.After(time.Now().UTC()) {
+		return nil, fmt.Errorf("BuildBatch: settlement date is in the future: %w", domain.ErrInvalidRequest)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("BuildBatch: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := s.batches.GetOrCreateOpen(ctx, tx, currency, day)
+	if err != nil {
+		return nil, fmt.Errorf("BuildBatch: %w", err)
+	}
+
+	added, err := s.batches.AttachEligiblePayments(ctx, tx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("BuildBatch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("BuildBatch: commit: %w", err)
+	}
+
+	log.Info("settlement batch built",
+		"batch_id", batch.ID,
+		"currency", batch.Currency,
+		"settlement_date", day.Format(time.DateOnly),
+		"added", added,
+		"payment_count", batch.PaymentCount,
+		"total_amount", batch.TotalAmount,
+	)
+	return batch, nil
+}
+
+// CloseBatch sweeps the batch total out of the outgoing system account into
+// the settled system account, one debit/credit pair per payment.
+func (s *SettlementService) CloseBatch(ctx context.Context, batchID uuid.UUID, closedBy uuid.UUID) (*domain.SettlementBatch, error) {
+	log := logging.FromContext(ctx)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := s.batches.GetForUpdate(ctx, tx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+	if batch.Status != domain.SettlementStatusOpen {
+		return nil, fmt.Errorf("CloseBatch: %w", domain.ErrSettlementClosed)
+	}
+	if batch.PaymentCount == 0 {
+		return nil, fmt.Errorf("CloseBatch: %w", domain.ErrSettlementEmpty)
+	}
+
+	items, err := s.batches.ListItems(ctx, batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+
+	outgoing, err := s.getSystemAccount(ctx, domain.AccountTypeOutgoing, batch.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+	settled, err := s.getSystemAccount(ctx, domain.AccountTypeSettled, batch.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+
+	locked, err := lockAccountsInOrder(ctx, tx, s.accounts, outgoing.ID, settled.ID)
+	if err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+	outgoing, settled = locked[outgoing.ID], locked[settled.ID]
+
+	if outgoing.Balance < batch.TotalAmount {
+		return nil, fmt.Errorf("CloseBatch: %w", domain.NewDomainError(domain.ErrInsufficientFunds,
+			"account_id", outgoing.ID, "available", outgoing.Balance, "amount", batch.TotalAmount))
+	}
+
+	now := time.Now().UTC()
+	outgoingBalance, settledBalance := outgoing.Balance, settled.Balance
+	for _, it := range items {
+		debit := &domain.LedgerEntry{
+			ID:            uuid.New(),
+			PaymentID:     it.PaymentID,
+			AccountID:     outgoing.ID,
+			EntryType:     domain.EntryTypeDebit,
+			Category:      domain.EntryCategoryPrincipal,
+			Amount:        domain.NewMoney(it.Amount, batch.Currency),
+			BalanceBefore: outgoingBalance,
+			BalanceAfter:  outgoingBalance - it.Amount,
+			CreatedAt:     now,
+		}
+		if err := s.ledger.Create(ctx, tx, debit); err != nil {
+			return nil, fmt.Errorf("CloseBatch: debit outgoing: %w", err)
+		}
+		outgoingBalance -= it.Amount
+
+		credit := &domain.LedgerEntry{
+			ID:            uuid.New(),
+			PaymentID:     it.PaymentID,
+			AccountID:     settled.ID,
+			EntryType:     domain.EntryTypeCredit,
+			Category:      domain.EntryCategoryPrincipal,
+			Amount:        domain.NewMoney(it.Amount, batch.Currency),
+			BalanceBefore: settledBalance,
+			BalanceAfter:  settledBalance + it.Amount,
+			CreatedAt:     now,
+		}
+		if err := s.ledger.Create(ctx, tx, credit); err != nil {
+			return nil, fmt.Errorf("CloseBatch: credit settled: %w", err)
+		}
+		settledBalance += it.Amount
+	}
+
+	if err := s.accounts.UpdateBalance(ctx, tx, outgoing.ID, outgoingBalance, outgoing.Version+1); err != nil {
+		return nil, fmt.Errorf("CloseBatch: update outgoing: %w", err)
+	}
+	if err := s.accounts.UpdateBalance(ctx, tx, settled.ID, settledBalance, settled.Version+1); err != nil {
+		return nil, fmt.Errorf("CloseBatch: update settled: %w", err)
+	}
+
+	if err := s.batches.Close(ctx, tx, batch.ID, closedBy, now); err != nil {
+		return nil, fmt.Errorf("CloseBatch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("CloseBatch: commit: %w", err)
+	}
+
+	batch.Status = domain.SettlementStatusClosed
+	batch.ClosedAt = &now
+	batch.ClosedBy = &closedBy
+
+	log.Info("settlement batch closed",
+		"batch_id", batch.ID,
+		"currency", batch.Currency,
+		"payment_count", batch.PaymentCount,
+		"total_amount", batch.TotalAmount,
+		"closed_by", closedBy,
+	)
+	return batch, nil
+}
+
+func (s *SettlementService) List(ctx context.Context, currency domain.Currency, status domain.SettlementStatus, limit, offset int) ([]domain.SettlementBatch, error) {
+	batches, err := s.batches.List(ctx, currency, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return batches, nil
+}
+
+func (s *SettlementService) Report(ctx context.Context, batchID uuid.UUID) (*SettlementReport, error) {
+	batch, err := s.batches.GetByID(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("Report: %w", err)
+	}
+	items, err := s.batches.ListItems(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("Report: %w", err)
+	}
+	return &SettlementReport{Batch: batch, Items: items}, nil
+}
+
+func (s *SettlementService) getSystemAccount(ctx context.Context, accountType domain.AccountType, currency domain.Currency) (*domain.Account, error) {
+	acct, err := s.accounts.GetByUserAndCurrency(ctx, payment.SystemUserID, currency, accountType)
+	if err != nil {
+		return nil, fmt.Errorf("getSystemAccount: %s %s: %w", accountType, currency, err)
+	}
+	return acct, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+func setupSettlementTest(t *testing.T, db *pgxpool.Pool) *SettlementService {
+	t.Helper()
+	return NewSettlementService(
+		repository.NewSettlementRepository(db),
+		repository.NewAccountRepository(db),
+		repository.NewLedgerRepository(db),
+		db,
+	)
+}
+
+func createCompletedPayout(t *testing.T, paymentSvc *payment.Service, processor *WebhookProcessor, webhookRepo *repository.WebhookEventRepository, userID uuid.UUID, amount int64) *domain.Payment {
+	t.Helper()
+	ctx := context.Background()
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   userID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         amount,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "completed", "")
+	require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent)))
+	return p
+}
+
+func TestSettlement_BuildAndClose(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, webhookRepo := setupWebhookTest(t, db)
+	settlements := setupSettlementTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_st")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	admin := testutil.SeedTestUser(t, db, "ops@test.com", "Ops", "ops_st")
+
+	p1 := createCompletedPayout(t, paymentSvc, processor, webhookRepo, sender.ID, 3000)
+	p2 := createCompletedPayout(t, paymentSvc, processor, webhookRepo, sender.ID, 2000)
+
+	outgoingBefore := testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID)
+	settledBefore := testutil.GetAccountBalance(t, db, testutil.SettledUSDID)
+
+	batch, err := settlements.BuildBatch(ctx, domain.CurrencyUSD, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, domain.SettlementStatusOpen, batch.Status)
+	assert.Equal(t, 2, batch.PaymentCount)
+	assert.Equal(t, int64(5000), batch.TotalAmount)
+
+	again, err := settlements.BuildBatch(ctx, domain.CurrencyUSD, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, batch.ID, again.ID)
+	assert.Equal(t, 2, again.PaymentCount)
+
+	report, err := settlements.Report(ctx, batch.ID)
+	require.NoError(t, err)
+	require.Len(t, report.Items, 2)
+
+	closed, err := settlements.CloseBatch(ctx, batch.ID, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SettlementStatusClosed, closed.Status)
+
+	assert.Equal(t, outgoingBefore-5000, testutil.GetAccountBalance(t, db, testutil.OutgoingUSDID))
+	assert.Equal(t, settledBefore+5000, testutil.GetAccountBalance(t, db, testutil.SettledUSDID))
+	assert.Equal(t, 4, testutil.CountLedgerEntries(t, db, p1.ID))
+	assert.Equal(t, 4, testutil.CountLedgerEntries(t, db, p2.ID))
+
+	_, err = settlements.CloseBatch(ctx, batch.ID, admin.ID)
+	assert.ErrorIs(t, err, domain.ErrSettlementClosed)
+
+	next, err := settlements.BuildBatch(ctx, domain.CurrencyUSD, time.Now().UTC())
+	require.NoError(t, err)
+	assert.NotEqual(t, batch.ID, next.ID)
+	assert.Equal(t, 0, next.PaymentCount)
+
+	_, err = settlements.CloseBatch(ctx, next.ID, admin.ID)
+	assert.ErrorIs(t, err, domain.ErrSettlementEmpty)
+}
+
+func TestSettlement_SkipsPendingPayouts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, _, _ := setupWebhookTest(t, db)
+	settlements := setupSettlementTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_st2")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	_, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	batch, err := settlements.BuildBatch(ctx, domain.CurrencyUSD, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 0, batch.PaymentCount)
+	assert.Equal(t, int64(0), batch.TotalAmount)
+}
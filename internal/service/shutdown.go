@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+var inFlightKinds = []domain.InFlightKind{
+	domain.InFlightRequest,
+	domain.InFlightWebhookEvent,
+	domain.InFlightProviderSubmission,
+}
+
+type inFlightOp struct {
+	kind      domain.InFlightKind
+	ref       string
+	startedAt time.Time
+}
+
+// InFlightTracker records work that is currently running so a shutdown can
+// report what finished inside the grace period and what was cut off. A nil
+// tracker is valid and tracks nothing.
+type InFlightTracker struct {
+	mu           sync.Mutex
+	seq          uint64
+	active       map[uint64]inFlightOp
+	drainStarted time.Time
+	draining     map[uint64]domain.InFlightKind
+	startedLate  map[domain.InFlightKind]int
+}
+
+func NewInFlightTracker() *InFlightTracker {
+	return &InFlightTracker{
+		active:      make(map[uint64]inFlightOp),
+		startedLate: make(map[domain.InFlightKind]int),
+	}
+}
+
+// Track registers one unit of work and returns the func that ends it.
+func (t *InFlightTracker) Track(kind domain.InFlightKind, ref string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	t.mu.Lock()
+	t.seq++
+	id := t.seq
+	op := inFlightOp{kind: kind, ref: ref, startedAt: time.Now()}
+	if t.draining != nil {
+		t.startedLate[kind]++
+	}
+	t.active[id] = op
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.active, id)
+			t.mu.Unlock()
+		})
+	}
+}
+
+// BeginDrain snapshots the work in flight when shutdown starts.
+func (t *InFlightTracker) BeginDrain(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.drainStarted = now
+	t.draining = make(map[uint64]domain.InFlightKind, len(t.active))
+	for id, op := range t.active {
+		t.draining[id] = op.kind
+	}
+}
+
+// Report compares the drain snapshot with what is still running. Anything
+// still active counts as abandoned, including work that started during the
+// drain.
+func (t *InFlightTracker) Report(instance, signal string, grace time.Duration, now time.Time) *domain.ShutdownReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[domain.InFlightKind]*domain.ShutdownCount, len(inFlightKinds))
+	for _, kind := range inFlightKinds {
+		counts[kind] = &domain.ShutdownCount{Kind: kind, StartedDuringDrain: t.startedLate[kind]}
+	}
+	count := func(kind domain.InFlightKind) *domain.ShutdownCount {
+		if c, ok := counts[kind]; ok {
+			return c
+		}
+		c := &domain.ShutdownCount{Kind: kind}
+		counts[kind] = c
+		return c
+	}
+
+	for id, kind := range t.draining {
+		c := count(kind)
+		c.InFlight++
+		if _, running := t.active[id]; !running {
+			c.Completed++
+		}
+	}
+
+	report := &domain.ShutdownReport{
+		ID:          uuid.New(),
+		Instance:    instance,
+		Signal:      signal,
+		GracePeriod: grace,
+		StartedAt:   t.drainStarted,
+		FinishedAt:  now,
+	}
+	for _, op := range t.active {
+		count(op.kind).Abandoned++
+		report.Abandoned = append(report.Abandoned, domain.AbandonedOperation{
+			Kind: op.kind, Ref: op.ref, StartedAt: op.startedAt,
+		})
+	}
+	sort.Slice(report.Abandoned, func(i, j int) bool {
+		return report.Abandoned[i].StartedAt.Before(report.Abandoned[j].StartedAt)
+	})
+
+	for _, c := range counts {
+		report.Counts = append(report.Counts, *c)
+	}
+	sort.Slice(report.Counts, func(i, j int) bool { return report.Counts[i].Kind < report.Counts[j].Kind })
+	return report
+}
+
+type shutdownReportRepo interface {
+	Create(ctx context.Context, report *domain.ShutdownReport) error
+}
+
+type ShutdownReporter struct {
+	reports shutdownReportRepo
+	logger  *slog.Logger
+}
+
+func NewShutdownReporter(reports shutdownReportRepo, logger *slog.Logger) *ShutdownReporter {
+	return &ShutdownReporter{reports: reports, logger: logger}
+}
+
+// Publish logs the report and stores it. The log line goes out first so the
+// report survives a database that is already unreachable.
+func (r *ShutdownReporter) Publish(ctx context.Context, report *domain.ShutdownReport) error {
+	attrs := []any{
+		"instance", report.Instance,
+		"signal", report.Signal,
+		"clean", report.Clean(),
+		"grace_period_ms", report.GracePeriod.Milliseconds(),
+		"drain_ms", report.FinishedAt.Sub(report.StartedAt).Milliseconds(),
+	}
+	for _, c := range report.Counts {
+		attrs = append(attrs, slog.Group(string(c.Kind),
+			"in_flight", c.InFlight,
+			"completed", c.Completed,
+			"abandoned", c.Abandoned,
+			"started_during_drain", c.StartedDuringDrain,
+		))
+	}
+
+	if report.Clean() {
+		r.logger.Info("shutdown report", attrs...)
+	} else {
+		r.logger.Warn("shutdown report", attrs...)
+		for _, a := range report.Abandoned {
+			r.logger.Warn("abandoned in-flight work",
+				"kind", a.Kind,
+				"ref", a.Ref,
+				"started_at", a.StartedAt,
+			)
+		}
+	}
+
+	if err := r.reports.Create(ctx, report); err != nil {
+		return fmt.Errorf("Publish: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubShutdownReportRepo struct {
+	stored []*domain.ShutdownReport
+}
+
+func (s *stubShutdownReportRepo) Create(_ context.Context, r *domain.ShutdownReport) error {
+	s.stored = append(s.stored, r)
+	return nil
+}
+
+func countFor(t *testing.T, r *domain.ShutdownReport, kind domain.InFlightKind) domain.ShutdownCount {
+	t.Helper()
+	for _, c := range r.Counts {
+		if c.Kind == kind {
+			return c
+		}
+	}
+	t.Fatalf("no count for %s", kind)
+	return domain.ShutdownCount{}
+}
+
+func TestInFlightTracker_Report(t *testing.T) {
+	tracker := NewInFlightTracker()
+
+	finished := tracker.Track(domain.InFlightRequest, "GET /a")
+	tracker.Track(domain.InFlightRequest, "POST /api/v1/payments")
+	submitted := tracker.Track(domain.InFlightProviderSubmission, "pay-1")
+	tracker.Track(domain.InFlightRequest, "done before drain")()
+
+	start := time.Now()
+	tracker.BeginDrain(start)
+
+	finished()
+	submitted()
+	submitted()
+	tracker.Track(domain.InFlightWebhookEvent, "evt-1")
+
+	report := tracker.Report("api-1", "terminated", 30*time.Second, start.Add(time.Second))
+
+	assert.Equal(t, start, report.StartedAt)
+	assert.False(t, report.Clean())
+	assert.Equal(t, domain.ShutdownCount{Kind: domain.InFlightRequest, InFlight: 2, Completed: 1, Abandoned: 1},
+		countFor(t, report, domain.InFlightRequest))
+	assert.Equal(t, domain.ShutdownCount{Kind: domain.InFlightProviderSubmission, InFlight: 1, Completed: 1},
+		countFor(t, report, domain.InFlightProviderSubmission))
+	assert.Equal(t, domain.ShutdownCount{Kind: domain.InFlightWebhookEvent, Abandoned: 1, StartedDuringDrain: 1},
+		countFor(t, report, domain.InFlightWebhookEvent))
+
+	require.Len(t, report.Abandoned, 2)
+	assert.Equal(t, "POST /api/v1/payments", report.Abandoned[0].Ref)
+	assert.Equal(t, "evt-1", report.Abandoned[1].Ref)
+}
+
+func TestInFlightTracker_NilIsNoop(t *testing.T) {
+	var tracker *InFlightTracker
+	assert.NotPanics(t, func() { tracker.Track(domain.InFlightRequest, "x")() })
+}
+
+func TestShutdownReporter_PublishesCleanReport(t *testing.T) {
+	tracker := NewInFlightTracker()
+	done := tracker.Track(domain.InFlightRequest, "GET /a")
+	tracker.BeginDrain(time.Now())
+	done()
+
+	repo := &stubShutdownReportRepo{}
+	report := tracker.Report("api-1", "interrupt", time.Second, time.Now())
+	require.NoError(t, NewShutdownReporter(repo, slog.Default()).Publish(context.Background(), report))
+
+	require.Len(t, repo.stored, 1)
+	assert.True(t, repo.stored[0].Clean())
+	assert.Len(t, repo.stored[0].Counts, 3)
+}
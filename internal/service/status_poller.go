@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type stalePaymentRepo interface {
+	ListStalePending(ctx context.Context, olderThan time.Time, limit int) ([]domain.Payment, error)
+}
+
+type providerLookup interface {
+	Get(name string) (payment.Provider, bool)
+}
+
+type statusChecker interface {
+	GetStatus(ctx context.Context, paymentID uuid.UUID) (*ProviderStatus, error)
+}
+
+type StatusPoller struct {
+	payments  stalePaymentRepo
+	providers providerLookup
+	processor *WebhookProcessor
+	logger    *slog.Logger
+	threshold time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+func NewStatusPoller(
+	payments stalePaymentRepo,
+	providers providerLookup,
+	processor *WebhookProcessor,
+	logger *slog.Logger,
+	threshold time.Duration,
+	interval time.Duration,
+) *StatusPoller {
+	return &StatusPoller{
+		payments:  payments,
+		providers: providers,
+		processor: processor,
+		logger:    logger,
+		threshold: threshold,
+		interval:  interval,
+		batchSize: 50,
+	}
+}
+
+func (sp *StatusPoller) Start(ctx context.Context) {
+	sp.logger.Info("provider status poller started", "interval", sp.interval, "threshold", sp.threshold)
+
+	ticker := time.NewTicker(sp.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			sp.logger.Info("provider status poller stopped")
+			return
+		case <-ticker.C:
+			sp.poll(ctx)
+		}
+	}
+}
+
+func (sp *StatusPoller) poll(ctx context.Context) {
+	stale, err := sp.payments.ListStalePending(ctx, time.Now().UTC().Add(-sp.threshold), sp.batchSize)
+	if err != nil {
+		sp.logger.Error("failed to list stale pending payouts", "error", err)
+		return
+	}
+
+	for i := range stale {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := sp.reconcile(ctx, &stale[i]); err != nil {
+			sp.logger.Error("failed to reconcile payout", "payment_id", stale[i].ID, "error", err)
+		}
+	}
+}
+
+func (sp *StatusPoller) reconcile(ctx context.Context, pmt *domain.Payment) error {
+	name := stringValue(pmt.Provider)
+	provider, ok := sp.providers.Get(name)
+	if !ok {
+		sp.logger.Warn("stale payout references unknown provider", "payment_id", pmt.ID, "provider", name)
+		return nil
+	}
+
+	checker, ok := provider.(statusChecker)
+	if !ok {
+		return nil
+	}
+
+	status, err := checker.GetStatus(ctx, pmt.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			sp.logger.Warn("provider has no record of payout", "payment_id", pmt.ID, "provider", name)
+			return nil
+		}
+		return err
+	}
+
+	if status.Status != "completed" && status.Status != "failed" {
+		return nil
+	}
+
+	sp.logger.Info("applying polled provider status",
+		"payment_id", pmt.ID,
+		"provider", name,
+		"status", status.Status,
+		"age_s", int(time.Since(pmt.CreatedAt).Seconds()),
+	)
+
+	err = sp.processor.applyOutcome(ctx, pmt, status.Status, status.ProviderRef, status.Reason, domain.FailureCode(status.Code), "system", uuid.Nil)
+	// A webhook got there first. If it left the payout open, the next poll
+	// tries again.
+	if errors.Is(err, domain.ErrPaymentTerminal) || errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrVersionConflict) {
+		return nil
+	}
+	return err
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
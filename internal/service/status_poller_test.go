@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+type stubStatusProvider struct {
+	stubProvider
+	status *ProviderStatus
+}
+
+func (p stubStatusProvider) GetStatus(context.Context, uuid.UUID) (*ProviderStatus, error) {
+	return p.status, nil
+}
+
+func createStalePayout(t *testing.T, db *pgxpool.Pool, paymentSvc *payment.Service, userID uuid.UUID, provider string) *domain.Payment {
+	t.Helper()
+
+	p, err := paymentSvc.CreateExternalPayout(context.Background(), payment.ExternalPayoutRequest{
+		SenderUserID:   userID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), `UPDATE payments SET provider = $1, created_at = now() - interval '1 hour' WHERE id = $2`, provider, p.ID)
+	require.NoError(t, err)
+	return p
+}
+
+func TestStatusPoller_AppliesFailedStatus(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_sp")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p := createStalePayout(t, db, paymentSvc, sender.ID, "slow")
+
+	router := NewProviderRouter("slow", nil)
+	router.Register(stubStatusProvider{
+		stubProvider: stubProvider{name: "slow"},
+		status:       &ProviderStatus{Status: "failed", Reason: "bank down", Code: "bank_unavailable"},
+	})
+
+	poller := NewStatusPoller(repository.NewPaymentRepository(db), router, processor, slog.Default(), 10*time.Minute, time.Second)
+	poller.poll(ctx)
+
+	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, updated.Status)
+	require.NotNil(t, updated.FailureCode)
+	assert.Equal(t, domain.FailureCodeBankUnavailable, *updated.FailureCode)
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+}
+
+func TestStatusPoller_LeavesPendingAlone(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_sp2")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	p := createStalePayout(t, db, paymentSvc, sender.ID, "slow")
+
+	router := NewProviderRouter("slow", nil)
+	router.Register(stubStatusProvider{
+		stubProvider: stubProvider{name: "slow"},
+		status:       &ProviderStatus{Status: "pending"},
+	})
+
+	poller := NewStatusPoller(repository.NewPaymentRepository(db), router, processor, slog.Default(), 10*time.Minute, time.Second)
+	poller.poll(ctx)
+
+	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, updated.Status)
+}
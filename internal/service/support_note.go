@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const maxNoteBodyLength = 4000
+
+// mentionPattern matches @handle at the start of the body or after
+// whitespace or an opening or separating punctuation mark, so the @ in an
+// email address or a path isn't read as a mention. A handle has to end at a
+// non-word character, so a run longer than 20 isn't cut short into someone
+// else's handle.
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(\[{"',;:!?])@([A-Za-z0-9_]{1,20})\b`)
+
+type supportNoteRepo interface {
+	Create(ctx context.Context, note *domain.SupportNote) error
+	ListBySubject(ctx context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error)
+}
+
+type noteUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetByUniqueName(ctx context.Context, uniqueName string) (*domain.User, error)
+}
+
+type notePaymentRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+}
+
+type SupportNoteService struct {
+	notes    supportNoteRepo
+	users    noteUserRepo
+	payments notePaymentRepo
+}
+
+func NewSupportNoteService(notes supportNoteRepo, users noteUserRepo, payments notePaymentRepo) *SupportNoteService {
+	return &SupportNoteService{notes: notes, users: users, payments: payments}
+}
+
+type AddNoteRequest struct {
+	AuthorID    uuid.UUID
+	SubjectType domain.NoteSubjectType
+	SubjectID   uuid.UUID
+	Body        string
+	Flagged     bool
+}
+
+func (s *SupportNoteService) AddNote(ctx context.Context, req AddNoteRequest) (*domain.SupportNote, error) {
+	log := logging.FromContext(ctx)
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" || len(body) > maxNoteBodyLength {
+		return nil, fmt.Errorf("AddNote: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "body", "max_length", maxNoteBodyLength))
+	}
+
+	if err := s.checkSubjectExists(ctx, req.SubjectType, req.SubjectID); err != nil {
+		return nil, fmt.Errorf("AddNote: %w", err)
+	}
+
+	mentioned, err := s.resolveMentions(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("AddNote: %w", err)
+	}
+
+	note := &domain.SupportNote{
+		ID:               uuid.New(),
+		SubjectType:      req.SubjectType,
+		SubjectID:        req.SubjectID,
+		AuthorID:         req.AuthorID,
+		Body:             body,
+		MentionedUserIDs: mentioned,
+		Flagged:          req.Flagged,
+		CreatedAt:        time.Now().UTC(),
+	}
+
+	if err := s.notes.Create(ctx, note); err != nil {
+		return nil, fmt.Errorf("AddNote: %w", err)
+	}
+
+	log.Info("support note added",
+		"note_id", note.ID,
+		"subject_type", note.SubjectType,
+		"subject_id", note.SubjectID,
+		"author_id", note.AuthorID,
+		"flagged", note.Flagged,
+		"mentions", len(note.MentionedUserIDs),
+	)
+
+	return note, nil
+}
+
+func (s *SupportNoteService) ListNotes(ctx context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error) {
+	notes, err := s.notes.ListBySubject(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("ListNotes: %w", err)
+	}
+	return notes, nil
+}
+
+func (s *SupportNoteService) checkSubjectExists(ctx context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) error {
+	switch subjectType {
+	case domain.NoteSubjectPayment:
+		if _, err := s.payments.GetByID(ctx, subjectID); err != nil {
+			return fmt.Errorf("checkSubjectExists: %w", err)
+		}
+	case domain.NoteSubjectUser:
+		if _, err := s.users.GetByID(ctx, subjectID); err != nil {
+			return fmt.Errorf("checkSubjectExists: %w", err)
+		}
+	default:
+		return fmt.Errorf("checkSubjectExists: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "subject_type", "value", subjectType))
+	}
+	return nil
+}
+
+// Mentions may only reference staff; notes are never surfaced to customers.
+func (s *SupportNoteService) resolveMentions(ctx context.Context, body string) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := m[1]
+		u, err := s.users.GetByUniqueName(ctx, handle)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("resolveMentions: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "body", "mention", handle))
+			}
+			return nil, fmt.Errorf("resolveMentions: %w", err)
+		}
+		if !u.Role.IsStaff() {
+			return nil, fmt.Errorf("resolveMentions: not staff: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "body", "mention", handle))
+		}
+		if !seen[u.ID] {
+			seen[u.ID] = true
+			ids = append(ids, u.ID)
+		}
+	}
+
+	return ids, nil
+}
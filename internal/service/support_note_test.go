@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+type stubSupportNotes struct {
+	notes []domain.SupportNote
+}
+
+func (s *stubSupportNotes) Create(_ context.Context, note *domain.SupportNote) error {
+	s.notes = append(s.notes, *note)
+	return nil
+}
+
+func (s *stubSupportNotes) ListBySubject(_ context.Context, subjectType domain.NoteSubjectType, subjectID uuid.UUID) ([]domain.SupportNote, error) {
+	var out []domain.SupportNote
+	for _, n := range s.notes {
+		if n.SubjectType == subjectType && n.SubjectID == subjectID {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+type stubNoteUsers []*domain.User
+
+func (s stubNoteUsers) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	for _, u := range s {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s stubNoteUsers) GetByUniqueName(_ context.Context, name string) (*domain.User, error) {
+	for _, u := range s {
+		if u.UniqueName != nil && *u.UniqueName == name {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+type stubNotePayments map[uuid.UUID]*domain.Payment
+
+func (s stubNotePayments) GetByID(_ context.Context, id uuid.UUID) (*domain.Payment, error) {
+	if p, ok := s[id]; ok {
+		return p, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func handle(s string) *string { return &s }
+
+type supportNoteFixture struct {
+	svc      *SupportNoteService
+	notes    *stubSupportNotes
+	author   *domain.User
+	ops      *domain.User
+	admin    *domain.User
+	customer *domain.User
+	payment  *domain.Payment
+}
+
+func setupSupportNoteTest() *supportNoteFixture {
+	f := &supportNoteFixture{
+		notes:    &stubSupportNotes{},
+		author:   &domain.User{ID: uuid.New(), UniqueName: handle("agent_smith"), Role: domain.UserRoleSupport},
+		ops:      &domain.User{ID: uuid.New(), UniqueName: handle("ops_lead"), Role: domain.UserRolePaymentOps},
+		admin:    &domain.User{ID: uuid.New(), UniqueName: handle("admin"), Role: domain.UserRoleAdmin},
+		customer: &domain.User{ID: uuid.New(), UniqueName: handle("alice"), Role: domain.UserRoleUser},
+		payment:  &domain.Payment{ID: uuid.New()},
+	}
+	users := stubNoteUsers{f.author, f.ops, f.admin, f.customer}
+	f.svc = NewSupportNoteService(f.notes, users, stubNotePayments{f.payment.ID: f.payment})
+	return f
+}
+
+func (f *supportNoteFixture) add(body string) (*domain.SupportNote, error) {
+	return f.svc.AddNote(context.Background(), AddNoteRequest{
+		AuthorID: f.author.ID, SubjectType: domain.NoteSubjectPayment, SubjectID: f.payment.ID, Body: body,
+	})
+}
+
+func TestSupportNote_AddAndList(t *testing.T) {
+	f := setupSupportNoteTest()
+	ctx := context.Background()
+
+	note, err := f.svc.AddNote(ctx, AddNoteRequest{
+		AuthorID: f.author.ID, SubjectType: domain.NoteSubjectPayment, SubjectID: f.payment.ID,
+		Body: "  customer called about this payout  ", Flagged: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "customer called about this payout", note.Body, "body is trimmed")
+	assert.True(t, note.Flagged)
+	assert.Empty(t, note.MentionedUserIDs)
+
+	_, err = f.svc.AddNote(ctx, AddNoteRequest{
+		AuthorID: f.author.ID, SubjectType: domain.NoteSubjectUser, SubjectID: f.customer.ID, Body: "verified by phone",
+	})
+	require.NoError(t, err)
+
+	notes, err := f.svc.ListNotes(ctx, domain.NoteSubjectPayment, f.payment.ID)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, note.ID, notes[0].ID)
+
+	notes, err = f.svc.ListNotes(ctx, domain.NoteSubjectUser, f.customer.ID)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "verified by phone", notes[0].Body)
+}
+
+func TestSupportNote_RejectsBadSubjectOrBody(t *testing.T) {
+	f := setupSupportNoteTest()
+	ctx := context.Background()
+
+	_, err := f.svc.AddNote(ctx, AddNoteRequest{
+		AuthorID: f.author.ID, SubjectType: domain.NoteSubjectPayment, SubjectID: uuid.New(), Body: "hello",
+	})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = f.svc.AddNote(ctx, AddNoteRequest{
+		AuthorID: f.author.ID, SubjectType: "dispute", SubjectID: f.payment.ID, Body: "hello",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	_, err = f.add("   ")
+	assert.ErrorIs(t, err, domain.ErrInvalidRequest)
+
+	assert.Empty(t, f.notes.notes)
+}
+
+func TestSupportNote_Mentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want func(f *supportNoteFixture) []uuid.UUID
+	}{
+		{"at start", "@ops_lead please look", func(f *supportNoteFixture) []uuid.UUID { return []uuid.UUID{f.ops.ID} }},
+		{"after whitespace", "cc @ops_lead\nand\t@admin", func(f *supportNoteFixture) []uuid.UUID { return []uuid.UUID{f.ops.ID, f.admin.ID} }},
+		{"after punctuation", "(@ops_lead), @admin: done", func(f *supportNoteFixture) []uuid.UUID { return []uuid.UUID{f.ops.ID, f.admin.ID} }},
+		{"repeated", "@ops_lead and again @ops_lead", func(f *supportNoteFixture) []uuid.UUID { return []uuid.UUID{f.ops.ID} }},
+		{"email address", "customer wrote from alice@example.com", func(*supportNoteFixture) []uuid.UUID { return nil }},
+		{"email with staff handle", "forwarded to admin@ops_lead.io", func(*supportNoteFixture) []uuid.UUID { return nil }},
+		{"handle too long", "@ops_lead_with_a_much_longer_name", func(*supportNoteFixture) []uuid.UUID { return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setupSupportNoteTest()
+			note, err := f.add(tt.body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want(f), note.MentionedUserIDs)
+		})
+	}
+}
+
+func TestSupportNote_InvalidMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		mention string
+	}{
+		{"unknown handle", "cc @nobody_here", "nobody_here"},
+		{"customer", "ask @alice for documents", "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setupSupportNoteTest()
+			_, err := f.add(tt.body)
+			require.ErrorIs(t, err, domain.ErrInvalidRequest)
+			var de *domain.DomainError
+			require.ErrorAs(t, err, &de)
+			assert.Equal(t, tt.mention, de.Context["mention"])
+			assert.Empty(t, f.notes.notes, "nothing is stored")
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// totpSkew is how many time steps either side of now a code is accepted
+// from, to allow for clock drift on the user's phone.
+const totpSkew = 1
+
+type userTOTPRepo interface {
+	Get(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (*domain.UserTOTP, error)
+	Enroll(ctx context.Context, userID uuid.UUID, secret []byte, at time.Time) error
+	UseStep(ctx context.Context, tx pgx.Tx, userID uuid.UUID, step int64, at time.Time) error
+	Delete(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error
+}
+
+type totpUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// TOTPStatus is whether the user has two-factor authentication on, or an
+// enrollment waiting to be confirmed.
+type TOTPStatus struct {
+	Enabled     bool
+	Pending     bool
+	ConfirmedAt *time.Time
+}
+
+// TOTPEnrollment is the secret to load into an authenticator app, as text
+// and as an otpauth:// URI for a QR code. It is only shown once.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TOTPService manages authenticator app enrollments and checks their codes.
+// Secrets are encrypted with AES-GCM before they are stored. Each accepted
+// code records its time step, so a code can't be replayed.
+type TOTPService struct {
+	totps  userTOTPRepo
+	users  totpUserRepo
+	db     *pgxpool.Pool
+	aead   cipher.AEAD
+	issuer string
+}
+
+// NewTOTPService encrypts secrets with key, which must be 32 bytes. issuer
+// is the name authenticator apps show next to the code.
+func NewTOTPService(totps userTOTPRepo, users totpUserRepo, db *pgxpool.Pool, key []byte, issuer string) (*TOTPService, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("NewTOTPService: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("NewTOTPService: %w", err)
+	}
+	return &TOTPService{totps: totps, users: users, db: db, aead: aead, issuer: issuer}, nil
+}
+
+func (s *TOTPService) Status(ctx context.Context, userID uuid.UUID) (*TOTPStatus, error) {
+	t, err := s.totps.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return &TOTPStatus{}, nil
+		}
+		return nil, fmt.Errorf("Status: %w", err)
+	}
+	return &TOTPStatus{Enabled: t.Enabled(), Pending: !t.Enabled(), ConfirmedAt: t.ConfirmedAt}, nil
+}
+
+// Enroll starts an enrollment with a new secret. It replaces an enrollment
+// that was never confirmed; a confirmed one must be disabled first.
+func (s *TOTPService) Enroll(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Enroll: %w", err)
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("Enroll: %w", err)
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("Enroll: %w", err)
+	}
+	if err := s.totps.Enroll(ctx, userID, sealed, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("Enroll: %w", err)
+	}
+
+	securityEvent(ctx, "totp_enrollment_started", "user_id", userID)
+	return &TOTPEnrollment{Secret: secret, URI: auth.TOTPURI(s.issuer, user.Email, secret)}, nil
+}
+
+// Confirm turns two-factor authentication on once the user proves their app
+// produces the right codes.
+func (s *TOTPService) Confirm(ctx context.Context, userID uuid.UUID, code string) error {
+	err := s.withEnrollment(ctx, userID, func(tx pgx.Tx, t *domain.UserTOTP) error {
+		if t.Enabled() {
+			return domain.ErrTOTPAlreadyEnabled
+		}
+		return s.accept(ctx, tx, t, code)
+	})
+	if err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	securityEvent(ctx, "totp_enabled", "user_id", userID)
+	return nil
+}
+
+// Disable turns two-factor authentication off. It takes a current code, so a
+// stolen session alone can't remove it.
+func (s *TOTPService) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	err := s.withEnrollment(ctx, userID, func(tx pgx.Tx, t *domain.UserTOTP) error {
+		if !t.Enabled() {
+			return domain.ErrTOTPNotEnabled
+		}
+		if code == "" {
+			return domain.ErrTOTPRequired
+		}
+		if err := s.accept(ctx, tx, t, code); err != nil {
+			return err
+		}
+		return s.totps.Delete(ctx, tx, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("Disable: %w", err)
+	}
+	securityEvent(ctx, "totp_disabled", "user_id", userID)
+	return nil
+}
+
+// Verify checks a code from a user with two-factor authentication on. It
+// returns domain.ErrTOTPNotEnabled if the user has none, ErrTOTPRequired if
+// code is empty and ErrInvalidTOTPCode if it doesn't match or was used.
+func (s *TOTPService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	err := s.withEnrollment(ctx, userID, func(tx pgx.Tx, t *domain.UserTOTP) error {
+		if !t.Enabled() {
+			return domain.ErrTOTPNotEnabled
+		}
+		if code == "" {
+			return domain.ErrTOTPRequired
+		}
+		return s.accept(ctx, tx, t, code)
+	})
+	if err != nil {
+		return fmt.Errorf("Verify: %w", err)
+	}
+	return nil
+}
+
+// withEnrollment runs fn with the user's enrollment locked and commits if fn
+// succeeds. A user with no enrollment gets domain.ErrTOTPNotEnabled.
+func (s *TOTPService) withEnrollment(ctx context.Context, userID uuid.UUID, fn func(tx pgx.Tx, t *domain.UserTOTP) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := s.totps.GetForUpdate(ctx, tx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrTOTPNotEnabled
+		}
+		return err
+	}
+	if err := fn(tx, t); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// accept checks code against the enrollment's secret and spends its time
+// step.
+func (s *TOTPService) accept(ctx context.Context, tx pgx.Tx, t *domain.UserTOTP, code string) error {
+	secret, err := s.open(t.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	step, ok := auth.VerifyTOTP(secret, code, now, totpSkew, t.LastUsedStep)
+	if !ok {
+		securityEvent(ctx, "totp_invalid", "user_id", t.UserID)
+		return domain.ErrInvalidTOTPCode
+	}
+	return s.totps.UseStep(ctx, tx, t.UserID, step, now)
+}
+
+// seal encrypts a secret, with the random nonce prepended.
+func (s *TOTPService) seal(secret string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("seal: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+func (s *TOTPService) open(sealed []byte) (string, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return "", errors.New("open: sealed secret too short")
+	}
+	secret, err := s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+	return string(secret), nil
+}
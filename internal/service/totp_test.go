@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/auth"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+)
+
+var testTOTPKey = bytes.Repeat([]byte{7}, 32)
+
+func TestTOTPService_SealOpen(t *testing.T) {
+	svc, err := NewTOTPService(nil, nil, nil, testTOTPKey, "Grey")
+	require.NoError(t, err)
+
+	a, err := svc.seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	b, err := svc.seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b, "each seal uses a fresh nonce")
+
+	secret, err := svc.open(a)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	a[len(a)-1] ^= 1
+	_, err = svc.open(a)
+	assert.Error(t, err, "tampered secrets don't open")
+
+	_, err = NewTOTPService(nil, nil, nil, []byte("short"), "Grey")
+	assert.Error(t, err)
+}
+
+func TestTOTPService_EnrollVerifyDisable(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	svc, err := NewTOTPService(repository.NewUserTOTPRepository(db), repository.NewUserRepository(db), db, testTOTPKey, "Grey")
+	require.NoError(t, err)
+
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	code := func(secret string, offset int64) string {
+		c, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+offset)
+		require.NoError(t, err)
+		return c
+	}
+
+	err = svc.Verify(ctx, alice.ID, "123456")
+	assert.ErrorIs(t, err, domain.ErrTOTPNotEnabled)
+
+	first, err := svc.Enroll(ctx, alice.ID)
+	require.NoError(t, err)
+	enrollment, err := svc.Enroll(ctx, alice.ID)
+	require.NoError(t, err, "a pending enrollment can be restarted")
+	assert.NotEqual(t, first.Secret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	status, err := svc.Status(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.True(t, status.Pending)
+	assert.False(t, status.Enabled)
+
+	err = svc.Verify(ctx, alice.ID, code(enrollment.Secret, 0))
+	assert.ErrorIs(t, err, domain.ErrTOTPNotEnabled, "codes don't count until confirmed")
+	err = svc.Confirm(ctx, alice.ID, code(first.Secret, 0))
+	assert.ErrorIs(t, err, domain.ErrInvalidTOTPCode, "the replaced secret is gone")
+
+	require.NoError(t, svc.Confirm(ctx, alice.ID, code(enrollment.Secret, -1)))
+	_, err = svc.Enroll(ctx, alice.ID)
+	assert.ErrorIs(t, err, domain.ErrTOTPAlreadyEnabled)
+
+	err = svc.Verify(ctx, alice.ID, "")
+	assert.ErrorIs(t, err, domain.ErrTOTPRequired)
+	err = svc.Verify(ctx, alice.ID, code(enrollment.Secret, -1))
+	assert.ErrorIs(t, err, domain.ErrInvalidTOTPCode, "a used code is rejected")
+	require.NoError(t, svc.Verify(ctx, alice.ID, code(enrollment.Secret, 0)))
+	err = svc.Verify(ctx, alice.ID, code(enrollment.Secret, 0))
+	assert.ErrorIs(t, err, domain.ErrInvalidTOTPCode, "a code can't be replayed")
+
+	err = svc.Disable(ctx, alice.ID, "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidTOTPCode)
+	require.NoError(t, svc.Disable(ctx, alice.ID, code(enrollment.Secret, 1)))
+
+	status, err = svc.Status(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+	assert.False(t, status.Pending)
+}
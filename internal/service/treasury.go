@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
+)
+
+type poolAccountRepo interface {
+	GetByUserIDAndType(ctx context.Context, userID uuid.UUID, accountType domain.AccountType) ([]domain.Account, error)
+}
+
+type fxPoolWatermarkRepo interface {
+	List(ctx context.Context) ([]domain.FXPoolWatermark, error)
+	Upsert(ctx context.Context, m *domain.FXPoolWatermark) error
+	Delete(ctx context.Context, currency domain.Currency) error
+}
+
+type treasuryMetrics interface {
+	PoolChecked(currency string, balance int64, low bool)
+	PoolLowAlerted(currency string)
+}
+
+// TreasuryService reports FX pool balances against their low-watermarks and
+// alerts operators when a pool drops below its watermark.
+type TreasuryService struct {
+	accounts   poolAccountRepo
+	watermarks fxPoolWatermarkRepo
+	alerter    Alerter
+	metrics    treasuryMetrics
+	logger     *slog.Logger
+	interval   time.Duration
+
+	// low is the set of pools already alerted on, so a pool alerts once when
+	// it crosses its watermark rather than on every check.
+	low map[domain.Currency]bool
+}
+
+func NewTreasuryService(
+	accounts poolAccountRepo,
+	watermarks fxPoolWatermarkRepo,
+	alerter Alerter,
+	metrics treasuryMetrics,
+	logger *slog.Logger,
+	interval time.Duration,
+) *TreasuryService {
+	return &TreasuryService{
+		accounts:   accounts,
+		watermarks: watermarks,
+		alerter:    alerter,
+		metrics:    metrics,
+		logger:     logger,
+		interval:   interval,
+		low:        make(map[domain.Currency]bool),
+	}
+}
+
+type SetWatermarkRequest struct {
+	Currency     domain.Currency
+	LowWatermark int64
+	ActorID      uuid.UUID
+}
+
+func (s *TreasuryService) ListPools(ctx context.Context) ([]domain.FXPool, error) {
+	accounts, err := s.accounts.GetByUserIDAndType(ctx, payment.SystemUserID, domain.AccountTypeFXPool)
+	if err != nil {
+		return nil, fmt.Errorf("ListPools: %w", err)
+	}
+	marks, err := s.watermarks.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListPools: %w", err)
+	}
+
+	byCurrency := make(map[domain.Currency]*domain.FXPoolWatermark, len(marks))
+	for i := range marks {
+		byCurrency[marks[i].Currency] = &marks[i]
+	}
+
+	pools := make([]domain.FXPool, len(accounts))
+	for i, a := range accounts {
+		pools[i] = domain.FXPool{
+			Currency:  a.Currency,
+			AccountID: a.ID,
+			Balance:   a.Balance,
+			Watermark: byCurrency[a.Currency],
+		}
+	}
+	return pools, nil
+}
+
+func (s *TreasuryService) SetWatermark(ctx context.Context, req SetWatermarkRequest) (*domain.FXPoolWatermark, error) {
+	if !req.Currency.IsValid() {
+		return nil, fmt.Errorf("SetWatermark: %w", domain.ErrInvalidCurrency)
+	}
+	if req.LowWatermark <= 0 {
+		return nil, fmt.Errorf("SetWatermark: %w", domain.ErrInvalidAmount)
+	}
+
+	m := &domain.FXPoolWatermark{
+		Currency:     req.Currency,
+		LowWatermark: req.LowWatermark,
+		UpdatedBy:    req.ActorID,
+		UpdatedAt:    time.Now().UTC(),
+	}
+	if err := s.watermarks.Upsert(ctx, m); err != nil {
+		return nil, fmt.Errorf("SetWatermark: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("fx pool watermark set",
+		"currency", req.Currency,
+		"low_watermark", req.LowWatermark,
+		"actor_id", req.ActorID,
+	)
+	return m, nil
+}
+
+func (s *TreasuryService) ClearWatermark(ctx context.Context, currency domain.Currency, actorID uuid.UUID) error {
+	if !currency.IsValid() {
+		return fmt.Errorf("ClearWatermark: %w", domain.ErrInvalidCurrency)
+	}
+	if err := s.watermarks.Delete(ctx, currency); err != nil {
+		return fmt.Errorf("ClearWatermark: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("fx pool watermark cleared", "currency", currency, "actor_id", actorID)
+	return nil
+}
+
+func (s *TreasuryService) Start(ctx context.Context) {
+	s.logger.Info("fx pool monitor started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("fx pool monitor stopped")
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *TreasuryService) check(ctx context.Context) {
+	pools, err := s.ListPools(ctx)
+	if err != nil {
+		s.logger.Error("failed to check fx pools", "error", err)
+		return
+	}
+
+	for _, p := range pools {
+		low := p.Low()
+		if s.metrics != nil {
+			s.metrics.PoolChecked(string(p.Currency), p.Balance, low)
+		}
+
+		switch {
+		case low && !s.low[p.Currency]:
+			s.low[p.Currency] = true
+			if s.metrics != nil {
+				s.metrics.PoolLowAlerted(string(p.Currency))
+			}
+			s.alerter.Alert(ctx, Alert{
+				Kind:    "fx_pool_low",
+				Subject: fmt.Sprintf("FX pool %s is below its low-watermark", p.Currency),
+				Attrs: []any{
+					"currency", p.Currency,
+					"account_id", p.AccountID,
+					"balance", p.Balance,
+					"low_watermark", p.Watermark.LowWatermark,
+				},
+			})
+		case !low && s.low[p.Currency]:
+			delete(s.low, p.Currency)
+			s.logger.Info("fx pool back above watermark", "currency", p.Currency, "balance", p.Balance)
+		}
+	}
+}
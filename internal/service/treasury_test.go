@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/metrics"
+)
+
+type stubPoolAccounts struct {
+	accounts []domain.Account
+}
+
+func (s *stubPoolAccounts) GetByUserIDAndType(context.Context, uuid.UUID, domain.AccountType) ([]domain.Account, error) {
+	return s.accounts, nil
+}
+
+type stubWatermarks struct {
+	marks []domain.FXPoolWatermark
+}
+
+func (s *stubWatermarks) List(context.Context) ([]domain.FXPoolWatermark, error) {
+	return s.marks, nil
+}
+
+func (s *stubWatermarks) Upsert(_ context.Context, m *domain.FXPoolWatermark) error {
+	s.marks = append(s.marks, *m)
+	return nil
+}
+
+func (s *stubWatermarks) Delete(context.Context, domain.Currency) error {
+	return nil
+}
+
+func TestTreasuryService_AlertsOncePerWatermarkCrossing(t *testing.T) {
+	ctx := context.Background()
+	accounts := &stubPoolAccounts{accounts: []domain.Account{
+		{ID: uuid.New(), Currency: domain.CurrencyUSD, Balance: 500},
+		{ID: uuid.New(), Currency: domain.CurrencyEUR, Balance: 500},
+	}}
+	watermarks := &stubWatermarks{marks: []domain.FXPoolWatermark{
+		{Currency: domain.CurrencyUSD, LowWatermark: 1000},
+	}}
+	alerter := &recordingAlerter{}
+	reg := metrics.NewRegistry()
+	svc := NewTreasuryService(accounts, watermarks, alerter, metrics.NewTreasuryMetrics(reg), slog.Default(), time.Minute)
+
+	pools, err := svc.ListPools(ctx)
+	require.NoError(t, err)
+	require.Len(t, pools, 2)
+	assert.True(t, pools[0].Low())
+	assert.False(t, pools[1].Low(), "no watermark configured")
+
+	svc.check(ctx)
+	svc.check(ctx)
+	require.Len(t, alerter.alerts, 1, "a pool that stays low alerts once")
+	assert.Equal(t, "fx_pool_low", alerter.alerts[0].Kind)
+
+	accounts.accounts[0].Balance = 5000
+	svc.check(ctx)
+	accounts.accounts[0].Balance = 900
+	svc.check(ctx)
+	assert.Len(t, alerter.alerts, 2, "recovering re-arms the alert")
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+const maxLimitReasonLength = 500
+
+type userLimitRepo interface {
+	Get(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.UserLimit, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.UserLimit, error)
+	Upsert(ctx context.Context, l *domain.UserLimit) error
+	Delete(ctx context.Context, userID uuid.UUID, currency domain.Currency) error
+}
+
+type limitUserRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	SetTier(ctx context.Context, id uuid.UUID, tier domain.UserTier) (*domain.User, error)
+}
+
+// UserLimitService manages per-user overrides of the per-transaction limit,
+// and the user tiers the defaults are scaled by. Users without an override
+// get the configured default for the currency.
+type UserLimitService struct {
+	limits   userLimitRepo
+	users    limitUserRepo
+	defaults map[domain.Currency]int64
+	audit    auditRecorder
+}
+
+func NewUserLimitService(limits userLimitRepo, users limitUserRepo, defaults map[domain.Currency]int64, audit auditRecorder) *UserLimitService {
+	return &UserLimitService{limits: limits, users: users, defaults: defaults, audit: audit}
+}
+
+type SetLimitRequest struct {
+	UserID   uuid.UUID
+	Currency domain.Currency
+	TxLimit  int64
+	Reason   string
+	ActorID  uuid.UUID
+}
+
+func (s *UserLimitService) ListLimits(ctx context.Context, userID uuid.UUID) ([]domain.TxLimit, error) {
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("ListLimits: %w", err)
+	}
+
+	overrides, err := s.limits.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListLimits: %w", err)
+	}
+
+	byCurrency := make(map[domain.Currency]*domain.UserLimit, len(overrides))
+	for i := range overrides {
+		byCurrency[overrides[i].Currency] = &overrides[i]
+	}
+
+	limits := make([]domain.TxLimit, len(domain.SupportedCurrencies))
+	for i, c := range domain.SupportedCurrencies {
+		limits[i] = domain.TxLimit{Currency: c, Default: s.defaults[c], Override: byCurrency[c]}
+	}
+	return limits, nil
+}
+
+func (s *UserLimitService) SetLimit(ctx context.Context, req SetLimitRequest) (*domain.TxLimit, error) {
+	log := logging.FromContext(ctx)
+
+	if !req.Currency.IsValid() {
+		return nil, fmt.Errorf("SetLimit: %w", domain.ErrInvalidCurrency)
+	}
+	if req.TxLimit <= 0 {
+		return nil, fmt.Errorf("SetLimit: %w", domain.ErrInvalidAmount)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxLimitReasonLength {
+		return nil, fmt.Errorf("SetLimit: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "max_length", maxLimitReasonLength))
+	}
+
+	if _, err := s.users.GetByID(ctx, req.UserID); err != nil {
+		return nil, fmt.Errorf("SetLimit: %w", err)
+	}
+
+	before, err := s.override(ctx, req.UserID, req.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("SetLimit: %w", err)
+	}
+
+	l := &domain.UserLimit{
+		UserID:    req.UserID,
+		Currency:  req.Currency,
+		TxLimit:   req.TxLimit,
+		Reason:    reason,
+		UpdatedBy: req.ActorID,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := s.limits.Upsert(ctx, l); err != nil {
+		return nil, fmt.Errorf("SetLimit: %w", err)
+	}
+
+	log.Info("user transaction limit set",
+		"user_id", l.UserID,
+		"currency", l.Currency,
+		"tx_limit", l.TxLimit,
+		"default_limit", s.defaults[l.Currency],
+		"actor_id", l.UpdatedBy,
+	)
+
+	limit := &domain.TxLimit{Currency: l.Currency, Default: s.defaults[l.Currency], Override: l}
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:       domain.AuditActionLimitSet,
+		ResourceType: "user_limit",
+		ResourceID:   limitResourceID(req.UserID, req.Currency),
+		Before:       limitSnapshot(&domain.TxLimit{Currency: l.Currency, Default: s.defaults[l.Currency], Override: before}),
+		After:        limitSnapshot(limit),
+	})
+	return limit, nil
+}
+
+// ResetLimit removes the override so the user falls back to the default.
+func (s *UserLimitService) ResetLimit(ctx context.Context, userID uuid.UUID, currency domain.Currency, actorID uuid.UUID) (*domain.TxLimit, error) {
+	if !currency.IsValid() {
+		return nil, fmt.Errorf("ResetLimit: %w", domain.ErrInvalidCurrency)
+	}
+
+	before, err := s.override(ctx, userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("ResetLimit: %w", err)
+	}
+
+	if err := s.limits.Delete(ctx, userID, currency); err != nil {
+		return nil, fmt.Errorf("ResetLimit: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("user transaction limit reset",
+		"user_id", userID,
+		"currency", currency,
+		"actor_id", actorID,
+	)
+
+	limit := &domain.TxLimit{Currency: currency, Default: s.defaults[currency]}
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:       domain.AuditActionLimitReset,
+		ResourceType: "user_limit",
+		ResourceID:   limitResourceID(userID, currency),
+		Before:       limitSnapshot(&domain.TxLimit{Currency: currency, Default: s.defaults[currency], Override: before}),
+		After:        limitSnapshot(limit),
+	})
+	return limit, nil
+}
+
+// SetTier moves a user to another tier. Their limits and FX pricing change
+// from their next payment; payments already made keep the tier they were
+// priced at.
+func (s *UserLimitService) SetTier(ctx context.Context, userID uuid.UUID, tier domain.UserTier, reason string, actorID uuid.UUID) (*domain.User, error) {
+	if !tier.IsValid() {
+		return nil, fmt.Errorf("SetTier: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "tier", "value", tier))
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxLimitReasonLength {
+		return nil, fmt.Errorf("SetTier: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "reason", "max_length", maxLimitReasonLength))
+	}
+
+	before, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("SetTier: %w", err)
+	}
+	u, err := s.users.SetTier(ctx, userID, tier)
+	if err != nil {
+		return nil, fmt.Errorf("SetTier: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("user tier set", "user_id", userID, "from", before.Tier, "to", u.Tier, "actor_id", actorID)
+	after := map[string]any{"tier": u.Tier}
+	if reason != "" {
+		after["reason"] = reason
+	}
+	s.audit.Record(ctx, domain.AuditEntry{
+		Action:       domain.AuditActionTierSet,
+		ResourceType: "user",
+		ResourceID:   userID.String(),
+		Before:       map[string]any{"tier": before.Tier},
+		After:        after,
+	})
+	return u, nil
+}
+
+// override returns the user's override for the currency, or nil if they
+// have none.
+func (s *UserLimitService) override(ctx context.Context, userID uuid.UUID, currency domain.Currency) (*domain.UserLimit, error) {
+	l, err := s.limits.Get(ctx, userID, currency)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	return l, err
+}
+
+func limitResourceID(userID uuid.UUID, currency domain.Currency) string {
+	return userID.String() + "/" + string(currency)
+}
+
+// limitSnapshot is how a limit appears in the audit log.
+func limitSnapshot(l *domain.TxLimit) map[string]any {
+	snap := map[string]any{
+		"tx_limit":   l.Effective(),
+		"overridden": l.Override != nil,
+	}
+	if l.Override != nil {
+		snap["reason"] = l.Override.Reason
+		snap["updated_by"] = l.Override.UpdatedBy
+	}
+	return snap
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+)
+
+const webhookDeliveryBatchSize = 100
+
+type webhookQueue interface {
+	Enqueue(ctx context.Context, tx pgx.Tx, d *domain.WebhookDelivery) error
+}
+
+// webhookRecipients are the users an event on a payment goes to: the
+// sender hears about every event, and the recipient of an internal transfer
+// about it completing.
+func webhookRecipients(parties *domain.PaymentParties, topic string) []uuid.UUID {
+	users := []uuid.UUID{parties.SenderID}
+	if topic == string(events.TypePaymentCompleted) && parties.Type == domain.PaymentTypeInternalTransfer &&
+		parties.RecipientID != nil && *parties.RecipientID != parties.SenderID {
+		users = append(users, *parties.RecipientID)
+	}
+	return users
+}
+
+type webhookDeliveryRepo interface {
+	ListDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, a *domain.WebhookDeliveryAttempt, status domain.WebhookDeliveryStatus, next time.Time) error
+}
+
+// WebhookDispatcher sends queued payment events to users' endpoints. A
+// delivery the endpoint doesn't answer with a 2xx is tried again after
+// backoff, doubled for each attempt after the first, until maxAttempts
+// have been made. Delivery is at least once: receivers drop repeats by the
+// event ID.
+type WebhookDispatcher struct {
+	deliveries  webhookDeliveryRepo
+	sender      *WebhookSender
+	logger      *slog.Logger
+	interval    time.Duration
+	backoff     time.Duration
+	maxAttempts int
+}
+
+func NewWebhookDispatcher(deliveries webhookDeliveryRepo, sender *WebhookSender, logger *slog.Logger, interval, backoff time.Duration, maxAttempts int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		deliveries:  deliveries,
+		sender:      sender,
+		logger:      logger,
+		interval:    interval,
+		backoff:     backoff,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	d.logger.Info("webhook dispatcher started", "interval", d.interval, "backoff", d.backoff, "max_attempts", d.maxAttempts)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch makes one attempt at each due delivery, a batch at a time, and
+// returns how many were delivered.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) int {
+	delivered := 0
+	for ctx.Err() == nil {
+		due, err := d.deliveries.ListDue(ctx, time.Now().UTC(), webhookDeliveryBatchSize)
+		if err != nil {
+			d.logger.Error("failed to list due webhook deliveries", "error", err)
+			return delivered
+		}
+
+		for i := range due {
+			if ctx.Err() != nil {
+				return delivered
+			}
+			ok, err := d.deliver(ctx, &due[i])
+			if err != nil {
+				// Unrecorded, it stays due and is listed again; stop this
+				// pass rather than loop on it.
+				d.logger.Error("failed to deliver webhook", "delivery_id", due[i].ID, "user_id", due[i].UserID, "error", err)
+				return delivered
+			}
+			if ok {
+				delivered++
+			}
+		}
+		if len(due) < webhookDeliveryBatchSize {
+			break
+		}
+	}
+	return delivered
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, w *domain.WebhookDelivery) (bool, error) {
+	var env events.Envelope
+	if err := json.Unmarshal(w.Payload, &env); err != nil {
+		return false, fmt.Errorf("deliver: %w", err)
+	}
+
+	endpoint := &domain.WebhookEndpoint{UserID: w.UserID, URL: w.URL, SecretEncrypted: w.SecretEncrypted}
+	a, err := d.sender.send(ctx, endpoint, w.EventType, webhookBody{
+		ID:        w.EventID,
+		Type:      string(env.Type),
+		Version:   env.Version,
+		Data:      env.Data,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("deliver: %w", err)
+	}
+	a.DeliveryID = &w.ID
+	a.Attempt = w.Attempts + 1
+
+	status := domain.WebhookDeliveryDelivered
+	next := a.CreatedAt
+	log := d.logger.With("delivery_id", w.ID, "user_id", w.UserID, "event_type", w.EventType, "attempt", a.Attempt)
+	switch {
+	case a.Succeeded():
+		log.Info("webhook delivered", "status_code", *a.StatusCode, "latency_ms", a.LatencyMS)
+	case a.Attempt >= d.maxAttempts:
+		status = domain.WebhookDeliveryFailed
+		log.Warn("giving up on webhook delivery", "error", *a.Error)
+	default:
+		status = domain.WebhookDeliveryPending
+		next = a.CreatedAt.Add(d.backoff << min(a.Attempt-1, 10))
+		log.Warn("webhook delivery failed", "error", *a.Error, "next_attempt_at", next)
+	}
+
+	if err := d.deliveries.RecordAttempt(ctx, a, status, next); err != nil {
+		return false, fmt.Errorf("deliver: %w", err)
+	}
+	return status == domain.WebhookDeliveryDelivered, nil
+}
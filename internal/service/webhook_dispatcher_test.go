@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/repository"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
+)
+
+type stubWebhookDeliveries struct {
+	due      []domain.WebhookDelivery
+	attempts []domain.WebhookDeliveryAttempt
+	statuses []domain.WebhookDeliveryStatus
+	next     []time.Time
+}
+
+func (s *stubWebhookDeliveries) ListDue(_ context.Context, _ time.Time, _ int) ([]domain.WebhookDelivery, error) {
+	due := s.due
+	s.due = nil
+	return due, nil
+}
+
+func (s *stubWebhookDeliveries) RecordAttempt(_ context.Context, a *domain.WebhookDeliveryAttempt, status domain.WebhookDeliveryStatus, next time.Time) error {
+	s.attempts = append(s.attempts, *a)
+	s.statuses = append(s.statuses, status)
+	s.next = append(s.next, next)
+	return nil
+}
+
+func newTestWebhookSender(t *testing.T) *WebhookSender {
+	t.Helper()
+	sender, err := NewWebhookSender(NewWebhookHTTPClient(time.Second, true), make([]byte, 32))
+	require.NoError(t, err)
+	return sender
+}
+
+func queuedDelivery(t *testing.T, sender *WebhookSender, url string, attempts int) (domain.WebhookDelivery, string) {
+	t.Helper()
+	secret, sealed, err := sender.newSecret()
+	require.NoError(t, err)
+	eventID := uuid.New()
+	payload, err := json.Marshal(map[string]any{
+		"id": eventID, "type": "payment.completed", "version": 1,
+		"data": map[string]any{"payment_id": uuid.New()},
+	})
+	require.NoError(t, err)
+	return domain.WebhookDelivery{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		EventID:         eventID,
+		EventType:       "payment.completed",
+		Payload:         payload,
+		Attempts:        attempts,
+		URL:             url,
+		SecretEncrypted: sealed,
+	}, secret
+}
+
+func TestWebhookDispatcher_SignsDeliveries(t *testing.T) {
+	sender := newTestWebhookSender(t)
+
+	var body []byte
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(webhookverify.SignatureHeader)
+	}))
+	defer srv.Close()
+
+	d, secret := queuedDelivery(t, sender, srv.URL, 0)
+	repo := &stubWebhookDeliveries{due: []domain.WebhookDelivery{d}}
+	dispatcher := NewWebhookDispatcher(repo, sender, slog.Default(), time.Second, time.Minute, 3)
+
+	assert.Equal(t, 1, dispatcher.Dispatch(context.Background()))
+	require.NoError(t, webhookverify.Verify(secret, body, signature))
+
+	var got webhookBody
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, d.EventID, got.ID)
+	assert.Equal(t, "payment.completed", got.Type)
+	assert.False(t, got.Timestamp.IsZero())
+
+	require.Len(t, repo.attempts, 1)
+	assert.Equal(t, domain.WebhookDeliveryDelivered, repo.statuses[0])
+	assert.Equal(t, d.ID, *repo.attempts[0].DeliveryID)
+	assert.Equal(t, 200, *repo.attempts[0].StatusCode)
+}
+
+func TestWebhookDispatcher_RetriesWithBackoffThenGivesUp(t *testing.T) {
+	sender := newTestWebhookSender(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	retry, _ := queuedDelivery(t, sender, srv.URL, 1)
+	last, _ := queuedDelivery(t, sender, srv.URL, 2)
+	repo := &stubWebhookDeliveries{due: []domain.WebhookDelivery{retry, last}}
+	dispatcher := NewWebhookDispatcher(repo, sender, slog.Default(), time.Second, time.Minute, 3)
+
+	assert.Equal(t, 0, dispatcher.Dispatch(context.Background()))
+	require.Len(t, repo.attempts, 2)
+
+	assert.Equal(t, domain.WebhookDeliveryPending, repo.statuses[0])
+	assert.Equal(t, 2, repo.attempts[0].Attempt)
+	assert.Equal(t, 2*time.Minute, repo.next[0].Sub(repo.attempts[0].CreatedAt))
+	require.NotNil(t, repo.attempts[0].Error)
+
+	assert.Equal(t, domain.WebhookDeliveryFailed, repo.statuses[1])
+	assert.Equal(t, 3, repo.attempts[1].Attempt)
+	assert.Equal(t, http.StatusBadGateway, *repo.attempts[1].StatusCode)
+}
+
+func TestWebhookHTTPClient_RefusesPrivateAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	_, err := NewWebhookHTTPClient(time.Second, false).Post(srv.URL, "application/json", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errPrivateWebhookAddress)
+	assert.Zero(t, hits.Load())
+}
+
+func TestWebhookEndpointService_ValidatesURL(t *testing.T) {
+	svc := NewWebhookEndpointService(nil, newTestWebhookSender(t), false)
+	for _, u := range []string{"http://example.com/hook", "ftp://example.com", "https://user:pw@example.com/", "not a url", "/relative"} {
+		assert.ErrorIs(t, svc.validateURL(u), domain.ErrInvalidRequest, u)
+	}
+	assert.NoError(t, svc.validateURL("https://example.com/hooks/grey"))
+
+	dev := NewWebhookEndpointService(nil, newTestWebhookSender(t), true)
+	assert.NoError(t, dev.validateURL("http://localhost:9000/hook"))
+}
+
+func TestWebhooks_QueuedByFanOutAndDelivered(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	alice := testutil.SeedTestUser(t, db, "alice@test.com", "Alice", "alice")
+	bob := testutil.SeedTestUser(t, db, "bob@test.com", "Bob", "bob")
+	aliceUSD := testutil.SeedTestAccount(t, db, alice.ID, "USD", 10000)
+	bobUSD := testutil.SeedTestAccount(t, db, bob.ID, "USD", 0)
+
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		received.Add(1)
+	}))
+	defer srv.Close()
+
+	endpoints := repository.NewWebhookEndpointRepository(db)
+	sender := newTestWebhookSender(t)
+	svc := NewWebhookEndpointService(endpoints, sender, true)
+	_, err := svc.Set(ctx, bob.ID, srv.URL)
+	require.NoError(t, err)
+
+	outboxRepo := repository.NewOutboxRepository(db)
+	w := NewPaymentEventOutbox(repository.NewPaymentEventRepository(db), outboxRepo)
+	fanOut := NewNotificationFanOut(outboxRepo, repository.NewNotificationRepository(db), endpoints, nil, db, slog.Default(), time.Second, 10)
+
+	now := time.Now().UTC()
+	p := &domain.Payment{
+		ID: uuid.New(), IdempotencyKey: uuid.NewString(), Type: domain.PaymentTypeInternalTransfer,
+		Status: domain.PaymentStatusCompleted, SourceAccountID: aliceUSD.ID, DestAccountID: &bobUSD.ID,
+		Source: domain.NewMoney(2500, domain.CurrencyUSD), Dest: domain.NewMoney(2500, domain.CurrencyUSD),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	completed, err := events.Marshal(events.NewPaymentCompleted(p, "", now))
+	require.NoError(t, err)
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repository.NewPaymentRepository(db).Create(ctx, tx, p))
+	require.NoError(t, w.Create(ctx, tx, &domain.PaymentEvent{ID: uuid.New(), PaymentID: p.ID, EventType: domain.PaymentEventTypeCompleted, Actor: "system", Payload: completed, CreatedAt: now}))
+	require.NoError(t, tx.Commit(ctx))
+
+	assert.Equal(t, 1, fanOut.FanOut(ctx))
+
+	// Alice has no endpoint, so only Bob's delivery is queued.
+	dispatcher := NewWebhookDispatcher(endpoints, sender, slog.Default(), time.Second, time.Minute, 3)
+	assert.Equal(t, 1, dispatcher.Dispatch(ctx))
+	assert.Zero(t, dispatcher.Dispatch(ctx), "delivered events aren't sent again")
+	assert.Equal(t, int32(1), received.Load())
+
+	attempts, err := svc.ListAttempts(ctx, bob.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "payment.completed", attempts[0].EventType)
+	assert.True(t, attempts[0].Succeeded())
+
+	test, err := svc.SendTest(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookEventTest, test.EventType)
+	assert.Nil(t, test.DeliveryID)
+
+	rotated, err := svc.RotateSecret(ctx, bob.ID)
+	require.NoError(t, err)
+	require.NotNil(t, rotated.SecretRotatedAt)
+	got, err := svc.Get(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Secret, got.Secret)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+	"github.com/josh-kwaku/grey-backend-assessment/pkg/webhookverify"
+)
+
+const (
+	maxWebhookURLLength = 2048
+	webhookSecretPrefix = "whsec_"
+	// maxWebhookAttemptsListed caps how many attempts a user can list.
+	maxWebhookAttemptsListed = 100
+)
+
+var errPrivateWebhookAddress = errors.New("webhook endpoint resolves to a private address")
+
+// NewWebhookHTTPClient returns the client requests to users' endpoints go
+// through. Unless allowPrivate is set it only connects to public
+// addresses, checked after DNS resolution so a hostname can't point it at
+// the internal network. Redirects aren't followed.
+func NewWebhookHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateWebhookAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// webhookBody is what an endpoint receives: the event's envelope and when
+// it was sent, so receivers can reject replayed requests.
+type webhookBody struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// WebhookSender signs and sends webhooks to users' endpoints, and seals
+// the endpoints' secrets for storage with AES-GCM. Requests carry the
+// HMAC-SHA256 of the body in webhookverify.SignatureHeader, so receivers
+// can check them with pkg/webhookverify.
+type WebhookSender struct {
+	client *http.Client
+	aead   cipher.AEAD
+}
+
+// NewWebhookSender seals secrets with key, which must be 32 bytes.
+func NewWebhookSender(client *http.Client, key []byte) (*WebhookSender, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("NewWebhookSender: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("NewWebhookSender: %w", err)
+	}
+	return &WebhookSender{client: client, aead: aead}, nil
+}
+
+// send posts body to the endpoint and returns the attempt, which says
+// whether it succeeded. The caller sets the delivery and attempt number.
+func (s *WebhookSender) send(ctx context.Context, endpoint *domain.WebhookEndpoint, eventType string, body webhookBody) (*domain.WebhookDeliveryAttempt, error) {
+	secret, err := s.open(endpoint.SecretEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("send: %w", err)
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("send: %w", err)
+	}
+
+	a := &domain.WebhookDeliveryAttempt{
+		ID:        uuid.New(),
+		UserID:    endpoint.UserID,
+		EventID:   body.ID,
+		EventType: eventType,
+		Attempt:   1,
+		URL:       endpoint.URL,
+		CreatedAt: body.Timestamp,
+	}
+
+	start := time.Now()
+	code, err := s.post(ctx, endpoint.URL, secret, raw)
+	a.LatencyMS = int(time.Since(start).Milliseconds())
+	if code != 0 {
+		a.StatusCode = &code
+	}
+	if err != nil {
+		msg := err.Error()
+		a.Error = &msg
+	}
+	return a, nil
+}
+
+func (s *WebhookSender) post(ctx context.Context, endpointURL, secret string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookverify.SignatureHeader, webhookverify.Sign(secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *WebhookSender) newSecret() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("newSecret: %w", err)
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(b)
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return "", nil, fmt.Errorf("newSecret: %w", err)
+	}
+	return secret, sealed, nil
+}
+
+func (s *WebhookSender) seal(secret string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("seal: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+func (s *WebhookSender) open(sealed []byte) (string, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return "", errors.New("open: sealed secret too short")
+	}
+	secret, err := s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+	return string(secret), nil
+}
+
+type webhookEndpointRepo interface {
+	Get(ctx context.Context, userID uuid.UUID) (*domain.WebhookEndpoint, error)
+	Save(ctx context.Context, e *domain.WebhookEndpoint) (*domain.WebhookEndpoint, error)
+	SetSecret(ctx context.Context, userID uuid.UUID, secret []byte, at time.Time) (*domain.WebhookEndpoint, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+	RecordAttempt(ctx context.Context, a *domain.WebhookDeliveryAttempt, status domain.WebhookDeliveryStatus, next time.Time) error
+	ListAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]domain.WebhookDeliveryAttempt, error)
+}
+
+// WebhookEndpointWithSecret is an endpoint with its signing secret in the
+// clear, for its owner to configure their receiver with.
+type WebhookEndpointWithSecret struct {
+	domain.WebhookEndpoint
+	Secret string
+}
+
+// WebhookEndpointService lets users manage the endpoint their payment
+// webhooks go to and debug their receiver: they can read and rotate the
+// signing secret, list recent delivery attempts and send a test event.
+type WebhookEndpointService struct {
+	endpoints    webhookEndpointRepo
+	sender       *WebhookSender
+	allowPrivate bool
+}
+
+// NewWebhookEndpointService accepts plain http endpoints only when
+// allowPrivate is set.
+func NewWebhookEndpointService(endpoints webhookEndpointRepo, sender *WebhookSender, allowPrivate bool) *WebhookEndpointService {
+	return &WebhookEndpointService{endpoints: endpoints, sender: sender, allowPrivate: allowPrivate}
+}
+
+func (s *WebhookEndpointService) Get(ctx context.Context, userID uuid.UUID) (*WebhookEndpointWithSecret, error) {
+	e, err := s.endpoints.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	secret, err := s.sender.open(e.SecretEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return &WebhookEndpointWithSecret{WebhookEndpoint: *e, Secret: secret}, nil
+}
+
+// Set points the user's webhooks at rawURL. A new endpoint gets a new
+// secret; an existing one keeps its own.
+func (s *WebhookEndpointService) Set(ctx context.Context, userID uuid.UUID, rawURL string) (*WebhookEndpointWithSecret, error) {
+	if err := s.validateURL(rawURL); err != nil {
+		return nil, fmt.Errorf("Set: %w", err)
+	}
+	_, sealed, err := s.sender.newSecret()
+	if err != nil {
+		return nil, fmt.Errorf("Set: %w", err)
+	}
+	e, err := s.endpoints.Save(ctx, &domain.WebhookEndpoint{
+		UserID:          userID,
+		URL:             rawURL,
+		SecretEncrypted: sealed,
+		UpdatedAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Set: %w", err)
+	}
+	secret, err := s.sender.open(e.SecretEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("Set: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("webhook endpoint set", "user_id", userID, "url", e.URL)
+	return &WebhookEndpointWithSecret{WebhookEndpoint: *e, Secret: secret}, nil
+}
+
+// RotateSecret replaces the signing secret. Requests are signed with the
+// new one from then on, including retries of earlier events.
+func (s *WebhookEndpointService) RotateSecret(ctx context.Context, userID uuid.UUID) (*WebhookEndpointWithSecret, error) {
+	secret, sealed, err := s.sender.newSecret()
+	if err != nil {
+		return nil, fmt.Errorf("RotateSecret: %w", err)
+	}
+	e, err := s.endpoints.SetSecret(ctx, userID, sealed, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("RotateSecret: %w", err)
+	}
+
+	securityEvent(ctx, "webhook_secret_rotated", "user_id", userID)
+	return &WebhookEndpointWithSecret{WebhookEndpoint: *e, Secret: secret}, nil
+}
+
+// Delete stops the user's webhooks. Events not yet delivered are dropped.
+func (s *WebhookEndpointService) Delete(ctx context.Context, userID uuid.UUID) error {
+	if err := s.endpoints.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	logging.FromContext(ctx).Info("webhook endpoint deleted", "user_id", userID)
+	return nil
+}
+
+// ListAttempts returns up to limit of the user's most recent delivery
+// attempts, newest first.
+func (s *WebhookEndpointService) ListAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]domain.WebhookDeliveryAttempt, error) {
+	if limit <= 0 || limit > maxWebhookAttemptsListed {
+		limit = maxWebhookAttemptsListed
+	}
+	attempts, err := s.endpoints.ListAttempts(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// SendTest sends a signed webhook.test event to the user's endpoint and
+// returns the attempt, whether or not the endpoint accepted it. Test
+// events aren't retried.
+func (s *WebhookEndpointService) SendTest(ctx context.Context, userID uuid.UUID) (*domain.WebhookDeliveryAttempt, error) {
+	e, err := s.endpoints.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("SendTest: %w", err)
+	}
+
+	data, err := json.Marshal(map[string]any{"user_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("SendTest: %w", err)
+	}
+	a, err := s.sender.send(ctx, e, domain.WebhookEventTest, webhookBody{
+		ID:        uuid.New(),
+		Type:      domain.WebhookEventTest,
+		Version:   1,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SendTest: %w", err)
+	}
+
+	status := domain.WebhookDeliveryDelivered
+	if !a.Succeeded() {
+		status = domain.WebhookDeliveryFailed
+	}
+	if err := s.endpoints.RecordAttempt(ctx, a, status, a.CreatedAt); err != nil {
+		return nil, fmt.Errorf("SendTest: %w", err)
+	}
+	logging.FromContext(ctx).Info("webhook test event sent", "user_id", userID, "status_code", a.StatusCode, "latency_ms", a.LatencyMS)
+	return a, nil
+}
+
+func (s *WebhookEndpointService) validateURL(rawURL string) error {
+	if len(rawURL) > maxWebhookURLLength {
+		return fmt.Errorf("url: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "url", "max_length", maxWebhookURLLength))
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.User != nil {
+		return fmt.Errorf("url: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "url"))
+	}
+	if u.Scheme != "https" && !(s.allowPrivate && u.Scheme == "http") {
+		return fmt.Errorf("url must use https: %w", domain.NewDomainError(domain.ErrInvalidRequest, "field", "url"))
+	}
+	return nil
+}
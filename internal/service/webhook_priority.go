@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/logging"
+)
+
+type webhookPaymentRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+}
+
+// WebhookPrioritizer picks the processing class of a provider callback as it
+// arrives, so a backlog clears the payments that matter most first.
+type WebhookPrioritizer struct {
+	payments  webhookPaymentRepo
+	highValue map[domain.Currency]int64
+}
+
+// NewWebhookPrioritizer builds the prioritizer. highValue is, per currency,
+// the payment amount at or above which a callback is high priority.
+func NewWebhookPrioritizer(payments webhookPaymentRepo, highValue map[domain.Currency]int64) *WebhookPrioritizer {
+	return &WebhookPrioritizer{payments: payments, highValue: highValue}
+}
+
+// Priority looks up the callback's payment. Callbacks it can't tie to a
+// payment are normal priority; the processor fails them anyway.
+func (p *WebhookPrioritizer) Priority(ctx context.Context, paymentID string, eventType domain.WebhookEventType) domain.WebhookPriority {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return domain.WebhookPriorityNormal
+	}
+	payment, err := p.payments.GetByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			logging.FromContext(ctx).Warn("webhook priority lookup failed", "payment_id", id, "error", err)
+		}
+		return domain.WebhookPriorityNormal
+	}
+	return webhookPriority(payment, eventType, p.highValue[payment.Source.Currency])
+}
+
+// webhookPriority puts payments of at least highValue first, whatever the
+// outcome. Below it, failures come before completions: a failure returns
+// the sender's money, while a completion only confirms money already gone.
+func webhookPriority(payment *domain.Payment, eventType domain.WebhookEventType, highValue int64) domain.WebhookPriority {
+	if highValue > 0 && payment.Source.Amount >= highValue {
+		return domain.WebhookPriorityHigh
+	}
+	if eventType == domain.WebhookEventTypePaymentFailed {
+		return domain.WebhookPriorityNormal
+	}
+	return domain.WebhookPriorityLow
+}
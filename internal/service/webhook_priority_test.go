@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+func TestWebhookPriority(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		eventType domain.WebhookEventType
+		highValue int64
+		want      domain.WebhookPriority
+	}{
+		{"large completion", 1_000_000, domain.WebhookEventTypePaymentCompleted, 1_000_000, domain.WebhookPriorityHigh},
+		{"large failure", 5_000_000, domain.WebhookEventTypePaymentFailed, 1_000_000, domain.WebhookPriorityHigh},
+		{"small failure", 999_999, domain.WebhookEventTypePaymentFailed, 1_000_000, domain.WebhookPriorityNormal},
+		{"small completion", 999_999, domain.WebhookEventTypePaymentCompleted, 1_000_000, domain.WebhookPriorityLow},
+		{"no high class for the currency", 5_000_000, domain.WebhookEventTypePaymentCompleted, 0, domain.WebhookPriorityLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &domain.Payment{Source: domain.NewMoney(tt.amount, domain.CurrencyUSD)}
+			assert.Equal(t, tt.want, webhookPriority(p, tt.eventType, tt.highValue))
+		})
+	}
+}
@@ -2,77 +2,161 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain/events"
 )
 
 type webhookRepo interface {
-	GetPending(ctx context.Context, limit int) ([]domain.WebhookEvent, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.WebhookEventStatus) error
+	Claim(ctx context.Context, owner string, limit int) ([]domain.WebhookEvent, error)
+	Renew(ctx context.Context, id uuid.UUID, owner string) error
+	Finish(ctx context.Context, id uuid.UUID, owner string, status domain.WebhookEventStatus, lastError string) error
+	Release(ctx context.Context, ids []uuid.UUID, owner string) error
 }
 
 type wpPaymentRepo interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
-	UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error
+	UpdateStatus(ctx context.Context, tx pgx.Tx, id uuid.UUID, from, to domain.PaymentStatus, providerRef *string, failureReason *string, completedAt *time.Time) error
+	SetFailureCode(ctx context.Context, tx pgx.Tx, id uuid.UUID, code domain.FailureCode) error
+	RejectReview(ctx context.Context, tx pgx.Tx, id uuid.UUID, failureReason string) error
+	ListPendingReversal(ctx context.Context, limit int) ([]domain.Payment, error)
+	MarkPendingReversal(ctx context.Context, tx pgx.Tx, id uuid.UUID, from domain.PaymentStatus, failureReason string) error
 }
 
 type wpAccountRepo interface {
 	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency domain.Currency, accountType domain.AccountType) (*domain.Account, error)
-	GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.Account, error)
-	UpdateBalance(ctx context.Context, tx *sql.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Account, error)
+	UpdateBalance(ctx context.Context, tx pgx.Tx, id uuid.UUID, newBalance int64, newVersion int64) error
+	Reopen(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
 }
 
 type wpLedgerRepo interface {
-	Create(ctx context.Context, tx *sql.Tx, entry *domain.LedgerEntry) error
+	Create(ctx context.Context, tx pgx.Tx, entry *domain.LedgerEntry) error
+	CreateBatch(ctx context.Context, tx pgx.Tx, entries []*domain.LedgerEntry) error
+	GetByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]domain.LedgerEntry, error)
 }
 
 type wpEventRepo interface {
-	Create(ctx context.Context, tx *sql.Tx, event *domain.PaymentEvent) error
+	Create(ctx context.Context, tx pgx.Tx, event *domain.PaymentEvent) error
+}
+
+type wpLatencyRepo interface {
+	Record(ctx context.Context, tx pgx.Tx, l *domain.ProviderLatency) error
+}
+
+type processedEventRepo interface {
+	Mark(ctx context.Context, tx pgx.Tx, consumer string, eventID uuid.UUID) error
+}
+
+// webhookConsumer is the webhook processor's name in processed_events.
+const webhookConsumer = "webhook_processor"
+
+type webhookMetrics interface {
+	WebhookProcessed(priority, outcome string, wait time.Duration)
 }
 
 type WebhookProcessor struct {
-	webhooks webhookRepo
-	payments wpPaymentRepo
-	accounts wpAccountRepo
-	ledger   wpLedgerRepo
-	events   wpEventRepo
-	db       *sql.DB
-	logger   *slog.Logger
-	interval time.Duration
+	webhooks  webhookRepo
+	payments  wpPaymentRepo
+	accounts  wpAccountRepo
+	ledger    wpLedgerRepo
+	events    wpEventRepo
+	latencies wpLatencyRepo
+	processed processedEventRepo
+	db        *pgxpool.Pool
+	logger    *slog.Logger
+	tracker   *InFlightTracker
+	alerter   Alerter
+	metrics   webhookMetrics
+	interval  time.Duration
+	wake      <-chan struct{}
+
+	// owner names this processor on the events it claims. workers holds a
+	// slot per payment being processed; busy holds those payments.
+	owner    string
+	workers  chan struct{}
+	again    chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	busy     map[string]bool
+	failures atomic.Int64
 }
 
+// webhookBatchSize is how many events one poll claims.
+const webhookBatchSize = 10
+
 func NewWebhookProcessor(
 	webhooks webhookRepo,
 	payments wpPaymentRepo,
 	accounts wpAccountRepo,
 	ledger wpLedgerRepo,
 	events wpEventRepo,
-	db *sql.DB,
+	latencies wpLatencyRepo,
+	processed processedEventRepo,
+	db *pgxpool.Pool,
 	logger *slog.Logger,
+	tracker *InFlightTracker,
+	alerter Alerter,
+	metrics webhookMetrics,
 	interval time.Duration,
+	wake <-chan struct{},
+	concurrency int,
 ) *WebhookProcessor {
+	if concurrency < 1 {
+		concurrency = 1
+	}
 	return &WebhookProcessor{
-		webhooks: webhooks,
-		payments: payments,
-		accounts: accounts,
-		ledger:   ledger,
-		events:   events,
-		db:       db,
-		logger:   logger,
-		interval: interval,
+		webhooks:  webhooks,
+		payments:  payments,
+		accounts:  accounts,
+		ledger:    ledger,
+		events:    events,
+		latencies: latencies,
+		processed: processed,
+		db:        db,
+		logger:    logger,
+		tracker:   tracker,
+		alerter:   alerter,
+		metrics:   metrics,
+		interval:  interval,
+		wake:      wake,
+		owner:     webhookClaimOwner(),
+		workers:   make(chan struct{}, concurrency),
+		again:     make(chan struct{}, 1),
+		busy:      make(map[string]bool),
 	}
 }
 
+// webhookClaimOwner identifies this processor on its claims, so operators
+// can tell which instance holds an event.
+func webhookClaimOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.NewString()[:8])
+}
+
+// Start polls every interval. With a wake channel (fed by LISTEN on
+// webhook_events) it also polls as soon as an event is stored, and the
+// interval is only a sweep for notifications lost to a dropped connection.
+// Events are processed by up to concurrency workers; on shutdown Start
+// returns once the ones in progress have finished. Events claimed but not
+// started by then are claimed again once their lease expires.
 func (p *WebhookProcessor) Start(ctx context.Context) {
-	p.logger.Info("webhook processor started", "interval", p.interval)
+	p.logger.Info("webhook processor started", "interval", p.interval, "notified", p.wake != nil, "concurrency", cap(p.workers))
 
 	ticker := time.NewTicker(p.interval)
 	defer ticker.Stop()
@@ -80,29 +164,172 @@ func (p *WebhookProcessor) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			p.wg.Wait()
 			p.logger.Info("webhook processor stopped")
 			return
 		case <-ticker.C:
-			p.poll(ctx)
+			p.drain(ctx)
+		case <-p.wake:
+			p.drain(ctx)
+		case <-p.again:
+			p.drain(ctx)
+		}
+	}
+}
+
+// drain polls until a poll hands out less than a full batch. A failure
+// during the drain stops it too, so an event that keeps failing is retried
+// at the next wake-up or sweep rather than in a tight loop.
+func (p *WebhookProcessor) drain(ctx context.Context) {
+	failures := p.failures.Load()
+	for ctx.Err() == nil && p.failures.Load() == failures {
+		if p.poll(ctx) < webhookBatchSize {
+			break
 		}
 	}
+	p.retryPendingReversals(ctx)
 }
 
-func (p *WebhookProcessor) poll(ctx context.Context) {
-	events, err := p.webhooks.GetPending(ctx, 10)
+// poll claims a batch of events and hands them to the workers, a payment's
+// events to one worker in order, and returns how many it claimed. It blocks
+// while every worker is busy.
+func (p *WebhookProcessor) poll(ctx context.Context) int {
+	events, err := p.webhooks.Claim(ctx, p.owner, webhookBatchSize)
 	if err != nil {
-		p.logger.Error("failed to fetch pending webhook events", "error", err)
-		return
+		p.logger.Error("failed to claim webhook events", "error", err)
+		return 0
 	}
 
+	var keys []string
+	groups := make(map[string][]domain.WebhookEvent)
 	for _, event := range events {
-		if err := p.processEvent(ctx, event); err != nil {
-			p.logger.Error("failed to process webhook event",
-				"webhook_event_id", event.ID,
-				"error", err,
-			)
+		key := webhookPaymentKey(event)
+		if groups[key] == nil {
+			keys = append(keys, key)
 		}
+		groups[key] = append(groups[key], event)
+	}
+
+	for _, key := range keys {
+		select {
+		case p.workers <- struct{}{}:
+		case <-ctx.Done():
+			return len(events)
+		}
+		p.setBusy(key, true)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			group := groups[key]
+			failed := false
+			for i, event := range group {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := p.process(ctx, event); err != nil {
+					// The payment's later events must not overtake this
+					// one, so they go back to the queue unprocessed.
+					failed = true
+					p.release(ctx, group[i+1:])
+					break
+				}
+			}
+			<-p.workers
+			p.setBusy(key, false)
+
+			// The payment's later events weren't claimable while these were
+			// leased. An event that failed waits for the next wake-up or
+			// sweep instead, so it isn't retried in a tight loop.
+			if !failed {
+				select {
+				case p.again <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+	return len(events)
+}
+
+// process renews the event's lease, so a long batch doesn't lose the events
+// at its end, and processes it. An event whose claim has already passed to
+// another processor is left to that one. It returns an error when the event
+// wasn't processed, and the payment's later events have to wait for it.
+func (p *WebhookProcessor) process(ctx context.Context, event domain.WebhookEvent) error {
+	if err := p.webhooks.Renew(ctx, event.ID, p.owner); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			p.logger.Warn("webhook event claim lost", "webhook_event_id", event.ID)
+			return fmt.Errorf("process: %w", err)
+		}
+		p.failures.Add(1)
+		p.logger.Error("failed to renew webhook event lease", "webhook_event_id", event.ID, "error", err)
+		return fmt.Errorf("process: %w", err)
+	}
+
+	done := p.tracker.Track(domain.InFlightWebhookEvent, event.ID.String())
+	defer done()
+
+	outcome := "ok"
+	err := p.processEvent(ctx, event)
+	if err != nil {
+		outcome = "error"
+		p.failures.Add(1)
+		p.logger.Error("failed to process webhook event",
+			"webhook_event_id", event.ID,
+			"priority", event.Priority,
+			"error", err,
+		)
+	}
+	if p.metrics != nil {
+		p.metrics.WebhookProcessed(event.Priority.String(), outcome, time.Since(event.CreatedAt))
+	}
+	if err != nil {
+		return fmt.Errorf("process: %w", err)
+	}
+	return nil
+}
+
+// release hands claimed events back to the queue without processing them.
+// If that fails they are claimed again once their lease expires.
+func (p *WebhookProcessor) release(ctx context.Context, events []domain.WebhookEvent) {
+	if len(events) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
 	}
+	if err := p.webhooks.Release(ctx, ids, p.owner); err != nil {
+		p.logger.Error("failed to release webhook events", "webhook_event_ids", ids, "error", err)
+		return
+	}
+	p.logger.Warn("released webhook events behind a failed one", "webhook_event_ids", ids)
+}
+
+func (p *WebhookProcessor) setBusy(key string, busy bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if busy {
+		p.busy[key] = true
+	} else {
+		delete(p.busy, key)
+	}
+}
+
+func (p *WebhookProcessor) paymentBusy(id uuid.UUID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.busy[id.String()]
+}
+
+// webhookPaymentKey is the payment an event is about, which orders it
+// against the payment's other events. An event that doesn't name one is
+// ordered against nothing.
+func webhookPaymentKey(event domain.WebhookEvent) string {
+	if event.PaymentID != nil {
+		return event.PaymentID.String()
+	}
+	return "event:" + event.ID.String()
 }
 
 type webhookCallbackPayload struct {
@@ -111,25 +338,37 @@ type webhookCallbackPayload struct {
 	Status      string `json:"status"`
 	ProviderRef string `json:"provider_ref,omitempty"`
 	Reason      string `json:"reason,omitempty"`
+	Code        string `json:"code,omitempty"`
 }
 
 func (p *WebhookProcessor) processEvent(ctx context.Context, event domain.WebhookEvent) error {
 	var payload webhookCallbackPayload
 	if err := json.Unmarshal(event.Payload, &payload); err != nil {
 		p.logger.Error("malformed webhook payload", "webhook_event_id", event.ID, "error", err)
-		return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusFailed)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed, "malformed payload: "+err.Error())
 	}
 
 	paymentID, err := uuid.Parse(payload.PaymentID)
 	if err != nil {
 		p.logger.Error("invalid payment_id in webhook", "webhook_event_id", event.ID, "payment_id", payload.PaymentID)
-		return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusFailed)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed, fmt.Sprintf("invalid payment_id %q", payload.PaymentID))
 	}
 
 	payment, err := p.payments.GetByID(ctx, paymentID)
 	if err != nil {
 		p.logger.Warn("payment not found for webhook", "webhook_event_id", event.ID, "payment_id", paymentID)
-		return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusFailed)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed, "payment not found: "+err.Error())
+	}
+
+	if !providerMatches(event, payment) {
+		p.logger.Warn("webhook from a provider the payout wasn't routed to",
+			"webhook_event_id", event.ID,
+			"payment_id", paymentID,
+			"event_provider", stringValue(event.Provider),
+			"payment_provider", stringValue(payment.Provider),
+		)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed,
+			fmt.Sprintf("callback from %q for a payout routed to %q", *event.Provider, stringValue(payment.Provider)))
 	}
 
 	if isTerminalStatus(payment.Status) {
@@ -138,39 +377,101 @@ func (p *WebhookProcessor) processEvent(ctx context.Context, event domain.Webhoo
 			"payment_id", paymentID,
 			"payment_status", payment.Status,
 		)
-		return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusDispatched)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusDispatched, "")
 	}
 
-	switch payload.Status {
-	case "completed":
-		err = p.handleCompleted(ctx, payment, payload.ProviderRef)
-	case "failed":
-		err = p.handleFailed(ctx, payment, payload.Reason)
-	default:
+	err = p.applyOutcome(ctx, payment, payload.Status, payload.ProviderRef, payload.Reason, domain.FailureCode(payload.Code), "system", event.ID)
+	if errors.Is(err, errUnknownOutcome) {
 		p.logger.Error("unknown webhook status", "webhook_event_id", event.ID, "status", payload.Status)
-		return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusFailed)
+		return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed, fmt.Sprintf("unknown status %q", payload.Status))
 	}
 
 	if err != nil {
+		// An earlier attempt at this event committed, and this one was
+		// handed the event again (its lease expired, or finishing failed).
+		if errors.Is(err, domain.ErrAlreadyProcessed) {
+			p.logger.Info("webhook event already applied, skipping",
+				"webhook_event_id", event.ID,
+				"payment_id", paymentID,
+			)
+			return p.finish(ctx, event.ID, domain.WebhookEventStatusDispatched, "")
+		}
 		if errors.Is(err, domain.ErrPaymentTerminal) {
 			p.logger.Info("payment transitioned to terminal during processing",
 				"webhook_event_id", event.ID,
 				"payment_id", paymentID,
 			)
-			return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusDispatched)
+			return p.finish(ctx, event.ID, domain.WebhookEventStatusDispatched, "")
+		}
+		// The payment is somewhere this outcome can't move it from, such
+		// as a payout still in review. Retrying won't change that.
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			p.logger.Warn("webhook outcome not allowed from payment status",
+				"webhook_event_id", event.ID,
+				"payment_id", paymentID,
+				"error", err,
+			)
+			return p.finish(ctx, event.ID, domain.WebhookEventStatusFailed, err.Error())
+		}
+		// Back in the queue for a later claim. That includes
+		// ErrVersionConflict: the payment moved to another open status
+		// while this ran, and the next attempt reads it again.
+		if ferr := p.finish(ctx, event.ID, domain.WebhookEventStatusPending, err.Error()); ferr != nil {
+			p.logger.Error("failed to release webhook event", "webhook_event_id", event.ID, "error", ferr)
 		}
 		return fmt.Errorf("processEvent: %w", err)
 	}
 
-	return p.webhooks.UpdateStatus(ctx, event.ID, domain.WebhookEventStatusDispatched)
+	return p.finish(ctx, event.ID, domain.WebhookEventStatusDispatched, "")
+}
+
+// providerMatches reports whether the callback came from the provider the
+// payout was handed to. Only that provider may settle it; a payout not yet
+// handed to one can't be settled by any. Events stored before callbacks
+// recorded their provider are not checked.
+func providerMatches(event domain.WebhookEvent, payment *domain.Payment) bool {
+	if event.Provider == nil {
+		return true
+	}
+	return payment.Provider != nil && *payment.Provider == *event.Provider
+}
+
+// finish records the attempt, and why it didn't succeed when it didn't,
+// and gives up the claim.
+func (p *WebhookProcessor) finish(ctx context.Context, id uuid.UUID, status domain.WebhookEventStatus, lastError string) error {
+	if err := p.webhooks.Finish(ctx, id, p.owner, status, lastError); err != nil {
+		return fmt.Errorf("finish: %w", err)
+	}
+	return nil
+}
+
+var errUnknownOutcome = errors.New("unknown provider outcome")
+
+// applyOutcome moves the payout to the provider's outcome. actor is who
+// reported it, recorded on the payment event. eventID is the webhook event
+// it came from, recorded with the change so the event is applied once; it
+// is uuid.Nil for outcomes that didn't come from one.
+func (p *WebhookProcessor) applyOutcome(ctx context.Context, payment *domain.Payment, status, providerRef, reason string, code domain.FailureCode, actor string, eventID uuid.UUID) error {
+	switch status {
+	case "completed":
+		return p.handleCompleted(ctx, payment, providerRef, actor, eventID)
+	case "failed":
+		return p.handleFailed(ctx, payment, reason, code, actor, eventID)
+	default:
+		return fmt.Errorf("applyOutcome: %q: %w", status, errUnknownOutcome)
+	}
 }
 
-func (p *WebhookProcessor) handleCompleted(ctx context.Context, payment *domain.Payment, providerRef string) error {
-	tx, err := p.db.BeginTx(ctx, nil)
+func (p *WebhookProcessor) handleCompleted(ctx context.Context, payment *domain.Payment, providerRef, actor string, eventID uuid.UUID) error {
+	tx, err := p.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("handleCompleted: begin tx: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
+
+	if err := p.markProcessed(ctx, tx, eventID); err != nil {
+		return fmt.Errorf("handleCompleted: %w", err)
+	}
 
 	now := time.Now().UTC()
 	var ref *string
@@ -178,22 +479,31 @@ func (p *WebhookProcessor) handleCompleted(ctx context.Context, payment *domain.
 		ref = &providerRef
 	}
 
-	if err := p.payments.UpdateStatus(ctx, tx, payment.ID, domain.PaymentStatusCompleted, ref, nil, &now); err != nil {
+	if err := p.payments.UpdateStatus(ctx, tx, payment.ID, payment.Status, domain.PaymentStatusCompleted, ref, nil, &now); err != nil {
 		return fmt.Errorf("handleCompleted: update payment: %w", err)
 	}
+	if err := p.recordLatency(ctx, tx, payment, domain.PaymentStatusCompleted, now); err != nil {
+		return fmt.Errorf("handleCompleted: %w", err)
+	}
+
+	payload, err := events.Marshal(events.NewPaymentCompleted(payment, providerRef, now))
+	if err != nil {
+		return fmt.Errorf("handleCompleted: %w", err)
+	}
 
 	event := &domain.PaymentEvent{
 		ID:        uuid.New(),
 		PaymentID: payment.ID,
 		EventType: domain.PaymentEventTypeCompleted,
-		Actor:     "system",
+		Actor:     actor,
+		Payload:   payload,
 		CreatedAt: now,
 	}
 	if err := p.events.Create(ctx, tx, event); err != nil {
 		return fmt.Errorf("handleCompleted: create event: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("handleCompleted: commit: %w", err)
 	}
 
@@ -201,80 +511,276 @@ func (p *WebhookProcessor) handleCompleted(ctx context.Context, payment *domain.
 	return nil
 }
 
-func (p *WebhookProcessor) handleFailed(ctx context.Context, payment *domain.Payment, reason string) error {
-	isCrossCurrency := payment.SourceCurrency != payment.DestCurrency
+func (p *WebhookProcessor) handleFailed(ctx context.Context, payment *domain.Payment, reason string, code domain.FailureCode, actor string, eventID uuid.UUID) error {
+	return p.failPayout(ctx, payment, reason, code, actor, eventID)
+}
+
+// markProcessed records in tx that the webhook event is applied. It goes
+// first, so a second attempt at the event waits for the first to finish
+// and then, if it committed, gets domain.ErrAlreadyProcessed and writes
+// nothing.
+func (p *WebhookProcessor) markProcessed(ctx context.Context, tx pgx.Tx, eventID uuid.UUID) error {
+	if eventID == uuid.Nil || p.processed == nil {
+		return nil
+	}
+	if err := p.processed.Mark(ctx, tx, webhookConsumer, eventID); err != nil {
+		return fmt.Errorf("markProcessed: %w", err)
+	}
+	return nil
+}
+
+// failPayout marks the payout failed and reverses its debit. Payouts under
+// review only fail if they are still pending review, so a concurrent
+// approval wins cleanly. eventID is the webhook event that reported the
+// failure, or uuid.Nil.
+func (p *WebhookProcessor) failPayout(ctx context.Context, payment *domain.Payment, reason string, code domain.FailureCode, actor string, eventID uuid.UUID) error {
+	isCrossCurrency := payment.Source.Currency != payment.Dest.Currency
 
 	accountIDs := []uuid.UUID{payment.SourceAccountID}
 
 	var outgoingID uuid.UUID
-	outgoing, err := p.getSystemAccount(ctx, domain.AccountTypeOutgoing, payment.DestCurrency)
+	outgoing, err := p.getSystemAccount(ctx, domain.AccountTypeOutgoing, payment.Dest.Currency)
 	if err != nil {
-		return fmt.Errorf("handleFailed: %w", err)
+		return fmt.Errorf("failPayout: %w", err)
 	}
 	outgoingID = outgoing.ID
 	accountIDs = append(accountIDs, outgoingID)
 
-	var fxPoolSourceID, fxPoolDestID uuid.UUID
+	var fxPoolSourceID, fxPoolDestID, revenueID uuid.UUID
 	if isCrossCurrency {
-		fxSrc, err := p.getSystemAccount(ctx, domain.AccountTypeFXPool, payment.SourceCurrency)
+		fxSrc, err := p.getSystemAccount(ctx, domain.AccountTypeFXPool, payment.Source.Currency)
 		if err != nil {
-			return fmt.Errorf("handleFailed: %w", err)
+			return fmt.Errorf("failPayout: %w", err)
 		}
-		fxDst, err := p.getSystemAccount(ctx, domain.AccountTypeFXPool, payment.DestCurrency)
+		fxDst, err := p.getSystemAccount(ctx, domain.AccountTypeFXPool, payment.Dest.Currency)
 		if err != nil {
-			return fmt.Errorf("handleFailed: %w", err)
+			return fmt.Errorf("failPayout: %w", err)
 		}
 		fxPoolSourceID = fxSrc.ID
 		fxPoolDestID = fxDst.ID
 		accountIDs = append(accountIDs, fxPoolSourceID, fxPoolDestID)
+
+		revenueID, err = p.bookedFeeAccount(ctx, payment)
+		if err != nil {
+			return fmt.Errorf("failPayout: %w", err)
+		}
+		if revenueID != uuid.Nil {
+			accountIDs = append(accountIDs, revenueID)
+		}
 	}
 
-	tx, err := p.db.BeginTx(ctx, nil)
+	tx, err := p.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("handleFailed: begin tx: %w", err)
+		return fmt.Errorf("failPayout: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := p.markProcessed(ctx, tx, eventID); err != nil {
+		return fmt.Errorf("failPayout: %w", err)
 	}
-	defer tx.Rollback()
 
 	locked, err := lockAccountsInOrder(ctx, tx, p.accounts, accountIDs...)
 	if err != nil {
-		return fmt.Errorf("handleFailed: %w", err)
+		return fmt.Errorf("failPayout: %w", err)
 	}
 
 	now := time.Now().UTC()
 	failureReason := &reason
 
-	if err := p.payments.UpdateStatus(ctx, tx, payment.ID, domain.PaymentStatusFailed, nil, failureReason, nil); err != nil {
-		return fmt.Errorf("handleFailed: update payment: %w", err)
-	}
-
+	var entries []reversalEntry
 	if isCrossCurrency {
-		if err := p.writeCrossCurrencyReversal(ctx, tx, payment, locked, outgoingID, fxPoolSourceID, fxPoolDestID, now); err != nil {
-			return fmt.Errorf("handleFailed: %w", err)
+		if entries, err = crossCurrencyReversalEntries(payment, locked, outgoingID, fxPoolSourceID, fxPoolDestID, revenueID); err != nil {
+			return fmt.Errorf("failPayout: %w", err)
 		}
 	} else {
-		if err := p.writeSameCurrencyReversal(ctx, tx, payment, locked, outgoingID, now); err != nil {
-			return fmt.Errorf("handleFailed: %w", err)
+		entries = sameCurrencyReversalEntries(payment, locked, outgoingID)
+	}
+	if short := reversalShortfall(entries); short != nil {
+		return p.parkReversal(ctx, tx, payment, reason, code, actor, short, now)
+	}
+
+	retrying := payment.Status == domain.PaymentStatusPendingReversal
+	if payment.Status == domain.PaymentStatusPendingReview {
+		if err := p.payments.RejectReview(ctx, tx, payment.ID, reason); err != nil {
+			return fmt.Errorf("failPayout: %w", err)
+		}
+	} else if err := p.payments.UpdateStatus(ctx, tx, payment.ID, payment.Status, domain.PaymentStatusFailed, nil, failureReason, nil); err != nil {
+		return fmt.Errorf("failPayout: update payment: %w", err)
+	}
+	if code != "" {
+		if err := p.payments.SetFailureCode(ctx, tx, payment.ID, code); err != nil {
+			return fmt.Errorf("failPayout: %w", err)
+		}
+	}
+	if !retrying {
+		if err := p.recordLatency(ctx, tx, payment, domain.PaymentStatusFailed, now); err != nil {
+			return fmt.Errorf("failPayout: %w", err)
+		}
+	}
+
+	if err := p.writeReversalEntries(ctx, tx, payment.ID, entries, now); err != nil {
+		return fmt.Errorf("failPayout: %w", err)
+	}
+
+	// A failed closure sweep brings the money back, so the account it
+	// emptied has to be usable again.
+	if payment.Type == domain.PaymentTypeSweep {
+		if err := p.accounts.Reopen(ctx, tx, payment.SourceAccountID); err != nil {
+			return fmt.Errorf("failPayout: %w", err)
+		}
+	}
+
+	payload, err := events.Marshal(events.NewPaymentFailed(payment, reason, code, true, now))
+	if err != nil {
+		return fmt.Errorf("failPayout: %w", err)
+	}
+
+	event := &domain.PaymentEvent{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		EventType: domain.PaymentEventTypeFailed,
+		Actor:     actor,
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	if err := p.events.Create(ctx, tx, event); err != nil {
+		return fmt.Errorf("failPayout: create event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failPayout: commit: %w", err)
+	}
+
+	p.logger.Info("payment failed, reversal complete", "payment_id", payment.ID, "reason", reason, "code", code)
+	return nil
+}
+
+// parkReversal handles a failed payout whose reversal a system account can't
+// cover. The payout moves to pending_reversal with the sender still debited,
+// a failed event records that nothing was reversed, and operators are
+// alerted. retryPendingReversals finishes it once the account is funded.
+// A payout already parked stays as it is.
+func (p *WebhookProcessor) parkReversal(ctx context.Context, tx pgx.Tx, payment *domain.Payment, reason string, code domain.FailureCode, actor string, short *reversalEntry, now time.Time) error {
+	if payment.Status == domain.PaymentStatusPendingReversal {
+		p.logger.Warn("reversal still short of funds",
+			"payment_id", payment.ID,
+			"account_id", short.account.ID,
+			"balance", short.account.Balance,
+			"needed", short.amount.Amount,
+		)
+		return nil
+	}
+
+	if err := p.payments.MarkPendingReversal(ctx, tx, payment.ID, payment.Status, reason); err != nil {
+		return fmt.Errorf("parkReversal: %w", err)
+	}
+	if code != "" {
+		if err := p.payments.SetFailureCode(ctx, tx, payment.ID, code); err != nil {
+			return fmt.Errorf("parkReversal: %w", err)
 		}
 	}
+	if err := p.recordLatency(ctx, tx, payment, domain.PaymentStatusFailed, now); err != nil {
+		return fmt.Errorf("parkReversal: %w", err)
+	}
 
-	reasonJSON, _ := json.Marshal(map[string]string{"reason": reason})
+	payload, err := events.Marshal(events.NewPaymentFailed(payment, reason, code, false, now))
+	if err != nil {
+		return fmt.Errorf("parkReversal: %w", err)
+	}
 	event := &domain.PaymentEvent{
 		ID:        uuid.New(),
 		PaymentID: payment.ID,
 		EventType: domain.PaymentEventTypeFailed,
-		Actor:     "system",
-		Payload:   reasonJSON,
+		Actor:     actor,
+		Payload:   payload,
 		CreatedAt: now,
 	}
 	if err := p.events.Create(ctx, tx, event); err != nil {
-		return fmt.Errorf("handleFailed: create event: %w", err)
+		return fmt.Errorf("parkReversal: create event: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("handleFailed: commit: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("parkReversal: commit: %w", err)
 	}
 
-	p.logger.Info("payment failed, reversal complete", "payment_id", payment.ID, "reason", reason)
+	if p.alerter != nil {
+		p.alerter.Alert(ctx, Alert{
+			Kind:    "reversal_short_of_funds",
+			Subject: "Failed payout can't be reversed: system account short of funds",
+			Attrs: []any{
+				"payment_id", payment.ID,
+				"account_id", short.account.ID,
+				"account_type", short.account.AccountType,
+				"currency", short.amount.Currency,
+				"balance", short.account.Balance,
+				"needed", short.amount.Amount,
+			},
+		})
+	}
 	return nil
 }
 
+// retryPendingReversals re-runs the reversal of payouts parked in
+// pending_reversal. Those whose system accounts are still short stay parked.
+func (p *WebhookProcessor) retryPendingReversals(ctx context.Context) {
+	payments, err := p.payments.ListPendingReversal(ctx, 10)
+	if err != nil {
+		p.logger.Error("failed to list pending reversals", "error", err)
+		return
+	}
+
+	for i := range payments {
+		pmt := &payments[i]
+		// A webhook for it in progress settles it either way.
+		if p.paymentBusy(pmt.ID) {
+			continue
+		}
+		var code domain.FailureCode
+		if pmt.FailureCode != nil {
+			code = *pmt.FailureCode
+		}
+		var reason string
+		if pmt.FailureReason != nil {
+			reason = *pmt.FailureReason
+		}
+		err := p.failPayout(ctx, pmt, reason, code, "system", uuid.Nil)
+		switch {
+		case errors.Is(err, domain.ErrPaymentTerminal), errors.Is(err, domain.ErrVersionConflict):
+			// Another processor finished it since it was listed.
+			p.logger.Info("pending reversal already moved on", "payment_id", pmt.ID, "error", err)
+		case err != nil:
+			p.logger.Error("failed to retry pending reversal", "payment_id", pmt.ID, "error", err)
+		}
+	}
+}
+
+// recordLatency stores how long the provider took to reach a terminal
+// outcome. Payouts never handed to a provider have nothing to measure.
+func (p *WebhookProcessor) recordLatency(ctx context.Context, tx pgx.Tx, payment *domain.Payment, outcome domain.PaymentStatus, resolvedAt time.Time) error {
+	if payment.Provider == nil {
+		return nil
+	}
+	submittedAt := payment.CreatedAt
+	if payment.SubmittedAt != nil {
+		submittedAt = *payment.SubmittedAt
+	}
+	latency := resolvedAt.Sub(submittedAt).Milliseconds()
+	if latency < 0 {
+		latency = 0
+	}
+
+	err := p.latencies.Record(ctx, tx, &domain.ProviderLatency{
+		PaymentID:      payment.ID,
+		Provider:       *payment.Provider,
+		SourceCurrency: payment.Source.Currency,
+		DestCurrency:   payment.Dest.Currency,
+		Outcome:        outcome,
+		SubmittedAt:    submittedAt,
+		ResolvedAt:     resolvedAt,
+		LatencyMS:      latency,
+	})
+	if err != nil {
+		return fmt.Errorf("recordLatency: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,15 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -20,7 +22,7 @@ import (
 	"github.com/josh-kwaku/grey-backend-assessment/internal/testutil"
 )
 
-func setupWebhookTest(t *testing.T, db *sql.DB) (*payment.Service, *WebhookProcessor, *repository.WebhookEventRepository) {
+func setupWebhookTest(t *testing.T, db *pgxpool.Pool) (*payment.Service, *WebhookProcessor, *repository.WebhookEventRepository) {
 	t.Helper()
 
 	paymentSvc := payment.NewService(
@@ -29,26 +31,38 @@ func setupWebhookTest(t *testing.T, db *sql.DB) (*payment.Service, *WebhookProce
 		repository.NewLedgerRepository(db),
 		repository.NewPaymentEventRepository(db),
 		repository.NewUserRepository(db),
-		fx.NewRateService(0.005),
+		repository.NewUserLimitRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		fx.NewRateService(0.005, domain.CurrencyUSD, testutil.FXRates()),
+		nil,
+		nil,
+		nil,
+		nil,
 		nil,
 		db,
 		&config.Config{
-			TxLimitUSD: 10_000_000,
-			TxLimitEUR: 9_000_000,
-			TxLimitGBP: 8_000_000,
+			TxLimits: map[string]int64{"USD": 10_000_000, "EUR": 9_000_000, "GBP": 8_000_000},
 		},
 	)
 
-	webhookRepo := repository.NewWebhookEventRepository(db)
+	webhookRepo := repository.NewWebhookEventRepository(db, 0, 0, time.Minute)
 	processor := NewWebhookProcessor(
 		webhookRepo,
 		repository.NewPaymentRepository(db),
 		repository.NewAccountRepository(db),
 		repository.NewLedgerRepository(db),
 		repository.NewPaymentEventRepository(db),
+		repository.NewProviderLatencyRepository(db),
+		repository.NewProcessedEventRepository(db),
 		db,
 		slog.Default(),
+		nil,
+		nil,
+		nil,
 		time.Second,
+		nil,
+		1,
 	)
 
 	return paymentSvc, processor, webhookRepo
@@ -75,6 +89,7 @@ func insertWebhookEvent(t *testing.T, repo *repository.WebhookEventRepository, p
 		IdempotencyKey: uuid.NewString(),
 		EventType:      eventType,
 		Payload:        payload,
+		PaymentID:      &paymentID,
 		Status:         domain.WebhookEventStatusPending,
 		CreatedAt:      time.Now().UTC(),
 	}
@@ -82,10 +97,25 @@ func insertWebhookEvent(t *testing.T, repo *repository.WebhookEventRepository, p
 	return event
 }
 
-func getWebhookStatus(t *testing.T, db *sql.DB, id uuid.UUID) domain.WebhookEventStatus {
+// claimWebhookEvent claims event for the processor, as its poll would, so
+// the test can hand it to processEvent.
+func claimWebhookEvent(t *testing.T, repo *repository.WebhookEventRepository, processor *WebhookProcessor, event *domain.WebhookEvent) domain.WebhookEvent {
+	t.Helper()
+	claimed, err := repo.Claim(context.Background(), processor.owner, 10)
+	require.NoError(t, err)
+	for _, e := range claimed {
+		if e.ID == event.ID {
+			return e
+		}
+	}
+	require.FailNow(t, "webhook event was not claimed")
+	return domain.WebhookEvent{}
+}
+
+func getWebhookStatus(t *testing.T, db *pgxpool.Pool, id uuid.UUID) domain.WebhookEventStatus {
 	t.Helper()
 	var status domain.WebhookEventStatus
-	err := db.QueryRow(`SELECT status FROM webhook_events WHERE id = $1`, id).Scan(&status)
+	err := db.QueryRow(context.Background(), `SELECT status FROM webhook_events WHERE id = $1`, id).Scan(&status)
 	require.NoError(t, err)
 	return status
 }
@@ -112,7 +142,7 @@ func TestWebhookProcessor_CompletedPayout(t *testing.T) {
 
 	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "completed", "")
 
-	err = processor.processEvent(ctx, *webhookEvent)
+	err = processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent))
 	require.NoError(t, err)
 
 	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
@@ -136,6 +166,174 @@ func TestWebhookProcessor_CompletedPayout(t *testing.T) {
 	assert.Equal(t, domain.WebhookEventStatusDispatched, getWebhookStatus(t, db, webhookEvent.ID))
 }
 
+func TestWebhookProcessor_StaleOutcomeLosesTheRace(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, _ := setupWebhookTest(t, db)
+	payments := repository.NewPaymentRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_race")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	// Both outcomes were read while the payout was still pending.
+	stale, err := payments.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	require.NoError(t, processor.applyOutcome(ctx, stale, "completed", "prov-ref-123", "", "", "system", uuid.New()))
+
+	err = processor.applyOutcome(ctx, stale, "failed", "", "provider_declined", "", "system", uuid.New())
+	assert.ErrorIs(t, err, domain.ErrPaymentTerminal)
+
+	updated, err := payments.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, updated.Status)
+	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, senderAcct.ID), "no reversal was written")
+	assert.Equal(t, 2, testutil.CountLedgerEntries(t, db, p.ID))
+
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	err = payments.UpdateStatus(ctx, tx, p.ID, domain.PaymentStatusCompleted, domain.PaymentStatusFailed, nil, nil, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+}
+
+func TestWebhookProcessor_RecordsProviderLatency(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, webhookRepo := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_lat")
+	testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), `UPDATE payments SET provider = 'mock_provider', submitted_at = now() - interval '90 seconds' WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+
+	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "completed", "")
+	require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent)))
+
+	stats, err := repository.NewProviderLatencyRepository(db).Stats(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "mock_provider", stats[0].Provider)
+	assert.Equal(t, "USD-USD", stats[0].Corridor())
+	assert.Equal(t, 1, stats[0].Samples)
+	assert.GreaterOrEqual(t, stats[0].P95MS, int64(90_000))
+}
+
+func TestWebhookProcessor_RejectsCallbackFromAnotherProvider(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, webhookRepo := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_xprov")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyUSD,
+		Amount:         5000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `UPDATE payments SET provider = 'mock_provider' WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		provider string
+		status   string
+	}{
+		{name: "completion from another provider", provider: "acme", status: "completed"},
+		{name: "failure from another provider", provider: "acme", status: "failed"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, _ := json.Marshal(webhookCallbackPayload{
+				EventID:     uuid.NewString(),
+				PaymentID:   p.ID.String(),
+				Status:      tc.status,
+				ProviderRef: "acme-ref",
+				Reason:      "provider_declined",
+			})
+			eventType := domain.WebhookEventTypePaymentCompleted
+			if tc.status == "failed" {
+				eventType = domain.WebhookEventTypePaymentFailed
+			}
+			provider := tc.provider
+			event := &domain.WebhookEvent{
+				ID:             uuid.New(),
+				IdempotencyKey: uuid.NewString(),
+				EventType:      eventType,
+				Payload:        payload,
+				PaymentID:      &p.ID,
+				Provider:       &provider,
+				Status:         domain.WebhookEventStatusPending,
+				CreatedAt:      time.Now().UTC(),
+			}
+			require.NoError(t, webhookRepo.Create(ctx, event))
+
+			require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, event)))
+
+			assert.Equal(t, domain.WebhookEventStatusFailed, getWebhookStatus(t, db, event.ID))
+			stored, err := webhookRepo.GetByID(ctx, event.ID)
+			require.NoError(t, err)
+			require.NotNil(t, stored.LastError)
+			assert.Contains(t, *stored.LastError, `"acme"`)
+
+			updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, domain.PaymentStatusPending, updated.Status, "the payout is untouched")
+			assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+			assert.Equal(t, 2, testutil.CountLedgerEntries(t, db, p.ID))
+		})
+	}
+
+	// The provider the payout was routed to can still settle it.
+	provider := "mock_provider"
+	payload, _ := json.Marshal(webhookCallbackPayload{EventID: uuid.NewString(), PaymentID: p.ID.String(), Status: "completed", ProviderRef: "prov-ref-123"})
+	event := &domain.WebhookEvent{
+		ID:             uuid.New(),
+		IdempotencyKey: uuid.NewString(),
+		EventType:      domain.WebhookEventTypePaymentCompleted,
+		Payload:        payload,
+		PaymentID:      &p.ID,
+		Provider:       &provider,
+		Status:         domain.WebhookEventStatusPending,
+		CreatedAt:      time.Now().UTC(),
+	}
+	require.NoError(t, webhookRepo.Create(ctx, event))
+	require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, event)))
+
+	updated, err := repository.NewPaymentRepository(db).GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, updated.Status)
+}
+
 func TestWebhookProcessor_FailedPayout_Reversal(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	ctx := context.Background()
@@ -162,7 +360,7 @@ func TestWebhookProcessor_FailedPayout_Reversal(t *testing.T) {
 
 	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "failed", "provider_declined")
 
-	err = processor.processEvent(ctx, *webhookEvent)
+	err = processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent))
 	require.NoError(t, err)
 
 	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
@@ -201,6 +399,146 @@ func TestWebhookProcessor_FailedPayout_Reversal(t *testing.T) {
 	assert.Equal(t, domain.WebhookEventStatusDispatched, getWebhookStatus(t, db, webhookEvent.ID))
 }
 
+func TestWebhookProcessor_FailedCrossCurrencyPayout_ReversesFee(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, webhookRepo := setupWebhookTest(t, db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_fxrev")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+	fxPoolEURBefore := testutil.GetAccountBalance(t, db, testutil.FXPoolEURID)
+	revenueBefore := testutil.GetAccountBalance(t, db, testutil.RevenueEURID)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyEUR,
+		Amount:         10000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(46), p.Fee.Amount)
+	assert.Equal(t, revenueBefore+46, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
+	assert.Equal(t, fxPoolEURBefore-9200, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+
+	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "failed", "provider_declined")
+	require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent)))
+
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, revenueBefore, testutil.GetAccountBalance(t, db, testutil.RevenueEURID))
+	assert.Equal(t, fxPoolEURBefore, testutil.GetAccountBalance(t, db, testutil.FXPoolEURID))
+
+	ledgerEntries, err := repository.NewLedgerRepository(db).GetByPaymentID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Len(t, ledgerEntries, 10)
+
+	feeReversal := findLedgerEntry(ledgerEntries, testutil.RevenueEURID, domain.EntryTypeDebit)
+	require.NotNil(t, feeReversal)
+	assert.Equal(t, int64(46), feeReversal.Amount)
+	assert.Equal(t, domain.EntryCategoryReversal, feeReversal.Category)
+	feeCredit := findLedgerEntry(ledgerEntries, testutil.RevenueEURID, domain.EntryTypeCredit)
+	require.NotNil(t, feeCredit)
+	assert.Equal(t, domain.EntryCategoryFee, feeCredit.Category)
+
+	// The trial balance for the day holds both sides of every entry.
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	totals, err := repository.NewLedgerRepository(db).TotalsByCategory(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	for _, cur := range []domain.Currency{domain.CurrencyUSD, domain.CurrencyEUR} {
+		var debits, credits int64
+		for _, tot := range totals {
+			if tot.Currency == cur {
+				debits += tot.Debits
+				credits += tot.Credits
+			}
+		}
+		assert.Equal(t, debits, credits, cur)
+	}
+}
+
+type recordingAlerter struct {
+	alerts []Alert
+}
+
+func (a *recordingAlerter) Alert(_ context.Context, alert Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func TestWebhookProcessor_FailedCrossCurrencyPayout_PoolDrained(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	paymentSvc, processor, webhookRepo := setupWebhookTest(t, db)
+	alerter := &recordingAlerter{}
+	processor.alerter = alerter
+	payments := repository.NewPaymentRepository(db)
+
+	sender := testutil.SeedTestUser(t, db, "sender@test.com", "Sender", "sender_drained")
+	senderAcct := testutil.SeedTestAccount(t, db, sender.ID, "USD", 10000)
+
+	p, err := paymentSvc.CreateExternalPayout(ctx, payment.ExternalPayoutRequest{
+		SenderUserID:   sender.ID,
+		SourceCurrency: domain.CurrencyUSD,
+		DestCurrency:   domain.CurrencyEUR,
+		Amount:         10000,
+		DestIBAN:       "DE89370400440532013000",
+		DestBankName:   "Deutsche Bank",
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	// Drain the USD pool below what the reversal has to take back out of it.
+	poolUSD := testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID)
+	_, err = db.Exec(context.Background(), `UPDATE accounts SET balance = 5000 WHERE id = $1`, testutil.FXPoolUSDID)
+	require.NoError(t, err)
+
+	webhookEvent := insertWebhookEvent(t, webhookRepo, p.ID, "failed", "provider_declined")
+	require.NoError(t, processor.processEvent(ctx, claimWebhookEvent(t, webhookRepo, processor, webhookEvent)))
+	assert.Equal(t, domain.WebhookEventStatusDispatched, getWebhookStatus(t, db, webhookEvent.ID))
+
+	parked, err := payments.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPendingReversal, parked.Status)
+	assert.Equal(t, int64(0), testutil.GetAccountBalance(t, db, senderAcct.ID), "sender stays debited")
+	assert.Equal(t, int64(5000), testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
+
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "reversal_short_of_funds", alerter.alerts[0].Kind)
+
+	// The same event applied again, from a read taken before it was parked,
+	// is dropped by the processed-event ledger rather than parked twice.
+	err = processor.applyOutcome(ctx, p, "failed", "", "provider_declined", "", "system", webhookEvent.ID)
+	require.ErrorIs(t, err, domain.ErrAlreadyProcessed)
+	assert.Len(t, alerter.alerts, 1)
+
+	ledgerEntries, err := repository.NewLedgerRepository(db).GetByPaymentID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Len(t, ledgerEntries, 5, "no reversal entries yet")
+
+	// Still short: the retry leaves it parked and doesn't alert again.
+	processor.retryPendingReversals(ctx)
+	parked, err = payments.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPendingReversal, parked.Status)
+	assert.Len(t, alerter.alerts, 1)
+
+	_, err = db.Exec(context.Background(), `UPDATE accounts SET balance = $1 WHERE id = $2`, poolUSD, testutil.FXPoolUSDID)
+	require.NoError(t, err)
+
+	processor.retryPendingReversals(ctx)
+
+	reversed, err := payments.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, reversed.Status)
+	assert.Equal(t, int64(10000), testutil.GetAccountBalance(t, db, senderAcct.ID))
+	assert.Equal(t, poolUSD-10000, testutil.GetAccountBalance(t, db, testutil.FXPoolUSDID))
+
+	ledgerEntries, err = repository.NewLedgerRepository(db).GetByPaymentID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Len(t, ledgerEntries, 10)
+}
+
 func findLedgerEntry(entries []domain.LedgerEntry, accountID uuid.UUID, entryType domain.EntryType) *domain.LedgerEntry {
 	for _, e := range entries {
 		if e.AccountID == accountID && e.EntryType == entryType {
@@ -209,3 +547,371 @@ func findLedgerEntry(entries []domain.LedgerEntry, accountID uuid.UUID, entryTyp
 	}
 	return nil
 }
+
+func TestWebhookEventRepository_ClaimByPriority(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	repo := repository.NewWebhookEventRepository(db, 0, time.Minute, time.Minute)
+
+	insert := func(priority domain.WebhookPriority, age time.Duration) uuid.UUID {
+		e := &domain.WebhookEvent{
+			ID:             uuid.New(),
+			IdempotencyKey: uuid.NewString(),
+			EventType:      domain.WebhookEventTypePaymentCompleted,
+			Payload:        json.RawMessage(`{}`),
+			Status:         domain.WebhookEventStatusPending,
+			Priority:       priority,
+			CreatedAt:      time.Now().UTC().Add(-age),
+		}
+		require.NoError(t, repo.Create(ctx, e))
+		return e.ID
+	}
+
+	lowNew := insert(domain.WebhookPriorityLow, 0)
+	high := insert(domain.WebhookPriorityHigh, 0)
+	normal := insert(domain.WebhookPriorityNormal, 10*time.Second)
+	// Waited three aging intervals, so it now competes with high events on age.
+	lowOld := insert(domain.WebhookPriorityLow, 3*time.Minute)
+
+	events, err := repo.Claim(ctx, "a", 10)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+		assert.Equal(t, domain.WebhookEventStatusProcessing, e.Status)
+		require.NotNil(t, e.ClaimedBy)
+		assert.Equal(t, "a", *e.ClaimedBy)
+	}
+	assert.Equal(t, []uuid.UUID{lowOld, high, normal, lowNew}, ids)
+	assert.Equal(t, domain.WebhookPriorityLow, events[0].Priority)
+
+	again, err := repo.Claim(ctx, "b", 10)
+	require.NoError(t, err)
+	assert.Empty(t, again, "leased events are not claimed twice")
+}
+
+func TestWebhookEventRepository_ClaimLeases(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	repo := repository.NewWebhookEventRepository(db, 0, 0, time.Minute)
+
+	paymentID := uuid.New()
+	insert := func(age time.Duration) uuid.UUID {
+		e := &domain.WebhookEvent{
+			ID:             uuid.New(),
+			IdempotencyKey: uuid.NewString(),
+			EventType:      domain.WebhookEventTypePaymentCompleted,
+			Payload:        json.RawMessage(`{}`),
+			PaymentID:      &paymentID,
+			Status:         domain.WebhookEventStatusPending,
+			Priority:       domain.WebhookPriorityNormal,
+			CreatedAt:      time.Now().UTC().Add(-age),
+		}
+		require.NoError(t, repo.Create(ctx, e))
+		return e.ID
+	}
+
+	first := insert(time.Second)
+	claimed, err := repo.Claim(ctx, "a", 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// A later event for the same payment waits while the first is leased.
+	second := insert(0)
+	claimed, err = repo.Claim(ctx, "b", 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// "a" dies: once its lease runs out, "b" takes both over, in order.
+	_, err = db.Exec(context.Background(), `UPDATE webhook_events SET lease_expires_at = now() - interval '1 second' WHERE id = $1`, first)
+	require.NoError(t, err)
+	claimed, err = repo.Claim(ctx, "b", 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, first, claimed[0].ID)
+	assert.Equal(t, second, claimed[1].ID)
+
+	// A late finish from "a" is refused; the event is "b"'s now.
+	assert.ErrorIs(t, repo.Finish(ctx, first, "a", domain.WebhookEventStatusDispatched, ""), domain.ErrNotFound)
+	assert.ErrorIs(t, repo.Renew(ctx, first, "a"), domain.ErrNotFound)
+	require.NoError(t, repo.Renew(ctx, first, "b"))
+	require.NoError(t, repo.Finish(ctx, first, "b", domain.WebhookEventStatusDispatched, ""))
+	assert.Equal(t, domain.WebhookEventStatusDispatched, getWebhookStatus(t, db, first))
+
+	// Handing an event back puts it in the queue again with the attempt counted.
+	require.NoError(t, repo.Finish(ctx, second, "b", domain.WebhookEventStatusPending, "provider timeout"))
+	claimed, err = repo.Claim(ctx, "a", 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, second, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	require.NotNil(t, claimed[0].LastError)
+	assert.Equal(t, "provider timeout", *claimed[0].LastError)
+
+	// Releasing only gives up the caller's own claim, and costs no attempt.
+	require.NoError(t, repo.Release(ctx, []uuid.UUID{second}, "b"))
+	assert.Equal(t, domain.WebhookEventStatusProcessing, getWebhookStatus(t, db, second))
+	require.NoError(t, repo.Release(ctx, []uuid.UUID{second}, "a"))
+	claimed, err = repo.Claim(ctx, "b", 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 1, claimed[0].Attempts)
+}
+
+func TestWebhookEventRepository_ListAndRequeue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	repo := repository.NewWebhookEventRepository(db, 0, 0, time.Minute)
+
+	paymentID := uuid.New()
+	var ids []uuid.UUID
+	for i := range 3 {
+		e := &domain.WebhookEvent{
+			ID:             uuid.New(),
+			IdempotencyKey: uuid.NewString(),
+			EventType:      domain.WebhookEventTypePaymentFailed,
+			Payload:        json.RawMessage(`{"status":"settled"}`),
+			PaymentID:      &paymentID,
+			Status:         domain.WebhookEventStatusPending,
+			CreatedAt:      time.Now().UTC().Add(time.Duration(i) * time.Second),
+		}
+		require.NoError(t, repo.Create(ctx, e))
+		ids = append(ids, e.ID)
+	}
+	claimed, err := repo.Claim(ctx, "a", 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.NoError(t, repo.Finish(ctx, claimed[0].ID, "a", domain.WebhookEventStatusFailed, `unknown status "settled"`))
+
+	failed, err := repo.List(ctx, domain.WebhookEventFilter{Statuses: []domain.WebhookEventStatus{domain.WebhookEventStatusFailed}}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, ids[0], failed[0].ID)
+	require.NotNil(t, failed[0].LastError)
+	assert.Equal(t, `unknown status "settled"`, *failed[0].LastError)
+
+	all, err := repo.List(ctx, domain.WebhookEventFilter{PaymentID: &paymentID}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, ids[2], all[0].ID, "newest first")
+
+	requeued, err := repo.Requeue(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookEventStatusPending, requeued.Status)
+	assert.Equal(t, 1, requeued.Attempts)
+
+	_, err = repo.Requeue(ctx, ids[0])
+	assert.ErrorIs(t, err, domain.ErrWebhookEventNotRetriable, "a pending event is already queued")
+	_, err = repo.Requeue(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// stubWebhookQueue hands out pending events in batches, leasing each to one
+// claim at a time and never two of a payment's events to different claims,
+// and records what each was marked and which were released. Renewing an
+// event in renewErr fails with its error.
+type stubWebhookQueue struct {
+	mu       sync.Mutex
+	pending  []domain.WebhookEvent
+	claimed  map[uuid.UUID]bool
+	marked   map[uuid.UUID]domain.WebhookEventStatus
+	released []uuid.UUID
+	renewErr map[uuid.UUID]error
+}
+
+func newStubWebhookQueue() *stubWebhookQueue {
+	return &stubWebhookQueue{
+		claimed:  make(map[uuid.UUID]bool),
+		marked:   make(map[uuid.UUID]domain.WebhookEventStatus),
+		renewErr: make(map[uuid.UUID]error),
+	}
+}
+
+func (q *stubWebhookQueue) Claim(_ context.Context, _ string, limit int) ([]domain.WebhookEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	leased := make(map[string]bool)
+	for _, e := range q.pending {
+		if q.claimed[e.ID] {
+			leased[webhookPaymentKey(e)] = true
+		}
+	}
+	var out []domain.WebhookEvent
+	for _, e := range q.pending {
+		_, done := q.marked[e.ID]
+		if done || q.claimed[e.ID] || leased[webhookPaymentKey(e)] || len(out) >= limit {
+			continue
+		}
+		out = append(out, e)
+	}
+	for _, e := range out {
+		q.claimed[e.ID] = true
+	}
+	return out, nil
+}
+
+func (q *stubWebhookQueue) Renew(_ context.Context, id uuid.UUID, _ string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.claimed[id] {
+		return domain.ErrNotFound
+	}
+	return q.renewErr[id]
+}
+
+func (q *stubWebhookQueue) Release(_ context.Context, ids []uuid.UUID, _ string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, id := range ids {
+		if q.claimed[id] {
+			delete(q.claimed, id)
+			q.released = append(q.released, id)
+		}
+	}
+	return nil
+}
+
+func (q *stubWebhookQueue) Finish(_ context.Context, id uuid.UUID, _ string, status domain.WebhookEventStatus, _ string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.claimed[id] {
+		return domain.ErrNotFound
+	}
+	delete(q.claimed, id)
+	if status != domain.WebhookEventStatusPending {
+		q.marked[id] = status
+	}
+	return nil
+}
+
+func (q *stubWebhookQueue) markedCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.marked)
+}
+
+type stubNoReversals struct{ wpPaymentRepo }
+
+func (stubNoReversals) ListPendingReversal(context.Context, int) ([]domain.Payment, error) {
+	return nil, nil
+}
+
+func TestWebhookProcessor_NotificationDrainsTheQueue(t *testing.T) {
+	// More than two batches of events the processor fails without touching
+	// a payment, so only the queue is involved.
+	queue := newStubWebhookQueue()
+	for range 2*webhookBatchSize + 5 {
+		queue.pending = append(queue.pending, domain.WebhookEvent{ID: uuid.New(), Payload: json.RawMessage(`not json`)})
+	}
+
+	wake := make(chan struct{}, 1)
+	processor := NewWebhookProcessor(queue, stubNoReversals{}, nil, nil, nil, nil, nil, nil,
+		slog.Default(), nil, nil, nil, time.Hour, wake, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		processor.Start(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	wake <- struct{}{}
+	require.Eventually(t, func() bool { return queue.markedCount() == len(queue.pending) },
+		2*time.Second, 10*time.Millisecond, "one notification should drain every batch without waiting for the sweep")
+	for _, e := range queue.pending {
+		assert.Equal(t, domain.WebhookEventStatusFailed, queue.marked[e.ID])
+	}
+}
+
+// stubSettledPayments reports every payment completed, so the processor
+// just marks each event dispatched, and records how many events per payment
+// were being looked at at once.
+type stubSettledPayments struct {
+	stubNoReversals
+	mu      sync.Mutex
+	active  map[uuid.UUID]int
+	overlap bool
+	running int
+	peak    int
+}
+
+func (s *stubSettledPayments) GetByID(_ context.Context, id uuid.UUID) (*domain.Payment, error) {
+	s.mu.Lock()
+	s.active[id]++
+	s.running++
+	s.overlap = s.overlap || s.active[id] > 1
+	s.peak = max(s.peak, s.running)
+	s.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	s.mu.Lock()
+	s.active[id]--
+	s.running--
+	s.mu.Unlock()
+	return &domain.Payment{ID: id, Status: domain.PaymentStatusCompleted}, nil
+}
+
+func TestWebhookProcessor_ConcurrentButOrderedPerPayment(t *testing.T) {
+	queue := newStubWebhookQueue()
+	payments := &stubSettledPayments{active: make(map[uuid.UUID]int)}
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	for range 3 {
+		for _, id := range ids {
+			payload, err := json.Marshal(webhookCallbackPayload{PaymentID: id.String(), Status: "completed"})
+			require.NoError(t, err)
+			queue.pending = append(queue.pending, domain.WebhookEvent{ID: uuid.New(), Payload: payload, PaymentID: &id})
+		}
+	}
+
+	wake := make(chan struct{}, 1)
+	processor := NewWebhookProcessor(queue, payments, nil, nil, nil, nil, nil, nil,
+		slog.Default(), nil, nil, nil, time.Hour, wake, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		processor.Start(ctx)
+		close(done)
+	}()
+
+	wake <- struct{}{}
+	require.Eventually(t, func() bool { return queue.markedCount() == len(queue.pending) },
+		2*time.Second, 10*time.Millisecond, "events skipped behind their payment are picked up without waiting for the sweep")
+	cancel()
+	<-done
+
+	assert.False(t, payments.overlap, "a payment's events must not be processed at the same time")
+	assert.Greater(t, payments.peak, 1, "different payments should be processed at the same time")
+}
+
+func TestWebhookProcessor_FailureStopsThePaymentsEvents(t *testing.T) {
+	queue := newStubWebhookQueue()
+	payments := &stubSettledPayments{active: make(map[uuid.UUID]int)}
+	failing, other := uuid.New(), uuid.New()
+	var events []domain.WebhookEvent
+	for _, id := range []uuid.UUID{failing, failing, failing, other} {
+		payload, err := json.Marshal(webhookCallbackPayload{PaymentID: id.String(), Status: "completed"})
+		require.NoError(t, err)
+		events = append(events, domain.WebhookEvent{ID: uuid.New(), Payload: payload, PaymentID: &id})
+	}
+	queue.pending = events
+	queue.renewErr[events[0].ID] = errors.New("connection reset")
+
+	processor := NewWebhookProcessor(queue, payments, nil, nil, nil, nil, nil, nil,
+		slog.Default(), nil, nil, nil, time.Hour, nil, 4)
+
+	ctx := context.Background()
+	assert.Equal(t, len(events), processor.poll(ctx))
+	processor.wg.Wait()
+
+	assert.Equal(t, []uuid.UUID{events[1].ID, events[2].ID}, queue.released,
+		"the payment's later events go back to the queue")
+	assert.True(t, queue.claimed[events[0].ID], "the failed event keeps its lease until it expires")
+	assert.Equal(t, map[uuid.UUID]domain.WebhookEventStatus{events[3].ID: domain.WebhookEventStatusDispatched}, queue.marked,
+		"only the other payment's event is processed")
+}
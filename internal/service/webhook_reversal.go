@@ -2,103 +2,110 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/service/payment"
 )
 
-func (p *WebhookProcessor) writeSameCurrencyReversal(
-	ctx context.Context,
-	tx *sql.Tx,
+func sameCurrencyReversalEntries(
 	pmt *domain.Payment,
 	locked map[uuid.UUID]*domain.Account,
 	outgoingID uuid.UUID,
-	now time.Time,
-) error {
+) []reversalEntry {
 	sender := locked[pmt.SourceAccountID]
 	outgoing := locked[outgoingID]
 
-	entries := []reversalEntry{
-		{outgoing, domain.EntryTypeDebit, pmt.DestAmount, pmt.DestCurrency},
-		{sender, domain.EntryTypeCredit, pmt.SourceAmount, pmt.SourceCurrency},
+	return []reversalEntry{
+		{outgoing, domain.EntryTypeDebit, pmt.Dest},
+		{sender, domain.EntryTypeCredit, pmt.Source},
 	}
-
-	return p.writeReversalEntries(ctx, tx, pmt.ID, entries, now)
 }
 
-func (p *WebhookProcessor) writeCrossCurrencyReversal(
-	ctx context.Context,
-	tx *sql.Tx,
+func crossCurrencyReversalEntries(
 	pmt *domain.Payment,
 	locked map[uuid.UUID]*domain.Account,
-	outgoingID, fxPoolSourceID, fxPoolDestID uuid.UUID,
-	now time.Time,
-) error {
+	outgoingID, fxPoolSourceID, fxPoolDestID, revenueID uuid.UUID,
+) ([]reversalEntry, error) {
 	sender := locked[pmt.SourceAccountID]
 	outgoing := locked[outgoingID]
 	fxPoolSource := locked[fxPoolSourceID]
 	fxPoolDest := locked[fxPoolDestID]
 
-	// Reverse the original 4 entries:
-	// Original: debit sender, credit FX source, debit FX dest, credit outgoing
-	// Reversal: debit outgoing, credit FX dest, debit FX source, credit sender
+	// Reverse the original entries:
+	// Original: debit sender, credit FX source, debit FX dest (dest + fee), credit outgoing, credit revenue (fee)
+	// Reversal: debit outgoing, debit revenue, credit FX dest, debit FX source, credit sender
+	// Payments created before fees were booked have no revenue entry; revenueID is uuid.Nil for those.
+	fee := domain.NewMoney(0, pmt.Fee.Currency)
+	if revenueID != uuid.Nil {
+		fee = pmt.Fee
+	}
+	poolCredit, err := pmt.Dest.Add(fee)
+	if err != nil {
+		return nil, fmt.Errorf("crossCurrencyReversalEntries: %w", err)
+	}
+
 	entries := []reversalEntry{
-		{outgoing, domain.EntryTypeDebit, pmt.DestAmount, pmt.DestCurrency},
-		{fxPoolDest, domain.EntryTypeCredit, pmt.DestAmount, pmt.DestCurrency},
-		{fxPoolSource, domain.EntryTypeDebit, pmt.SourceAmount, pmt.SourceCurrency},
-		{sender, domain.EntryTypeCredit, pmt.SourceAmount, pmt.SourceCurrency},
+		{outgoing, domain.EntryTypeDebit, pmt.Dest},
 	}
+	if fee.IsPositive() {
+		entries = append(entries, reversalEntry{locked[revenueID], domain.EntryTypeDebit, fee})
+	}
+	entries = append(entries,
+		reversalEntry{fxPoolDest, domain.EntryTypeCredit, poolCredit},
+		reversalEntry{fxPoolSource, domain.EntryTypeDebit, pmt.Source},
+		reversalEntry{sender, domain.EntryTypeCredit, pmt.Source},
+	)
 
-	return p.writeReversalEntries(ctx, tx, pmt.ID, entries, now)
+	return entries, nil
+}
+
+// reversalShortfall returns the first debit leg whose account can't cover
+// it. Writing that leg would take a system account negative, which
+// chk_accounts_balance rejects anyway; checking first lets the payout park
+// in pending_reversal instead of failing the whole transaction on every
+// retry.
+func reversalShortfall(entries []reversalEntry) *reversalEntry {
+	for i, e := range entries {
+		if e.entryType == domain.EntryTypeDebit && e.account.Balance < e.amount.Amount {
+			return &entries[i]
+		}
+	}
+	return nil
 }
 
 type reversalEntry struct {
 	account   *domain.Account
 	entryType domain.EntryType
-	amount    int64
-	currency  domain.Currency
+	amount    domain.Money
 }
 
 func (p *WebhookProcessor) writeReversalEntries(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx pgx.Tx,
 	paymentID uuid.UUID,
 	entries []reversalEntry,
 	now time.Time,
 ) error {
-	for _, e := range entries {
-		var newBalance int64
-		if e.entryType == domain.EntryTypeDebit {
-			newBalance = e.account.Balance - e.amount
-		} else {
-			newBalance = e.account.Balance + e.amount
-		}
-
-		entry := &domain.LedgerEntry{
-			ID:            uuid.New(),
-			PaymentID:     paymentID,
-			AccountID:     e.account.ID,
-			EntryType:     e.entryType,
-			Amount:        e.amount,
-			Currency:      e.currency,
-			BalanceBefore: e.account.Balance,
-			BalanceAfter:  newBalance,
-			CreatedAt:     now,
-		}
-		if err := p.ledger.Create(ctx, tx, entry); err != nil {
-			return fmt.Errorf("writeReversalEntries: %s %s: %w", e.entryType, e.account.ID, err)
+	batch := make([]*domain.LedgerEntry, len(entries))
+	for i, e := range entries {
+		entry, err := domain.NewLedgerEntry(paymentID, e.account, e.entryType, domain.EntryCategoryReversal, e.amount, now)
+		if err != nil {
+			return fmt.Errorf("writeReversalEntries: %w", err)
 		}
-
-		if err := p.accounts.UpdateBalance(ctx, tx, e.account.ID, newBalance, e.account.Version+1); err != nil {
+		batch[i] = entry
+		if err := p.accounts.UpdateBalance(ctx, tx, e.account.ID, entry.BalanceAfter, e.account.Version+1); err != nil {
 			return fmt.Errorf("writeReversalEntries: update %s: %w", e.account.ID, err)
 		}
 	}
+	if err := p.ledger.CreateBatch(ctx, tx, batch); err != nil {
+		return fmt.Errorf("writeReversalEntries: %w", err)
+	}
 
 	return nil
 }
@@ -111,16 +118,42 @@ func (p *WebhookProcessor) getSystemAccount(ctx context.Context, accountType dom
 	return acct, nil
 }
 
+// bookedFeeAccount returns the revenue account credited with the payment's
+// fee, or uuid.Nil if no fee entry was written for it.
+func (p *WebhookProcessor) bookedFeeAccount(ctx context.Context, pmt *domain.Payment) (uuid.UUID, error) {
+	if pmt.Fee.Amount <= 0 {
+		return uuid.Nil, nil
+	}
+	revenue, err := p.getSystemAccount(ctx, domain.AccountTypeRevenue, pmt.Dest.Currency)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bookedFeeAccount: %w", err)
+	}
+	entries, err := p.ledger.GetByPaymentID(ctx, pmt.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bookedFeeAccount: %w", err)
+	}
+	for _, e := range entries {
+		if e.AccountID == revenue.ID && e.EntryType == domain.EntryTypeCredit {
+			return revenue.ID, nil
+		}
+	}
+	return uuid.Nil, nil
+}
+
 func isTerminalStatus(s domain.PaymentStatus) bool {
 	switch s {
 	case domain.PaymentStatusCompleted, domain.PaymentStatusFailed, domain.PaymentStatusReversed:
 		return true
+	// The provider has already failed it; only the reversal is outstanding,
+	// and retryPendingReversals owns that.
+	case domain.PaymentStatusPendingReversal:
+		return true
 	default:
 		return false
 	}
 }
 
-func lockAccountsInOrder(ctx context.Context, tx *sql.Tx, accounts wpAccountRepo, ids ...uuid.UUID) (map[uuid.UUID]*domain.Account, error) {
+func lockAccountsInOrder(ctx context.Context, tx pgx.Tx, accounts wpAccountRepo, ids ...uuid.UUID) (map[uuid.UUID]*domain.Account, error) {
 	sorted := make([]uuid.UUID, len(ids))
 	copy(sorted, ids)
 	sort.Slice(sorted, func(i, j int) bool {
@@ -0,0 +1,146 @@
+package templates
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+)
+
+// Template names. Notification templates share their name with the
+// notification kind.
+const (
+	PaymentRequestReceived  = "payment_request.received"
+	PaymentRequestAccepted  = "payment_request.accepted"
+	PaymentRequestDeclined  = "payment_request.declined"
+	PaymentRequestCancelled = "payment_request.cancelled"
+	PaymentRequestExpired   = "payment_request.expired"
+	APIKeyCreated           = "api_key_created"
+	PaymentsDigest          = "payments_digest"
+	PasswordReset           = "password_reset"
+	PasswordChanged         = "password_changed"
+	TransferReceived        = "transfer.received"
+	PayoutCompleted         = "payout.completed"
+	PayoutFailed            = "payout.failed"
+	NewDeviceLogin          = "login.new_device"
+)
+
+// PaymentRequestData is rendered by the payment_request.* templates.
+// Requester is only set for payment_request.received.
+type PaymentRequestData struct {
+	ID        uuid.UUID
+	Requester string
+	Amount    int64
+	Currency  domain.Currency
+	Status    domain.PaymentRequestStatus
+}
+
+type APIKeyCreatedData struct {
+	Name      string
+	MaskedKey string
+	CreatedAt time.Time
+	IP        string
+	UserAgent string
+}
+
+// DigestData covers Start up to and including Last.
+type DigestData struct {
+	Period domain.DigestPeriod
+	Start  time.Time
+	Last   time.Time
+	Totals []domain.DigestTotals
+}
+
+type PasswordResetData struct {
+	IP   string
+	TTL  time.Duration
+	Link string
+}
+
+type PasswordChangedData struct {
+	At time.Time
+}
+
+// PaymentNotificationData is rendered by the transfer.* and payout.*
+// templates. Sender is only set for transfer.received, and Reason and
+// Returned only for payout.failed.
+type PaymentNotificationData struct {
+	PaymentID uuid.UUID
+	Amount    int64
+	Currency  domain.Currency
+	Sender    string
+	Reason    string
+	// Returned reports whether the money is already back in the account.
+	Returned bool
+}
+
+// NewDeviceLoginData describes a sign-in from a device the user hasn't used
+// before.
+type NewDeviceLoginData struct {
+	IP        string
+	UserAgent string
+	At        time.Time
+}
+
+// samples is the data the admin preview renders each template with.
+var samples = map[string]any{
+	PaymentRequestReceived: PaymentRequestData{
+		ID: uuid.MustParse("3f1c2b7a-5d4e-4f60-8a9b-0c1d2e3f4a5b"), Requester: "alice",
+		Amount: 2500, Currency: domain.CurrencyUSD, Status: domain.PaymentRequestStatusPending,
+	},
+	PaymentRequestAccepted: PaymentRequestData{
+		ID:     uuid.MustParse("3f1c2b7a-5d4e-4f60-8a9b-0c1d2e3f4a5b"),
+		Amount: 2500, Currency: domain.CurrencyUSD, Status: domain.PaymentRequestStatusAccepted,
+	},
+	PaymentRequestDeclined: PaymentRequestData{
+		ID:     uuid.MustParse("3f1c2b7a-5d4e-4f60-8a9b-0c1d2e3f4a5b"),
+		Amount: 2500, Currency: domain.CurrencyUSD, Status: domain.PaymentRequestStatusDeclined,
+	},
+	PaymentRequestCancelled: PaymentRequestData{
+		ID:     uuid.MustParse("3f1c2b7a-5d4e-4f60-8a9b-0c1d2e3f4a5b"),
+		Amount: 2500, Currency: domain.CurrencyUSD, Status: domain.PaymentRequestStatusCancelled,
+	},
+	PaymentRequestExpired: PaymentRequestData{
+		ID:     uuid.MustParse("3f1c2b7a-5d4e-4f60-8a9b-0c1d2e3f4a5b"),
+		Amount: 2500, Currency: domain.CurrencyUSD, Status: domain.PaymentRequestStatusExpired,
+	},
+	APIKeyCreated: APIKeyCreatedData{
+		Name: "ci", MaskedKey: "grey_1a2b3c4d5e6f…WxYz",
+		CreatedAt: time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC),
+		IP:        "203.0.113.7", UserAgent: "curl/8.0",
+	},
+	PaymentsDigest: DigestData{
+		Period: domain.DigestPeriodWeekly,
+		Start:  time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+		Last:   time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+		Totals: []domain.DigestTotals{
+			{Currency: domain.CurrencyUSD, SentCount: 2, Sent: 5000, ReceivedCount: 1, Received: 1200, Fees: 50, FXCount: 1, FXSent: 2000},
+			{Currency: domain.CurrencyEUR, ReceivedCount: 1, Received: 900},
+		},
+	},
+	PasswordReset: PasswordResetData{
+		IP: "203.0.113.7", TTL: 30 * time.Minute,
+		Link: "http://localhost:3000/reset-password?token=sample-token",
+	},
+	PasswordChanged: PasswordChangedData{
+		At: time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC),
+	},
+	TransferReceived: PaymentNotificationData{
+		PaymentID: uuid.MustParse("7b2e4c1d-9f3a-4e6b-8c5d-1a2b3c4d5e6f"),
+		Amount:    2500, Currency: domain.CurrencyUSD, Sender: "alice",
+	},
+	PayoutCompleted: PaymentNotificationData{
+		PaymentID: uuid.MustParse("7b2e4c1d-9f3a-4e6b-8c5d-1a2b3c4d5e6f"),
+		Amount:    10000, Currency: domain.CurrencyEUR,
+	},
+	PayoutFailed: PaymentNotificationData{
+		PaymentID: uuid.MustParse("7b2e4c1d-9f3a-4e6b-8c5d-1a2b3c4d5e6f"),
+		Amount:    10000, Currency: domain.CurrencyEUR,
+		Reason: "beneficiary account closed", Returned: true,
+	},
+	NewDeviceLogin: NewDeviceLoginData{
+		IP: "203.0.113.7", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X)",
+		At: time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC),
+	},
+}
@@ -0,0 +1,179 @@
+// Package templates renders the text of user-facing notifications and
+// emails. Each message kind has one Go text/template file per locale under
+// <locale>/<name>.tmpl, defining a "subject" and a "body" template. Files
+// are embedded in the binary; a template's version is a hash of its file,
+// so a sent message can be traced to the exact text that produced it.
+package templates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultLocale is used when a template has no file for the requested
+// locale.
+const DefaultLocale = "en"
+
+var ErrUnknownTemplate = errors.New("unknown template")
+
+//go:embed */*.tmpl
+var files embed.FS
+
+// Ref identifies the template version a message was rendered from.
+type Ref struct {
+	Name    string `json:"name"`
+	Locale  string `json:"locale"`
+	Version string `json:"version"`
+}
+
+func (r Ref) String() string {
+	if r.Name == "" {
+		return ""
+	}
+	return r.Name + "@" + r.Locale + ":" + r.Version
+}
+
+// Message is a rendered template.
+type Message struct {
+	Ref     Ref
+	Subject string
+	Body    string
+}
+
+type entry struct {
+	ref  Ref
+	tmpl *template.Template
+}
+
+// Set holds parsed templates by name and locale.
+type Set struct {
+	entries map[string]map[string]*entry
+}
+
+var funcs = template.FuncMap{
+	"date":    func(t time.Time) string { return t.Format("2 Jan 2006") },
+	"rfc1123": func(t time.Time) string { return t.Format(time.RFC1123) },
+}
+
+var defaultSet = mustLoad()
+
+func mustLoad() *Set {
+	s, err := Load(files)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Default returns the templates embedded in the binary.
+func Default() *Set { return defaultSet }
+
+// Render renders an embedded template.
+func Render(name, locale string, data any) (Message, error) {
+	return defaultSet.Render(name, locale, data)
+}
+
+// Load parses every <locale>/<name>.tmpl file in fsys.
+func Load(fsys fs.FS) (*Set, error) {
+	s := &Set{entries: make(map[string]map[string]*entry)}
+	paths, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+	for _, p := range paths {
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("Load: %w", err)
+		}
+		tmpl, err := template.New(p).Funcs(funcs).Parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("Load: %w", err)
+		}
+		for _, part := range []string{"subject", "body"} {
+			if tmpl.Lookup(part) == nil {
+				return nil, fmt.Errorf("Load: %s does not define %q", p, part)
+			}
+		}
+
+		locale, file := path.Split(p)
+		locale = strings.TrimSuffix(locale, "/")
+		name := strings.TrimSuffix(file, ".tmpl")
+		sum := sha256.Sum256(src)
+		if s.entries[name] == nil {
+			s.entries[name] = make(map[string]*entry)
+		}
+		s.entries[name][locale] = &entry{
+			ref:  Ref{Name: name, Locale: locale, Version: hex.EncodeToString(sum[:6])},
+			tmpl: tmpl,
+		}
+	}
+	return s, nil
+}
+
+// Render renders the named template in locale, falling back to
+// DefaultLocale.
+func (s *Set) Render(name, locale string, data any) (Message, error) {
+	e, err := s.lookup(name, locale)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var subject, body bytes.Buffer
+	if err := e.tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("Render %s: %w", e.ref, err)
+	}
+	if err := e.tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("Render %s: %w", e.ref, err)
+	}
+	return Message{Ref: e.ref, Subject: strings.TrimSpace(subject.String()), Body: body.String()}, nil
+}
+
+// Preview renders the named template with its sample data.
+func (s *Set) Preview(name, locale string) (Message, error) {
+	data, ok := samples[name]
+	if !ok {
+		return Message{}, fmt.Errorf("Preview %q: no sample data: %w", name, ErrUnknownTemplate)
+	}
+	return s.Render(name, locale, data)
+}
+
+// List returns every template, ordered by name and locale.
+func (s *Set) List() []Ref {
+	var refs []Ref
+	for _, locales := range s.entries {
+		for _, e := range locales {
+			refs = append(refs, e.ref)
+		}
+	}
+	slices.SortFunc(refs, func(a, b Ref) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Locale, b.Locale)
+	})
+	return refs
+}
+
+func (s *Set) lookup(name, locale string) (*entry, error) {
+	locales, ok := s.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q: %w", name, ErrUnknownTemplate)
+	}
+	if e, ok := locales[locale]; ok {
+		return e, nil
+	}
+	if e, ok := locales[DefaultLocale]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("template %q has no %s or %s locale: %w", name, locale, DefaultLocale, ErrUnknownTemplate)
+}
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault_EveryTemplateRendersItsSample(t *testing.T) {
+	refs := Default().List()
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		msg, err := Default().Preview(ref.Name, ref.Locale)
+		require.NoError(t, err, ref.String())
+		assert.Equal(t, ref, msg.Ref)
+		assert.NotEmpty(t, msg.Subject, ref.String())
+		assert.NotEmpty(t, msg.Body, ref.String())
+		assert.NotContains(t, msg.Subject+msg.Body, "<no value>", ref.String())
+	}
+	for name := range samples {
+		_, err := Default().lookup(name, DefaultLocale)
+		assert.NoError(t, err, "sample %s has a template", name)
+	}
+}
+
+func TestRender_Digest(t *testing.T) {
+	msg, err := Render(PaymentsDigest, DefaultLocale, samples[PaymentsDigest])
+	require.NoError(t, err)
+	assert.Equal(t, "Your weekly payments summary, 26 Feb 2024 - 3 Mar 2024", msg.Subject)
+	assert.Equal(t, "USD: sent 5000 in 2 payments, received 1200 in 1 payments, fees 50, 2000 converted in 1 FX payments\n"+
+		"EUR: sent 0 in 0 payments, received 900 in 1 payments, fees 0\n", msg.Body)
+
+	msg, err = Render(PaymentsDigest, DefaultLocale, DigestData{Period: "monthly"})
+	require.NoError(t, err)
+	assert.Equal(t, "No payment activity this period.\n", msg.Body)
+}
+
+func TestSet_LocaleFallbackAndVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en/hello.tmpl": {Data: []byte(`{{define "subject"}}Hello {{.}}{{end}}{{define "body"}}Hi{{end}}`)},
+		"fr/hello.tmpl": {Data: []byte(`{{define "subject"}}Bonjour {{.}}{{end}}{{define "body"}}Salut{{end}}`)},
+	}
+	s, err := Load(fsys)
+	require.NoError(t, err)
+
+	fr, err := s.Render("hello", "fr", "Ada")
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour Ada", fr.Subject)
+
+	de, err := s.Render("hello", "de", "Ada")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada", de.Subject)
+	assert.Equal(t, "en", de.Ref.Locale)
+	assert.NotEqual(t, fr.Ref.Version, de.Ref.Version)
+
+	_, err = s.Render("missing", "en", nil)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+
+	fsys["en/hello.tmpl"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}Hey {{.}}{{end}}{{define "body"}}Hi{{end}}`)}
+	changed, err := Load(fsys)
+	require.NoError(t, err)
+	en, err := changed.Render("hello", "en", "Ada")
+	require.NoError(t, err)
+	assert.NotEqual(t, de.Ref.Version, en.Ref.Version, "editing a template changes its version")
+
+	_, err = Load(fstest.MapFS{"en/bad.tmpl": {Data: []byte(`{{define "subject"}}x{{end}}`)}})
+	assert.Error(t, err, "templates must define a body")
+}
@@ -1,12 +1,14 @@
 package testutil
 
 import (
-	"database/sql"
+	"context"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/josh-kwaku/grey-backend-assessment/internal/domain"
+	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,18 +21,42 @@ var (
 	OutgoingUSDID = uuid.MustParse("00000000-0000-0000-0002-000000000001")
 	OutgoingEURID = uuid.MustParse("00000000-0000-0000-0002-000000000002")
 	OutgoingGBPID = uuid.MustParse("00000000-0000-0000-0002-000000000003")
+	SettledUSDID  = uuid.MustParse("00000000-0000-0000-0004-000000000001")
+	SettledEURID  = uuid.MustParse("00000000-0000-0000-0004-000000000002")
+	SettledGBPID  = uuid.MustParse("00000000-0000-0000-0004-000000000003")
+	RevenueUSDID  = uuid.MustParse("00000000-0000-0000-0005-000000000001")
+	RevenueEURID  = uuid.MustParse("00000000-0000-0000-0005-000000000002")
+	RevenueGBPID  = uuid.MustParse("00000000-0000-0000-0005-000000000003")
 )
 
 const fxPoolInitialBalance int64 = 1_000_000_000
 
-func SeedSystemUser(t *testing.T, db *sql.DB) uuid.UUID {
+// FXRates returns the rates FX_RATES ships with, for fx.NewRateService.
+func FXRates() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"USD_EUR": decimal.RequireFromString("0.92"),
+		"EUR_USD": decimal.RequireFromString("1.087"),
+		"USD_GBP": decimal.RequireFromString("0.79"),
+		"GBP_USD": decimal.RequireFromString("1.266"),
+		"EUR_GBP": decimal.RequireFromString("0.858"),
+		"GBP_EUR": decimal.RequireFromString("1.166"),
+		"USD_NGN": decimal.RequireFromString("1550"),
+		"NGN_USD": decimal.RequireFromString("0.000645"),
+		"USD_KES": decimal.RequireFromString("129.5"),
+		"KES_USD": decimal.RequireFromString("0.00772"),
+		"USD_GHS": decimal.RequireFromString("15.4"),
+		"GHS_USD": decimal.RequireFromString("0.0649"),
+	}
+}
+
+func SeedSystemUser(t testing.TB, db *pgxpool.Pool) uuid.UUID {
 	t.Helper()
 
 	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
 	if err != nil {
 		t.Fatalf("hash password: %v", err)
 	}
-	_, err = db.Exec(
+	_, err = db.Exec(context.Background(),
 		`INSERT INTO users (id, email, name, password_hash, unique_name, status)
 		 VALUES ($1, $2, $3, $4, $5, $6)
 		 ON CONFLICT (id) DO NOTHING`,
@@ -42,7 +68,7 @@ func SeedSystemUser(t *testing.T, db *sql.DB) uuid.UUID {
 	return SystemUserID
 }
 
-func SeedSystemAccounts(t *testing.T, db *sql.DB, systemUserID uuid.UUID) {
+func SeedSystemAccounts(t testing.TB, db *pgxpool.Pool, systemUserID uuid.UUID) {
 	t.Helper()
 
 	systemAccounts := []struct {
@@ -57,10 +83,16 @@ func SeedSystemAccounts(t *testing.T, db *sql.DB, systemUserID uuid.UUID) {
 		{OutgoingUSDID, "outgoing", "USD", 0},
 		{OutgoingEURID, "outgoing", "EUR", 0},
 		{OutgoingGBPID, "outgoing", "GBP", 0},
+		{SettledUSDID, "settled", "USD", 0},
+		{SettledEURID, "settled", "EUR", 0},
+		{SettledGBPID, "settled", "GBP", 0},
+		{RevenueUSDID, "revenue", "USD", 0},
+		{RevenueEURID, "revenue", "EUR", 0},
+		{RevenueGBPID, "revenue", "GBP", 0},
 	}
 
 	for _, a := range systemAccounts {
-		_, err := db.Exec(
+		_, err := db.Exec(context.Background(),
 			`INSERT INTO accounts (id, user_id, currency, account_type, balance, status)
 			 VALUES ($1, $2, $3, $4, $5, 'active')
 			 ON CONFLICT (id) DO NOTHING`,
@@ -72,7 +104,7 @@ func SeedSystemAccounts(t *testing.T, db *sql.DB, systemUserID uuid.UUID) {
 	}
 }
 
-func SeedTestUser(t *testing.T, db *sql.DB, email, name, uniqueName string) *domain.User {
+func SeedTestUser(t testing.TB, db *pgxpool.Pool, email, name, uniqueName string) *domain.User {
 	t.Helper()
 
 	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
@@ -86,13 +118,14 @@ func SeedTestUser(t *testing.T, db *sql.DB, email, name, uniqueName string) *dom
 		PasswordHash: string(hash),
 		UniqueName:   &uniqueName,
 		Status:       domain.UserStatusActive,
+		KYCTier:      domain.KYCTierFull,
 		CreatedAt:    time.Now().UTC(),
 	}
 
-	_, err = db.Exec(
-		`INSERT INTO users (id, email, name, password_hash, unique_name, status, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		u.ID, u.Email, u.Name, u.PasswordHash, u.UniqueName, u.Status, u.CreatedAt,
+	_, err = db.Exec(context.Background(),
+		`INSERT INTO users (id, email, name, password_hash, unique_name, status, kyc_tier, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		u.ID, u.Email, u.Name, u.PasswordHash, u.UniqueName, u.Status, u.KYCTier, u.CreatedAt,
 	)
 	if err != nil {
 		t.Fatalf("seed test user %s: %v", email, err)
@@ -100,7 +133,7 @@ func SeedTestUser(t *testing.T, db *sql.DB, email, name, uniqueName string) *dom
 	return u
 }
 
-func SeedTestAccount(t *testing.T, db *sql.DB, userID uuid.UUID, currency string, balance int64) *domain.Account {
+func SeedTestAccount(t testing.TB, db *pgxpool.Pool, userID uuid.UUID, currency string, balance int64) *domain.Account {
 	t.Helper()
 
 	a := &domain.Account{
@@ -114,7 +147,7 @@ func SeedTestAccount(t *testing.T, db *sql.DB, userID uuid.UUID, currency string
 		CreatedAt:   time.Now().UTC(),
 	}
 
-	_, err := db.Exec(
+	_, err := db.Exec(context.Background(),
 		`INSERT INTO accounts (id, user_id, currency, account_type, balance, version, status, created_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 		a.ID, a.UserID, a.Currency, a.AccountType, a.Balance, a.Version, a.Status, a.CreatedAt,
@@ -125,22 +158,22 @@ func SeedTestAccount(t *testing.T, db *sql.DB, userID uuid.UUID, currency string
 	return a
 }
 
-func GetAccountBalance(t *testing.T, db *sql.DB, accountID uuid.UUID) int64 {
+func GetAccountBalance(t testing.TB, db *pgxpool.Pool, accountID uuid.UUID) int64 {
 	t.Helper()
 
 	var balance int64
-	err := db.QueryRow(`SELECT balance FROM accounts WHERE id = $1`, accountID).Scan(&balance)
+	err := db.QueryRow(context.Background(), `SELECT balance FROM accounts WHERE id = $1`, accountID).Scan(&balance)
 	if err != nil {
 		t.Fatalf("get account balance %s: %v", accountID, err)
 	}
 	return balance
 }
 
-func CountLedgerEntries(t *testing.T, db *sql.DB, paymentID uuid.UUID) int {
+func CountLedgerEntries(t testing.TB, db *pgxpool.Pool, paymentID uuid.UUID) int {
 	t.Helper()
 
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM ledger_entries WHERE payment_id = $1`, paymentID).Scan(&count)
+	err := db.QueryRow(context.Background(), `SELECT COUNT(*) FROM ledger_entries WHERE payment_id = $1`, paymentID).Scan(&count)
 	if err != nil {
 		t.Fatalf("count ledger entries for payment %s: %v", paymentID, err)
 	}
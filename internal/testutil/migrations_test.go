@@ -0,0 +1,414 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SQLSTATE codes the probes expect.
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// Fixtures the probes build on. Each only uses columns that existed when the
+// table was created, so it stays valid at every later migration.
+const (
+	probeUserA = `INSERT INTO users (id, email, name, password_hash)
+		VALUES ('00000000-0000-0000-00aa-000000000001', 'probe-a@test.com', 'Probe A', 'x')`
+	probeUserB = `INSERT INTO users (id, email, name, password_hash)
+		VALUES ('00000000-0000-0000-00aa-000000000002', 'probe-b@test.com', 'Probe B', 'x')`
+	probeAccount = `INSERT INTO accounts (id, user_id, currency, balance)
+		VALUES ('00000000-0000-0000-00ab-000000000001', '00000000-0000-0000-00aa-000000000001', 'USD', 100)`
+	probePaymentKey = `INSERT INTO payment_keys (id, idempotency_key, source_account_id, created_at)
+		VALUES ('00000000-0000-0000-00ac-000000000001', 'probe-1', '00000000-0000-0000-00ab-000000000001', '2026-01-15T00:00:00Z')`
+	probePayment = `INSERT INTO payments (id, idempotency_key, type, source_account_id,
+		source_amount, source_currency, dest_amount, dest_currency, created_at)
+		VALUES ('00000000-0000-0000-00ac-000000000001', 'probe-1', 'internal_transfer', '00000000-0000-0000-00ab-000000000001',
+		100, 'USD', 100, 'USD', '2026-01-15T00:00:00Z')`
+)
+
+// invariantProbe is a schema-level guarantee: after the setup statements,
+// violate must be rejected by the database itself with code, and by
+// constraint when it is set.
+type invariantProbe struct {
+	name       string
+	since      int // migration that introduced the guarantee
+	setup      []string
+	violate    string
+	code       string
+	constraint string
+}
+
+var invariantProbes = []invariantProbe{
+	{
+		name:       "user email is unique",
+		since:      1,
+		setup:      []string{probeUserA},
+		violate:    `INSERT INTO users (id, email, name, password_hash) VALUES (gen_random_uuid(), 'probe-a@test.com', 'Other', 'x')`,
+		code:       pgUniqueViolation,
+		constraint: "idx_users_email",
+	},
+	{
+		name:       "account balance cannot go negative",
+		since:      2,
+		setup:      []string{probeUserA, probeAccount},
+		violate:    `UPDATE accounts SET balance = balance - 101 WHERE id = '00000000-0000-0000-00ab-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_accounts_balance",
+	},
+	{
+		name:       "account cannot be opened with a negative balance",
+		since:      2,
+		setup:      []string{probeUserA},
+		violate:    `INSERT INTO accounts (user_id, currency, balance) VALUES ('00000000-0000-0000-00aa-000000000001', 'EUR', -1)`,
+		code:       pgCheckViolation,
+		constraint: "chk_accounts_balance",
+	},
+	{
+		name:       "one account per user, currency and type",
+		since:      2,
+		setup:      []string{probeUserA, probeAccount},
+		violate:    `INSERT INTO accounts (user_id, currency) VALUES ('00000000-0000-0000-00aa-000000000001', 'USD')`,
+		code:       pgUniqueViolation,
+		constraint: "idx_accounts_user_currency_type",
+	},
+	{
+		name:       "account belongs to an existing user",
+		since:      2,
+		violate:    `INSERT INTO accounts (user_id, currency) VALUES ('00000000-0000-0000-00aa-0000000000ff', 'USD')`,
+		code:       pgForeignKeyViolation,
+		constraint: "accounts_user_id_fkey",
+	},
+	{
+		name:       "user limit is positive",
+		since:      18,
+		setup:      []string{probeUserA},
+		violate:    `INSERT INTO user_limits (user_id, currency, tx_limit, updated_by) VALUES ('00000000-0000-0000-00aa-000000000001', 'USD', 0, '00000000-0000-0000-00aa-000000000001')`,
+		code:       pgCheckViolation,
+		constraint: "user_limits_tx_limit_check",
+	},
+	{
+		name:       "idempotency key is unique per source account",
+		since:      19,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey},
+		violate:    `INSERT INTO payment_keys (id, idempotency_key, source_account_id, created_at) VALUES (gen_random_uuid(), 'probe-1', '00000000-0000-0000-00ab-000000000001', now())`,
+		code:       pgUniqueViolation,
+		constraint: "idx_payment_keys_idempotency",
+	},
+	{
+		name:  "a payment is retried at most once",
+		since: 19,
+		setup: []string{probeUserA, probeAccount, probePaymentKey,
+			`INSERT INTO payment_keys (id, idempotency_key, source_account_id, retry_of_payment_id, created_at) VALUES (gen_random_uuid(), 'probe-retry-1', '00000000-0000-0000-00ab-000000000001', '00000000-0000-0000-00ac-000000000001', now())`},
+		violate:    `INSERT INTO payment_keys (id, idempotency_key, source_account_id, retry_of_payment_id, created_at) VALUES (gen_random_uuid(), 'probe-retry-2', '00000000-0000-0000-00ab-000000000001', '00000000-0000-0000-00ac-000000000001', now())`,
+		code:       pgUniqueViolation,
+		constraint: "idx_payment_keys_retry_of",
+	},
+	{
+		name:    "a payment row needs its payment key",
+		since:   19,
+		setup:   []string{probeUserA, probeAccount},
+		violate: probePayment,
+		code:    pgForeignKeyViolation,
+	},
+	{
+		name:  "a payment is reversed at most once",
+		since: 26,
+		setup: []string{probeUserA, probeAccount, probePaymentKey,
+			`INSERT INTO payment_keys (id, idempotency_key, source_account_id, reversal_of_payment_id, created_at) VALUES (gen_random_uuid(), 'probe-reversal-1', '00000000-0000-0000-00ab-000000000001', '00000000-0000-0000-00ac-000000000001', now())`},
+		violate:    `INSERT INTO payment_keys (id, idempotency_key, source_account_id, reversal_of_payment_id, created_at) VALUES (gen_random_uuid(), 'probe-reversal-2', '00000000-0000-0000-00ab-000000000001', '00000000-0000-0000-00ac-000000000001', now())`,
+		code:       pgUniqueViolation,
+		constraint: "idx_payment_keys_reversal_of",
+	},
+	{
+		name:       "refunds cannot exceed the amount received",
+		since:      27,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey, probePayment},
+		violate:    `UPDATE payments SET refunded_amount = 101 WHERE id = '00000000-0000-0000-00ac-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_payments_refunded_amount",
+	},
+	{
+		name:       "refunded amount cannot be negative",
+		since:      27,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey, probePayment},
+		violate:    `UPDATE payments SET refunded_amount = -1 WHERE id = '00000000-0000-0000-00ac-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_payments_refunded_amount",
+	},
+	{
+		name:       "payment request parties differ",
+		since:      29,
+		setup:      []string{probeUserA},
+		violate:    `INSERT INTO payment_requests (id, requester_user_id, payer_user_id, amount, currency, expires_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', '00000000-0000-0000-00aa-000000000001', 100, 'USD', now())`,
+		code:       pgCheckViolation,
+		constraint: "chk_payment_requests_parties",
+	},
+	{
+		name:       "payment request amount is positive",
+		since:      29,
+		setup:      []string{probeUserA, probeUserB},
+		violate:    `INSERT INTO payment_requests (id, requester_user_id, payer_user_id, amount, currency, expires_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', '00000000-0000-0000-00aa-000000000002', 0, 'USD', now())`,
+		code:       pgCheckViolation,
+		constraint: "payment_requests_amount_check",
+	},
+	{
+		name:       "hold amount is positive",
+		since:      30,
+		setup:      []string{probeUserA, probeAccount},
+		violate:    `INSERT INTO holds (id, account_id, amount, currency, expires_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00ab-000000000001', 0, 'USD', now())`,
+		code:       pgCheckViolation,
+		constraint: "holds_amount_check",
+	},
+	{
+		name:       "hold capture cannot exceed the hold",
+		since:      30,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey},
+		violate:    `INSERT INTO holds (id, account_id, amount, currency, status, payment_id, captured_amount, expires_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00ab-000000000001', 100, 'USD', 'captured', '00000000-0000-0000-00ac-000000000001', 101, now())`,
+		code:       pgCheckViolation,
+		constraint: "chk_holds_capture",
+	},
+	{
+		name:       "captured hold records its payment",
+		since:      30,
+		setup:      []string{probeUserA, probeAccount},
+		violate:    `INSERT INTO holds (id, account_id, amount, currency, status, captured_amount, expires_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00ab-000000000001', 100, 'USD', 'captured', 100, now())`,
+		code:       pgCheckViolation,
+		constraint: "chk_holds_capture",
+	},
+	{
+		name:       "webhook event keeps exactly one payload",
+		since:      35,
+		violate:    `INSERT INTO webhook_events (idempotency_key, event_type, payload) VALUES ('probe-1', 'payment.completed', NULL)`,
+		code:       pgCheckViolation,
+		constraint: "chk_webhook_events_payload",
+	},
+	{
+		name:       "webhook event priority is in range",
+		since:      46,
+		violate:    `INSERT INTO webhook_events (idempotency_key, event_type, payload, priority) VALUES ('probe-1', 'payment.completed', '{}', 3)`,
+		code:       pgCheckViolation,
+		constraint: "chk_webhook_events_priority",
+	},
+	{
+		name:       "freeze reason is a known reason",
+		since:      49,
+		setup:      []string{probeUserA, probeAccount},
+		violate:    `UPDATE accounts SET status = 'frozen', freeze_reason = 'bored' WHERE id = '00000000-0000-0000-00ab-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_accounts_freeze_reason",
+	},
+	{
+		name:  "a payment notifies each user once per kind",
+		since: 50,
+		setup: []string{probeUserA,
+			`INSERT INTO notifications (id, user_id, kind, subject, body, payment_id, template_name, template_locale, template_version) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', 'transfer.received', 's', 'b', '00000000-0000-0000-00ac-000000000001', 't', 'en', 'v')`},
+		violate:    `INSERT INTO notifications (id, user_id, kind, subject, body, payment_id, template_name, template_locale, template_version) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', 'transfer.received', 's', 'b', '00000000-0000-0000-00ac-000000000001', 't', 'en', 'v')`,
+		code:       pgUniqueViolation,
+		constraint: "idx_notifications_payment",
+	},
+	{
+		name:       "residency is a country code",
+		since:      56,
+		setup:      []string{probeUserA},
+		violate:    `UPDATE users SET country = 'ng' WHERE id = '00000000-0000-0000-00aa-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_users_country",
+	},
+	{
+		name:  "one residency rule per scope",
+		since: 56,
+		setup: []string{probeUserA,
+			`INSERT INTO residency_rules (country, source_currency, created_by) VALUES ('NG', 'GBP', '00000000-0000-0000-00aa-000000000001')`},
+		violate:    `INSERT INTO residency_rules (country, source_currency, created_by) VALUES ('NG', 'GBP', '00000000-0000-0000-00aa-000000000001')`,
+		code:       pgUniqueViolation,
+		constraint: "idx_residency_rules_scope",
+	},
+	{
+		name:       "webhook event status is a known status",
+		since:      57,
+		violate:    `INSERT INTO webhook_events (idempotency_key, event_type, payload, status) VALUES ('probe-1', 'payment.completed', '{}', 'done')`,
+		code:       pgCheckViolation,
+		constraint: "chk_webhook_events_status",
+	},
+	{
+		name:       "processing webhook event is claimed and leased",
+		since:      57,
+		violate:    `INSERT INTO webhook_events (idempotency_key, event_type, payload, status, claimed_by) VALUES ('probe-1', 'payment.completed', '{}', 'processing', 'probe')`,
+		code:       pgCheckViolation,
+		constraint: "chk_webhook_events_claim",
+	},
+	{
+		name:       "ledger entry category is known",
+		since:      60,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey, probePayment},
+		violate:    `INSERT INTO ledger_entries (payment_id, account_id, entry_type, entry_category, amount, currency, balance_before, balance_after, created_at) VALUES ('00000000-0000-0000-00ac-000000000001', '00000000-0000-0000-00ab-000000000001', 'debit', 'bonus', 100, 'USD', 100, 0, '2026-01-15T00:00:00Z')`,
+		code:       pgCheckViolation,
+		constraint: "chk_ledger_entries_category",
+	},
+	{
+		name:       "provider submission error class is known",
+		since:      61,
+		violate:    `INSERT INTO provider_submissions (id, payment_id, provider, attempt, error_class) VALUES ('00000000-0000-0000-00ad-000000000001', '00000000-0000-0000-00ac-000000000001', 'mock_provider', 1, 'timeout')`,
+		code:       pgCheckViolation,
+		constraint: "chk_provider_submissions_error_class",
+	},
+	{
+		name:  "one provider submission per attempt",
+		since: 61,
+		setup: []string{
+			`INSERT INTO provider_submissions (id, payment_id, provider, attempt) VALUES ('00000000-0000-0000-00ad-000000000001', '00000000-0000-0000-00ac-000000000001', 'mock_provider', 1)`},
+		violate:    `INSERT INTO provider_submissions (id, payment_id, provider, attempt) VALUES ('00000000-0000-0000-00ad-000000000002', '00000000-0000-0000-00ac-000000000001', 'mock_provider', 1)`,
+		code:       pgUniqueViolation,
+		constraint: "idx_provider_submissions_attempt",
+	},
+	{
+		name:       "user tier is known",
+		since:      62,
+		setup:      []string{probeUserA},
+		violate:    `UPDATE users SET tier = 'gold' WHERE id = '00000000-0000-0000-00aa-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_users_tier",
+	},
+	{
+		name:       "payment user tier is known",
+		since:      62,
+		setup:      []string{probeUserA, probeAccount, probePaymentKey, probePayment},
+		violate:    `UPDATE payments SET user_tier = 'gold' WHERE id = '00000000-0000-0000-00ac-000000000001'`,
+		code:       pgCheckViolation,
+		constraint: "chk_payments_user_tier",
+	},
+	{
+		name:  "webhook delivery status is known",
+		since: 63,
+		setup: []string{probeUserA,
+			`INSERT INTO webhook_endpoints (user_id, url, secret_encrypted) VALUES ('00000000-0000-0000-00aa-000000000001', 'https://example.com/hook', '\x00')`},
+		violate:    `INSERT INTO webhook_deliveries (id, user_id, event_id, event_type, payload, status, next_attempt_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', gen_random_uuid(), 'payment.completed', '{}', 'sent', now())`,
+		code:       pgCheckViolation,
+		constraint: "chk_webhook_deliveries_status",
+	},
+	{
+		name:  "one webhook delivery per endpoint and event",
+		since: 63,
+		setup: []string{probeUserA,
+			`INSERT INTO webhook_endpoints (user_id, url, secret_encrypted) VALUES ('00000000-0000-0000-00aa-000000000001', 'https://example.com/hook', '\x00')`,
+			`INSERT INTO webhook_deliveries (id, user_id, event_id, event_type, payload, next_attempt_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', '00000000-0000-0000-00ae-000000000001', 'payment.completed', '{}', now())`},
+		violate:    `INSERT INTO webhook_deliveries (id, user_id, event_id, event_type, payload, next_attempt_at) VALUES (gen_random_uuid(), '00000000-0000-0000-00aa-000000000001', '00000000-0000-0000-00ae-000000000001', 'payment.completed', '{}', now())`,
+		code:       pgUniqueViolation,
+		constraint: "idx_webhook_deliveries_event",
+	},
+	{
+		name:       "an event is processed once per consumer",
+		since:      64,
+		setup:      []string{`INSERT INTO processed_events (consumer, event_id) VALUES ('webhook_processor', '00000000-0000-0000-00ae-000000000001')`},
+		violate:    `INSERT INTO processed_events (consumer, event_id) VALUES ('webhook_processor', '00000000-0000-0000-00ae-000000000001')`,
+		code:       pgUniqueViolation,
+		constraint: "processed_events_pkey",
+	},
+	{
+		name:       "one balance snapshot per account and instant",
+		since:      65,
+		setup:      []string{probeUserA, probeAccount, `INSERT INTO balance_snapshots (account_id, as_of, balance) VALUES ('00000000-0000-0000-00ab-000000000001', '2026-01-31T00:00:00Z', 100)`},
+		violate:    `INSERT INTO balance_snapshots (account_id, as_of, balance) VALUES ('00000000-0000-0000-00ab-000000000001', '2026-01-31T00:00:00Z', 200)`,
+		code:       pgUniqueViolation,
+		constraint: "balance_snapshots_pkey",
+	},
+}
+
+// TestMigrations_KeepSchemaInvariants applies the migrations one at a time
+// and, after each, runs every probe whose guarantee already exists. A later
+// migration that drops, renames or weakens a constraint (for example when
+// rebuilding a table) fails here at the migration that did it.
+func TestMigrations_KeepSchemaInvariants(t *testing.T) {
+	db := startPostgres(t)
+
+	files, err := migrationFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest := 0
+	for _, f := range files {
+		version, err := migrationVersion(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := applyMigration(db, f); err != nil {
+			t.Fatal(err)
+		}
+		latest = version
+
+		for _, p := range invariantProbes {
+			if p.since > version {
+				continue
+			}
+			t.Run(fmt.Sprintf("%06d/%s", version, p.name), func(t *testing.T) {
+				runInvariantProbe(t, db, p)
+			})
+		}
+	}
+
+	for _, p := range invariantProbes {
+		if p.since > latest {
+			t.Errorf("probe %q is for migration %06d, but the latest is %06d", p.name, p.since, latest)
+		}
+	}
+}
+
+// Each probe runs in its own transaction and is rolled back, so probes don't
+// see each other's fixtures and the next migration runs on an empty schema.
+func runInvariantProbe(t *testing.T, db *pgxpool.Pool, p invariantProbe) {
+	t.Helper()
+	ctx := context.Background()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, stmt := range p.setup {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			t.Fatalf("setup (the fixture may need updating for this migration): %v", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, p.violate)
+	if err == nil {
+		t.Fatalf("violating statement succeeded; the guarantee from migration %06d is gone", p.since)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		t.Fatalf("violating statement: %v", err)
+	}
+	if pgErr.Code != p.code {
+		t.Errorf("SQLSTATE = %s (%s), want %s", pgErr.Code, pgErr.Message, p.code)
+	}
+	if p.constraint != "" && pgErr.ConstraintName != p.constraint {
+		t.Errorf("constraint = %q, want %q", pgErr.ConstraintName, p.constraint)
+	}
+}
+
+// migrationVersion reads the number a migration file name starts with.
+func migrationVersion(path string) (int, error) {
+	name := filepath.Base(path)
+	prefix, _, ok := strings.Cut(name, "_")
+	if !ok {
+		return 0, fmt.Errorf("migration %s: no version prefix", name)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration %s: %w", name, err)
+	}
+	return version, nil
+}
@@ -2,7 +2,6 @@ package testutil
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,13 +10,25 @@ import (
 	"testing"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-func SetupTestDB(t *testing.T) *sql.DB {
+func SetupTestDB(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	db := startPostgres(t)
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	return db
+}
+
+// startPostgres starts an empty database with no migrations applied.
+func startPostgres(t testing.TB) *pgxpool.Pool {
 	t.Helper()
 	ctx := context.Background()
 
@@ -46,46 +57,57 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("get connection string: %v", err)
 	}
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := pgxpool.New(ctx, connStr)
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
 
 	t.Cleanup(func() { db.Close() })
 
-	if err := runMigrations(db); err != nil {
-		t.Fatalf("run migrations: %v", err)
-	}
-
 	return db
 }
 
-func runMigrations(db *sql.DB) error {
+func runMigrations(db *pgxpool.Pool) error {
+	upFiles, err := migrationFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range upFiles {
+		if err := applyMigration(db, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrationFiles returns the paths of the up migrations in the order they
+// are applied.
+func migrationFiles() ([]string, error) {
 	migrationsDir := findMigrationsDir()
 
 	entries, err := os.ReadDir(migrationsDir)
 	if err != nil {
-		return fmt.Errorf("read migrations dir: %w", err)
+		return nil, fmt.Errorf("read migrations dir: %w", err)
 	}
 
 	var upFiles []string
 	for _, e := range entries {
 		if strings.HasSuffix(e.Name(), ".up.sql") {
-			upFiles = append(upFiles, e.Name())
+			upFiles = append(upFiles, filepath.Join(migrationsDir, e.Name()))
 		}
 	}
 	sort.Strings(upFiles)
+	return upFiles, nil
+}
 
-	for _, f := range upFiles {
-		content, err := os.ReadFile(filepath.Join(migrationsDir, f))
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", f, err)
-		}
-		if _, err := db.Exec(string(content)); err != nil {
-			return fmt.Errorf("execute migration %s: %w", f, err)
-		}
+func applyMigration(db *pgxpool.Pool, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read migration %s: %w", filepath.Base(path), err)
+	}
+	if _, err := db.Exec(context.Background(), string(content)); err != nil {
+		return fmt.Errorf("execute migration %s: %w", filepath.Base(path), err)
 	}
-
 	return nil
 }
 
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS support_notes;
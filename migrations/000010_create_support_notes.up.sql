@@ -0,0 +1,13 @@
+CREATE TABLE support_notes (
+    id                 UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_type       VARCHAR(20)  NOT NULL,
+    subject_id         UUID         NOT NULL,
+    author_id          UUID         NOT NULL REFERENCES users(id),
+    body               TEXT         NOT NULL,
+    mentioned_user_ids UUID[]       NOT NULL DEFAULT '{}',
+    flagged            BOOLEAN      NOT NULL DEFAULT false,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_support_notes_subject ON support_notes (subject_type, subject_id, created_at);
+CREATE INDEX idx_support_notes_flagged ON support_notes (created_at) WHERE flagged;
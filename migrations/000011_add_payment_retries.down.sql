@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_retry_of;
+ALTER TABLE payments DROP COLUMN IF EXISTS retry_of_payment_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS failure_code;
@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN failure_code VARCHAR(50);
+ALTER TABLE payments ADD COLUMN retry_of_payment_id UUID REFERENCES payments(id);
+
+CREATE UNIQUE INDEX idx_payments_retry_of ON payments (retry_of_payment_id) WHERE retry_of_payment_id IS NOT NULL;
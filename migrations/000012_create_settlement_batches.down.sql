@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS settlement_batch_payments;
+DROP TABLE IF EXISTS settlement_batches;
+DELETE FROM accounts WHERE account_type = 'settled' AND user_id = '00000000-0000-0000-0000-000000000001';
@@ -0,0 +1,30 @@
+-- System accounts: Settled (one per currency). Outgoing balances are swept here when a settlement batch closes.
+INSERT INTO accounts (id, user_id, currency, account_type, balance, status) VALUES
+    ('00000000-0000-0000-0004-000000000001', '00000000-0000-0000-0000-000000000001', 'USD', 'settled', 0, 'active'),
+    ('00000000-0000-0000-0004-000000000002', '00000000-0000-0000-0000-000000000001', 'EUR', 'settled', 0, 'active'),
+    ('00000000-0000-0000-0004-000000000003', '00000000-0000-0000-0000-000000000001', 'GBP', 'settled', 0, 'active')
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE settlement_batches (
+    id               UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    currency         CHAR(3)      NOT NULL,
+    settlement_date  DATE         NOT NULL,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'open',
+    payment_count    INT          NOT NULL DEFAULT 0,
+    total_amount     BIGINT       NOT NULL DEFAULT 0,
+    closed_at        TIMESTAMPTZ,
+    closed_by        UUID         REFERENCES users(id),
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_settlement_batches_open ON settlement_batches (currency, settlement_date) WHERE status = 'open';
+CREATE INDEX idx_settlement_batches_date ON settlement_batches (settlement_date DESC);
+
+CREATE TABLE settlement_batch_payments (
+    batch_id    UUID    NOT NULL REFERENCES settlement_batches(id),
+    payment_id  UUID    NOT NULL REFERENCES payments(id),
+    amount      BIGINT  NOT NULL,
+    PRIMARY KEY (batch_id, payment_id)
+);
+
+CREATE UNIQUE INDEX idx_settlement_batch_payments_payment ON settlement_batch_payments (payment_id);
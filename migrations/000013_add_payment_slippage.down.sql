@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_fx_corridor;
+ALTER TABLE payments DROP COLUMN IF EXISTS slippage_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS mid_market_rate;
@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN mid_market_rate DECIMAL(20,10);
+ALTER TABLE payments ADD COLUMN slippage_amount BIGINT;
+
+CREATE INDEX idx_payments_fx_corridor ON payments (source_currency, dest_currency, created_at) WHERE mid_market_rate IS NOT NULL;
@@ -0,0 +1 @@
+DELETE FROM accounts WHERE account_type = 'revenue' AND user_id = '00000000-0000-0000-0000-000000000001';
@@ -0,0 +1,6 @@
+-- System accounts: Revenue (one per currency). FX spread fees are credited here.
+INSERT INTO accounts (id, user_id, currency, account_type, balance, status) VALUES
+    ('00000000-0000-0000-0005-000000000001', '00000000-0000-0000-0000-000000000001', 'USD', 'revenue', 0, 'active'),
+    ('00000000-0000-0000-0005-000000000002', '00000000-0000-0000-0000-000000000001', 'EUR', 'revenue', 0, 'active'),
+    ('00000000-0000-0000-0005-000000000003', '00000000-0000-0000-0000-000000000001', 'GBP', 'revenue', 0, 'active')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,22 @@
+CREATE TABLE disputes (
+    id                 UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id            UUID         NOT NULL REFERENCES users(id),
+    payment_id         UUID         NOT NULL REFERENCES payments(id),
+    ledger_entry_id    UUID         REFERENCES ledger_entries(id) ON DELETE RESTRICT,
+    reason             VARCHAR(30)  NOT NULL,
+    description        TEXT         NOT NULL DEFAULT '',
+    status             VARCHAR(20)  NOT NULL DEFAULT 'open',
+    respond_by         TIMESTAMPTZ  NOT NULL,
+    resolve_by         TIMESTAMPTZ  NOT NULL,
+    first_response_at  TIMESTAMPTZ,
+    escalated_at       TIMESTAMPTZ,
+    resolved_at        TIMESTAMPTZ,
+    resolved_by        UUID         REFERENCES users(id),
+    resolution_note    TEXT,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_disputes_active_payment ON disputes (user_id, payment_id) WHERE status IN ('open', 'in_review');
+CREATE INDEX idx_disputes_user ON disputes (user_id, created_at DESC);
+CREATE INDEX idx_disputes_sla ON disputes (respond_by) WHERE status = 'open' AND escalated_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS provider_latencies;
+ALTER TABLE payments DROP COLUMN IF EXISTS submitted_at;
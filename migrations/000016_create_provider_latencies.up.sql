@@ -0,0 +1,14 @@
+ALTER TABLE payments ADD COLUMN submitted_at TIMESTAMPTZ;
+
+CREATE TABLE provider_latencies (
+    payment_id       UUID         PRIMARY KEY REFERENCES payments(id),
+    provider         VARCHAR(50)  NOT NULL,
+    source_currency  CHAR(3)      NOT NULL,
+    dest_currency    CHAR(3)      NOT NULL,
+    outcome          VARCHAR(20)  NOT NULL,
+    submitted_at     TIMESTAMPTZ  NOT NULL,
+    resolved_at      TIMESTAMPTZ  NOT NULL,
+    latency_ms       BIGINT       NOT NULL CHECK (latency_ms >= 0)
+);
+
+CREATE INDEX idx_provider_latencies_corridor ON provider_latencies (provider, source_currency, dest_currency, resolved_at);
@@ -0,0 +1 @@
+CREATE INDEX idx_payments_source_account_created ON payments (source_account_id, created_at);
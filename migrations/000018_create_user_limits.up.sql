@@ -0,0 +1,10 @@
+CREATE TABLE user_limits (
+    user_id     UUID         NOT NULL REFERENCES users(id),
+    currency    CHAR(3)      NOT NULL,
+    tx_limit    BIGINT       NOT NULL CHECK (tx_limit > 0),
+    reason      TEXT         NOT NULL DEFAULT '',
+    updated_by  UUID         NOT NULL REFERENCES users(id),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, currency)
+);
@@ -0,0 +1,56 @@
+ALTER TABLE disputes DROP CONSTRAINT disputes_ledger_entry_id_fkey;
+ALTER TABLE disputes DROP CONSTRAINT disputes_ledger_entry_created_at_check;
+ALTER TABLE disputes DROP COLUMN ledger_entry_created_at;
+ALTER TABLE disputes DROP CONSTRAINT disputes_payment_id_fkey;
+ALTER TABLE provider_latencies DROP CONSTRAINT provider_latencies_payment_id_fkey;
+ALTER TABLE settlement_batch_payments DROP CONSTRAINT settlement_batch_payments_payment_id_fkey;
+ALTER TABLE payment_events DROP CONSTRAINT payment_events_payment_id_fkey;
+
+ALTER TABLE payments RENAME TO payments_partitioned;
+ALTER TABLE payments_partitioned RENAME CONSTRAINT payments_pkey TO payments_partitioned_pkey;
+ALTER TABLE ledger_entries RENAME TO ledger_entries_partitioned;
+ALTER TABLE ledger_entries_partitioned RENAME CONSTRAINT ledger_entries_pkey TO ledger_entries_partitioned_pkey;
+DROP INDEX idx_payments_source_account_created, idx_payments_dest_account, idx_payments_status,
+    idx_payments_fx_corridor, idx_ledger_entries_account, idx_ledger_entries_payment;
+
+CREATE TABLE payments (LIKE payments_partitioned INCLUDING DEFAULTS);
+ALTER TABLE payments ADD PRIMARY KEY (id);
+INSERT INTO payments SELECT * FROM payments_partitioned;
+
+CREATE TABLE ledger_entries (LIKE ledger_entries_partitioned INCLUDING DEFAULTS);
+ALTER TABLE ledger_entries ADD PRIMARY KEY (id);
+INSERT INTO ledger_entries SELECT * FROM ledger_entries_partitioned;
+
+DROP TABLE ledger_entries_partitioned;
+DROP TABLE payments_partitioned;
+DROP FUNCTION sync_payment_key_created_at();
+DROP FUNCTION ensure_monthly_partitions(TEXT, TIMESTAMPTZ, TIMESTAMPTZ);
+
+ALTER TABLE payments ADD CONSTRAINT payments_source_account_id_fkey FOREIGN KEY (source_account_id) REFERENCES accounts(id);
+ALTER TABLE payments ADD CONSTRAINT payments_dest_account_id_fkey FOREIGN KEY (dest_account_id) REFERENCES accounts(id);
+ALTER TABLE payments ADD CONSTRAINT payments_retry_of_payment_id_fkey FOREIGN KEY (retry_of_payment_id) REFERENCES payments(id);
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_payment_id_fkey FOREIGN KEY (payment_id) REFERENCES payments(id);
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts(id);
+
+CREATE UNIQUE INDEX idx_payments_idempotency_key ON payments (idempotency_key, source_account_id);
+CREATE UNIQUE INDEX idx_payments_retry_of ON payments (retry_of_payment_id) WHERE retry_of_payment_id IS NOT NULL;
+CREATE INDEX idx_payments_source_account ON payments (source_account_id);
+CREATE INDEX idx_payments_source_account_created ON payments (source_account_id, created_at);
+CREATE INDEX idx_payments_dest_account ON payments (dest_account_id);
+CREATE INDEX idx_payments_status ON payments (status);
+CREATE INDEX idx_payments_fx_corridor ON payments (source_currency, dest_currency, created_at) WHERE mid_market_rate IS NOT NULL;
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account_id);
+CREATE INDEX idx_ledger_entries_payment ON ledger_entries (payment_id);
+
+ALTER TABLE payment_events ADD CONSTRAINT payment_events_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id);
+ALTER TABLE settlement_batch_payments ADD CONSTRAINT settlement_batch_payments_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id);
+ALTER TABLE provider_latencies ADD CONSTRAINT provider_latencies_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id);
+ALTER TABLE disputes ADD CONSTRAINT disputes_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id);
+ALTER TABLE disputes ADD CONSTRAINT disputes_ledger_entry_id_fkey
+    FOREIGN KEY (ledger_entry_id) REFERENCES ledger_entries(id) ON DELETE RESTRICT;
+
+DROP TABLE payment_keys;
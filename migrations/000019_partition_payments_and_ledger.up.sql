@@ -0,0 +1,198 @@
+-- Partitioned tables can only enforce uniqueness on keys that include the
+-- partition column. payment_keys stays unpartitioned and takes over global
+-- payment identity, idempotency-key uniqueness and the retry-once rule. It is
+-- what other tables reference, and its created_at tells queries which
+-- partition a payment lives in.
+CREATE TABLE payment_keys (
+    id                   UUID          PRIMARY KEY,
+    idempotency_key      VARCHAR(255)  NOT NULL,
+    source_account_id    UUID          NOT NULL REFERENCES accounts(id),
+    retry_of_payment_id  UUID          REFERENCES payment_keys(id),
+    created_at           TIMESTAMPTZ   NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_payment_keys_idempotency ON payment_keys (idempotency_key, source_account_id);
+CREATE UNIQUE INDEX idx_payment_keys_retry_of ON payment_keys (retry_of_payment_id) WHERE retry_of_payment_id IS NOT NULL;
+
+INSERT INTO payment_keys (id, idempotency_key, source_account_id, retry_of_payment_id, created_at)
+SELECT id, idempotency_key, source_account_id, retry_of_payment_id, created_at FROM payments;
+
+-- Creates the monthly partitions of parent covering [from_ts, to_ts] that do
+-- not exist yet and returns their names. Months are UTC. Concurrent calls
+-- serialize on an advisory lock per parent.
+CREATE FUNCTION ensure_monthly_partitions(parent TEXT, from_ts TIMESTAMPTZ, to_ts TIMESTAMPTZ)
+RETURNS SETOF TEXT
+LANGUAGE plpgsql AS $$
+DECLARE
+    month_start    TIMESTAMP;
+    partition_name TEXT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('ensure_monthly_partitions:' || parent));
+
+    month_start := date_trunc('month', from_ts AT TIME ZONE 'UTC');
+    WHILE month_start <= to_ts AT TIME ZONE 'UTC' LOOP
+        partition_name := parent || '_' || to_char(month_start, 'YYYY_MM');
+        IF to_regclass(partition_name) IS NULL THEN
+            EXECUTE format(
+                'CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+                partition_name, parent,
+                month_start AT TIME ZONE 'UTC',
+                (month_start + interval '1 month') AT TIME ZONE 'UTC'
+            );
+            RETURN NEXT partition_name;
+        END IF;
+        month_start := month_start + interval '1 month';
+    END LOOP;
+END;
+$$;
+
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_payment_id_fkey;
+ALTER TABLE payment_events DROP CONSTRAINT payment_events_payment_id_fkey;
+ALTER TABLE settlement_batch_payments DROP CONSTRAINT settlement_batch_payments_payment_id_fkey;
+ALTER TABLE provider_latencies DROP CONSTRAINT provider_latencies_payment_id_fkey;
+ALTER TABLE disputes DROP CONSTRAINT disputes_payment_id_fkey;
+ALTER TABLE disputes DROP CONSTRAINT disputes_ledger_entry_id_fkey;
+
+ALTER TABLE payments RENAME TO payments_unpartitioned;
+ALTER TABLE payments_unpartitioned RENAME CONSTRAINT payments_pkey TO payments_unpartitioned_pkey;
+DROP INDEX idx_payments_idempotency_key, idx_payments_source_account, idx_payments_dest_account,
+    idx_payments_status, idx_payments_retry_of, idx_payments_fx_corridor, idx_payments_source_account_created;
+
+ALTER TABLE ledger_entries RENAME TO ledger_entries_unpartitioned;
+ALTER TABLE ledger_entries_unpartitioned RENAME CONSTRAINT ledger_entries_pkey TO ledger_entries_unpartitioned_pkey;
+DROP INDEX idx_ledger_entries_account, idx_ledger_entries_payment;
+
+CREATE TABLE payments (
+    id                    UUID           NOT NULL DEFAULT gen_random_uuid() REFERENCES payment_keys(id),
+    idempotency_key       VARCHAR(255)   NOT NULL,
+    type                  VARCHAR(30)    NOT NULL,
+    status                VARCHAR(30)    NOT NULL DEFAULT 'pending',
+
+    source_account_id     UUID           NOT NULL REFERENCES accounts(id),
+
+    dest_account_id       UUID           REFERENCES accounts(id),
+    dest_account_number   VARCHAR(50),
+    dest_iban             VARCHAR(34),
+    dest_swift_bic        VARCHAR(11),
+    dest_bank_name        VARCHAR(255),
+
+    source_amount         BIGINT         NOT NULL,
+    source_currency       CHAR(3)        NOT NULL,
+    dest_amount           BIGINT         NOT NULL,
+    dest_currency         CHAR(3)        NOT NULL,
+    exchange_rate         DECIMAL(20,10),
+    fee_amount            BIGINT         NOT NULL DEFAULT 0,
+    fee_currency          CHAR(3),
+
+    provider              VARCHAR(50),
+    provider_ref          VARCHAR(255),
+    failure_reason        TEXT,
+    metadata              JSONB,
+
+    created_at            TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    completed_at          TIMESTAMPTZ,
+
+    failure_code          VARCHAR(50),
+    retry_of_payment_id   UUID           REFERENCES payment_keys(id),
+    mid_market_rate       DECIMAL(20,10),
+    slippage_amount       BIGINT,
+    submitted_at          TIMESTAMPTZ,
+
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE ledger_entries (
+    id             UUID         NOT NULL DEFAULT gen_random_uuid(),
+    payment_id     UUID         NOT NULL REFERENCES payment_keys(id),
+    account_id     UUID         NOT NULL REFERENCES accounts(id),
+    entry_type     VARCHAR(10)  NOT NULL,
+    amount         BIGINT       NOT NULL,
+    currency       CHAR(3)      NOT NULL,
+    balance_before BIGINT       NOT NULL,
+    balance_after  BIGINT       NOT NULL,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- The default partitions only catch rows outside the maintained range; the
+-- partition maintainer keeps future months created ahead of time.
+CREATE TABLE payments_default PARTITION OF payments DEFAULT;
+CREATE TABLE ledger_entries_default PARTITION OF ledger_entries DEFAULT;
+
+SELECT ensure_monthly_partitions('payments',
+    (SELECT COALESCE(MIN(created_at), now()) FROM payments_unpartitioned), now() + interval '3 months');
+SELECT ensure_monthly_partitions('ledger_entries',
+    (SELECT COALESCE(MIN(created_at), now()) FROM ledger_entries_unpartitioned), now() + interval '3 months');
+
+INSERT INTO payments (
+    id, idempotency_key, type, status, source_account_id,
+    dest_account_id, dest_account_number, dest_iban, dest_swift_bic, dest_bank_name,
+    source_amount, source_currency, dest_amount, dest_currency, exchange_rate,
+    fee_amount, fee_currency, provider, provider_ref, failure_reason, metadata,
+    created_at, updated_at, completed_at, failure_code, retry_of_payment_id,
+    mid_market_rate, slippage_amount, submitted_at
+)
+SELECT
+    id, idempotency_key, type, status, source_account_id,
+    dest_account_id, dest_account_number, dest_iban, dest_swift_bic, dest_bank_name,
+    source_amount, source_currency, dest_amount, dest_currency, exchange_rate,
+    fee_amount, fee_currency, provider, provider_ref, failure_reason, metadata,
+    created_at, updated_at, completed_at, failure_code, retry_of_payment_id,
+    mid_market_rate, slippage_amount, submitted_at
+FROM payments_unpartitioned;
+
+INSERT INTO ledger_entries (
+    id, payment_id, account_id, entry_type, amount, currency,
+    balance_before, balance_after, created_at
+)
+SELECT
+    id, payment_id, account_id, entry_type, amount, currency,
+    balance_before, balance_after, created_at
+FROM ledger_entries_unpartitioned;
+
+DROP TABLE ledger_entries_unpartitioned;
+DROP TABLE payments_unpartitioned;
+
+CREATE INDEX idx_payments_source_account_created ON payments (source_account_id, created_at);
+CREATE INDEX idx_payments_dest_account ON payments (dest_account_id);
+CREATE INDEX idx_payments_status ON payments (status);
+CREATE INDEX idx_payments_fx_corridor ON payments (source_currency, dest_currency, created_at) WHERE mid_market_rate IS NOT NULL;
+
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account_id, created_at);
+CREATE INDEX idx_ledger_entries_payment ON ledger_entries (payment_id);
+
+-- created_at is the partition key; if it is ever rewritten (backfills, test
+-- fixtures) payment_keys must follow or partition-pruned lookups miss the row.
+CREATE FUNCTION sync_payment_key_created_at() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE payment_keys SET created_at = NEW.created_at WHERE id = NEW.id;
+    RETURN NEW;
+END;
+$$;
+
+-- BEFORE rather than AFTER: an update that moves a row to another partition
+-- runs as delete plus insert and skips AFTER UPDATE triggers.
+CREATE TRIGGER trg_payments_sync_key_created_at
+    BEFORE UPDATE OF created_at ON payments
+    FOR EACH ROW WHEN (OLD.created_at IS DISTINCT FROM NEW.created_at)
+    EXECUTE FUNCTION sync_payment_key_created_at();
+
+ALTER TABLE payment_events ADD CONSTRAINT payment_events_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payment_keys(id);
+ALTER TABLE settlement_batch_payments ADD CONSTRAINT settlement_batch_payments_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payment_keys(id);
+ALTER TABLE provider_latencies ADD CONSTRAINT provider_latencies_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payment_keys(id);
+ALTER TABLE disputes ADD CONSTRAINT disputes_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payment_keys(id);
+
+-- A foreign key into a partitioned table must cover its partition key.
+ALTER TABLE disputes ADD COLUMN ledger_entry_created_at TIMESTAMPTZ;
+UPDATE disputes d SET ledger_entry_created_at = l.created_at
+FROM ledger_entries l WHERE l.id = d.ledger_entry_id;
+ALTER TABLE disputes ADD CONSTRAINT disputes_ledger_entry_created_at_check
+    CHECK ((ledger_entry_id IS NULL) = (ledger_entry_created_at IS NULL));
+ALTER TABLE disputes ADD CONSTRAINT disputes_ledger_entry_id_fkey
+    FOREIGN KEY (ledger_entry_id, ledger_entry_created_at) REFERENCES ledger_entries(id, created_at) ON DELETE RESTRICT;
@@ -0,0 +1,21 @@
+ALTER TABLE users ADD COLUMN kyc_tier VARCHAR(20) NOT NULL DEFAULT 'unverified';
+
+-- Users onboarded before tiers existed were verified out of band.
+UPDATE users SET kyc_tier = 'full';
+
+CREATE TABLE kyc_submissions (
+    id              UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID          NOT NULL REFERENCES users(id),
+    requested_tier  VARCHAR(20)   NOT NULL,
+    document_type   VARCHAR(30)   NOT NULL,
+    document_ref    VARCHAR(255)  NOT NULL,
+    status          VARCHAR(20)   NOT NULL DEFAULT 'pending',
+    reviewed_by     UUID          REFERENCES users(id),
+    reviewed_at     TIMESTAMPTZ,
+    review_note     TEXT,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_kyc_submissions_pending_user ON kyc_submissions (user_id) WHERE status = 'pending';
+CREATE INDEX idx_kyc_submissions_status ON kyc_submissions (status, created_at);
+CREATE INDEX idx_kyc_submissions_user ON kyc_submissions (user_id, created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE denylist_entries (
+    id          UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    field       VARCHAR(20)   NOT NULL CHECK (field IN ('iban', 'bank_name')),
+    value       VARCHAR(255)  NOT NULL,
+    reason      TEXT          NOT NULL DEFAULT '',
+    created_by  UUID          NOT NULL REFERENCES users(id),
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_denylist_entries_field_value ON denylist_entries (field, value);
@@ -0,0 +1,12 @@
+CREATE TABLE identifier_changes (
+    id          UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID          NOT NULL REFERENCES users(id),
+    kind        VARCHAR(20)   NOT NULL CHECK (kind IN ('email', 'unique_name')),
+    old_value   VARCHAR(255),
+    new_value   VARCHAR(255),
+    changed_at  TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_identifier_changes_user ON identifier_changes (user_id, changed_at);
+CREATE INDEX idx_identifier_changes_old_value ON identifier_changes (kind, old_value, changed_at) WHERE old_value IS NOT NULL;
+CREATE INDEX idx_identifier_changes_new_value ON identifier_changes (kind, new_value, changed_at) WHERE new_value IS NOT NULL;
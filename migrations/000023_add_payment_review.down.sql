@@ -0,0 +1,14 @@
+-- Only screening reviews were holds before this migration. A payment held
+-- for its amount has no status to go back to, so refuse to roll back while
+-- any are waiting rather than relabel them as screening holds.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM payments WHERE status = 'pending_review' AND review_reason = 'amount_threshold') THEN
+        RAISE EXCEPTION 'payments are pending amount_threshold review; approve or reject them before rolling back';
+    END IF;
+END
+$$;
+
+UPDATE payments SET status = 'on_hold' WHERE status = 'pending_review' AND review_reason = 'screening';
+
+ALTER TABLE payments DROP COLUMN review_reason;
@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN review_reason VARCHAR(30)
+    CHECK (review_reason IN ('screening', 'amount_threshold'));
+
+UPDATE payments SET status = 'pending_review', review_reason = 'screening' WHERE status = 'on_hold';
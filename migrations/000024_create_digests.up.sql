@@ -0,0 +1,20 @@
+CREATE TABLE digest_preferences (
+    user_id     UUID          PRIMARY KEY REFERENCES users(id),
+    weekly      BOOLEAN       NOT NULL DEFAULT false,
+    monthly     BOOLEAN       NOT NULL DEFAULT false,
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE TABLE digests (
+    id            UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID          NOT NULL REFERENCES users(id),
+    period        VARCHAR(10)   NOT NULL CHECK (period IN ('weekly', 'monthly')),
+    period_start  TIMESTAMPTZ   NOT NULL,
+    period_end    TIMESTAMPTZ   NOT NULL,
+    totals        JSONB         NOT NULL DEFAULT '[]',
+    delivered_at  TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_digests_user_period ON digests (user_id, period, period_start);
+CREATE INDEX idx_digests_undelivered ON digests (created_at) WHERE delivered_at IS NULL;
@@ -0,0 +1 @@
+DROP TABLE shutdown_reports;
@@ -0,0 +1,14 @@
+CREATE TABLE shutdown_reports (
+    id               UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    instance         VARCHAR(255)  NOT NULL,
+    signal           VARCHAR(20)   NOT NULL,
+    grace_period_ms  BIGINT        NOT NULL,
+    started_at       TIMESTAMPTZ   NOT NULL,
+    finished_at      TIMESTAMPTZ   NOT NULL,
+    clean            BOOLEAN       NOT NULL,
+    counts           JSONB         NOT NULL DEFAULT '[]',
+    abandoned        JSONB         NOT NULL DEFAULT '[]',
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_shutdown_reports_started ON shutdown_reports (started_at DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN reversal_of_payment_id;
+DROP INDEX idx_payment_keys_reversal_of;
+ALTER TABLE payment_keys DROP COLUMN reversal_of_payment_id;
@@ -0,0 +1,6 @@
+-- A reversal is its own payment linked to the transfer it undoes. The unique
+-- index on payment_keys allows at most one reversal per payment.
+ALTER TABLE payment_keys ADD COLUMN reversal_of_payment_id UUID REFERENCES payment_keys(id);
+CREATE UNIQUE INDEX idx_payment_keys_reversal_of ON payment_keys (reversal_of_payment_id) WHERE reversal_of_payment_id IS NOT NULL;
+
+ALTER TABLE payments ADD COLUMN reversal_of_payment_id UUID REFERENCES payment_keys(id);
@@ -0,0 +1,4 @@
+DROP INDEX idx_payments_refund_of;
+ALTER TABLE payments DROP CONSTRAINT chk_payments_refunded_amount;
+ALTER TABLE payments DROP COLUMN refunded_amount;
+ALTER TABLE payments DROP COLUMN refund_of_payment_id;
@@ -0,0 +1,6 @@
+ALTER TABLE payments ADD COLUMN refund_of_payment_id UUID REFERENCES payment_keys(id);
+ALTER TABLE payments ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_refunded_amount
+    CHECK (refunded_amount >= 0 AND refunded_amount <= dest_amount);
+
+CREATE INDEX idx_payments_refund_of ON payments (refund_of_payment_id) WHERE refund_of_payment_id IS NOT NULL;
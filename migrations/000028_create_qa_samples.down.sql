@@ -0,0 +1 @@
+DROP TABLE qa_samples;
@@ -0,0 +1,23 @@
+CREATE TABLE qa_samples (
+    id           UUID PRIMARY KEY,
+    payment_id   UUID NOT NULL REFERENCES payment_keys(id),
+    payment_type VARCHAR(30) NOT NULL,
+    amount       BIGINT NOT NULL,
+    currency     VARCHAR(3) NOT NULL,
+    reason       VARCHAR(30) NOT NULL,
+    weight       DOUBLE PRECISION NOT NULL,
+    probability  DOUBLE PRECISION NOT NULL,
+    status       VARCHAR(20) NOT NULL DEFAULT 'pending',
+    outcome      VARCHAR(20),
+    review_note  TEXT,
+    reviewed_by  UUID REFERENCES users(id),
+    reviewed_at  TIMESTAMPTZ,
+    sampled_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_qa_samples_review CHECK (
+        (status = 'pending' AND outcome IS NULL AND reviewed_by IS NULL AND reviewed_at IS NULL)
+        OR (status = 'reviewed' AND outcome IS NOT NULL AND reviewed_by IS NOT NULL AND reviewed_at IS NOT NULL)
+    )
+);
+
+CREATE UNIQUE INDEX idx_qa_samples_payment ON qa_samples (payment_id);
+CREATE INDEX idx_qa_samples_status_sampled ON qa_samples (status, sampled_at);
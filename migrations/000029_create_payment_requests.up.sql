@@ -0,0 +1,19 @@
+CREATE TABLE payment_requests (
+    id                UUID PRIMARY KEY,
+    requester_user_id UUID NOT NULL REFERENCES users(id),
+    payer_user_id     UUID NOT NULL REFERENCES users(id),
+    amount            BIGINT NOT NULL CHECK (amount > 0),
+    currency          VARCHAR(3) NOT NULL,
+    note              TEXT,
+    status            VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payment_id        UUID REFERENCES payment_keys(id),
+    expires_at        TIMESTAMPTZ NOT NULL,
+    responded_at      TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_payment_requests_parties CHECK (requester_user_id <> payer_user_id)
+);
+
+CREATE INDEX idx_payment_requests_payer ON payment_requests (payer_user_id, created_at);
+CREATE INDEX idx_payment_requests_requester ON payment_requests (requester_user_id, created_at);
+CREATE INDEX idx_payment_requests_expiry ON payment_requests (expires_at) WHERE status = 'pending';
@@ -0,0 +1,20 @@
+CREATE TABLE holds (
+    id              UUID PRIMARY KEY,
+    account_id      UUID NOT NULL REFERENCES accounts(id),
+    amount          BIGINT NOT NULL CHECK (amount > 0),
+    currency        VARCHAR(3) NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'active',
+    reference       TEXT,
+    payment_id      UUID REFERENCES payment_keys(id),
+    captured_amount BIGINT,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at     TIMESTAMPTZ,
+    CONSTRAINT chk_holds_capture CHECK (
+        (status = 'captured') = (payment_id IS NOT NULL AND captured_amount IS NOT NULL)
+        AND (captured_amount IS NULL OR (captured_amount > 0 AND captured_amount <= amount))
+    )
+);
+
+CREATE INDEX idx_holds_account_active ON holds (account_id, expires_at) WHERE status = 'active';
+CREATE INDEX idx_holds_account_created ON holds (account_id, created_at);
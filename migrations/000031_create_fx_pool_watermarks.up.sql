@@ -0,0 +1,7 @@
+CREATE TABLE fx_pool_watermarks (
+    currency       CHAR(3)      PRIMARY KEY,
+    low_watermark  BIGINT       NOT NULL CHECK (low_watermark > 0),
+    updated_by     UUID         NOT NULL REFERENCES users(id),
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_webhook_events_last_attempt;
+DROP INDEX idx_webhook_events_created_at;
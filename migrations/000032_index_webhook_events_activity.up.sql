@@ -0,0 +1,2 @@
+CREATE INDEX idx_webhook_events_created_at ON webhook_events (created_at);
+CREATE INDEX idx_webhook_events_last_attempt ON webhook_events (last_attempt) WHERE last_attempt IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN default_account_id UUID REFERENCES accounts(id);
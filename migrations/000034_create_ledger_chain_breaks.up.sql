@@ -0,0 +1,19 @@
+CREATE TABLE ledger_chain_breaks (
+    id                 UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id         UUID         NOT NULL REFERENCES accounts(id),
+    entry_id           UUID         NOT NULL,
+    entry_created_at   TIMESTAMPTZ  NOT NULL,
+    prev_entry_id      UUID         NOT NULL,
+    prev_created_at    TIMESTAMPTZ  NOT NULL,
+    expected_before    BIGINT       NOT NULL,
+    actual_before      BIGINT       NOT NULL,
+    detected_at        TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    annotation         TEXT,
+    repair_payment_id  UUID         REFERENCES payment_keys(id),
+    annotated_by       UUID         REFERENCES users(id),
+    annotated_at       TIMESTAMPTZ,
+    FOREIGN KEY (entry_id, entry_created_at) REFERENCES ledger_entries(id, created_at) ON DELETE RESTRICT
+);
+
+CREATE UNIQUE INDEX idx_ledger_chain_breaks_entry ON ledger_chain_breaks (entry_id);
+CREATE INDEX idx_ledger_chain_breaks_open ON ledger_chain_breaks (detected_at) WHERE annotated_at IS NULL;
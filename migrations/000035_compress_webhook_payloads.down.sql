@@ -0,0 +1,5 @@
+-- Fails while any payload is stored compressed; those rows have to be
+-- decompressed into payload before rolling back.
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS chk_webhook_events_payload;
+ALTER TABLE webhook_events ALTER COLUMN payload SET NOT NULL;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS payload_gzip;
@@ -0,0 +1,6 @@
+-- Payloads over WEBHOOK_COMPRESS_ABOVE_BYTES are stored gzipped in
+-- payload_gzip instead of payload. Exactly one of the two is set.
+ALTER TABLE webhook_events ALTER COLUMN payload DROP NOT NULL;
+ALTER TABLE webhook_events ADD COLUMN payload_gzip BYTEA;
+ALTER TABLE webhook_events ADD CONSTRAINT chk_webhook_events_payload
+    CHECK ((payload IS NULL) <> (payload_gzip IS NULL));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_events_created_at;
+DROP TABLE IF EXISTS payment_event_archive_payments;
+DROP TABLE IF EXISTS payment_event_archives;
@@ -0,0 +1,19 @@
+-- One row per archived batch of payment_events. The events themselves live
+-- in the archive store under object_key as gzipped NDJSON.
+CREATE TABLE payment_event_archives (
+    object_key       TEXT        PRIMARY KEY,
+    event_count      INT         NOT NULL,
+    first_created_at TIMESTAMPTZ NOT NULL,
+    last_created_at  TIMESTAMPTZ NOT NULL,
+    archived_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Which batches hold events for a payment, so a payment's history can be
+-- read back without scanning the archive.
+CREATE TABLE payment_event_archive_payments (
+    payment_id UUID NOT NULL REFERENCES payment_keys(id),
+    object_key TEXT NOT NULL REFERENCES payment_event_archives(object_key),
+    PRIMARY KEY (payment_id, object_key)
+);
+
+CREATE INDEX idx_payment_events_created_at ON payment_events (created_at, id);
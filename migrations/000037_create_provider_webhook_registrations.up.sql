@@ -0,0 +1,11 @@
+-- Callback handshake state per provider. A provider without a row hasn't
+-- attempted the handshake yet.
+CREATE TABLE provider_webhook_registrations (
+    provider          VARCHAR(50) PRIMARY KEY,
+    status            VARCHAR(20) NOT NULL CHECK (status IN ('verified', 'failed')),
+    challenges        INT         NOT NULL DEFAULT 0,
+    failures          INT         NOT NULL DEFAULT 0,
+    last_challenge_at TIMESTAMPTZ NOT NULL,
+    verified_at       TIMESTAMPTZ,
+    last_failure_at   TIMESTAMPTZ
+);
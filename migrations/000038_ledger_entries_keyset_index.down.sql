@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ledger_entries_account;
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account_id, created_at);
@@ -0,0 +1,5 @@
+-- Transaction history pages through an account's entries on (created_at, id),
+-- so the account index carries id as a tiebreaker for entries written in the
+-- same instant.
+DROP INDEX IF EXISTS idx_ledger_entries_account;
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account_id, created_at, id);
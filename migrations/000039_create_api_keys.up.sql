@@ -0,0 +1,19 @@
+-- User-created API keys. Only a SHA-256 hash of the key is stored; prefix
+-- and last_four identify a key in listings without revealing it.
+CREATE TABLE api_keys (
+    id                 UUID         PRIMARY KEY,
+    user_id            UUID         NOT NULL REFERENCES users(id),
+    name               VARCHAR(100) NOT NULL,
+    prefix             VARCHAR(20)  NOT NULL UNIQUE,
+    key_hash           BYTEA        NOT NULL,
+    last_four          VARCHAR(4)   NOT NULL,
+    scopes             TEXT[]       NOT NULL CHECK (cardinality(scopes) > 0),
+    created_ip         VARCHAR(45)  NOT NULL,
+    created_user_agent TEXT         NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    last_used_at       TIMESTAMPTZ,
+    revoked_at         TIMESTAMPTZ,
+    rotated_from       UUID         REFERENCES api_keys(id)
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys (user_id, created_at DESC);
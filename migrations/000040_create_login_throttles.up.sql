@@ -0,0 +1,10 @@
+-- Failed login counters per email address ("email:<address>") and per
+-- client IP ("ip:<address>"). A subject that reaches the failure threshold
+-- within the window is locked until locked_until.
+CREATE TABLE login_throttles (
+    subject      TEXT        PRIMARY KEY,
+    failures     INT         NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    lockouts     INT         NOT NULL DEFAULT 0
+);
@@ -0,0 +1,14 @@
+-- One-time password reset tokens. Only a SHA-256 hash of the token is
+-- stored. A token is spent by setting used_at; a reset also spends every
+-- other outstanding token for the user.
+CREATE TABLE password_reset_tokens (
+    id         UUID        PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users(id),
+    token_hash BYTEA       NOT NULL UNIQUE,
+    created_ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens (user_id) WHERE used_at IS NULL;
@@ -0,0 +1,15 @@
+-- One row per delivered notification, recording which template version the
+-- text came from. Bodies aren't kept; the template version and the records
+-- the notification was about are enough to reproduce them.
+CREATE TABLE notification_log (
+    id               UUID        PRIMARY KEY,
+    user_id          UUID        NOT NULL REFERENCES users(id),
+    kind             TEXT        NOT NULL,
+    template_name    TEXT        NOT NULL,
+    template_locale  TEXT        NOT NULL,
+    template_version TEXT        NOT NULL,
+    sent_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_notification_log_user ON notification_log (user_id, sent_at DESC);
+CREATE INDEX idx_notification_log_template ON notification_log (template_name, template_version);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS corridor_daily_stats;
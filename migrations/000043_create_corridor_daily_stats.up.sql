@@ -0,0 +1,18 @@
+-- Daily rollup of customer payments (internal transfers and external
+-- payouts) per source/destination currency pair, keyed by the UTC day the
+-- payment was created. The corridor projector rebuilds recent days as
+-- payments finish; analytics read from here instead of scanning payments.
+CREATE TABLE corridor_daily_stats (
+    day                DATE             NOT NULL,
+    source_currency    CHAR(3)          NOT NULL,
+    dest_currency      CHAR(3)          NOT NULL,
+    payments           BIGINT           NOT NULL,
+    succeeded          BIGINT           NOT NULL,
+    failed             BIGINT           NOT NULL,
+    volume             BIGINT           NOT NULL,
+    fees               BIGINT           NOT NULL,
+    timed              BIGINT           NOT NULL,
+    completion_seconds DOUBLE PRECISION NOT NULL,
+    refreshed_at       TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (day, source_currency, dest_currency)
+);
@@ -0,0 +1,10 @@
+-- Authenticator app (TOTP) enrollments, one per user. The secret is
+-- encrypted by the application before it is stored. A row with no
+-- confirmed_at is an enrollment waiting for its first code.
+CREATE TABLE user_totp (
+    user_id          UUID        PRIMARY KEY REFERENCES users(id),
+    secret_encrypted BYTEA       NOT NULL,
+    confirmed_at     TIMESTAMPTZ,
+    last_used_step   BIGINT      NOT NULL DEFAULT 0,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+DROP INDEX idx_payments_beneficiary;
+ALTER TABLE payments DROP COLUMN beneficiary_id;
+DROP TABLE IF EXISTS beneficiaries;
@@ -0,0 +1,21 @@
+-- Bank accounts users save to pay out to. Deleting one sets deleted_at and
+-- keeps the row, so payouts that went to it can still be joined to it.
+CREATE TABLE beneficiaries (
+    id         UUID         PRIMARY KEY,
+    user_id    UUID         NOT NULL REFERENCES users(id),
+    name       VARCHAR(100) NOT NULL,
+    iban       VARCHAR(34)  NOT NULL,
+    bank_name  VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_beneficiaries_user ON beneficiaries (user_id, created_at DESC);
+CREATE UNIQUE INDEX idx_beneficiaries_user_iban ON beneficiaries (user_id, iban) WHERE deleted_at IS NULL;
+
+-- Payouts keep their own copy of the IBAN and bank name, so a beneficiary
+-- removed for good only clears the link.
+ALTER TABLE payments ADD COLUMN beneficiary_id UUID REFERENCES beneficiaries(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_payments_beneficiary ON payments (beneficiary_id) WHERE beneficiary_id IS NOT NULL;
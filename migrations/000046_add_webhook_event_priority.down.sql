@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_events_pending_priority;
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS chk_webhook_events_priority;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS priority;
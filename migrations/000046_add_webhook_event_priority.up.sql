@@ -0,0 +1,8 @@
+-- Processing class set when the callback arrives: 0 high, 1 normal, 2 low.
+-- Pending events are claimed in priority order, with waiting events
+-- promoted over time so low ones aren't starved.
+ALTER TABLE webhook_events ADD COLUMN priority SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE webhook_events ADD CONSTRAINT chk_webhook_events_priority CHECK (priority BETWEEN 0 AND 2);
+
+CREATE INDEX idx_webhook_events_pending_priority ON webhook_events (priority, created_at)
+    WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- Who did what to which resource, for logins, limit changes, account status
+-- changes and admin requests. Rows are only ever inserted.
+CREATE TABLE audit_logs (
+    id            UUID         PRIMARY KEY,
+    actor_id      UUID,
+    action        VARCHAR(50)  NOT NULL,
+    resource_type VARCHAR(50)  NOT NULL,
+    resource_id   VARCHAR(255) NOT NULL DEFAULT '',
+    ip            VARCHAR(45)  NOT NULL DEFAULT '',
+    before        JSONB,
+    after         JSONB,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_logs_created ON audit_logs (created_at DESC, id DESC);
+CREATE INDEX idx_audit_logs_actor ON audit_logs (actor_id, created_at DESC) WHERE actor_id IS NOT NULL;
+CREATE INDEX idx_audit_logs_resource ON audit_logs (resource_type, resource_id, created_at DESC);
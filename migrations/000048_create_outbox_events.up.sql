@@ -0,0 +1,14 @@
+-- Events waiting to go to the event bus. Rows are written in the same
+-- transaction as the change they describe and marked published by the relay.
+CREATE TABLE outbox_events (
+    id           UUID         PRIMARY KEY,
+    topic        VARCHAR(100) NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    payload      JSONB        NOT NULL,
+    attempts     INT          NOT NULL DEFAULT 0,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events (created_at, id) WHERE published_at IS NULL;
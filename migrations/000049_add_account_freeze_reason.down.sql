@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_accounts_frozen;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_accounts_freeze_reason;
+ALTER TABLE accounts DROP COLUMN frozen_by;
+ALTER TABLE accounts DROP COLUMN frozen_at;
+ALTER TABLE accounts DROP COLUMN freeze_reason;
@@ -0,0 +1,10 @@
+-- Why a frozen account is frozen, and who froze it. Cleared on unfreeze; the
+-- history is in the audit log.
+ALTER TABLE accounts ADD COLUMN freeze_reason VARCHAR(30);
+ALTER TABLE accounts ADD COLUMN frozen_at TIMESTAMPTZ;
+ALTER TABLE accounts ADD COLUMN frozen_by UUID REFERENCES users(id);
+
+ALTER TABLE accounts ADD CONSTRAINT chk_accounts_freeze_reason
+    CHECK (freeze_reason IN ('compliance_review', 'user_requested', 'fraud_suspected'));
+
+CREATE INDEX idx_accounts_frozen ON accounts (freeze_reason, frozen_at) WHERE status = 'frozen';
@@ -0,0 +1,21 @@
+-- The in-app notification feed. Rows are written in the same transaction as
+-- the payment change they're about, rendered at that point, and keep the
+-- template version they came from.
+CREATE TABLE notifications (
+    id               UUID        PRIMARY KEY,
+    user_id          UUID        NOT NULL REFERENCES users(id),
+    kind             VARCHAR(50) NOT NULL,
+    subject          TEXT        NOT NULL,
+    body             TEXT        NOT NULL,
+    payment_id       UUID,
+    template_name    TEXT        NOT NULL,
+    template_locale  TEXT        NOT NULL,
+    template_version TEXT        NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    read_at          TIMESTAMPTZ
+);
+
+CREATE INDEX idx_notifications_user ON notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+-- A payment event written twice (a replayed webhook) doesn't notify twice.
+CREATE UNIQUE INDEX idx_notifications_payment ON notifications (payment_id, kind, user_id) WHERE payment_id IS NOT NULL;
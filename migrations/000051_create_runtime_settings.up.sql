@@ -0,0 +1,8 @@
+-- Settings staff change while the service runs, without a deploy. A missing
+-- row means the setting's default.
+CREATE TABLE runtime_settings (
+    key        VARCHAR(100) PRIMARY KEY,
+    value      JSONB        NOT NULL,
+    updated_by UUID         NOT NULL REFERENCES users(id),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
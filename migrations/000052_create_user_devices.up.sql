@@ -0,0 +1,12 @@
+-- Browsers and apps each user has signed in from, so a sign-in from a new
+-- one can be reported to them. A device is its user agent; addresses change
+-- too often on mobile networks to tell devices apart by.
+CREATE TABLE user_devices (
+    user_id         UUID        NOT NULL REFERENCES users(id),
+    user_agent_hash CHAR(64)    NOT NULL,
+    user_agent      TEXT        NOT NULL,
+    last_ip         VARCHAR(45) NOT NULL,
+    first_seen_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, user_agent_hash)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_unemailed;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS email_error,
+    DROP COLUMN IF EXISTS email_attempts,
+    DROP COLUMN IF EXISTS emailed_at;
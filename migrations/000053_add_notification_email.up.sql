@@ -0,0 +1,10 @@
+ALTER TABLE notifications
+    ADD COLUMN emailed_at     TIMESTAMPTZ,
+    ADD COLUMN email_attempts INT  NOT NULL DEFAULT 0,
+    ADD COLUMN email_error    TEXT;
+
+-- Notifications from before email delivery are already in the feed; don't
+-- email them now.
+UPDATE notifications SET emailed_at = created_at;
+
+CREATE INDEX idx_notifications_unemailed ON notifications (created_at, id) WHERE emailed_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS trg_webhook_events_notify ON webhook_events;
+DROP FUNCTION IF EXISTS notify_webhook_events();
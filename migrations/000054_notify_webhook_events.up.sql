@@ -0,0 +1,15 @@
+-- Wakes the webhook processor as soon as a callback is stored. NOTIFY is
+-- delivered on commit and identical notifications in one transaction fold
+-- into one, so a statement-level trigger without a payload is enough; the
+-- processor reads the events from the table either way.
+CREATE FUNCTION notify_webhook_events() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM pg_notify('webhook_events', '');
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER trg_webhook_events_notify
+    AFTER INSERT ON webhook_events
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_webhook_events();
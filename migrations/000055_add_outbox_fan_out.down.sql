@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_events_fan_out;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS fanned_out_at;
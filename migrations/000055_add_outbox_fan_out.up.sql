@@ -0,0 +1,8 @@
+-- Work an event sets off after its transaction commits (in-app
+-- notifications) is done by the fan-out stage, which stamps the event here.
+-- Events from before the stage existed were handled inline.
+ALTER TABLE outbox_events ADD COLUMN fanned_out_at TIMESTAMPTZ;
+
+UPDATE outbox_events SET fanned_out_at = created_at;
+
+CREATE INDEX idx_outbox_events_fan_out ON outbox_events (created_at, id) WHERE fanned_out_at IS NULL;
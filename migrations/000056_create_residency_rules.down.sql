@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS residency_rules;
+
+ALTER TABLE users DROP COLUMN IF EXISTS country;
@@ -0,0 +1,23 @@
+-- Country of residence, ISO 3166-1 alpha-2. Set by staff once verified.
+ALTER TABLE users ADD COLUMN country CHAR(2)
+    CONSTRAINT chk_users_country CHECK (country ~ '^[A-Z]{2}$');
+
+-- Each rule forbids residents of country from sending payments that match
+-- it. A null column matches anything.
+CREATE TABLE residency_rules (
+    id               UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    country          CHAR(2)       NOT NULL,
+    source_currency  CHAR(3),
+    dest_currency    CHAR(3),
+    dest_country     CHAR(2),
+    reason           TEXT          NOT NULL DEFAULT '',
+    created_by       UUID          NOT NULL REFERENCES users(id),
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    CONSTRAINT chk_residency_rules_country CHECK (country ~ '^[A-Z]{2}$'),
+    CONSTRAINT chk_residency_rules_dest_country CHECK (dest_country ~ '^[A-Z]{2}$')
+);
+
+CREATE UNIQUE INDEX idx_residency_rules_scope ON residency_rules (
+    country, COALESCE(source_currency, ''), COALESCE(dest_currency, ''), COALESCE(dest_country, '')
+);
@@ -0,0 +1,8 @@
+UPDATE webhook_events SET status = 'pending' WHERE status = 'processing';
+
+DROP INDEX IF EXISTS idx_webhook_events_processing;
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS chk_webhook_events_claim;
+ALTER TABLE webhook_events DROP CONSTRAINT IF EXISTS chk_webhook_events_status;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS claimed_by;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS payment_id;
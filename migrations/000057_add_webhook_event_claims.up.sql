@@ -0,0 +1,20 @@
+-- Processors claim events instead of reading them: a claimed event is
+-- 'processing', names the processor holding it, and is leased until
+-- lease_expires_at. A processor that stops mid-batch leaves its events to be
+-- claimed again once the lease runs out. payment_id orders a payment's
+-- events: none is claimed while another for the same payment is leased.
+ALTER TABLE webhook_events ADD COLUMN payment_id UUID;
+ALTER TABLE webhook_events ADD COLUMN claimed_by TEXT;
+ALTER TABLE webhook_events ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+UPDATE webhook_events SET payment_id = (payload->>'payment_id')::uuid
+WHERE payload->>'payment_id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$';
+
+ALTER TABLE webhook_events ADD CONSTRAINT chk_webhook_events_status
+    CHECK (status IN ('pending', 'processing', 'dispatched', 'failed'));
+ALTER TABLE webhook_events ADD CONSTRAINT chk_webhook_events_claim
+    CHECK ((status = 'processing') = (claimed_by IS NOT NULL AND lease_expires_at IS NOT NULL)
+        AND (claimed_by IS NULL) = (lease_expires_at IS NULL));
+
+CREATE INDEX idx_webhook_events_processing ON webhook_events (payment_id, lease_expires_at)
+    WHERE status = 'processing';
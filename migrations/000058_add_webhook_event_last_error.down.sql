@@ -0,0 +1 @@
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS last_error;
@@ -0,0 +1,3 @@
+-- Why the last attempt at an event didn't succeed, for support staff
+-- inspecting failed events. Cleared when an attempt succeeds.
+ALTER TABLE webhook_events ADD COLUMN last_error TEXT;
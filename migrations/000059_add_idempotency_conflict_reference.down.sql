@@ -0,0 +1,5 @@
+ALTER TABLE idempotency_cache
+    DROP COLUMN IF EXISTS resource_location,
+    DROP COLUMN IF EXISTS request_fields,
+    DROP COLUMN IF EXISTS request_path,
+    DROP COLUMN IF EXISTS request_method;
@@ -0,0 +1,9 @@
+-- What a conflicting retry is told about the request that first used the
+-- key: where the resource it created lives, and enough about the request
+-- to say which fields changed. Fields are kept as hashes of their values,
+-- not the values. Rows written before this are left NULL.
+ALTER TABLE idempotency_cache
+    ADD COLUMN request_method    TEXT,
+    ADD COLUMN request_path      TEXT,
+    ADD COLUMN request_fields    JSONB,
+    ADD COLUMN resource_location TEXT;
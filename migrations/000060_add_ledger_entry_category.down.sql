@@ -0,0 +1,2 @@
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS chk_ledger_entries_category;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS entry_category;
@@ -0,0 +1,22 @@
+-- Which part of a payment an entry carries, so fee and reversal reporting
+-- doesn't have to join back to payments. Existing entries are classified
+-- the way the writers now classify new ones: reversal and refund payments
+-- and the compensating entries of failed payouts (written after the
+-- payment was created) are reversals, pool transfers are adjustments, and
+-- otherwise revenue accounts take fees and FX pools the spread legs.
+ALTER TABLE ledger_entries ADD COLUMN entry_category VARCHAR(20) NOT NULL DEFAULT 'principal';
+
+UPDATE ledger_entries l SET entry_category = CASE
+        WHEN p.type IN ('reversal', 'refund') THEN 'reversal'
+        WHEN p.status = 'failed' AND l.created_at > p.created_at THEN 'reversal'
+        WHEN p.type = 'pool_transfer' THEN 'adjustment'
+        WHEN a.account_type = 'revenue' THEN 'fee'
+        WHEN a.account_type = 'fx_pool' THEN 'fx_spread'
+        ELSE 'principal'
+    END
+FROM payments p, accounts a
+WHERE p.id = l.payment_id AND a.id = l.account_id;
+
+ALTER TABLE ledger_entries ALTER COLUMN entry_category DROP DEFAULT;
+ALTER TABLE ledger_entries ADD CONSTRAINT chk_ledger_entries_category
+    CHECK (entry_category IN ('principal', 'fee', 'fx_spread', 'reversal', 'adjustment'));
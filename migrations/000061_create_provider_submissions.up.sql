@@ -0,0 +1,19 @@
+-- One row per attempt to hand a payout to its provider. A failed attempt
+-- keeps how it failed, which decides whether the submission retrier sends
+-- it again or fails the payout.
+CREATE TABLE provider_submissions (
+    id           UUID        PRIMARY KEY,
+    payment_id   UUID        NOT NULL,
+    provider     VARCHAR(50) NOT NULL,
+    attempt      INT         NOT NULL,
+    error_class  VARCHAR(20),
+    status_code  INT,
+    error        TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_provider_submissions_error_class
+        CHECK (error_class IN ('network', 'retriable', 'permanent', 'validation')),
+    CONSTRAINT chk_provider_submissions_attempt CHECK (attempt >= 1)
+);
+
+CREATE UNIQUE INDEX idx_provider_submissions_attempt ON provider_submissions (payment_id, attempt);
+CREATE INDEX idx_provider_submissions_provider ON provider_submissions (provider, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_user_tier;
+ALTER TABLE payments DROP COLUMN IF EXISTS user_tier;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_tier;
+ALTER TABLE users DROP COLUMN IF EXISTS tier;
@@ -0,0 +1,9 @@
+-- The commercial tier a user is on, which sets their FX spread and scales
+-- their default limits, and the sender's tier on each user payment so its
+-- pricing can be audited after the user changes tier.
+ALTER TABLE users ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'standard';
+ALTER TABLE users ADD CONSTRAINT chk_users_tier CHECK (tier IN ('standard', 'plus', 'business'));
+
+ALTER TABLE payments ADD COLUMN user_tier VARCHAR(20);
+ALTER TABLE payments ADD CONSTRAINT chk_payments_user_tier
+    CHECK (user_tier IS NULL OR user_tier IN ('standard', 'plus', 'business'));
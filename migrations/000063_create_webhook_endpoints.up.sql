@@ -0,0 +1,47 @@
+-- Where users receive signed webhooks about their payments. The signing
+-- secret is encrypted, since users can read it back.
+CREATE TABLE webhook_endpoints (
+    user_id           UUID        PRIMARY KEY REFERENCES users(id),
+    url               TEXT        NOT NULL,
+    secret_encrypted  BYTEA       NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    secret_rotated_at TIMESTAMPTZ
+);
+
+-- One row per event to send to an endpoint, until it is delivered or
+-- given up on. Deleting the endpoint drops its queue and history.
+CREATE TABLE webhook_deliveries (
+    id              UUID        PRIMARY KEY,
+    user_id         UUID        NOT NULL REFERENCES webhook_endpoints(user_id) ON DELETE CASCADE,
+    event_id        UUID        NOT NULL,
+    event_type      VARCHAR(50) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ,
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries (user_id, event_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- Each POST to an endpoint, for users debugging their receiver. Test
+-- events have no delivery.
+CREATE TABLE webhook_delivery_attempts (
+    id          UUID        PRIMARY KEY,
+    delivery_id UUID        REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    user_id     UUID        NOT NULL REFERENCES webhook_endpoints(user_id) ON DELETE CASCADE,
+    event_id    UUID        NOT NULL,
+    event_type  VARCHAR(50) NOT NULL,
+    attempt     INT         NOT NULL,
+    url         TEXT        NOT NULL,
+    status_code INT,
+    latency_ms  INT         NOT NULL,
+    error       TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_user ON webhook_delivery_attempts (user_id, created_at DESC);
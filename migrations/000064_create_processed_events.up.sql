@@ -0,0 +1,10 @@
+-- Events a consumer has applied, written in the same transaction as the
+-- side effects, so an event handled twice (a retry after a crash, or two
+-- workers racing on it) is applied once. The second insert waits for the
+-- first transaction and then conflicts.
+CREATE TABLE processed_events (
+    consumer     VARCHAR(50) NOT NULL,
+    event_id     UUID        NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (consumer, event_id)
+);
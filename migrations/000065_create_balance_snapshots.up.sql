@@ -0,0 +1,10 @@
+-- Each account's ledger balance at the end of a UTC day, so a balance as of
+-- a past instant is worked back from the nearest later snapshot instead of
+-- from the current balance through every entry since.
+CREATE TABLE balance_snapshots (
+    account_id UUID        NOT NULL REFERENCES accounts(id),
+    as_of      TIMESTAMPTZ NOT NULL,
+    balance    BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (account_id, as_of)
+);
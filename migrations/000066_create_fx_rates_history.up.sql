@@ -0,0 +1,14 @@
+-- Every pair's rate at each rate refresh, so a payment can be checked against
+-- the rate in effect when it was made and rates can be charted over time.
+-- Rates have the precision payments store them with.
+CREATE TABLE fx_rates_history (
+    id              BIGSERIAL      PRIMARY KEY,
+    from_currency   CHAR(3)        NOT NULL,
+    to_currency     CHAR(3)        NOT NULL,
+    mid_market_rate DECIMAL(20,10) NOT NULL,
+    spread_pct      DECIMAL(10,6)  NOT NULL,
+    via             CHAR(3),
+    recorded_at     TIMESTAMPTZ    NOT NULL
+);
+
+CREATE INDEX idx_fx_rates_history_pair ON fx_rates_history (from_currency, to_currency, recorded_at);
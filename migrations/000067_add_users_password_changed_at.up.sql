@@ -0,0 +1,3 @@
+-- When the password was last changed. JWTs issued before it are refused,
+-- so a password reset ends every existing session. NULL means never.
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMPTZ;
@@ -0,0 +1,8 @@
+-- Only the standard spread was recorded before this migration; the other
+-- tiers' rows have nowhere to go.
+DELETE FROM fx_rates_history WHERE tier <> 'standard';
+
+DROP INDEX idx_fx_rates_history_pair;
+CREATE INDEX idx_fx_rates_history_pair ON fx_rates_history (from_currency, to_currency, recorded_at);
+
+ALTER TABLE fx_rates_history DROP COLUMN tier;
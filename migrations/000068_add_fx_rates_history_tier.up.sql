@@ -0,0 +1,7 @@
+-- Each user tier is quoted at its own spread, so a pair's rate is recorded
+-- once per tier. Rows recorded before this were at the standard spread.
+ALTER TABLE fx_rates_history ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'standard';
+ALTER TABLE fx_rates_history ALTER COLUMN tier DROP DEFAULT;
+
+DROP INDEX idx_fx_rates_history_pair;
+CREATE INDEX idx_fx_rates_history_pair ON fx_rates_history (from_currency, to_currency, tier, recorded_at);
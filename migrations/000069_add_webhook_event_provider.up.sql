@@ -0,0 +1,4 @@
+-- The provider whose signature a callback was verified against. A callback
+-- may only settle a payout routed to that provider. Events stored before
+-- this have none and are not checked.
+ALTER TABLE webhook_events ADD COLUMN provider TEXT;
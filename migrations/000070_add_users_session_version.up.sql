@@ -0,0 +1,8 @@
+-- Bumped on every password change. JWTs carry the version they were issued
+-- at, and one below the user's current version is refused, so a password
+-- reset ends every existing session. Users who have already reset their
+-- password start at 1, so tokens from before the version claim existed,
+-- which read as 0, stay ended for them.
+ALTER TABLE users ADD COLUMN session_version INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users SET session_version = 1 WHERE password_changed_at IS NOT NULL;
@@ -0,0 +1,42 @@
+package webhookverify
+
+import "errors"
+
+// Some providers check a callback URL before they send events to it. The
+// provider sends GET <callback>?challenge=<token> with the token signed in
+// SignatureHeader, and the receiver proves it holds the same secret by
+// answering with the token and ChallengeResponse(secret, token). The answer
+// signs a different message from the request, so a receiver can't pass by
+// echoing the request's signature back.
+
+// ChallengeParam is the query parameter carrying the challenge token.
+const ChallengeParam = "challenge"
+
+// MaxChallengeLen caps the challenge token receivers accept.
+const MaxChallengeLen = 256
+
+var ErrChallengeMismatch = errors.New("webhookverify: challenge reply is for a different challenge")
+
+// ChallengeReply is the receiver's answer to a handshake.
+type ChallengeReply struct {
+	Challenge string `json:"challenge"`
+	Signature string `json:"signature"`
+}
+
+// SignChallenge returns the signature a provider sends with challenge.
+func SignChallenge(secret, challenge string) string {
+	return Sign(secret, []byte(challenge))
+}
+
+// ChallengeResponse returns the signature a receiver answers challenge with.
+func ChallengeResponse(secret, challenge string) string {
+	return Sign(secret, []byte("handshake-response:"+challenge))
+}
+
+// VerifyChallengeReply checks a receiver's answer to challenge.
+func VerifyChallengeReply(secret, challenge string, reply ChallengeReply) error {
+	if reply.Challenge != challenge {
+		return ErrChallengeMismatch
+	}
+	return Verify(secret, []byte("handshake-response:"+challenge), reply.Signature)
+}
@@ -0,0 +1,232 @@
+// Package webhookverify signs and verifies payment status webhooks.
+//
+// A webhook body is a JSON object signed with HMAC-SHA256 over the raw
+// bytes, keyed by the shared secret. The lowercase hex digest is sent in
+// the X-Webhook-Signature header. The body's timestamp field (RFC 3339)
+// lets receivers reject old deliveries that are replayed.
+//
+// The package only depends on the standard library so it can be vendored
+// into client code. Our own webhook handler and mock provider use it too,
+// which keeps both sides of the scheme in one place.
+package webhookverify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const SignatureHeader = "X-Webhook-Signature"
+
+// MaxBodyBytes caps how much of a request body VerifyRequest reads.
+const MaxBodyBytes = 1 << 20
+
+var (
+	ErrMissingSignature = errors.New("webhookverify: missing signature")
+	ErrInvalidSignature = errors.New("webhookverify: invalid signature")
+	ErrMalformedPayload = errors.New("webhookverify: malformed payload")
+	ErrStaleEvent       = errors.New("webhookverify: timestamp outside tolerance")
+)
+
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Event is a parsed payment status webhook.
+type Event struct {
+	EventID     string    `json:"event_id"`
+	PaymentID   string    `json:"payment_id"`
+	Status      Status    `json:"status"`
+	ProviderRef string    `json:"provider_ref,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Code        string    `json:"code,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// FieldError describes one invalid field in a payload.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// PayloadError is returned by Parse when the body is valid JSON but one or
+// more fields are missing or invalid.
+type PayloadError struct {
+	Fields []FieldError
+}
+
+func (e *PayloadError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "webhookverify: invalid payload: " + strings.Join(parts, ", ")
+}
+
+// Sign returns the signature for body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature against body in constant time.
+func Verify(secret string, body []byte, signature string) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type rawEvent struct {
+	EventID     string `json:"event_id"`
+	PaymentID   string `json:"payment_id"`
+	Status      string `json:"status"`
+	ProviderRef string `json:"provider_ref"`
+	Reason      string `json:"reason"`
+	Code        string `json:"code"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// Parse decodes and validates a webhook body. It doesn't check the
+// signature. Malformed JSON wraps ErrMalformedPayload; missing or invalid
+// fields return a *PayloadError.
+func Parse(body []byte) (*Event, error) {
+	var raw rawEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+
+	var fields []FieldError
+
+	if raw.EventID == "" {
+		fields = append(fields, FieldError{Field: "event_id", Message: "required"})
+	} else if !isUUID(raw.EventID) {
+		fields = append(fields, FieldError{Field: "event_id", Message: "must be a valid UUID"})
+	}
+
+	if raw.PaymentID == "" {
+		fields = append(fields, FieldError{Field: "payment_id", Message: "required"})
+	} else if !isUUID(raw.PaymentID) {
+		fields = append(fields, FieldError{Field: "payment_id", Message: "must be a valid UUID"})
+	}
+
+	status := Status(raw.Status)
+	if raw.Status == "" {
+		fields = append(fields, FieldError{Field: "status", Message: "required"})
+	} else if status != StatusCompleted && status != StatusFailed {
+		fields = append(fields, FieldError{Field: "status", Message: "must be completed or failed"})
+	}
+
+	var ts time.Time
+	if raw.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339, raw.Timestamp)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "timestamp", Message: "must be an RFC 3339 time"})
+		}
+		ts = t
+	}
+
+	if len(fields) > 0 {
+		return nil, &PayloadError{Fields: fields}
+	}
+
+	return &Event{
+		EventID:     raw.EventID,
+		PaymentID:   raw.PaymentID,
+		Status:      status,
+		ProviderRef: raw.ProviderRef,
+		Reason:      raw.Reason,
+		Code:        raw.Code,
+		Timestamp:   ts,
+	}, nil
+}
+
+// CheckTimestamp rejects events whose timestamp is more than tolerance away
+// from now, in either direction. A zero tolerance disables the check; with
+// a tolerance set, events without a timestamp are rejected.
+func CheckTimestamp(e *Event, now time.Time, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		return nil
+	}
+	if e.Timestamp.IsZero() {
+		return fmt.Errorf("%w: no timestamp", ErrStaleEvent)
+	}
+	skew := now.Sub(e.Timestamp)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > tolerance {
+		return fmt.Errorf("%w: off by %s", ErrStaleEvent, skew.Round(time.Second))
+	}
+	return nil
+}
+
+// Verifier bundles the secret and tolerance a receiver checks webhooks with.
+type Verifier struct {
+	secret    string
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewVerifier(secret string, tolerance time.Duration) *Verifier {
+	return &Verifier{secret: secret, tolerance: tolerance, now: time.Now}
+}
+
+// VerifyBody checks the signature, parses the body and applies the
+// timestamp tolerance, in that order, so nothing unsigned is ever parsed.
+func (v *Verifier) VerifyBody(body []byte, signature string) (*Event, error) {
+	if err := Verify(v.secret, body, signature); err != nil {
+		return nil, err
+	}
+	e, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckTimestamp(e, v.now(), v.tolerance); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// VerifyRequest reads the request body and verifies it with the signature
+// from SignatureHeader. The body is consumed.
+func (v *Verifier) VerifyRequest(r *http.Request) (*Event, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("webhookverify: read body: %w", err)
+	}
+	return v.VerifyBody(body, r.Header.Get(SignatureHeader))
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package webhookverify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret  = "test-secret-key"
+	testEventID = "3f1c2b9e-8d7a-4c6b-9e5f-1a2b3c4d5e6f"
+	testPayment = "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9c0d"
+)
+
+func testBody(status, timestamp string) []byte {
+	return []byte(`{"event_id":"` + testEventID + `","payment_id":"` + testPayment +
+		`","status":"` + status + `","provider_ref":"ref_1","timestamp":"` + timestamp + `"}`)
+}
+
+func TestSignMatchesKnownVector(t *testing.T) {
+	// Pinned so a change to the scheme breaks this test before it breaks
+	// every receiver in the wild.
+	assert.Equal(t,
+		"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		Sign("key", []byte("The quick brown fox jumps over the lazy dog")),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	body := testBody("completed", "2026-02-20T00:00:00Z")
+
+	assert.NoError(t, Verify(testSecret, body, Sign(testSecret, body)))
+	assert.ErrorIs(t, Verify(testSecret, body, ""), ErrMissingSignature)
+	assert.ErrorIs(t, Verify(testSecret, body, Sign("other-secret", body)), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, append(body, ' '), Sign(testSecret, body)), ErrInvalidSignature)
+}
+
+func TestParse(t *testing.T) {
+	e, err := Parse(testBody("completed", "2026-02-20T00:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, testEventID, e.EventID)
+	assert.Equal(t, testPayment, e.PaymentID)
+	assert.Equal(t, StatusCompleted, e.Status)
+	assert.Equal(t, "ref_1", e.ProviderRef)
+	assert.Equal(t, time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC), e.Timestamp.UTC())
+
+	_, err = Parse([]byte("not-json"))
+	assert.ErrorIs(t, err, ErrMalformedPayload)
+
+	_, err = Parse([]byte(`{"event_id":"nope","status":"pending","timestamp":"yesterday"}`))
+	var perr *PayloadError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, []FieldError{
+		{Field: "event_id", Message: "must be a valid UUID"},
+		{Field: "payment_id", Message: "required"},
+		{Field: "status", Message: "must be completed or failed"},
+		{Field: "timestamp", Message: "must be an RFC 3339 time"},
+	}, perr.Fields)
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *Event { return &Event{Timestamp: now.Add(d)} }
+
+	assert.NoError(t, CheckTimestamp(at(-4*time.Minute), now, 5*time.Minute))
+	assert.NoError(t, CheckTimestamp(at(4*time.Minute), now, 5*time.Minute))
+	assert.ErrorIs(t, CheckTimestamp(at(-6*time.Minute), now, 5*time.Minute), ErrStaleEvent)
+	assert.ErrorIs(t, CheckTimestamp(at(6*time.Minute), now, 5*time.Minute), ErrStaleEvent)
+	assert.ErrorIs(t, CheckTimestamp(&Event{}, now, 5*time.Minute), ErrStaleEvent)
+	assert.NoError(t, CheckTimestamp(at(-24*time.Hour), now, 0))
+}
+
+func TestVerifierVerifyRequest(t *testing.T) {
+	v := NewVerifier(testSecret, 5*time.Minute)
+	v.now = func() time.Time { return time.Date(2026, 2, 20, 0, 1, 0, 0, time.UTC) }
+
+	request := func(body []byte, sig string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(string(body)))
+		r.Header.Set(SignatureHeader, sig)
+		return r
+	}
+
+	body := testBody("failed", "2026-02-20T00:00:00Z")
+	e, err := v.VerifyRequest(request(body, Sign(testSecret, body)))
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, e.Status)
+
+	_, err = v.VerifyRequest(request(body, Sign("wrong", body)))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	stale := testBody("failed", "2026-02-19T00:00:00Z")
+	_, err = v.VerifyRequest(request(stale, Sign(testSecret, stale)))
+	assert.ErrorIs(t, err, ErrStaleEvent)
+
+	// An unsigned body is rejected before it's parsed.
+	_, err = v.VerifyRequest(request([]byte("not-json"), ""))
+	assert.ErrorIs(t, err, ErrMissingSignature)
+}
+
+func TestChallengeReply(t *testing.T) {
+	const challenge = "c2f0a9e1"
+
+	reply := ChallengeReply{Challenge: challenge, Signature: ChallengeResponse(testSecret, challenge)}
+	assert.NoError(t, VerifyChallengeReply(testSecret, challenge, reply))
+
+	echoed := ChallengeReply{Challenge: challenge, Signature: SignChallenge(testSecret, challenge)}
+	assert.ErrorIs(t, VerifyChallengeReply(testSecret, challenge, echoed), ErrInvalidSignature)
+
+	assert.ErrorIs(t, VerifyChallengeReply(testSecret, "other", reply), ErrChallengeMismatch)
+	assert.ErrorIs(t, VerifyChallengeReply("other-secret", challenge, reply), ErrInvalidSignature)
+}
@@ -0,0 +1,15 @@
+-- Development-only staff user for the admin API. Not part of the migration
+-- chain: docker compose applies it after the migrations, and it must never
+-- be run against a shared or production database.
+-- Password: password123
+INSERT INTO users (id, email, name, password_hash, unique_name, status, role)
+VALUES (
+    '00000000-0000-0000-0000-000000000005',
+    'admin@test.com',
+    'Admin',
+    '$2a$10$B/szfaq.8qQo.9a/Efj4Seyc/kIdzlF4IY4TwnmAiOlQYu927DFbq',
+    'admin',
+    'active',
+    'admin'
+)
+ON CONFLICT (id) DO NOTHING;
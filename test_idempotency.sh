@@ -45,7 +45,7 @@ H1=$(curl -s -o /tmp/h1.json -D /tmp/h1_headers.txt -w '%{http_code}' -X POST "$
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H1" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":1000}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"10.00"}')
 if [ "$H1" = "201" ]; then pass "H1 — 201 Created"; else fail "H1" "expected 201, got $H1"; fi
 H1_PAYMENT_ID=$(python3 -c "import sys,json; print(json.load(open('/tmp/h1.json'))['data']['id'])")
 
@@ -55,7 +55,7 @@ H2=$(curl -s -o /tmp/h2.json -D /tmp/h2_headers.txt -w '%{http_code}' -X POST "$
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H1" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":1000}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"10.00"}')
 H2_REPLAYED=$(check_header 'x-idempotent-replayed' /tmp/h2_headers.txt)
 H2_PAYMENT_ID=$(python3 -c "import sys,json; print(json.load(open('/tmp/h2.json'))['data']['id'])")
 if [ "$H2" = "201" ]; then pass "H2 — 201 replayed"; else fail "H2" "expected 201, got $H2"; fi
@@ -69,7 +69,7 @@ H3=$(curl -s -o /tmp/h3.json -D /tmp/h3_headers.txt -w '%{http_code}' -X POST "$
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H3" \
-  -d '{"source_currency":"USD","dest_currency":"USD","amount":500,"dest_iban":"DE89370400440532013000","dest_bank_name":"Deutsche Bank"}')
+  -d '{"source_currency":"USD","dest_currency":"USD","amount":"5.00","dest_iban":"DE89370400440532013000","dest_bank_name":"Deutsche Bank"}')
 if [ "$H3" = "202" ]; then pass "H3 — 202 Accepted"; else fail "H3" "expected 202, got $H3"; fi
 
 # H4: Replay external payout same key + same body
@@ -78,7 +78,7 @@ H4=$(curl -s -o /tmp/h4.json -D /tmp/h4_headers.txt -w '%{http_code}' -X POST "$
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H3" \
-  -d '{"source_currency":"USD","dest_currency":"USD","amount":500,"dest_iban":"DE89370400440532013000","dest_bank_name":"Deutsche Bank"}')
+  -d '{"source_currency":"USD","dest_currency":"USD","amount":"5.00","dest_iban":"DE89370400440532013000","dest_bank_name":"Deutsche Bank"}')
 H4_REPLAYED=$(check_header 'x-idempotent-replayed' /tmp/h4_headers.txt)
 if [ "$H4" = "202" ]; then pass "H4 — 202 replayed"; else fail "H4" "expected 202, got $H4"; fi
 if [ "$H4_REPLAYED" -ge 1 ]; then pass "H4 — X-Idempotent-Replayed header present"; else fail "H4" "missing X-Idempotent-Replayed header"; fi
@@ -96,12 +96,12 @@ H6_BOB=$(curl -s -o /tmp/h6_bob.json -w '%{http_code}' -X POST "$BASE/api/v1/pay
   -H "Authorization: Bearer $BOB_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H6" \
-  -d '{"recipient_unique_name":"alice","source_currency":"USD","dest_currency":"USD","amount":100}')
+  -d '{"recipient_unique_name":"alice","source_currency":"USD","dest_currency":"USD","amount":"1.00"}')
 H6_ALICE=$(curl -s -o /tmp/h6_alice.json -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H6" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":100}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"1.00"}')
 if [ "$H6_BOB" = "201" ]; then pass "H6 — Bob 201 Created"; else fail "H6" "Bob expected 201, got $H6_BOB"; fi
 if [ "$H6_ALICE" = "201" ]; then pass "H6 — Alice 201 Created (same key, different user)"; else fail "H6" "Alice expected 201, got $H6_ALICE"; fi
 
@@ -113,12 +113,12 @@ H7A=$(curl -s -o /dev/null -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H7A" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":200}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"2.00"}')
 H7B=$(curl -s -o /dev/null -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H7B" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":300}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"3.00"}')
 if [ "$H7A" = "201" ]; then pass "H7 — first request 201"; else fail "H7" "first expected 201, got $H7A"; fi
 if [ "$H7B" = "201" ]; then pass "H7 — second request 201"; else fail "H7" "second expected 201, got $H7B"; fi
 
@@ -131,7 +131,7 @@ echo "S1: Missing Idempotency-Key (internal)"
 S1=$(curl -s -o /tmp/s1.json -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":100}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"1.00"}')
 S1_CODE=$(python3 -c "import json; print(json.load(open('/tmp/s1.json'))['error']['code'])")
 if [ "$S1" = "400" ]; then pass "S1 — 400 returned"; else fail "S1" "expected 400, got $S1"; fi
 if [ "$S1_CODE" = "MISSING_IDEMPOTENCY_KEY" ]; then pass "S1 — correct error code"; else fail "S1" "expected MISSING_IDEMPOTENCY_KEY, got $S1_CODE"; fi
@@ -141,7 +141,7 @@ echo "S2: Missing Idempotency-Key (external)"
 S2=$(curl -s -o /tmp/s2.json -w '%{http_code}' -X POST "$BASE/api/v1/payments/external" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
-  -d '{"source_currency":"USD","dest_currency":"USD","amount":100,"dest_iban":"DE123","dest_bank_name":"Test"}')
+  -d '{"source_currency":"USD","dest_currency":"USD","amount":"1.00","dest_iban":"DE123","dest_bank_name":"Test"}')
 S2_CODE=$(python3 -c "import json; print(json.load(open('/tmp/s2.json'))['error']['code'])")
 if [ "$S2" = "400" ]; then pass "S2 — 400 returned"; else fail "S2" "expected 400, got $S2"; fi
 if [ "$S2_CODE" = "MISSING_IDEMPOTENCY_KEY" ]; then pass "S2 — correct error code"; else fail "S2" "expected MISSING_IDEMPOTENCY_KEY, got $S2_CODE"; fi
@@ -152,7 +152,7 @@ S3=$(curl -s -o /tmp/s3.json -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H1" \
-  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":9999}')
+  -d '{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"99.99"}')
 S3_CODE=$(python3 -c "import json; print(json.load(open('/tmp/s3.json'))['error']['code'])")
 if [ "$S3" = "409" ]; then pass "S3 — 409 Conflict"; else fail "S3" "expected 409, got $S3"; fi
 if [ "$S3_CODE" = "IDEMPOTENCY_CONFLICT" ]; then pass "S3 — correct error code"; else fail "S3" "expected IDEMPOTENCY_CONFLICT, got $S3_CODE"; fi
@@ -163,7 +163,7 @@ S4=$(curl -s -o /tmp/s4.json -w '%{http_code}' -X POST "$BASE/api/v1/payments" \
   -H "Authorization: Bearer $ALICE_TOKEN" \
   -H "Content-Type: application/json" \
   -H "Idempotency-Key: $KEY_H1" \
-  -d '{"recipient_unique_name":"charlie","source_currency":"USD","dest_currency":"USD","amount":1000}')
+  -d '{"recipient_unique_name":"charlie","source_currency":"USD","dest_currency":"USD","amount":"10.00"}')
 S4_CODE=$(python3 -c "import json; print(json.load(open('/tmp/s4.json'))['error']['code'])")
 if [ "$S4" = "409" ]; then pass "S4 — 409 Conflict"; else fail "S4" "expected 409, got $S4"; fi
 if [ "$S4_CODE" = "IDEMPOTENCY_CONFLICT" ]; then pass "S4 — correct error code"; else fail "S4" "expected IDEMPOTENCY_CONFLICT, got $S4_CODE"; fi
@@ -193,7 +193,7 @@ echo "=== RACE CONDITIONS ==="
 # R1: 10 concurrent requests, same key + same body
 echo "R1: 10 concurrent requests, same key"
 KEY_R1=$(python3 -c "import uuid; print(uuid.uuid4())")
-R1_BODY='{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":100}'
+R1_BODY='{"recipient_unique_name":"bob","source_currency":"USD","dest_currency":"USD","amount":"1.00"}'
 for i in $(seq 1 10); do
   curl -s -o "/tmp/r1_$i.json" -D "/tmp/r1_${i}_headers.txt" -w '%{http_code}\n' -X POST "$BASE/api/v1/payments" \
     -H "Authorization: Bearer $ALICE_TOKEN" \